@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+
+	"github.com/spf13/cobra"
+)
+
+// benchOptions 存放 bench 命令的可配置参数，分析相关参数与 scan 命令一致。
+type benchOptions struct {
+	format         string
+	workers        int
+	iterations     int
+	warmup         int
+	adaptive       bool
+	cache          bool
+	contentCache   string
+	mmap           bool
+	mmapThreshold  int64
+	summaryOnly    bool
+	countFunctions bool
+	stringLines    bool
+	whitespace     bool
+	excludes       []string
+	languages      []string
+	disabled       []string
+}
+
+// newBenchCmd 创建 bench 子命令。
+// 命令对同一组路径重复执行完整扫描并报告吞吐与分配统计，便于在自己的机器上比较不同配置，例如：
+//
+//	gocloc bench . --iterations 10 --workers 8
+//	gocloc bench . --adaptive-workers --workers 64
+func newBenchCmd() *cobra.Command {
+	options := benchOptions{
+		format:     "table",
+		workers:    runtime.NumCPU(),
+		iterations: 5,
+		warmup:     1,
+	}
+
+	benchCmd := &cobra.Command{
+		Use:   "bench [path...]",
+		Short: "重复扫描并报告吞吐（files/s、MB/s）、分配统计与按语言的分析吞吐",
+		Long: "重复扫描并报告吞吐（files/s、MB/s）、分配统计与按语言的分析吞吐。\n" +
+			"先执行 --warmup 次不计入结果的扫描（预热文件系统缓存），再执行 --iterations 次测量，整体吞吐取耗时中位数的那次扫描；\n" +
+			"每次扫描前执行一次 GC，分配统计为扫描期间的堆分配次数与字节数。\n" +
+			"--cache 在各次扫描之间共享进程内缓存，--content-cache 使用按内容缓存，用于评估缓存命中时的吞吐。",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loaded, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			configInt(cmd, "workers", &options.workers, loaded.Workers)
+			configTableFormat(cmd, &options.format, loaded.Format)
+			configStrings(cmd, "include-language", &options.languages, loaded.Languages)
+			configStrings(cmd, "disable-language", &options.disabled, loaded.DisabledLanguages)
+
+			format := strings.ToLower(strings.TrimSpace(options.format))
+			if format != "table" && format != "json" {
+				return errors.New("unsupported format, allowed values: table, json")
+			}
+			if options.workers <= 0 {
+				return errors.New("workers must be greater than 0")
+			}
+			if options.iterations <= 0 {
+				return errors.New("iterations must be greater than 0")
+			}
+			if options.warmup < 0 {
+				return errors.New("warmup must not be negative")
+			}
+			if options.mmapThreshold <= 0 {
+				return errors.New("mmap-threshold must be greater than 0")
+			}
+			for _, arg := range args {
+				if arg == "-" {
+					return errors.New("bench cannot read stdin (-)")
+				}
+			}
+
+			logger, err := commandLogger(cmd)
+			if err != nil {
+				return err
+			}
+			scanOptions := gocloc.Options{
+				Workers:            options.workers,
+				CountFunctions:     options.countFunctions,
+				StringLiteralLines: options.stringLines,
+				WhitespaceStats:    options.whitespace,
+				Logger:             logger,
+				Excludes:           append(append([]string(nil), loaded.Exclude...), options.excludes...),
+				Languages:          options.languages,
+				DisabledLanguages:  options.disabled,
+				AdaptiveWorkers:    options.adaptive,
+				SummaryOnly:        options.summaryOnly,
+			}
+			if options.mmap {
+				scanOptions.MmapThreshold = options.mmapThreshold << 20
+			}
+			if options.cache {
+				scanOptions.Cache = gocloc.NewMemoryCache()
+			}
+			if options.contentCache != "" {
+				store, err := gocloc.OpenContentStore(options.contentCache)
+				if err != nil {
+					return err
+				}
+				scanOptions.ContentCache = gocloc.NewContentCache(store)
+			}
+
+			runs := make([]model.BenchRun, 0, options.iterations)
+			languages := make(map[string]*model.BenchLanguage)
+			for iteration := 0; iteration < options.warmup+options.iterations; iteration++ {
+				run, recorder, err := benchRun(scanOptions, args)
+				if err != nil {
+					return err
+				}
+				measured := iteration >= options.warmup
+				logger.Info("bench run finished", "iteration", iteration+1, "warmup", !measured, "duration", run.Duration, "files", run.Files)
+				if !measured {
+					continue
+				}
+				runs = append(runs, run)
+				for name, item := range recorder.languages {
+					total, ok := languages[name]
+					if !ok {
+						total = &model.BenchLanguage{Language: name}
+						languages[name] = total
+					}
+					total.Files += item.Files
+					total.Bytes += item.Bytes
+					total.Duration += item.Duration
+				}
+			}
+
+			items := make([]model.BenchLanguage, 0, len(languages))
+			for _, item := range languages {
+				items = append(items, *item)
+			}
+			result := model.NewBenchResult(args, options.workers, options.warmup, runs, items)
+			if format == "json" {
+				return report.PrintBenchJSON(cmd.OutOrStdout(), result)
+			}
+			return report.PrintBenchTable(cmd.OutOrStdout(), result)
+		},
+	}
+
+	benchCmd.Flags().StringVar(&options.format, "format", options.format, "输出格式: table 或 json")
+	benchCmd.Flags().IntVar(&options.iterations, "iterations", options.iterations, "计入结果的扫描次数")
+	benchCmd.Flags().IntVar(&options.warmup, "warmup", options.warmup, "正式测量前不计入结果的预热扫描次数")
+	benchCmd.Flags().IntVar(&options.workers, "workers", options.workers, "并发 worker 数量")
+	benchCmd.Flags().BoolVar(&options.adaptive, "adaptive-workers", false, "按 CPU 利用率（I/O 等待程度）在扫描中自动调整并发，--workers 作为上限")
+	benchCmd.Flags().BoolVar(&options.cache, "cache", false, "在各次扫描之间共享进程内单文件结果缓存（按路径、大小与修改时间命中）")
+	benchCmd.Flags().StringVar(&options.contentCache, "content-cache", "", "按文件内容哈希缓存分析结果的位置：本地目录、http(s):// 地址或 s3://bucket/prefix")
+	benchCmd.Flags().BoolVar(&options.mmap, "mmap", false, "通过内存映射读取超过 --mmap-threshold 的大文件")
+	benchCmd.Flags().Int64Var(&options.mmapThreshold, "mmap-threshold", 64, "--mmap 生效的文件大小下限，单位 MiB")
+	benchCmd.Flags().BoolVar(&options.summaryOnly, "summary-only", false, "只汇总不保留文件明细")
+	benchCmd.Flags().BoolVar(&options.countFunctions, "count-functions", false, "统计函数/方法定义数量")
+	benchCmd.Flags().BoolVar(&options.stringLines, "string-lines", false, "把只包含字符串字面量内容的行单独计入 string_literal")
+	benchCmd.Flags().BoolVar(&options.whitespace, "whitespace", false, "统计缩进风格、最大缩进宽度与行尾空白行数")
+	benchCmd.Flags().StringArrayVar(&options.excludes, "exclude", nil, "排除匹配的路径（相对扫描路径，支持 **），与配置文件中的 exclude 合并，可重复指定")
+	benchCmd.Flags().StringArrayVar(&options.languages, "include-language", nil, "只统计指定语言（不区分大小写），可重复指定")
+	benchCmd.Flags().StringArrayVar(&options.disabled, "disable-language", nil, "不统计指定语言（不区分大小写），可重复指定")
+
+	return benchCmd
+}
+
+// benchRun 执行一次完整扫描并测量耗时与分配；扫描器在计时之外创建，每次扫描前先执行 GC，减少上一次扫描的垃圾对本次的干扰。
+func benchRun(options gocloc.Options, paths []string) (model.BenchRun, *benchRecorder, error) {
+	recorder := &benchRecorder{languages: make(map[string]*model.BenchLanguage)}
+	options.Metrics = recorder
+	codeScanner := gocloc.NewScanner(options)
+	if err := codeScanner.Err(); err != nil {
+		return model.BenchRun{}, nil, err
+	}
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	startedAt := time.Now()
+	if _, err := codeScanner.ScanPaths(paths...); err != nil {
+		return model.BenchRun{}, nil, err
+	}
+	duration := time.Since(startedAt)
+	runtime.ReadMemStats(&after)
+
+	run := model.BenchRun{
+		Duration:   duration,
+		Allocs:     after.Mallocs - before.Mallocs,
+		AllocBytes: after.TotalAlloc - before.TotalAlloc,
+		GCCycles:   after.NumGC - before.NumGC,
+	}
+	for _, item := range recorder.languages {
+		run.Files += item.Files
+		run.Bytes += item.Bytes
+	}
+	return run, recorder, nil
+}
+
+// benchRecorder 通过 gocloc.LanguageObserver 按语言累计文件数、字节数与分析耗时。
+type benchRecorder struct {
+	mu        sync.Mutex
+	languages map[string]*model.BenchLanguage
+}
+
+func (r *benchRecorder) IncFiles(string)                   {}
+func (r *benchRecorder) AddBytes(int64)                    {}
+func (r *benchRecorder) ObserveFileDuration(time.Duration) {}
+
+// ObserveLanguage 实现 gocloc.LanguageObserver。
+func (r *benchRecorder) ObserveLanguage(language string, bytes int64, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	item, ok := r.languages[language]
+	if !ok {
+		item = &model.BenchLanguage{Language: language}
+		r.languages[language] = item
+	}
+	item.Files++
+	item.Bytes += bytes
+	item.Duration += duration
+}
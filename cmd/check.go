@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/check"
+	"github.com/zhizhixiongxuwei/gocloc/internal/config"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+
+	"github.com/spf13/cobra"
+)
+
+// checkOptions 存放 check 命令的可配置参数。
+type checkOptions struct {
+	format    string
+	workers   int
+	excludes  []string
+	languages []string
+	disabled  []string
+	budgets   check.Budgets
+	policy    string
+	baseline  string
+	// maxGrowth 为相对基线的增长上限（如 2% 或 500），为空时不做棘轮检查。
+	maxGrowth      string
+	updateBaseline bool
+}
+
+// newCheckCmd 创建 check 子命令。
+// 命令按预算检查扫描结果，存在违规时以非 0 状态退出，例如：gocloc check . --max-file-lines 1000
+func newCheckCmd() *cobra.Command {
+	options := checkOptions{
+		format:  "table",
+		workers: runtime.NumCPU(),
+	}
+
+	checkCmd := &cobra.Command{
+		Use:   "check [path...]",
+		Short: "按预算检查代码度量，超出预算时以非 0 状态退出",
+		Long: "按预算检查代码度量，超出预算时以非 0 状态退出。\n" +
+			"预算可以来自命令行参数或配置文件的 check 节，命令行参数优先；\n" +
+			"策略文件 " + check.PolicyFileName + " 可以按路径与语言声明带 ID 的规则，与预算同时生效。",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loaded, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			configInt(cmd, "workers", &options.workers, loaded.Workers)
+			configStrings(cmd, "include-language", &options.languages, loaded.Languages)
+			configStrings(cmd, "disable-language", &options.disabled, loaded.DisabledLanguages)
+			if !cmd.Flags().Changed("max-file-lines") {
+				options.budgets.MaxFileLines = loaded.Check.MaxFileLines
+			}
+			if !cmd.Flags().Changed("max-total-code") {
+				options.budgets.MaxTotalCode = loaded.Check.MaxTotalCode
+			}
+			if !cmd.Flags().Changed("min-comment-density") {
+				options.budgets.MinCommentDensity = loaded.Check.MinCommentDensity
+			}
+
+			format := strings.ToLower(strings.TrimSpace(options.format))
+			if format != "table" && format != "json" {
+				return errors.New("unsupported format, allowed values: table, json")
+			}
+			if options.workers <= 0 {
+				return errors.New("workers must be greater than 0")
+			}
+			policy, hasPolicy, err := loadPolicy(cmd, options.policy, args)
+			if err != nil {
+				return err
+			}
+			var growth *check.Growth
+			if options.maxGrowth != "" {
+				parsed, err := check.ParseGrowth(options.maxGrowth)
+				if err != nil {
+					return err
+				}
+				growth = &parsed
+			}
+			if options.budgets.Empty() && !hasPolicy && growth == nil && !options.updateBaseline {
+				return fmt.Errorf("no check budgets configured, set them in the config file, in %s or via --max-file-lines, --max-total-code, --min-comment-density, --max-growth", check.PolicyFileName)
+			}
+			if (growth != nil || options.updateBaseline) && options.baseline == "" {
+				return errors.New("--max-growth and --update-baseline require --baseline")
+			}
+			var baseline *model.ScanResult
+			if options.baseline != "" {
+				loaded, err := report.Load(options.baseline)
+				switch {
+				case err == nil:
+					baseline = &loaded
+				case options.updateBaseline && errors.Is(err, fs.ErrNotExist):
+					// 首次建立基线，文件不存在时直接写入。
+				default:
+					return err
+				}
+			} else if id, ok := policy.NeedsBaseline(); ok {
+				return fmt.Errorf("policy rule %q sets max_total_growth, a --baseline result is required", id)
+			}
+
+			logger, err := commandLogger(cmd)
+			if err != nil {
+				return err
+			}
+			result, err := gocloc.NewScanner(gocloc.Options{
+				Workers:           options.workers,
+				Excludes:          append(append([]string(nil), loaded.Exclude...), options.excludes...),
+				Languages:         options.languages,
+				DisabledLanguages: options.disabled,
+				Logger:            logger,
+			}).ScanPaths(args...)
+			if err != nil {
+				return err
+			}
+
+			violations := check.Evaluate(result, options.budgets)
+			if hasPolicy {
+				violations = append(violations, check.EvaluatePolicy(result, baseline, policy)...)
+			}
+			// 更新基线表示接受当前的代码量，因此不做棘轮检查。
+			if growth != nil && baseline != nil && !options.updateBaseline {
+				violations = append(violations, check.EvaluateRatchet(result, *baseline, *growth)...)
+			}
+			if err := writeViolations(cmd, format, violations); err != nil {
+				return err
+			}
+			if options.updateBaseline {
+				if err := report.Save(options.baseline, result); err != nil {
+					return err
+				}
+				logger.Info("baseline updated", "path", options.baseline, "code", result.Total.Code)
+			}
+			if len(violations) > 0 {
+				return fmt.Errorf("%d budget violation(s)", len(violations))
+			}
+			return nil
+		},
+	}
+
+	checkCmd.Flags().StringVar(&options.format, "format", options.format, "违规输出格式: table 或 json")
+	checkCmd.Flags().IntVar(&options.workers, "workers", options.workers, "并发 worker 数量")
+	checkCmd.Flags().StringArrayVar(&options.excludes, "exclude", nil, "排除匹配的路径（相对扫描路径，支持 **），与配置文件中的 exclude 合并，可重复指定")
+	checkCmd.Flags().StringArrayVar(&options.languages, "include-language", nil, "只检查指定语言（不区分大小写），可重复指定")
+	checkCmd.Flags().StringArrayVar(&options.disabled, "disable-language", nil, "不检查指定语言（不区分大小写），可重复指定")
+	checkCmd.Flags().Int64Var(&options.budgets.MaxFileLines, "max-file-lines", 0, "单文件总行数上限，0 表示不检查")
+	checkCmd.Flags().Int64Var(&options.budgets.MaxTotalCode, "max-total-code", 0, "项目代码行总数上限，0 表示不检查")
+	checkCmd.Flags().Float64Var(&options.budgets.MinCommentDensity, "min-comment-density", 0, "项目注释密度（comment/code）下限，0 表示不检查")
+	checkCmd.Flags().StringVar(&options.policy, "policy", "", "策略文件路径，未指定时从第一个检查路径向上查找 "+check.PolicyFileName)
+	checkCmd.Flags().StringVar(&options.baseline, "baseline", "", "基线扫描结果（scan 导出的 JSON），--max-growth 与策略中的 max_total_growth 规则相对它计算增长")
+	checkCmd.Flags().StringVar(&options.maxGrowth, "max-growth", "", "代码行总数与各语言代码行相对 --baseline 的增长上限，如 2%（基线的百分比）或 500（行数）")
+	checkCmd.Flags().BoolVar(&options.updateBaseline, "update-baseline", false, "把本次扫描结果写入 --baseline（文件不存在时创建），接受当前代码量而不做 --max-growth 检查")
+
+	return checkCmd
+}
+
+// loadPolicy 加载 --policy 指定的策略文件，未指定时从第一个检查路径向上自动发现，找不到时返回 false。
+func loadPolicy(cmd *cobra.Command, explicit string, paths []string) (check.Policy, bool, error) {
+	policyPath := strings.TrimSpace(explicit)
+	if policyPath == "" {
+		start := "."
+		if len(paths) > 0 {
+			start = paths[0]
+		}
+		discovered, ok := config.DiscoverFile(start, check.PolicyFileName)
+		if !ok {
+			return check.Policy{}, false, nil
+		}
+		policyPath = discovered
+	}
+
+	policy, err := check.LoadPolicy(policyPath)
+	if err != nil {
+		return check.Policy{}, false, err
+	}
+	logger, err := commandLogger(cmd)
+	if err != nil {
+		return check.Policy{}, false, err
+	}
+	logger.Info("policy loaded", "path", policy.Path, "rules", len(policy.Rules))
+	return policy, true, nil
+}
+
+// writeViolations 按格式输出违规记录，table 格式在没有违规时输出一行 OK。
+func writeViolations(cmd *cobra.Command, format string, violations []check.Violation) error {
+	if format == "json" {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Violations []check.Violation `json:"violations"`
+		}{Violations: violations})
+	}
+
+	if len(violations) == 0 {
+		_, err := fmt.Fprintln(cmd.OutOrStdout(), "OK: all budgets satisfied")
+		return err
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(writer, "ID\tRULE\tSCOPE\tACTUAL\tLIMIT"); err != nil {
+		return err
+	}
+	for _, item := range violations {
+		id := item.ID
+		if id == "" {
+			id = "-"
+		}
+		scope := item.Path
+		if scope == "" {
+			scope = item.Language
+		}
+		if scope == "" {
+			scope = "-"
+		}
+		if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", id, item.Rule, scope, item.Actual, item.Limit); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
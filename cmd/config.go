@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// loadConfig 加载 --config（或 GOCLOC_CONFIG）指定的配置文件，未指定时从第一个扫描路径向上自动发现 .gocloc.yaml，
+// 再叠加 GOCLOC_* 环境变量。
+func loadConfig(cmd *cobra.Command, paths []string) (config.Config, error) {
+	explicit, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return config.Config{}, err
+	}
+	if !cmd.Flags().Changed("config") {
+		explicit = os.Getenv(config.EnvConfigPath)
+	}
+
+	start := "."
+	if len(paths) > 0 && paths[0] != "-" {
+		start = paths[0]
+	}
+	loaded, err := config.Resolve(explicit, start)
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	if loaded.Path != "" {
+		logger, err := commandLogger(cmd)
+		if err != nil {
+			return config.Config{}, err
+		}
+		logger.Info("config loaded", "path", loaded.Path)
+	}
+	return loaded.ApplyEnv(os.LookupEnv)
+}
+
+// 以下辅助函数按“命令行参数 > 环境变量 > 配置文件 > 默认值”的优先级合并单个设置：
+// 命令行显式设置过的参数保持不变，否则使用 loadConfig 合并了环境变量后的非零值。
+
+// configInt 合并整数设置。
+func configInt[T int | int64](cmd *cobra.Command, flag string, target *T, value T) {
+	if !cmd.Flags().Changed(flag) && value > 0 {
+		*target = value
+	}
+}
+
+// configString 合并字符串设置。
+func configString(cmd *cobra.Command, flag string, target *string, value string) {
+	if !cmd.Flags().Changed(flag) && value != "" {
+		*target = value
+	}
+}
+
+// configTableFormat 为只支持 table 与 json 的命令合并 format 设置。配置中的 format 可以是 scan 的任一输出格式，
+// 其它取值只对 scan 有意义，这些命令忽略它们并保留默认格式。
+func configTableFormat(cmd *cobra.Command, target *string, value string) {
+	if format := strings.ToLower(strings.TrimSpace(value)); format == "table" || format == "json" {
+		configString(cmd, "format", target, value)
+	}
+}
+
+// configStrings 合并列表设置。
+func configStrings(cmd *cobra.Command, flag string, target *[]string, value []string) {
+	if !cmd.Flags().Changed(flag) && len(value) > 0 {
+		*target = value
+	}
+}
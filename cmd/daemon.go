@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+// daemonOptions 存放 daemon 命令的可配置参数。
+type daemonOptions struct {
+	socket  string
+	root    string
+	workers int
+}
+
+// defaultDaemonSocket 返回默认的 unix socket 路径（用户缓存目录下），取不到缓存目录时使用临时目录。
+func defaultDaemonSocket() string {
+	directory, err := os.UserCacheDir()
+	if err != nil {
+		directory = os.TempDir()
+	}
+	return filepath.Join(directory, "gocloc", "daemon.sock")
+}
+
+// newDaemonCmd 创建 daemon 子命令。
+// daemon 在内存中保留单文件结果缓存，scan --daemon 把扫描请求转发给它，重复扫描时只重新分析变化的文件，例如：
+//
+//	gocloc daemon &
+//	gocloc scan --daemon . --format json
+func newDaemonCmd() *cobra.Command {
+	options := daemonOptions{
+		socket:  defaultDaemonSocket(),
+		root:    "/",
+		workers: runtime.NumCPU(),
+	}
+
+	daemonCmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "常驻运行并缓存单文件结果，通过 unix socket 响应 scan --daemon 的请求",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			socket := strings.TrimSpace(options.socket)
+			if socket == "" {
+				return errors.New("socket path is empty")
+			}
+			if options.workers <= 0 {
+				return errors.New("workers must be greater than 0")
+			}
+			logger, err := commandLogger(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(socket), 0o700); err != nil {
+				return fmt.Errorf("create socket directory: %w", err)
+			}
+			// 上次异常退出可能留下 socket 文件；仍能连上说明已有 daemon 在运行，此时不抢占。
+			if conn, err := net.Dial("unix", socket); err == nil {
+				_ = conn.Close()
+				return fmt.Errorf("daemon is already running on %s", socket)
+			}
+			_ = os.Remove(socket)
+			listener, err := net.Listen("unix", socket)
+			if err != nil {
+				return fmt.Errorf("listen unix socket: %w", err)
+			}
+			defer os.Remove(socket)
+			// socket 只允许当前用户访问，daemon 可以读取 root 下当前用户可读的任意文件。
+			if err := os.Chmod(socket, 0o600); err != nil {
+				_ = listener.Close()
+				return fmt.Errorf("restrict socket permissions: %w", err)
+			}
+
+			daemon := server.NewDaemon(server.Config{Root: options.root, Workers: options.workers, Logger: logger})
+			httpServer := &http.Server{Handler: daemon.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+				defer cancel()
+				_ = httpServer.Shutdown(shutdownCtx)
+			}()
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "daemon listening on %s\n", socket)
+			if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	daemonCmd.Flags().StringVar(&options.socket, "socket", options.socket, "unix socket 路径")
+	daemonCmd.Flags().StringVar(&options.root, "root", options.root, "允许扫描的根目录，请求路径不能越出")
+	daemonCmd.Flags().IntVar(&options.workers, "workers", options.workers, "每次扫描默认的并发 worker 数量")
+
+	return daemonCmd
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+
+	"github.com/spf13/cobra"
+)
+
+// explainOptions 存放 explain 命令的可配置参数。
+type explainOptions struct {
+	language     string
+	color        string
+	languageDefs string
+	docstrings   string
+	sqlDialect   string
+	goDirectives string
+	shebang      string
+	stringLines  bool
+	logical      bool
+	cgoPreamble  bool
+}
+
+// explainClassColors 为各行分类的 ANSI 颜色。
+var explainClassColors = map[string]string{
+	model.LineClassCode:         "32",
+	model.LineClassComment:      "36",
+	model.LineClassBlank:        "90",
+	model.LineClassMixed:        "33",
+	model.LineClassPreprocessor: "35",
+	model.LineClassString:       "31",
+}
+
+// newExplainCmd 创建 explain 子命令。
+// 命令逐行输出文件的分类以及分析器 FSM 在行首、行尾所处的状态，用于排查某一行为什么被计为代码或注释，例如：gocloc explain main.go
+func newExplainCmd() *cobra.Command {
+	options := explainOptions{
+		color:        "auto",
+		docstrings:   "code",
+		sqlDialect:   languages.SQLDialectAuto,
+		goDirectives: languages.GoDirectivesComment,
+		shebang:      languages.ShebangComment,
+	}
+
+	explainCmd := &cobra.Command{
+		Use:   "explain <file>",
+		Short: "逐行展示文件的分类与 FSM 状态变化",
+		Long: "逐行输出行号、分类（code/comment/blank/mixed 等）、该行前后 FSM 所处的状态与行内容。\n" +
+			"状态写作 \"code → block-comment\" 时表示块注释、字符串等结构从这一行延续到下一行；外部插件不提供状态，显示为 -。\n" +
+			"分析选项与 scan 的同名参数一致，同样会读取配置文件。",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loaded, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			configString(cmd, "python-docstrings", &options.docstrings, loaded.PythonDocstrings)
+			configString(cmd, "sql-dialect", &options.sqlDialect, loaded.SQLDialect)
+			configString(cmd, "go-directives", &options.goDirectives, loaded.GoDirectives)
+			configString(cmd, "shebang", &options.shebang, loaded.Shebang)
+
+			docstrings := strings.ToLower(strings.TrimSpace(options.docstrings))
+			if docstrings != "code" && docstrings != "comment" {
+				return errors.New("unsupported python-docstrings, allowed values: code, comment")
+			}
+			colored, err := explainColor(options.color, cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			definitions, _, err := parseLanguageSources(options.languageDefs, nil)
+			if err != nil {
+				return err
+			}
+
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			analyzerOptions := languages.Options{
+				Annotate:           true,
+				TraceStates:        true,
+				StringLiteralLines: options.stringLines,
+				PythonDocstrings:   docstrings == "comment",
+				SQLDialect:         options.sqlDialect,
+				GoDirectives:       options.goDirectives,
+				Shebang:            options.shebang,
+				LogicalDirectives:  options.logical,
+				CgoPreamble:        options.cgoPreamble,
+			}
+			registry := languages.NewRegistryWithOptions(analyzerOptions)
+			for _, definition := range definitions {
+				registry.Register(&languages.GenericAnalyzer{Definition: definition, Options: analyzerOptions})
+			}
+
+			language := strings.TrimSpace(options.language)
+			if language == "" {
+				detected, ok := registry.Detect(args[0], content)
+				if !ok {
+					return fmt.Errorf("cannot detect language of %s, use --language", args[0])
+				}
+				language = detected
+			}
+			analyzer, ok := registry.AnalyzerForLanguage(language)
+			if !ok {
+				return fmt.Errorf("unsupported language %q", language)
+			}
+
+			var metrics model.LineMetrics
+			if pathAnalyzer, ok := analyzer.(languages.PathAnalyzer); ok {
+				metrics, err = pathAnalyzer.AnalyzePath(args[0], bytes.NewReader(content))
+			} else {
+				metrics, err = analyzer.Analyze(bytes.NewReader(content))
+			}
+			if err != nil {
+				return fmt.Errorf("analyze %s: %w", args[0], err)
+			}
+			return writeExplain(cmd.OutOrStdout(), analyzer.Name(), content, metrics, colored)
+		},
+	}
+
+	explainCmd.Flags().StringVar(&options.language, "language", "", "按指定语言分析，默认按后缀、文件名、shebang 与 modeline 识别")
+	explainCmd.Flags().StringVar(&options.color, "color", options.color, "是否输出颜色: auto（输出到终端且未设置 NO_COLOR 时）、always 或 never")
+	explainCmd.Flags().StringVar(&options.languageDefs, "language-defs", "", "自定义语言定义文件（YAML 或 JSON）")
+	explainCmd.Flags().StringVar(&options.docstrings, "python-docstrings", options.docstrings, "Python 模块、类与函数体开头的 docstring 计为 code 或 comment")
+	explainCmd.Flags().StringVar(&options.sqlDialect, "sql-dialect", options.sqlDialect, "SQL 方言: auto（按语法特征判断）、ansi、mysql、postgres、tsql 或 oracle")
+	explainCmd.Flags().StringVar(&options.goDirectives, "go-directives", options.goDirectives, "Go 编译指令行计为 comment、directive 或 code")
+	explainCmd.Flags().StringVar(&options.shebang, "shebang", options.shebang, "文件首行 shebang（#!）计为 comment、directive 或 code")
+	explainCmd.Flags().BoolVar(&options.stringLines, "string-lines", false, "把只包含字符串字面量内容的行单独分类为 string")
+	explainCmd.Flags().BoolVar(&options.logical, "logical-directives", false, "C/C++ 以反斜杠续行的多行预处理指令只计为一条 preprocessor")
+	explainCmd.Flags().BoolVar(&options.cgoPreamble, "cgo-preamble", false, "把紧邻 import \"C\" 的注释块按 C/C++ 分析")
+
+	return explainCmd
+}
+
+// explainColor 解析 --color；auto 时仅在输出为终端且未设置 NO_COLOR 环境变量时输出颜色。
+func explainColor(mode string, writer io.Writer) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		if _, disabled := os.LookupEnv("NO_COLOR"); disabled {
+			return false, nil
+		}
+		file, ok := writer.(*os.File)
+		if !ok {
+			return false, nil
+		}
+		info, err := file.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, fmt.Errorf("unsupported color mode %q, allowed values: auto, always, never", mode)
+}
+
+// writeExplain 逐行输出行号、分类、FSM 状态与行内容，最后输出该文件的统计。
+// 行内容按与分析器一致的规则切分：跳过 BOM，\r\n 与单独的 \r 都视为换行。
+func writeExplain(writer io.Writer, language string, content []byte, metrics model.LineMetrics, colored bool) error {
+	text := strings.TrimPrefix(string(content), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	lines := strings.Split(text, "\n")
+
+	paint := func(code string, value string) string {
+		if !colored || code == "" {
+			return value
+		}
+		return "\x1b[" + code + "m" + value + "\x1b[0m"
+	}
+
+	width := len(strconv.Itoa(len(metrics.LineClasses)))
+	stateWidth := 1
+	for _, state := range metrics.LineStates {
+		stateWidth = max(stateWidth, utf8.RuneCountInString(explainTransition(state)))
+	}
+	for index, class := range metrics.LineClasses {
+		transition := "-"
+		stateColor := "90"
+		if index < len(metrics.LineStates) {
+			state := metrics.LineStates[index]
+			transition = explainTransition(state)
+			if state.Start != state.End {
+				stateColor = "1"
+			}
+		}
+		line := ""
+		if index < len(lines) {
+			line = lines[index]
+		}
+		_, err := fmt.Fprintf(writer, "%s  %s  %s  %s\n",
+			paint("90", fmt.Sprintf("%*d", width, index+1)),
+			paint(explainClassColors[class], fmt.Sprintf("%-12s", class)),
+			paint(stateColor, fmt.Sprintf("%-*s", stateWidth, transition)),
+			line)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(writer, "%s: total %d, code %d, comment %d, blank %d\n",
+		language, metrics.Total, metrics.Code, metrics.Comment, metrics.Blank)
+	return err
+}
+
+// explainTransition 把一行前后的状态格式化为 "code" 或 "code → string"。
+func explainTransition(state model.LineState) string {
+	if state.Start == state.End {
+		return state.Start
+	}
+	return state.Start + " → " + state.End
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/config"
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+
+	"github.com/spf13/cobra"
+)
+
+// genConfigOptions 存放 gen-config 命令的可配置参数。
+type genConfigOptions struct {
+	output string
+	force  bool
+}
+
+// newGenConfigCmd 创建 gen-config 子命令。
+// 命令生成带注释与默认值的配置文件模板，例如：gocloc gen-config 或 gocloc gen-config -o - > ci.yaml
+func newGenConfigCmd(registry *languages.Registry) *cobra.Command {
+	options := genConfigOptions{output: config.FileName}
+
+	genConfigCmd := &cobra.Command{
+		Use:   "gen-config",
+		Short: "生成带注释与默认值的 " + config.FileName + " 配置文件",
+		Long: "生成带注释的配置文件：每个设置项附带说明并填入当前默认值，语言设置上方列出全部已注册语言及其后缀。\n" +
+			"默认写入当前目录的 " + config.FileName + "，文件已存在时需要 --force 才会覆盖；-o - 输出到标准输出。",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			content := config.Template(registry.Languages())
+			output := strings.TrimSpace(options.output)
+			if output == "" {
+				return errors.New("--output must not be empty")
+			}
+			if output == "-" {
+				_, err := cmd.OutOrStdout().Write(content)
+				return err
+			}
+
+			flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+			if options.force {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			}
+			file, err := os.OpenFile(output, flags, 0o644)
+			if errors.Is(err, fs.ErrExist) {
+				return fmt.Errorf("%s already exists, use --force to overwrite", output)
+			}
+			if err != nil {
+				return fmt.Errorf("create config: %w", err)
+			}
+			if _, err := file.Write(content); err != nil {
+				_ = file.Close()
+				return fmt.Errorf("write config: %w", err)
+			}
+			if err := file.Close(); err != nil {
+				return fmt.Errorf("write config: %w", err)
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "config written to %s\n", output)
+			return err
+		},
+	}
+
+	genConfigCmd.Flags().StringVarP(&options.output, "output", "o", options.output, "配置文件写入的路径，- 表示输出到标准输出")
+	genConfigCmd.Flags().BoolVar(&options.force, "force", false, "覆盖已存在的文件")
+
+	return genConfigCmd
+}
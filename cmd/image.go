@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/config"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/oci"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+
+	"github.com/spf13/cobra"
+)
+
+// 镜像仓库凭证的环境变量，未设置时匿名拉取。
+const (
+	envRegistryUsername = config.EnvPrefix + "REGISTRY_USERNAME"
+	envRegistryPassword = config.EnvPrefix + "REGISTRY_PASSWORD"
+)
+
+// scanImage 拉取镜像并把各层合并展开到临时目录后扫描，结果的 ScannedPath 为完整的镜像引用。
+func scanImage(cmd *cobra.Command, scanner *gocloc.Scanner, image string, platform string) (model.ScanResult, error) {
+	reference, err := oci.ParseReference(image)
+	if err != nil {
+		return model.ScanResult{}, err
+	}
+	directory, err := os.MkdirTemp("", "gocloc-image-")
+	if err != nil {
+		return model.ScanResult{}, err
+	}
+	defer os.RemoveAll(directory)
+
+	logger, err := commandLogger(cmd)
+	if err != nil {
+		return model.ScanResult{}, err
+	}
+	client := &oci.Client{
+		Username: os.Getenv(envRegistryUsername),
+		Password: os.Getenv(envRegistryPassword),
+		Platform: platform,
+	}
+	pulled, err := client.Pull(cmd.Context(), reference, directory)
+	if err != nil {
+		return model.ScanResult{}, err
+	}
+	logger.Info("image pulled", "image", reference.String(), "digest", pulled.Digest, "platform", pulled.Platform,
+		"layers", pulled.Layers, "bytes", pulled.Bytes)
+
+	result, err := scanner.ScanContext(cmd.Context(), directory)
+	if err != nil {
+		return model.ScanResult{}, err
+	}
+	result.ScannedPath = reference.String()
+	return result, nil
+}
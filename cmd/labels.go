@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// parseLabels 解析 --label 的 KEY=VALUE 标签，同一个键重复时后出现的为准。
+func parseLabels(specs []string) (map[string]string, error) {
+	labels := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label %q, expected KEY=VALUE", spec)
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+// mergeLabels 合并配置文件中的 labels 与 --label，同一个键以命令行为准；两者都为空时返回 nil。
+func mergeLabels(configured map[string]string, specs []string) (map[string]string, error) {
+	parsed, err := parseLabels(specs)
+	if err != nil {
+		return nil, err
+	}
+	if len(configured) == 0 && len(parsed) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(configured)+len(parsed))
+	for key, value := range configured {
+		labels[key] = value
+	}
+	for key, value := range parsed {
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// formatLabels 把标签按键排序格式化为 k=v,k=v，没有标签时返回 -。
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "-"
+	}
+	return strings.Join(model.LabelPairs(labels), ",")
+}
@@ -5,26 +5,36 @@ import (
 	"strings"
 	"text/tabwriter"
 
-	"gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
 
 	"github.com/spf13/cobra"
 )
 
 // newLanguageCmd 创建 language 子命令。
-// 命令用于展示当前已经实现的语言以及对应文件后缀。
+// 命令用于展示当前已经实现的语言以及对应文件后缀，--details 时额外展示每个 FSM 支持的注释与字符串语法。
 func newLanguageCmd(registry *languages.Registry) *cobra.Command {
-	return &cobra.Command{
+	details := false
+
+	languageCmd := &cobra.Command{
 		Use:   "language",
 		Short: "展示已实现语言及后缀",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
 
-			if _, err := fmt.Fprintln(writer, "LANGUAGE\tEXTENSIONS"); err != nil {
+			header := "LANGUAGE\tEXTENSIONS"
+			if details {
+				header += "\tLINE COMMENTS\tBLOCK COMMENTS\tSTRINGS\tNESTED"
+			}
+			if _, err := fmt.Fprintln(writer, header); err != nil {
 				return err
 			}
 
 			for _, item := range registry.Languages() {
-				if _, err := fmt.Fprintf(writer, "%s\t%s\n", item.Name, strings.Join(item.Extensions, ", ")); err != nil {
+				row := item.Name + "\t" + strings.Join(item.Extensions, ", ")
+				if details {
+					row += "\t" + capabilityColumns(item.Capabilities)
+				}
+				if _, err := fmt.Fprintln(writer, row); err != nil {
 					return err
 				}
 			}
@@ -32,4 +42,37 @@ func newLanguageCmd(registry *languages.Registry) *cobra.Command {
 			return writer.Flush()
 		},
 	}
+
+	languageCmd.Flags().BoolVar(&details, "details", false, "展示每种语言支持的行注释、块注释、字符串定界符与注释嵌套")
+	return languageCmd
+}
+
+// capabilityColumns 把语法能力格式化为表格列，未声明能力的分析器（如外部插件）显示为 -。
+func capabilityColumns(capabilities *languages.Capabilities) string {
+	if capabilities == nil {
+		return "-\t-\t-\t-"
+	}
+
+	blocks := make([]string, 0, len(capabilities.BlockComments))
+	for _, pair := range capabilities.BlockComments {
+		blocks = append(blocks, pair.Start+" "+pair.End)
+	}
+	nested := "no"
+	if capabilities.NestedComments {
+		nested = "yes"
+	}
+	return strings.Join([]string{
+		orDash(strings.Join(capabilities.LineComments, " ")),
+		orDash(strings.Join(blocks, ", ")),
+		orDash(strings.Join(capabilities.StringDelimiters, " ")),
+		nested,
+	}, "\t")
+}
+
+// orDash 在值为空时返回 -。
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
 }
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+
+	"github.com/spf13/cobra"
+)
+
+// listFilesOptions 存放 list-files 命令的可配置参数，过滤相关参数与 scan 命令一致。
+type listFilesOptions struct {
+	format       string
+	languageDefs string
+	plugins      []string
+	excludes     []string
+	languages    []string
+	disabled     []string
+	extensionMap []string
+	attributes   bool
+	gitignore    bool
+	followLinks  bool
+}
+
+// newListFilesCmd 创建 list-files 子命令。
+// 命令只执行发现阶段，列出 scan 会分析的文件及其语言而不读取内容，便于在长时间扫描前验证过滤规则，例如：
+//
+//	gocloc list-files . --exclude 'vendor/**'
+func newListFilesCmd() *cobra.Command {
+	options := listFilesOptions{format: "table"}
+
+	listFilesCmd := &cobra.Command{
+		Use:   "list-files [path...]",
+		Short: "列出扫描时会被分析的文件及其语言（不做统计）",
+		Long: "列出扫描时会被分析的文件及其语言（不做统计）。\n" +
+			"排除规则、语言开关与后缀映射的取值方式与 scan 命令完全一致，包括配置文件中的设置。",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loaded, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			configTableFormat(cmd, &options.format, loaded.Format)
+			configStrings(cmd, "include-language", &options.languages, loaded.Languages)
+			configStrings(cmd, "disable-language", &options.disabled, loaded.DisabledLanguages)
+
+			format := strings.ToLower(strings.TrimSpace(options.format))
+			if format != "table" && format != "json" {
+				return errors.New("unsupported format, allowed values: table, json")
+			}
+
+			definitions, plugins, err := parseLanguageSources(options.languageDefs, options.plugins)
+			if err != nil {
+				return err
+			}
+			overrides, err := parseExtensionMap(options.extensionMap)
+			if err != nil {
+				return err
+			}
+			logger, err := commandLogger(cmd)
+			if err != nil {
+				return err
+			}
+
+			files, err := gocloc.NewScanner(gocloc.Options{
+				LanguageDefinitions: definitions,
+				Plugins:             plugins,
+				Logger:              logger,
+				Excludes:            append(append([]string(nil), loaded.Exclude...), options.excludes...),
+				Languages:           options.languages,
+				DisabledLanguages:   options.disabled,
+				ExtensionOverrides:  overrides,
+				Gitattributes:       options.attributes,
+				Gitignore:           options.gitignore,
+				FollowLinks:         options.followLinks,
+			}).ListFiles(cmd.Context(), args...)
+			if err != nil {
+				return err
+			}
+			return writeFileList(cmd, format, files)
+		},
+	}
+
+	listFilesCmd.Flags().StringVar(&options.format, "format", options.format, "输出格式: table 或 json")
+	listFilesCmd.Flags().StringVar(&options.languageDefs, "language-defs", "", "自定义语言定义文件（YAML 或 JSON），用于支持内置之外的语言")
+	listFilesCmd.Flags().StringArrayVar(&options.plugins, "plugin", nil, "外部分析器插件，格式 NAME:EXT[,EXT...]:COMMAND，可重复指定")
+	listFilesCmd.Flags().StringArrayVar(&options.excludes, "exclude", nil, "排除匹配的路径（相对扫描路径，支持 **），与配置文件中的 exclude 合并，可重复指定")
+	listFilesCmd.Flags().StringArrayVar(&options.languages, "include-language", nil, "只列出指定语言（不区分大小写），可重复指定")
+	listFilesCmd.Flags().StringArrayVar(&options.disabled, "disable-language", nil, "不列出指定语言（不区分大小写），可重复指定")
+	listFilesCmd.Flags().StringArrayVar(&options.extensionMap, "map-extension", nil, "把后缀映射到指定语言，格式 EXT=LANGUAGE（如 .inc=C/C++），可重复指定")
+
+	listFilesCmd.Flags().BoolVar(&options.attributes, "gitattributes", false, "按 .gitattributes 的 linguist-* 属性排除文件或改变语言，与 scan 的同名选项一致")
+	listFilesCmd.Flags().BoolVar(&options.gitignore, "gitignore", false, "跳过 .gitignore 忽略的文件与目录，与 scan 的同名选项一致")
+	listFilesCmd.Flags().BoolVar(&options.followLinks, "follow-links", false, "进入指向目录的符号链接与 Windows 目录联接（带循环保护），与 scan 的同名选项一致")
+
+	return listFilesCmd
+}
+
+// writeFileList 按格式输出文件清单，table 格式末尾附带文件总数。
+func writeFileList(cmd *cobra.Command, format string, files []gocloc.DiscoveredFile) error {
+	if format == "json" {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Files []gocloc.DiscoveredFile `json:"files"`
+		}{Files: files})
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(writer, "PATH\tLANGUAGE"); err != nil {
+		return err
+	}
+	for _, item := range files {
+		if _, err := fmt.Fprintf(writer, "%s\t%s\n", item.Path, item.Language); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(cmd.OutOrStdout(), "\n%d file(s)\n", len(files))
+	return err
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+
+	"github.com/spf13/cobra"
+)
+
+// mergeOptions 存放 merge 命令的可配置参数。
+type mergeOptions struct {
+	format    string
+	output    string
+	noExport  bool
+	anonymize bool
+}
+
+// newMergeCmd 创建 merge 子命令。
+// 命令用于合并多个分片扫描或不同仓库导出的 JSON 结果，例如：gocloc merge shard-*.json -o combined.json
+func newMergeCmd() *cobra.Command {
+	options := mergeOptions{format: "table"}
+
+	mergeCmd := &cobra.Command{
+		Use:   "merge [result.json...]",
+		Short: "合并多个 JSON 扫描结果并重新汇总",
+		Long: "合并多个 JSON 扫描结果（分片扫描或不同仓库）并重新汇总。\n" +
+			"文件按路径去重，后出现的为准；-o 指定时合并结果只导出为 JSON 文件（不再输出到标准输出），可再次作为 merge 的输入。",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := strings.ToLower(strings.TrimSpace(options.format))
+			if format != "table" && format != "json" {
+				return errors.New("unsupported format, allowed values: table, json")
+			}
+
+			var merged model.ScanResult
+			for _, path := range args {
+				result, err := report.Load(path)
+				if err != nil {
+					return err
+				}
+				merged.Merge(result)
+			}
+
+			output, err := expandOutput(options.output, ".")
+			if err != nil {
+				return err
+			}
+			if options.noExport {
+				output = ""
+			}
+			if options.anonymize {
+				merged = merged.Anonymized()
+			}
+			// 指定 -o 时合并结果只写入文件，标准输出保持干净，便于在管道与 CI 中使用。
+			if strings.TrimSpace(output) != "" {
+				if err := report.Save(output, merged); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(noticeWriter(cmd), "JSON exported to %s\n", output)
+				return nil
+			}
+			reporter, _ := report.NewRegistry().Lookup(format)
+			return writeResult(cmd, reporter, "", merged)
+		},
+	}
+
+	mergeCmd.Flags().StringVar(&options.format, "format", options.format, "未指定 --output 时输出到标准输出的格式: table 或 json")
+	mergeCmd.Flags().StringVarP(&options.output, "output", "o", "", "把合并结果以 json 导出到该文件（不再输出到标准输出），以 .gz 结尾时 gzip 压缩，支持 {date}、{time}、{ref}、{path-hash} 占位符；未指定时不写文件")
+	mergeCmd.Flags().BoolVar(&options.noExport, "no-export", false, "不导出 json 文件，即使指定了 --output")
+	mergeCmd.Flags().BoolVar(&options.anonymize, "anonymize-paths", false, "把输出（含导出文件）中的路径替换为保留后缀的稳定哈希，可用于匿名化已有的导出结果")
+
+	return mergeCmd
+}
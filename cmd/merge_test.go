@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+)
+
+// TestMergeOutput 验证指定 -o 时合并结果写入该文件而不输出到标准输出，未指定时输出到标准输出且不写文件。
+func TestMergeOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	shards := make([]string, 0, 2)
+	for _, path := range []string{"api/main.go", "web/app.go"} {
+		result := model.ScanResult{ScannedPath: ".", Files: []model.FileMetrics{
+			{Path: path, Language: "Go", Metrics: model.LineMetrics{Total: 3, Code: 2, Blank: 1}},
+		}}
+		result.Summarize(model.SummaryOptions{})
+		shard := filepath.Join(tempDir, strings.ReplaceAll(filepath.Dir(path), "/", "-")+".json")
+		if err := report.Save(shard, result); err != nil {
+			t.Fatal(err)
+		}
+		shards = append(shards, shard)
+	}
+	run := func(args ...string) (string, string) {
+		cmd := newRootCmd("test", languages.NewRegistry())
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs(append(append([]string{"merge"}, shards...), args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("merge %v: %v", args, err)
+		}
+		return stdout.String(), stderr.String()
+	}
+
+	for _, format := range []string{"table", "json"} {
+		output := filepath.Join(tempDir, "combined-"+format+".json")
+		stdout, stderr := run("--format", format, "-o", output)
+		if stdout != "" {
+			t.Fatalf("expected empty stdout with -o (%s), got:\n%s", format, stdout)
+		}
+		if !strings.Contains(stderr, "JSON exported to "+output) {
+			t.Fatalf("missing export notice: %q", stderr)
+		}
+		merged, err := report.Load(output)
+		if err != nil {
+			t.Fatalf("load merged result: %v", err)
+		}
+		if merged.Total.Files != 2 || merged.Total.Code != 4 || len(merged.Files) != 2 {
+			t.Fatalf("unexpected merged result: %+v", merged.Total)
+		}
+	}
+
+	stdout, _ := run("--format", "json")
+	if !strings.Contains(stdout, `"schema_version"`) || !strings.Contains(stdout, "web/app.go") {
+		t.Fatalf("expected merged JSON on stdout without -o:\n%s", stdout)
+	}
+}
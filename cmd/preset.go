@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultExcludes 是常见的依赖、构建产物与压缩文件目录，ci 与 fast 预设会排除它们。
+var defaultExcludes = []string{
+	".git",
+	"**/node_modules",
+	"**/vendor",
+	"**/third_party",
+	"**/dist",
+	"**/build",
+	"**/*.min.js",
+}
+
+// scanPreset 是一组 scan 参数的组合，只作用于命令行未显式设置的参数。
+type scanPreset struct {
+	// description 出现在 --preset 的帮助信息中。
+	description string
+	apply       func(cmd *cobra.Command, options *scanOptions)
+}
+
+// scanPresets 是 --preset 支持的预设。
+var scanPresets = map[string]scanPreset{
+	"ci": {
+		description: "排除默认目录与 .gitignore 忽略的文件，只输出汇总，存在失败文件时以非 0 状态退出",
+		apply: func(cmd *cobra.Command, options *scanOptions) {
+			options.excludes = append(options.excludes, defaultExcludes...)
+			presetBool(cmd, "gitignore", &options.gitignore, true)
+			presetBool(cmd, "fail-on-error", &options.failOnError, true)
+			// 显式要求了依赖文件明细的参数时保留明细，预设不覆盖命令行。
+			summary := *options
+			summary.summaryOnly = true
+			if checkSummaryOnly(summary) == nil {
+				presetBool(cmd, "summary-only", &options.summaryOnly, true)
+			}
+		},
+	},
+	"fast": {
+		description: "排除默认目录与 .gitignore 忽略的文件，关闭所有逐文件的附加统计，只做基础行数统计",
+		apply: func(cmd *cobra.Command, options *scanOptions) {
+			options.excludes = append(options.excludes, defaultExcludes...)
+			presetBool(cmd, "gitignore", &options.gitignore, true)
+			for flag, target := range map[string]*bool{
+				"count-functions": &options.countFunctions,
+				"duplicates":      &options.duplicates,
+				"whitespace":      &options.whitespace,
+				"scripts":         &options.scripts,
+				"distribution":    &options.distribution,
+				"git-blame":       &options.gitBlame,
+				"annotate":        &options.annotate,
+				"string-lines":    &options.stringLines,
+			} {
+				presetBool(cmd, flag, target, false)
+			}
+			if !cmd.Flags().Changed("top") {
+				options.top = 0
+			}
+		},
+	},
+	"strict": {
+		description: "不排除任何目录，存在失败文件时以非 0 状态退出",
+		apply: func(cmd *cobra.Command, options *scanOptions) {
+			presetBool(cmd, "fail-on-error", &options.failOnError, true)
+		},
+	},
+	"full": {
+		description: "开启函数统计、重复检测、空白、脚本与分布统计，并输出前 10 大文件",
+		apply: func(cmd *cobra.Command, options *scanOptions) {
+			presetBool(cmd, "count-functions", &options.countFunctions, true)
+			presetBool(cmd, "duplicates", &options.duplicates, true)
+			presetBool(cmd, "whitespace", &options.whitespace, true)
+			presetBool(cmd, "scripts", &options.scripts, true)
+			presetBool(cmd, "distribution", &options.distribution, true)
+			if !cmd.Flags().Changed("top") {
+				options.top = 10
+			}
+		},
+	},
+}
+
+// applyScanPreset 按名称应用预设，名称为空时不做任何事。
+func applyScanPreset(cmd *cobra.Command, name string, options *scanOptions) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil
+	}
+	preset, ok := scanPresets[name]
+	if !ok {
+		return fmt.Errorf("unknown preset %q, allowed values: %s", name, strings.Join(scanPresetNames(), ", "))
+	}
+	preset.apply(cmd, options)
+	return nil
+}
+
+// scanPresetNames 返回排序后的预设名称。
+func scanPresetNames() []string {
+	names := make([]string, 0, len(scanPresets))
+	for name := range scanPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scanPresetUsage 返回 --preset 的帮助信息，列出每个预设包含的参数。
+func scanPresetUsage() string {
+	lines := []string{"参数预设，只作用于未显式设置的参数:"}
+	for _, name := range scanPresetNames() {
+		lines = append(lines, fmt.Sprintf("  %s: %s", name, scanPresets[name].description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// presetBool 在命令行未显式设置该参数时使用预设值。
+func presetBool(cmd *cobra.Command, flag string, target *bool, value bool) {
+	if !cmd.Flags().Changed(flag) {
+		*target = value
+	}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+// presetOptions 解析命令行参数后应用预设，返回预设作用后的选项。
+func presetOptions(t *testing.T, name string, options scanOptions, args ...string) scanOptions {
+	t.Helper()
+	cmd := newScanCmd()
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	if err := applyScanPreset(cmd, name, &options); err != nil {
+		t.Fatalf("apply preset %s: %v", name, err)
+	}
+	return options
+}
+
+func TestScanPresetCI(t *testing.T) {
+	options := presetOptions(t, "ci", scanOptions{})
+	if !options.summaryOnly || !options.gitignore || !options.failOnError {
+		t.Fatalf("ci preset: summaryOnly=%v gitignore=%v failOnError=%v", options.summaryOnly, options.gitignore, options.failOnError)
+	}
+	if !slices.Contains(options.excludes, "**/node_modules") {
+		t.Fatalf("ci preset excludes = %v", options.excludes)
+	}
+	if err := checkSummaryOnly(options); err != nil {
+		t.Fatalf("ci preset options conflict: %v", err)
+	}
+
+	// 显式要求文件明细时，预设不开启汇总模式。
+	options = presetOptions(t, "ci", scanOptions{duplicates: true}, "--duplicates")
+	if options.summaryOnly {
+		t.Fatal("ci preset enabled summary-only together with --duplicates")
+	}
+	options = presetOptions(t, "ci", scanOptions{}, "--summary-only=false", "--gitignore=false")
+	if options.summaryOnly || options.gitignore {
+		t.Fatalf("ci preset overrode explicit flags: summaryOnly=%v gitignore=%v", options.summaryOnly, options.gitignore)
+	}
+}
+
+func TestScanPresetFast(t *testing.T) {
+	options := presetOptions(t, "fast", scanOptions{
+		countFunctions: true,
+		duplicates:     true,
+		whitespace:     true,
+		scripts:        true,
+		distribution:   true,
+		gitBlame:       true,
+		annotate:       true,
+		stringLines:    true,
+		top:            5,
+	})
+	extras := options.countFunctions || options.duplicates || options.whitespace || options.scripts ||
+		options.distribution || options.gitBlame || options.annotate || options.stringLines
+	if extras || options.top != 0 {
+		t.Fatalf("fast preset kept per-file extras: %+v", options)
+	}
+	if !options.gitignore || !slices.Contains(options.excludes, ".git") {
+		t.Fatalf("fast preset: gitignore=%v excludes=%v", options.gitignore, options.excludes)
+	}
+
+	options = presetOptions(t, "fast", scanOptions{duplicates: true, top: 3}, "--duplicates", "--top", "3")
+	if !options.duplicates || options.top != 3 {
+		t.Fatalf("fast preset overrode explicit flags: duplicates=%v top=%d", options.duplicates, options.top)
+	}
+}
+
+func TestScanPresetStrict(t *testing.T) {
+	options := presetOptions(t, "strict", scanOptions{})
+	if !options.failOnError || len(options.excludes) != 0 || options.gitignore || options.summaryOnly {
+		t.Fatalf("strict preset options = %+v", options)
+	}
+}
+
+func TestScanPresetFull(t *testing.T) {
+	options := presetOptions(t, "full", scanOptions{})
+	if !options.countFunctions || !options.duplicates || !options.whitespace || !options.scripts || !options.distribution {
+		t.Fatalf("full preset options = %+v", options)
+	}
+	if options.top != 10 {
+		t.Fatalf("full preset top = %d, want 10", options.top)
+	}
+}
+
+func TestScanPresetUnknown(t *testing.T) {
+	if err := applyScanPreset(newScanCmd(), "slow", &scanOptions{}); err == nil {
+		t.Fatal("expected error for unknown preset")
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/history"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+	"github.com/zhizhixiongxuwei/gocloc/internal/vcs"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+
+	"github.com/spf13/cobra"
+)
+
+// recordOptions 存放 record 命令的可配置参数。
+type recordOptions struct {
+	database  string
+	ref       string
+	labels    []string
+	result    string
+	workers   int
+	excludes  []string
+	languages []string
+	disabled  []string
+}
+
+// newRecordCmd 创建 record 子命令。
+// 命令把一次扫描的汇总追加到历史数据库，例如：gocloc record . --db metrics.db --label team=api
+func newRecordCmd() *cobra.Command {
+	options := recordOptions{workers: runtime.NumCPU()}
+
+	recordCmd := &cobra.Command{
+		Use:   "record [path]",
+		Short: "扫描并把汇总追加到历史数据库，供 trend 查询",
+		Long: "扫描并把总计与各语言的汇总连同时间、版本引用与标签追加到历史数据库，供 trend 查询。\n" +
+			"--db 以 .db、.sqlite 或 .sqlite3 结尾时为 SQLite 数据库（可用 sqlite3 做 SQL 查询），其它路径为 JSON Lines 文件（每行一条记录，只追加），\n" +
+			"不存在时创建；--result 可以记录已导出的扫描结果而不重新扫描。\n" +
+			"--ref 未指定时取扫描路径所在仓库的当前分支或短提交哈希，不在仓库中时留空。",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(options.database) == "" {
+				return errors.New("--db is required")
+			}
+			store, err := history.Open(options.database)
+			if err != nil {
+				return err
+			}
+			path := "."
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			loaded, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			labels, err := mergeLabels(loaded.Labels, options.labels)
+			if err != nil {
+				return err
+			}
+
+			var result model.ScanResult
+			if options.result != "" {
+				if len(args) > 0 {
+					return errors.New("--result cannot be combined with a scan path")
+				}
+				if result, err = report.Load(options.result); err != nil {
+					return err
+				}
+			} else {
+				configInt(cmd, "workers", &options.workers, loaded.Workers)
+				configStrings(cmd, "include-language", &options.languages, loaded.Languages)
+				configStrings(cmd, "disable-language", &options.disabled, loaded.DisabledLanguages)
+				if options.workers <= 0 {
+					return errors.New("workers must be greater than 0")
+				}
+				logger, err := commandLogger(cmd)
+				if err != nil {
+					return err
+				}
+				result, err = gocloc.NewScanner(gocloc.Options{
+					Workers:           options.workers,
+					Excludes:          append(append([]string(nil), loaded.Exclude...), options.excludes...),
+					Languages:         options.languages,
+					DisabledLanguages: options.disabled,
+					Logger:            logger,
+				}).ScanPaths(path)
+				if err != nil {
+					return err
+				}
+			}
+
+			ref := strings.TrimSpace(options.ref)
+			if ref == "" && options.result == "" {
+				ref = currentRef(path)
+			}
+			entry := history.NewEntry(result, time.Now(), ref, labels)
+			if err := store.Append(entry); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "recorded %d code lines in %d files (ref %s) to %s\n",
+				entry.Total.Code, entry.Total.Files, displayRef(entry.Ref), options.database)
+			return err
+		},
+	}
+
+	recordCmd.Flags().StringVar(&options.database, "db", "", "历史数据库：.db/.sqlite/.sqlite3 为 SQLite 数据库，其它路径为 JSON Lines 文件，不存在时创建")
+	recordCmd.Flags().StringVar(&options.ref, "ref", "", "记录的版本引用，未指定时取扫描路径所在仓库的当前分支或短提交哈希")
+	recordCmd.Flags().StringArrayVar(&options.labels, "label", nil, "记录的标签 KEY=VALUE（如 team=api），与配置文件中的 labels 合并，trend 可按标签过滤，可重复指定")
+	recordCmd.Flags().StringVar(&options.result, "result", "", "记录已导出的 JSON 扫描结果而不重新扫描")
+	recordCmd.Flags().IntVar(&options.workers, "workers", options.workers, "并发 worker 数量")
+	recordCmd.Flags().StringArrayVar(&options.excludes, "exclude", nil, "排除匹配的路径（相对扫描路径，支持 **），与配置文件中的 exclude 合并，可重复指定")
+	recordCmd.Flags().StringArrayVar(&options.languages, "include-language", nil, "只统计指定语言（不区分大小写），可重复指定")
+	recordCmd.Flags().StringArrayVar(&options.disabled, "disable-language", nil, "不统计指定语言（不区分大小写），可重复指定")
+
+	return recordCmd
+}
+
+// currentRef 返回 path 所在仓库的当前分支或短提交哈希，不在仓库中时返回空字符串。
+func currentRef(path string) string {
+	directory, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	if info, err := os.Stat(directory); err == nil && !info.IsDir() {
+		directory = filepath.Dir(directory)
+	}
+	ref, err := vcs.CurrentRef(directory)
+	if err != nil {
+		return ""
+	}
+	return ref
+}
+
+// displayRef 把空的版本引用显示为 -。
+func displayRef(ref string) string {
+	if ref == "" {
+		return "-"
+	}
+	return ref
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+)
+
+// newReporterRegistry 创建包含内置输出格式与 --reporter 插件的注册中心，插件与内置格式同名时替换内置格式。
+func newReporterRegistry(specs []string) (*report.Registry, error) {
+	registry := report.NewRegistry()
+	for _, spec := range specs {
+		definition, err := report.ParseReporterSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		registry.Register(report.CommandReporter{Definition: definition})
+	}
+	return registry, nil
+}
+
+// reporterUsage 返回 --format 的帮助文本，列出内置输出格式及其说明。
+func reporterUsage() string {
+	lines := []string{"输出格式，--reporter 注册的插件同样可选:"}
+	for _, reporter := range report.NewRegistry().Reporters() {
+		lines = append(lines, fmt.Sprintf("  %s: %s", reporter.Name(), report.TraitsOf(reporter).Description))
+	}
+	return strings.Join(lines, "\n")
+}
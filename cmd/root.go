@@ -2,7 +2,16 @@
 package cmd
 
 import (
-	"gocloc/internal/languages"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/config"
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
 
 	"github.com/spf13/cobra"
 )
@@ -23,11 +32,81 @@ func newRootCmd(version string, registry *languages.Registry) *cobra.Command {
 		Long: "gocloc 是一个基于有限状态机（FSM）的代码统计工具，\n" +
 			"用于统计 total/code/comment/blank 行数，支持并发扫描与 JSON 导出。",
 		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			logger, err := commandLogger(cmd)
+			if err != nil {
+				return err
+			}
+			report.SetLogger(logger)
+			// 静默模式下丢弃所有标准输出，结果只能通过导出文件与退出码获得；错误仍输出到标准错误。
+			if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+				cmd.Root().SetOut(io.Discard)
+			}
+			return nil
+		},
 	}
+	rootCmd.PersistentFlags().String("log-level", "error", "日志级别: debug、info、warn 或 error，日志输出到标准错误")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "输出扫描过程信息（各阶段耗时等），等同于 --log-level info")
+	rootCmd.PersistentFlags().Bool("debug", false, "输出发现阶段的决策（跳过原因、匹配的分析器）与单文件结果，等同于 --log-level debug")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "静默模式：不输出任何非错误信息（结果、提示与 error 级别以下的日志），依赖退出码与导出文件")
+	rootCmd.PersistentFlags().String("config", "", "配置文件路径，未指定时从扫描路径向上查找 .gocloc.yaml")
 
 	rootCmd.AddCommand(newVersionCmd(version))
 	rootCmd.AddCommand(newLanguageCmd(registry))
-	rootCmd.AddCommand(newScanCmd(registry))
+	rootCmd.AddCommand(newGenConfigCmd(registry))
+	rootCmd.AddCommand(newExplainCmd())
+	rootCmd.AddCommand(newScanCmd())
+	rootCmd.AddCommand(newListFilesCmd())
+	rootCmd.AddCommand(newScanManyCmd())
+	rootCmd.AddCommand(newBenchCmd())
+	rootCmd.AddCommand(newCheckCmd())
+	rootCmd.AddCommand(newDiffCmd())
+	rootCmd.AddCommand(newRecordCmd())
+	rootCmd.AddCommand(newTrendCmd())
+	rootCmd.AddCommand(newMergeCmd())
+	rootCmd.AddCommand(newServeCmd())
+	rootCmd.AddCommand(newDaemonCmd())
 
 	return rootCmd
 }
+
+// noticeWriter 返回写到标准错误的提示信息输出，-q/--quiet 时丢弃。
+func noticeWriter(cmd *cobra.Command) io.Writer {
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		return io.Discard
+	}
+	return cmd.ErrOrStderr()
+}
+
+// commandLogger 按 --log-level（未设置时取 GOCLOC_LOG_LEVEL）创建输出到标准错误的文本日志；-v/--verbose 与 --debug 会把级别至少放宽到 info/debug，
+// -q/--quiet 会把级别至少收紧到 error。
+func commandLogger(cmd *cobra.Command) (*slog.Logger, error) {
+	value, err := cmd.Flags().GetString("log-level")
+	if err != nil {
+		return nil, err
+	}
+	if env := os.Getenv(config.EnvLogLevel); env != "" && !cmd.Flags().Changed("log-level") {
+		value = env
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return nil, fmt.Errorf("invalid log level %q, allowed values: debug, info, warn, error", value)
+	}
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	debug, _ := cmd.Flags().GetBool("debug")
+	if quiet && (verbose || debug) {
+		return nil, errors.New("--quiet cannot be combined with --verbose or --debug")
+	}
+	if quiet && level < slog.LevelError {
+		level = slog.LevelError
+	}
+	if verbose && level > slog.LevelInfo {
+		level = slog.LevelInfo
+	}
+	if debug {
+		level = slog.LevelDebug
+	}
+	return slog.New(slog.NewTextHandler(cmd.ErrOrStderr(), &slog.HandlerOptions{Level: level})), nil
+}
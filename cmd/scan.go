@@ -1,23 +1,206 @@
 package cmd
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
-	"gocloc/internal/languages"
-	"gocloc/internal/report"
-	"gocloc/internal/scanner"
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+	"github.com/zhizhixiongxuwei/gocloc/internal/scanner"
+	"github.com/zhizhixiongxuwei/gocloc/internal/server"
+	"github.com/zhizhixiongxuwei/gocloc/internal/vcs"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
 
 	"github.com/spf13/cobra"
 )
 
+// writeResult 用 reporter 把结果输出到标准输出；output 非空时同时以 JSON 导出到该文件。
+// 输出为完整文档的格式（见 report.Traits）在 output 不以 .json 或 .gz 结尾时改为把文档写入该文件、不再输出到标准输出，
+// 导出提示写到标准错误，避免混入输出内容；静默模式下不输出提示。
+func writeResult(cmd *cobra.Command, reporter report.Reporter, output string, result model.ScanResult) error {
+	notice := cmd.OutOrStdout()
+	document := report.TraitsOf(reporter).Document
+	if document {
+		notice = noticeWriter(cmd)
+	}
+
+	outputPath := strings.TrimSpace(output)
+	if document && outputPath != "" && !isJSONOutput(outputPath) {
+		if err := renderFile(outputPath, reporter, result); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(notice, "%s exported to %s\n", strings.ToUpper(reporter.Name()), outputPath)
+		return nil
+	}
+	if err := reporter.Render(cmd.OutOrStdout(), result); err != nil {
+		return err
+	}
+	if outputPath == "" {
+		return nil
+	}
+	if err := report.Save(outputPath, result); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(notice, "\nJSON exported to %s\n", outputPath)
+	return nil
+}
+
+// isJSONOutput 判断 --output 路径是否为 JSON 导出（.json 或 gzip 压缩的 .gz）。
+func isJSONOutput(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".json" || ext == ".gz"
+}
+
+// renderFile 用 reporter 把结果写入 path，按需创建所在目录；渲染失败时不留下不完整的文件。
+func renderFile(path string, reporter report.Reporter, result model.ScanResult) error {
+	var content bytes.Buffer
+	if err := reporter.Render(&content, result); err != nil {
+		return err
+	}
+	if directory := filepath.Dir(path); directory != "." && directory != "" {
+		if err := os.MkdirAll(directory, 0o755); err != nil {
+			return fmt.Errorf("create output directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, content.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write output file: %w", err)
+	}
+	return nil
+}
+
+// expandOutput 展开 --output 中的 {date}、{time}、{ref}、{path-hash} 占位符，base 为扫描路径（{ref} 取其所在仓库的当前分支）。
+func expandOutput(output string, base string) (string, error) {
+	if strings.TrimSpace(output) == "" {
+		return "", nil
+	}
+	if base == "" || base == "-" {
+		base = "."
+	}
+	absolute, err := filepath.Abs(base)
+	if err != nil {
+		return "", fmt.Errorf("resolve absolute path: %w", err)
+	}
+	return report.ExpandOutputPath(output, report.OutputTemplateValues{
+		Time:        time.Now(),
+		ScannedPath: absolute,
+		Ref: func() (string, error) {
+			directory := absolute
+			if info, err := os.Stat(directory); err == nil && !info.IsDir() {
+				directory = filepath.Dir(directory)
+			}
+			return vcs.CurrentRef(directory)
+		},
+	})
+}
+
+// parseLanguageSources 加载 --language-defs 指定的自定义语言定义并解析 --plugin 插件描述。
+func parseLanguageSources(languageDefs string, pluginSpecs []string) ([]gocloc.LanguageDefinition, []gocloc.PluginDefinition, error) {
+	var definitions []gocloc.LanguageDefinition
+	if path := strings.TrimSpace(languageDefs); path != "" {
+		loaded, err := gocloc.LoadLanguageDefinitions(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		definitions = loaded
+	}
+
+	plugins := make([]gocloc.PluginDefinition, 0, len(pluginSpecs))
+	for _, spec := range pluginSpecs {
+		plugin, err := gocloc.ParsePluginSpec(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		plugins = append(plugins, plugin)
+	}
+	return definitions, plugins, nil
+}
+
+// parseExtensionMap 解析 --map-extension 的 EXT=LANGUAGE 映射。
+func parseExtensionMap(specs []string) (map[string]string, error) {
+	overrides := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		ext, language, ok := strings.Cut(spec, "=")
+		if !ok || strings.TrimSpace(ext) == "" || strings.TrimSpace(language) == "" {
+			return nil, fmt.Errorf("invalid extension mapping %q, expected EXT=LANGUAGE", spec)
+		}
+		overrides[strings.TrimSpace(ext)] = strings.TrimSpace(language)
+	}
+	return overrides, nil
+}
+
 // scanOptions 存放 scan 命令的可配置参数。
 type scanOptions struct {
-	format  string
-	output  string
-	workers int
+	format         string
+	output         string
+	workers        int
+	countFunctions bool
+	top            int
+	annotate       bool
+	duplicates     bool
+	duplicateLines int
+	gitBlame       bool
+	stringLines    bool
+	whitespace     bool
+	scripts        bool
+	distribution   bool
+	languageDefs   string
+	plugins        []string
+	disabled       []string
+	extensionMap   []string
+	excludes       []string
+	languages      []string
+	stdinLanguage  string
+	failOnError    bool
+	daemon         bool
+	daemonSocket   string
+	noExport       bool
+	preset         string
+	anonymize      bool
+	mmap           bool
+	mmapThreshold  int64
+	adaptive       bool
+	summaryOnly    bool
+	unsorted       bool
+	docstrings     string
+	sqlDialect     string
+	goDirectives   string
+	shebang        string
+	logical        bool
+	cgoPreamble    bool
+	trailingEmpty  bool
+	gitattributes  bool
+	gitignore      bool
+	contentCache   string
+	checkpoint     string
+	resume         bool
+	// checkpointInterval 为检查点两次写盘之间的最短间隔。
+	checkpointInterval time.Duration
+	// maxReadRate 与 ioConcurrency 为文件读取的速率（字节/秒）与并发上限，0 表示不限制。
+	maxReadRate   int64
+	ioConcurrency int
+	// maxFileSize 与 fileTimeout 为单文件的大小（字节）与分析时间上限，超过时记为 oversize/timeout 类错误，0 表示不限制。
+	maxFileSize int64
+	fileTimeout time.Duration
+	followLinks bool
+	packages    bool
+	// codeOwners 为 CODEOWNERS 文件路径，"auto" 表示在扫描路径下按 GitHub 的位置查找。
+	codeOwners string
+	// history 为 PDF 报告趋势图使用的历史结果文件，按时间先后排列。
+	history []string
+	// reporters 为 --reporter 注册的外部输出格式插件描述。
+	reporters []string
+	// image 为要扫描的容器镜像引用，指定时不接受扫描路径；imagePlatform 为多平台镜像中选择的平台。
+	image         string
+	imagePlatform string
+	// labels 为 --label 指定的 KEY=VALUE 标签，与配置文件中的 labels 合并后附加到扫描结果。
+	labels []string
 }
 
 // newScanCmd 创建 scan 子命令。
@@ -25,60 +208,492 @@ type scanOptions struct {
 //
 //	gocloc scan .
 //	gocloc scan ./project --format json --output result.json
-func newScanCmd(registry *languages.Registry) *cobra.Command {
+//	cat main.go | gocloc scan - --language go
+//	gocloc scan --image registry.example.com/app:1.0
+func newScanCmd() *cobra.Command {
 	options := scanOptions{
-		format:  "table",
-		output:  "output.json",
-		workers: runtime.NumCPU(),
+		format:       "table",
+		workers:      runtime.NumCPU(),
+		docstrings:   "code",
+		sqlDialect:   languages.SQLDialectAuto,
+		goDirectives: languages.GoDirectivesComment,
+		shebang:      languages.ShebangComment,
 	}
 
 	scanCmd := &cobra.Command{
-		Use:   "scan [path]",
+		Use:   "scan [path...]",
 		Short: "扫描目录或文件并输出代码度量信息",
-		Args:  cobra.ExactArgs(1),
+		Long: "扫描目录或文件并输出代码度量信息。\n" +
+			"指定多个路径时分别扫描后合并为一份结果，文件路径以各自的扫描路径为前缀。\n" +
+			"路径为 - 时从标准输入读取单个内容缓冲区，需要用 --language 指定语言。\n" +
+			"指定 --image 时不接受路径，改为从镜像仓库拉取镜像，扫描各层合并后的文件系统。",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if options.image != "" {
+				if len(args) > 0 {
+					return errors.New("--image cannot be combined with scan paths")
+				}
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			format := strings.ToLower(strings.TrimSpace(options.format))
-			if format != "table" && format != "json" {
-				return errors.New("unsupported format, allowed values: table, json")
+			loaded, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			configInt(cmd, "workers", &options.workers, loaded.Workers)
+			configString(cmd, "format", &options.format, loaded.Format)
+			configString(cmd, "output", &options.output, loaded.Output)
+			configString(cmd, "content-cache", &options.contentCache, loaded.ContentCache)
+			configString(cmd, "python-docstrings", &options.docstrings, loaded.PythonDocstrings)
+			configString(cmd, "sql-dialect", &options.sqlDialect, loaded.SQLDialect)
+			configString(cmd, "go-directives", &options.goDirectives, loaded.GoDirectives)
+			configString(cmd, "shebang", &options.shebang, loaded.Shebang)
+			configInt(cmd, "max-read-bytes-per-sec", &options.maxReadRate, loaded.MaxReadBytesPerSec)
+			configInt(cmd, "io-concurrency", &options.ioConcurrency, loaded.IOConcurrency)
+			if options.noExport {
+				options.output = ""
+			}
+			base := "."
+			if len(args) > 0 {
+				base = args[0]
+			}
+			if options.output, err = expandOutput(options.output, base); err != nil {
+				return err
+			}
+			configStrings(cmd, "include-language", &options.languages, loaded.Languages)
+			configStrings(cmd, "disable-language", &options.disabled, loaded.DisabledLanguages)
+			if err := applyScanPreset(cmd, options.preset, &options); err != nil {
+				return err
+			}
+			excludes := append(append([]string(nil), loaded.Exclude...), options.excludes...)
+			labels, err := mergeLabels(loaded.Labels, options.labels)
+			if err != nil {
+				return err
+			}
+
+			reporters, err := newReporterRegistry(options.reporters)
+			if err != nil {
+				return err
+			}
+			reporter, ok := reporters.Lookup(options.format)
+			if !ok {
+				return fmt.Errorf("unsupported format, allowed values: %s", strings.Join(reporters.Names(), ", "))
+			}
+			if report.TraitsOf(reporter).RequiresFiles && options.summaryOnly && !cmd.Flags().Changed("summary-only") {
+				// 汇总模式来自预设时，让位于需要文件明细的输出格式。
+				options.summaryOnly = false
+			}
+			if report.TraitsOf(reporter).RequiresFiles && options.summaryOnly {
+				return fmt.Errorf("--format %s needs per-file results and cannot be combined with --summary-only", reporter.Name())
+			}
+			if _, ok := reporter.(report.PDFReporter); len(options.history) > 0 && !ok {
+				return errors.New("--history is only valid with --format pdf")
+			}
+			// 历史结果在扫描前读取，避免长时间扫描后才发现文件有误。
+			trend, err := loadTrend(options.history)
+			if err != nil {
+				return err
 			}
 
 			if options.workers <= 0 {
 				return errors.New("workers must be greater than 0")
 			}
 
-			service := scanner.NewService(registry, options.workers)
-			result, err := service.ScanPath(args[0])
+			if options.top < 0 {
+				return errors.New("top must not be negative")
+			}
+			if options.maxReadRate < 0 || options.ioConcurrency < 0 {
+				return errors.New("max-read-bytes-per-sec and io-concurrency must not be negative")
+			}
+			if options.maxFileSize < 0 || options.fileTimeout < 0 {
+				return errors.New("max-file-size and file-timeout must not be negative")
+			}
+			docstrings := strings.ToLower(strings.TrimSpace(options.docstrings))
+			if docstrings != "code" && docstrings != "comment" {
+				return errors.New("unsupported python-docstrings, allowed values: code, comment")
+			}
+			if options.sqlDialect, err = languages.ParseSQLDialect(options.sqlDialect); err != nil {
+				return err
+			}
+			if options.goDirectives, err = languages.ParseGoDirectives(options.goDirectives); err != nil {
+				return err
+			}
+			if options.shebang, err = languages.ParseShebang(options.shebang); err != nil {
+				return err
+			}
+			if err := checkSummaryOnly(options); err != nil {
+				return err
+			}
+			if options.mmapThreshold <= 0 {
+				return errors.New("mmap-threshold must be greater than 0")
+			}
+			var mmapThreshold int64
+			if options.mmap {
+				mmapThreshold = options.mmapThreshold << 20
+			}
+
+			if options.gitBlame {
+				if err := vcs.Available(); err != nil {
+					return err
+				}
+			}
+
+			definitions, plugins, err := parseLanguageSources(options.languageDefs, options.plugins)
+			if err != nil {
+				return err
+			}
+			overrides, err := parseExtensionMap(options.extensionMap)
+			if err != nil {
+				return err
+			}
+
+			logger, err := commandLogger(cmd)
 			if err != nil {
 				return err
 			}
 
-			switch format {
-			case "table":
-				return report.PrintTable(cmd.OutOrStdout(), result)
-			case "json":
-				if err := report.PrintJSON(cmd.OutOrStdout(), result); err != nil {
+			stdin := false
+			for _, arg := range args {
+				if arg == "-" {
+					stdin = true
+				}
+			}
+			if stdin && len(args) > 1 {
+				return errors.New("scan path - (stdin) cannot be combined with other paths")
+			}
+			if stdin && strings.TrimSpace(options.stdinLanguage) == "" {
+				return errors.New("--language is required when scanning stdin")
+			}
+			if !stdin && cmd.Flags().Changed("language") {
+				return errors.New("--language is only valid when scanning stdin (-)")
+			}
+
+			if options.resume && options.checkpoint == "" {
+				return errors.New("--resume requires --checkpoint")
+			}
+			if options.checkpoint != "" && stdin {
+				return errors.New("--checkpoint cannot be used when scanning stdin (-)")
+			}
+
+			if options.imagePlatform != "" && options.image == "" {
+				return errors.New("--image-platform requires --image")
+			}
+			if options.image != "" {
+				switch {
+				case options.daemon:
+					return errors.New("--daemon cannot scan --image")
+				case options.checkpoint != "":
+					return errors.New("--checkpoint cannot be used with --image, the image is extracted to a new directory on every run")
+				case options.gitBlame:
+					return errors.New("--git-blame cannot be used with --image, images contain no git history")
+				case options.codeOwners == "auto":
+					return errors.New("--codeowners auto cannot be used with --image, pass the CODEOWNERS file instead")
+				}
+			}
+
+			if options.daemon {
+				if stdin {
+					return errors.New("--daemon cannot scan stdin (-)")
+				}
+				if options.checkpoint != "" {
+					return errors.New("--daemon does not support --checkpoint")
+				}
+				if options.followLinks {
+					return errors.New("--daemon does not support --follow-links")
+				}
+				if options.maxFileSize > 0 || options.fileTimeout > 0 {
+					return errors.New("--daemon does not support --max-file-size or --file-timeout")
+				}
+				if options.maxReadRate > 0 || options.ioConcurrency > 0 {
+					return errors.New("--daemon does not support --max-read-bytes-per-sec or --io-concurrency, the daemon reads the files")
+				}
+				if len(definitions) > 0 || len(plugins) > 0 {
+					return errors.New("--daemon does not support --language-defs or --plugin")
+				}
+				if cmd.Flags().Changed("content-cache") {
+					return errors.New("--daemon does not support --content-cache, the daemon keeps its own cache")
+				}
+				if options.codeOwners != "" {
+					return errors.New("--daemon does not support --codeowners")
+				}
+				result, err := scanWithDaemon(cmd, options, args, excludes, overrides, labels)
+				if err != nil {
 					return err
 				}
+				return finishScan(cmd, reporter, options, result, trend)
+			}
+
+			if options.codeOwners != "" {
+				if stdin {
+					return errors.New("--codeowners cannot be used when scanning stdin (-)")
+				}
+				if options.codeOwners == "auto" {
+					if len(args) > 1 {
+						return errors.New("--codeowners auto requires a single scan path, pass the CODEOWNERS file instead")
+					}
+					found, ok := gocloc.FindCodeOwners(args[0])
+					if !ok {
+						return fmt.Errorf("no CODEOWNERS file found in %s", args[0])
+					}
+					options.codeOwners = found
+				}
+			}
 
-				outputPath := strings.TrimSpace(options.output)
-				if outputPath == "" {
-					outputPath = "output.json"
+			var contentCache *gocloc.ContentCache
+			if options.contentCache != "" {
+				store, err := gocloc.OpenContentStore(options.contentCache)
+				if err != nil {
+					return err
 				}
-				if err := report.WriteJSONFile(outputPath, result); err != nil {
+				contentCache = gocloc.NewContentCache(store)
+			}
+			var checkpoint *gocloc.Checkpoint
+			if options.checkpoint != "" {
+				if checkpoint, err = gocloc.OpenCheckpoint(options.checkpoint, options.resume); err != nil {
 					return err
 				}
+				checkpoint.Interval = options.checkpointInterval
+				logger.Info("checkpoint opened", "path", options.checkpoint, "resume", options.resume, "restored", checkpoint.Restored())
+			}
 
-				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\nJSON exported to %s\n", outputPath)
-				return nil
+			// 分析选项来自命令行参数，因此每次执行都按当前参数构建扫描器。
+			codeScanner := gocloc.NewScanner(gocloc.Options{
+				Workers:             options.workers,
+				CountFunctions:      options.countFunctions,
+				Annotate:            options.annotate,
+				StringLiteralLines:  options.stringLines,
+				WhitespaceStats:     options.whitespace,
+				PythonDocstrings:    docstrings == "comment",
+				SQLDialect:          options.sqlDialect,
+				GoDirectives:        options.goDirectives,
+				Shebang:             options.shebang,
+				LogicalDirectives:   options.logical,
+				CgoPreamble:         options.cgoPreamble,
+				TrailingEmptyLine:   options.trailingEmpty,
+				DetectDuplicates:    options.duplicates,
+				DuplicateWindow:     options.duplicateLines,
+				ScriptStats:         options.scripts,
+				SizeDistribution:    options.distribution,
+				GitBlame:            options.gitBlame,
+				Top:                 options.top,
+				LanguageDefinitions: definitions,
+				Plugins:             plugins,
+				Logger:              logger,
+				Excludes:            excludes,
+				Languages:           options.languages,
+				DisabledLanguages:   options.disabled,
+				ExtensionOverrides:  overrides,
+				MmapThreshold:       mmapThreshold,
+				AdaptiveWorkers:     options.adaptive,
+				SummaryOnly:         options.summaryOnly,
+				UnsortedFiles:       options.unsorted,
+				ContentCache:        contentCache,
+				Gitattributes:       options.gitattributes,
+				Gitignore:           options.gitignore,
+				Checkpoint:          checkpoint,
+				MaxReadBytesPerSec:  options.maxReadRate,
+				IOConcurrency:       options.ioConcurrency,
+				MaxFileSize:         options.maxFileSize,
+				FileTimeout:         options.fileTimeout,
+				FollowLinks:         options.followLinks,
+				Packages:            options.packages,
+				CodeOwners:          options.codeOwners,
+				Labels:              labels,
+			})
+			var result model.ScanResult
+			switch {
+			case stdin:
+				result, err = codeScanner.ScanReader("-", options.stdinLanguage, cmd.InOrStdin())
+			case options.image != "":
+				result, err = scanImage(cmd, codeScanner, options.image, options.imagePlatform)
 			default:
-				return errors.New("unsupported format")
+				result, err = codeScanner.ScanPaths(args...)
+			}
+			if checkpoint != nil {
+				// 扫描失败时保留检查点，之后加 --resume 重新执行即可继续。
+				if err != nil {
+					return errors.Join(err, checkpoint.Close())
+				}
+				if err := checkpoint.Remove(); err != nil {
+					return err
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if contentCache != nil {
+				stats := contentCache.Stats()
+				logger.Info("content cache", "hits", stats.Hits, "misses", stats.Misses, "errors", stats.Errors)
 			}
+			return finishScan(cmd, reporter, options, result, trend)
 		},
 	}
 
-	scanCmd.Flags().StringVar(&options.format, "format", options.format, "输出格式: table 或 json")
-	scanCmd.Flags().StringVar(&options.output, "output", options.output, "json 导出文件路径，默认 output.json")
+	scanCmd.Flags().StringVar(&options.format, "format", options.format, reporterUsage())
+	scanCmd.Flags().StringArrayVar(&options.reporters, "reporter", nil, "注册外部输出格式插件，格式 NAME:COMMAND（可重复），之后可用 --format NAME 选择，协议见 README")
+	scanCmd.Flags().StringVar(&options.image, "image", "", "拉取并扫描容器镜像（如 registry.example.com/app:1.0），统计各层合并后文件系统中的源码；凭证取自 "+envRegistryUsername+" 与 "+envRegistryPassword)
+	scanCmd.Flags().StringVar(&options.imagePlatform, "image-platform", "", "多平台镜像中扫描的平台 OS/ARCH[/VARIANT]，默认为 linux 与当前 CPU 架构")
+	scanCmd.Flags().StringArrayVar(&options.history, "history", nil, "PDF 报告趋势图使用的历史结果（scan 导出的 JSON），按时间先后重复指定")
+	scanCmd.Flags().StringVar(&options.preset, "preset", "", scanPresetUsage())
+	scanCmd.Flags().StringVarP(&options.output, "output", "o", "", "同时把结果以 json 导出到该文件，以 .gz 结尾时 gzip 压缩；文档格式（如 pdf）且不以 .json/.gz 结尾时改为写入该格式的文档，支持 {date}、{time}、{ref}、{path-hash} 占位符；未指定时不写文件")
+	scanCmd.Flags().BoolVar(&options.noExport, "no-export", false, "不导出 json 文件，即使配置文件或环境变量中设置了 output")
 	scanCmd.Flags().IntVar(&options.workers, "workers", options.workers, "并发 worker 数量")
+	scanCmd.Flags().BoolVar(&options.adaptive, "adaptive-workers", false, "按 CPU 利用率（I/O 等待程度）在扫描中自动调整并发，--workers 作为上限")
+	scanCmd.Flags().IntVar(&options.top, "top", 0, "额外输出代码行数/总行数最多的前 N 个文件，0 表示不输出")
+	scanCmd.Flags().BoolVar(&options.countFunctions, "count-functions", false, "统计函数/方法定义数量（按语言规则启发式识别）")
+	scanCmd.Flags().BoolVar(&options.duplicates, "duplicates", false, "检测跨文件重复代码块")
+	scanCmd.Flags().IntVar(&options.duplicateLines, "duplicate-lines", scanner.DefaultDuplicateWindow, "判定重复代码块的最小连续代码行数")
+	scanCmd.Flags().BoolVar(&options.stringLines, "string-lines", false, "把只包含字符串字面量内容的行单独计入 string_literal，而非 code")
+	scanCmd.Flags().StringVar(&options.docstrings, "python-docstrings", options.docstrings, "Python 模块、类与函数体开头的 docstring 计为 code 或 comment")
+	scanCmd.Flags().StringVar(&options.sqlDialect, "sql-dialect", options.sqlDialect, "SQL 方言: auto（按语法特征判断）、ansi、mysql、postgres、tsql 或 oracle")
+	scanCmd.Flags().StringVar(&options.goDirectives, "go-directives", options.goDirectives, "Go 编译指令行（//go:build、//go:generate 等）计为 comment、directive（计入 preprocessor）或 code")
+	scanCmd.Flags().StringVar(&options.shebang, "shebang", options.shebang, "Python、Ruby、JavaScript、TypeScript 与自定义语言文件首行 shebang（#!）计为 comment、directive（计入 preprocessor）或 code")
+	scanCmd.Flags().BoolVar(&options.logical, "logical-directives", false, "C/C++ 以反斜杠续行的多行预处理指令只计为一条 preprocessor")
+	scanCmd.Flags().BoolVar(&options.cgoPreamble, "cgo-preamble", false, "把 Go 文件中紧邻 import \"C\" 的注释块（cgo 前导代码）按 C/C++ 统计，而非 Go 注释")
+	scanCmd.Flags().BoolVar(&options.trailingEmpty, "trailing-empty-line", false, "把文件末尾换行符之后的空内容计为一个空白行（与编辑器行号一致）；默认与 cloc、tokei 一致")
+	scanCmd.Flags().StringVar(&options.checkpoint, "checkpoint", "", "定期把已完成的文件结果写入该检查点文件，扫描成功后删除；中断后加 --resume 重新执行可跳过已完成的文件")
+	scanCmd.Flags().BoolVar(&options.resume, "resume", false, "从 --checkpoint 指定的检查点继续上次中断的扫描（检查点不存在时从头开始）")
+	scanCmd.Flags().DurationVar(&options.checkpointInterval, "checkpoint-interval", scanner.DefaultCheckpointInterval, "检查点两次写盘之间的最短间隔")
+	scanCmd.Flags().BoolVar(&options.gitattributes, "gitattributes", false, "按 .gitattributes 的 linguist-vendored/linguist-documentation 排除文件、linguist-generated 单独汇总、linguist-language 改变语言")
+	scanCmd.Flags().BoolVar(&options.gitignore, "gitignore", false, "跳过扫描目录（含子目录）中 .gitignore 忽略的文件与目录")
+	scanCmd.Flags().BoolVar(&options.packages, "packages", false, "按 go.mod、package.json、Cargo.toml、pom.xml、setup.py 所在目录划分包，输出每个包的文件数与行数")
+	scanCmd.Flags().StringVar(&options.codeOwners, "codeowners", "", "按 CODEOWNERS 文件为每个文件确定所有者并输出每个所有者的文件数与行数；只写 --codeowners 时在扫描路径下的 .github/、根目录与 docs/ 中查找")
+	scanCmd.Flags().Lookup("codeowners").NoOptDefVal = "auto"
+	scanCmd.Flags().BoolVar(&options.followLinks, "follow-links", false, "进入指向目录的符号链接与 Windows 目录联接（junction），指回已遍历目录的链接会被跳过以避免循环与重复统计")
+	scanCmd.Flags().BoolVar(&options.whitespace, "whitespace", false, "统计缩进风格（tab/空格）、最大缩进宽度与行尾空白行数")
+	scanCmd.Flags().BoolVar(&options.scripts, "scripts", false, "统计带 shebang 与可执行权限位的文件，并按解释器汇总")
+	scanCmd.Flags().BoolVar(&options.distribution, "distribution", false, "按语言统计单文件行数分布（p50/p90/max 与分桶直方图）")
+	scanCmd.Flags().StringVar(&options.languageDefs, "language-defs", "", "自定义语言定义文件（YAML 或 JSON），用于支持内置之外的语言")
+	scanCmd.Flags().StringArrayVar(&options.plugins, "plugin", nil, "外部分析器插件，格式 NAME:EXT[,EXT...]:COMMAND，可重复指定")
+	scanCmd.Flags().BoolVar(&options.failOnError, "fail-on-error", false, "存在扫描失败的文件时以非 0 状态退出（仍会输出部分结果）")
+	scanCmd.Flags().StringVar(&options.stdinLanguage, "language", "", "扫描标准输入（路径为 -）时内容所属的语言，不区分大小写")
+	scanCmd.Flags().StringArrayVar(&options.excludes, "exclude", nil, "排除匹配的路径（相对扫描路径，支持 **），与配置文件中的 exclude 合并，可重复指定")
+	scanCmd.Flags().StringArrayVar(&options.labels, "label", nil, "附加到扫描结果的标签 KEY=VALUE（如 team=payments），与配置文件中的 labels 合并，输出到 json、table、teamcity 与 prometheus 格式，可重复指定")
+	scanCmd.Flags().StringArrayVar(&options.languages, "include-language", nil, "只统计指定语言（不区分大小写），可重复指定")
+	scanCmd.Flags().StringArrayVar(&options.disabled, "disable-language", nil, "不统计指定语言（不区分大小写），可重复指定")
+	scanCmd.Flags().StringArrayVar(&options.extensionMap, "map-extension", nil, "把后缀映射到指定语言，格式 EXT=LANGUAGE（如 .inc=C/C++），可重复指定")
+	scanCmd.Flags().BoolVar(&options.daemon, "daemon", false, "把扫描请求交给已启动的 gocloc daemon，复用其缓存（路径按绝对路径发送）")
+	scanCmd.Flags().StringVar(&options.daemonSocket, "daemon-socket", defaultDaemonSocket(), "gocloc daemon 的 unix socket 路径")
+	scanCmd.Flags().BoolVar(&options.gitBlame, "git-blame", false, "通过 git blame 补充每个文件的作者数与最后修改时间")
+	scanCmd.Flags().BoolVar(&options.anonymize, "anonymize-paths", false, "把输出（含导出文件）中的路径替换为保留后缀的稳定哈希，便于对外分享结果而不泄露目录结构")
+	scanCmd.Flags().BoolVar(&options.mmap, "mmap", false, "通过内存映射读取超过 --mmap-threshold 的大文件（生成代码、SQL 导出等），不支持的平台回退到流式读取")
+	scanCmd.Flags().Int64Var(&options.mmapThreshold, "mmap-threshold", 64, "--mmap 生效的文件大小下限，单位 MiB")
+	scanCmd.Flags().Int64Var(&options.maxReadRate, "max-read-bytes-per-sec", 0, "所有 worker 合计的文件读取速率上限（字节/秒，令牌桶），避免定时扫描挤占共享存储，0 表示不限制")
+	scanCmd.Flags().IntVar(&options.ioConcurrency, "io-concurrency", 0, "同时进行中的文件读取数量上限，与 --workers 独立（分析仍按 --workers 并行），0 表示不限制")
+	scanCmd.Flags().Int64Var(&options.maxFileSize, "max-file-size", 0, "超过该字节数的文件不读取，记为 oversize 类错误，0 表示不限制")
+	scanCmd.Flags().DurationVar(&options.fileTimeout, "file-timeout", 0, "单个文件的分析时间上限（如 5s），超时的文件记为 timeout 类错误，0 表示不限制")
+	scanCmd.Flags().StringVar(&options.contentCache, "content-cache", "", "按文件内容哈希缓存分析结果的位置：本地目录、http(s):// 地址（GET/PUT）或 s3://bucket/prefix，CI 中重复扫描只分析变化的内容")
+	scanCmd.Flags().BoolVar(&options.summaryOnly, "summary-only", false, "只输出语言汇总与总计，不保留文件明细，降低超大仓库扫描的内存占用")
+	scanCmd.Flags().BoolVar(&options.unsorted, "unsorted", false, "文件与错误保持分析完成的顺序，不按路径排序，缩短百万级文件扫描的汇总阶段（只对单个扫描路径生效）")
+	scanCmd.Flags().BoolVar(&options.annotate, "annotate", false, "在 JSON 输出中附带每个文件的逐行分类（code/comment/blank/mixed）")
 
 	return scanCmd
 }
+
+// checkSummaryOnly 拒绝与 --summary-only 同时使用的参数，它们都依赖文件级明细。
+func checkSummaryOnly(options scanOptions) error {
+	if !options.summaryOnly {
+		return nil
+	}
+	conflicts := []struct {
+		flag string
+		set  bool
+	}{
+		{"duplicates", options.duplicates},
+		{"scripts", options.scripts},
+		{"top", options.top > 0},
+		{"annotate", options.annotate},
+		{"git-blame", options.gitBlame},
+	}
+	for _, conflict := range conflicts {
+		if conflict.set {
+			return fmt.Errorf("--summary-only cannot be combined with --%s", conflict.flag)
+		}
+	}
+	return nil
+}
+
+// finishScan 输出扫描结果（设置了 --anonymize-paths 时先匿名化路径），并在设置了 --fail-on-error 且存在失败文件时返回错误。
+// trend 为 PDF 报告趋势图使用的历史结果。
+func finishScan(cmd *cobra.Command, reporter report.Reporter, options scanOptions, result model.ScanResult, trend []report.TrendPoint) error {
+	if options.anonymize {
+		result = result.Anonymized()
+	}
+	if pdf, ok := reporter.(report.PDFReporter); ok {
+		pdf.Options = report.PDFOptions{Generated: time.Now(), Top: options.top, History: trend}
+		reporter = pdf
+	}
+	if err := writeResult(cmd, reporter, options.output, result); err != nil {
+		return err
+	}
+	// 先输出部分结果再报错，CI 既能看到报告，也不会因不可读文件而静默少算。
+	if options.failOnError && len(result.Errors) > 0 {
+		return fmt.Errorf("%d file(s) failed to scan", len(result.Errors))
+	}
+	return nil
+}
+
+// loadTrend 按给定顺序读取 --history 指定的历史结果，标签为去掉 .json/.gz 后缀的文件名
+// （例如 --output 'loc-{date}.json' 导出的 loc-2024-05-01）。
+func loadTrend(paths []string) ([]report.TrendPoint, error) {
+	trend := make([]report.TrendPoint, 0, len(paths))
+	for _, path := range paths {
+		result, err := report.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		label := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".gz"), ".json")
+		trend = append(trend, report.TrendPoint{Label: label, Total: result.Total})
+	}
+	return trend, nil
+}
+
+// scanWithDaemon 把扫描请求转发给 daemon；未显式指定 --workers 时使用 daemon 的默认值。
+func scanWithDaemon(cmd *cobra.Command, options scanOptions, args []string, excludes []string, overrides map[string]string, labels map[string]string) (model.ScanResult, error) {
+	paths := make([]string, 0, len(args))
+	for _, arg := range args {
+		path, err := filepath.Abs(arg)
+		if err != nil {
+			return model.ScanResult{}, fmt.Errorf("resolve absolute path: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	request := server.DaemonScanRequest{
+		Paths: paths,
+		Options: server.ScanOptions{
+			CountFunctions:    options.countFunctions,
+			Annotate:          options.annotate,
+			StringLines:       options.stringLines,
+			Whitespace:        options.whitespace,
+			PythonDocstrings:  strings.EqualFold(strings.TrimSpace(options.docstrings), "comment"),
+			SQLDialect:        options.sqlDialect,
+			GoDirectives:      options.goDirectives,
+			Shebang:           options.shebang,
+			LogicalDirectives: options.logical,
+			CgoPreamble:       options.cgoPreamble,
+			TrailingEmptyLine: options.trailingEmpty,
+			Duplicates:        options.duplicates,
+			DuplicateLines:    options.duplicateLines,
+			Scripts:           options.scripts,
+			SizeDistribution:  options.distribution,
+			GitBlame:          options.gitBlame,
+			Top:               options.top,
+			SummaryOnly:       options.summaryOnly,
+			Unsorted:          options.unsorted,
+			Gitattributes:     options.gitattributes,
+			Gitignore:         options.gitignore,
+			Packages:          options.packages,
+			DisabledLanguages: options.disabled,
+			ExtensionMap:      overrides,
+			Languages:         options.languages,
+			Excludes:          excludes,
+			Labels:            labels,
+		},
+	}
+	if cmd.Flags().Changed("workers") {
+		request.Options.Workers = options.workers
+	}
+	return server.ScanWithDaemon(cmd.Context(), options.daemonSocket, request)
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/config"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+	"github.com/zhizhixiongxuwei/gocloc/internal/vcs"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+
+	"github.com/spf13/cobra"
+)
+
+// scanManyOptions 存放 scan-many 命令的可配置参数。
+type scanManyOptions struct {
+	format    string
+	output    string
+	workers   int
+	parallel  int
+	excludes  []string
+	languages []string
+	disabled  []string
+	labels    []string
+}
+
+// newScanManyCmd 创建 scan-many 子命令。
+// 命令按清单扫描多个本地目录或远程仓库，输出每个仓库的结果与全部仓库的汇总，例如：
+//
+//	gocloc scan-many repos.yaml --parallel 4 -o org.json
+func newScanManyCmd() *cobra.Command {
+	options := scanManyOptions{
+		format:   "table",
+		workers:  runtime.NumCPU(),
+		parallel: 1,
+	}
+
+	scanManyCmd := &cobra.Command{
+		Use:   "scan-many <manifest.yaml>",
+		Short: "按清单批量扫描多个仓库，输出各仓库结果与组织级汇总",
+		Long: "按清单批量扫描多个仓库，输出各仓库结果与组织级汇总。\n" +
+			"清单中的仓库可以是本地目录（path）或远程地址（git_url，扫描前浅克隆默认分支），每个仓库需要唯一的 label。\n" +
+			"单个仓库失败不影响其他仓库，所有仓库处理完并输出结果后，存在失败的仓库时以非 0 状态退出。",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manifest, err := config.LoadManifest(args[0])
+			if err != nil {
+				return err
+			}
+			loaded, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			configInt(cmd, "workers", &options.workers, loaded.Workers)
+			configTableFormat(cmd, &options.format, loaded.Format)
+			configString(cmd, "output", &options.output, loaded.Output)
+			configStrings(cmd, "include-language", &options.languages, loaded.Languages)
+			configStrings(cmd, "disable-language", &options.disabled, loaded.DisabledLanguages)
+
+			format := strings.ToLower(strings.TrimSpace(options.format))
+			if format != "table" && format != "json" {
+				return errors.New("unsupported format, allowed values: table, json")
+			}
+			if options.workers <= 0 {
+				return errors.New("workers must be greater than 0")
+			}
+			if options.parallel <= 0 {
+				return errors.New("parallel must be greater than 0")
+			}
+
+			labels, err := mergeLabels(loaded.Labels, options.labels)
+			if err != nil {
+				return err
+			}
+			logger, err := commandLogger(cmd)
+			if err != nil {
+				return err
+			}
+			scanner := gocloc.NewScanner(gocloc.Options{
+				Workers:           options.workers,
+				Excludes:          append(append([]string(nil), loaded.Exclude...), options.excludes...),
+				Languages:         options.languages,
+				DisabledLanguages: options.disabled,
+				Labels:            labels,
+				Logger:            logger,
+			})
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+
+			results := make([]model.RepositoryResult, len(manifest.Repositories))
+			semaphore := make(chan struct{}, options.parallel)
+			var group sync.WaitGroup
+			for index, repository := range manifest.Repositories {
+				group.Add(1)
+				semaphore <- struct{}{}
+				go func() {
+					defer group.Done()
+					defer func() { <-semaphore }()
+					results[index] = scanRepository(cmd.Context(), scanner, repository)
+					if results[index].Error != "" {
+						logger.Warn("repository failed", "label", repository.Label, "error", results[index].Error)
+					}
+				}()
+			}
+			group.Wait()
+
+			batch := model.NewBatchResult(results)
+			batch.Aggregate.AddLabels(labels)
+			if format == "json" {
+				err = report.PrintBatchJSON(cmd.OutOrStdout(), batch)
+			} else {
+				err = report.PrintBatchTable(cmd.OutOrStdout(), batch)
+			}
+			if err != nil {
+				return err
+			}
+			outputPath, err := expandOutput(options.output, args[0])
+			if err != nil {
+				return err
+			}
+			if outputPath != "" {
+				if err := report.Save(outputPath, batch.Aggregate); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\nAggregate JSON exported to %s\n", outputPath)
+			}
+
+			failed := 0
+			for _, item := range batch.Repositories {
+				if item.Error != "" {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d repository(s) failed to scan", failed)
+			}
+			return nil
+		},
+	}
+
+	scanManyCmd.Flags().StringVar(&options.format, "format", options.format, "输出格式: table 或 json")
+	scanManyCmd.Flags().StringVarP(&options.output, "output", "o", "", "把组织级汇总结果以 json 导出到该文件（可作为 merge/diff 的输入），以 .gz 结尾时 gzip 压缩，支持 {date}、{time}、{ref}、{path-hash} 占位符")
+	scanManyCmd.Flags().IntVar(&options.workers, "workers", options.workers, "每个仓库扫描的并发 worker 数量")
+	scanManyCmd.Flags().IntVar(&options.parallel, "parallel", options.parallel, "同时扫描的仓库数量")
+	scanManyCmd.Flags().StringArrayVar(&options.excludes, "exclude", nil, "排除匹配的路径（相对各仓库根目录，支持 **），与配置文件中的 exclude 合并，可重复指定")
+	scanManyCmd.Flags().StringArrayVar(&options.languages, "include-language", nil, "只统计指定语言（不区分大小写），可重复指定")
+	scanManyCmd.Flags().StringArrayVar(&options.disabled, "disable-language", nil, "不统计指定语言（不区分大小写），可重复指定")
+	scanManyCmd.Flags().StringArrayVar(&options.labels, "label", nil, "附加到各仓库结果与汇总的标签 KEY=VALUE，清单中仓库的 labels 覆盖同名的键，可重复指定")
+
+	return scanManyCmd
+}
+
+// scanRepository 扫描清单中的一个仓库，失败时把原因记录在结果中而不是中断整个批次。
+// 远程仓库浅克隆到临时目录后扫描，结果的 scanned_path 为其地址；清单中仓库的标签附加到结果上。
+func scanRepository(ctx context.Context, scanner *gocloc.Scanner, repository config.Repository) model.RepositoryResult {
+	item := model.RepositoryResult{Label: repository.Label, Source: repository.Source()}
+	if repository.GitURL == "" {
+		result, err := scanner.ScanContext(ctx, repository.Path)
+		if err != nil {
+			item.Error = err.Error()
+			return item
+		}
+		result.AddLabels(repository.Labels)
+		item.Result = result
+		return item
+	}
+
+	directory, err := os.MkdirTemp("", "gocloc-clone-")
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	defer os.RemoveAll(directory)
+
+	if err := vcs.Clone(ctx, repository.GitURL, directory); err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	result, err := scanner.ScanContext(ctx, directory)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	result.ScannedPath = repository.GitURL
+	result.AddLabels(repository.Labels)
+	item.Result = result
+	return item
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+
+	"github.com/spf13/cobra"
+)
+
+// resultCommand 返回带 --quiet 参数、输出写入缓冲区的命令，供 writeResult 使用。
+func resultCommand(t *testing.T, quiet bool) (*cobra.Command, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	cmd := &cobra.Command{}
+	cmd.Flags().BoolP("quiet", "q", quiet, "")
+	var stdout, stderr bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	return cmd, &stdout, &stderr
+}
+
+func lookupReporter(t *testing.T, name string) report.Reporter {
+	t.Helper()
+	reporter, ok := report.NewRegistry().Lookup(name)
+	if !ok {
+		t.Fatalf("reporter %s not registered", name)
+	}
+	return reporter
+}
+
+// TestWriteResultDocumentOutput 验证文档格式的 -o：非 JSON 路径写入所选格式的文档，.json 路径仍导出 JSON，
+// 静默模式下不输出导出提示。
+func TestWriteResultDocumentOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	result := model.ScanResult{ScannedPath: "."}
+	pdf := lookupReporter(t, "pdf")
+
+	cmd, stdout, stderr := resultCommand(t, false)
+	path := filepath.Join(tempDir, "out", "report.pdf")
+	if err := writeResult(cmd, pdf, path, result); err != nil {
+		t.Fatalf("write result: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || !bytes.HasPrefix(content, []byte("%PDF-")) {
+		t.Fatalf("expected PDF document in %s, got %.20q (%v)", path, content, err)
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "PDF exported to "+path) {
+		t.Fatalf("stdout = %d bytes, stderr = %q", stdout.Len(), stderr.String())
+	}
+
+	cmd, stdout, stderr = resultCommand(t, false)
+	path = filepath.Join(tempDir, "result.json")
+	if err := writeResult(cmd, pdf, path, result); err != nil {
+		t.Fatalf("write result: %v", err)
+	}
+	if _, err := report.Load(path); err != nil {
+		t.Fatalf("expected JSON export: %v", err)
+	}
+	if !bytes.HasPrefix(stdout.Bytes(), []byte("%PDF-")) || !strings.Contains(stderr.String(), "JSON exported to") {
+		t.Fatalf("stdout = %.20q, stderr = %q", stdout.Bytes(), stderr.String())
+	}
+
+	cmd, _, stderr = resultCommand(t, true)
+	if err := writeResult(cmd, pdf, filepath.Join(tempDir, "quiet.pdf"), result); err != nil {
+		t.Fatalf("write result: %v", err)
+	}
+	if err := writeResult(cmd, pdf, filepath.Join(tempDir, "quiet.json"), result); err != nil {
+		t.Fatalf("write result: %v", err)
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("expected no notice in quiet mode, got %q", stderr.String())
+	}
+}
+
+// TestScanFailOnError 验证存在读取失败的文件（此处为指向不存在文件的符号链接）时：
+// 默认仍正常结束（退出码为 0），设置 --fail-on-error 后返回错误（main 以非 0 状态退出），两种情况都输出部分结果。
+func TestScanFailOnError(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(tempDir, "missing.go"), filepath.Join(tempDir, "broken.go")); err != nil {
+		t.Skipf("symlink not supported: %v", err)
+	}
+	run := func(args ...string) (string, error) {
+		cmd := newRootCmd("test", languages.NewRegistry())
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"scan", tempDir, "--format", "json"}, args...))
+		err := cmd.Execute()
+		return stdout.String(), err
+	}
+
+	output, err := run()
+	if err != nil || !strings.Contains(output, "broken.go") || !strings.Contains(output, "main.go") {
+		t.Fatalf("expected success with partial result: %v\n%s", err, output)
+	}
+	output, err = run("--fail-on-error")
+	if err == nil || !strings.Contains(err.Error(), "1 file(s) failed to scan") {
+		t.Fatalf("expected fail-on-error failure, got %v", err)
+	}
+	if !strings.Contains(output, "broken.go") || !strings.Contains(output, "main.go") {
+		t.Fatalf("expected partial result before failure:\n%s", output)
+	}
+}
+
+// TestScanWithoutOutput 验证未指定 -o 时（包括 JSON 格式）扫描不在工作目录中生成文件，
+// 指定 -o 但设置 --no-export 时同样不导出。
+func TestScanWithoutOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	workDir := t.TempDir()
+	t.Chdir(workDir)
+
+	for _, args := range [][]string{
+		{"--format", "json"},
+		{"--format", "table"},
+		{"--format", "json", "-o", "result.json", "--no-export"},
+	} {
+		cmd := newRootCmd("test", languages.NewRegistry())
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"scan", tempDir}, args...))
+		if err := cmd.Execute(); err != nil || !strings.Contains(stdout.String(), "main.go") {
+			t.Fatalf("scan %v: %v\n%s", args, err, stdout.String())
+		}
+		entries, err := os.ReadDir(workDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("scan %v created %s in the working directory", args, entries[0].Name())
+		}
+	}
+}
+
+// TestScanQuiet 验证 -q 时进程标准输出为空、不输出导出提示，-o 仍写入导出文件；不加 -q 时同样的参数会输出结果。
+func TestScanQuiet(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(output string, args ...string) (string, string) {
+		// 不调用 SetOut，结果写到进程的标准输出，这里临时替换为文件以捕获直接写 os.Stdout 的输出。
+		capture, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer capture.Close()
+		stdout := os.Stdout
+		os.Stdout = capture
+		defer func() { os.Stdout = stdout }()
+
+		cmd := newRootCmd("test", languages.NewRegistry())
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+		cmd.SetArgs(append([]string{"scan", tempDir, "-o", output}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("scan %v: %v", args, err)
+		}
+		content, err := os.ReadFile(capture.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content), stderr.String()
+	}
+
+	output := filepath.Join(tempDir, "out", "loud.json")
+	if stdout, stderr := run(output); !strings.Contains(stdout, "main.go") || !strings.Contains(stdout+stderr, "JSON exported to") {
+		t.Fatalf("expected output without -q: stdout=%q stderr=%q", stdout, stderr)
+	}
+	for _, args := range [][]string{{"-q"}, {"--quiet", "--format", "json"}} {
+		output := filepath.Join(tempDir, "out", "quiet.json")
+		stdout, stderr := run(output, args...)
+		if stdout != "" || stderr != "" {
+			t.Fatalf("expected no output with %v: stdout=%q stderr=%q", args, stdout, stderr)
+		}
+		result, err := report.Load(output)
+		if err != nil || result.Total.Files != 1 {
+			t.Fatalf("expected exported result with %v: %+v (%v)", args, result.Total, err)
+		}
+		if err := os.Remove(output); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestScanConfigFormat 验证配置文件中的 format 在合并命令行参数后按输出格式注册中心校验：
+// 注册中心中的格式被接受，未知格式报错，命令行参数优先于配置文件。
+func TestScanConfigFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(format string, args ...string) (string, error) {
+		config := filepath.Join(t.TempDir(), ".gocloc.yaml")
+		if err := os.WriteFile(config, []byte("format: "+format+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cmd := newRootCmd("test", languages.NewRegistry())
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"scan", tempDir, "--config", config}, args...))
+		err := cmd.Execute()
+		return stdout.String(), err
+	}
+
+	if output, err := run("folded"); err != nil || !strings.Contains(output, "main.go") {
+		t.Fatalf("folded from config: %q (%v)", output, err)
+	}
+	if _, err := run("xml"); err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Fatalf("expected unsupported format error, got %v", err)
+	}
+	if output, err := run("xml", "--format", "json"); err != nil || !strings.Contains(output, `"schema_version"`) {
+		t.Fatalf("flag should override config format: %q (%v)", output, err)
+	}
+}
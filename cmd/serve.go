@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/server"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+
+	"github.com/spf13/cobra"
+)
+
+// shutdownTimeout 是收到退出信号后等待进行中请求完成的最长时间。
+const shutdownTimeout = 30 * time.Second
+
+// serveOptions 存放 serve 命令的可配置参数。
+type serveOptions struct {
+	httpAddress string
+	grpcAddress string
+	root        string
+	workers     int
+}
+
+// newServeCmd 创建 serve 子命令。
+// 命令示例：gocloc serve --http :8080 --grpc :9090 --root /srv/repos
+func newServeCmd() *cobra.Command {
+	options := serveOptions{
+		httpAddress: ":8080",
+		root:        ".",
+		workers:     runtime.NumCPU(),
+	}
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "以服务方式提供扫描能力（HTTP REST 与 gRPC）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			httpAddress := strings.TrimSpace(options.httpAddress)
+			grpcAddress := strings.TrimSpace(options.grpcAddress)
+			if httpAddress == "" && grpcAddress == "" {
+				return errors.New("no listener configured, use --http or --grpc to set an address")
+			}
+
+			logger, err := commandLogger(cmd)
+			if err != nil {
+				return err
+			}
+
+			config := server.Config{
+				Root:    options.root,
+				Workers: options.workers,
+				Logger:  logger,
+				Metrics: gocloc.NewExpvarMetrics("gocloc"),
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// 任一服务退出（包括启动失败）都会触发整体退出，errs 收集各服务的退出原因。
+			errs := make(chan error, 2)
+			running := 0
+
+			if httpAddress != "" {
+				listener, err := net.Listen("tcp", httpAddress)
+				if err != nil {
+					return fmt.Errorf("listen http: %w", err)
+				}
+				httpServer := &http.Server{Handler: server.NewHTTPHandler(config), ReadHeaderTimeout: 10 * time.Second}
+				running++
+				go func() {
+					err := httpServer.Serve(listener)
+					if errors.Is(err, http.ErrServerClosed) {
+						err = nil
+					}
+					errs <- err
+				}()
+				go func() {
+					<-ctx.Done()
+					shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+					defer cancel()
+					_ = httpServer.Shutdown(shutdownCtx)
+				}()
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "HTTP server listening on %s\n", listener.Addr())
+			}
+
+			if grpcAddress != "" {
+				listener, err := net.Listen("tcp", grpcAddress)
+				if err != nil {
+					stop()
+					return fmt.Errorf("listen grpc: %w", err)
+				}
+				grpcServer := server.NewGRPCServer(config)
+				running++
+				go func() {
+					errs <- grpcServer.Serve(listener)
+				}()
+				go func() {
+					// 收到退出信号后等待进行中的请求完成再退出。
+					<-ctx.Done()
+					grpcServer.GracefulStop()
+				}()
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "gRPC server listening on %s\n", listener.Addr())
+			}
+
+			var firstErr error
+			for ; running > 0; running-- {
+				if err := <-errs; err != nil && firstErr == nil {
+					firstErr = err
+				}
+				stop()
+			}
+			return firstErr
+		},
+	}
+
+	serveCmd.Flags().StringVar(&options.httpAddress, "http", options.httpAddress, "HTTP 监听地址，传空字符串关闭 HTTP 服务")
+	serveCmd.Flags().StringVar(&options.grpcAddress, "grpc", "", "gRPC 监听地址，例如 :9090，默认不开启")
+	serveCmd.Flags().StringVar(&options.root, "root", options.root, "允许扫描的根目录，请求路径按它解析且不能越出")
+	serveCmd.Flags().IntVar(&options.workers, "workers", options.workers, "每次扫描默认的并发 worker 数量")
+
+	return serveCmd
+}
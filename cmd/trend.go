@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/history"
+
+	"github.com/spf13/cobra"
+)
+
+// trendBarWidth 为 trend 表格中最长条形的字符数。
+const trendBarWidth = 40
+
+// trendOptions 存放 trend 命令的可配置参数。
+type trendOptions struct {
+	database string
+	language string
+	labels   []string
+	ref      string
+	since    string
+	until    string
+	format   string
+}
+
+// newTrendCmd 创建 trend 子命令。
+// 命令从历史数据库中查询代码行的趋势，例如：gocloc trend --db metrics.db --language Go
+func newTrendCmd() *cobra.Command {
+	options := trendOptions{format: "table"}
+
+	trendCmd := &cobra.Command{
+		Use:   "trend",
+		Short: "查询 record 写入的历史数据库，输出代码行趋势",
+		Long: "查询 record 写入的历史数据库，按时间输出总计或单个语言的趋势。\n" +
+			"table 格式每条记录一行，包含相对上一条的代码行变化与按代码行缩放的条形图；json 格式输出数据点数组，可交给其它工具绘图。\n" +
+			"--since 与 --until 接受 RFC 3339 时间或 YYYY-MM-DD 日期（UTC，--until 的日期包含当天）。",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(options.database) == "" {
+				return errors.New("--db is required")
+			}
+			store, err := history.Open(options.database)
+			if err != nil {
+				return err
+			}
+			format := strings.ToLower(strings.TrimSpace(options.format))
+			if format != "table" && format != "json" {
+				return errors.New("unsupported format, allowed values: table, json")
+			}
+			labels, err := parseLabels(options.labels)
+			if err != nil {
+				return err
+			}
+			filter := history.Filter{Language: strings.TrimSpace(options.language), Labels: labels, Ref: strings.TrimSpace(options.ref)}
+			if filter.Since, err = parseTrendTime(options.since, false); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			if filter.Until, err = parseTrendTime(options.until, true); err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+
+			entries, err := store.Load()
+			if err != nil {
+				return err
+			}
+			points := history.Trend(entries, filter)
+			if format == "json" {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(struct {
+					Language string          `json:"language,omitempty"`
+					Points   []history.Point `json:"points"`
+				}{Language: filter.Language, Points: points})
+			}
+			return writeTrendTable(cmd, points)
+		},
+	}
+
+	trendCmd.Flags().StringVar(&options.database, "db", "", "record 写入的历史数据库（SQLite 或 JSON Lines 文件）")
+	trendCmd.Flags().StringVar(&options.language, "language", "", "只查询指定语言（不区分大小写），未指定时查询总计")
+	trendCmd.Flags().StringArrayVar(&options.labels, "label", nil, "只查询带有标签 KEY=VALUE 的记录，可重复指定（需全部满足）")
+	trendCmd.Flags().StringVar(&options.ref, "ref", "", "只查询指定版本引用的记录")
+	trendCmd.Flags().StringVar(&options.since, "since", "", "只查询该时间及之后的记录（RFC 3339 或 YYYY-MM-DD）")
+	trendCmd.Flags().StringVar(&options.until, "until", "", "只查询该时间及之前的记录（RFC 3339 或 YYYY-MM-DD）")
+	trendCmd.Flags().StringVar(&options.format, "format", options.format, "输出格式: table 或 json")
+
+	return trendCmd
+}
+
+// parseTrendTime 解析 --since/--until，空值返回零值；endOfDay 为 true 时日期取当天最后一刻。
+func parseTrendTime(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither RFC 3339 nor YYYY-MM-DD", value)
+	}
+	if endOfDay {
+		parsed = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+	return parsed, nil
+}
+
+// writeTrendTable 以表格输出趋势，条形按最大代码行缩放到 trendBarWidth 个字符。
+func writeTrendTable(cmd *cobra.Command, points []history.Point) error {
+	if len(points) == 0 {
+		_, err := fmt.Fprintln(cmd.OutOrStdout(), "no matching records")
+		return err
+	}
+	var largest int64
+	for _, point := range points {
+		largest = max(largest, point.Counts.Code)
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(writer, "TIME\tREF\tLABELS\tFILES\tCODE\tCOMMENT\tBLANK\tCHANGE\tTREND"); err != nil {
+		return err
+	}
+	for index, point := range points {
+		change := "-"
+		if index > 0 {
+			change = fmt.Sprintf("%+d", point.Counts.Code-points[index-1].Counts.Code)
+		}
+		bar := ""
+		if largest > 0 {
+			bar = strings.Repeat("#", int(point.Counts.Code*trendBarWidth/largest))
+		}
+		if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			point.Time.UTC().Format(time.RFC3339), displayRef(point.Ref), formatLabels(point.Labels),
+			point.Counts.Files, point.Counts.Code, point.Counts.Comment, point.Counts.Blank, change, bar); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
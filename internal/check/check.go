@@ -0,0 +1,100 @@
+// Package check 根据预算（budget）检查扫描结果，供 CI 拦截超大文件、代码量超标或注释不足等问题。
+package check
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// 预算规则名称，用于 Violation.Rule。
+const (
+	RuleMaxFileLines      = "max-file-lines"
+	RuleMaxTotalCode      = "max-total-code"
+	RuleMinCommentDensity = "min-comment-density"
+)
+
+// Budgets 描述检查预算，取值为 0 的项表示不检查。
+type Budgets struct {
+	// MaxFileLines 为单文件总行数上限。
+	MaxFileLines int64 `json:"max_file_lines,omitempty" yaml:"max_file_lines"`
+	// MaxTotalCode 为项目代码行总数上限。
+	MaxTotalCode int64 `json:"max_total_code,omitempty" yaml:"max_total_code"`
+	// MinCommentDensity 为项目注释密度（comment/code）下限。
+	MinCommentDensity float64 `json:"min_comment_density,omitempty" yaml:"min_comment_density"`
+}
+
+// Empty 判断是否没有设置任何预算。
+func (b Budgets) Empty() bool {
+	return b.MaxFileLines <= 0 && b.MaxTotalCode <= 0 && b.MinCommentDensity <= 0
+}
+
+// Violation 表示一条超出预算的记录。Path 与 Language 均为空表示项目级（或策略规则范围级）规则，
+// Language 只用于按语言检查的规则（如 max-growth）。
+// ID 为策略文件中的规则 ID，来自命令行参数或配置文件的预算没有 ID。
+type Violation struct {
+	ID       string `json:"id,omitempty"`
+	Rule     string `json:"rule"`
+	Path     string `json:"path,omitempty"`
+	Language string `json:"language,omitempty"`
+	Actual   string `json:"actual"`
+	Limit    string `json:"limit"`
+	Message  string `json:"message"`
+}
+
+// Evaluate 按预算检查扫描结果，返回的违规记录按规则、路径排序（均没有 ID）。
+func Evaluate(result model.ScanResult, budgets Budgets) []Violation {
+	violations := make([]Violation, 0)
+
+	if budgets.MaxFileLines > 0 {
+		for _, item := range result.Files {
+			if item.Metrics.Total > budgets.MaxFileLines {
+				violations = append(violations, Violation{
+					Rule:    RuleMaxFileLines,
+					Path:    item.Path,
+					Actual:  fmt.Sprintf("%d", item.Metrics.Total),
+					Limit:   fmt.Sprintf("%d", budgets.MaxFileLines),
+					Message: fmt.Sprintf("file has %d lines, budget is %d", item.Metrics.Total, budgets.MaxFileLines),
+				})
+			}
+		}
+	}
+
+	if budgets.MaxTotalCode > 0 && result.Total.Code > budgets.MaxTotalCode {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxTotalCode,
+			Actual:  fmt.Sprintf("%d", result.Total.Code),
+			Limit:   fmt.Sprintf("%d", budgets.MaxTotalCode),
+			Message: fmt.Sprintf("project has %d code lines, budget is %d", result.Total.Code, budgets.MaxTotalCode),
+		})
+	}
+
+	if budgets.MinCommentDensity > 0 && result.Total.Ratios.CommentDensity < budgets.MinCommentDensity {
+		violations = append(violations, Violation{
+			Rule:    RuleMinCommentDensity,
+			Actual:  fmt.Sprintf("%.3f", result.Total.Ratios.CommentDensity),
+			Limit:   fmt.Sprintf("%.3f", budgets.MinCommentDensity),
+			Message: fmt.Sprintf("comment density is %.3f, minimum is %.3f", result.Total.Ratios.CommentDensity, budgets.MinCommentDensity),
+		})
+	}
+
+	sortViolations(violations)
+	return violations
+}
+
+// sortViolations 按 ID、规则名称、路径、语言排序违规记录。
+func sortViolations(violations []Violation) {
+	sort.SliceStable(violations, func(i int, j int) bool {
+		if violations[i].ID != violations[j].ID {
+			return violations[i].ID < violations[j].ID
+		}
+		if violations[i].Rule != violations[j].Rule {
+			return violations[i].Rule < violations[j].Rule
+		}
+		if violations[i].Path != violations[j].Path {
+			return violations[i].Path < violations[j].Path
+		}
+		return violations[i].Language < violations[j].Language
+	})
+}
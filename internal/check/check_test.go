@@ -0,0 +1,150 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// TestEvaluate 验证各预算规则的违规判定与排序。
+func TestEvaluate(t *testing.T) {
+	result := model.ScanResult{Files: []model.FileMetrics{
+		{Path: "b.go", Language: "Go", Metrics: model.LineMetrics{Total: 120, Code: 100, Comment: 5}},
+		{Path: "a.go", Language: "Go", Metrics: model.LineMetrics{Total: 80, Code: 70, Comment: 5}},
+		{Path: "c.go", Language: "Go", Metrics: model.LineMetrics{Total: 10, Code: 10}},
+	}}
+	result.Summarize(model.SummaryOptions{})
+
+	violations := Evaluate(result, Budgets{MaxFileLines: 50, MaxTotalCode: 150, MinCommentDensity: 0.1})
+	if len(violations) != 4 {
+		t.Fatalf("unexpected violations: %+v", violations)
+	}
+	if violations[0].Rule != RuleMaxFileLines || violations[0].Path != "a.go" || violations[1].Path != "b.go" {
+		t.Fatalf("unexpected file violations: %+v", violations[:2])
+	}
+	if violations[2].Rule != RuleMaxTotalCode || violations[2].Actual != "180" || violations[3].Rule != RuleMinCommentDensity {
+		t.Fatalf("unexpected project violations: %+v", violations[2:])
+	}
+
+	if violations := Evaluate(result, Budgets{MaxFileLines: 200}); len(violations) != 0 {
+		t.Fatalf("expected no violations: %+v", violations)
+	}
+}
+
+// TestEvaluatePolicy 验证策略规则按路径与语言限定范围、相对基线计算增长，并在违规记录中带上规则 ID。
+func TestEvaluatePolicy(t *testing.T) {
+	result := model.ScanResult{Files: []model.FileMetrics{
+		{Path: "internal/core/big.go", Language: "Go", Metrics: model.LineMetrics{Total: 900, Code: 800, Comment: 10}},
+		{Path: "internal/core/util.py", Language: "Python", Metrics: model.LineMetrics{Total: 900, Code: 900}},
+		{Path: "cmd/main.go", Language: "Go", Metrics: model.LineMetrics{Total: 50, Code: 40, Comment: 10}},
+	}}
+	result.Summarize(model.SummaryOptions{})
+	baseline := model.ScanResult{Files: []model.FileMetrics{
+		{Path: "internal/core/big.go", Language: "Go", Metrics: model.LineMetrics{Total: 600, Code: 500}},
+		{Path: "cmd/main.go", Language: "Go", Metrics: model.LineMetrics{Total: 50, Code: 40}},
+	}}
+	baseline.Summarize(model.SummaryOptions{})
+
+	policy := Policy{Rules: []PolicyRule{
+		{ID: "go-size", Languages: []string{"go"}, MaxFileLines: 500},
+		{ID: "core", Paths: []string{"internal/core"}, Languages: []string{"Go"}, MaxTotalGrowth: 100, MinCommentDensity: 0.1},
+		{ID: "cmd", Paths: []string{"cmd/**"}, MaxTotalGrowth: 10, MinCommentDensity: 0.1},
+	}}
+	violations := EvaluatePolicy(result, &baseline, policy)
+	if len(violations) != 3 {
+		t.Fatalf("unexpected violations: %+v", violations)
+	}
+	if violations[0].ID != "core" || violations[0].Rule != RuleMaxTotalGrowth || violations[0].Actual != "+300" {
+		t.Fatalf("unexpected growth violation: %+v", violations[0])
+	}
+	if violations[1].ID != "core" || violations[1].Rule != RuleMinCommentDensity {
+		t.Fatalf("unexpected density violation: %+v", violations[1])
+	}
+	if violations[2].ID != "go-size" || violations[2].Path != "internal/core/big.go" {
+		t.Fatalf("unexpected file violation: %+v", violations[2])
+	}
+
+	if violations := EvaluatePolicy(result, nil, Policy{Rules: policy.Rules[1:2]}); len(violations) != 1 || violations[0].Rule != RuleMinCommentDensity {
+		t.Fatalf("expected growth check skipped without baseline: %+v", violations)
+	}
+}
+
+// TestLoadPolicy 验证策略文件的解析与校验。
+func TestLoadPolicy(t *testing.T) {
+	directory := t.TempDir()
+	write := func(content string) string {
+		policyPath := filepath.Join(directory, PolicyFileName)
+		if err := os.WriteFile(policyPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("write policy: %v", err)
+		}
+		return policyPath
+	}
+
+	policy, err := LoadPolicy(write("rules:\n  - id: core\n    paths: [\"internal/**\"]\n    max_total_growth: 100\n"))
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	if id, ok := policy.NeedsBaseline(); !ok || id != "core" || policy.Path == "" {
+		t.Fatalf("unexpected policy: %+v", policy)
+	}
+
+	invalid := []string{
+		"rules: []\n",
+		"rules:\n  - paths: [src]\n    max_file_lines: 10\n",
+		"rules:\n  - id: a\n    max_file_lines: 10\n  - id: a\n    max_file_lines: 20\n",
+		"rules:\n  - id: a\n    languages: [Go]\n",
+		"rules:\n  - id: a\n    paths: [\"[\"]\n    max_file_lines: 10\n",
+		"rules:\n  - id: a\n    max_file_line: 10\n",
+	}
+	for _, content := range invalid {
+		if _, err := LoadPolicy(write(content)); err == nil {
+			t.Fatalf("expected error for policy %q", content)
+		}
+	}
+}
+
+// TestEvaluateRatchet 验证棘轮检查：总计与基线中已有的语言按百分比或行数限制增长，新增语言与代码减少不违规。
+func TestEvaluateRatchet(t *testing.T) {
+	baseline := model.ScanResult{Files: []model.FileMetrics{
+		{Path: "main.go", Language: "Go", Metrics: model.LineMetrics{Total: 1000, Code: 1000}},
+		{Path: "app.py", Language: "Python", Metrics: model.LineMetrics{Total: 1000, Code: 1000}},
+	}}
+	baseline.Summarize(model.SummaryOptions{})
+	result := model.ScanResult{Files: []model.FileMetrics{
+		{Path: "main.go", Language: "Go", Metrics: model.LineMetrics{Total: 1030, Code: 1030}},
+		{Path: "app.py", Language: "Python", Metrics: model.LineMetrics{Total: 990, Code: 990}},
+		{Path: "build.rs", Language: "Rust", Metrics: model.LineMetrics{Total: 50, Code: 50}},
+	}}
+	result.Summarize(model.SummaryOptions{})
+
+	growth, err := ParseGrowth("2%")
+	if err != nil {
+		t.Fatalf("parse growth: %v", err)
+	}
+	violations := EvaluateRatchet(result, baseline, growth)
+	if len(violations) != 2 {
+		t.Fatalf("unexpected violations: %+v", violations)
+	}
+	if violations[0].Language != "" || violations[0].Actual != "+70" || violations[0].Limit != "+40" {
+		t.Fatalf("unexpected total violation: %+v", violations[0])
+	}
+	if violations[1].Language != "Go" || violations[1].Actual != "+30" || violations[1].Limit != "+20" {
+		t.Fatalf("unexpected language violation: %+v", violations[1])
+	}
+
+	growth, err = ParseGrowth("100")
+	if err != nil || growth.String() != "100" {
+		t.Fatalf("unexpected growth %+v (%v)", growth, err)
+	}
+	if violations := EvaluateRatchet(result, baseline, growth); len(violations) != 0 {
+		t.Fatalf("expected no violations: %+v", violations)
+	}
+
+	for _, value := range []string{"", "-1", "-2%", "abc", "2%%"} {
+		if _, err := ParseGrowth(value); err == nil {
+			t.Fatalf("expected error for growth %q", value)
+		}
+	}
+}
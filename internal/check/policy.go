@@ -0,0 +1,196 @@
+package check
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/glob"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyFileName 是自动发现的策略文件名。
+const PolicyFileName = "gocloc.policy.yaml"
+
+// RuleMaxTotalGrowth 是策略规则中“相对基线的代码行增长上限”的规则名称。
+const RuleMaxTotalGrowth = "max-total-growth"
+
+// Policy 是策略文件内容：一组带 ID 的规则，每条规则只作用于匹配的路径与语言。
+//
+// 文件格式示例：
+//
+//	rules:
+//	  - id: go-file-size
+//	    languages: [Go]
+//	    max_file_lines: 800
+//	  - id: core-no-bloat
+//	    paths: ["internal/**"]
+//	    max_total_growth: 2000
+//	    min_comment_density: 0.1
+type Policy struct {
+	Rules []PolicyRule `yaml:"rules"`
+
+	// Path 为加载的策略文件路径。
+	Path string `yaml:"-"`
+}
+
+// PolicyRule 描述一条策略规则，取值为 0 的限制项表示不检查，但每条规则至少需要一个限制项。
+type PolicyRule struct {
+	// ID 为规则标识，出现在违规记录中，在同一策略文件中唯一。
+	ID string `yaml:"id"`
+	// Paths 为规则作用的路径通配（相对扫描根目录，支持 **），匹配文件本身或其任一上级目录即生效；为空时作用于全部文件。
+	Paths []string `yaml:"paths"`
+	// Languages 为规则作用的语言（不区分大小写），为空时作用于全部语言。
+	Languages []string `yaml:"languages"`
+	// MaxFileLines 为范围内单文件总行数上限。
+	MaxFileLines int64 `yaml:"max_file_lines"`
+	// MaxTotalGrowth 为范围内代码行总数相对基线的增长上限，需要提供基线结果。
+	MaxTotalGrowth int64 `yaml:"max_total_growth"`
+	// MinCommentDensity 为范围内注释密度（comment/code）下限，范围内没有代码时不检查。
+	MinCommentDensity float64 `yaml:"min_comment_density"`
+}
+
+// LoadPolicy 读取并校验策略文件，未知字段视为错误。
+func LoadPolicy(path string) (Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("read policy: %w", err)
+	}
+
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return Policy{}, fmt.Errorf("parse policy %s: %w", path, err)
+	}
+	if err := policy.validate(); err != nil {
+		return Policy{}, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	policy.Path = path
+	return policy, nil
+}
+
+// validate 校验规则 ID、限制项取值与路径通配格式。
+func (p Policy) validate() error {
+	if len(p.Rules) == 0 {
+		return errors.New("at least one rule is required")
+	}
+	seen := make(map[string]bool, len(p.Rules))
+	for index, rule := range p.Rules {
+		id := strings.TrimSpace(rule.ID)
+		if id == "" {
+			return fmt.Errorf("rule %d: id is required", index+1)
+		}
+		if seen[id] {
+			return fmt.Errorf("duplicate rule id %q", id)
+		}
+		seen[id] = true
+		if rule.MaxFileLines < 0 || rule.MaxTotalGrowth < 0 || rule.MinCommentDensity < 0 {
+			return fmt.Errorf("rule %q: limits must not be negative", id)
+		}
+		if rule.MaxFileLines == 0 && rule.MaxTotalGrowth == 0 && rule.MinCommentDensity == 0 {
+			return fmt.Errorf("rule %q: at least one of max_file_lines, max_total_growth, min_comment_density is required", id)
+		}
+		for _, pattern := range rule.Paths {
+			if err := glob.Validate(pattern); err != nil {
+				return fmt.Errorf("rule %q: invalid path pattern %q: %w", id, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// NeedsBaseline 判断是否有规则设置了 max_total_growth，返回第一条这样的规则 ID。
+func (p Policy) NeedsBaseline() (string, bool) {
+	for _, rule := range p.Rules {
+		if rule.MaxTotalGrowth > 0 {
+			return strings.TrimSpace(rule.ID), true
+		}
+	}
+	return "", false
+}
+
+// EvaluatePolicy 按策略检查扫描结果，违规记录的 ID 为规则 ID，按 ID、规则名称、路径排序。
+// baseline 为基线结果（通常是上一次导出的 JSON），只有 max_total_growth 规则使用；为 nil 时跳过这类检查。
+func EvaluatePolicy(result model.ScanResult, baseline *model.ScanResult, policy Policy) []Violation {
+	violations := make([]Violation, 0)
+	for _, rule := range policy.Rules {
+		id := strings.TrimSpace(rule.ID)
+		scope := result.Filter(rule.matches, nil)
+
+		if rule.MaxFileLines > 0 {
+			for _, item := range scope.Files {
+				if item.Metrics.Total > rule.MaxFileLines {
+					violations = append(violations, Violation{
+						ID:      id,
+						Rule:    RuleMaxFileLines,
+						Path:    item.Path,
+						Actual:  fmt.Sprintf("%d", item.Metrics.Total),
+						Limit:   fmt.Sprintf("%d", rule.MaxFileLines),
+						Message: fmt.Sprintf("file has %d lines, rule %s allows %d", item.Metrics.Total, id, rule.MaxFileLines),
+					})
+				}
+			}
+		}
+
+		if rule.MaxTotalGrowth > 0 && baseline != nil {
+			growth := scope.Total.Code - baseline.Filter(rule.matches, nil).Total.Code
+			if growth > rule.MaxTotalGrowth {
+				violations = append(violations, Violation{
+					ID:      id,
+					Rule:    RuleMaxTotalGrowth,
+					Actual:  fmt.Sprintf("%+d", growth),
+					Limit:   fmt.Sprintf("%+d", rule.MaxTotalGrowth),
+					Message: fmt.Sprintf("code grew by %d lines since baseline, rule %s allows %d", growth, id, rule.MaxTotalGrowth),
+				})
+			}
+		}
+
+		density := scope.Total.Ratios.CommentDensity
+		if rule.MinCommentDensity > 0 && scope.Total.Code > 0 && density < rule.MinCommentDensity {
+			violations = append(violations, Violation{
+				ID:      id,
+				Rule:    RuleMinCommentDensity,
+				Actual:  fmt.Sprintf("%.3f", density),
+				Limit:   fmt.Sprintf("%.3f", rule.MinCommentDensity),
+				Message: fmt.Sprintf("comment density is %.3f, rule %s requires %.3f", density, id, rule.MinCommentDensity),
+			})
+		}
+	}
+
+	sortViolations(violations)
+	return violations
+}
+
+// matches 判断文件是否在规则的路径与语言范围内。
+func (r PolicyRule) matches(item model.FileMetrics) bool {
+	if len(r.Languages) > 0 {
+		matched := false
+		for _, language := range r.Languages {
+			if strings.EqualFold(item.Language, strings.TrimSpace(language)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range r.Paths {
+		for candidate := item.Path; candidate != "." && candidate != "/" && candidate != ""; candidate = path.Dir(candidate) {
+			if ok, _ := glob.Match(pattern, candidate); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
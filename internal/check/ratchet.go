@@ -0,0 +1,98 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// RuleMaxGrowth 是棘轮检查（相对基线的代码行增长上限）的规则名称。
+const RuleMaxGrowth = "max-growth"
+
+// Growth 是相对基线允许的代码行增长：Percent 为基线代码行的百分比，否则为 Lines 行。
+type Growth struct {
+	Lines   int64
+	Percent float64
+	// percent 表示按百分比计算，区分 "0%" 与 "0"。
+	percent bool
+}
+
+// ParseGrowth 解析增长上限：以 % 结尾时为基线代码行的百分比（如 "2%"），否则为代码行数（如 "500"），均不能为负。
+func ParseGrowth(value string) (Growth, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Growth{}, errors.New("max growth is empty")
+	}
+	if number, ok := strings.CutSuffix(value, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || percent < 0 {
+			return Growth{}, fmt.Errorf("invalid max growth %q, expected a non-negative percentage like 2%%", value)
+		}
+		return Growth{Percent: percent, percent: true}, nil
+	}
+	lines, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || lines < 0 {
+		return Growth{}, fmt.Errorf("invalid max growth %q, expected a non-negative line count or a percentage like 2%%", value)
+	}
+	return Growth{Lines: lines}, nil
+}
+
+// Allowed 返回基线为 base 行时允许增长的代码行数，百分比向下取整。
+func (g Growth) Allowed(base int64) int64 {
+	if g.percent {
+		return int64(float64(base) * g.Percent / 100)
+	}
+	return g.Lines
+}
+
+// String 返回增长上限的原始写法。
+func (g Growth) String() string {
+	if g.percent {
+		return strconv.FormatFloat(g.Percent, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatInt(g.Lines, 10)
+}
+
+// EvaluateRatchet 比较当前结果与基线，代码行总数或某个语言的代码行增长超过上限时记录违规，按语言排序（总计在前）。
+// 只检查基线中已有的语言：新增语言的代码计入总数，但不单独受限，否则按百分比计算时任何新语言都会违规。
+// 代码行减少从不违规，之后用 --update-baseline 更新基线即可把上限收紧到新的水平。
+func EvaluateRatchet(result model.ScanResult, baseline model.ScanResult, growth Growth) []Violation {
+	violations := make([]Violation, 0)
+	if violation, ok := ratchetViolation("", result.Total.Code, baseline.Total.Code, growth); ok {
+		violations = append(violations, violation)
+	}
+
+	current := make(map[string]int64, len(result.Languages))
+	for _, item := range result.Languages {
+		current[item.Language] = item.Metrics.Code
+	}
+	for _, item := range baseline.Languages {
+		if violation, ok := ratchetViolation(item.Language, current[item.Language], item.Metrics.Code, growth); ok {
+			violations = append(violations, violation)
+		}
+	}
+	sortViolations(violations)
+	return violations
+}
+
+// ratchetViolation 判断一个范围（language 为空表示总计）的增长是否超过上限。
+func ratchetViolation(language string, code int64, base int64, growth Growth) (Violation, bool) {
+	allowed := growth.Allowed(base)
+	if code-base <= allowed {
+		return Violation{}, false
+	}
+	scope := "project"
+	if language != "" {
+		scope = language
+	}
+	return Violation{
+		Rule:     RuleMaxGrowth,
+		Language: language,
+		Actual:   fmt.Sprintf("%+d", code-base),
+		Limit:    fmt.Sprintf("%+d", allowed),
+		Message:  fmt.Sprintf("%s code grew from %d to %d lines since baseline, max growth %s allows %d", scope, base, code, growth, allowed),
+	}, true
+}
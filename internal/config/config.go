@@ -0,0 +1,172 @@
+// Package config 负责加载 .gocloc.yaml 配置文件。
+//
+// 配置文件可以在扫描根目录及其上级目录中自动发现，也可以通过 --config 显式指定；
+// 各项设置的优先级为：命令行参数 > GOCLOC_* 环境变量（见 ApplyEnv）> 配置文件 > 默认值。
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/check"
+	"github.com/zhizhixiongxuwei/gocloc/internal/glob"
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName 是自动发现的配置文件名。
+const FileName = ".gocloc.yaml"
+
+// Config 是配置文件内容，零值字段表示不覆盖对应的默认值。
+//
+// 文件格式示例：
+//
+//	workers: 8
+//	format: json
+//	output: reports/cloc.json
+//	exclude:
+//	  - vendor
+//	  - "**/*_generated.go"
+//	languages: [Go, Python]
+//	disabled_languages: [SQL]
+//	python_docstrings: comment
+//	sql_dialect: postgres
+//	go_directives: directive
+//	labels:
+//	  team: payments
+//	check:
+//	  max_file_lines: 1000
+//	  max_total_code: 200000
+//	  min_comment_density: 0.1
+type Config struct {
+	// Workers 为并发 worker 数量。
+	Workers int `yaml:"workers"`
+	// Format 为输出格式。取值由 scan 在合并命令行与环境变量后按输出格式注册中心校验（包括 --reporter 注册的外部命令），
+	// 这里不做校验；只支持 table 与 json 的命令忽略其它取值。
+	Format string `yaml:"format"`
+	// Output 为 json 导出文件路径。
+	Output string `yaml:"output"`
+	// Exclude 为排除的路径通配，相对扫描根目录，支持 **。
+	Exclude []string `yaml:"exclude"`
+	// Languages 非空时只统计列出的语言。
+	Languages []string `yaml:"languages"`
+	// DisabledLanguages 为不统计的语言。
+	DisabledLanguages []string `yaml:"disabled_languages"`
+	// PythonDocstrings 为 Python docstring 的计入方式：code（默认）或 comment。
+	PythonDocstrings string `yaml:"python_docstrings"`
+	// SQLDialect 为 SQL 方言：auto（默认）、ansi、mysql、postgres、tsql 或 oracle。
+	SQLDialect string `yaml:"sql_dialect"`
+	// GoDirectives 为 Go 编译指令行的计入方式：comment（默认）、directive 或 code。
+	GoDirectives string `yaml:"go_directives"`
+	// Shebang 为脚本首行 shebang 的计入方式：comment（默认）、directive 或 code。
+	Shebang string `yaml:"shebang"`
+	// ContentCache 为按内容缓存分析结果的位置：本地目录、http(s):// 地址或 s3://bucket/prefix。
+	ContentCache string `yaml:"content_cache"`
+	// MaxReadBytesPerSec 为所有 worker 合计的文件读取速率上限（字节/秒），0 表示不限制。
+	MaxReadBytesPerSec int64 `yaml:"max_read_bytes_per_sec"`
+	// IOConcurrency 为同时进行中的文件读取数量上限，0 表示不限制。
+	IOConcurrency int `yaml:"io_concurrency"`
+	// Labels 为附加到扫描结果的标签，与 --label 合并，同一个键以命令行为准。
+	Labels map[string]string `yaml:"labels"`
+	// Check 为 check 命令使用的预算。
+	Check check.Budgets `yaml:"check"`
+
+	// Path 为加载的配置文件路径，没有配置文件时为空。
+	Path string `yaml:"-"`
+}
+
+// Load 读取并校验配置文件，未知字段视为错误，避免拼写错误被静默忽略。
+func Load(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if err := config.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	config.Path = path
+	return config, nil
+}
+
+// validate 校验取值范围与通配格式。
+func (c Config) validate() error {
+	if c.Workers < 0 {
+		return errors.New("workers must not be negative")
+	}
+	if c.MaxReadBytesPerSec < 0 || c.IOConcurrency < 0 {
+		return errors.New("max_read_bytes_per_sec and io_concurrency must not be negative")
+	}
+	for _, pattern := range c.Exclude {
+		if err := glob.Validate(pattern); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+	if mode := strings.ToLower(strings.TrimSpace(c.PythonDocstrings)); mode != "" && mode != "code" && mode != "comment" {
+		return fmt.Errorf("unsupported python_docstrings %q, allowed values: code, comment", c.PythonDocstrings)
+	}
+	if _, err := languages.ParseSQLDialect(c.SQLDialect); err != nil {
+		return err
+	}
+	if _, err := languages.ParseGoDirectives(c.GoDirectives); err != nil {
+		return err
+	}
+	if _, err := languages.ParseShebang(c.Shebang); err != nil {
+		return err
+	}
+	if c.Check.MaxFileLines < 0 || c.Check.MaxTotalCode < 0 || c.Check.MinCommentDensity < 0 {
+		return errors.New("check budgets must not be negative")
+	}
+	return nil
+}
+
+// Discover 从 start（目录或文件所在目录）开始向上查找 FileName，返回找到的路径。
+func Discover(start string) (string, bool) {
+	return DiscoverFile(start, FileName)
+}
+
+// DiscoverFile 从 start（目录或文件所在目录）开始向上查找名为 name 的文件，返回找到的路径。
+func DiscoverFile(start string, name string) (string, bool) {
+	directory, err := filepath.Abs(start)
+	if err != nil {
+		return "", false
+	}
+	if info, err := os.Stat(directory); err == nil && !info.IsDir() {
+		directory = filepath.Dir(directory)
+	}
+
+	for {
+		candidate := filepath.Join(directory, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+		parent := filepath.Dir(directory)
+		if parent == directory {
+			return "", false
+		}
+		directory = parent
+	}
+}
+
+// Resolve 加载 explicit 指定的配置文件；explicit 为空时从 start 向上自动发现，找不到时返回零值。
+func Resolve(explicit string, start string) (Config, error) {
+	if path := strings.TrimSpace(explicit); path != "" {
+		return Load(path)
+	}
+	if path, ok := Discover(start); ok {
+		return Load(path)
+	}
+	return Config{}, nil
+}
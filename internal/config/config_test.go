@@ -0,0 +1,184 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+)
+
+// TestDiscoverAndLoad 验证从子目录向上发现配置文件并解析全部字段。
+func TestDiscoverAndLoad(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	content := "workers: 4\n" +
+		"format: json\n" +
+		"exclude: [vendor, \"**/*_gen.go\"]\n" +
+		"languages: [Go]\n" +
+		"check:\n" +
+		"  max_file_lines: 500\n" +
+		"  min_comment_density: 0.2\n"
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+
+	config, err := Resolve("", nested)
+	if err != nil {
+		t.Fatalf("resolve config failed: %v", err)
+	}
+	if config.Path != filepath.Join(root, FileName) || config.Workers != 4 || config.Format != "json" {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+	if len(config.Exclude) != 2 || config.Languages[0] != "Go" || config.Check.MaxFileLines != 500 || config.Check.MinCommentDensity != 0.2 {
+		t.Fatalf("unexpected config lists or budgets: %+v", config)
+	}
+}
+
+// TestLoadRejectsInvalidConfig 验证未知字段、非法格式与错误通配都会报错。
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	for _, content := range []string{"wrokers: 2\n", "exclude: [\"src/[a\"]\n", "check:\n  max_total_code: -1\n", "python_docstrings: docs\n", "sql_dialect: sqlite\n", "go_directives: pragma\n", "shebang: skip\n", "io_concurrency: -1\n"} {
+		path := filepath.Join(t.TempDir(), FileName)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write config failed: %v", err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Fatalf("expected error for config %q", content)
+		}
+	}
+
+	// format 由 scan 按输出格式注册中心校验，配置文件不限制取值。
+	document := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(document, []byte("format: pdf\n"), 0o644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+	if config, err := Load(document); err != nil || config.Format != "pdf" {
+		t.Fatalf("format pdf should be accepted: %+v (%v)", config, err)
+	}
+
+	empty := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+	if _, err := Load(empty); err != nil {
+		t.Fatalf("empty config should be accepted: %v", err)
+	}
+}
+
+// TestApplyEnv 验证环境变量覆盖配置文件、exclude 合并而不是替换，以及非法取值报错。
+func TestApplyEnv(t *testing.T) {
+	base := Config{Workers: 2, Format: "table", Exclude: []string{"vendor"}, Languages: []string{"Python"}}
+	env := map[string]string{
+		"GOCLOC_WORKERS":             "8",
+		"GOCLOC_FORMAT":              "teamcity",
+		"GOCLOC_CONTENT_CACHE":       "s3://ci-cache/gocloc",
+		"GOCLOC_EXCLUDE":             "**/*_gen.go, dist",
+		"GOCLOC_LANGUAGES":           "",
+		"GOCLOC_DISABLED_LANGUAGES":  "SQL,Java",
+		"GOCLOC_MAX_FILE_LINES":      "1000",
+		"GOCLOC_MIN_COMMENT_DENSITY": "0.1",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	config, err := base.ApplyEnv(lookup)
+	if err != nil {
+		t.Fatalf("apply env failed: %v", err)
+	}
+	if config.Workers != 8 || config.Format != "teamcity" || config.Languages[0] != "Python" || config.ContentCache != "s3://ci-cache/gocloc" {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+	if !reflect.DeepEqual(config.Exclude, []string{"vendor", "**/*_gen.go", "dist"}) || len(base.Exclude) != 1 {
+		t.Fatalf("unexpected excludes: %v", config.Exclude)
+	}
+	if len(config.DisabledLanguages) != 2 || config.Check.MaxFileLines != 1000 || config.Check.MinCommentDensity != 0.1 {
+		t.Fatalf("unexpected lists or budgets: %+v", config)
+	}
+
+	for name, value := range map[string]string{"GOCLOC_WORKERS": "many", "GOCLOC_MAX_TOTAL_CODE": "-1"} {
+		env = map[string]string{name: value}
+		if _, err := base.ApplyEnv(lookup); err == nil || !strings.Contains(err.Error(), "GOCLOC_") {
+			t.Fatalf("expected error for %s=%s, got %v", name, value, err)
+		}
+	}
+}
+
+// TestLoadManifest 验证清单解析、相对路径按清单目录解析，以及标签与来源的校验。
+func TestLoadManifest(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "repos.yaml")
+	content := "repositories:\n" +
+		"  - label: api\n" +
+		"    path: services/api\n" +
+		"  - label: web\n" +
+		"    git_url: https://github.com/example/web.git\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write manifest failed: %v", err)
+	}
+
+	manifest, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("load manifest failed: %v", err)
+	}
+	if len(manifest.Repositories) != 2 || manifest.Repositories[0].Path != filepath.Join(directory, "services", "api") {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if manifest.Repositories[1].Source() != "https://github.com/example/web.git" {
+		t.Fatalf("unexpected source: %s", manifest.Repositories[1].Source())
+	}
+
+	for _, invalid := range []string{
+		"repositories: []\n",
+		"repositories:\n  - path: a\n",
+		"repositories:\n  - label: a/b\n    path: a\n",
+		"repositories:\n  - label: a\n    path: a\n  - label: a\n    path: b\n",
+		"repositories:\n  - label: a\n    path: a\n    git_url: https://x/y.git\n",
+		"repositories:\n  - label: a\n    url: https://x/y.git\n",
+	} {
+		if err := os.WriteFile(path, []byte(invalid), 0o644); err != nil {
+			t.Fatalf("write manifest failed: %v", err)
+		}
+		if _, err := LoadManifest(path); err == nil {
+			t.Fatalf("expected error for manifest %q", invalid)
+		}
+	}
+}
+
+// TestTemplate 验证生成的模板可以被 Load 加载且等同于默认值，覆盖 Config 的全部设置项并列出已注册语言。
+func TestTemplate(t *testing.T) {
+	registered := languages.NewRegistry().Languages()
+	content := Template(registered)
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("load template failed: %v\n%s", err, content)
+	}
+	if loaded.Format != "table" || loaded.Workers != 0 || len(loaded.Exclude) != 0 || !loaded.Check.Empty() {
+		t.Fatalf("unexpected defaults: %+v", loaded)
+	}
+
+	// 新增设置项时模板需要同步更新。
+	text := string(content)
+	fields := reflect.TypeOf(Config{})
+	for index := 0; index < fields.NumField(); index++ {
+		key := fields.Field(index).Tag.Get("yaml")
+		if key != "-" && !strings.Contains(text, "\n"+key+":") && !strings.Contains(text, "\n# "+key+":") {
+			t.Fatalf("template is missing %q", key)
+		}
+	}
+	for _, language := range registered {
+		if !strings.Contains(text, "#   "+language.Name+": ") {
+			t.Fatalf("template is missing language %s", language.Name)
+		}
+	}
+}
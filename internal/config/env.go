@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix 是环境变量配置的统一前缀。
+const EnvPrefix = "GOCLOC_"
+
+// 不属于配置文件、由命令层直接读取的环境变量。
+const (
+	// EnvConfigPath 对应 --config。
+	EnvConfigPath = EnvPrefix + "CONFIG"
+	// EnvLogLevel 对应 --log-level。
+	EnvLogLevel = EnvPrefix + "LOG_LEVEL"
+)
+
+// ApplyEnv 用 GOCLOC_* 环境变量覆盖配置文件中的设置，使容器化的 CI 任务无需冗长的命令行即可配置 gocloc，
+// 优先级变为：命令行参数 > 环境变量 > 配置文件 > 默认值。
+//
+// 支持的变量（值为空视为未设置，列表以逗号分隔）：
+// - GOCLOC_WORKERS、GOCLOC_FORMAT、GOCLOC_OUTPUT、GOCLOC_CONTENT_CACHE
+// - GOCLOC_EXCLUDE：与配置文件中的 exclude 合并，而不是替换
+// - GOCLOC_LANGUAGES、GOCLOC_DISABLED_LANGUAGES
+// - GOCLOC_MAX_FILE_LINES、GOCLOC_MAX_TOTAL_CODE、GOCLOC_MIN_COMMENT_DENSITY
+//
+// lookup 通常为 os.LookupEnv，取值无法解析或校验失败时返回带变量名的错误。
+func (c Config) ApplyEnv(lookup func(string) (string, bool)) (Config, error) {
+	get := func(name string) (string, bool) {
+		value, ok := lookup(EnvPrefix + name)
+		value = strings.TrimSpace(value)
+		return value, ok && value != ""
+	}
+
+	if value, ok := get("WORKERS"); ok {
+		workers, err := strconv.Atoi(value)
+		if err != nil {
+			return c, fmt.Errorf("invalid %sWORKERS %q: %w", EnvPrefix, value, err)
+		}
+		c.Workers = workers
+	}
+	if value, ok := get("FORMAT"); ok {
+		c.Format = value
+	}
+	if value, ok := get("OUTPUT"); ok {
+		c.Output = value
+	}
+	if value, ok := get("CONTENT_CACHE"); ok {
+		c.ContentCache = value
+	}
+	if value, ok := get("EXCLUDE"); ok {
+		c.Exclude = append(append([]string(nil), c.Exclude...), splitList(value)...)
+	}
+	if value, ok := get("LANGUAGES"); ok {
+		c.Languages = splitList(value)
+	}
+	if value, ok := get("DISABLED_LANGUAGES"); ok {
+		c.DisabledLanguages = splitList(value)
+	}
+	budgets := []struct {
+		name   string
+		target *int64
+	}{
+		{name: "MAX_FILE_LINES", target: &c.Check.MaxFileLines},
+		{name: "MAX_TOTAL_CODE", target: &c.Check.MaxTotalCode},
+	}
+	for _, budget := range budgets {
+		if value, ok := get(budget.name); ok {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return c, fmt.Errorf("invalid %s%s %q: %w", EnvPrefix, budget.name, value, err)
+			}
+			*budget.target = parsed
+		}
+	}
+	if value, ok := get("MIN_COMMENT_DENSITY"); ok {
+		density, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return c, fmt.Errorf("invalid %sMIN_COMMENT_DENSITY %q: %w", EnvPrefix, value, err)
+		}
+		c.Check.MinCommentDensity = density
+	}
+
+	if err := c.validate(); err != nil {
+		return c, fmt.Errorf("invalid %s* environment: %w", EnvPrefix, err)
+	}
+	return c, nil
+}
+
+// splitList 按逗号拆分列表，忽略空项。
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
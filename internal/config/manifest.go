@@ -0,0 +1,100 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest 是 scan-many 使用的多仓库清单。
+//
+// 文件格式示例：
+//
+//	repositories:
+//	  - label: api
+//	    path: ../services/api
+//	  - label: web
+//	    git_url: https://github.com/example/web.git
+//	    labels:
+//	      team: frontend
+type Manifest struct {
+	Repositories []Repository `yaml:"repositories"`
+}
+
+// Repository 是清单中的一个仓库，Path 与 GitURL 二选一。
+type Repository struct {
+	// Label 为仓库标签，在清单内唯一，用作汇总结果中的路径前缀。
+	Label string `yaml:"label"`
+	// Path 为本地目录，相对路径按清单文件所在目录解析。
+	Path string `yaml:"path"`
+	// GitURL 为远程仓库地址，扫描前浅克隆默认分支。
+	GitURL string `yaml:"git_url"`
+	// Labels 为附加到该仓库结果的标签，同一个键覆盖 --label 与配置文件中的标签。
+	Labels map[string]string `yaml:"labels"`
+}
+
+// Source 返回仓库来源的描述（本地路径或 git 地址）。
+func (r Repository) Source() string {
+	if r.GitURL != "" {
+		return r.GitURL
+	}
+	return r.Path
+}
+
+// LoadManifest 读取并校验多仓库清单，未知字段视为错误，相对路径按清单文件所在目录解析为绝对路径。
+func LoadManifest(path string) (Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil && !errors.Is(err, io.EOF) {
+		return Manifest{}, fmt.Errorf("parse manifest %s: %w", path, err)
+	}
+	if err := manifest.validate(); err != nil {
+		return Manifest{}, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return Manifest{}, fmt.Errorf("resolve manifest directory: %w", err)
+	}
+	for index, repository := range manifest.Repositories {
+		manifest.Repositories[index].Label = strings.TrimSpace(repository.Label)
+		if repository.Path != "" && !filepath.IsAbs(repository.Path) {
+			manifest.Repositories[index].Path = filepath.Join(base, repository.Path)
+		}
+	}
+	return manifest, nil
+}
+
+// validate 校验标签唯一、来源二选一。
+func (m Manifest) validate() error {
+	if len(m.Repositories) == 0 {
+		return errors.New("no repositories listed")
+	}
+	labels := make(map[string]bool, len(m.Repositories))
+	for index, repository := range m.Repositories {
+		label := strings.TrimSpace(repository.Label)
+		if label == "" || strings.ContainsAny(label, `/\`) {
+			return fmt.Errorf("repository #%d: label must be non-empty and must not contain path separators", index+1)
+		}
+		if labels[label] {
+			return fmt.Errorf("duplicate repository label %q", label)
+		}
+		labels[label] = true
+		if (strings.TrimSpace(repository.Path) == "") == (strings.TrimSpace(repository.GitURL) == "") {
+			return fmt.Errorf("repository %q: exactly one of path and git_url is required", label)
+		}
+	}
+	return nil
+}
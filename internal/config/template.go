@@ -0,0 +1,83 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+)
+
+// Template 生成带注释的配置文件模板：每个设置项都带说明并填入当前默认值（workers 的默认值随机器变化，因此注释掉），
+// languages 与 disabled_languages 上方列出全部已注册语言及其后缀。生成的内容可以直接被 Load 加载，效果与没有配置文件相同。
+func Template(registered []languages.LanguageDescriptor) []byte {
+	var buffer bytes.Buffer
+	line := func(format string, args ...any) {
+		fmt.Fprintf(&buffer, format+"\n", args...)
+	}
+
+	line("# gocloc 配置文件，由 gocloc gen-config 生成。")
+	line("# gocloc 从扫描路径向上查找 %s，也可以用 --config 或 %s 指定。", FileName, EnvConfigPath)
+	line("# 优先级：命令行参数 > GOCLOC_* 环境变量 > 配置文件 > 默认值；下面的取值均为默认值，可以删除不需要修改的项。")
+	line("")
+	line("# 并发 worker 数量，默认为 CPU 核数。")
+	line("# workers: 8")
+	line("")
+	line("# 输出格式，取值同 gocloc scan --format（table、json、pdf、treemap 等及 --reporter 注册的外部命令）；")
+	line("# 只支持 table 与 json 的命令（diff、list-files、scan-many、bench）遇到其它取值时使用默认格式。")
+	line("format: table")
+	line("")
+	line("# 同时把结果以 json 导出到该文件，以 .gz 结尾时 gzip 压缩，支持 {date}、{time}、{ref}、{path-hash} 占位符；为空时不导出。")
+	line(`output: ""`)
+	line("")
+	line("# 排除的路径（相对扫描路径，支持 **），与 --exclude 及 GOCLOC_EXCLUDE 合并生效，例如：")
+	line("#   - vendor")
+	line(`#   - "**/*_generated.go"`)
+	line("exclude: []")
+	line("")
+	line("# 只统计列出的语言（不区分大小写），为空时统计全部语言。已注册的语言及其后缀：")
+	for _, language := range registered {
+		extensions := "-"
+		if len(language.Extensions) > 0 {
+			extensions = strings.Join(language.Extensions, " ")
+		}
+		line("#   %s: %s", language.Name, extensions)
+	}
+	line("languages: []")
+	line("")
+	line("# 不统计的语言（不区分大小写），取值同 languages。")
+	line("disabled_languages: []")
+	line("")
+	line("# Python docstring 的计入方式：code 或 comment。")
+	line("python_docstrings: code")
+	line("")
+	line("# SQL 方言：auto（按语法特征判断）、ansi、mysql、postgres、tsql 或 oracle。")
+	line("sql_dialect: %s", languages.SQLDialectAuto)
+	line("")
+	line("# Go 编译指令行（//go:build 等）的计入方式：comment、directive 或 code。")
+	line("go_directives: %s", languages.GoDirectivesComment)
+	line("")
+	line("# 脚本首行 shebang（#!）的计入方式：comment、directive 或 code。")
+	line("shebang: %s", languages.ShebangComment)
+	line("")
+	line("# 按内容缓存分析结果的位置：本地目录、http(s):// 地址或 s3://bucket/prefix；为空时不缓存。")
+	line(`content_cache: ""`)
+	line("")
+	line("# 所有 worker 合计的文件读取速率上限（字节/秒）与同时进行中的文件读取数量上限，0 表示不限制。")
+	line("max_read_bytes_per_sec: 0")
+	line("io_concurrency: 0")
+	line("")
+	line("# 附加到扫描结果的标签，输出到 json、table 与 teamcity 格式并由 record 写入历史数据库，与 --label 合并，例如：")
+	line("#   team: payments")
+	line("labels: {}")
+	line("")
+	line("# check 命令的预算，0 表示不检查。")
+	line("check:")
+	line("  # 单文件总行数上限。")
+	line("  max_file_lines: 0")
+	line("  # 项目代码行总数上限。")
+	line("  max_total_code: 0")
+	line("  # 项目注释密度（comment/code）下限。")
+	line("  min_comment_density: 0")
+	return buffer.Bytes()
+}
@@ -0,0 +1,57 @@
+// Package glob 提供以 / 分隔的路径通配匹配，在 path.Match 的基础上支持跨目录的 ** 段。
+package glob
+
+import (
+	"path"
+	"strings"
+)
+
+// Match 判断以 / 分隔的相对路径是否匹配 pattern。
+//
+// 规则说明：
+// - 每个路径段按 path.Match 规则匹配（*、?、[...]），* 不跨越 /
+// - 单独成段的 ** 匹配零个或多个路径段，例如 "src/**" 匹配 src 下的全部文件，"**/*_test.go" 匹配任意目录下的测试文件
+// - pattern 格式错误时返回 path.ErrBadPattern
+func Match(pattern string, name string) (bool, error) {
+	if err := Validate(pattern); err != nil {
+		return false, err
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/")), nil
+}
+
+// Validate 检查 pattern 的格式，适合在加载配置时提前报错。
+func Validate(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchSegments 逐段匹配，遇到 ** 时尝试吞掉任意数量的路径段。
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for skip := 0; skip <= len(name); skip++ {
+				if matchSegments(pattern[1:], name[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		// 格式已由 Validate 检查，这里的错误只可能是 ErrBadPattern，不会出现。
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
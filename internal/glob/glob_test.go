@@ -0,0 +1,36 @@
+package glob
+
+import "testing"
+
+// TestMatch 验证单段通配与跨目录 ** 的匹配规则。
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "src/**", name: "src/a/b/main.go", expected: true},
+		{pattern: "src/**", name: "lib/main.go", expected: false},
+		{pattern: "**/*_test.go", name: "main_test.go", expected: true},
+		{pattern: "**/*_test.go", name: "pkg/a/util_test.go", expected: true},
+		{pattern: "*.go", name: "pkg/main.go", expected: false},
+		{pattern: "pkg/**/gen/*.go", name: "pkg/gen/a.go", expected: true},
+		{pattern: "pkg/**/gen/*.go", name: "pkg/x/y/gen/a.go", expected: true},
+		{pattern: "pkg/**/gen/*.go", name: "pkg/x/gen/sub/a.go", expected: false},
+		{pattern: "vendor", name: "vendor", expected: true},
+	}
+
+	for _, item := range cases {
+		ok, err := Match(item.pattern, item.name)
+		if err != nil {
+			t.Fatalf("match %q failed: %v", item.pattern, err)
+		}
+		if ok != item.expected {
+			t.Fatalf("match %q against %q: expected %v", item.pattern, item.name, item.expected)
+		}
+	}
+
+	if _, err := Match("src/[a", "src/a"); err == nil {
+		t.Fatalf("expected bad pattern error")
+	}
+}
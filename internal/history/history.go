@@ -0,0 +1,235 @@
+// Package history 把扫描结果的汇总按时间追加到本地历史数据库，并按语言、标签与时间范围查询趋势，
+// 供 record 与 trend 命令使用。
+//
+// 存储后端通过 Store 接口接入，Open 按路径选择：
+//   - SQLiteStore：SQLite 数据库文件（scans 与 languages 两张表），按文件格式直接读写，不需要 cgo 或数据库驱动，
+//     可以用 sqlite3 或任意 SQLite 客户端做 SQL 查询
+//   - JSONLinesStore：JSON Lines 文件，每行一条记录，只追加不改写，便于 jq 处理与多个进程同时追加
+//
+// Postgres 等数据库服务尚未支持。
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// Counts 是一条记录中总计或单个语言的计数。
+type Counts struct {
+	Files   int64 `json:"files"`
+	Total   int64 `json:"total"`
+	Code    int64 `json:"code"`
+	Comment int64 `json:"comment"`
+	Blank   int64 `json:"blank"`
+}
+
+// Entry 是历史文件中的一条记录：一次扫描的时间、版本引用、标签与汇总计数，不保存文件级明细。
+type Entry struct {
+	Time        time.Time         `json:"time"`
+	Ref         string            `json:"ref,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	ScannedPath string            `json:"scanned_path"`
+	Total       Counts            `json:"total"`
+	Languages   map[string]Counts `json:"languages"`
+}
+
+// NewEntry 从扫描结果构建记录：结果自带的标签（ScanResult.Labels）与 labels 合并后复制到记录中，同名键以 labels 为准。
+func NewEntry(result model.ScanResult, recorded time.Time, ref string, labels map[string]string) Entry {
+	entry := Entry{
+		Time:        recorded.UTC(),
+		Ref:         ref,
+		ScannedPath: result.ScannedPath,
+		Total: Counts{
+			Files:   result.Total.Files,
+			Total:   result.Total.Total,
+			Code:    result.Total.Code,
+			Comment: result.Total.Comment,
+			Blank:   result.Total.Blank,
+		},
+		Languages: make(map[string]Counts, len(result.Languages)),
+	}
+	if len(result.Labels) > 0 || len(labels) > 0 {
+		entry.Labels = make(map[string]string, len(result.Labels)+len(labels))
+		for _, source := range []map[string]string{result.Labels, labels} {
+			for key, value := range source {
+				entry.Labels[key] = value
+			}
+		}
+	}
+	for _, item := range result.Languages {
+		entry.Languages[item.Language] = Counts{
+			Files:   item.Files,
+			Total:   item.Metrics.Total,
+			Code:    item.Metrics.Code,
+			Comment: item.Metrics.Comment,
+			Blank:   item.Metrics.Blank,
+		}
+	}
+	return entry
+}
+
+// Store 是历史记录的存储后端。
+type Store interface {
+	// Append 追加一条记录。
+	Append(entry Entry) error
+	// Load 读取全部记录，按时间排序（时间相同时保持写入顺序）。
+	Load() ([]Entry, error)
+}
+
+// Open 按路径选择存储后端：已存在的 SQLite 文件或 .db、.sqlite、.sqlite3 后缀为 SQLite 数据库（SQLiteStore），
+// 其余路径为 JSON Lines 文件（JSONLinesStore，推荐 .jsonl 后缀）。Postgres 等数据库服务的连接串返回错误。
+func Open(path string) (Store, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, errors.New("history database path is empty")
+	}
+	if lower := strings.ToLower(path); strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://") {
+		return nil, errors.New("postgres history databases are not supported; use a SQLite .db file or a .jsonl file")
+	}
+	if sqliteDatabasePath(path) {
+		return SQLiteStore{Path: path}, nil
+	}
+	return JSONLinesStore{Path: path}, nil
+}
+
+// JSONLinesStore 是 JSON Lines 文件的存储后端。
+type JSONLinesStore struct {
+	Path string
+}
+
+// Append 实现 Store。
+func (s JSONLinesStore) Append(entry Entry) error {
+	return Append(s.Path, entry)
+}
+
+// Load 实现 Store。
+func (s JSONLinesStore) Load() ([]Entry, error) {
+	return Load(s.Path)
+}
+
+// Append 把记录追加到 JSON Lines 历史文件末尾，文件或目录不存在时创建。
+// 每条记录以一次写入完成，多个进程同时追加到本地文件时记录不会交错。
+func Append(path string, entry Entry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal history entry: %w", err)
+	}
+	if directory := filepath.Dir(path); directory != "." && directory != "" {
+		if err := os.MkdirAll(directory, 0o755); err != nil {
+			return fmt.Errorf("create history directory: %w", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open history file: %w", err)
+	}
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write history file: %w", err)
+	}
+	return file.Close()
+}
+
+// Load 读取 JSON Lines 历史文件中的全部记录，按时间排序（时间相同时保持写入顺序）。
+// 末尾没有换行的不完整记录（追加时被中断）被忽略，其余格式错误的行返回带行号的错误。
+func Load(path string) ([]Entry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read history file: %w", err)
+	}
+
+	entries := make([]Entry, 0)
+	reader := bufio.NewReader(bytes.NewReader(content))
+	for number := 1; ; number++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// 没有换行结尾的最后一行只可能是被中断的追加。
+			break
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("parse history file %s line %d: %w", path, number, err)
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i int, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// Filter 限定趋势查询的范围，零值字段表示不限制。
+type Filter struct {
+	// Language 为只查询的语言（不区分大小写），为空时查询总计。
+	Language string
+	// Labels 为记录必须全部带有的标签。
+	Labels map[string]string
+	// Ref 为只查询的版本引用。
+	Ref string
+	// Since 与 Until 为时间范围（含两端）。
+	Since time.Time
+	Until time.Time
+}
+
+// Point 是趋势中的一个数据点；Present 为 false 表示该记录中没有查询的语言（计数为 0）。
+type Point struct {
+	Time    time.Time         `json:"time"`
+	Ref     string            `json:"ref,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Counts  Counts            `json:"counts"`
+	Present bool              `json:"present"`
+}
+
+// Trend 按过滤条件从已排序的记录中取出趋势数据点。
+func Trend(entries []Entry, filter Filter) []Point {
+	points := make([]Point, 0, len(entries))
+	for _, entry := range entries {
+		if !filter.matches(entry) {
+			continue
+		}
+		point := Point{Time: entry.Time, Ref: entry.Ref, Labels: entry.Labels, Counts: entry.Total, Present: true}
+		if filter.Language != "" {
+			point.Counts, point.Present = Counts{}, false
+			for language, counts := range entry.Languages {
+				if strings.EqualFold(language, filter.Language) {
+					point.Counts, point.Present = counts, true
+					break
+				}
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// matches 判断记录是否满足过滤条件（语言除外，缺少语言的记录计为 0 而不是被排除，以保留时间轴）。
+func (f Filter) matches(entry Entry) bool {
+	if f.Ref != "" && entry.Ref != f.Ref {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	for key, value := range f.Labels {
+		if actual, ok := entry.Labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,240 @@
+package history
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// TestAppendLoad 验证追加后按时间排序读取，记录带有结果与参数合并后的标签，且被中断的最后一行被忽略。
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "metrics.jsonl")
+	first := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	result := model.ScanResult{
+		ScannedPath: "/src/api",
+		Languages:   []model.LanguageMetrics{{Language: "Go", Files: 2, Metrics: model.LineMetrics{Total: 12, Code: 10, Blank: 2}}},
+		Total:       model.TotalMetrics{Files: 2, LineMetrics: model.LineMetrics{Total: 12, Code: 10, Blank: 2}},
+		Labels:      map[string]string{"team": "core", "env": "ci"},
+	}
+	if err := Append(path, NewEntry(result, first, "main", map[string]string{"team": "api"})); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := Append(path, NewEntry(model.ScanResult{}, first.Add(-time.Hour), "v1", nil)); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.WriteString(`{"time":"2026-`)
+	_ = file.Close()
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Ref != "v1" || entries[1].Ref != "main" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if entries[1].Languages["Go"].Code != 10 || entries[1].Labels["team"] != "api" || entries[1].Labels["env"] != "ci" || entries[1].ScannedPath != "/src/api" {
+		t.Fatalf("unexpected entry: %+v", entries[1])
+	}
+
+	if err := os.WriteFile(path, []byte("not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error for malformed line")
+	}
+}
+
+// TestTrend 验证按语言、标签、版本引用与时间范围过滤，缺少语言的记录计为 0。
+func TestTrend(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	entries := []Entry{
+		{Time: day(1), Ref: "main", Labels: map[string]string{"team": "api"}, Total: Counts{Code: 10}, Languages: map[string]Counts{"Go": {Code: 10}}},
+		{Time: day(2), Ref: "main", Labels: map[string]string{"team": "web"}, Total: Counts{Code: 5}, Languages: map[string]Counts{"TypeScript": {Code: 5}}},
+		{Time: day(3), Ref: "dev", Labels: map[string]string{"team": "api"}, Total: Counts{Code: 30}, Languages: map[string]Counts{"Go": {Code: 20}, "YAML": {Code: 10}}},
+		{Time: day(4), Ref: "main", Labels: map[string]string{"team": "api"}, Total: Counts{Code: 8}, Languages: map[string]Counts{"YAML": {Code: 8}}},
+	}
+
+	points := Trend(entries, Filter{Language: "go", Labels: map[string]string{"team": "api"}})
+	if len(points) != 3 || points[0].Counts.Code != 10 || points[1].Counts.Code != 20 {
+		t.Fatalf("unexpected points: %+v", points)
+	}
+	if points[2].Present || points[2].Counts.Code != 0 {
+		t.Fatalf("expected missing language to count as zero: %+v", points[2])
+	}
+
+	points = Trend(entries, Filter{Ref: "main", Since: day(2), Until: day(3)})
+	if len(points) != 1 || points[0].Counts.Code != 5 || !points[0].Present {
+		t.Fatalf("unexpected points: %+v", points)
+	}
+}
+
+// TestOpen 验证按路径选择后端：数据库后缀与已存在的 SQLite 文件使用 SQLite，其余路径使用 JSON Lines，Postgres 连接串报错。
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"metrics.db", "history/metrics.SQLITE", "metrics.sqlite3"} {
+		if store, err := Open(filepath.Join(dir, path)); err != nil {
+			t.Fatalf("open %s failed: %v", path, err)
+		} else if _, ok := store.(SQLiteStore); !ok {
+			t.Fatalf("expected SQLite store for %s, got %T", path, store)
+		}
+	}
+	if _, err := Open("postgres://localhost/gocloc"); err == nil {
+		t.Fatal("expected error for postgres connection string")
+	}
+
+	path := filepath.Join(dir, "metrics.jsonl")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if _, ok := store.(JSONLinesStore); !ok {
+		t.Fatalf("expected JSON Lines store, got %T", store)
+	}
+	if err := store.Append(NewEntry(model.ScanResult{}, time.Now(), "main", nil)); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if entries, err := store.Load(); err != nil || len(entries) != 1 || entries[0].Ref != "main" {
+		t.Fatalf("unexpected entries: %+v (%v)", entries, err)
+	}
+
+	// 内容为 SQLite 的文件不论后缀都按数据库读写。
+	renamed := filepath.Join(dir, "history.bin")
+	if err := (SQLiteStore{Path: renamed}).Append(NewEntry(model.ScanResult{}, time.Now(), "v1", nil)); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if store, err := Open(renamed); err != nil {
+		t.Fatalf("open failed: %v", err)
+	} else if _, ok := store.(SQLiteStore); !ok {
+		t.Fatalf("expected SQLite store for existing database, got %T", store)
+	}
+}
+
+// TestSQLiteStore 验证 SQLite 后端的往返：多页的表、需要溢出页的大标签、按时间排序读取，以及写入的文件头与表结构。
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "metrics.db")
+	store := SQLiteStore{Path: path}
+	if _, err := store.Load(); err == nil {
+		t.Fatal("expected error for missing database")
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	const scans = 40
+	for index := range scans {
+		entry := Entry{
+			// 写入顺序与时间顺序相反，Load 按时间排序。
+			Time:        start.Add(time.Duration(scans-index) * time.Hour),
+			Ref:         fmt.Sprintf("r%d", index),
+			ScannedPath: "/src",
+			Total:       Counts{Files: int64(index), Code: int64(index) << 40, Comment: -1},
+			Languages:   make(map[string]Counts),
+		}
+		for language := range 50 {
+			entry.Languages[fmt.Sprintf("L%02d", language)] = Counts{Code: int64(index*100 + language)}
+		}
+		if index == 7 {
+			entry.Labels = map[string]string{"team": "api", "notes": strings.Repeat("x", 10000)}
+		}
+		if err := store.Append(entry); err != nil {
+			t.Fatalf("append %d failed: %v", index, err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "SQLite format 3\x00") || len(content)%sqlitePageSize != 0 || len(content) <= 4*sqlitePageSize {
+		t.Fatalf("unexpected database file of %d bytes", len(content))
+	}
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(entries) != scans || entries[0].Ref != "r39" || entries[scans-1].Ref != "r0" {
+		t.Fatalf("unexpected order: %d entries, first %+v", len(entries), entries[0])
+	}
+	labelled := entries[scans-1-7]
+	if labelled.Ref != "r7" || labelled.Labels["team"] != "api" || len(labelled.Labels["notes"]) != 10000 {
+		t.Fatalf("unexpected labelled entry: ref %s, labels %d", labelled.Ref, len(labelled.Labels))
+	}
+	if labelled.Total.Code != 7<<40 || labelled.Total.Comment != -1 || len(labelled.Languages) != 50 || labelled.Languages["L49"].Code != 749 {
+		t.Fatalf("unexpected counts: %+v", labelled.Total)
+	}
+	if !labelled.Time.Equal(start.Add((scans - 7) * time.Hour)) {
+		t.Fatalf("unexpected time: %v", labelled.Time)
+	}
+}
+
+// sqliteFixture 是 sqlite3 创建的历史数据库（页大小 512，删除过一行后 VACUUM），gzip 后 base64 编码。
+const sqliteFixture = "" +
+	"H4sIAMKH0GoC/+2U22rCMBjHk6joDsKESS8NvVJWt26ywdzNOslEVnWrHUxvJLbRlfUgtoIwdiE+2h5j77BnWFtqYVPY5Q74Iwn5" +
+	"/jn++UI6d7LhMTx0Jhb1cAUgACG4xBgAkIzqksSXGILvSYJDDaaCHoriDb+KbJBI+Ar8suFvcoF2wGk+fwADTsSTs7JYKYvHqihW" +
+	"w9KzqGEfuRPtmfcYtfgq5unY4F8SM303E+QfwTcA3/1mw38ihzIwvxU8CtSVmvL+djqxBzMwF74TVHeQ3kulwvz7v4D/SWfBfIrS" +
+	"XKEAF+ceHZjMpPZoSkfMjTuJmkIklWBVupIJjuWiq1G7b+i40VJJnSi41VZx616WsUKuiUJaNdLBwRy3aOglIV6IVfKgxpMFPDRM" +
+	"5q5sImDP8ai5Rtccna2VLYvZ3pqRgX/y04pems9gmuM4uGiHvsObhg365HfpIN7gVmk0JaWLb0jXv6RhrRiasGEoCeFam+n9MfUe" +
+	"I8mkA2a6UfBT1j8AiSz0RQAIAAA="
+
+// TestSQLiteStoreExternalDatabase 验证读取并追加 sqlite3 写入的数据库：rowid 不连续时新记录的 id 接在最大值之后。
+func TestSQLiteStoreExternalDatabase(t *testing.T) {
+	compressed, err := base64.StdEncoding.DecodeString(sqliteFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "external.db")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := SQLiteStore{Path: path}
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Ref != "main" || entries[0].Labels["team"] != "api" || entries[0].Total.Code != 100 ||
+		entries[0].Languages["Go"].Code != 90 || entries[0].Languages["YAML"].Comment != 7 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	if err := store.Append(Entry{Time: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), Ref: "next", Languages: map[string]Counts{"Go": {Code: 95}}}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	rewritten, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := parseSQLite(rewritten)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(tables.scans) != 2 || tables.scans[0].rowid != 2 || tables.scans[1].rowid != 3 || sqliteInt(tables.languages[2].values[0]) != 3 {
+		t.Fatalf("unexpected rows: %+v", tables)
+	}
+
+	if err := os.WriteFile(path, []byte("SQLite format 3\x00 truncated"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Fatal("expected error for truncated database")
+	}
+}
@@ -0,0 +1,824 @@
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SQLite 文件格式（https://www.sqlite.org/fileformat2.html）中本实现使用的常量。
+const (
+	sqliteMagic    = "SQLite format 3\x00"
+	sqlitePageSize = 4096
+	// sqliteVersion 是写入文件头的 SQLite 版本号，只用于标识写入者，读取时不校验。
+	sqliteVersion = 3045000
+
+	sqliteInteriorTable = 0x05
+	sqliteLeafTable     = 0x0d
+)
+
+// sqliteScansSQL 与 sqliteLanguagesSQL 是历史数据库的表结构，labels 为 JSON 文本（可用 json_extract 查询）。
+const (
+	sqliteScansSQL = "CREATE TABLE scans(id INTEGER PRIMARY KEY, time TEXT NOT NULL, ref TEXT, scanned_path TEXT, labels TEXT, " +
+		"files INTEGER NOT NULL, total INTEGER NOT NULL, code INTEGER NOT NULL, comment INTEGER NOT NULL, blank INTEGER NOT NULL)"
+	sqliteLanguagesSQL = "CREATE TABLE languages(scan_id INTEGER NOT NULL REFERENCES scans(id), language TEXT NOT NULL, " +
+		"files INTEGER NOT NULL, total INTEGER NOT NULL, code INTEGER NOT NULL, comment INTEGER NOT NULL, blank INTEGER NOT NULL)"
+)
+
+// SQLiteStore 是 SQLite 数据库文件的存储后端，不依赖 cgo 或数据库驱动：直接按 SQLite 3 文件格式读写，
+// 生成的文件可以用 sqlite3 命令行或任意 SQLite 客户端查询，例如：
+//
+//	SELECT time, ref, code FROM scans WHERE json_extract(labels, '$.team') = 'api' ORDER BY time;
+//	SELECT s.time, l.code FROM languages l JOIN scans s ON s.id = l.scan_id WHERE l.language = 'Go';
+//
+// 使用约定：
+// - 每次 Append 读取全部记录后重写整个文件（先写临时文件再重命名），历史记录只有汇总，文件通常很小
+// - 多个进程同时 Append 时后完成的写入会覆盖先完成的写入，需要由调用方串行化（例如 CI 中的单个任务）
+// - 读取支持其它 SQLite 客户端修改过的文件（增删行、VACUUM），但不支持 WAL 模式与 UTF-16 编码
+// - 数据库中存在 gocloc 之外的表、索引或视图，或表结构被修改时，Append 返回错误而不是丢弃它们
+type SQLiteStore struct {
+	Path string
+}
+
+// sqliteRow 是表 b-tree 中的一行。
+type sqliteRow struct {
+	rowid  int64
+	values []any
+}
+
+// sqliteTables 是从文件读出的 scans 与 languages 表。
+type sqliteTables struct {
+	scans     []sqliteRow
+	languages []sqliteRow
+	// foreign 为 true 表示 schema 中有 gocloc 之外的对象。
+	foreign bool
+	// changeCounter 为文件头中的修改计数，重写时加 1。
+	changeCounter uint32
+}
+
+// Append 实现 Store。
+func (s SQLiteStore) Append(entry Entry) error {
+	tables, err := s.read()
+	if errors.Is(err, os.ErrNotExist) {
+		tables, err = sqliteTables{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	if tables.foreign {
+		return fmt.Errorf("history database %s contains tables, indexes or views not created by gocloc; refusing to rewrite it", s.Path)
+	}
+
+	var labels any
+	if len(entry.Labels) > 0 {
+		content, err := json.Marshal(entry.Labels)
+		if err != nil {
+			return fmt.Errorf("marshal history labels: %w", err)
+		}
+		labels = string(content)
+	}
+	scanID := nextRowid(tables.scans)
+	tables.scans = append(tables.scans, sqliteRow{rowid: scanID, values: []any{
+		nil, entry.Time.UTC().Format(time.RFC3339Nano), sqliteText(entry.Ref), sqliteText(entry.ScannedPath), labels,
+		entry.Total.Files, entry.Total.Total, entry.Total.Code, entry.Total.Comment, entry.Total.Blank,
+	}})
+	languages := make([]string, 0, len(entry.Languages))
+	for language := range entry.Languages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	rowid := nextRowid(tables.languages)
+	for _, language := range languages {
+		counts := entry.Languages[language]
+		tables.languages = append(tables.languages, sqliteRow{rowid: rowid, values: []any{
+			scanID, language, counts.Files, counts.Total, counts.Code, counts.Comment, counts.Blank,
+		}})
+		rowid++
+	}
+
+	content, err := buildSQLite(tables)
+	if err != nil {
+		return fmt.Errorf("write history database: %w", err)
+	}
+	return writeAtomic(s.Path, content)
+}
+
+// Load 实现 Store。
+func (s SQLiteStore) Load() ([]Entry, error) {
+	tables, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	languages := make(map[int64]map[string]Counts)
+	for _, row := range tables.languages {
+		if len(row.values) < 7 {
+			return nil, fmt.Errorf("read history database %s: languages row %d has %d columns", s.Path, row.rowid, len(row.values))
+		}
+		scanID := sqliteInt(row.values[0])
+		if languages[scanID] == nil {
+			languages[scanID] = make(map[string]Counts)
+		}
+		languages[scanID][sqliteString(row.values[1])] = sqliteCounts(row.values[2:7])
+	}
+
+	entries := make([]Entry, 0, len(tables.scans))
+	for _, row := range tables.scans {
+		if len(row.values) < 10 {
+			return nil, fmt.Errorf("read history database %s: scans row %d has %d columns", s.Path, row.rowid, len(row.values))
+		}
+		recorded, err := time.Parse(time.RFC3339Nano, sqliteString(row.values[1]))
+		if err != nil {
+			return nil, fmt.Errorf("read history database %s: scans row %d: %w", s.Path, row.rowid, err)
+		}
+		entry := Entry{
+			Time:        recorded,
+			Ref:         sqliteString(row.values[2]),
+			ScannedPath: sqliteString(row.values[3]),
+			Total:       sqliteCounts(row.values[5:10]),
+			Languages:   languages[row.rowid],
+		}
+		if entry.Languages == nil {
+			entry.Languages = make(map[string]Counts)
+		}
+		if labels := sqliteString(row.values[4]); labels != "" {
+			if err := json.Unmarshal([]byte(labels), &entry.Labels); err != nil {
+				return nil, fmt.Errorf("read history database %s: scans row %d labels: %w", s.Path, row.rowid, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i int, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// read 读取并解析数据库文件，表按 rowid 顺序返回。
+func (s SQLiteStore) read() (sqliteTables, error) {
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		return sqliteTables{}, fmt.Errorf("read history database: %w", err)
+	}
+	tables, err := parseSQLite(content)
+	if err != nil {
+		return sqliteTables{}, fmt.Errorf("read history database %s: %w", s.Path, err)
+	}
+	return tables, nil
+}
+
+// isSQLiteFile 判断 path 是否为已存在的 SQLite 数据库文件。
+func isSQLiteFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header := make([]byte, len(sqliteMagic))
+	if _, err := file.Read(header); err != nil {
+		return false
+	}
+	return string(header) == sqliteMagic
+}
+
+// nextRowid 返回追加到表末尾的下一行 rowid。
+func nextRowid(rows []sqliteRow) int64 {
+	var last int64
+	for _, row := range rows {
+		last = max(last, row.rowid)
+	}
+	return last + 1
+}
+
+// sqliteText 把空字符串存为 NULL。
+func sqliteText(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// sqliteInt 把列值转换为整数，其它客户端写入的浮点数按截断处理，NULL 与文本为 0。
+func sqliteInt(value any) int64 {
+	switch typed := value.(type) {
+	case int64:
+		return typed
+	case float64:
+		return int64(typed)
+	}
+	return 0
+}
+
+// sqliteString 把列值转换为字符串，NULL 为空字符串。
+func sqliteString(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case []byte:
+		return string(typed)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// sqliteCounts 按 files、total、code、comment、blank 的顺序读取计数列。
+func sqliteCounts(values []any) Counts {
+	return Counts{
+		Files:   sqliteInt(values[0]),
+		Total:   sqliteInt(values[1]),
+		Code:    sqliteInt(values[2]),
+		Comment: sqliteInt(values[3]),
+		Blank:   sqliteInt(values[4]),
+	}
+}
+
+// writeAtomic 先写入同目录的临时文件再重命名，中断时不会留下不完整的数据库。
+func writeAtomic(path string, content []byte) error {
+	directory := filepath.Dir(path)
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return fmt.Errorf("create history directory: %w", err)
+	}
+	file, err := os.CreateTemp(directory, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("write history database: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(content); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write history database: %w", err)
+	}
+	if err := file.Chmod(0o644); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write history database: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write history database: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("write history database: %w", err)
+	}
+	return nil
+}
+
+// parseSQLite 解析 SQLite 文件，读出 scans 与 languages 表。
+func parseSQLite(content []byte) (sqliteTables, error) {
+	if len(content) < 100 || string(content[:len(sqliteMagic)]) != sqliteMagic {
+		return sqliteTables{}, errors.New("not a SQLite database")
+	}
+	pageSize := int(binary.BigEndian.Uint16(content[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return sqliteTables{}, fmt.Errorf("invalid page size %d", pageSize)
+	}
+	if content[18] == 2 || content[19] == 2 {
+		return sqliteTables{}, errors.New("database uses WAL journal mode; run PRAGMA journal_mode=DELETE first")
+	}
+	if encoding := binary.BigEndian.Uint32(content[56:60]); encoding > 1 {
+		return sqliteTables{}, errors.New("only UTF-8 databases are supported")
+	}
+	reader := sqliteReader{content: content, pageSize: pageSize, usable: pageSize - int(content[20])}
+
+	schema, err := reader.table(1)
+	if err != nil {
+		return sqliteTables{}, fmt.Errorf("read schema: %w", err)
+	}
+	tables := sqliteTables{changeCounter: binary.BigEndian.Uint32(content[24:28])}
+	found := 0
+	for _, row := range schema {
+		if len(row.values) < 5 {
+			return sqliteTables{}, errors.New("invalid schema row")
+		}
+		kind, name, sql := sqliteString(row.values[0]), sqliteString(row.values[1]), sqliteString(row.values[4])
+		var target *[]sqliteRow
+		switch {
+		case kind == "table" && name == "scans" && sql == sqliteScansSQL:
+			target = &tables.scans
+		case kind == "table" && name == "languages" && sql == sqliteLanguagesSQL:
+			target = &tables.languages
+		case kind == "table" && (name == "scans" || name == "languages"):
+			return sqliteTables{}, fmt.Errorf("table %s has an unexpected schema", name)
+		default:
+			tables.foreign = true
+			continue
+		}
+		rows, err := reader.table(int(sqliteInt(row.values[3])))
+		if err != nil {
+			return sqliteTables{}, fmt.Errorf("read table %s: %w", name, err)
+		}
+		*target = rows
+		found++
+	}
+	if found != 2 {
+		return sqliteTables{}, errors.New("not a gocloc history database (tables scans and languages are missing)")
+	}
+	return tables, nil
+}
+
+// sqliteReader 读取表 b-tree。
+type sqliteReader struct {
+	content  []byte
+	pageSize int
+	usable   int
+}
+
+// page 返回页号对应的内容。
+func (r sqliteReader) page(number int) ([]byte, error) {
+	start := (number - 1) * r.pageSize
+	if number < 1 || start+r.pageSize > len(r.content) {
+		return nil, fmt.Errorf("page %d is out of range", number)
+	}
+	return r.content[start : start+r.pageSize], nil
+}
+
+// table 按 rowid 顺序读出以 root 为根的表 b-tree 中的全部行。
+func (r sqliteReader) table(root int) ([]sqliteRow, error) {
+	var rows []sqliteRow
+	visited := make(map[int]bool)
+	var walk func(number int) error
+	walk = func(number int) error {
+		if visited[number] {
+			return fmt.Errorf("page %d is referenced twice", number)
+		}
+		visited[number] = true
+		page, err := r.page(number)
+		if err != nil {
+			return err
+		}
+		offset := 0
+		if number == 1 {
+			offset = 100
+		}
+		kind := page[offset]
+		headerSize := 8
+		if kind == sqliteInteriorTable {
+			headerSize = 12
+		} else if kind != sqliteLeafTable {
+			return fmt.Errorf("page %d is not a table b-tree page", number)
+		}
+		count := int(binary.BigEndian.Uint16(page[offset+3 : offset+5]))
+		if offset+headerSize+2*count > len(page) {
+			return fmt.Errorf("page %d has too many cells", number)
+		}
+		for index := range count {
+			pointer := offset + headerSize + 2*index
+			cell := int(binary.BigEndian.Uint16(page[pointer : pointer+2]))
+			if cell >= len(page) {
+				return fmt.Errorf("page %d has an invalid cell pointer", number)
+			}
+			if kind == sqliteInteriorTable {
+				if cell+4 > len(page) {
+					return fmt.Errorf("page %d has an invalid cell", number)
+				}
+				if err := walk(int(binary.BigEndian.Uint32(page[cell : cell+4]))); err != nil {
+					return err
+				}
+				continue
+			}
+			row, err := r.leafCell(page[cell:])
+			if err != nil {
+				return fmt.Errorf("page %d: %w", number, err)
+			}
+			rows = append(rows, row)
+		}
+		if kind == sqliteInteriorTable {
+			return walk(int(binary.BigEndian.Uint32(page[offset+8 : offset+12])))
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// leafCell 解析表叶子页中的一个单元格，按需读取溢出页。
+func (r sqliteReader) leafCell(cell []byte) (sqliteRow, error) {
+	size, n := readVarint(cell)
+	if n == 0 {
+		return sqliteRow{}, errors.New("truncated cell")
+	}
+	rowid, m := readVarint(cell[n:])
+	if m == 0 {
+		return sqliteRow{}, errors.New("truncated cell")
+	}
+	cell = cell[n+m:]
+	if size > uint64(len(r.content)) {
+		return sqliteRow{}, errors.New("invalid payload size")
+	}
+	payloadSize := int(size)
+	local := localPayload(payloadSize, r.usable)
+	if local > len(cell) || (local < payloadSize && local+4 > len(cell)) {
+		return sqliteRow{}, errors.New("truncated cell")
+	}
+	payload := append([]byte(nil), cell[:local]...)
+	if local < payloadSize {
+		next := int(binary.BigEndian.Uint32(cell[local : local+4]))
+		for len(payload) < payloadSize {
+			page, err := r.page(next)
+			if err != nil {
+				return sqliteRow{}, fmt.Errorf("overflow: %w", err)
+			}
+			chunk := min(payloadSize-len(payload), r.usable-4)
+			payload = append(payload, page[4:4+chunk]...)
+			next = int(binary.BigEndian.Uint32(page[:4]))
+		}
+	}
+	values, err := decodeRecord(payload)
+	if err != nil {
+		return sqliteRow{}, err
+	}
+	return sqliteRow{rowid: int64(rowid), values: values}, nil
+}
+
+// localPayload 返回表叶子单元格中保存在页内的负载字节数，其余部分写入溢出页。
+func localPayload(size int, usable int) int {
+	maxLocal := usable - 35
+	if size <= maxLocal {
+		return size
+	}
+	minLocal := (usable-12)*32/255 - 23
+	local := minLocal + (size-minLocal)%(usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	return local
+}
+
+// decodeRecord 解析记录格式的负载。
+func decodeRecord(payload []byte) ([]any, error) {
+	headerSize, n := readVarint(payload)
+	if n == 0 || headerSize > uint64(len(payload)) {
+		return nil, errors.New("invalid record header")
+	}
+	var types []uint64
+	for position := n; position < int(headerSize); {
+		serial, m := readVarint(payload[position:int(headerSize)])
+		if m == 0 {
+			return nil, errors.New("invalid record header")
+		}
+		types = append(types, serial)
+		position += m
+	}
+
+	body := payload[headerSize:]
+	values := make([]any, 0, len(types))
+	for _, serial := range types {
+		length := serialLength(serial)
+		if length > len(body) {
+			return nil, errors.New("truncated record")
+		}
+		field := body[:length]
+		body = body[length:]
+		switch {
+		case serial == 0:
+			values = append(values, nil)
+		case serial <= 6:
+			var value int64
+			for _, b := range field {
+				value = value<<8 | int64(b)
+			}
+			// 按字段宽度做符号扩展。
+			shift := 64 - 8*uint(length)
+			values = append(values, value<<shift>>shift)
+		case serial == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case serial == 8:
+			values = append(values, int64(0))
+		case serial == 9:
+			values = append(values, int64(1))
+		case serial == 10 || serial == 11:
+			return nil, fmt.Errorf("reserved serial type %d", serial)
+		case serial%2 == 0:
+			values = append(values, append([]byte(nil), field...))
+		default:
+			values = append(values, string(field))
+		}
+	}
+	return values, nil
+}
+
+// serialLength 返回序列类型对应的字段字节数。
+func serialLength(serial uint64) int {
+	switch {
+	case serial <= 4:
+		return int(serial)
+	case serial == 5:
+		return 6
+	case serial == 6 || serial == 7:
+		return 8
+	case serial < 12:
+		return 0
+	}
+	return int((serial - 12) / 2)
+}
+
+// encodeRecord 把列值编码为记录格式，支持 nil、int64、float64、string 与 []byte。
+func encodeRecord(values []any) ([]byte, error) {
+	var types []byte
+	var body []byte
+	for _, value := range values {
+		switch typed := value.(type) {
+		case nil:
+			types = appendVarint(types, 0)
+		case int64:
+			serial, field := encodeInt(typed)
+			types = appendVarint(types, serial)
+			body = append(body, field...)
+		case float64:
+			types = appendVarint(types, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(typed))
+		case string:
+			types = appendVarint(types, uint64(13+2*len(typed)))
+			body = append(body, typed...)
+		case []byte:
+			types = appendVarint(types, uint64(12+2*len(typed)))
+			body = append(body, typed...)
+		default:
+			return nil, fmt.Errorf("unsupported column value %T", value)
+		}
+	}
+	// 头部长度包含长度字段本身。
+	headerSize := len(types) + 1
+	for varintLength(uint64(headerSize)) != headerSize-len(types) {
+		headerSize = len(types) + varintLength(uint64(headerSize))
+	}
+	record := appendVarint(nil, uint64(headerSize))
+	record = append(record, types...)
+	return append(record, body...), nil
+}
+
+// encodeInt 选择能容纳 value 的最短整数序列类型。
+func encodeInt(value int64) (uint64, []byte) {
+	switch {
+	case value == 0:
+		return 8, nil
+	case value == 1:
+		return 9, nil
+	case value >= math.MinInt8 && value <= math.MaxInt8:
+		return 1, []byte{byte(value)}
+	case value >= math.MinInt16 && value <= math.MaxInt16:
+		return 2, binary.BigEndian.AppendUint16(nil, uint16(value))
+	case value >= -1<<23 && value < 1<<23:
+		return 3, []byte{byte(value >> 16), byte(value >> 8), byte(value)}
+	case value >= math.MinInt32 && value <= math.MaxInt32:
+		return 4, binary.BigEndian.AppendUint32(nil, uint32(value))
+	case value >= -1<<47 && value < 1<<47:
+		return 5, binary.BigEndian.AppendUint64(nil, uint64(value))[2:]
+	}
+	return 6, binary.BigEndian.AppendUint64(nil, uint64(value))
+}
+
+// readVarint 读取 SQLite 的变长整数（大端，最多 9 字节），返回值与字节数，数据不完整时字节数为 0。
+func readVarint(data []byte) (uint64, int) {
+	var value uint64
+	for index := range 8 {
+		if index >= len(data) {
+			return 0, 0
+		}
+		value = value<<7 | uint64(data[index]&0x7f)
+		if data[index]&0x80 == 0 {
+			return value, index + 1
+		}
+	}
+	if len(data) < 9 {
+		return 0, 0
+	}
+	return value<<8 | uint64(data[8]), 9
+}
+
+// appendVarint 追加 SQLite 的变长整数。
+func appendVarint(data []byte, value uint64) []byte {
+	if value > 1<<56-1 {
+		var buffer [9]byte
+		buffer[8] = byte(value)
+		value >>= 8
+		for index := 7; index >= 0; index-- {
+			buffer[index] = byte(value&0x7f) | 0x80
+			value >>= 7
+		}
+		return append(data, buffer[:]...)
+	}
+	var groups []byte
+	for {
+		groups = append(groups, byte(value&0x7f))
+		value >>= 7
+		if value == 0 {
+			break
+		}
+	}
+	for index := len(groups) - 1; index >= 0; index-- {
+		b := groups[index]
+		if index > 0 {
+			b |= 0x80
+		}
+		data = append(data, b)
+	}
+	return data
+}
+
+// varintLength 返回 value 编码为变长整数的字节数。
+func varintLength(value uint64) int {
+	return len(appendVarint(nil, value))
+}
+
+// sqliteBuilder 按页构建数据库文件，pages[0] 为第 1 页。
+type sqliteBuilder struct {
+	pages [][]byte
+}
+
+// allocate 分配一个新页，返回页号。
+func (b *sqliteBuilder) allocate() int {
+	b.pages = append(b.pages, make([]byte, sqlitePageSize))
+	return len(b.pages)
+}
+
+// buildSQLite 生成只包含 scans 与 languages 两张表的数据库文件。
+func buildSQLite(tables sqliteTables) ([]byte, error) {
+	builder := &sqliteBuilder{}
+	builder.allocate() // 第 1 页为 sqlite_schema 的根页，表写完后再填充。
+	scansRoot, err := builder.table(tables.scans)
+	if err != nil {
+		return nil, err
+	}
+	languagesRoot, err := builder.table(tables.languages)
+	if err != nil {
+		return nil, err
+	}
+	schema := []sqliteRow{
+		{rowid: 1, values: []any{"table", "scans", "scans", int64(scansRoot), sqliteScansSQL}},
+		{rowid: 2, values: []any{"table", "languages", "languages", int64(languagesRoot), sqliteLanguagesSQL}},
+	}
+	var cells [][]byte
+	for _, row := range schema {
+		cell, err := builder.leafCell(row)
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, cell)
+	}
+	if !writeBTreePage(builder.pages[0], 100, sqliteLeafTable, cells, 0) {
+		return nil, errors.New("schema does not fit on the first page")
+	}
+
+	header := builder.pages[0][:100]
+	copy(header, sqliteMagic)
+	binary.BigEndian.PutUint16(header[16:], sqlitePageSize)
+	header[18], header[19] = 1, 1 // 回滚日志模式
+	header[21], header[22], header[23] = 64, 32, 32
+	changeCounter := tables.changeCounter + 1
+	binary.BigEndian.PutUint32(header[24:], changeCounter)
+	binary.BigEndian.PutUint32(header[28:], uint32(len(builder.pages)))
+	binary.BigEndian.PutUint32(header[40:], 1) // schema cookie
+	binary.BigEndian.PutUint32(header[44:], 4) // schema format
+	binary.BigEndian.PutUint32(header[56:], 1) // UTF-8
+	binary.BigEndian.PutUint32(header[92:], changeCounter)
+	binary.BigEndian.PutUint32(header[96:], sqliteVersion)
+	return bytes.Join(builder.pages, nil), nil
+}
+
+// table 写入一张表的 b-tree，返回根页号：先把行按 rowid 顺序装入叶子页，再逐层构建内部页直到只剩一个根页。
+func (b *sqliteBuilder) table(rows []sqliteRow) (int, error) {
+	type child struct {
+		page   int
+		maxKey int64
+	}
+	var level []child
+	var cells [][]byte
+	var lastKey int64
+	flush := func() {
+		page := b.allocate()
+		writeBTreePage(b.pages[page-1], 0, sqliteLeafTable, cells, 0)
+		level = append(level, child{page: page, maxKey: lastKey})
+		cells = nil
+	}
+	for _, row := range rows {
+		cell, err := b.leafCell(row)
+		if err != nil {
+			return 0, err
+		}
+		if !fitsBTreePage(8, append(cells, cell)) {
+			flush()
+		}
+		cells = append(cells, cell)
+		lastKey = row.rowid
+	}
+	if len(cells) > 0 || len(level) == 0 {
+		flush()
+	}
+
+	for len(level) > 1 {
+		var parents []child
+		var group []child
+		flushGroup := func() {
+			cells := make([][]byte, 0, len(group)-1)
+			for _, item := range group[:len(group)-1] {
+				cells = append(cells, appendVarint(binary.BigEndian.AppendUint32(nil, uint32(item.page)), uint64(item.maxKey)))
+			}
+			page := b.allocate()
+			last := group[len(group)-1]
+			writeBTreePage(b.pages[page-1], 0, sqliteInteriorTable, cells, last.page)
+			parents = append(parents, child{page: page, maxKey: last.maxKey})
+			group = nil
+		}
+		for _, item := range level {
+			// 加入 item 后，组内除最后一个之外的子页各占一个单元格。
+			if len(group) > 0 {
+				cells := make([][]byte, 0, len(group))
+				for _, existing := range group {
+					cells = append(cells, appendVarint(make([]byte, 4), uint64(existing.maxKey)))
+				}
+				if !fitsBTreePage(12, cells) {
+					flushGroup()
+				}
+			}
+			group = append(group, item)
+		}
+		flushGroup()
+		level = parents
+	}
+	return level[0].page, nil
+}
+
+// leafCell 把一行编码为表叶子单元格，超出页内容量的负载写入新分配的溢出页。
+func (b *sqliteBuilder) leafCell(row sqliteRow) ([]byte, error) {
+	payload, err := encodeRecord(row.values)
+	if err != nil {
+		return nil, err
+	}
+	cell := appendVarint(nil, uint64(len(payload)))
+	cell = appendVarint(cell, uint64(row.rowid))
+	local := localPayload(len(payload), sqlitePageSize)
+	cell = append(cell, payload[:local]...)
+	if local == len(payload) {
+		return cell, nil
+	}
+
+	rest := payload[local:]
+	first := b.allocate()
+	cell = binary.BigEndian.AppendUint32(cell, uint32(first))
+	for page := first; ; {
+		chunk := min(len(rest), sqlitePageSize-4)
+		copy(b.pages[page-1][4:], rest[:chunk])
+		rest = rest[chunk:]
+		if len(rest) == 0 {
+			return cell, nil
+		}
+		next := b.allocate()
+		binary.BigEndian.PutUint32(b.pages[page-1][:4], uint32(next))
+		page = next
+	}
+}
+
+// fitsBTreePage 判断单元格能否装入一个页（headerSize 为页头长度，第 1 页之外的页）。
+func fitsBTreePage(headerSize int, cells [][]byte) bool {
+	used := headerSize
+	for _, cell := range cells {
+		used += 2 + len(cell)
+	}
+	return used <= sqlitePageSize
+}
+
+// writeBTreePage 在 page 的 offset 处写入 b-tree 页头、单元格指针数组与单元格（单元格从页尾向前排列），
+// rightmost 为内部页的最右子页。单元格装不下时返回 false。
+func writeBTreePage(page []byte, offset int, kind byte, cells [][]byte, rightmost int) bool {
+	headerSize := 8
+	if kind == sqliteInteriorTable {
+		headerSize = 12
+	}
+	if !fitsBTreePage(offset+headerSize, cells) {
+		return false
+	}
+	content := len(page)
+	for index, cell := range cells {
+		content -= len(cell)
+		copy(page[content:], cell)
+		binary.BigEndian.PutUint16(page[offset+headerSize+2*index:], uint16(content))
+	}
+	page[offset] = kind
+	binary.BigEndian.PutUint16(page[offset+3:], uint16(len(cells)))
+	binary.BigEndian.PutUint16(page[offset+5:], uint16(content%65536))
+	if kind == sqliteInteriorTable {
+		binary.BigEndian.PutUint32(page[offset+8:], uint32(rightmost))
+	}
+	return true
+}
+
+// sqliteDatabasePath 判断路径是否按 SQLite 数据库处理：已存在的 SQLite 文件，或 .db、.sqlite、.sqlite3 后缀。
+func sqliteDatabasePath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite", ".sqlite3":
+		return true
+	}
+	return isSQLiteFile(path)
+}
@@ -1,10 +1,19 @@
 package languages
 
 import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"unicode/utf8"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
 // analyzeText 是测试辅助函数，用于快速运行某个分析器并返回统计结果。
@@ -48,6 +57,109 @@ func TestGoStringContainsCommentToken(t *testing.T) {
 	}
 }
 
+// TestGoDirectives 验证 Go 编译指令行按 GoDirectives 计为注释、preprocessor 或代码，
+// 普通注释、// go:xxx（带空格）与块注释中的指令文本不受影响。
+func TestGoDirectives(t *testing.T) {
+	content := "//go:build linux && !cgo\n" +
+		"// +build linux\n" +
+		"\n" +
+		"// Package main is a demo.\n" +
+		"package main\n" +
+		"\n" +
+		"//go:generate stringer -type=Kind\n" +
+		"// go:not-a-directive\n" +
+		"/*\n" +
+		"//go:embed inside comment\n" +
+		"*/\n" +
+		"    //go:embed static\n" +
+		"var static string //go:noinline\n"
+
+	cases := []struct {
+		mode         string
+		code         int64
+		comment      int64
+		preprocessor int64
+	}{
+		{mode: "", code: 2, comment: 10, preprocessor: 0},
+		{mode: GoDirectivesDirective, code: 2, comment: 6, preprocessor: 4},
+		{mode: GoDirectivesCode, code: 6, comment: 6, preprocessor: 0},
+	}
+	for _, tc := range cases {
+		metrics := analyzeText(t, &GoAnalyzer{Options: Options{GoDirectives: tc.mode}}, content)
+		if metrics.Code != tc.code || metrics.Comment != tc.comment || metrics.Preprocessor != tc.preprocessor || metrics.Blank != 2 {
+			t.Fatalf("mode %q: unexpected metrics: %+v", tc.mode, metrics)
+		}
+	}
+
+	if _, err := (&GoAnalyzer{Options: Options{GoDirectives: "pragma"}}).Analyze(strings.NewReader("package main\n")); err == nil {
+		t.Fatalf("expected unknown go directives mode to be rejected")
+	}
+}
+
+// TestJSX 验证 JSX 元素文本中的 '、// 不会改变字符串/注释状态，{/* ... */} 计为注释行，
+// 且 .tsx 识别 JSX 而 .ts 中的 <T>expr 类型断言与泛型不受影响。
+func TestJSX(t *testing.T) {
+	content := strings.Join([]string{
+		`export function App({ items }) {`,
+		`  const title = items.length < 2 ? "few" : "many";`,
+		`  return (`,
+		`    <div className="app" style={{ color: "red" }}>`,
+		`      {/* header comment */}`,
+		`      <h1>Don't panic: see http://example.com</h1>`,
+		`      {/*`,
+		`        multi-line JSX comment`,
+		`      */}`,
+		`      {items.map((item) => <Item key={item.id} {...item} />)}`,
+		`      <>`,
+		`        text with 'quote`,
+		`      </>`,
+		`    </div>`,
+		`  );`,
+		`}`,
+		`// trailing comment`,
+		``,
+	}, "\n")
+	expected := []string{
+		"code", "code", "code", "code", "comment",
+		"code", "comment", "comment", "comment", "code",
+		"code", "code", "code", "code", "code",
+		"code", "comment",
+	}
+
+	registry := NewRegistryWithOptions(Options{Annotate: true})
+	for _, path := range []string{"App.jsx", "App.js", "App.tsx"} {
+		analyzer, ok := registry.AnalyzerForFile(path)
+		if !ok {
+			t.Fatalf("no analyzer for %s", path)
+		}
+		classes := analyzeText(t, analyzer, content).LineClasses
+		if strings.Join(classes, ",") != strings.Join(expected, ",") {
+			t.Fatalf("%s: unexpected line classes:\n%v\n%v", path, classes, expected)
+		}
+	}
+
+	generics := strings.Join([]string{
+		`const identity = <T,>(x: T): T => x;`,
+		`const wrap = <T extends object>(x: T) => [x];`,
+		`let fn: <T>(x: T) => T = identity;`,
+		`const less = a <b;`,
+		`// it's a comment`,
+		``,
+	}, "\n")
+	assertions := "const value = <string>input;\n// it's a comment\n"
+	tsx, _ := registry.AnalyzerForFile("util.tsx")
+	ts, _ := registry.AnalyzerForFile("util.ts")
+	for name, metrics := range map[string]model.LineMetrics{
+		"tsx generics":  analyzeText(t, tsx, generics),
+		"ts generics":   analyzeText(t, ts, generics),
+		"ts assertions": analyzeText(t, ts, assertions),
+	} {
+		if metrics.Comment != 1 || metrics.Code != metrics.Total-1 {
+			t.Fatalf("%s: unexpected metrics: %+v", name, metrics)
+		}
+	}
+}
+
 // TestRustNestedBlockComment 验证 Rust 嵌套块注释。
 func TestRustNestedBlockComment(t *testing.T) {
 	analyzer := &RustAnalyzer{}
@@ -62,6 +174,26 @@ func TestRustNestedBlockComment(t *testing.T) {
 	}
 }
 
+// TestRustByteStringsAndLifetimes 验证字节字符串、字节字符、多字符转义与生命周期不会改变跨行字符串状态。
+func TestRustByteStringsAndLifetimes(t *testing.T) {
+	analyzer := &RustAnalyzer{}
+	content := "fn longest<'a, T: 'static>(x: &'a str, y: &'a T) -> &'a str {\n" +
+		"    let bytes = b\"it's /* not a comment\";\n" +
+		"    let quote = b'\"';\n" +
+		"    let chars = ['\\x7f','\"', '\\u{1F600}', '\\'', b'\\\\'];\n" +
+		"    'outer: loop { break 'outer; }\n" +
+		"    let ptr = for_each(r#\"raw \"quoted\" text\"#);\n" +
+		"    x\n" +
+		"}\n" +
+		"// trailing comment\n"
+
+	metrics := analyzeText(t, analyzer, content)
+
+	if metrics.Total != 9 || metrics.Code != 8 || metrics.Comment != 1 || metrics.Blank != 0 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+}
+
 // TestRubyBeginEndComment 验证 Ruby 的 =begin/=end 块注释。
 func TestRubyBeginEndComment(t *testing.T) {
 	analyzer := &RubyAnalyzer{}
@@ -77,6 +209,112 @@ func TestRubyBeginEndComment(t *testing.T) {
 	}
 }
 
+// TestRubyPercentLiterals 验证 % 字面量中的 # 不会被当作注释，成对定界符可嵌套、可跨行，且取模运算不受影响。
+func TestRubyPercentLiterals(t *testing.T) {
+	analyzer := &RubyAnalyzer{}
+	content := "tags = %w(#tag (nested #x) done)\n" +
+		"puts %Q[#{name} # not comment]\n" +
+		"syms = %i<a b> # comment\n" +
+		"text = %q{\n" +
+		"  # still text\n" +
+		"}\n" +
+		"bar = %|#pipe|\n" +
+		"rest = count%(size) # comment\n" +
+		"rest %= 3 # comment\n"
+
+	metrics := analyzeText(t, analyzer, content)
+
+	if metrics.Total != 9 || metrics.Code != 9 || metrics.Comment != 3 || metrics.Blank != 0 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+}
+
+// TestHeredocs 验证各风格的 heredoc 正文不会被当作注释，缩进、引号与同一行多个 heredoc 的结束标记都能正确匹配，
+// 左移运算与 shell 的 <<< here-string 不受影响。
+func TestHeredocs(t *testing.T) {
+	shell := func(style string) Analyzer {
+		return &GenericAnalyzer{Definition: LanguageDefinition{Name: "Test", LineComments: []string{"#", "//"}, Heredoc: style}}
+	}
+	cases := []struct {
+		name     string
+		analyzer Analyzer
+		content  string
+		code     int64
+		comment  int64
+	}{
+		{
+			name:     "ruby squiggly and quoted",
+			analyzer: &RubyAnalyzer{},
+			content:  "text = <<~EOS\n  # not comment\n=begin\n  EOS\nraw = <<-'RAW' # comment\n  #{x}\n  RAW\n# comment\n",
+			code:     7,
+			comment:  2,
+		},
+		{
+			name:     "ruby multiple heredocs on one line",
+			analyzer: &RubyAnalyzer{},
+			content:  "call(<<A, <<B)\n# a\nA\n# b\nB\n# comment\n",
+			code:     5,
+			comment:  1,
+		},
+		{
+			name:     "ruby shift is not heredoc",
+			analyzer: &RubyAnalyzer{},
+			content:  "list <<value\nx = 1 << 2\n# comment\n",
+			code:     2,
+			comment:  1,
+		},
+		{
+			name:     "shell tabs only",
+			analyzer: shell(HeredocShell),
+			content:  "cat <<-EOF\n# text\n  EOF\n\tEOF\n# comment\ncat << 'END'\n# text\nEND\nread x <<< \"# here\"\n# comment\n",
+			code:     8,
+			comment:  3,
+		},
+		{
+			name:     "perl",
+			analyzer: shell(HeredocPerl),
+			content:  "print <<\"EOF\";\n# text\nEOF\nprint <<~EOT;\n    # text\n    EOT\n$x = $y <<2; # comment\n",
+			code:     7,
+			comment:  1,
+		},
+		{
+			name:     "php suffix",
+			analyzer: shell(HeredocPHP),
+			content:  "$a = <<<EOT\n  // text\n  EOTX\n  EOT;\n$b = <<<'NOW'\n# text\nNOW;\n// comment\n",
+			code:     7,
+			comment:  1,
+		},
+		{
+			name:     "hcl",
+			analyzer: shell(HeredocHCL),
+			content:  "policy = <<-EOT\n  # text\n  EOT\nuser_data = <<EOF\n# text\nEOF\n# comment\n",
+			code:     6,
+			comment:  1,
+		},
+		{
+			name:     "disabled",
+			analyzer: shell(""),
+			content:  "cat <<EOF\n# text\nEOF\n",
+			code:     2,
+			comment:  1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics := analyzeText(t, tc.analyzer, tc.content)
+			if metrics.Code != tc.code || metrics.Comment != tc.comment {
+				t.Fatalf("unexpected metrics: %+v", metrics)
+			}
+		})
+	}
+
+	definition := LanguageDefinition{Name: "Bad", Extensions: []string{".bad"}, Heredoc: "bash"}
+	if err := definition.normalize(); err == nil {
+		t.Fatalf("expected unsupported heredoc style to be rejected")
+	}
+}
+
 // TestPythonStringAndComment 验证 Python 字符串中 # 与真实注释的区分。
 func TestPythonStringAndComment(t *testing.T) {
 	analyzer := &PythonAnalyzer{}
@@ -90,6 +328,314 @@ func TestPythonStringAndComment(t *testing.T) {
 	}
 }
 
+// TestPythonStringPrefixes 验证 r/b/f/rb 等前缀、f-string 表达式中的嵌套引号（含与外层相同的引号），
+// 以及未闭合的单行字符串不会让后续各行的字符串状态错乱。
+func TestPythonStringPrefixes(t *testing.T) {
+	content := strings.Join([]string{
+		`pattern = r"\d+\"" # raw string keeps escaped quote`,
+		`data = rb'\x00' + b"it's" + Rb'#'`,
+		`label = f"{user["name"]}'s # not comment {{literal}}"`,
+		`nested = f'{", ".join(f"{x!r}" for x in items)}'`,
+		`spec = f"{value:{width}.{precision}}" # comment`,
+		`report = f"""`,
+		`{data["it's"]} # still string`,
+		`"""`,
+		`broken = "unterminated`,
+		`# real comment`,
+		`joined = 'continued \`,
+		`# inside string'`,
+		``,
+	}, "\n")
+	expected := []string{
+		"mixed", "code", "code", "code", "mixed",
+		"code", "code", "code", "code", "comment",
+		"code", "code",
+	}
+
+	classes := analyzeText(t, &PythonAnalyzer{Options: Options{Annotate: true}}, content).LineClasses
+	if strings.Join(classes, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected line classes:\n%v\n%v", classes, expected)
+	}
+}
+
+// TestTraceStates 验证逐行状态与逐行分类一一对应，跨行的块注释、字符串在行首行尾状态中体现，
+// shebang 行与 cgo 前导代码等不经过语言 FSM 的行同样有记录。
+func TestTraceStates(t *testing.T) {
+	cases := []struct {
+		name     string
+		analyzer Analyzer
+		content  string
+		expected []string
+	}{
+		{
+			name:     "go",
+			analyzer: &GoAnalyzer{Options: Options{Annotate: true, TraceStates: true}},
+			content:  "x := `a\nb` /* c\n*/ y := 1\n\n",
+			expected: []string{"code>raw-string", "raw-string>block-comment", "block-comment>code", "code>code"},
+		},
+		{
+			name:     "rust nested comment",
+			analyzer: &RustAnalyzer{Options: Options{Annotate: true, TraceStates: true}},
+			content:  "/* a /* b\n*/ c */\n",
+			expected: []string{"code>block-comment(2)", "block-comment(2)>code"},
+		},
+		{
+			name:     "python shebang and docstring",
+			analyzer: &PythonAnalyzer{Options: Options{Annotate: true, TraceStates: true, PythonDocstrings: true}},
+			content:  "#!/usr/bin/env python3\n\"\"\"doc\nmore\"\"\"\n",
+			expected: []string{"shebang>shebang", "code>docstring", "docstring>code"},
+		},
+		{
+			name:     "cgo preamble",
+			analyzer: &GoAnalyzer{Options: Options{Annotate: true, TraceStates: true, CgoPreamble: true}},
+			content:  "// #include <stdio.h>\nimport \"C\"\n",
+			expected: []string{"code>code", "code>code"},
+		},
+	}
+
+	for _, item := range cases {
+		metrics := analyzeText(t, item.analyzer, item.content)
+		states := make([]string, 0, len(metrics.LineStates))
+		for _, state := range metrics.LineStates {
+			states = append(states, state.Start+">"+state.End)
+		}
+		if strings.Join(states, ",") != strings.Join(item.expected, ",") {
+			t.Fatalf("%s: unexpected states:\n%v\n%v", item.name, states, item.expected)
+		}
+		if len(metrics.LineClasses) != len(metrics.LineStates) {
+			t.Fatalf("%s: %d classes for %d states", item.name, len(metrics.LineClasses), len(metrics.LineStates))
+		}
+	}
+
+	if metrics := analyzeText(t, &GoAnalyzer{}, "x := 1\n"); metrics.LineStates != nil {
+		t.Fatalf("states recorded without TraceStates: %+v", metrics.LineStates)
+	}
+}
+
+// TestTrailingEmptyLine 验证默认与 cloc 一致，末尾换行符不产生额外的行；开启 TrailingEmptyLine 后末尾换行符之后计一个空白行。
+func TestTrailingEmptyLine(t *testing.T) {
+	cases := []struct {
+		content  string
+		total    int64
+		trailing int64
+	}{
+		{content: "", total: 0, trailing: 0},
+		{content: "x = 1", total: 1, trailing: 1},
+		{content: "x = 1\n", total: 1, trailing: 2},
+		{content: "x = 1\r\n\n", total: 2, trailing: 3},
+		{content: "\"\"\"open\n", total: 1, trailing: 2},
+		{content: "#!/bin/sh\n", total: 1, trailing: 2},
+	}
+
+	for _, item := range cases {
+		base := analyzeText(t, &PythonAnalyzer{}, item.content)
+		if base.Total != item.total {
+			t.Fatalf("%q: expected %d lines, got %+v", item.content, item.total, base)
+		}
+		metrics := analyzeText(t, &PythonAnalyzer{Options: Options{TrailingEmptyLine: true}}, item.content)
+		if metrics.Total != item.trailing || metrics.Blank != base.Blank+item.trailing-item.total {
+			t.Fatalf("%q: expected %d lines with trailing empty line, got %+v", item.content, item.trailing, metrics)
+		}
+	}
+}
+
+// TestByteOrderMark 验证文件开头的 UTF-8 BOM 会被跳过，首行注释仍计为注释。
+func TestByteOrderMark(t *testing.T) {
+	cases := []struct {
+		analyzer Analyzer
+		content  string
+	}{
+		{analyzer: &GoAnalyzer{Options: Options{Annotate: true}}, content: "\uFEFF// Package main.\npackage main\n"},
+		{analyzer: &PythonAnalyzer{Options: Options{Annotate: true}}, content: "\uFEFF# comment\nimport os\n"},
+		{analyzer: &GenericAnalyzer{Options: Options{Annotate: true}, Definition: LanguageDefinition{Name: "Shell", LineComments: []string{"#"}}}, content: "\uFEFF# comment\necho hi\n"},
+	}
+
+	for _, item := range cases {
+		classes := analyzeText(t, item.analyzer, item.content).LineClasses
+		if strings.Join(classes, ",") != "comment,code" {
+			t.Fatalf("%s: unexpected line classes %v", item.analyzer.Name(), classes)
+		}
+	}
+	if interpreter := ShebangInterpreter([]byte("\uFEFF#!/bin/sh\n")); interpreter != "sh" {
+		t.Fatalf("unexpected interpreter after BOM: %q", interpreter)
+	}
+}
+
+// TestLineEndings 验证 \n、\r\n 与经典 Mac 的单独 \r 换行（包括混用）都按行切分。
+func TestLineEndings(t *testing.T) {
+	cases := []string{
+		"// comment\npackage main\n\nvar x = 1\n",
+		"// comment\r\npackage main\r\n\r\nvar x = 1\r\n",
+		"// comment\rpackage main\r\rvar x = 1\r",
+		"// comment\r\npackage main\r\rvar x = 1",
+	}
+
+	for _, content := range cases {
+		metrics := analyzeText(t, &GoAnalyzer{Options: Options{Annotate: true}}, content)
+		if metrics.Total != 4 || metrics.Code != 2 || metrics.Comment != 1 || metrics.Blank != 1 {
+			t.Fatalf("%q: unexpected metrics %+v", content, metrics)
+		}
+		if strings.Join(metrics.LineClasses, ",") != "comment,code,blank,code" {
+			t.Fatalf("%q: unexpected line classes %v", content, metrics.LineClasses)
+		}
+	}
+
+	// 逐字节读取时，\r 与后面的 \n 分属两次读取，仍应识别为一个换行符。
+	content, err := io.ReadAll(&crLineReader{reader: iotest.OneByteReader(strings.NewReader("a\r\nb\rc\r\r\nd\r"))})
+	if err != nil || string(content) != "a\r\nb\nc\n\r\nd\n" {
+		t.Fatalf("unexpected converted content %q (%v)", content, err)
+	}
+}
+
+// TestUnicodeBlankLines 验证只包含 NBSP、全角空格或零宽字符的行计为空白行，这些字符在代码行首尾也不影响分类与 ULOC 去重。
+func TestUnicodeBlankLines(t *testing.T) {
+	content := "x := 1\n\u00A0\u00A0\n\u3000\n\t\u200B \n\u2060\u200D\n\u3000x := 1\u00A0\n// note\u200B\n"
+	analyzers := []Analyzer{
+		&GoAnalyzer{Options: Options{Annotate: true}},
+		&GenericAnalyzer{Options: Options{Annotate: true}, Definition: LanguageDefinition{Name: "Shell", LineComments: []string{"//"}}},
+	}
+
+	for _, analyzer := range analyzers {
+		metrics := analyzeText(t, analyzer, content)
+		if strings.Join(metrics.LineClasses, ",") != "code,blank,blank,blank,blank,code,comment" {
+			t.Fatalf("%s: unexpected line classes %v", analyzer.Name(), metrics.LineClasses)
+		}
+	}
+	if hashCodeLine("\u3000x := 1\u00A0") != hashCodeLine("x := 1") {
+		t.Fatalf("unicode whitespace must not affect code line hash")
+	}
+}
+
+// TestShebang 验证首行 shebang 在各语言中按 Options.Shebang 一致计数，且不影响后续行的状态；
+// 只有文件开头（BOM 之后）的 #! 才是 shebang。
+func TestShebang(t *testing.T) {
+	analyzers := map[string]func(Options) Analyzer{
+		"python":     func(options Options) Analyzer { return &PythonAnalyzer{Options: options} },
+		"ruby":       func(options Options) Analyzer { return &RubyAnalyzer{Options: options} },
+		"javascript": func(options Options) Analyzer { return &JavaScriptAnalyzer{Options: options} },
+		"typescript": func(options Options) Analyzer { return &TypeScriptAnalyzer{Options: options} },
+		"shell": func(options Options) Analyzer {
+			return &GenericAnalyzer{Options: options, Definition: LanguageDefinition{Name: "Shell", LineComments: []string{"#"}}}
+		},
+	}
+	cases := map[string]string{
+		"":          "comment",
+		"comment":   "comment",
+		"Directive": "preprocessor",
+		"code":      "code",
+	}
+
+	for name, newAnalyzer := range analyzers {
+		for mode, class := range cases {
+			metrics := analyzeText(t, newAnalyzer(Options{Annotate: true, Shebang: mode}), "\uFEFF#!/usr/bin/env -S node --title='x\nx = 1\n")
+			if strings.Join(metrics.LineClasses, ",") != class+",code" {
+				t.Fatalf("%s %q: unexpected line classes %v", name, mode, metrics.LineClasses)
+			}
+			if (class == "preprocessor") != (metrics.Preprocessor == 1) {
+				t.Fatalf("%s %q: unexpected preprocessor count %d", name, mode, metrics.Preprocessor)
+			}
+		}
+	}
+
+	metrics := analyzeText(t, &PythonAnalyzer{Options: Options{Annotate: true, Shebang: ShebangCode}}, "x = 1\n#!/bin/sh\n")
+	if strings.Join(metrics.LineClasses, ",") != "code,comment" {
+		t.Fatalf("#! after the first line must not be a shebang: %v", metrics.LineClasses)
+	}
+	if _, err := (&PythonAnalyzer{Options: Options{Shebang: "skip"}}).Analyze(strings.NewReader("")); err == nil {
+		t.Fatalf("expected error for unknown shebang mode")
+	}
+}
+
+// TestCgoPreamble 验证开启 CgoPreamble 后，紧邻 import "C" 的注释块按 C/C++ 计入 Embedded，
+// 与 import "C" 之间隔着空行或后面不是 import "C" 的注释块仍是 Go 注释；逐行标注保持原有行序。
+func TestCgoPreamble(t *testing.T) {
+	content := strings.Join([]string{
+		"// Package wrap wraps libfoo.",
+		"package wrap",
+		"",
+		"/*",
+		"#cgo LDFLAGS: -lfoo",
+		"#include <foo.h>",
+		"",
+		"static int add(int a, int b) { // helper",
+		"    return a + b;",
+		"}",
+		"*/",
+		`import "C"`,
+		"",
+		"// #include <ignored.h>",
+		"",
+		`import "C"`,
+		"// int twice(int x) { return 2 * x; }",
+		`import "C" // second preamble`,
+		"",
+		"func Add() int { return int(C.add(1, 2)) }",
+		"",
+	}, "\n")
+
+	metrics := analyzeText(t, &GoAnalyzer{Options: Options{Annotate: true, CgoPreamble: true}}, content)
+	expected := "comment,code,blank,blank,preprocessor,preprocessor,blank,mixed,code,code,blank,code,blank,comment,blank,code,code,mixed,blank,code"
+	if strings.Join(metrics.LineClasses, ",") != expected {
+		t.Fatalf("unexpected line classes:\n%v\n%v", metrics.LineClasses, expected)
+	}
+	if metrics.Total != 11 || metrics.Code != 5 || metrics.Comment != 3 || metrics.Blank != 4 {
+		t.Fatalf("unexpected go metrics: %+v", metrics)
+	}
+	if len(metrics.Embedded) != 1 || metrics.Embedded[0].Language != "C/C++" {
+		t.Fatalf("unexpected embedded metrics: %+v", metrics.Embedded)
+	}
+	if embedded := metrics.Embedded[0].Metrics; embedded.Total != 9 || embedded.Code != 4 || embedded.Preprocessor != 2 || embedded.Comment != 1 || embedded.Blank != 3 {
+		t.Fatalf("unexpected C/C++ metrics: %+v", embedded)
+	}
+
+	if metrics := analyzeText(t, &GoAnalyzer{}, content); metrics.Total != 20 || metrics.Comment != 12 || metrics.Embedded != nil {
+		t.Fatalf("preamble must stay Go comments by default: %+v", metrics)
+	}
+}
+
+// TestPythonDocstrings 验证开启 PythonDocstrings 后，模块、类与函数体开头的三引号字符串计为注释，
+// 其他位置的三引号字符串（赋值、调用参数、函数体中间）与同一行写完的函数体仍然是代码；未开启时 docstring 计为代码。
+func TestPythonDocstrings(t *testing.T) {
+	content := strings.Join([]string{
+		"#!/usr/bin/env python3",
+		`"""Module docstring.`,
+		``,
+		`Details."""`,
+		`import os`,
+		`class Foo(Base):`,
+		`    r"""Class docstring."""`,
+		`    def method(`,
+		`        self, value=(1, 2),`,
+		`    ) -> "Foo":`,
+		`        # leading comment`,
+		`        '''Method`,
+		`        docstring.'''`,
+		`        text = """not a`,
+		`        docstring"""`,
+		`        """Trailing string."""`,
+		`        return text`,
+		`def short(): """inline body"""`,
+		`print("""argument""")`,
+		``,
+	}, "\n")
+
+	classes := analyzeText(t, &PythonAnalyzer{Options: Options{PythonDocstrings: true, Annotate: true}}, content).LineClasses
+	expected := []string{
+		"comment", "comment", "comment", "comment", "code",
+		"code", "comment", "code", "code", "code",
+		"comment", "comment", "comment", "code", "code",
+		"code", "code", "code", "code",
+	}
+	if strings.Join(classes, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected line classes:\n%v\n%v", classes, expected)
+	}
+
+	metrics := analyzeText(t, &PythonAnalyzer{}, content)
+	if metrics.Comment != 2 || metrics.Code != 17 {
+		t.Fatalf("expected docstrings counted as code by default: %+v", metrics)
+	}
+}
+
 // TestSQLNestedBlockComment 验证 SQL 嵌套块注释和行注释。
 func TestSQLNestedBlockComment(t *testing.T) {
 	analyzer := &SQLAnalyzer{}
@@ -103,7 +649,59 @@ func TestSQLNestedBlockComment(t *testing.T) {
 	}
 }
 
-// TestRegistryLanguages 确认注册中心包含用户要求的 9 种语言。
+// TestSQLDialects 验证各方言对 # 注释、美元引号字符串、替代引号字符串与方括号标识符的识别，以及 auto 的判断规则。
+func TestSQLDialects(t *testing.T) {
+	postgres := "CREATE FUNCTION f() RETURNS text AS $body$\n" +
+		"  -- it's inside the body\n" +
+		"  SELECT $$don't$$;\n" +
+		"$body$ LANGUAGE sql;\n" +
+		"SELECT $1 # 2; -- xor\n"
+	mysql := "# comment\n" +
+		"SELECT 1; #trailing\n"
+	tsql := "SELECT [it's -- col] FROM #tmp;\n" +
+		"SELECT 1 -- comment\n"
+	oracle := "BEGIN\n" +
+		"  v_sql := q'[SELECT 'x' -- not a comment\n" +
+		"    FROM dual]';\n" +
+		"  v_other := nq'{a}' || Q'!it's!'; -- comment\n" +
+		"  SELECT freq'a' FROM t; -- comment\n"
+
+	cases := []struct {
+		name    string
+		dialect string
+		content string
+		code    int64
+		comment int64
+	}{
+		{name: "postgres", dialect: SQLDialectPostgres, content: postgres, code: 5, comment: 1},
+		{name: "postgres auto", dialect: "", content: postgres, code: 5, comment: 1},
+		{name: "postgres ansi", dialect: SQLDialectANSI, content: postgres, code: 4, comment: 1},
+		{name: "mysql", dialect: SQLDialectMySQL, content: mysql, code: 1, comment: 2},
+		{name: "mysql auto", dialect: SQLDialectAuto, content: mysql, code: 1, comment: 1},
+		{name: "tsql", dialect: SQLDialectTSQL, content: tsql, code: 2, comment: 1},
+		{name: "tsql mysql", dialect: SQLDialectMySQL, content: tsql, code: 2, comment: 0},
+		{name: "oracle", dialect: SQLDialectOracle, content: oracle, code: 5, comment: 2},
+		{name: "oracle auto", dialect: SQLDialectAuto, content: oracle, code: 5, comment: 2},
+		{name: "oracle ansi", dialect: SQLDialectANSI, content: oracle, code: 5, comment: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics := analyzeText(t, &SQLAnalyzer{Options: Options{SQLDialect: tc.dialect}}, tc.content)
+			if metrics.Code != tc.code || metrics.Comment != tc.comment {
+				t.Fatalf("unexpected metrics: %+v", metrics)
+			}
+		})
+	}
+
+	if _, err := ParseSQLDialect("sqlite"); err == nil {
+		t.Fatalf("expected unknown dialect to be rejected")
+	}
+	if dialect, err := ParseSQLDialect(" TSQL "); err != nil || dialect != SQLDialectTSQL {
+		t.Fatalf("unexpected dialect %q: %v", dialect, err)
+	}
+}
+
+// TestRegistryLanguages 确认注册中心包含用户要求的 9 种语言，且均声明了语法能力。
 func TestRegistryLanguages(t *testing.T) {
 	registry := NewRegistry()
 	languages := registry.Languages()
@@ -112,6 +710,12 @@ func TestRegistryLanguages(t *testing.T) {
 		t.Fatalf("unexpected language count: %d", len(languages))
 	}
 
+	for _, language := range languages {
+		if language.Capabilities == nil || len(language.Capabilities.LineComments) == 0 || len(language.Capabilities.StringDelimiters) == 0 {
+			t.Fatalf("missing capabilities for %s", language.Name)
+		}
+	}
+
 	requiredExtensions := []string{".go", ".js", ".ts", ".py", ".rs", ".rb", ".java", ".cpp", ".sql"}
 	for _, extension := range requiredExtensions {
 		if _, ok := registry.AnalyzerForFile("x" + extension); !ok {
@@ -119,3 +723,545 @@ func TestRegistryLanguages(t *testing.T) {
 		}
 	}
 }
+
+// TestRegistryOverrides 验证覆盖设置只作用于副本，并在并发请求间互不影响。
+func TestRegistryOverrides(t *testing.T) {
+	base := NewRegistry()
+
+	var wait sync.WaitGroup
+	for index := 0; index < 8; index++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			registry, err := base.WithOverrides(RegistryOverrides{
+				DisabledLanguages: []string{"python"},
+				Extensions:        map[string]string{"inc": "c/c++", ".go": "Rust"},
+			})
+			if err != nil {
+				t.Errorf("apply overrides failed: %v", err)
+				return
+			}
+			if _, ok := registry.AnalyzerForFile("x.py"); ok {
+				t.Errorf("expected python to be disabled")
+			}
+			if analyzer, ok := registry.AnalyzerForFile("x.inc"); !ok || analyzer.Name() != "C/C++" {
+				t.Errorf("expected .inc to map to C/C++")
+			}
+			if analyzer, ok := registry.AnalyzerForFile("x.go"); !ok || analyzer.Name() != "Rust" {
+				t.Errorf("expected .go to map to Rust")
+			}
+		}()
+	}
+	wait.Wait()
+
+	if analyzer, ok := base.AnalyzerForFile("x.py"); !ok || analyzer.Name() != "Python" {
+		t.Fatalf("base registry must not be modified")
+	}
+	if _, ok := base.AnalyzerForFile("x.inc"); ok {
+		t.Fatalf("base registry must not be modified")
+	}
+	if len(base.Languages()) != 9 {
+		t.Fatalf("unexpected base language count: %d", len(base.Languages()))
+	}
+
+	only, err := base.WithOverrides(RegistryOverrides{EnabledLanguages: []string{"go", "Rust"}})
+	if err != nil || len(only.Languages()) != 2 {
+		t.Fatalf("unexpected enabled languages: %v %v", only.Languages(), err)
+	}
+	if _, err := base.WithOverrides(RegistryOverrides{EnabledLanguages: []string{"Cobol"}}); err == nil {
+		t.Fatalf("expected unknown language error")
+	}
+	if _, err := base.WithOverrides(RegistryOverrides{DisabledLanguages: []string{"Cobol"}}); err == nil {
+		t.Fatalf("expected unknown language error")
+	}
+	if _, err := base.WithOverrides(RegistryOverrides{Extensions: map[string]string{".x": "Python"}, DisabledLanguages: []string{"Python"}}); err == nil {
+		t.Fatalf("expected error when mapping to a disabled language")
+	}
+
+	// .tsx 映射到 TypeScript 的 JSX 变体，但仍按 TypeScript 语言参与后缀查询与禁用。
+	if analyzer, ok := base.AnalyzerForFile("x.tsx"); !ok || !analyzer.(*TypeScriptAnalyzer).JSX {
+		t.Fatalf("expected .tsx to map to the JSX variant of TypeScript")
+	}
+	if extensions := base.ExtensionsForLanguage("TypeScript"); !reflect.DeepEqual(extensions, []string{".ts", ".tsx"}) {
+		t.Fatalf("unexpected TypeScript extensions: %v", extensions)
+	}
+	withoutTS, err := base.WithOverrides(RegistryOverrides{DisabledLanguages: []string{"typescript"}})
+	if err != nil {
+		t.Fatalf("disable typescript failed: %v", err)
+	}
+	if _, ok := withoutTS.AnalyzerForFile("x.tsx"); ok {
+		t.Fatalf("expected .tsx to be disabled with TypeScript")
+	}
+}
+
+// TestDetect 验证后缀、文件名、shebang 与 modeline 四种识别规则及其优先级。
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		path     string
+		head     string
+		expected string
+	}{
+		{path: "src/main.go", head: "#!/usr/bin/env python3\n", expected: "Go"},
+		{path: "Rakefile", expected: "Ruby"},
+		{path: "build/SConstruct", expected: "Python"},
+		{path: "bin/tool", head: "#!/usr/bin/env -S python3.12 -u\nprint(1)\n", expected: "Python"},
+		{path: "bin/serve", head: "#!/usr/local/bin/node\n", expected: "JavaScript"},
+		{path: "scripts/setup", head: "# -*- mode: ruby; coding: utf-8 -*-\n", expected: "Ruby"},
+		{path: "scripts/run", head: "#!/bin/sh\n# vim: set ft=python:\n", expected: "Python"},
+		{path: "config/schema", head: "-- -*- sql -*-\n", expected: "SQL"},
+		{path: "README", head: "# vim: set ft=markdown:\n", expected: ""},
+		{path: "bin/run", head: "#!/bin/bash\n", expected: ""},
+		{path: "bin/bom", head: "\uFEFF#!/usr/bin/env python3\n", expected: "Python"},
+		{path: "scripts/bom", head: "\uFEFF# vim: set ft=ruby:\n", expected: "Ruby"},
+	}
+
+	for _, item := range cases {
+		language, ok := Detect(item.path, []byte(item.head))
+		if language != item.expected || ok != (item.expected != "") {
+			t.Fatalf("detect %s: expected %q, got %q (%v)", item.path, item.expected, language, ok)
+		}
+	}
+
+	registry, err := NewRegistry().WithOverrides(RegistryOverrides{DisabledLanguages: []string{"Ruby"}})
+	if err != nil {
+		t.Fatalf("apply overrides failed: %v", err)
+	}
+	if _, ok := registry.Detect("Gemfile", nil); ok {
+		t.Fatalf("disabled language must not be detected")
+	}
+}
+
+// TestLineLengthMetrics 验证最大/平均行长度按 rune 统计且不含换行符。
+func TestLineLengthMetrics(t *testing.T) {
+	analyzer := &GoAnalyzer{}
+	content := "package main\r\n" +
+		"\n" +
+		"var s = \"中文\"\n"
+
+	metrics := analyzeText(t, analyzer, content)
+
+	if metrics.MaxLineLength != 12 || metrics.Characters != 24 {
+		t.Fatalf("unexpected line length metrics: %+v", metrics)
+	}
+	if metrics.AvgLineLength != 8 {
+		t.Fatalf("unexpected average line length: %v", metrics.AvgLineLength)
+	}
+}
+
+// TestFunctionCounting 验证开启函数计数后只统计代码态中的函数定义。
+func TestFunctionCounting(t *testing.T) {
+	goContent := "package main\n" +
+		"func main() {}\n" +
+		"func (s *server) Run() error {\n" +
+		"    return nil\n" +
+		"}\n" +
+		"var doc = `\n" +
+		"func notCounted() {}\n" +
+		"`\n" +
+		"// func commented() {}\n"
+	if metrics := analyzeText(t, &GoAnalyzer{Options: Options{CountFunctions: true}}, goContent); metrics.Functions != 2 {
+		t.Fatalf("unexpected go functions: %+v", metrics)
+	}
+	if metrics := analyzeText(t, &GoAnalyzer{}, goContent); metrics.Functions != 0 {
+		t.Fatalf("functions should not be counted by default: %+v", metrics)
+	}
+
+	pythonContent := "def a():\n" +
+		"    pass\n" +
+		"class C:\n" +
+		"    async def b(self):\n" +
+		"        '''\n" +
+		"        def c():\n" +
+		"        '''\n"
+	if metrics := analyzeText(t, &PythonAnalyzer{Options: Options{CountFunctions: true}}, pythonContent); metrics.Functions != 2 {
+		t.Fatalf("unexpected python functions: %+v", metrics)
+	}
+
+	javaContent := "public class A {\n" +
+		"    public static void main(String[] args) {\n" +
+		"        if (args.length > 0) {\n" +
+		"        } else if (true) {\n" +
+		"        }\n" +
+		"        return;\n" +
+		"    }\n" +
+		"    private List<String> names(int count) {\n" +
+		"        abstract void skip();\n" +
+		"    }\n" +
+		"}\n"
+	if metrics := analyzeText(t, &JavaAnalyzer{Options: Options{CountFunctions: true}}, javaContent); metrics.Functions != 2 {
+		t.Fatalf("unexpected java functions: %+v", metrics)
+	}
+}
+
+// TestAnnotateLineClasses 验证开启逐行标注后的分类序列。
+func TestAnnotateLineClasses(t *testing.T) {
+	analyzer := &GoAnalyzer{Options: Options{Annotate: true}}
+	content := "package main\n" +
+		"\n" +
+		"// comment\n" +
+		"x := 1 // note\n"
+
+	metrics := analyzeText(t, analyzer, content)
+
+	expected := []string{"code", "blank", "comment", "mixed"}
+	if strings.Join(metrics.LineClasses, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected line classes: %v", metrics.LineClasses)
+	}
+	if plain := analyzeText(t, &GoAnalyzer{}, content); plain.LineClasses != nil {
+		t.Fatalf("line classes should be empty by default: %v", plain.LineClasses)
+	}
+}
+
+// TestCCPPPreprocessorLines 验证预处理指令行单独计数且不计入 code。
+func TestCCPPPreprocessorLines(t *testing.T) {
+	analyzer := &CCPPAnalyzer{}
+	content := "#include <stdio.h> // io\n" +
+		"  #define MAX 10\n" +
+		"/*\n" +
+		"#not a directive\n" +
+		"*/\n" +
+		"int x = MAX; // #define inside comment\n"
+
+	metrics := analyzeText(t, analyzer, content)
+
+	if metrics.Total != 6 || metrics.Preprocessor != 2 || metrics.Code != 1 || metrics.Comment != 5 || metrics.Blank != 0 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+}
+
+// TestCCPPVariant 验证 C/C++ 按后缀（不区分大小写）划分 Header 与 Implementation。
+func TestCCPPVariant(t *testing.T) {
+	var classifier VariantClassifier = &CCPPAnalyzer{}
+	for path, expected := range map[string]string{
+		"include/lib.h":   "Header",
+		"include/lib.HPP": "Header",
+		"src/lib.hh":      "Header",
+		"src/lib.hxx":     "Header",
+		"src/lib.c":       "Implementation",
+		"src/lib.cpp":     "Implementation",
+		"src/lib.cc":      "Implementation",
+		"src/lib.inl":     "Implementation",
+	} {
+		if variant := classifier.Variant(path); variant != expected {
+			t.Fatalf("Variant(%q) = %q, want %q", path, variant, expected)
+		}
+	}
+}
+
+// TestCCPPLineContinuations 验证反斜杠续行：多行 #define 的续行（包括以 // 开头的内容）属于该指令，
+// 以反斜杠结尾的 // 注释延续到下一行；开启 LogicalDirectives 时一组续行只计为一条 preprocessor。
+func TestCCPPLineContinuations(t *testing.T) {
+	content := strings.Join([]string{
+		"#define CHECK(x) \\",
+		"  if (!(x)) { \\",
+		"// looks like a comment \\",
+		"    abort(); }",
+		"int y = 1; // note \\",
+		"int z = 2;",
+		"#include <stdio.h>",
+		"int w = 3; \\",
+		"#not a directive",
+		"",
+	}, "\n")
+	expected := "preprocessor,preprocessor,preprocessor,preprocessor,mixed,comment,preprocessor,code,code"
+
+	metrics := analyzeText(t, &CCPPAnalyzer{Options: Options{Annotate: true}}, content)
+	if strings.Join(metrics.LineClasses, ",") != expected || metrics.Preprocessor != 5 {
+		t.Fatalf("unexpected metrics: %v, preprocessor %d", metrics.LineClasses, metrics.Preprocessor)
+	}
+
+	metrics = analyzeText(t, &CCPPAnalyzer{Options: Options{Annotate: true, LogicalDirectives: true}}, content)
+	if strings.Join(metrics.LineClasses, ",") != expected || metrics.Preprocessor != 2 || metrics.Total != 9 || metrics.Comment != 4 {
+		t.Fatalf("unexpected logical metrics: %v, %+v", metrics.LineClasses, metrics)
+	}
+}
+
+// TestTemplateLiteralInterpolation 验证模板字符串 ${...} 插值按代码解析：其中的 // 是注释，
+// 嵌套的反引号开始内层模板字符串而不会结束外层，与 ${ 配对的 } 回到外层模板字符串。
+func TestTemplateLiteralInterpolation(t *testing.T) {
+	content := strings.Join([]string{
+		"const a = `outer ${items.map(x => `inner ${x}`).join(\"`\")} // text`;",
+		"const b = `${",
+		"  // comment inside interpolation",
+		"  f({ key: `${{ nested: 1 }.nested}` })",
+		"} // still text",
+		"`;",
+		"// real comment",
+		"",
+	}, "\n")
+	expected := "code,code,comment,code,code,code,comment"
+
+	for _, analyzer := range []Analyzer{
+		&JavaScriptAnalyzer{Options: Options{Annotate: true}},
+		&TypeScriptAnalyzer{Options: Options{Annotate: true}},
+		&TypeScriptAnalyzer{Options: Options{Annotate: true}, JSX: true},
+	} {
+		classes := analyzeText(t, analyzer, content).LineClasses
+		if strings.Join(classes, ",") != expected {
+			t.Fatalf("%s: unexpected line classes %v", analyzer.Name(), classes)
+		}
+	}
+
+	metrics := analyzeText(t, &JavaScriptAnalyzer{Options: Options{StringLiteralLines: true}}, "const s = `\n  ${value}\n  plain text\n`;\n")
+	if metrics.StringLiteral != 2 || metrics.Code != 2 {
+		t.Fatalf("unexpected string literal metrics: %+v", metrics)
+	}
+}
+
+// TestJavaUnicodeEscapesAndTextBlocks 验证 Unicode 转义在词法分析前还原（\u0022 结束字符串、\u002F\u002F 是注释，
+// \\u0022 不是转义），以及文本块中的 \" 不会提前结束文本块、结束定界符后的代码按代码计。
+func TestJavaUnicodeEscapesAndTextBlocks(t *testing.T) {
+	content := strings.Join([]string{
+		`String a = "quote\u0022; // comment`,
+		`String b = \u0022it's\u0022;`,
+		`\u002F\u002F hidden comment`,
+		`String c = "\\u0022 // still string";`,
+		`String d = """`,
+		`    He said \"""hi\""" // text`,
+		`    """.strip(); // comment`,
+		`int x = 1;`,
+		`String e = \uuu0022escaped\u0022; /* block */`,
+		``,
+	}, "\n")
+	expected := "mixed,code,comment,code,code,code,mixed,code,mixed"
+
+	classes := analyzeText(t, &JavaAnalyzer{Options: Options{Annotate: true}}, content).LineClasses
+	if strings.Join(classes, ",") != expected {
+		t.Fatalf("unexpected line classes: %v", classes)
+	}
+}
+
+// TestStringLiteralLines 验证字符串行模式下纯字面量行单独计数。
+func TestStringLiteralLines(t *testing.T) {
+	content := "package main\n" +
+		"var query = `\n" +
+		"SELECT * -- not a comment\n" +
+		"FROM t\n" +
+		"`\n" +
+		"var names = []string{\n" +
+		"    \"alice\", // first\n" +
+		"    \"bob\" +\n" +
+		"}\n"
+
+	metrics := analyzeText(t, &GoAnalyzer{Options: Options{StringLiteralLines: true}}, content)
+	if metrics.StringLiteral != 5 || metrics.Code != 4 || metrics.Comment != 1 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+
+	plain := analyzeText(t, &GoAnalyzer{}, content)
+	if plain.StringLiteral != 0 || plain.Code != 9 {
+		t.Fatalf("unexpected default metrics: %+v", plain)
+	}
+}
+
+// TestWhitespaceStats 验证缩进风格、最大缩进宽度与行尾空白统计。
+func TestWhitespaceStats(t *testing.T) {
+	analyzer := &PythonAnalyzer{Options: Options{WhitespaceStats: true}}
+	content := "def f():\n" +
+		"    x = 1  \n" +
+		"\tif x:\n" +
+		"\t    return x\n" +
+		"   \n"
+
+	metrics := analyzeText(t, analyzer, content)
+
+	whitespace := metrics.Whitespace
+	if whitespace == nil {
+		t.Fatalf("expected whitespace stats")
+	}
+	if whitespace.SpaceIndentedLines != 1 || whitespace.TabIndentedLines != 1 || whitespace.MixedIndentedLines != 1 {
+		t.Fatalf("unexpected indentation counts: %+v", whitespace)
+	}
+	if whitespace.MaxIndentWidth != 8 || whitespace.TrailingWhitespaceLines != 1 || whitespace.IndentStyle != "mixed" {
+		t.Fatalf("unexpected whitespace stats: %+v", whitespace)
+	}
+}
+
+// TestGenericAnalyzerFromDefinitions 验证从 YAML 定义加载的通用分析器能处理行注释、嵌套块注释与多字符定界符。
+func TestGenericAnalyzerFromDefinitions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "langs.yaml")
+	definitions := "languages:\n" +
+		"  - name: Lua\n" +
+		"    extensions: [lua]\n" +
+		"    line_comments: [\"--\"]\n" +
+		"    block_comments: [{start: \"--[[\", end: \"]]\"}]\n" +
+		"    string_delimiters: ['\"', \"[==[\"]\n" +
+		"  - name: Toy\n" +
+		"    extensions: [.toy]\n" +
+		"    block_comments: [{start: \"(*\", end: \"*)\"}]\n" +
+		"    nested_comments: true\n"
+	if err := os.WriteFile(path, []byte(definitions), 0o644); err != nil {
+		t.Fatalf("write definitions failed: %v", err)
+	}
+
+	loaded, err := LoadLanguageDefinitions(path)
+	if err != nil {
+		t.Fatalf("load definitions failed: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Extensions[0] != ".lua" {
+		t.Fatalf("unexpected definitions: %+v", loaded)
+	}
+
+	lua := analyzeText(t, &GenericAnalyzer{Definition: loaded[0]}, "--[[ block\nstill ]]\nlocal s = \"-- not comment\" -- tail\n\n")
+	if lua.Code != 1 || lua.Comment != 3 || lua.Blank != 1 {
+		t.Fatalf("unexpected lua metrics: %+v", lua)
+	}
+
+	toy := analyzeText(t, &GenericAnalyzer{Definition: loaded[1]}, "(* outer (* inner *)\nstill comment *)\nx\n")
+	if toy.Code != 1 || toy.Comment != 2 {
+		t.Fatalf("unexpected nested metrics: %+v", toy)
+	}
+
+	registry := NewRegistry()
+	registry.Register(&GenericAnalyzer{Definition: loaded[0]})
+	if analyzer, ok := registry.AnalyzerForFile("init.lua"); !ok || analyzer.Name() != "Lua" {
+		t.Fatalf("expected registered lua analyzer")
+	}
+}
+
+// TestDecodeRunesMatchesConversion 验证复用缓冲区的解码结果与 []rune 转换一致（包括非法 UTF-8），且容量足够时不重新分配。
+func TestDecodeRunesMatchesConversion(t *testing.T) {
+	var buffer []rune
+	for _, line := range []string{"x := \"héllo\" // 注释", "bad \xff\xfe bytes", "", "ok"} {
+		buffer = decodeRunes(buffer, line)
+		expected := []rune(line)
+		if len(buffer) != len(expected) {
+			t.Fatalf("unexpected length for %q: %d != %d", line, len(buffer), len(expected))
+		}
+		for index := range expected {
+			if buffer[index] != expected[index] {
+				t.Fatalf("unexpected rune %d for %q: %q != %q", index, line, buffer[index], expected[index])
+			}
+		}
+	}
+
+	allocations := testing.AllocsPerRun(100, func() {
+		buffer = decodeRunes(buffer, "x := \"héllo\"")
+	})
+	if allocations != 0 {
+		t.Fatalf("expected no allocations with a warm buffer, got %.1f", allocations)
+	}
+}
+
+// TestLineScratchReadSegment 验证 readSegment 对不超过 maxLineSegment 的行与 ReadString('\n') 的行切分一致
+// （包括超过缓冲区大小的行与无换行的末行），且稳定状态下不分配内存。
+func TestLineScratchReadSegment(t *testing.T) {
+	content := "short\r\n\n" + strings.Repeat("x", 10000) + "\nlast"
+	expected := make([]string, 0)
+	reference := bufio.NewReader(strings.NewReader(content))
+	for {
+		line, err := reference.ReadString('\n')
+		if len(line) > 0 {
+			expected = append(expected, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	scratch := acquireLineScratch(strings.NewReader(content), Options{})
+	defer releaseLineScratch(scratch)
+	actual := make([]string, 0)
+	for {
+		line, more, err := scratch.readSegment()
+		if more {
+			t.Fatalf("unexpected segmented line: %d bytes", len(line))
+		}
+		if len(line) > 0 {
+			actual = append(actual, strings.Clone(line))
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read line failed: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected lines: %d lines, want %d", len(actual), len(expected))
+	}
+
+	source := strings.NewReader("")
+	allocations := testing.AllocsPerRun(100, func() {
+		source.Reset("package main\nfunc main() {}\n")
+		scratch.reader.Reset(source)
+		for {
+			if _, _, err := scratch.readSegment(); err != nil {
+				break
+			}
+		}
+	})
+	if allocations != 0 {
+		t.Fatalf("expected no allocations per line, got %.1f", allocations)
+	}
+}
+
+// TestLineScratchLongLineSegments 验证超长行按段返回：各段拼接后与原行一致，每段不超过 maxLineSegment，
+// 且切分点位于两个字母/数字之间。
+func TestLineScratchLongLineSegments(t *testing.T) {
+	long := strings.Repeat("token42 = \"a;b\"; ", 20000)
+	scratch := acquireLineScratch(strings.NewReader(long+"\nnext\n"), Options{})
+	defer releaseLineScratch(scratch)
+
+	var joined strings.Builder
+	segments := 0
+	for {
+		segment, more, err := scratch.readSegment()
+		if err != nil {
+			t.Fatalf("read segment failed: %v", err)
+		}
+		if len(segment) > maxLineSegment {
+			t.Fatalf("segment exceeds limit: %d bytes", len(segment))
+		}
+		if more && !isASCIIAlphanumeric(segment[len(segment)-1]) {
+			t.Fatalf("unexpected cut after %q", segment[len(segment)-10:])
+		}
+		joined.WriteString(segment)
+		segments++
+		if !more {
+			break
+		}
+	}
+	if joined.String() != long+"\n" || segments < 2 {
+		t.Fatalf("unexpected segments: %d segments, %d bytes", segments, joined.Len())
+	}
+	if line, more, err := scratch.readSegment(); line != "next\n" || more || err != nil {
+		t.Fatalf("unexpected next line: %q %v %v", line, more, err)
+	}
+}
+
+// TestAnalyzeExtremelyLongLines 验证数 MB 的单行（压缩后的 bundle）按段处理后分类、行长度与去重哈希与整行处理一致，
+// 分段边界上的字符串、块注释状态正确延续，行注释之后的内容不会影响后续行。
+func TestAnalyzeExtremelyLongLines(t *testing.T) {
+	bundle := "  " + strings.Repeat(`var a1="x // y";/* c */b2=a1+'q';`, 100000) + " "
+	commented := "run(); // " + strings.Repeat(`it's "open /* `, 300000)
+	content := bundle + "\n" + bundle + "\n" + commented + "\nx = 1;\n"
+
+	analyzer := &JavaScriptAnalyzer{Options: Options{WhitespaceStats: true}}
+	metrics, err := analyzer.Analyze(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("analyze failed: %v", err)
+	}
+	if metrics.Total != 4 || metrics.Code != 4 || metrics.Comment != 3 || metrics.Blank != 0 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+	if metrics.ULOC != 3 {
+		t.Fatalf("expected identical long lines to share a hash, got uloc %d", metrics.ULOC)
+	}
+	if metrics.MaxLineLength != int64(len(commented)) {
+		t.Fatalf("unexpected max line length: %d, want %d", metrics.MaxLineLength, len(commented))
+	}
+	if metrics.Whitespace == nil || metrics.Whitespace.TrailingWhitespaceLines != 3 || metrics.Whitespace.SpaceIndentedLines != 2 {
+		t.Fatalf("unexpected whitespace stats: %+v", metrics.Whitespace)
+	}
+
+	digest := lineDigest{}
+	digest.reset()
+	line := " \t héllo  wörld \t"
+	for _, segment := range []string{line[:3], line[3:9], line[9:]} {
+		digest.add(segment)
+	}
+	if digest.hash != hashCodeLine(line) || digest.runes != int64(utf8.RuneCountInString(line)) || !digest.trailingWhitespace {
+		t.Fatalf("unexpected digest: %+v", digest)
+	}
+}
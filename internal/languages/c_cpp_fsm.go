@@ -1,16 +1,32 @@
 package languages
 
 import (
-	"bufio"
 	"errors"
 	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"unicode"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// cCppFunctionMatcher 以“返回类型 + 函数名 + (”且行内不含分号作为函数定义特征。
+// 与 Java 一样属于启发式识别：声明（以分号结尾）与控制流语句不会计入。
+var cCppFunctionMatcher = functionMatcher{
+	pattern: regexp.MustCompile(`^[\w:<>,~]+([\s*&]+[\w:<>,~]+)*[\s*&]+[\w:~]+\s*\([^;]*$`),
+	excludedKeywords: map[string]bool{
+		"return": true, "new": true, "delete": true, "if": true, "else": true, "for": true,
+		"while": true, "switch": true, "case": true, "do": true, "sizeof": true, "throw": true,
+		"goto": true, "typedef": true, "using": true,
+	},
+}
+
 // CCPPAnalyzer 是 C/C++ 专用 FSM 分析器。
-type CCPPAnalyzer struct{}
+type CCPPAnalyzer struct {
+	// Options 控制附加统计能力，零值仅统计基础行数。
+	Options Options
+}
 
 // Name 返回语言名称。
 func (a *CCPPAnalyzer) Name() string {
@@ -22,17 +38,64 @@ func (a *CCPPAnalyzer) Extensions() []string {
 	return []string{".c", ".cc", ".cpp", ".cxx", ".h", ".hh", ".hpp", ".hxx"}
 }
 
+// Capabilities 返回该 FSM 支持的注释与字符串语法。
+func (a *CCPPAnalyzer) Capabilities() Capabilities {
+	return Capabilities{
+		LineComments:     []string{"//"},
+		BlockComments:    []BlockCommentPair{{Start: "/*", End: "*/"}},
+		StringDelimiters: []string{"\"", "'"},
+		Notes: []string{
+			"以 # 开头的预处理指令行单独计入 preprocessor，以反斜杠续行的后续行同样属于该指令",
+			"以反斜杠结尾的 // 行注释延续到下一行",
+		},
+	}
+}
+
+// cCppHeaderExtensions 是 C/C++ 头文件后缀集合，其余后缀视为实现文件。
+var cCppHeaderExtensions = map[string]bool{".h": true, ".hh": true, ".hpp": true, ".hxx": true}
+
+// Variant 把 C/C++ 文件划分为 Header 与 Implementation 两类，
+// 便于观察接口面（头文件）与实现代码的比例。
+func (a *CCPPAnalyzer) Variant(path string) string {
+	if cCppHeaderExtensions[strings.ToLower(filepath.Ext(path))] {
+		return "Header"
+	}
+	return "Implementation"
+}
+
+// IsTestFile 按 gtest 等常见约定识别测试文件：文件名含 _test/_unittest，或位于 test/tests 目录。
+func (a *CCPPAnalyzer) IsTestFile(path string) bool {
+	stem := strings.TrimSuffix(pathBase(path), filepath.Ext(path))
+	return strings.HasSuffix(stem, "_test") ||
+		strings.HasSuffix(stem, "_unittest") ||
+		hasPathSegment(path, "test") ||
+		hasPathSegment(path, "tests")
+}
+
 // Analyze 使用 C/C++ 独立 FSM 对内容进行流式扫描。
 func (a *CCPPAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
-	engine := &cCppFSMEngine{}
+	engine := &cCppFSMEngine{options: a.Options}
 	return engine.analyze(reader)
 }
 
 // cCppFSMEngine 维护 C/C++ 注释和字符串状态。
 type cCppFSMEngine struct {
+	options Options
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
 	inBlockComment bool
 	inDoubleQuoted bool
 	inSingleQuoted bool
+	// inDirective 表示上一行是以反斜杠续行的预处理指令，当前行仍属于该指令。
+	inDirective bool
+	// inLineComment 表示上一行的 // 注释以反斜杠续行，当前整行仍是注释。
+	inLineComment bool
+	// spliced 表示上一行以反斜杠结尾，当前行与其拼接为同一逻辑行，行首的 # 不是预处理指令。
+	spliced bool
+	// lineEnd 为当前物理行的最后一个字符，用于判断是否以反斜杠续行。
+	lineEnd rune
 }
 
 // analyze 逐行处理输入流。
@@ -41,38 +104,97 @@ func (e *cCppFSMEngine) analyze(reader io.Reader) (model.LineMetrics, error) {
 
 	// C/C++ 使用按行流式读取，避免大文件造成内存压力。
 	// 块注释和字符串状态由 engine 持久化，保证跨行解析正确。
-	bufferedReader := bufio.NewReader(reader)
-
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
 	for {
-		line, err := bufferedReader.ReadString('\n')
+		startsInCode := e.inCodeState()
+		continued := e.inDirective
+		e.lineEnd = 0
+		// 把当前行交给 processLine，根据 FSM 状态做精确分类。
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
 		// 没有残留字符的 EOF 说明读取完成。
-		if errors.Is(err, io.EOF) && len(line) == 0 {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		// 真正读取错误直接返回。
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return metrics, err
 		}
 
-		// 把当前行交给 processLine，根据 FSM 状态做精确分类。
-		currentLine := normalizeLine(line)
-		hasCode, hasComment := e.processLine(currentLine)
-		applyLineClassification(&metrics, currentLine, hasCode, hasComment)
+		// 反斜杠续行在编译器拼接物理行时生效：多行 #define 的后续行仍属于该指令，
+		// 即使以 // 等看似注释或代码的内容开头；以反斜杠结尾的 // 注释同样延续到下一行。
+		directive := continued || (startsInCode && !e.spliced && isCPreprocessorDirective(line.text))
+		e.spliced = e.lineEnd == '\\'
+		e.inDirective = directive && e.spliced
+		e.inLineComment = e.lineCommented && e.spliced
 
-		// 最后一行即使没有换行，也已完成统计。
-		if errors.Is(err, io.EOF) {
-			break
+		// 预处理指令（#include/#define/#if 等）单独计入 preprocessor，避免宏密集的代码被混入 code。
+		if directive && continued && e.options.LogicalDirectives {
+			// 续行与指令首行合计为一条逻辑指令：行本身仍标记为 preprocessor，但不重复计数。
+			recordLine(&metrics, e.options, line, model.LineClassPreprocessor)
+			if hasComment {
+				metrics.Comment++
+			}
+		} else if directive {
+			applyPreprocessorLine(&metrics, e.options, line, hasComment)
+		} else {
+			if e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic {
+				applyStringLiteralLine(&metrics, e.options, line, hasComment)
+			} else {
+				applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+			}
+			if e.options.CountFunctions && startsInCode && hasCode && cCppFunctionMatcher.matches(line.text) {
+				metrics.Functions++
+			}
 		}
 	}
 
 	return metrics, nil
 }
 
+// inCodeState 判断当前是否处于普通代码态（不在注释或字符串等跨行状态中）。
+func (e *cCppFSMEngine) inCodeState() bool {
+	return !e.inBlockComment && !e.inDoubleQuoted && !e.inSingleQuoted && !e.inLineComment
+}
+
+// isCPreprocessorDirective 判断一行是否是预处理指令：首个非空白字符为 #。
+// 调用方需保证该行以普通代码态开始，避免把块注释或字符串中的 # 误判为指令。
+func isCPreprocessorDirective(line string) bool {
+	return strings.HasPrefix(strings.TrimLeftFunc(line, unicode.IsSpace), "#")
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *cCppFSMEngine) state() string {
+	switch {
+	case e.inBlockComment:
+		return "block-comment"
+	case e.inDoubleQuoted:
+		return "string"
+	case e.inSingleQuoted:
+		return "char"
+	case e.inLineComment:
+		return "line-comment"
+	case e.inDirective:
+		return "directive"
+	}
+	return "code"
+}
+
 // processLine 解析单行 C/C++ 内容。
 func (e *cCppFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
 	hasCode := false
 	hasComment := false
-	runes := []rune(line)
+	runes := e.scratch.decode(line)
+	if len(runes) > 0 {
+		e.lineEnd = runes[len(runes)-1]
+	}
+	if e.inLineComment {
+		e.lineCommented = true
+		return false, true
+	}
 
 	// 初始化当前行分类标记，先继承跨行状态。
 	if e.inBlockComment {
@@ -80,6 +202,7 @@ func (e *cCppFSMEngine) processLine(line string) (bool, bool) {
 	}
 	if e.inDoubleQuoted || e.inSingleQuoted {
 		hasCode = true
+		e.lineHasLiteral = true
 	}
 
 	for idx := 0; idx < len(runes); {
@@ -104,6 +227,7 @@ func (e *cCppFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inDoubleQuoted {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 字符串里的转义字符优先消费，避免误识别结束引号。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -118,6 +242,7 @@ func (e *cCppFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inSingleQuoted {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 字符字面量同样需要跳过转义。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -130,7 +255,7 @@ func (e *cCppFSMEngine) processLine(line string) (bool, bool) {
 			continue
 		}
 
-		if unicode.IsSpace(current) {
+		if isBlankRune(current) {
 			// 空白字符不参与注释/代码判断。
 			idx++
 			continue
@@ -138,6 +263,7 @@ func (e *cCppFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '/' && hasNext && next == '/' {
 			hasComment = true
+			e.lineCommented = true
 			return hasCode, hasComment
 		}
 
@@ -150,6 +276,7 @@ func (e *cCppFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inDoubleQuoted = true
 			idx++
 			continue
@@ -157,12 +284,16 @@ func (e *cCppFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '\'' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inSingleQuoted = true
 			idx++
 			continue
 		}
 
 		hasCode = true
+		if !isStringLiteralPunctuation(current) {
+			e.lineHasLogic = true
+		}
 		idx++
 	}
 
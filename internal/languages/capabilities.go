@@ -0,0 +1,25 @@
+package languages
+
+// Capabilities 描述分析器能识别的词法结构，供 language 命令、文档生成与调试工具展示。
+type Capabilities struct {
+	// LineComments 为行注释起始符。
+	LineComments []string `json:"line_comments"`
+	// BlockComments 为块注释起止符对。
+	BlockComments []BlockCommentPair `json:"block_comments"`
+	// StringDelimiters 为字符串（含字符字面量、原始字符串等）的起始定界符。
+	StringDelimiters []string `json:"string_delimiters"`
+	// NestedComments 表示块注释允许嵌套。
+	NestedComments bool `json:"nested_comments"`
+	// LineStartBlockComments 表示块注释起止符只在行首生效（如 Ruby 的 =begin/=end），不能跟在代码之后。
+	LineStartBlockComments bool `json:"line_start_block_comments,omitempty"`
+	// Shebang 表示首行 shebang（#!）按 Options.Shebang 计入，而不是按该语言的语法分析。
+	Shebang bool `json:"shebang,omitempty"`
+	// Notes 为无法用上述字段表达的特殊规则说明。
+	Notes []string `json:"notes,omitempty"`
+}
+
+// CapabilityDescriber 是分析器可选实现的接口，用于声明其 FSM 支持的注释与字符串语法。
+// 外部插件等无法静态描述的分析器不实现该接口。
+type CapabilityDescriber interface {
+	Capabilities() Capabilities
+}
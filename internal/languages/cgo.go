@@ -0,0 +1,84 @@
+package languages
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// cgoImportPattern 识别 import "C" 行（允许行尾注释）；cgo 只接受单独的 import "C"，不会出现在 import 分组中。
+var cgoImportPattern = regexp.MustCompile(`^import\s+"C"\s*(//.*)?$`)
+
+// cgoPreamble 在 Options.CgoPreamble 开启时暂存以普通代码态开始的注释块：
+// 注释块紧接着 import "C" 时它是 cgo 前导代码，按 C/C++ 重新分析并计入 LineMetrics.Embedded；
+// 否则按原样计为 Go 注释。注释块在遇到下一行之前无法判断，因此只能先复制暂存。
+type cgoPreamble struct {
+	lines []lineText
+}
+
+// pending 判断是否有暂存的注释行。
+func (p *cgoPreamble) pending() bool {
+	return len(p.lines) > 0
+}
+
+// add 暂存一行注释；line 引用复用的缓冲区，因此保存副本。
+func (p *cgoPreamble) add(line lineText) {
+	saved := lineText{text: strings.Clone(line.text)}
+	if line.long != nil {
+		digest := *line.long
+		saved.long = &digest
+	}
+	p.lines = append(p.lines, saved)
+}
+
+// flush 把暂存的注释行按 Go 注释计入 metrics。
+func (p *cgoPreamble) flush(metrics *model.LineMetrics, options Options) {
+	for _, line := range p.lines {
+		applyLineClassification(metrics, options, line, false, true)
+	}
+	p.lines = p.lines[:0]
+}
+
+// attribute 去掉 Go 注释符号后用 C/C++ 分析器分析暂存的注释行，结果计入 metrics.Embedded；
+// 逐行标注按原有行序并入 metrics.LineClasses。
+func (p *cgoPreamble) attribute(metrics *model.LineMetrics, options Options) error {
+	source := make([]string, 0, len(p.lines))
+	inBlock := false
+	for _, line := range p.lines {
+		text := line.text
+		if !inBlock {
+			trimmed := strings.TrimLeftFunc(text, isBlankRune)
+			switch {
+			case strings.HasPrefix(trimmed, "//"):
+				text = trimmed[2:]
+			case strings.HasPrefix(trimmed, "/*"):
+				text = trimmed[2:]
+				inBlock = true
+			}
+		}
+		if inBlock {
+			if end := strings.Index(text, "*/"); end >= 0 {
+				text = text[:end]
+				inBlock = false
+			}
+		}
+		source = append(source, text)
+	}
+	p.lines = p.lines[:0]
+
+	cOptions := options
+	cOptions.TrackCodeLines = false
+	cOptions.TrailingEmptyLine = false
+	// 这些行的状态已由 Go 引擎记录。
+	cOptions.TraceStates = false
+	analyzer := &CCPPAnalyzer{Options: cOptions}
+	embedded, err := analyzer.Analyze(strings.NewReader(strings.Join(source, "\n") + "\n"))
+	if err != nil {
+		return err
+	}
+	metrics.LineClasses = append(metrics.LineClasses, embedded.LineClasses...)
+	embedded.LineClasses = nil
+	metrics.AddEmbedded(analyzer.Name(), embedded)
+	return nil
+}
@@ -1,13 +1,79 @@
 package languages
 
 import (
+	"hash/fnv"
+	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"unicode"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// Options 描述分析器可选的附加统计能力。
+// 零值表示只统计 total/code/comment/blank 等基础指标，附加能力均需显式开启。
+type Options struct {
+	// CountFunctions 开启函数/方法定义计数（按语言规则识别行首定义）。
+	CountFunctions bool
+	// Annotate 开启逐行分类记录，结果写入 LineMetrics.LineClasses。
+	Annotate bool
+	// TraceStates 记录每行开始与结束时的 FSM 状态，结果写入 LineMetrics.LineStates，供 explain 命令排查分类问题。
+	// 只有内置 FSM 与自定义语言定义支持，外部插件不填充。
+	TraceStates bool
+	// TrackCodeLines 记录每个代码行的行号与内容哈希（LineMetrics.CodeLines），供重复代码检测使用。
+	TrackCodeLines bool
+	// StringLiteralLines 把只包含字符串字面量内容的行计入 StringLiteral 而非 Code，
+	// 避免内嵌 SQL、大段测试数据等字面量抬高逻辑代码行数。
+	StringLiteralLines bool
+	// WhitespaceStats 开启缩进风格、最大缩进宽度与行尾空白统计（LineMetrics.Whitespace）。
+	WhitespaceStats bool
+	// PythonDocstrings 把 Python 模块、类与函数体开头的三引号字符串（docstring）计为注释而非代码。
+	PythonDocstrings bool
+	// SQLDialect 为 SQL 方言：auto（默认）、ansi、mysql、postgres、tsql 或 oracle，见 ParseSQLDialect。
+	SQLDialect string
+	// GoDirectives 为 Go 编译指令行（//go:build 等）的计入方式：comment（默认）、directive 或 code，见 ParseGoDirectives。
+	GoDirectives string
+	// LogicalDirectives 把 C/C++ 以反斜杠续行的多行预处理指令计为一条 Preprocessor（各物理行仍标记为 preprocessor）。
+	LogicalDirectives bool
+	// TrailingEmptyLine 把文件末尾换行符之后的空内容计为一个空白行（换行符视为行分隔符，与编辑器行号一致）；
+	// 默认与 cloc、tokei 一致，换行符是行的结束符，"a\n" 只有一行。
+	TrailingEmptyLine bool
+	// CgoPreamble 把 Go 文件中紧邻 import "C" 之前的注释块（cgo 前导代码）按 C/C++ 分析，
+	// 计入 LineMetrics.Embedded 而非 Go 注释。
+	CgoPreamble bool
+	// Shebang 为脚本首行 shebang（#!）的计入方式：comment（默认）、directive 或 code，见 ParseShebang。
+	Shebang string
+}
+
+// functionMatcher 描述某种语言“函数定义行”的识别规则。
+type functionMatcher struct {
+	// pattern 匹配去除首尾空白后的行文本。
+	pattern *regexp.Regexp
+	// excludedKeywords 中的关键字出现在行首时不视为函数定义，用于排除 if/return 等语句。
+	excludedKeywords map[string]bool
+}
+
+// matches 判断一行是否是函数定义。
+// 调用方需保证该行以普通代码态开始，避免把字符串或注释中的文本误判为定义。
+func (m functionMatcher) matches(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(m.excludedKeywords) > 0 {
+		firstWord := trimmed
+		if end := strings.IndexFunc(trimmed, func(r rune) bool {
+			return !(r == '_' || r == '$' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9'))
+		}); end >= 0 {
+			firstWord = trimmed[:end]
+		}
+		if m.excludedKeywords[firstWord] {
+			return false
+		}
+	}
+	return m.pattern.MatchString(trimmed)
+}
+
 // normalizeLine 用于去除每行末尾的换行符。
-// 该函数适配 Windows 的 \r\n 与 Unix 的 \n。
+// 该函数适配 Windows 的 \r\n 与 Unix 的 \n；经典 Mac 的 \r 换行在读取时已由 crLineReader 转换为 \n。
 func normalizeLine(line string) string {
 	line = strings.TrimSuffix(line, "\n")
 	line = strings.TrimSuffix(line, "\r")
@@ -20,16 +86,24 @@ func normalizeLine(line string) string {
 // - 每次调用都默认是“处理完一整行”，因此 Total 固定 +1
 // - 同一行可以同时具备 code/comment，两者独立累计
 // - 空白行判定要求：去掉空白字符后为空，且没有 code/comment 标记
-func applyLineClassification(metrics *model.LineMetrics, line string, hasCode bool, hasComment bool) {
-	metrics.Total++
+// - 行长度（rune 数）在这里统一记录，供最大/平均行长度统计使用
+// - 开启 Annotate 时额外记录逐行分类（code/comment/blank/mixed）
+// - 开启 TrackCodeLines 时额外记录代码行号与内容哈希
+func applyLineClassification(metrics *model.LineMetrics, options Options, line lineText, hasCode bool, hasComment bool) {
+	recordLine(metrics, options, line, classifyLine(hasCode, hasComment))
 
-	if strings.TrimSpace(line) == "" && !hasCode && !hasComment {
+	if line.blank() && !hasCode && !hasComment {
 		metrics.Blank++
 		return
 	}
 
 	if hasCode {
 		metrics.Code++
+		hash := line.hash()
+		metrics.AddUniqueLine(hash)
+		if options.TrackCodeLines {
+			metrics.CodeLines = append(metrics.CodeLines, model.CodeLine{Number: metrics.Total, Hash: hash})
+		}
 	}
 
 	if hasComment {
@@ -40,3 +114,146 @@ func applyLineClassification(metrics *model.LineMetrics, line string, hasCode bo
 		metrics.Blank++
 	}
 }
+
+// applyPreprocessorLine 记录一行预处理指令。
+// 指令行计入 Preprocessor 而非 Code；行内注释（如 #include <x> // note）仍计入 Comment。
+func applyPreprocessorLine(metrics *model.LineMetrics, options Options, line lineText, hasComment bool) {
+	recordLine(metrics, options, line, model.LineClassPreprocessor)
+	metrics.Preprocessor++
+	if hasComment {
+		metrics.Comment++
+	}
+}
+
+// applyStringLiteralLine 记录一行只包含字符串字面量内容的代码。
+// 该行计入 StringLiteral 而非 Code；行内注释仍计入 Comment。
+func applyStringLiteralLine(metrics *model.LineMetrics, options Options, line lineText, hasComment bool) {
+	recordLine(metrics, options, line, model.LineClassString)
+	metrics.StringLiteral++
+	if hasComment {
+		metrics.Comment++
+	}
+}
+
+// isStringLiteralPunctuation 判断字面量之外的字符是否只是连接/分隔标点。
+// 形如 "abc", 或 "abc" + 的行仍被视为纯字符串行。
+func isStringLiteralPunctuation(current rune) bool {
+	switch current {
+	case ',', ';', '+', ')', ']', '}':
+		return true
+	default:
+		return false
+	}
+}
+
+// recordLine 完成与分类无关的逐行记录：总行数、行长度与可选的逐行标注。
+func recordLine(metrics *model.LineMetrics, options Options, line lineText, class string) {
+	metrics.Total++
+	metrics.AddLineLength(line.runeCount())
+	if options.Annotate {
+		metrics.LineClasses = append(metrics.LineClasses, class)
+	}
+	if options.WhitespaceStats {
+		if metrics.Whitespace == nil {
+			metrics.Whitespace = &model.WhitespaceMetrics{IndentStyle: model.IndentStyleNone}
+		}
+		recordWhitespace(metrics.Whitespace, line)
+	}
+}
+
+// whitespaceTabWidth 是计算缩进宽度时 tab 对应的列数。
+const whitespaceTabWidth = 4
+
+// recordWhitespace 统计单行的缩进字符、缩进宽度与行尾空白。
+func recordWhitespace(stats *model.WhitespaceMetrics, line lineText) {
+	if line.blank() {
+		return
+	}
+
+	if line.trailingWhitespace() {
+		stats.TrailingWhitespaceLines++
+	}
+
+	hasTab := false
+	hasSpace := false
+	width := int64(0)
+	for idx := 0; idx < len(line.text); idx++ {
+		if line.text[idx] == '\t' {
+			hasTab = true
+			width += whitespaceTabWidth
+			continue
+		}
+		if line.text[idx] == ' ' {
+			hasSpace = true
+			width++
+			continue
+		}
+		break
+	}
+
+	switch {
+	case hasTab && hasSpace:
+		stats.MixedIndentedLines++
+	case hasTab:
+		stats.TabIndentedLines++
+	case hasSpace:
+		stats.SpaceIndentedLines++
+	}
+	if width > stats.MaxIndentWidth {
+		stats.MaxIndentWidth = width
+	}
+	stats.UpdateIndentStyle()
+}
+
+// classifyLine 把 FSM 的 code/comment 标记转换为逐行分类名称。
+func classifyLine(hasCode bool, hasComment bool) string {
+	switch {
+	case hasCode && hasComment:
+		return model.LineClassMixed
+	case hasCode:
+		return model.LineClassCode
+	case hasComment:
+		return model.LineClassComment
+	default:
+		return model.LineClassBlank
+	}
+}
+
+// hashCodeLine 计算去除首尾空白（见 isBlankRune）后的代码行哈希，用于 ULOC 去重。
+func hashCodeLine(line string) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(strings.TrimFunc(line, isBlankRune)))
+	return hasher.Sum64()
+}
+
+// isBlankRune 判断字符在空白行判定与各引擎的空白跳过中是否视为空白。
+// 除 unicode.IsSpace 覆盖的字符（含 NBSP U+00A0、全角空格 U+3000 等 Unicode 空格）外，
+// 还包括不可见的零宽字符 U+200B、U+200C、U+200D、U+2060 与 U+FEFF。
+func isBlankRune(r rune) bool {
+	switch r {
+	case '\u200B', '\u200C', '\u200D', '\u2060', '\uFEFF':
+		return true
+	}
+	return unicode.IsSpace(r)
+}
+
+// pathBase 返回路径的文件名部分，兼容 Windows 与 Unix 分隔符。
+func pathBase(filePath string) string {
+	return path.Base(filepath.ToSlash(filePath))
+}
+
+// hasPathSegment 判断路径的目录部分是否包含指定目录名。
+func hasPathSegment(filePath string, segment string) bool {
+	parts := strings.Split(path.Dir(filepath.ToSlash(filePath)), "/")
+	for _, part := range parts {
+		if part == segment {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTestInfix 判断文件名是否带有 .test. 或 .spec. 中缀（如 app.test.js、app.spec.ts）。
+func hasTestInfix(base string) bool {
+	return strings.Contains(base, ".test.") || strings.Contains(base, ".spec.")
+}
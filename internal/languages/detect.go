@@ -0,0 +1,187 @@
+package languages
+
+import (
+	"bytes"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// modelineLines 是识别编辑器 modeline 时检查的文件头部行数（与 vim 默认的 modelines=5 一致）。
+const modelineLines = 5
+
+// defaultRegistry 是包级 Detect 使用的内置语言注册中心，只读使用，因此可并发访问。
+var defaultRegistry = NewRegistry()
+
+// filenameLanguages 是没有可识别后缀、但文件名本身约定了语言的文件。
+var filenameLanguages = map[string]string{
+	"rakefile":    "Ruby",
+	"gemfile":     "Ruby",
+	"guardfile":   "Ruby",
+	"podfile":     "Ruby",
+	"vagrantfile": "Ruby",
+	"brewfile":    "Ruby",
+	"capfile":     "Ruby",
+	"fastfile":    "Ruby",
+	"berksfile":   "Ruby",
+	"thorfile":    "Ruby",
+	".irbrc":      "Ruby",
+	".pryrc":      "Ruby",
+	"sconstruct":  "Python",
+	"sconscript":  "Python",
+	"wscript":     "Python",
+	".pythonrc":   "Python",
+	"jakefile":    "JavaScript",
+}
+
+// interpreterLanguages 把 shebang 解释器名（去掉版本号后缀）映射到语言。
+var interpreterLanguages = map[string]string{
+	"python":      "Python",
+	"pypy":        "Python",
+	"ruby":        "Ruby",
+	"jruby":       "Ruby",
+	"node":        "JavaScript",
+	"nodejs":      "JavaScript",
+	"bun":         "JavaScript",
+	"ts-node":     "TypeScript",
+	"tsx":         "TypeScript",
+	"rust-script": "Rust",
+	"gorun":       "Go",
+	"java":        "Java",
+}
+
+// modelineLanguages 把 vim filetype / emacs mode 名称映射到语言。
+var modelineLanguages = map[string]string{
+	"go":         "Go",
+	"golang":     "Go",
+	"javascript": "JavaScript",
+	"js":         "JavaScript",
+	"js2":        "JavaScript",
+	"typescript": "TypeScript",
+	"ts":         "TypeScript",
+	"python":     "Python",
+	"py":         "Python",
+	"rust":       "Rust",
+	"ruby":       "Ruby",
+	"java":       "Java",
+	"c":          "C/C++",
+	"cpp":        "C/C++",
+	"c++":        "C/C++",
+	"sql":        "SQL",
+	"mysql":      "SQL",
+	"plsql":      "SQL",
+	"pgsql":      "SQL",
+}
+
+var (
+	// vimModeline 匹配 "vim: set ft=python:"、"vi: filetype=ruby" 等形式。
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex)(?:[<=>]?\d+)?:.*?\b(?:ft|filetype|syntax)=([A-Za-z0-9_+-]+)`)
+	// emacsModeline 匹配 "-*- mode: python -*-" 与 "-*- python -*-" 两种形式。
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?\bmode:\s*([A-Za-z0-9_+-]+)|([A-Za-z0-9_+-]+)\s*-\*-)`)
+)
+
+// Detect 使用内置语言识别文件语言，规则见 Registry.Detect。
+// head 为文件开头的若干字节（几百字节即可），为 nil 时只按路径识别。
+func Detect(path string, head []byte) (string, bool) {
+	return defaultRegistry.Detect(path, head)
+}
+
+// Detect 识别文件语言，供 linter、代码搜索等工具复用 gocloc 的识别规则而无需完整扫描。
+//
+// 按以下顺序判断，命中即返回：
+// 1. 后缀（与扫描时的映射一致，包含自定义语言与覆盖设置）
+// 2. 约定文件名（如 Rakefile、SConstruct）
+// 3. shebang 解释器（如 #!/usr/bin/env python3）
+// 4. 文件头部的 vim/emacs modeline（如 "vim: set ft=ruby:"、"-*- mode: python -*-"）
+//
+// 后三种规则只会返回注册中心中存在的语言，被禁用的语言不会被识别。
+func (r *Registry) Detect(path string, head []byte) (string, bool) {
+	head = bytes.TrimPrefix(head, utf8BOM)
+	if analyzer, ok := r.AnalyzerForFile(path); ok {
+		return analyzer.Name(), true
+	}
+
+	candidates := []string{
+		filenameLanguages[strings.ToLower(filepath.Base(path))],
+		interpreterLanguages[trimInterpreterVersion(ShebangInterpreter(head))],
+		modelineLanguage(head),
+	}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if analyzer, ok := r.AnalyzerForLanguage(candidate); ok {
+			return analyzer.Name(), true
+		}
+	}
+	return "", false
+}
+
+// utf8BOM 是 UTF-8 字节序标记；分析与识别语言前都会跳过文件开头的 BOM。
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ShebangInterpreter 在 head（可带 BOM）以 #! 开头时返回首行声明的解释器名，否则返回空字符串。
+//
+// 规则说明：
+// - "/bin/bash -e" 取可执行文件名 bash
+// - "/usr/bin/env python3" 取 env 后的第一个非选项参数 python3
+// - "/usr/bin/env -S node --flag" 同样跳过 env 的选项，取 node
+func ShebangInterpreter(head []byte) string {
+	head = bytes.TrimPrefix(head, utf8BOM)
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+
+	firstLine := head[2:]
+	if end := bytes.IndexAny(firstLine, "\r\n"); end >= 0 {
+		firstLine = firstLine[:end]
+	}
+	fields := strings.Fields(string(firstLine))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := path.Base(fields[0])
+	if interpreter != "env" {
+		return interpreter
+	}
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+			continue
+		}
+		return path.Base(field)
+	}
+	return interpreter
+}
+
+// trimInterpreterVersion 去掉解释器名末尾的版本号，例如 python3.12 -> python。
+func trimInterpreterVersion(interpreter string) string {
+	return strings.TrimRight(strings.ToLower(interpreter), "0123456789.")
+}
+
+// modelineLanguage 在文件头部的前几行中查找 vim/emacs modeline 并返回对应语言。
+func modelineLanguage(head []byte) string {
+	lines := bytes.SplitN(head, []byte("\n"), modelineLines+1)
+	if len(lines) > modelineLines {
+		lines = lines[:modelineLines]
+	}
+
+	for _, line := range lines {
+		if match := vimModeline.FindSubmatch(line); match != nil {
+			if language, ok := modelineLanguages[strings.ToLower(string(match[1]))]; ok {
+				return language
+			}
+		}
+		if match := emacsModeline.FindSubmatch(line); match != nil {
+			name := match[1]
+			if len(name) == 0 {
+				name = match[2]
+			}
+			if language, ok := modelineLanguages[strings.ToLower(string(name))]; ok {
+				return language
+			}
+		}
+	}
+	return ""
+}
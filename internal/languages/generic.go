@@ -0,0 +1,404 @@
+package languages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LanguageDefinition 是声明式的语言定义，用于在不编写 Go 代码的情况下支持小众语言。
+type LanguageDefinition struct {
+	// Name 为语言名称，与内置语言同名时会替换内置分析器。
+	Name string `json:"name" yaml:"name"`
+	// Extensions 为文件后缀列表，缺少点号时自动补齐。
+	Extensions []string `json:"extensions" yaml:"extensions"`
+	// LineComments 为行注释起始符，例如 "#"、"--"。
+	LineComments []string `json:"line_comments" yaml:"line_comments"`
+	// BlockComments 为块注释起止符对。
+	BlockComments []BlockCommentPair `json:"block_comments" yaml:"block_comments"`
+	// StringDelimiters 为字符串定界符，起止相同，例如 "\""、"'"、"\"\"\""；字符串内的反斜杠视为转义。
+	StringDelimiters []string `json:"string_delimiters" yaml:"string_delimiters"`
+	// NestedComments 表示块注释允许嵌套。
+	NestedComments bool `json:"nested_comments" yaml:"nested_comments"`
+	// Heredoc 为 heredoc 语法风格：shell、ruby、perl、php 或 hcl，为空时不识别 heredoc。
+	Heredoc string `json:"heredoc" yaml:"heredoc"`
+}
+
+// BlockCommentPair 表示一组块注释起止符。
+type BlockCommentPair struct {
+	Start string `json:"start" yaml:"start"`
+	End   string `json:"end" yaml:"end"`
+}
+
+// languageDefinitionFile 是语言定义文件的顶层结构。
+type languageDefinitionFile struct {
+	Languages []LanguageDefinition `json:"languages" yaml:"languages"`
+}
+
+// LoadLanguageDefinitions 从 YAML（.yaml/.yml）或 JSON（其他后缀）文件加载语言定义，并逐个校验。
+//
+// 文件格式示例（YAML）：
+//
+//	languages:
+//	  - name: Lua
+//	    extensions: [.lua]
+//	    line_comments: ["--"]
+//	    block_comments: [{start: "--[[", end: "]]"}]
+//	    string_delimiters: ['"', "'"]
+//	  - name: Shell
+//	    extensions: [.sh, .bash]
+//	    line_comments: ["#"]
+//	    string_delimiters: ['"', "'"]
+//	    heredoc: shell
+func LoadLanguageDefinitions(path string) ([]LanguageDefinition, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read language definitions: %w", err)
+	}
+
+	var file languageDefinitionFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &file)
+	default:
+		err = json.Unmarshal(content, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse language definitions: %w", err)
+	}
+
+	for index := range file.Languages {
+		if err := file.Languages[index].normalize(); err != nil {
+			return nil, fmt.Errorf("language definition #%d: %w", index+1, err)
+		}
+	}
+	return file.Languages, nil
+}
+
+// normalize 校验定义并补齐后缀点号。
+func (d *LanguageDefinition) normalize() error {
+	d.Name = strings.TrimSpace(d.Name)
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(d.Extensions) == 0 {
+		return fmt.Errorf("%s: at least one extension is required", d.Name)
+	}
+	for index, ext := range d.Extensions {
+		ext = strings.TrimSpace(ext)
+		if ext == "" || ext == "." {
+			return fmt.Errorf("%s: empty extension", d.Name)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		d.Extensions[index] = ext
+	}
+	for _, token := range d.LineComments {
+		if token == "" {
+			return fmt.Errorf("%s: empty line comment token", d.Name)
+		}
+	}
+	for _, pair := range d.BlockComments {
+		if pair.Start == "" || pair.End == "" {
+			return fmt.Errorf("%s: block comment requires both start and end", d.Name)
+		}
+	}
+	for _, delimiter := range d.StringDelimiters {
+		if delimiter == "" {
+			return fmt.Errorf("%s: empty string delimiter", d.Name)
+		}
+	}
+	d.Heredoc = strings.ToLower(strings.TrimSpace(d.Heredoc))
+	if _, ok := heredocSyntaxes[d.Heredoc]; d.Heredoc != "" && !ok {
+		return fmt.Errorf("%s: unsupported heredoc style %q, allowed values: shell, ruby, perl, php, hcl", d.Name, d.Heredoc)
+	}
+	return nil
+}
+
+// GenericAnalyzer 是由 LanguageDefinition 驱动的通用 FSM 分析器。
+type GenericAnalyzer struct {
+	// Definition 为语言定义，应先经 LoadLanguageDefinitions 校验。
+	Definition LanguageDefinition
+	// Options 控制附加统计能力，零值仅统计基础行数。函数统计对通用分析器不生效。
+	Options Options
+}
+
+// Name 返回语言名称。
+func (a *GenericAnalyzer) Name() string {
+	return a.Definition.Name
+}
+
+// Extensions 返回定义中的后缀。
+func (a *GenericAnalyzer) Extensions() []string {
+	return a.Definition.Extensions
+}
+
+// Capabilities 返回定义中声明的注释与字符串语法。
+func (a *GenericAnalyzer) Capabilities() Capabilities {
+	notes := []string{"字符串内的反斜杠视为转义", "首行 shebang（#!）按 Options.Shebang 计为注释、preprocessor 或代码"}
+	if a.Definition.Heredoc != "" {
+		notes = append(notes, "heredoc 按 "+a.Definition.Heredoc+" 风格识别，正文与结束标记行计为代码（字符串字面量）")
+	}
+	return Capabilities{
+		LineComments:     a.Definition.LineComments,
+		BlockComments:    a.Definition.BlockComments,
+		StringDelimiters: a.Definition.StringDelimiters,
+		NestedComments:   a.Definition.NestedComments,
+		Shebang:          true,
+		Notes:            notes,
+	}
+}
+
+// Analyze 使用通用 FSM 流式读取并统计。
+func (a *GenericAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
+	engine := newGenericFSMEngine(a.Definition, a.Options)
+	return engine.analyze(reader)
+}
+
+// genericFSMEngine 记录通用语法解析状态。
+// 注释与字符串的起止符都可能是多字符，因此预先转换为 rune 切片做前缀匹配。
+type genericFSMEngine struct {
+	options Options
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
+	lineComments     [][]rune
+	blockComments    [][2][]rune
+	stringDelimiters [][]rune
+	nestedComments   bool
+
+	// blockCommentDepth > 0 表示处于块注释中，blockCommentIndex 为当前块注释对的下标。
+	blockCommentDepth int
+	blockCommentIndex int
+	// stringDelimiter 非空表示处于字符串中。
+	stringDelimiter []rune
+	// heredoc 跟踪 heredoc 正文，定义未声明风格时不生效。
+	heredoc heredocTracker
+}
+
+// newGenericFSMEngine 按定义构建引擎，字符串定界符按长度降序匹配，保证 """ 优先于 "。
+func newGenericFSMEngine(definition LanguageDefinition, options Options) *genericFSMEngine {
+	engine := &genericFSMEngine{
+		options:        options,
+		nestedComments: definition.NestedComments,
+		heredoc:        newHeredocTracker(definition.Heredoc),
+	}
+	for _, token := range definition.LineComments {
+		engine.lineComments = append(engine.lineComments, []rune(token))
+	}
+	for _, pair := range definition.BlockComments {
+		engine.blockComments = append(engine.blockComments, [2][]rune{[]rune(pair.Start), []rune(pair.End)})
+	}
+	for _, delimiter := range definition.StringDelimiters {
+		engine.stringDelimiters = append(engine.stringDelimiters, []rune(delimiter))
+	}
+	sort.SliceStable(engine.stringDelimiters, func(i int, j int) bool {
+		return len(engine.stringDelimiters[i]) > len(engine.stringDelimiters[j])
+	})
+	return engine
+}
+
+// analyze 逐行执行解析，适配大文件流式处理。
+func (e *genericFSMEngine) analyze(reader io.Reader) (model.LineMetrics, error) {
+	var metrics model.LineMetrics
+
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
+	if err := e.scratch.scanShebang(&metrics, e.options); err != nil {
+		return metrics, err
+	}
+	for {
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
+		// 没有任何剩余字符时说明已经读完。
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		// 读取失败且不是 EOF 时，直接返回错误。
+		if err != nil {
+			return metrics, err
+		}
+
+		if e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic {
+			applyStringLiteralLine(&metrics, e.options, line, hasComment)
+		} else {
+			applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+		}
+		e.heredoc.endLine()
+	}
+
+	return metrics, nil
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *genericFSMEngine) state() string {
+	switch {
+	case e.blockCommentDepth > 1:
+		return fmt.Sprintf("block-comment(%d)", e.blockCommentDepth)
+	case e.blockCommentDepth == 1:
+		return "block-comment"
+	case e.stringDelimiter != nil:
+		return "string"
+	case e.heredoc.inBody():
+		return "heredoc"
+	}
+	return "code"
+}
+
+// processLine 分析一行代码。
+func (e *genericFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
+	hasCode := false
+	hasComment := false
+	if e.heredoc.inBody() {
+		e.heredoc.body(line)
+		e.lineHasLiteral = true
+		return true, false
+	}
+
+	runes := e.scratch.decode(line)
+
+	if e.blockCommentDepth > 0 {
+		hasComment = true
+	}
+	if e.stringDelimiter != nil {
+		hasCode = true
+		e.lineHasLiteral = true
+	}
+
+	for idx := 0; idx < len(runes); {
+		if e.blockCommentDepth > 0 {
+			hasComment = true
+			pair := e.blockComments[e.blockCommentIndex]
+			// 允许嵌套时，注释内部再次出现同一对的起始符会加深一层。
+			if e.nestedComments && hasRunePrefix(runes, idx, pair[0]) {
+				e.blockCommentDepth++
+				idx += len(pair[0])
+				continue
+			}
+			if hasRunePrefix(runes, idx, pair[1]) {
+				e.blockCommentDepth--
+				idx += len(pair[1])
+				continue
+			}
+			idx++
+			continue
+		}
+
+		if e.stringDelimiter != nil {
+			hasCode = true
+			e.lineHasLiteral = true
+			// 反斜杠优先，避免把转义后的定界符误判成闭合。
+			if runes[idx] == '\\' && idx+1 < len(runes) {
+				idx += 2
+				continue
+			}
+			if hasRunePrefix(runes, idx, e.stringDelimiter) {
+				idx += len(e.stringDelimiter)
+				e.stringDelimiter = nil
+				continue
+			}
+			idx++
+			continue
+		}
+
+		if isBlankRune(runes[idx]) {
+			// 空白字符不参与分类，仅推进扫描。
+			idx++
+			continue
+		}
+
+		// 块注释先于行注释匹配，避免 Lua 的 --[[ 被 -- 截断。
+		if index, ok := e.matchBlockCommentStart(runes, idx); ok {
+			hasComment = true
+			e.blockCommentDepth = 1
+			e.blockCommentIndex = index
+			idx += len(e.blockComments[index][0])
+			continue
+		}
+
+		if e.matchLineComment(runes, idx) {
+			hasComment = true
+			e.lineCommented = true
+			return hasCode, hasComment
+		}
+
+		if delimiter, ok := e.matchStringDelimiter(runes, idx); ok {
+			hasCode = true
+			e.lineHasLiteral = true
+			e.stringDelimiter = delimiter
+			idx += len(delimiter)
+			continue
+		}
+
+		if next, ok := e.heredoc.open(runes, idx); ok {
+			hasCode = true
+			e.lineHasLiteral = true
+			idx = next
+			continue
+		}
+
+		hasCode = true
+		if !isStringLiteralPunctuation(runes[idx]) {
+			e.lineHasLogic = true
+		}
+		idx++
+	}
+
+	return hasCode, hasComment
+}
+
+// matchBlockCommentStart 返回在 idx 处命中的块注释对下标。
+func (e *genericFSMEngine) matchBlockCommentStart(runes []rune, idx int) (int, bool) {
+	for index, pair := range e.blockComments {
+		if hasRunePrefix(runes, idx, pair[0]) {
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+// matchLineComment 判断 idx 处是否为行注释起始符。
+func (e *genericFSMEngine) matchLineComment(runes []rune, idx int) bool {
+	for _, token := range e.lineComments {
+		if hasRunePrefix(runes, idx, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchStringDelimiter 返回在 idx 处命中的字符串定界符。
+func (e *genericFSMEngine) matchStringDelimiter(runes []rune, idx int) ([]rune, bool) {
+	for _, delimiter := range e.stringDelimiters {
+		if hasRunePrefix(runes, idx, delimiter) {
+			return delimiter, true
+		}
+	}
+	return nil, false
+}
+
+// hasRunePrefix 判断 runes 从 idx 开始是否以 token 开头。
+func hasRunePrefix(runes []rune, idx int, token []rune) bool {
+	if idx+len(token) > len(runes) {
+		return false
+	}
+	for offset, value := range token {
+		if runes[idx+offset] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -1,17 +1,53 @@
 package languages
 
 import (
-	"bufio"
 	"errors"
+	"fmt"
 	"io"
+	"regexp"
+	"strings"
 	"unicode"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// goFunctionMatcher 识别 func 开头的函数与方法定义。
+var goFunctionMatcher = functionMatcher{
+	pattern: regexp.MustCompile(`^func\s`),
+}
+
+// goDirectivePattern 识别 Go 工具链指令行：//go:build、//go:generate、//go:embed 等，
+// 以及 //line、cgo 的 //export 与旧式构建约束 // +build。指令的 // 后不能有空格（// +build 除外）。
+var goDirectivePattern = regexp.MustCompile(`^(//go:[a-z]|//line |//export |//extern |// \+build( |$))`)
+
+// Go 编译指令行的计入方式。
+const (
+	// GoDirectivesComment 把指令行计为注释（默认）。
+	GoDirectivesComment = "comment"
+	// GoDirectivesDirective 把指令行计入 Preprocessor，与 C/C++ 预处理指令共用一个分类。
+	GoDirectivesDirective = "directive"
+	// GoDirectivesCode 把指令行计为代码。
+	GoDirectivesCode = "code"
+)
+
+// ParseGoDirectives 规范化 Go 编译指令的计入方式（不区分大小写，空串视为 comment），未知取值返回错误。
+func ParseGoDirectives(value string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(value))
+	switch mode {
+	case "":
+		return GoDirectivesComment, nil
+	case GoDirectivesComment, GoDirectivesDirective, GoDirectivesCode:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unsupported go directives mode %q, allowed values: comment, directive, code", value)
+}
+
 // GoAnalyzer 是 Go 语言专用分析器。
 // 该实现只处理 Go 语法相关状态，不与其他语言复用 FSM 类型。
-type GoAnalyzer struct{}
+type GoAnalyzer struct {
+	// Options 控制附加统计能力，零值仅统计基础行数。
+	Options Options
+}
 
 // Name 返回语言名称。
 func (a *GoAnalyzer) Name() string {
@@ -23,58 +59,140 @@ func (a *GoAnalyzer) Extensions() []string {
 	return []string{".go"}
 }
 
+// Capabilities 返回该 FSM 支持的注释与字符串语法。
+func (a *GoAnalyzer) Capabilities() Capabilities {
+	return Capabilities{
+		LineComments:     []string{"//"},
+		BlockComments:    []BlockCommentPair{{Start: "/*", End: "*/"}},
+		StringDelimiters: []string{"\"", "'", "`"},
+		Notes: []string{
+			"反引号原始字符串可以跨行",
+			"//go:build、//go:generate 等编译指令行按 Options.GoDirectives 计为注释、preprocessor 或代码",
+			"开启 Options.CgoPreamble 时，紧邻 import \"C\" 之前的注释块（cgo 前导代码）按 C/C++ 分析并计入 embedded",
+		},
+	}
+}
+
+// IsTestFile 按 Go 约定识别测试文件：文件名以 _test.go 结尾。
+func (a *GoAnalyzer) IsTestFile(path string) bool {
+	return strings.HasSuffix(pathBase(path), "_test.go")
+}
+
 // Analyze 使用 Go 专用 FSM 对输入流逐行扫描。
 func (a *GoAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
-	engine := &goFSMEngine{}
+	directives, err := ParseGoDirectives(a.Options.GoDirectives)
+	if err != nil {
+		return model.LineMetrics{}, err
+	}
+	engine := &goFSMEngine{options: a.Options, directives: directives}
 	return engine.analyze(reader)
 }
 
 // goFSMEngine 维护 Go 语言分析时的状态集合。
 type goFSMEngine struct {
+	options Options
+	// directives 为编译指令行的计入方式，见 ParseGoDirectives。
+	directives string
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
 	inBlockComment     bool
 	inDoubleQuotedStr  bool
 	inSingleQuotedRune bool
 	inRawStringLiteral bool
+	// preamble 暂存可能是 cgo 前导代码的注释块，仅在 Options.CgoPreamble 开启时使用。
+	preamble cgoPreamble
 }
 
 // analyze 采用流式读取逐行解析，避免一次性加载大文件。
 func (e *goFSMEngine) analyze(reader io.Reader) (model.LineMetrics, error) {
 	var metrics model.LineMetrics
 
-	// 这里使用 ReadString('\n') 做“按行流式”读取：
+	// 这里使用 scanLine（不为每行分配字符串，超长行按段处理）做“按行流式”读取：
 	// 1) 不会把整个文件一次性载入内存；
 	// 2) 便于和行级统计模型（code/comment/blank）天然对齐。
-	bufferedReader := bufio.NewReader(reader)
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
 	for {
-		line, err := bufferedReader.ReadString('\n')
+		startsInCode := e.inCodeState()
+		// 逐行交给 processLine，让状态机在“当前行+历史状态”基础上判断。
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
 		// EOF 且没有任何剩余字符时，说明已经没有可处理行，直接退出。
-		if errors.Is(err, io.EOF) && len(line) == 0 {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		// 非 EOF 错误需要立即返回，避免输出不完整统计结果。
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return metrics, err
 		}
 
-		// 逐行交给 processLine，让状态机在“当前行+历史状态”基础上判断。
-		currentLine := normalizeLine(line)
-		hasCode, hasComment := e.processLine(currentLine)
-		applyLineClassification(&metrics, currentLine, hasCode, hasComment)
-
-		// EOF 但 line 非空代表“最后一行没有换行符”，这行已经处理完，随后退出。
-		if errors.Is(err, io.EOF) {
-			break
+		directive := e.directives != GoDirectivesComment && startsInCode && !hasCode && isGoDirective(line.text)
+		if e.options.CgoPreamble {
+			if (startsInCode || e.preamble.pending()) && hasComment && !hasCode && !directive {
+				e.preamble.add(line)
+				continue
+			}
+			if e.preamble.pending() && startsInCode && cgoImportPattern.MatchString(strings.TrimSpace(line.text)) {
+				if err := e.preamble.attribute(&metrics, e.options); err != nil {
+					return metrics, err
+				}
+			}
+			e.preamble.flush(&metrics, e.options)
+		}
+		switch {
+		case directive && e.directives == GoDirectivesDirective:
+			applyPreprocessorLine(&metrics, e.options, line, false)
+		case directive:
+			applyLineClassification(&metrics, e.options, line, true, false)
+		case e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic:
+			applyStringLiteralLine(&metrics, e.options, line, hasComment)
+		default:
+			applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+		}
+		if e.options.CountFunctions && startsInCode && hasCode && goFunctionMatcher.matches(line.text) {
+			metrics.Functions++
 		}
 	}
+	e.preamble.flush(&metrics, e.options)
 
 	return metrics, nil
 }
 
+// inCodeState 判断当前是否处于普通代码态（不在注释或字符串等跨行状态中）。
+func (e *goFSMEngine) inCodeState() bool {
+	return !e.inBlockComment && !e.inDoubleQuotedStr && !e.inSingleQuotedRune && !e.inRawStringLiteral
+}
+
+// isGoDirective 判断一行是否是编译指令：去掉前导空白后以 //go:xxx 等指令开头。
+// 调用方需保证该行以普通代码态开始且不含代码，避免把块注释或字符串中的文本误判为指令。
+func isGoDirective(line string) bool {
+	return goDirectivePattern.MatchString(strings.TrimLeftFunc(line, unicode.IsSpace))
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *goFSMEngine) state() string {
+	switch {
+	case e.inBlockComment:
+		return "block-comment"
+	case e.inDoubleQuotedStr:
+		return "string"
+	case e.inSingleQuotedRune:
+		return "rune"
+	case e.inRawStringLiteral:
+		return "raw-string"
+	}
+	return "code"
+}
+
 // processLine 扫描单行并更新 FSM 状态，返回该行是否包含 code/comment。
 func (e *goFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
 	hasCode := false
 	hasComment := false
-	runes := []rune(line)
+	runes := e.scratch.decode(line)
 
 	// 先根据“跨行状态”做初始赋值：
 	// - 如果上一个行尾还处于块注释中，本行天然包含 comment；
@@ -84,6 +202,7 @@ func (e *goFSMEngine) processLine(line string) (bool, bool) {
 	}
 	if e.inDoubleQuotedStr || e.inSingleQuotedRune || e.inRawStringLiteral {
 		hasCode = true
+		e.lineHasLiteral = true
 	}
 
 	for idx := 0; idx < len(runes); {
@@ -108,6 +227,7 @@ func (e *goFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inRawStringLiteral {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 原始字符串仅由反引号闭合，不处理转义。
 			if current == '`' {
 				e.inRawStringLiteral = false
@@ -118,6 +238,7 @@ func (e *goFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inDoubleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 普通字符串里反斜杠会吞掉下一个字符，避免误把 \" 当结束引号。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -132,6 +253,7 @@ func (e *goFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inSingleQuotedRune {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 字符字面量同样处理转义，避免 '\'' 等场景误判。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -144,7 +266,7 @@ func (e *goFSMEngine) processLine(line string) (bool, bool) {
 			continue
 		}
 
-		if unicode.IsSpace(current) {
+		if isBlankRune(current) {
 			// 空白字符不决定 code/comment，仅推进游标。
 			idx++
 			continue
@@ -153,6 +275,7 @@ func (e *goFSMEngine) processLine(line string) (bool, bool) {
 		// 行注释：遇到 // 后剩余部分都属于注释。
 		if current == '/' && hasNext && next == '/' {
 			hasComment = true
+			e.lineCommented = true
 			return hasCode, hasComment
 		}
 
@@ -166,6 +289,7 @@ func (e *goFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inDoubleQuotedStr = true
 			idx++
 			continue
@@ -173,6 +297,7 @@ func (e *goFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '\'' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inSingleQuotedRune = true
 			idx++
 			continue
@@ -180,12 +305,16 @@ func (e *goFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '`' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inRawStringLiteral = true
 			idx++
 			continue
 		}
 
 		hasCode = true
+		if !isStringLiteralPunctuation(current) {
+			e.lineHasLogic = true
+		}
 		idx++
 	}
 
@@ -0,0 +1,182 @@
+package languages
+
+import (
+	"strings"
+	"unicode"
+)
+
+// heredoc 语法风格，用于 LanguageDefinition.Heredoc。
+const (
+	// HeredocShell 为 shell 风格：<<EOF、<< EOF、<<'EOF'、<<\EOF，<<- 允许结束标记前有制表符；<<< here-string 不是 heredoc。
+	HeredocShell = "shell"
+	// HeredocRuby 为 Ruby 风格：<<EOS、<<-EOS、<<~EOS，后两者允许结束标记缩进。
+	HeredocRuby = "ruby"
+	// HeredocPerl 为 Perl 风格：<<"EOF"、<<'EOF'、<<EOF，<<~ 允许结束标记缩进。
+	HeredocPerl = "perl"
+	// HeredocPHP 为 PHP 风格：<<<EOT、<<<"EOT" 与 nowdoc <<<'EOT'，结束标记允许缩进，其后可以紧跟 ; 或 ) 等。
+	HeredocPHP = "php"
+	// HeredocHCL 为 HCL/Terraform 风格：<<EOT 与允许结束标记缩进的 <<-EOT。
+	HeredocHCL = "hcl"
+)
+
+// heredocSyntax 描述一种 heredoc 语法：开始记号、修饰符以及结束标记的匹配方式。
+type heredocSyntax struct {
+	// opener 为开始记号，例如 << 或 PHP 的 <<<。
+	opener []rune
+	// indentModifiers 为可以紧跟开始记号、使结束标记允许缩进的修饰符，例如 Ruby 的 - 与 ~。
+	indentModifiers string
+	// tabsOnly 表示结束标记的缩进只能是制表符（shell 的 <<-）。
+	tabsOnly bool
+	// alwaysIndented 表示结束标记总是允许缩进（PHP 7.3 起）。
+	alwaysIndented bool
+	// spaced 表示开始记号与标记之间允许空白（shell 的 << EOF）。
+	spaced bool
+	// upperBare 表示不带修饰符且未加引号的标记必须以大写字母或下划线开头，用于与左移运算区分。
+	upperBare bool
+	// suffixed 表示结束标记之后允许紧跟非标识符字符，例如 PHP 的 EOT; 与 EOT)。
+	suffixed bool
+}
+
+// heredocSyntaxes 为各风格的语法。
+var heredocSyntaxes = map[string]heredocSyntax{
+	HeredocShell: {opener: []rune("<<"), indentModifiers: "-", tabsOnly: true, spaced: true},
+	HeredocRuby:  {opener: []rune("<<"), indentModifiers: "-~", upperBare: true},
+	HeredocPerl:  {opener: []rune("<<"), indentModifiers: "~", upperBare: true},
+	HeredocPHP:   {opener: []rune("<<<"), alwaysIndented: true, suffixed: true},
+	HeredocHCL:   {opener: []rune("<<"), indentModifiers: "-"},
+}
+
+// heredocTerminator 是一个尚未结束的 heredoc 的结束标记。
+type heredocTerminator struct {
+	word     string
+	indented bool
+}
+
+// heredocTracker 供 FSM 引擎跟踪 heredoc：开始记号所在行的其余部分照常按代码扫描，正文从下一行开始，
+// 直到遇到结束标记所在行；同一行可以开始多个 heredoc，它们的正文依次排列。
+// 正文与结束标记行都是字符串字面量内容，计为代码。
+type heredocTracker struct {
+	// syntax 为 nil 时不识别 heredoc。
+	syntax *heredocSyntax
+	// pending 为当前行开始、从下一行起生效的 heredoc，active 为正在读取正文的 heredoc。
+	pending []heredocTerminator
+	active  []heredocTerminator
+}
+
+// newHeredocTracker 按风格构建跟踪器，风格为空时不识别 heredoc。
+func newHeredocTracker(style string) heredocTracker {
+	syntax, ok := heredocSyntaxes[style]
+	if !ok {
+		return heredocTracker{}
+	}
+	return heredocTracker{syntax: &syntax}
+}
+
+// inBody 判断当前行是否属于 heredoc 正文（含结束标记行）。
+func (t *heredocTracker) inBody() bool {
+	return len(t.active) > 0
+}
+
+// body 消费一行正文，遇到结束标记时结束当前 heredoc。
+func (t *heredocTracker) body(line string) {
+	if t.active[0].matches(line, t.syntax) {
+		t.active = t.active[1:]
+	}
+}
+
+// endLine 在每行处理完成后调用，使本行开始的 heredoc 从下一行起生效。
+func (t *heredocTracker) endLine() {
+	if len(t.pending) > 0 {
+		t.active = append(t.active, t.pending...)
+		t.pending = t.pending[:0]
+	}
+}
+
+// open 判断普通代码态中 idx 处是否开始 heredoc，成功时登记结束标记并返回标记之后的索引。
+func (t *heredocTracker) open(runes []rune, idx int) (int, bool) {
+	if t.syntax == nil || !hasRunePrefix(runes, idx, t.syntax.opener) {
+		return idx, false
+	}
+	// 紧邻更长的 < 序列时不是开始记号，例如 shell 的 <<< here-string。
+	if idx > 0 && runes[idx-1] == '<' {
+		return idx, false
+	}
+	cursor := idx + len(t.syntax.opener)
+	if cursor < len(runes) && runes[cursor] == '<' {
+		return idx, false
+	}
+
+	indented := t.syntax.alwaysIndented
+	modified := false
+	if cursor < len(runes) && t.syntax.indentModifiers != "" && strings.ContainsRune(t.syntax.indentModifiers, runes[cursor]) {
+		indented = true
+		modified = true
+		cursor++
+	}
+	if t.syntax.spaced {
+		for cursor < len(runes) && (runes[cursor] == ' ' || runes[cursor] == '\t') {
+			cursor++
+		}
+	}
+	if cursor >= len(runes) {
+		return idx, false
+	}
+
+	var word string
+	switch quote := runes[cursor]; {
+	case quote == '\'' || quote == '"' || quote == '`':
+		end := cursor + 1
+		for end < len(runes) && runes[end] != quote {
+			end++
+		}
+		if end >= len(runes) || end == cursor+1 {
+			return idx, false
+		}
+		word = string(runes[cursor+1 : end])
+		cursor = end + 1
+	default:
+		// shell 的 <<\EOF 与 <<'EOF' 一样关闭展开。
+		if quote == '\\' && t.syntax.spaced {
+			cursor++
+		}
+		start := cursor
+		for cursor < len(runes) && heredocIsWordRune(runes[cursor]) {
+			cursor++
+		}
+		if cursor == start || unicode.IsDigit(runes[start]) {
+			return idx, false
+		}
+		if t.syntax.upperBare && !modified && !unicode.IsUpper(runes[start]) && runes[start] != '_' {
+			return idx, false
+		}
+		word = string(runes[start:cursor])
+	}
+
+	t.pending = append(t.pending, heredocTerminator{word: word, indented: indented})
+	return cursor, true
+}
+
+// matches 判断一行是否为结束标记行，行尾空白不影响匹配。
+func (h heredocTerminator) matches(line string, syntax *heredocSyntax) bool {
+	text := strings.TrimRightFunc(line, isBlankRune)
+	if h.indented {
+		if syntax.tabsOnly {
+			text = strings.TrimLeft(text, "\t")
+		} else {
+			text = strings.TrimLeftFunc(text, isBlankRune)
+		}
+	}
+	rest, ok := strings.CutPrefix(text, h.word)
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	return syntax.suffixed && !heredocIsWordRune([]rune(rest)[0])
+}
+
+// heredocIsWordRune 判断字符能否出现在未加引号的 heredoc 标记中。
+func heredocIsWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -1,16 +1,29 @@
 package languages
 
 import (
-	"bufio"
 	"errors"
 	"io"
-	"unicode"
+	"regexp"
+	"strings"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// javaFunctionMatcher 以“返回类型 + 方法名 + (”且行内不含分号作为方法定义特征。
+// Java 没有函数关键字，该规则属于启发式识别，控制流关键字开头的行会被排除。
+var javaFunctionMatcher = functionMatcher{
+	pattern: regexp.MustCompile(`^((public|protected|private|static|final|abstract|synchronized|native|default|strictfp)\s+)*(<[^>]+>\s+)?[\w.$<>\[\],?]+(\s*[\[\]])*\s+[\w$]+\s*\([^;]*$`),
+	excludedKeywords: map[string]bool{
+		"return": true, "new": true, "if": true, "else": true, "for": true, "while": true,
+		"switch": true, "catch": true, "throw": true, "case": true, "do": true, "try": true,
+	},
+}
+
 // JavaAnalyzer 是 Java 专用 FSM 分析器。
-type JavaAnalyzer struct{}
+type JavaAnalyzer struct {
+	// Options 控制附加统计能力，零值仅统计基础行数。
+	Options Options
+}
 
 // Name 返回语言名称。
 func (a *JavaAnalyzer) Name() string {
@@ -22,15 +35,41 @@ func (a *JavaAnalyzer) Extensions() []string {
 	return []string{".java"}
 }
 
+// Capabilities 返回该 FSM 支持的注释与字符串语法。
+func (a *JavaAnalyzer) Capabilities() Capabilities {
+	return Capabilities{
+		LineComments:     []string{"//"},
+		BlockComments:    []BlockCommentPair{{Start: "/*", End: "*/"}},
+		StringDelimiters: []string{"\"", "'", "\"\"\""},
+		Notes: []string{
+			"文本块（\"\"\"）可以跨行，其中的 \\\" 不会结束文本块",
+			"\\u0022 等 Unicode 转义在词法分析之前还原，可以表示引号、斜杠等字符",
+		},
+	}
+}
+
+// IsTestFile 按 Maven/Gradle 约定识别测试文件：位于 src/test 目录，或类名以 Test/Tests 结尾。
+func (a *JavaAnalyzer) IsTestFile(path string) bool {
+	base := pathBase(path)
+	return strings.Contains("/"+path, "/src/test/") ||
+		strings.HasSuffix(base, "Test.java") ||
+		strings.HasSuffix(base, "Tests.java")
+}
+
 // Analyze 调用 Java 独立 FSM。
 func (a *JavaAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
-	engine := &javaFSMEngine{}
+	engine := &javaFSMEngine{options: a.Options}
 	return engine.analyze(reader)
 }
 
 // javaFSMEngine 维护 Java 词法级状态。
 // 包含注释、普通字符串、字符字面量、文本块（"""）等状态。
 type javaFSMEngine struct {
+	options Options
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
 	inBlockComment bool
 	inDoubleQuoted bool
 	inSingleQuoted bool
@@ -43,38 +82,65 @@ func (e *javaFSMEngine) analyze(reader io.Reader) (model.LineMetrics, error) {
 
 	// Java 文件按行流式读取，避免一次性占用大内存。
 	// 文本块字符串（"""）和块注释状态通过 engine 字段跨行延续。
-	bufferedReader := bufio.NewReader(reader)
-
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
 	for {
-		line, err := bufferedReader.ReadString('\n')
+		startsInCode := e.inCodeState()
+		// 逐行交给 FSM 判定当前行的 code/comment 属性。
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
 		// EOF 且无文本时表示读取结束。
-		if errors.Is(err, io.EOF) && len(line) == 0 {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		// 任何非 EOF 读取异常都应立即失败。
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return metrics, err
 		}
 
-		// 逐行交给 FSM 判定当前行的 code/comment 属性。
-		currentLine := normalizeLine(line)
-		hasCode, hasComment := e.processLine(currentLine)
-		applyLineClassification(&metrics, currentLine, hasCode, hasComment)
-
-		// 最后一行已处理后退出。
-		if errors.Is(err, io.EOF) {
-			break
+		if e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic {
+			applyStringLiteralLine(&metrics, e.options, line, hasComment)
+		} else {
+			applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+		}
+		if e.options.CountFunctions && startsInCode && hasCode && javaFunctionMatcher.matches(line.text) {
+			metrics.Functions++
 		}
 	}
 
 	return metrics, nil
 }
 
+// inCodeState 判断当前是否处于普通代码态（不在注释或字符串等跨行状态中）。
+func (e *javaFSMEngine) inCodeState() bool {
+	return !e.inBlockComment && !e.inDoubleQuoted && !e.inSingleQuoted && !e.inTextBlockStr
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *javaFSMEngine) state() string {
+	switch {
+	case e.inBlockComment:
+		return "block-comment"
+	case e.inDoubleQuoted:
+		return "string"
+	case e.inSingleQuoted:
+		return "char"
+	case e.inTextBlockStr:
+		return "text-block"
+	}
+	return "code"
+}
+
 // processLine 处理一行 Java 文本。
 func (e *javaFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
 	hasCode := false
 	hasComment := false
-	runes := []rune(line)
+	runes := e.scratch.decode(line)
+	if strings.Contains(line, `\u`) {
+		runes = javaUnicodeEscapes(runes)
+	}
 
 	// 先注入跨行状态，确保多行注释/字符串不会漏算。
 	if e.inBlockComment {
@@ -82,6 +148,7 @@ func (e *javaFSMEngine) processLine(line string) (bool, bool) {
 	}
 	if e.inDoubleQuoted || e.inSingleQuoted || e.inTextBlockStr {
 		hasCode = true
+		e.lineHasLiteral = true
 	}
 
 	for idx := 0; idx < len(runes); {
@@ -111,7 +178,12 @@ func (e *javaFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inTextBlockStr {
 			hasCode = true
-			// 文本块字符串以 """ 闭合，内部可跨行包含注释符号文本。
+			e.lineHasLiteral = true
+			// 文本块字符串以 """ 闭合，内部可跨行包含注释符号文本；\""" 中被转义的引号不参与闭合。
+			if current == '\\' && hasNext {
+				idx += 2
+				continue
+			}
 			if current == '"' && hasNext && hasNextTwo && next == '"' && nextTwo == '"' {
 				e.inTextBlockStr = false
 				idx += 3
@@ -123,6 +195,7 @@ func (e *javaFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inDoubleQuoted {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 处理转义字符，避免 \" 导致提早退出字符串态。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -137,6 +210,7 @@ func (e *javaFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inSingleQuoted {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 字符字面量同样要处理转义。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -149,7 +223,7 @@ func (e *javaFSMEngine) processLine(line string) (bool, bool) {
 			continue
 		}
 
-		if unicode.IsSpace(current) {
+		if isBlankRune(current) {
 			// 空白字符不改变分类结果。
 			idx++
 			continue
@@ -157,6 +231,7 @@ func (e *javaFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '/' && hasNext && next == '/' {
 			hasComment = true
+			e.lineCommented = true
 			return hasCode, hasComment
 		}
 
@@ -169,6 +244,7 @@ func (e *javaFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '"' && hasNext && hasNextTwo && next == '"' && nextTwo == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inTextBlockStr = true
 			idx += 3
 			continue
@@ -176,6 +252,7 @@ func (e *javaFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inDoubleQuoted = true
 			idx++
 			continue
@@ -183,14 +260,71 @@ func (e *javaFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '\'' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inSingleQuoted = true
 			idx++
 			continue
 		}
 
 		hasCode = true
+		if !isStringLiteralPunctuation(current) {
+			e.lineHasLogic = true
+		}
 		idx++
 	}
 
 	return hasCode, hasComment
 }
+
+// javaUnicodeEscapes 就地还原一行中的 Unicode 转义（\uXXXX，u 可以重复），返回还原后的切片。
+// Java 在词法分析之前完成这一步，因此 "abc\u0022 中的 \u0022 会结束字符串，\u002F\u002F 是行注释。
+// 与 JLS 3.3 一致，只有前面有偶数个连续反斜杠的 \ 才能开始 Unicode 转义，"\\u0022" 中的 \\ 是普通转义。
+func javaUnicodeEscapes(runes []rune) []rune {
+	kept := 0
+	backslashes := 0
+	for idx := 0; idx < len(runes); idx++ {
+		current := runes[idx]
+		if current == '\\' && backslashes%2 == 0 && idx+1 < len(runes) && runes[idx+1] == 'u' {
+			cursor := idx + 1
+			for cursor < len(runes) && runes[cursor] == 'u' {
+				cursor++
+			}
+			if value, ok := javaHexValue(runes[cursor:]); ok {
+				runes[kept] = value
+				kept++
+				backslashes = 0
+				idx = cursor + 3
+				continue
+			}
+		}
+		if current == '\\' {
+			backslashes++
+		} else {
+			backslashes = 0
+		}
+		runes[kept] = current
+		kept++
+	}
+	return runes[:kept]
+}
+
+// javaHexValue 解析 runes 开头的 4 位十六进制数。
+func javaHexValue(runes []rune) (rune, bool) {
+	if len(runes) < 4 {
+		return 0, false
+	}
+	value := rune(0)
+	for _, digit := range runes[:4] {
+		switch {
+		case '0' <= digit && digit <= '9':
+			value = value*16 + digit - '0'
+		case 'a' <= digit && digit <= 'f':
+			value = value*16 + digit - 'a' + 10
+		case 'A' <= digit && digit <= 'F':
+			value = value*16 + digit - 'A' + 10
+		default:
+			return 0, false
+		}
+	}
+	return value, true
+}
@@ -1,16 +1,24 @@
 package languages
 
 import (
-	"bufio"
 	"errors"
 	"io"
-	"unicode"
+	"regexp"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// javaScriptFunctionMatcher 识别 function 声明（含 export/async 修饰）。
+// 箭头函数与类方法缺少稳定的行首特征，这里不做统计。
+var javaScriptFunctionMatcher = functionMatcher{
+	pattern: regexp.MustCompile(`^(export\s+(default\s+)?)?(async\s+)?function\b`),
+}
+
 // JavaScriptAnalyzer 是 JavaScript 专用 FSM 分析器。
-type JavaScriptAnalyzer struct{}
+type JavaScriptAnalyzer struct {
+	// Options 控制附加统计能力，零值仅统计基础行数。
+	Options Options
+}
 
 // Name 返回语言名称。
 func (a *JavaScriptAnalyzer) Name() string {
@@ -19,21 +27,51 @@ func (a *JavaScriptAnalyzer) Name() string {
 
 // Extensions 返回 JavaScript 常见后缀。
 func (a *JavaScriptAnalyzer) Extensions() []string {
-	return []string{".js", ".mjs", ".cjs"}
+	return []string{".js", ".mjs", ".cjs", ".jsx"}
+}
+
+// Capabilities 返回该 FSM 支持的注释与字符串语法。
+func (a *JavaScriptAnalyzer) Capabilities() Capabilities {
+	return Capabilities{
+		LineComments:     []string{"//"},
+		BlockComments:    []BlockCommentPair{{Start: "/*", End: "*/"}},
+		StringDelimiters: []string{"'", "\"", "`"},
+		Shebang:          true,
+		Notes: []string{
+			"模板字符串可以跨行，${...} 插值按代码解析，其中可以嵌套模板字符串",
+			"识别 JSX：元素文本中的 '、// 不是字符串或注释，只包含注释的 {/* ... */} 计为注释",
+			"首行 shebang（#!）按 Options.Shebang 计为注释、preprocessor 或代码",
+		},
+	}
+}
+
+// IsTestFile 按前端社区约定识别测试文件：*.test.js、*.spec.js 或位于 __tests__ 目录。
+func (a *JavaScriptAnalyzer) IsTestFile(path string) bool {
+	return hasTestInfix(pathBase(path)) || hasPathSegment(path, "__tests__")
 }
 
 // Analyze 使用 JavaScript 独立状态机进行流式分析。
 func (a *JavaScriptAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
-	engine := &javaScriptFSMEngine{}
+	// JavaScript 中 < 出现在表达式位置时只可能是 JSX，因此所有 JavaScript 文件都识别 JSX。
+	engine := &javaScriptFSMEngine{options: a.Options, jsx: jsxTracker{enabled: true}}
 	return engine.analyze(reader)
 }
 
 // javaScriptFSMEngine 持有 JavaScript 语法解析状态。
 type javaScriptFSMEngine struct {
+	options Options
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
 	inBlockComment    bool
 	inSingleQuotedStr bool
 	inDoubleQuotedStr bool
 	inTemplateLiteral bool
+	// templates 跟踪模板字符串中尚未闭合的 ${...} 插值，跨行保留。
+	templates templateTracker
+	// jsx 跟踪 JSX 元素结构，跨行保留。
+	jsx jsxTracker
 }
 
 // analyze 执行逐行读取与统计。
@@ -42,38 +80,65 @@ func (e *javaScriptFSMEngine) analyze(reader io.Reader) (model.LineMetrics, erro
 
 	// JavaScript 分析同样使用流式逐行读取：
 	// 这样既能控制内存，又能保持“每行独立计数 + 状态跨行延续”的语义。
-	bufferedReader := bufio.NewReader(reader)
-
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
+	if err := e.scratch.scanShebang(&metrics, e.options); err != nil {
+		return metrics, err
+	}
 	for {
-		line, err := bufferedReader.ReadString('\n')
+		startsInCode := e.inCodeState()
+		// processLine 会根据当前 FSM 状态判断本行是否包含 code/comment。
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
 		// 没有任何剩余数据时，说明读取结束。
-		if errors.Is(err, io.EOF) && len(line) == 0 {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		// 除 EOF 之外的读取错误直接返回。
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return metrics, err
 		}
 
-		// processLine 会根据当前 FSM 状态判断本行是否包含 code/comment。
-		currentLine := normalizeLine(line)
-		hasCode, hasComment := e.processLine(currentLine)
-		applyLineClassification(&metrics, currentLine, hasCode, hasComment)
-
-		// 最后一行可能没有 \n，处理后再退出。
-		if errors.Is(err, io.EOF) {
-			break
+		if e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic {
+			applyStringLiteralLine(&metrics, e.options, line, hasComment)
+		} else {
+			applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+		}
+		if e.options.CountFunctions && startsInCode && hasCode && javaScriptFunctionMatcher.matches(line.text) {
+			metrics.Functions++
 		}
 	}
 
 	return metrics, nil
 }
 
+// inCodeState 判断当前是否处于普通代码态（不在注释或字符串等跨行状态中）。
+func (e *javaScriptFSMEngine) inCodeState() bool {
+	return !e.inBlockComment && !e.inSingleQuotedStr && !e.inDoubleQuotedStr && !e.inTemplateLiteral && !e.jsx.markup()
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *javaScriptFSMEngine) state() string {
+	switch {
+	case e.inBlockComment:
+		return "block-comment"
+	case e.inSingleQuotedStr || e.inDoubleQuotedStr:
+		return "string"
+	case e.inTemplateLiteral:
+		return "template"
+	case e.jsx.markup():
+		return "jsx"
+	}
+	return "code"
+}
+
 // processLine 解析一行 JavaScript 代码。
 func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
 	hasCode := false
 	hasComment := false
-	runes := []rune(line)
+	runes := e.scratch.decode(line)
 
 	// 继承跨行状态：块注释和字符串/模板字符串都可能延续到下一行。
 	if e.inBlockComment {
@@ -81,6 +146,7 @@ func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
 	}
 	if e.inSingleQuotedStr || e.inDoubleQuotedStr || e.inTemplateLiteral {
 		hasCode = true
+		e.lineHasLiteral = true
 	}
 
 	for idx := 0; idx < len(runes); {
@@ -105,6 +171,7 @@ func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inSingleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 转义字符会消费下一个 rune，避免误判字符串结束。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -119,6 +186,7 @@ func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inDoubleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 双引号字符串的转义逻辑与单引号一致。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -134,6 +202,7 @@ func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
 		// 模板字符串中保留注释符号文本，不计为注释。
 		if e.inTemplateLiteral {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 模板字符串允许换行，也保留 //、/* 等文本，不应计入注释。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -142,11 +211,30 @@ func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
 			if current == '`' {
 				e.inTemplateLiteral = false
 			}
+			// ${ 进入插值表达式，表达式内按普通代码解析，直到配对的 } 回到模板字符串。
+			if current == '$' && hasNext && next == '{' {
+				e.inTemplateLiteral = false
+				e.lineHasLogic = true
+				e.templates.open(len(e.jsx.stack))
+				idx += 2
+				continue
+			}
 			idx++
 			continue
 		}
 
-		if unicode.IsSpace(current) {
+		// JSX 子内容中的 '、"、// 都是普通文本，只有 {、< 会改变结构。
+		if e.jsx.inChildren() {
+			next, code := e.jsx.scanChildren(runes, idx)
+			if code {
+				hasCode = true
+				e.lineHasLogic = true
+			}
+			idx = next
+			continue
+		}
+
+		if isBlankRune(current) {
 			// 空白字符不会直接贡献分类，继续扫描后续字符。
 			idx++
 			continue
@@ -154,6 +242,7 @@ func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '/' && hasNext && next == '/' {
 			hasComment = true
+			e.lineCommented = true
 			return hasCode, hasComment
 		}
 
@@ -164,8 +253,34 @@ func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
 			continue
 		}
 
+		// 模板字符串插值表达式中的花括号；与 ${ 配对的 } 回到模板字符串。
+		if closed, handled := e.templates.brace(current, len(e.jsx.stack)); handled {
+			hasCode = true
+			if closed {
+				e.inTemplateLiteral = true
+				e.lineHasLiteral = true
+			} else {
+				e.lineHasLogic = true
+				e.jsx.noteCode(current)
+			}
+			idx++
+			continue
+		}
+
+		// JSX 标签的 > 与 />、表达式容器的花括号以及开始元素的 <。
+		if next, code, handled := e.jsx.scanCode(runes, idx); handled {
+			if code {
+				hasCode = true
+				e.lineHasLogic = true
+			}
+			idx = next
+			continue
+		}
+
 		if current == '\'' {
 			hasCode = true
+			e.lineHasLiteral = true
+			e.jsx.noteCode(current)
 			e.inSingleQuotedStr = true
 			idx++
 			continue
@@ -173,6 +288,8 @@ func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
+			e.jsx.noteCode(current)
 			e.inDoubleQuotedStr = true
 			idx++
 			continue
@@ -180,12 +297,18 @@ func (e *javaScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '`' {
 			hasCode = true
+			e.lineHasLiteral = true
+			e.jsx.noteCode(current)
 			e.inTemplateLiteral = true
 			idx++
 			continue
 		}
 
 		hasCode = true
+		if !isStringLiteralPunctuation(current) {
+			e.lineHasLogic = true
+		}
+		e.jsx.noteCode(current)
 		idx++
 	}
 
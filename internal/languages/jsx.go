@@ -0,0 +1,205 @@
+package languages
+
+import (
+	"strings"
+	"unicode"
+)
+
+// JSX 解析栈中的层级类型。
+const (
+	// jsxTag 为 <div ...> 或 </div> 标签内部（标签名与属性）。
+	jsxTag = iota
+	// jsxChildren 为元素的子内容：文本与子元素，其中的 ' 与 // 都是普通文本。
+	jsxChildren
+	// jsxExpr 为 {...} 表达式容器，内部回到普通 JS 语法。
+	jsxExpr
+)
+
+// jsxFrame 是 JSX 解析栈中的一层。
+type jsxFrame struct {
+	kind int
+	// closing 表示 </div> 结束标签（仅 jsxTag）。
+	closing bool
+	// braces 为表达式容器内尚未闭合的 { 数量（仅 jsxExpr）。
+	braces int
+	// commentOnly 表示表达式容器目前只包含注释，例如 {/* note */}，此时花括号不计为代码（仅 jsxExpr）。
+	commentOnly bool
+}
+
+// jsxTracker 供 JavaScript/TypeScript 状态机跟踪 JSX 结构，使元素文本中的 '、// 不被误判为字符串或注释，
+// 并让只包含注释的 {/* ... */} 计为注释行。字符串、模板字符串与注释仍由各自的引擎处理。
+type jsxTracker struct {
+	// enabled 为 false 时从不进入 JSX（例如 .ts 文件中的 <T>expr 类型断言）。
+	enabled bool
+	stack   []jsxFrame
+	// last 为最近一个普通代码字符（不含空白与注释），跨行保留，用于判断 < 是否开始 JSX 元素。
+	last rune
+}
+
+// markup 判断当前是否处于 JSX 标签或子内容中（不是普通代码态）。
+func (t *jsxTracker) markup() bool {
+	return len(t.stack) > 0 && t.stack[len(t.stack)-1].kind != jsxExpr
+}
+
+// inChildren 判断当前是否处于元素子内容中。
+func (t *jsxTracker) inChildren() bool {
+	return len(t.stack) > 0 && t.stack[len(t.stack)-1].kind == jsxChildren
+}
+
+// noteCode 记录一个普通代码字符；表达式容器中出现代码后，其花括号按代码计。
+func (t *jsxTracker) noteCode(current rune) {
+	t.last = current
+	if len(t.stack) > 0 && t.stack[len(t.stack)-1].kind == jsxExpr {
+		t.stack[len(t.stack)-1].commentOnly = false
+	}
+}
+
+// scanChildren 处理子内容中 idx 处的字符，返回下一个索引以及该字符是否计为代码。
+// 文本计为代码；{ 进入表达式容器，< 进入子元素或结束标签。
+func (t *jsxTracker) scanChildren(runes []rune, idx int) (int, bool) {
+	switch current := runes[idx]; {
+	case current == '{':
+		commentOnly := jsxCommentFollows(runes, idx+1)
+		t.stack = append(t.stack, jsxFrame{kind: jsxExpr, commentOnly: commentOnly})
+		return idx + 1, !commentOnly
+	case current == '<' && idx+1 < len(runes) && runes[idx+1] == '/':
+		t.stack = append(t.stack, jsxFrame{kind: jsxTag, closing: true})
+		return idx + 2, true
+	case current == '<':
+		t.stack = append(t.stack, jsxFrame{kind: jsxTag})
+		return idx + 1, true
+	case isBlankRune(current):
+		return idx + 1, false
+	}
+	return idx + 1, true
+}
+
+// scanCode 处理标签内部或普通代码态中与 JSX 结构有关的字符：标签的 > 与 />、表达式容器的花括号，
+// 以及开始 JSX 元素的 <。handled 为 false 时由引擎按普通代码处理该字符。
+func (t *jsxTracker) scanCode(runes []rune, idx int) (next int, code bool, handled bool) {
+	current := runes[idx]
+	if len(t.stack) == 0 {
+		if current == '<' && t.opens(runes, idx) {
+			t.stack = append(t.stack, jsxFrame{kind: jsxTag})
+			return idx + 1, true, true
+		}
+		return idx, false, false
+	}
+
+	top := &t.stack[len(t.stack)-1]
+	switch top.kind {
+	case jsxTag:
+		switch {
+		case current == '>' && top.closing:
+			// </div> 结束标签同时结束所在元素的子内容。
+			t.stack = t.stack[:len(t.stack)-1]
+			if t.inChildren() {
+				t.stack = t.stack[:len(t.stack)-1]
+			}
+			t.endElement()
+			return idx + 1, true, true
+		case current == '>':
+			top.kind = jsxChildren
+			return idx + 1, true, true
+		case current == '/' && idx+1 < len(runes) && runes[idx+1] == '>':
+			t.stack = t.stack[:len(t.stack)-1]
+			t.endElement()
+			return idx + 2, true, true
+		case current == '{':
+			t.stack = append(t.stack, jsxFrame{kind: jsxExpr})
+			return idx + 1, true, true
+		}
+	case jsxExpr:
+		switch {
+		case current == '{':
+			top.braces++
+			top.commentOnly = false
+			t.last = current
+			return idx + 1, true, true
+		case current == '}' && top.braces > 0:
+			top.braces--
+			t.last = current
+			return idx + 1, true, true
+		case current == '}':
+			code := !top.commentOnly
+			t.stack = t.stack[:len(t.stack)-1]
+			return idx + 1, code, true
+		case current == '<' && t.opens(runes, idx):
+			top.commentOnly = false
+			t.stack = append(t.stack, jsxFrame{kind: jsxTag})
+			return idx + 1, true, true
+		}
+	}
+	return idx, false, false
+}
+
+// endElement 在元素结束后记录前一个代码字符：回到普通代码态时，元素相当于一个已结束的表达式。
+func (t *jsxTracker) endElement() {
+	if len(t.stack) == 0 || t.stack[len(t.stack)-1].kind == jsxExpr {
+		t.last = ')'
+	}
+}
+
+// opens 判断普通代码态中 idx 处的 < 是否开始 JSX 元素（含片段 <>）。
+// < 必须出现在表达式位置：前一个代码字符是 ( , = : ? & | ! { [ ; >（含 =>），或者是 return、yield 等关键字，
+// 或者位于文件开头；a < b、Array<T> 等比较与泛型因前面是标识符而不会被误判。
+// TSX 中的泛型箭头函数 <T,>(x) => x、<T extends U> 与函数类型 <T>(x: T) => T 也不视为元素。
+func (t *jsxTracker) opens(runes []rune, idx int) bool {
+	if !t.enabled || idx+1 >= len(runes) {
+		return false
+	}
+	if next := runes[idx+1]; next != '>' && !unicode.IsLetter(next) && next != '_' && next != '$' {
+		return false
+	}
+
+	previous := t.last
+	for back := idx - 1; back >= 0; back-- {
+		if unicode.IsSpace(runes[back]) {
+			continue
+		}
+		previous = runes[back]
+		if jsxIsIdentifierRune(previous) {
+			end := back + 1
+			for back >= 0 && jsxIsIdentifierRune(runes[back]) {
+				back--
+			}
+			switch string(runes[back+1 : end]) {
+			case "return", "yield", "default", "await", "case":
+				return !jsxLooksLikeGeneric(runes, idx)
+			}
+			return false
+		}
+		break
+	}
+	switch previous {
+	case 0, '(', ',', '=', ':', '?', '&', '|', '!', '{', '[', ';', '>':
+		return !jsxLooksLikeGeneric(runes, idx)
+	}
+	return false
+}
+
+// jsxLooksLikeGeneric 判断 idx 处的 < 是否是泛型参数列表：<T,>、<T extends U> 或 <T>(。
+func jsxLooksLikeGeneric(runes []rune, idx int) bool {
+	cursor := idx + 1
+	for cursor < len(runes) && (jsxIsIdentifierRune(runes[cursor]) || runes[cursor] == '.') {
+		cursor++
+	}
+	if cursor == idx+1 {
+		return false
+	}
+	rest := strings.TrimLeftFunc(string(runes[cursor:]), unicode.IsSpace)
+	return strings.HasPrefix(rest, ",") || strings.HasPrefix(rest, "extends ") || strings.HasPrefix(rest, ">(")
+}
+
+// jsxCommentFollows 判断 idx 起（跳过空白）是否紧跟 /* 或 //，用于识别 {/* ... */} 形式的 JSX 注释。
+func jsxCommentFollows(runes []rune, idx int) bool {
+	for idx < len(runes) && unicode.IsSpace(runes[idx]) {
+		idx++
+	}
+	return idx+1 < len(runes) && runes[idx] == '/' && (runes[idx+1] == '*' || runes[idx+1] == '/')
+}
+
+// jsxIsIdentifierRune 判断字符能否出现在 JS 标识符中。
+func jsxIsIdentifierRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,123 @@
+package languages
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// PathAnalyzer 是分析器可选实现的接口，用于在分析时获得文件路径（例如需要把路径转交给外部插件）。
+// path 为相对扫描根目录、以 / 分隔的路径；扫描服务优先调用该方法而非 Analyze。
+type PathAnalyzer interface {
+	AnalyzePath(path string, reader io.Reader) (model.LineMetrics, error)
+}
+
+// PluginDefinition 描述一个外部分析器插件。
+type PluginDefinition struct {
+	// Name 为语言名称，与内置语言同名时会替换内置分析器。
+	Name string
+	// Extensions 为插件负责的文件后缀。
+	Extensions []string
+	// Command 为插件命令及参数，Command[0] 为可执行文件。
+	Command []string
+}
+
+// PluginRequest 是发送给插件标准输入的 JSON 请求。
+type PluginRequest struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// ParsePluginSpec 解析命令行插件描述，格式为 NAME:EXT[,EXT...]:COMMAND [ARGS...]，
+// 例如 "Terraform:.tf,.tfvars:tf-cloc --strict"。命令部分按空白切分，不支持引号。
+func ParsePluginSpec(spec string) (PluginDefinition, error) {
+	parts := strings.SplitN(spec, ":", 3)
+	if len(parts) != 3 {
+		return PluginDefinition{}, fmt.Errorf("invalid plugin %q, expected NAME:EXT[,EXT...]:COMMAND", spec)
+	}
+
+	definition := PluginDefinition{
+		Name:    strings.TrimSpace(parts[0]),
+		Command: strings.Fields(parts[2]),
+	}
+	for _, ext := range strings.Split(parts[1], ",") {
+		ext = strings.TrimSpace(ext)
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		definition.Extensions = append(definition.Extensions, ext)
+	}
+
+	if definition.Name == "" || len(definition.Extensions) == 0 || len(definition.Command) == 0 {
+		return PluginDefinition{}, fmt.Errorf("invalid plugin %q, name, extensions and command are required", spec)
+	}
+	return definition, nil
+}
+
+// PluginAnalyzer 通过子进程协议调用外部分析器。
+//
+// 协议说明：
+// 1) 每个文件启动一次插件进程，标准输入写入一个 PluginRequest JSON（path + content）；
+// 2) 插件向标准输出写入一个 LineMetrics JSON（至少包含 total/code/comment/blank），并以 0 退出；
+// 3) 非 0 退出或输出无法解析时记为该文件的扫描错误，错误信息附带插件的标准错误输出。
+type PluginAnalyzer struct {
+	// Definition 为插件定义。
+	Definition PluginDefinition
+	// Options 控制附加统计能力；逐行类能力（标注、空白统计等）由插件自行决定是否支持。
+	Options Options
+}
+
+// Name 返回语言名称。
+func (a *PluginAnalyzer) Name() string {
+	return a.Definition.Name
+}
+
+// Extensions 返回插件负责的后缀。
+func (a *PluginAnalyzer) Extensions() []string {
+	return a.Definition.Extensions
+}
+
+// Analyze 在没有路径信息时调用插件，请求中的 path 为空。
+func (a *PluginAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
+	return a.AnalyzePath("", reader)
+}
+
+// AnalyzePath 把文件路径与内容交给插件进程，并解析其输出的统计结果。
+func (a *PluginAnalyzer) AnalyzePath(path string, reader io.Reader) (model.LineMetrics, error) {
+	var metrics model.LineMetrics
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return metrics, err
+	}
+	request, err := json.Marshal(PluginRequest{Path: path, Content: string(content)})
+	if err != nil {
+		return metrics, err
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd := exec.Command(a.Definition.Command[0], a.Definition.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return metrics, fmt.Errorf("plugin %s: %w: %w: %s", a.Definition.Name, model.ErrDecode, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &metrics); err != nil {
+		return metrics, fmt.Errorf("plugin %s: %w: %w", a.Definition.Name, model.ErrDecode, err)
+	}
+	if metrics.Total < 0 || metrics.Code < 0 || metrics.Comment < 0 || metrics.Blank < 0 {
+		return metrics, fmt.Errorf("plugin %s: %w: negative line counts", a.Definition.Name, model.ErrDecode)
+	}
+	return metrics, nil
+}
@@ -1,16 +1,25 @@
 package languages
 
 import (
-	"bufio"
 	"errors"
 	"io"
+	"regexp"
+	"strings"
 	"unicode"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// pythonFunctionMatcher 识别 def / async def 定义（含类方法）。
+var pythonFunctionMatcher = functionMatcher{
+	pattern: regexp.MustCompile(`^(async\s+)?def\s`),
+}
+
 // PythonAnalyzer 是 Python 语言专用 FSM 分析器。
-type PythonAnalyzer struct{}
+type PythonAnalyzer struct {
+	// Options 控制附加统计能力，零值仅统计基础行数。
+	Options Options
+}
 
 // Name 返回语言名称。
 func (a *PythonAnalyzer) Name() string {
@@ -22,18 +31,66 @@ func (a *PythonAnalyzer) Extensions() []string {
 	return []string{".py"}
 }
 
+// Capabilities 返回该 FSM 支持的注释与字符串语法。
+func (a *PythonAnalyzer) Capabilities() Capabilities {
+	return Capabilities{
+		LineComments:     []string{"#"},
+		StringDelimiters: []string{"'", "\"", "'''", "\"\"\""},
+		Shebang:          true,
+		Notes: []string{
+			"三引号字符串可以跨行",
+			"首行 shebang（#!）按 Options.Shebang 计为注释、preprocessor 或代码",
+		},
+	}
+}
+
+// IsTestFile 按 pytest/unittest 约定识别测试文件：test_*.py、*_test.py、conftest.py 或位于 tests 目录。
+func (a *PythonAnalyzer) IsTestFile(path string) bool {
+	base := pathBase(path)
+	return strings.HasPrefix(base, "test_") ||
+		strings.HasSuffix(base, "_test.py") ||
+		base == "conftest.py" ||
+		hasPathSegment(path, "tests")
+}
+
 // Analyze 使用 Python 独立 FSM 执行流式统计。
 func (a *PythonAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
-	engine := &pythonFSMEngine{}
+	engine := &pythonFSMEngine{options: a.Options}
 	return engine.analyze(reader)
 }
 
+// pythonBlockHeader 识别开启新代码块、其第一条语句可以是 docstring 的 def / async def / class 语句。
+var pythonBlockHeader = regexp.MustCompile(`^(async\s+def|def|class)\s`)
+
 // pythonFSMEngine 保存 Python 解析状态。
 type pythonFSMEngine struct {
+	options Options
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
 	inSingleQuotedStr bool
 	inDoubleQuotedStr bool
 	inTripleSingleStr bool
 	inTripleDoubleStr bool
+	// fstring 表示当前字符串带 f/t 前缀，其中的 {...} 是表达式；fstringDepth 为表达式内未闭合的 { 数量，
+	// fstringQuote 为表达式内嵌套字符串的引号（Python 3.12 起可以与外层引号相同，例如 f"{x["key"]}"）。
+	fstring      bool
+	fstringDepth int
+	fstringQuote rune
+	// lineEnd 为当前物理行的最后一个字符，用于判断普通引号字符串是否以反斜杠续行。
+	lineEnd rune
+
+	// 以下字段只在 Options.PythonDocstrings 开启时使用，用于跟踪“语句位置”：
+	// expectDocstring 表示下一条语句是模块、类或函数体的第一条语句；inDocstring 表示当前三引号字符串是 docstring。
+	expectDocstring bool
+	inDocstring     bool
+	// lineHasToken 表示当前行（超长行包括之前的分段）已出现代码；inHeader 表示当前逻辑行以 def/class 开头；
+	// depth 为括号嵌套深度；lastToken 为当前行最后一个代码字符，括号闭合且行末为 ':' 时开始新的代码块。
+	lineHasToken bool
+	inHeader     bool
+	depth        int
+	lastToken    rune
 }
 
 // analyze 流式读取并逐行统计。
@@ -42,42 +99,193 @@ func (e *pythonFSMEngine) analyze(reader io.Reader) (model.LineMetrics, error) {
 
 	// Python 引擎按行读取并保持状态机跨行延续：
 	// 三引号字符串经常跨行，必须在流式处理中持续保留状态。
-	bufferedReader := bufio.NewReader(reader)
-
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
+	if err := e.scratch.scanShebang(&metrics, e.options); err != nil {
+		return metrics, err
+	}
+	e.expectDocstring = e.options.PythonDocstrings
 	for {
-		line, err := bufferedReader.ReadString('\n')
+		startsInCode := e.inCodeState()
+		e.lineHasToken = false
+		e.lineEnd = 0
+		// 逐行归一化并交给 processLine 做 FSM 判定。
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
 		// 完整 EOF（无残余字符）直接结束。
-		if errors.Is(err, io.EOF) && len(line) == 0 {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		// 读取过程中出现非 EOF 错误时，返回已知错误以便上层感知。
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return metrics, err
 		}
+		e.endStringLine()
+		if e.options.PythonDocstrings {
+			e.endLine()
+		}
 
-		// 逐行归一化并交给 processLine 做 FSM 判定。
-		currentLine := normalizeLine(line)
-		hasCode, hasComment := e.processLine(currentLine)
-		applyLineClassification(&metrics, currentLine, hasCode, hasComment)
-
-		// EOF 但仍有本行内容时，需要在本轮统计后再退出。
-		if errors.Is(err, io.EOF) {
-			break
+		if e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic {
+			applyStringLiteralLine(&metrics, e.options, line, hasComment)
+		} else {
+			applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+		}
+		if e.options.CountFunctions && startsInCode && hasCode && pythonFunctionMatcher.matches(line.text) {
+			metrics.Functions++
 		}
 	}
 
 	return metrics, nil
 }
 
+// inCodeState 判断当前是否处于普通代码态（不在注释或字符串等跨行状态中）。
+func (e *pythonFSMEngine) inCodeState() bool {
+	return !e.inSingleQuotedStr && !e.inDoubleQuotedStr && !e.inTripleSingleStr && !e.inTripleDoubleStr
+}
+
+// endStringLine 在一行结束后结束未闭合的单行字符串：Python 的普通引号字符串只能用行尾反斜杠续行，
+// 没有续行的未闭合字符串（语法错误或无法识别的写法）不再延续到后续各行，避免整个文件的状态被带偏。
+func (e *pythonFSMEngine) endStringLine() {
+	if e.lineEnd == '\\' {
+		return
+	}
+	e.fstringQuote = 0
+	if e.inSingleQuotedStr || e.inDoubleQuotedStr {
+		e.inSingleQuotedStr = false
+		e.inDoubleQuotedStr = false
+		e.fstring = false
+		e.fstringDepth = 0
+	}
+}
+
+// openString 在 idx 处的引号开始字符串时调用，根据前缀设置 f-string 状态。
+// 前缀为引号前紧邻的 1～2 个字母（r、b、u、f、t 及其组合，不区分大小写），且前面不能再有标识符字符。
+// r/b/u 不影响字符串的结束位置：即使是原始字符串，反斜杠后的引号也不会结束字符串（r"\"" 是合法的两字符字符串）。
+func (e *pythonFSMEngine) openString(runes []rune, idx int) {
+	e.fstring = false
+	e.fstringDepth = 0
+	e.fstringQuote = 0
+	start := idx
+	for start > 0 && idx-start < 2 && strings.ContainsRune("rRbBuUfFtT", runes[start-1]) {
+		start--
+	}
+	if start == idx || (start > 0 && (runes[start-1] == '_' || unicode.IsLetter(runes[start-1]) || unicode.IsDigit(runes[start-1]))) {
+		return
+	}
+	e.fstring = strings.ContainsAny(string(runes[start:idx]), "fFtT")
+}
+
+// scanFString 处理 f-string 中 idx 处的字符，返回下一个索引；ok 为 false 时由调用方按普通字符串内容处理。
+// 字面部分的 { 开始表达式（{{ 为转义）；表达式内的引号开始嵌套字符串，嵌套字符串与花括号内的引号都不会结束外层字符串。
+func (e *pythonFSMEngine) scanFString(runes []rune, idx int) (int, bool) {
+	current := runes[idx]
+	switch {
+	case e.fstringQuote != 0:
+		if current == '\\' && idx+1 < len(runes) {
+			return idx + 2, true
+		}
+		if current == e.fstringQuote {
+			e.fstringQuote = 0
+		}
+		return idx + 1, true
+	case e.fstringDepth > 0:
+		switch current {
+		case '\'', '"':
+			e.fstringQuote = current
+		case '{':
+			e.fstringDepth++
+		case '}':
+			e.fstringDepth--
+		}
+		return idx + 1, true
+	case current == '{' && idx+1 < len(runes) && runes[idx+1] == '{':
+		return idx + 2, true
+	case current == '{':
+		e.fstringDepth = 1
+		return idx + 1, true
+	}
+	return idx, false
+}
+
+// endLine 在一行结束后更新语句位置：以 def/class 开头的逻辑行在括号闭合的行末以 ':' 结束时，
+// 下一条语句位于新代码块开头，可以是 docstring；同一行内写完的语句体（def f(): pass）不开启新代码块。
+func (e *pythonFSMEngine) endLine() {
+	if !e.lineHasToken || e.depth > 0 || !e.inCodeState() {
+		return
+	}
+	e.expectDocstring = e.inHeader && e.lastToken == ':'
+	e.inHeader = false
+}
+
+// startStatement 在一行的第一个代码字符处调用，runes 从该字符开始，line 为当前行文本。
+// 位于 docstring 位置且以三引号字符串（可带 r/u 前缀）开头时进入 docstring 并返回跳过的字符数，否则返回 0。
+func (e *pythonFSMEngine) startStatement(line string, runes []rune) int {
+	e.lineHasToken = true
+	if e.depth > 0 {
+		return 0
+	}
+	expected := e.expectDocstring
+	e.expectDocstring = false
+	e.inHeader = pythonBlockHeader.MatchString(strings.TrimLeftFunc(line, unicode.IsSpace))
+	if !expected {
+		return 0
+	}
+
+	start := 0
+	if len(runes) > 0 && strings.ContainsRune("rRuU", runes[0]) {
+		start = 1
+	}
+	if len(runes) < start+3 || (runes[start] != '"' && runes[start] != '\'') || runes[start+1] != runes[start] || runes[start+2] != runes[start] {
+		return 0
+	}
+	e.inDocstring = true
+	e.fstring = false
+	e.inTripleSingleStr = runes[start] == '\''
+	e.inTripleDoubleStr = runes[start] == '"'
+	return start + 3
+}
+
+// trackToken 记录代码字符，更新括号深度与行末字符。
+func (e *pythonFSMEngine) trackToken(current rune) {
+	switch current {
+	case '(', '[', '{':
+		e.depth++
+	case ')', ']', '}':
+		e.depth = max(e.depth-1, 0)
+	}
+	e.lastToken = current
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *pythonFSMEngine) state() string {
+	switch {
+	case (e.inTripleSingleStr || e.inTripleDoubleStr) && e.inDocstring:
+		return "docstring"
+	case e.inTripleSingleStr || e.inTripleDoubleStr:
+		return "triple-string"
+	case e.inSingleQuotedStr || e.inDoubleQuotedStr:
+		return "string"
+	}
+	return "code"
+}
+
 // processLine 处理单行 Python 文本。
 func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
 	hasCode := false
 	hasComment := false
-	runes := []rune(line)
+	runes := e.scratch.decode(line)
+	if len(runes) > 0 {
+		e.lineEnd = runes[len(runes)-1]
+	}
 
-	// 三引号或普通引号字符串如果跨行未闭合，当前行默认属于 code。
-	if e.inSingleQuotedStr || e.inDoubleQuotedStr || e.inTripleSingleStr || e.inTripleDoubleStr {
+	// 三引号或普通引号字符串如果跨行未闭合，当前行默认属于 code；docstring 的后续行属于 comment。
+	if e.inDocstring {
+		hasComment = true
+	} else if e.inSingleQuotedStr || e.inDoubleQuotedStr || e.inTripleSingleStr || e.inTripleDoubleStr {
 		hasCode = true
+		e.lineHasLiteral = true
 	}
 
 	for idx := 0; idx < len(runes); {
@@ -94,10 +302,23 @@ func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
 		}
 
 		if e.inTripleSingleStr {
-			hasCode = true
+			if e.inDocstring {
+				hasComment = true
+			} else {
+				hasCode = true
+				e.lineHasLiteral = true
+			}
+			if e.fstring {
+				if next, ok := e.scanFString(runes, idx); ok {
+					idx = next
+					continue
+				}
+			}
 			// 三单引号字符串只有遇到 ''' 才会退出。
 			if current == '\'' && hasNext && hasNextTwo && next == '\'' && nextTwo == '\'' {
 				e.inTripleSingleStr = false
+				e.inDocstring = false
+				e.lastToken = current
 				idx += 3
 				continue
 			}
@@ -106,10 +327,23 @@ func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
 		}
 
 		if e.inTripleDoubleStr {
-			hasCode = true
+			if e.inDocstring {
+				hasComment = true
+			} else {
+				hasCode = true
+				e.lineHasLiteral = true
+			}
+			if e.fstring {
+				if next, ok := e.scanFString(runes, idx); ok {
+					idx = next
+					continue
+				}
+			}
 			// 三双引号字符串只有遇到 """ 才会退出。
 			if current == '"' && hasNext && hasNextTwo && next == '"' && nextTwo == '"' {
 				e.inTripleDoubleStr = false
+				e.inDocstring = false
+				e.lastToken = current
 				idx += 3
 				continue
 			}
@@ -119,6 +353,13 @@ func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inSingleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
+			if e.fstring {
+				if next, ok := e.scanFString(runes, idx); ok {
+					idx = next
+					continue
+				}
+			}
 			// 普通字符串里反斜杠会转义下一个字符。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -126,6 +367,7 @@ func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
 			}
 			if current == '\'' {
 				e.inSingleQuotedStr = false
+				e.lastToken = current
 			}
 			idx++
 			continue
@@ -133,6 +375,13 @@ func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inDoubleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
+			if e.fstring {
+				if next, ok := e.scanFString(runes, idx); ok {
+					idx = next
+					continue
+				}
+			}
 			// 双引号字符串同样处理转义。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -140,12 +389,13 @@ func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
 			}
 			if current == '"' {
 				e.inDoubleQuotedStr = false
+				e.lastToken = current
 			}
 			idx++
 			continue
 		}
 
-		if unicode.IsSpace(current) {
+		if isBlankRune(current) {
 			// 空白字符继续跳过，等待第一个有效 token 决定分类。
 			idx++
 			continue
@@ -154,11 +404,23 @@ func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
 		// Python 的行注释标识为 #，字符串内 # 由字符串状态吞掉。
 		if current == '#' {
 			hasComment = true
+			e.lineCommented = true
 			return hasCode, hasComment
 		}
 
+		if e.options.PythonDocstrings && !e.lineHasToken {
+			if skip := e.startStatement(line, runes[idx:]); skip > 0 {
+				hasComment = true
+				idx += skip
+				continue
+			}
+		}
+
 		if current == '\'' {
 			hasCode = true
+			e.lineHasLiteral = true
+			e.lastToken = current
+			e.openString(runes, idx)
 			if hasNext && hasNextTwo && next == '\'' && nextTwo == '\'' {
 				e.inTripleSingleStr = true
 				idx += 3
@@ -171,6 +433,9 @@ func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
+			e.lastToken = current
+			e.openString(runes, idx)
 			if hasNext && hasNextTwo && next == '"' && nextTwo == '"' {
 				e.inTripleDoubleStr = true
 				idx += 3
@@ -182,6 +447,12 @@ func (e *pythonFSMEngine) processLine(line string) (bool, bool) {
 		}
 
 		hasCode = true
+		if !isStringLiteralPunctuation(current) {
+			e.lineHasLogic = true
+		}
+		if e.options.PythonDocstrings {
+			e.trackToken(current)
+		}
 		idx++
 	}
 
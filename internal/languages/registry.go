@@ -1,12 +1,13 @@
 package languages
 
 import (
+	"fmt"
 	"io"
 	"path/filepath"
 	"sort"
 	"strings"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
 // Analyzer 定义单语言 FSM 分析器接口。
@@ -20,10 +21,40 @@ type Analyzer interface {
 	Analyze(reader io.Reader) (model.LineMetrics, error)
 }
 
+// TestFileClassifier 是分析器可选实现的接口，用于按语言约定识别测试文件。
+// path 为相对扫描根目录、以 / 分隔的路径。
+type TestFileClassifier interface {
+	IsTestFile(path string) bool
+}
+
+// VariantClassifier 是分析器可选实现的接口，用于把同一语言的文件划分为子类别
+// （例如 C/C++ 的头文件与实现文件），汇总时会在语言下输出对应子行。
+type VariantClassifier interface {
+	Variant(path string) string
+}
+
+// ExtensionVariant 是分析器可选实现的接口，用于同一语言的部分后缀需要不同分析规则的情况
+// （例如 .tsx 需要识别 JSX，而 .ts 中的 <T>expr 是类型断言）。注册中心把后缀映射到 ForExtension 返回的分析器，
+// 返回值必须与原分析器同名；语言的禁用、后缀查询等操作按名称进行，因此变体与原分析器视为同一种语言。
+type ExtensionVariant interface {
+	ForExtension(ext string) Analyzer
+}
+
+// AnalyzerVersion 是内置分析逻辑的版本号，分类规则或统计口径变化时递增，使按内容缓存的旧结果失效。
+const AnalyzerVersion = 11
+
+// Fingerprint 返回分析器的指纹：分析逻辑版本、分析器类型与全部配置（分析选项、自定义语言定义）都相同时，
+// 同一份内容的分析结果相同，可用作按内容缓存的键。
+func Fingerprint(analyzer Analyzer) string {
+	return fmt.Sprintf("v%d|%T|%+v", AnalyzerVersion, analyzer, analyzer)
+}
+
 // LanguageDescriptor 用于对外展示语言及后缀信息。
+// Capabilities 仅在分析器实现 CapabilityDescriber 时填充。
 type LanguageDescriptor struct {
-	Name       string
-	Extensions []string
+	Name         string
+	Extensions   []string
+	Capabilities *Capabilities
 }
 
 // Registry 管理语言分析器注册与后缀映射。
@@ -32,18 +63,23 @@ type Registry struct {
 	analyzerByExt map[string]Analyzer
 }
 
-// NewRegistry 创建并注册所有内置语言分析器。
+// NewRegistry 创建并注册所有内置语言分析器（使用默认 Options）。
 func NewRegistry() *Registry {
+	return NewRegistryWithOptions(Options{})
+}
+
+// NewRegistryWithOptions 使用指定的分析选项创建内置语言分析器注册中心。
+func NewRegistryWithOptions(options Options) *Registry {
 	analyzers := []Analyzer{
-		&GoAnalyzer{},
-		&JavaScriptAnalyzer{},
-		&TypeScriptAnalyzer{},
-		&PythonAnalyzer{},
-		&RustAnalyzer{},
-		&RubyAnalyzer{},
-		&JavaAnalyzer{},
-		&CCPPAnalyzer{},
-		&SQLAnalyzer{},
+		&GoAnalyzer{Options: options},
+		&JavaScriptAnalyzer{Options: options},
+		&TypeScriptAnalyzer{Options: options},
+		&PythonAnalyzer{Options: options},
+		&RustAnalyzer{Options: options},
+		&RubyAnalyzer{Options: options},
+		&JavaAnalyzer{Options: options},
+		&CCPPAnalyzer{Options: options},
+		&SQLAnalyzer{Options: options},
 	}
 
 	registry := &Registry{
@@ -53,13 +89,53 @@ func NewRegistry() *Registry {
 
 	for _, analyzer := range analyzers {
 		for _, ext := range analyzer.Extensions() {
-			registry.analyzerByExt[strings.ToLower(ext)] = analyzer
+			registry.mapExtension(strings.ToLower(ext), analyzer)
 		}
 	}
 
 	return registry
 }
 
+// mapExtension 把规范化后的后缀映射到分析器；分析器实现 ExtensionVariant 时使用该后缀的变体。
+func (r *Registry) mapExtension(ext string, analyzer Analyzer) {
+	if variant, ok := analyzer.(ExtensionVariant); ok {
+		analyzer = variant.ForExtension(ext)
+	}
+	r.analyzerByExt[ext] = analyzer
+}
+
+// Register 注册额外的分析器（例如由语言定义文件构建的 GenericAnalyzer）。
+// 与已注册语言同名时替换原分析器；后缀冲突时以后注册的分析器为准。
+func (r *Registry) Register(analyzer Analyzer) {
+	for index, existing := range r.analyzers {
+		if existing.Name() != analyzer.Name() {
+			continue
+		}
+		for ext, mapped := range r.analyzerByExt {
+			if mapped.Name() == existing.Name() {
+				delete(r.analyzerByExt, ext)
+			}
+		}
+		r.analyzers = append(r.analyzers[:index:index], r.analyzers[index+1:]...)
+		break
+	}
+
+	r.analyzers = append(r.analyzers, analyzer)
+	for _, ext := range analyzer.Extensions() {
+		r.mapExtension(strings.ToLower(ext), analyzer)
+	}
+}
+
+// AnalyzerForLanguage 按语言名称查找分析器，名称比较不区分大小写。
+func (r *Registry) AnalyzerForLanguage(language string) (Analyzer, bool) {
+	for _, analyzer := range r.analyzers {
+		if strings.EqualFold(analyzer.Name(), strings.TrimSpace(language)) {
+			return analyzer, true
+		}
+	}
+	return nil, false
+}
+
 // AnalyzerForFile 根据文件后缀查找分析器。
 func (r *Registry) AnalyzerForFile(path string) (Analyzer, bool) {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -67,16 +143,19 @@ func (r *Registry) AnalyzerForFile(path string) (Analyzer, bool) {
 	return analyzer, ok
 }
 
-// Languages 返回已注册语言清单。
+// Languages 返回已注册语言清单，后缀按当前映射（含覆盖）计算。
 func (r *Registry) Languages() []LanguageDescriptor {
 	result := make([]LanguageDescriptor, 0, len(r.analyzers))
 	for _, analyzer := range r.analyzers {
-		extensions := append([]string(nil), analyzer.Extensions()...)
-		sort.Strings(extensions)
-		result = append(result, LanguageDescriptor{
+		descriptor := LanguageDescriptor{
 			Name:       analyzer.Name(),
-			Extensions: extensions,
-		})
+			Extensions: r.extensionsOf(analyzer),
+		}
+		if describer, ok := analyzer.(CapabilityDescriber); ok {
+			capabilities := describer.Capabilities()
+			descriptor.Capabilities = &capabilities
+		}
+		result = append(result, descriptor)
 	}
 
 	sort.Slice(result, func(i int, j int) bool {
@@ -86,14 +165,146 @@ func (r *Registry) Languages() []LanguageDescriptor {
 	return result
 }
 
-// ExtensionsForLanguage 返回指定语言对应的全部后缀。
+// ExtensionsForLanguage 返回指定语言当前映射到的全部后缀。
 func (r *Registry) ExtensionsForLanguage(language string) []string {
 	for _, analyzer := range r.analyzers {
 		if analyzer.Name() == language {
-			extensions := append([]string(nil), analyzer.Extensions()...)
-			sort.Strings(extensions)
-			return extensions
+			return r.extensionsOf(analyzer)
+		}
+	}
+	return nil
+}
+
+// extensionsOf 返回映射到指定分析器所属语言的后缀（排序后，含 ExtensionVariant 变体的后缀）。
+func (r *Registry) extensionsOf(analyzer Analyzer) []string {
+	extensions := make([]string, 0)
+	for ext, mapped := range r.analyzerByExt {
+		if mapped.Name() == analyzer.Name() {
+			extensions = append(extensions, ext)
 		}
 	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// Fingerprint 返回整个注册中心的指纹：各后缀映射到的分析器指纹（见 Fingerprint）按后缀排序拼接，
+// 分析选项、语言开关、后缀映射或自定义语言变化时指纹随之变化。
+func (r *Registry) Fingerprint() string {
+	extensions := make([]string, 0, len(r.analyzerByExt))
+	for ext := range r.analyzerByExt {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+
+	var builder strings.Builder
+	for _, ext := range extensions {
+		builder.WriteString(ext)
+		builder.WriteByte('=')
+		builder.WriteString(Fingerprint(r.analyzerByExt[ext]))
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+// RegistryOverrides 描述单次扫描对语言设置的覆盖。
+type RegistryOverrides struct {
+	// EnabledLanguages 非空时只保留列出的语言（不区分大小写），其余语言全部禁用。
+	EnabledLanguages []string
+	// DisabledLanguages 为需要禁用的语言名称（不区分大小写）。
+	DisabledLanguages []string
+	// Extensions 把后缀（可省略点号）映射到指定语言（不区分大小写），覆盖原有映射。
+	Extensions map[string]string
+}
+
+// Clone 返回注册中心的独立副本。分析器本身无状态（每次 Analyze 都创建新的引擎），因此在副本间共享；
+// 对副本的 Register/Disable/MapExtension 不会影响原注册中心。
+//
+// Registry 的修改方法不是并发安全的：常驻服务应持有一个只读的基础注册中心，
+// 每个请求先 Clone（或 WithOverrides）再修改，从而在并发请求间互不干扰。
+func (r *Registry) Clone() *Registry {
+	clone := &Registry{
+		analyzers:     append([]Analyzer(nil), r.analyzers...),
+		analyzerByExt: make(map[string]Analyzer, len(r.analyzerByExt)),
+	}
+	for ext, analyzer := range r.analyzerByExt {
+		clone.analyzerByExt[ext] = analyzer
+	}
+	return clone
+}
+
+// WithOverrides 返回应用了覆盖设置的副本，原注册中心保持不变。
+// 先按 EnabledLanguages 与 DisabledLanguages 禁用语言再映射后缀，因此后缀不能映射到被禁用的语言。
+func (r *Registry) WithOverrides(overrides RegistryOverrides) (*Registry, error) {
+	clone := r.Clone()
+	if len(overrides.EnabledLanguages) > 0 {
+		enabled := make(map[string]bool, len(overrides.EnabledLanguages))
+		for _, language := range overrides.EnabledLanguages {
+			analyzer, ok := r.AnalyzerForLanguage(language)
+			if !ok {
+				return nil, fmt.Errorf("unknown language to enable: %s", language)
+			}
+			enabled[analyzer.Name()] = true
+		}
+		for _, analyzer := range r.analyzers {
+			if !enabled[analyzer.Name()] {
+				clone.Disable(analyzer.Name())
+			}
+		}
+	}
+	for _, language := range overrides.DisabledLanguages {
+		if !clone.Disable(language) {
+			return nil, fmt.Errorf("unknown language to disable: %s", language)
+		}
+	}
+
+	extensions := make([]string, 0, len(overrides.Extensions))
+	for ext := range overrides.Extensions {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+	for _, ext := range extensions {
+		if err := clone.MapExtension(ext, overrides.Extensions[ext]); err != nil {
+			return nil, err
+		}
+	}
+	return clone, nil
+}
+
+// Disable 移除指定语言（不区分大小写）及其全部后缀映射，语言不存在时返回 false。
+func (r *Registry) Disable(language string) bool {
+	analyzer, ok := r.AnalyzerForLanguage(language)
+	if !ok {
+		return false
+	}
+
+	for ext, mapped := range r.analyzerByExt {
+		if mapped.Name() == analyzer.Name() {
+			delete(r.analyzerByExt, ext)
+		}
+	}
+	for index, existing := range r.analyzers {
+		if existing == analyzer {
+			r.analyzers = append(r.analyzers[:index:index], r.analyzers[index+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// MapExtension 把后缀映射到已注册的语言（不区分大小写），覆盖该后缀原有的映射。
+func (r *Registry) MapExtension(ext string, language string) error {
+	analyzer, ok := r.AnalyzerForLanguage(language)
+	if !ok {
+		return fmt.Errorf("unknown language for extension %s: %s", ext, language)
+	}
+
+	normalized := strings.ToLower(strings.TrimSpace(ext))
+	if normalized == "" || normalized == "." {
+		return fmt.Errorf("empty extension for language %s", language)
+	}
+	if !strings.HasPrefix(normalized, ".") {
+		normalized = "." + normalized
+	}
+	r.mapExtension(normalized, analyzer)
 	return nil
 }
@@ -1,17 +1,25 @@
 package languages
 
 import (
-	"bufio"
 	"errors"
 	"io"
+	"regexp"
 	"strings"
 	"unicode"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// rubyFunctionMatcher 识别 def 定义（含 def self.xxx 单例方法）。
+var rubyFunctionMatcher = functionMatcher{
+	pattern: regexp.MustCompile(`^def\s`),
+}
+
 // RubyAnalyzer 是 Ruby 专用 FSM 分析器。
-type RubyAnalyzer struct{}
+type RubyAnalyzer struct {
+	// Options 控制附加统计能力，零值仅统计基础行数。
+	Options Options
+}
 
 // Name 返回语言名称。
 func (a *RubyAnalyzer) Name() string {
@@ -23,18 +31,60 @@ func (a *RubyAnalyzer) Extensions() []string {
 	return []string{".rb"}
 }
 
+// Capabilities 返回该 FSM 支持的注释与字符串语法。
+func (a *RubyAnalyzer) Capabilities() Capabilities {
+	return Capabilities{
+		LineComments:           []string{"#"},
+		BlockComments:          []BlockCommentPair{{Start: "=begin", End: "=end"}},
+		StringDelimiters:       []string{"'", "\"", "%(", "%q(", "%Q(", "%w(", "%W(", "%i(", "%I(", "%r(", "%s(", "%x("},
+		LineStartBlockComments: true,
+		Shebang:                true,
+		Notes: []string{
+			"=begin/=end 只在行首生效",
+			"% 字面量支持任意定界符：成对括号 ()[]{}<> 可嵌套，其他标点以相同字符闭合，可以跨行",
+			"首行 shebang（#!）按 Options.Shebang 计为注释、preprocessor 或代码",
+			"heredoc（<<EOS、<<-EOS、<<~EOS，标记可加引号）的正文与结束标记行计为代码（字符串字面量），其中的 # 与 =begin 不是注释",
+		},
+	}
+}
+
+// IsTestFile 按 RSpec/Minitest 约定识别测试文件：*_spec.rb、*_test.rb 或位于 spec/test 目录。
+func (a *RubyAnalyzer) IsTestFile(path string) bool {
+	base := pathBase(path)
+	return strings.HasSuffix(base, "_spec.rb") ||
+		strings.HasSuffix(base, "_test.rb") ||
+		hasPathSegment(path, "spec") ||
+		hasPathSegment(path, "test")
+}
+
 // Analyze 使用 Ruby 独立 FSM 执行扫描。
 func (a *RubyAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
-	engine := &rubyFSMEngine{}
+	engine := &rubyFSMEngine{options: a.Options, heredoc: newHeredocTracker(HeredocRuby)}
 	return engine.analyze(reader)
 }
 
 // rubyFSMEngine 保存 Ruby 状态机状态。
 // Ruby 支持 =begin / =end 块注释，这里用独立状态处理。
 type rubyFSMEngine struct {
+	options Options
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
 	inBeginEndComment bool
 	inSingleQuotedStr bool
 	inDoubleQuotedStr bool
+
+	// inPercentLiteral 表示处于 %q()、%w[] 等 % 字面量中。
+	// percentOpen 为成对定界符的开括号（非成对定界符时为 0），percentClose 为结束符，
+	// percentDepth 记录成对定界符的嵌套深度，例如 %w(a (b) c) 在第二个 ) 处才结束。
+	inPercentLiteral bool
+	percentOpen      rune
+	percentClose     rune
+	percentDepth     int
+
+	// heredoc 跟踪 <<~EOS 等 heredoc 的正文。
+	heredoc heredocTracker
 }
 
 // analyze 逐行流式读取并统计。
@@ -44,38 +94,73 @@ func (e *rubyFSMEngine) analyze(reader io.Reader) (model.LineMetrics, error) {
 	// Ruby 同样按行流式处理：
 	// - 保证大文件可控；
 	// - 让 =begin/=end 与字符串状态能在行之间连续传播。
-	bufferedReader := bufio.NewReader(reader)
-
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
+	if err := e.scratch.scanShebang(&metrics, e.options); err != nil {
+		return metrics, err
+	}
 	for {
-		line, err := bufferedReader.ReadString('\n')
+		startsInCode := e.inCodeState()
+		// 把当前行交给 FSM 决策，然后统一写入统计模型。
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
 		// 完整读取结束时退出。
-		if errors.Is(err, io.EOF) && len(line) == 0 {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		// 真正的读取错误要立即上抛。
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return metrics, err
 		}
 
-		// 把当前行交给 FSM 决策，然后统一写入统计模型。
-		currentLine := normalizeLine(line)
-		hasCode, hasComment := e.processLine(currentLine)
-		applyLineClassification(&metrics, currentLine, hasCode, hasComment)
-
-		// 最后一行可能没有 \n，处理后再跳出循环。
-		if errors.Is(err, io.EOF) {
-			break
+		if e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic {
+			applyStringLiteralLine(&metrics, e.options, line, hasComment)
+		} else {
+			applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+		}
+		e.heredoc.endLine()
+		if e.options.CountFunctions && startsInCode && hasCode && rubyFunctionMatcher.matches(line.text) {
+			metrics.Functions++
 		}
 	}
 
 	return metrics, nil
 }
 
+// inCodeState 判断当前是否处于普通代码态（不在注释或字符串等跨行状态中）。
+func (e *rubyFSMEngine) inCodeState() bool {
+	return !e.inBeginEndComment && !e.inSingleQuotedStr && !e.inDoubleQuotedStr && !e.inPercentLiteral && !e.heredoc.inBody()
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *rubyFSMEngine) state() string {
+	switch {
+	case e.inBeginEndComment:
+		return "block-comment"
+	case e.inSingleQuotedStr || e.inDoubleQuotedStr:
+		return "string"
+	case e.inPercentLiteral:
+		return "percent-literal"
+	case e.heredoc.inBody():
+		return "heredoc"
+	}
+	return "code"
+}
+
 // processLine 处理单行 Ruby 内容。
 func (e *rubyFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
 	hasCode := false
 	hasComment := false
 
+	// heredoc 正文整行都是字符串内容，其中的 =begin 与 # 都不是注释。
+	if e.heredoc.inBody() {
+		e.heredoc.body(line)
+		e.lineHasLiteral = true
+		return true, false
+	}
+
 	// begin/end 注释块优先级高于其他词法结构：
 	// 只要处于该状态，整行都按 comment 处理，直到遇到 =end。
 	// 若已处于 begin/end 注释块中，整行视为注释，直到遇到 =end。
@@ -93,9 +178,10 @@ func (e *rubyFSMEngine) processLine(line string) (bool, bool) {
 		return false, true
 	}
 
-	runes := []rune(line)
-	if e.inSingleQuotedStr || e.inDoubleQuotedStr {
+	runes := e.scratch.decode(line)
+	if e.inSingleQuotedStr || e.inDoubleQuotedStr || e.inPercentLiteral {
 		hasCode = true
+		e.lineHasLiteral = true
 	}
 
 	for idx := 0; idx < len(runes); {
@@ -104,6 +190,7 @@ func (e *rubyFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inSingleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// Ruby 字符串支持反斜杠转义，需要先跳过被转义字符。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -118,6 +205,7 @@ func (e *rubyFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inDoubleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 双引号字符串的转义处理与单引号一致。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -130,7 +218,27 @@ func (e *rubyFSMEngine) processLine(line string) (bool, bool) {
 			continue
 		}
 
-		if unicode.IsSpace(current) {
+		if e.inPercentLiteral {
+			hasCode = true
+			e.lineHasLiteral = true
+			if current == '\\' && hasNext {
+				idx += 2
+				continue
+			}
+			// 成对定界符允许嵌套，只有深度回到 0 的结束符才离开字面量。
+			if e.percentOpen != 0 && current == e.percentOpen {
+				e.percentDepth++
+			} else if current == e.percentClose {
+				e.percentDepth--
+				if e.percentDepth == 0 {
+					e.inPercentLiteral = false
+				}
+			}
+			idx++
+			continue
+		}
+
+		if isBlankRune(current) {
 			// 空白字符不做分类决策，继续扫描后续 token。
 			idx++
 			continue
@@ -139,11 +247,13 @@ func (e *rubyFSMEngine) processLine(line string) (bool, bool) {
 		// Ruby 行注释标识：#
 		if current == '#' {
 			hasComment = true
+			e.lineCommented = true
 			return hasCode, hasComment
 		}
 
 		if current == '\'' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inSingleQuotedStr = true
 			idx++
 			continue
@@ -151,18 +261,82 @@ func (e *rubyFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inDoubleQuotedStr = true
 			idx++
 			continue
 		}
 
+		// % 字面量：%q() %Q[] %w{} %i<> 等，内部的 # 不是注释。
+		if current == '%' {
+			if consumed, started := e.tryStartPercentLiteral(runes, idx); started {
+				hasCode = true
+				e.lineHasLiteral = true
+				idx = consumed
+				continue
+			}
+		}
+
+		// heredoc 开始记号：<<EOS、<<~EOS 等，本行其余部分照常扫描，正文从下一行开始。
+		if current == '<' {
+			if next, started := e.heredoc.open(runes, idx); started {
+				hasCode = true
+				e.lineHasLiteral = true
+				idx = next
+				continue
+			}
+		}
+
 		hasCode = true
+		if !isStringLiteralPunctuation(current) {
+			e.lineHasLogic = true
+		}
 		idx++
 	}
 
 	return hasCode, hasComment
 }
 
+// rubyPercentClosers 为 % 字面量成对定界符的结束符。
+var rubyPercentClosers = map[rune]rune{'(': ')', '[': ']', '{': '}', '<': '>'}
+
+// tryStartPercentLiteral 检测并进入 % 字面量状态，返回值 consumed 是定界符之后的索引。
+// 为了与取模运算区分，% 前面必须是行首、空白或运算符/开括号，例如 puts %w(a b) 与 x = %(text) 是字面量，
+// 而 a%(b)、x % y、count %= 3 按取模处理；省略类型字母时定界符不能是字母、数字、空白或 =。
+func (e *rubyFSMEngine) tryStartPercentLiteral(runes []rune, idx int) (consumed int, started bool) {
+	if idx > 0 {
+		previous := runes[idx-1]
+		if previous == '_' || previous == ')' || previous == ']' || previous == '}' ||
+			unicode.IsLetter(previous) || unicode.IsDigit(previous) {
+			return idx + 1, false
+		}
+	}
+
+	cursor := idx + 1
+	if cursor < len(runes) && strings.ContainsRune("qQwWiIrsx", runes[cursor]) {
+		cursor++
+	} else if cursor < len(runes) && runes[cursor] == '=' {
+		return idx + 1, false
+	}
+	if cursor >= len(runes) {
+		return idx + 1, false
+	}
+	delimiter := runes[cursor]
+	if unicode.IsSpace(delimiter) || unicode.IsLetter(delimiter) || unicode.IsDigit(delimiter) {
+		return idx + 1, false
+	}
+
+	e.inPercentLiteral = true
+	e.percentDepth = 1
+	e.percentOpen = 0
+	e.percentClose = delimiter
+	if closer, ok := rubyPercentClosers[delimiter]; ok {
+		e.percentOpen = delimiter
+		e.percentClose = closer
+	}
+	return cursor + 1, true
+}
+
 // isRubyBeginEndDirective 判断当前行是否是 =begin 或 =end 指令。
 // 实际 Ruby 规范要求它们位于行首，这里允许前导空白，兼容更多代码风格。
 func isRubyBeginEndDirective(line string, directive string) bool {
@@ -1,16 +1,25 @@
 package languages
 
 import (
-	"bufio"
 	"errors"
+	"fmt"
 	"io"
+	"regexp"
 	"unicode"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// rustFunctionMatcher 识别 fn 定义，允许 pub/async/const/unsafe/extern 等前缀修饰。
+var rustFunctionMatcher = functionMatcher{
+	pattern: regexp.MustCompile(`^(pub(\([^)]*\))?\s+)?((default|async|const|unsafe|extern(\s+"[^"]*")?)\s+)*fn\s`),
+}
+
 // RustAnalyzer 是 Rust 语言专用 FSM 分析器。
-type RustAnalyzer struct{}
+type RustAnalyzer struct {
+	// Options 控制附加统计能力，零值仅统计基础行数。
+	Options Options
+}
 
 // Name 返回语言名称。
 func (a *RustAnalyzer) Name() string {
@@ -22,18 +31,42 @@ func (a *RustAnalyzer) Extensions() []string {
 	return []string{".rs"}
 }
 
+// Capabilities 返回该 FSM 支持的注释与字符串语法。
+func (a *RustAnalyzer) Capabilities() Capabilities {
+	return Capabilities{
+		LineComments:     []string{"//"},
+		BlockComments:    []BlockCommentPair{{Start: "/*", End: "*/"}},
+		StringDelimiters: []string{"\"", "'", "r\"", "r#\"", "b\"", "b'", "br\""},
+		NestedComments:   true,
+		Notes: []string{
+			"原始字符串 r#\"...\"# 按 # 数量匹配结束符",
+			"字符与字节字面量必须在同一行闭合，生命周期（如 'a、'static）不会被当作字符字面量",
+		},
+	}
+}
+
+// IsTestFile 按 Cargo 约定识别测试文件：位于 tests 或 benches 目录的集成测试。
+// 写在源码内的 #[cfg(test)] 模块无法按文件区分，仍计入生产代码。
+func (a *RustAnalyzer) IsTestFile(path string) bool {
+	return hasPathSegment(path, "tests") || hasPathSegment(path, "benches")
+}
+
 // Analyze 使用 Rust 独立 FSM 流式读取并统计。
 func (a *RustAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
-	engine := &rustFSMEngine{}
+	engine := &rustFSMEngine{options: a.Options}
 	return engine.analyze(reader)
 }
 
 // rustFSMEngine 记录 Rust 语法解析状态。
 // Rust 的块注释支持嵌套，因此采用 depth 计数。
 type rustFSMEngine struct {
+	options Options
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
 	blockCommentDepth int
 	inDoubleQuotedStr bool
-	inSingleQuotedChr bool
 	inRawString       bool
 	rawStringHashCnt  int
 }
@@ -44,46 +77,71 @@ func (e *rustFSMEngine) analyze(reader io.Reader) (model.LineMetrics, error) {
 
 	// Rust 文件可能很大，采用逐行流式读取来控制内存占用。
 	// 同时借助 engine 的成员字段保持跨行状态（嵌套注释、原始字符串等）。
-	bufferedReader := bufio.NewReader(reader)
-
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
 	for {
-		line, err := bufferedReader.ReadString('\n')
+		startsInCode := e.inCodeState()
+		// 把当前行交给状态机，得到该行 code/comment 标记后再统一计数。
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
 		// 没有任何剩余字符时说明已经读完。
-		if errors.Is(err, io.EOF) && len(line) == 0 {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		// 读取失败且不是 EOF 时，直接返回错误。
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return metrics, err
 		}
 
-		// 把当前行交给状态机，得到该行 code/comment 标记后再统一计数。
-		currentLine := normalizeLine(line)
-		hasCode, hasComment := e.processLine(currentLine)
-		applyLineClassification(&metrics, currentLine, hasCode, hasComment)
-
-		// EOF 且本行已被处理，退出主循环。
-		if errors.Is(err, io.EOF) {
-			break
+		if e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic {
+			applyStringLiteralLine(&metrics, e.options, line, hasComment)
+		} else {
+			applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+		}
+		if e.options.CountFunctions && startsInCode && hasCode && rustFunctionMatcher.matches(line.text) {
+			metrics.Functions++
 		}
 	}
 
 	return metrics, nil
 }
 
+// inCodeState 判断当前是否处于普通代码态（不在注释或字符串等跨行状态中）。
+func (e *rustFSMEngine) inCodeState() bool {
+	return e.blockCommentDepth == 0 && !e.inDoubleQuotedStr && !e.inRawString
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *rustFSMEngine) state() string {
+	switch {
+	case e.blockCommentDepth > 1:
+		return fmt.Sprintf("block-comment(%d)", e.blockCommentDepth)
+	case e.blockCommentDepth == 1:
+		return "block-comment"
+	case e.inDoubleQuotedStr:
+		return "string"
+	case e.inRawString:
+		return "raw-string"
+	}
+	return "code"
+}
+
 // processLine 分析一行 Rust 代码。
 func (e *rustFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
 	hasCode := false
 	hasComment := false
-	runes := []rune(line)
+	runes := e.scratch.decode(line)
 
 	// Rust 支持嵌套块注释，所以用 depth 计数器，而不是单一布尔值。
 	// 只要 depth > 0，本行至少包含 comment。
 	if e.blockCommentDepth > 0 {
 		hasComment = true
 	}
-	if e.inDoubleQuotedStr || e.inSingleQuotedChr || e.inRawString {
+	if e.inDoubleQuotedStr || e.inRawString {
 		hasCode = true
+		e.lineHasLiteral = true
 	}
 
 	for idx := 0; idx < len(runes); {
@@ -115,6 +173,7 @@ func (e *rustFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inRawString {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 原始字符串结束符是 "####... 的组合，# 数量必须与开头一致。
 			if current == '"' && e.matchRawStringTerminator(runes, idx) {
 				e.inRawString = false
@@ -127,6 +186,7 @@ func (e *rustFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inDoubleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 标准字符串中反斜杠优先，避免把 \" 误判成闭合。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -139,21 +199,7 @@ func (e *rustFSMEngine) processLine(line string) (bool, bool) {
 			continue
 		}
 
-		if e.inSingleQuotedChr {
-			hasCode = true
-			// 字符字面量同样处理转义，如 '\n'、'\''。
-			if current == '\\' && hasNext {
-				idx += 2
-				continue
-			}
-			if current == '\'' {
-				e.inSingleQuotedChr = false
-			}
-			idx++
-			continue
-		}
-
-		if unicode.IsSpace(current) {
+		if isBlankRune(current) {
 			// 空白字符不参与分类，仅推进扫描。
 			idx++
 			continue
@@ -161,6 +207,7 @@ func (e *rustFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '/' && hasNext && next == '/' {
 			hasComment = true
+			e.lineCommented = true
 			return hasCode, hasComment
 		}
 
@@ -172,28 +219,38 @@ func (e *rustFSMEngine) processLine(line string) (bool, bool) {
 			continue
 		}
 
-		// Rust 原始字符串格式：r"...", r#"..."#, br"..." 等。
+		// Rust 原始字符串格式：r"...", r#"..."#, br"..." 等；前缀只能出现在标识符开头，避免把 for"、ptr#"
+		// 之类的片段误判为原始字符串。字节字符串 b"..." 的 b 按普通代码处理，随后的 " 进入普通字符串态。
 		if consumed, started := e.tryStartRawString(runes, idx); started {
 			hasCode = true
+			e.lineHasLiteral = true
 			idx = consumed
 			continue
 		}
 
 		if current == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inDoubleQuotedStr = true
 			idx++
 			continue
 		}
 
-		if current == '\'' && rustLooksLikeCharLiteral(runes, idx) {
-			hasCode = true
-			e.inSingleQuotedChr = true
-			idx++
-			continue
+		// 字符与字节字面量（'a'、'\n'、'\u{1F600}'、b'x'）不能跨行，整体一次性消费；
+		// 无法在本行闭合的 ' 视为生命周期或标签（'a、'static、'outer:），按普通代码处理，不改变跨行状态。
+		if current == '\'' {
+			if end := rustCharLiteralEnd(runes, idx); end > idx {
+				hasCode = true
+				e.lineHasLiteral = true
+				idx = end
+				continue
+			}
 		}
 
 		hasCode = true
+		if !isStringLiteralPunctuation(current) {
+			e.lineHasLogic = true
+		}
 		idx++
 	}
 
@@ -203,7 +260,10 @@ func (e *rustFSMEngine) processLine(line string) (bool, bool) {
 // tryStartRawString 检测并进入 Rust 原始字符串状态。
 // 返回值 consumed 是“已消费到的新索引位置”。
 func (e *rustFSMEngine) tryStartRawString(runes []rune, idx int) (consumed int, started bool) {
-	// 允许前缀是 r 或 br。
+	// 允许前缀是 r 或 br，且必须位于标识符开头。
+	if rustIsIdentifierRune(runes, idx-1) {
+		return idx + 1, false
+	}
 	start := idx
 	if runes[idx] == 'b' {
 		if idx+1 >= len(runes) || runes[idx+1] != 'r' {
@@ -243,21 +303,53 @@ func (e *rustFSMEngine) matchRawStringTerminator(runes []rune, idx int) bool {
 	return true
 }
 
-// rustLooksLikeCharLiteral 用于区分字符字面量和生命周期标识（如 'a）。
-func rustLooksLikeCharLiteral(runes []rune, idx int) bool {
-	if idx+2 >= len(runes) {
-		return false
+// rustCharLiteralEnd 用于区分字符字面量和生命周期标识（如 'a、'static）。
+// idx 处为 ' 且能匹配完整的字符字面量时返回闭合 ' 之后的索引，否则返回 idx。
+// 支持普通字符 'a'、单字符转义 '\n'，以及 '\x7f'、'\u{1F600}' 这类多字符转义。
+func rustCharLiteralEnd(runes []rune, idx int) int {
+	cursor := idx + 1
+	if cursor >= len(runes) || runes[cursor] == '\'' {
+		return idx
 	}
 
-	// 普通字符：'a'
-	if runes[idx+1] != '\\' && runes[idx+2] == '\'' {
-		return true
+	if runes[cursor] != '\\' {
+		// 普通字符：'a'
+		cursor++
+	} else {
+		cursor++
+		if cursor >= len(runes) {
+			return idx
+		}
+		switch runes[cursor] {
+		case 'x':
+			// 十六进制转义固定两位：'\x7f'
+			cursor += 3
+		case 'u':
+			// Unicode 转义：'\u{1F600}'，花括号内最多 6 位
+			cursor++
+			if cursor >= len(runes) || runes[cursor] != '{' {
+				return idx
+			}
+			for cursor < len(runes) && runes[cursor] != '}' && cursor-idx <= 10 {
+				cursor++
+			}
+			cursor++
+		default:
+			// 单字符转义：'\n'、'\''、'\\'
+			cursor++
+		}
 	}
 
-	// 转义字符：'\n'
-	if runes[idx+1] == '\\' && idx+3 < len(runes) && runes[idx+3] == '\'' {
-		return true
+	if cursor >= len(runes) || runes[cursor] != '\'' {
+		return idx
 	}
+	return cursor + 1
+}
 
-	return false
+// rustIsIdentifierRune 判断 idx 处是否为标识符字符；idx 越界时返回 false。
+func rustIsIdentifierRune(runes []rune, idx int) bool {
+	if idx < 0 || idx >= len(runes) {
+		return false
+	}
+	return runes[idx] == '_' || unicode.IsLetter(runes[idx]) || unicode.IsDigit(runes[idx])
 }
@@ -0,0 +1,411 @@
+package languages
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+const (
+	// minRuneBuffer 是逐行 rune 缓冲区的最小容量，覆盖绝大多数源码行。
+	minRuneBuffer = 64
+	// maxPooledRuneBuffer 是归还到池中的 rune 缓冲区容量上限，超长行撑大的缓冲区不再复用，避免池长期占用大块内存。
+	maxPooledRuneBuffer = 64 * 1024
+	// maxPooledLineBuffer 是归还到池中的跨缓冲区行拼接缓冲区容量上限。
+	maxPooledLineBuffer = 256 * 1024
+	// maxLineSegment 是整行处理的最大字节数，更长的行（压缩后的 bundle、生成代码）按段处理，见 scanLine。
+	maxLineSegment = 64 * 1024
+	// maxLineHead 是超长行保留的行首字节数，供函数定义、预处理指令等只看行首的判断使用。
+	maxLineHead = 4 * 1024
+)
+
+// lineScratch 是分析单个文件时使用的读取与解码缓冲区。
+// 扫描数十万个小文件时，每个文件新建 bufio.Reader 与 rune 缓冲区会给 GC 带来明显压力，
+// 因此通过 lineScratchPool 在文件之间（也就是各 worker 连续处理的文件之间）复用。
+type lineScratch struct {
+	reader *bufio.Reader
+	// newlines 是 reader 的数据来源，把单独的 \r 换行转换为 \n。
+	newlines crLineReader
+	runes    []rune
+	// line 用于拼接超过 reader 缓冲区大小的行。
+	line []byte
+	// trailingEmptyLine 为 Options.TrailingEmptyLine；terminated 表示上一行以换行符结尾。
+	trailingEmptyLine bool
+	terminated        bool
+	// continued 表示上一段在 cut 处切断，line[cut:] 是当前行尚未处理的部分。
+	continued bool
+	cut       int
+	// head 与 digest 保存当前超长行的行首与逐段累计的统计信息。
+	head   []byte
+	digest lineDigest
+	// states 非 nil 时 scanLine 把每行开始与结束时的引擎状态追加到其中，见 traceStates。
+	states *[]model.LineState
+}
+
+// lineScratchPool 缓存空闲的 lineScratch。
+var lineScratchPool = sync.Pool{
+	New: func() any {
+		return &lineScratch{
+			reader: bufio.NewReader(nil),
+			runes:  make([]rune, 0, minRuneBuffer),
+		}
+	},
+}
+
+// acquireLineScratch 从池中借用缓冲区，并让其 reader 从 reader 读取；options 决定末尾换行符后是否还有一个空行。
+func acquireLineScratch(reader io.Reader, options Options) *lineScratch {
+	scratch := lineScratchPool.Get().(*lineScratch)
+	scratch.trailingEmptyLine = options.TrailingEmptyLine
+	scratch.terminated = false
+	scratch.newlines = crLineReader{reader: reader}
+	scratch.reader.Reset(&scratch.newlines)
+	// Windows 编辑器常在文件开头写入 UTF-8 BOM，跳过它以免首行被当作代码。
+	if prefix, _ := scratch.reader.Peek(len(utf8BOM)); bytes.Equal(prefix, utf8BOM) {
+		_, _ = scratch.reader.Discard(len(utf8BOM))
+	}
+	return scratch
+}
+
+// releaseLineScratch 归还缓冲区；归还前解除对输入的引用，使文件等对象可以被及时回收。
+func releaseLineScratch(scratch *lineScratch) {
+	scratch.reader.Reset(nil)
+	scratch.newlines = crLineReader{}
+	scratch.continued = false
+	scratch.states = nil
+	if cap(scratch.runes) > maxPooledRuneBuffer {
+		scratch.runes = make([]rune, 0, minRuneBuffer)
+	}
+	if cap(scratch.line) > maxPooledLineBuffer {
+		scratch.line = nil
+	}
+	lineScratchPool.Put(scratch)
+}
+
+// traceStates 在开启 Options.TraceStates 时让之后的 scanLine 把逐行的 FSM 状态追加到 metrics.LineStates。
+// 各引擎在借用缓冲区后、读取第一行（包括 shebang 行）之前调用。
+func (s *lineScratch) traceStates(metrics *model.LineMetrics, options Options) {
+	if options.TraceStates {
+		s.states = &metrics.LineStates
+	}
+}
+
+// crLineReader 让按 \n 分行的 scanLine 同样支持经典 Mac 的 \r 换行：单独的 \r 转换为 \n，\r\n 保持不变。
+// 转换只影响行末的换行符（normalizeLine 本就会去掉），文件字节数在读取之前另行统计，不受影响。
+type crLineReader struct {
+	reader io.Reader
+	// pendingCR 表示上一次读取以 \r 结尾，需要看到下一个字节才能确定它是否属于 \r\n，暂不返回。
+	pendingCR bool
+}
+
+// Read 实现 io.Reader。
+func (r *crLineReader) Read(buffer []byte) (int, error) {
+	if len(buffer) == 0 {
+		return 0, nil
+	}
+	start := 0
+	if r.pendingCR {
+		buffer[0] = '\r'
+		start = 1
+	}
+	n, err := r.reader.Read(buffer[start:])
+	if n == 0 && err == nil {
+		return 0, nil
+	}
+	n += start
+	r.pendingCR = false
+	if n > 0 && buffer[n-1] == '\r' && err == nil {
+		r.pendingCR = true
+		n--
+	}
+
+	data := buffer[:n]
+	for idx := bytes.IndexByte(data, '\r'); idx >= 0; {
+		if idx+1 == len(data) || data[idx+1] != '\n' {
+			data[idx] = '\n'
+		}
+		next := bytes.IndexByte(data[idx+1:], '\r')
+		if next < 0 {
+			break
+		}
+		idx += next + 1
+	}
+	return n, err
+}
+
+// lineProcessor 是 scanLine 驱动的 FSM 引擎。
+type lineProcessor interface {
+	// processLine 扫描一行（或超长行的一段）并更新跨行状态，返回其中是否包含 code/comment。
+	processLine(line string) (bool, bool)
+	// flags 返回引擎的当前行标记。
+	flags() *lineFlags
+	// state 返回引擎当前的跨行状态名称（code、block-comment、string 等），供 Options.TraceStates 记录。
+	state() string
+}
+
+// lineFlags 是各 FSM 引擎共有的当前行标记，嵌入引擎结构体使用。
+type lineFlags struct {
+	// lineHasLiteral/lineHasLogic 分别表示当前行是否出现字符串字面量内容、字面量之外的代码。
+	lineHasLiteral bool
+	lineHasLogic   bool
+	// lineCommented 表示当前行已进入行注释，由 processLine 在遇到行注释时设置。
+	lineCommented bool
+}
+
+// flags 实现 lineProcessor。
+func (f *lineFlags) flags() *lineFlags {
+	return f
+}
+
+// scanLine 读取下一行交给 engine 处理，返回行文本与该行是否包含 code/comment；读完后返回 io.EOF。
+// 最后一行没有换行符时照常返回该行，下一次调用才返回 io.EOF。
+// 与 cloc、tokei 一致，换行符默认是行的结束符，文件末尾的换行符不会再产生一行；
+// 开启 Options.TrailingEmptyLine 时换行符视为行分隔符（与编辑器的行号一致），末尾换行符之后再返回一个不交给 engine 的空行。
+//
+// 行文本直接引用复用的缓冲区，只在下一次 scanLine 之前有效。
+// 各 FSM 引擎只在处理当前行期间使用它，跨行状态只保存布尔值、计数与哈希，不能保存行内容或其子串。
+//
+// 不超过 maxLineSegment 的行整行处理；更长的行按段处理，内存占用与行长无关：
+// - 各段依次交给 processLine，字符串、块注释等状态与跨行时一样由引擎延续
+// - 各段的 code/comment 与字面量标记取并集；进入行注释后，其余分段不再扫描，直接视为注释
+// - 行长度、空白与内容哈希按段累计（见 lineDigest），结果与整行计算一致
+//
+// 开启状态跟踪（见 traceStates）时，每返回一行都记录处理该行前后 engine 的状态。
+func (s *lineScratch) scanLine(engine lineProcessor) (lineText, bool, bool, error) {
+	if s.states == nil {
+		return s.readLine(engine)
+	}
+	start := engine.state()
+	line, hasCode, hasComment, err := s.readLine(engine)
+	if err == nil {
+		*s.states = append(*s.states, model.LineState{Start: start, End: engine.state()})
+	}
+	return line, hasCode, hasComment, err
+}
+
+// readLine 实现 scanLine 的读取与分段处理。
+func (s *lineScratch) readLine(engine lineProcessor) (lineText, bool, bool, error) {
+	flags := engine.flags()
+	flags.lineCommented = false
+	segment, more, err := s.readSegment()
+	if errors.Is(err, io.EOF) && len(segment) == 0 {
+		if s.trailingEmptyLine && s.terminated {
+			s.terminated = false
+			return lineText{}, false, false, nil
+		}
+		return lineText{}, false, false, io.EOF
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return lineText{}, false, false, err
+	}
+	if !more {
+		s.terminated = strings.HasSuffix(segment, "\n")
+		line := normalizeLine(segment)
+		hasCode, hasComment := engine.processLine(line)
+		return lineText{text: line}, hasCode, hasComment, nil
+	}
+
+	s.head = append(s.head[:0], segment[:min(len(segment), maxLineHead)]...)
+	s.digest.reset()
+	hasCode, hasComment, hasLiteral, hasLogic := false, false, false, false
+	for {
+		if !more {
+			s.terminated = strings.HasSuffix(segment, "\n")
+			segment = normalizeLine(segment)
+		}
+		s.digest.add(segment)
+		if flags.lineCommented {
+			hasComment = true
+		} else {
+			segmentCode, segmentComment := engine.processLine(segment)
+			hasCode = hasCode || segmentCode
+			hasComment = hasComment || segmentComment
+			hasLiteral = hasLiteral || flags.lineHasLiteral
+			hasLogic = hasLogic || flags.lineHasLogic
+		}
+		if !more {
+			break
+		}
+		segment, more, err = s.readSegment()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return lineText{}, false, false, err
+		}
+	}
+	flags.lineHasLiteral = hasLiteral
+	flags.lineHasLogic = hasLogic
+	return lineText{text: bytesView(s.head), long: &s.digest}, hasCode, hasComment, nil
+}
+
+// readSegment 读取下一行，或超长行的下一段（more 为 true 表示该行还有后续分段）。
+// 返回值包含换行符，直接引用 reader 的缓冲区或复用的 line 缓冲区，只在下一次调用之前有效。
+//
+// 短行直接返回 reader 缓冲区中的切片，不分配内存；超过 reader 缓冲区的行拼接到 line 中，
+// 累计达到 maxLineSegment 仍未遇到换行符时在前 maxLineSegment 字节内按 segmentCut 切断，剩余部分留到下一段。
+func (s *lineScratch) readSegment() (string, bool, error) {
+	if s.continued {
+		s.line = s.line[:copy(s.line, s.line[s.cut:])]
+		s.continued = false
+	} else {
+		fragment, err := s.reader.ReadSlice('\n')
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return bytesView(fragment), false, err
+		}
+		s.line = append(s.line[:0], fragment...)
+	}
+
+	for len(s.line) < maxLineSegment {
+		fragment, err := s.reader.ReadSlice('\n')
+		s.line = append(s.line, fragment...)
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return bytesView(s.line), false, err
+		}
+	}
+	s.cut = segmentCut(s.line[:maxLineSegment])
+	s.continued = true
+	return bytesView(s.line[:s.cut]), true, nil
+}
+
+// segmentCut 返回超长行的切分位置。
+// 优先选在后半段中两个 ASCII 字母/数字之间：注释、字符串、转义等记号的起止符都不是字母或数字，
+// 在这里切断不会改变 FSM 的判断；找不到时退回到最后一个 UTF-8 字符边界（且不拆开 \r\n）。
+func segmentCut(line []byte) int {
+	for idx := len(line) - 1; idx > len(line)/2; idx-- {
+		if isASCIIAlphanumeric(line[idx-1]) && isASCIIAlphanumeric(line[idx]) {
+			return idx
+		}
+	}
+	for idx := len(line) - 1; idx > 0; idx-- {
+		if utf8.RuneStart(line[idx]) && line[idx-1] != '\r' {
+			return idx
+		}
+	}
+	return len(line)
+}
+
+// isASCIIAlphanumeric 判断字节是否是 ASCII 字母或数字。
+func isASCIIAlphanumeric(current byte) bool {
+	return ('a' <= current && current <= 'z') || ('A' <= current && current <= 'Z') || ('0' <= current && current <= '9')
+}
+
+// FNV-1a 64 位参数，与 hash/fnv 一致，使 lineDigest 的哈希与 hashCodeLine 相同。
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// lineDigest 按段累计超长行的统计信息，结果与对整行直接计算一致。
+type lineDigest struct {
+	runes int64
+	// hash 是截至最后一个非空白字符的内容哈希（去除首尾空白，同 hashCodeLine），
+	// pending 额外包含其后的空白，后面再出现非空白字符时成为新的 hash。
+	hash    uint64
+	pending uint64
+	// nonBlank 表示已出现非空白字符。
+	nonBlank bool
+	// trailingWhitespace 表示目前读到的内容以空格或 tab 结尾。
+	trailingWhitespace bool
+}
+
+// reset 开始累计新的一行。
+func (d *lineDigest) reset() {
+	*d = lineDigest{hash: fnvOffset64, pending: fnvOffset64}
+}
+
+// add 累计一段内容，各段须在 UTF-8 字符边界处切分。
+func (d *lineDigest) add(segment string) {
+	if segment == "" {
+		return
+	}
+	d.runes += int64(utf8.RuneCountInString(segment))
+	last := segment[len(segment)-1]
+	d.trailingWhitespace = last == ' ' || last == '\t'
+
+	for idx := 0; idx < len(segment); {
+		current, width := utf8.DecodeRuneInString(segment[idx:])
+		space := isBlankRune(current)
+		if d.nonBlank || !space {
+			for offset := idx; offset < idx+width; offset++ {
+				d.pending = (d.pending ^ uint64(segment[offset])) * fnvPrime64
+			}
+			if !space {
+				d.hash = d.pending
+				d.nonBlank = true
+			}
+		}
+		idx += width
+	}
+}
+
+// lineText 是交给分类统计函数的一行文本（不含换行符）。
+// 普通行的 text 即整行内容；超长行的 text 只保留行首 maxLineHead 字节，整行的长度、空白与哈希由 long 提供。
+type lineText struct {
+	text string
+	long *lineDigest
+}
+
+// runeCount 返回整行的 rune 数。
+func (l lineText) runeCount() int64 {
+	if l.long != nil {
+		return l.long.runes
+	}
+	return int64(utf8.RuneCountInString(l.text))
+}
+
+// blank 判断整行去除空白后是否为空。
+func (l lineText) blank() bool {
+	if l.long != nil {
+		return !l.long.nonBlank
+	}
+	return strings.TrimFunc(l.text, isBlankRune) == ""
+}
+
+// hash 返回整行去除首尾空白后的内容哈希。
+func (l lineText) hash() uint64 {
+	if l.long != nil {
+		return l.long.hash
+	}
+	return hashCodeLine(l.text)
+}
+
+// trailingWhitespace 判断整行是否以空格或 tab 结尾。
+func (l lineText) trailingWhitespace() bool {
+	if l.long != nil {
+		return l.long.trailingWhitespace
+	}
+	return strings.TrimRight(l.text, " \t") != l.text
+}
+
+// bytesView 返回与 data 共享内存的字符串，data 在字符串使用期间不能被修改。
+func bytesView(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	return unsafe.String(unsafe.SliceData(data), len(data))
+}
+
+// decode 把 line 解码到复用的 rune 缓冲区中并返回，返回值只在下一次 decode 之前有效。
+func (s *lineScratch) decode(line string) []rune {
+	s.runes = decodeRunes(s.runes, line)
+	return s.runes
+}
+
+// decodeRunes 把 line 解码到复用的 buffer 中并返回，结果与 []rune(line) 一致（非法 UTF-8 字节解码为 utf8.RuneError），
+// 但 buffer 容量足够时不分配内存，避免大仓库中每行一次的 []rune 转换成为主要的分配来源。
+//
+// 字节数不小于 rune 数，因此按字节数一次性扩容，解码过程中不会再增长；最小容量避免小文件的短行反复扩容。
+func decodeRunes(buffer []rune, line string) []rune {
+	if cap(buffer) < len(line) {
+		buffer = make([]rune, 0, max(len(line), minRuneBuffer))
+	}
+	buffer = buffer[:0]
+	for _, current := range line {
+		buffer = append(buffer, current)
+	}
+	return buffer
+}
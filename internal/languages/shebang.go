@@ -0,0 +1,73 @@
+package languages
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// 脚本首行 shebang（#!/usr/bin/env python3 等）的计入方式。
+const (
+	// ShebangComment 把 shebang 行计为注释（默认）。
+	ShebangComment = "comment"
+	// ShebangDirective 把 shebang 行计入 Preprocessor，与 C/C++ 预处理指令共用一个分类。
+	ShebangDirective = "directive"
+	// ShebangCode 把 shebang 行计为代码。
+	ShebangCode = "code"
+)
+
+// ParseShebang 规范化 shebang 行的计入方式（不区分大小写，空串视为 comment），未知取值返回错误。
+func ParseShebang(value string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(value))
+	switch mode {
+	case "":
+		return ShebangComment, nil
+	case ShebangComment, ShebangDirective, ShebangCode:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unsupported shebang mode %q, allowed values: comment, directive, code", value)
+}
+
+// shebangLine 是读取 shebang 行时使用的空引擎：该行不交给语言 FSM，因此不会影响后续行的状态。
+type shebangLine struct {
+	lineFlags
+}
+
+// processLine 实现 lineProcessor。
+func (*shebangLine) processLine(string) (bool, bool) {
+	return false, false
+}
+
+// state 实现 lineProcessor。
+func (*shebangLine) state() string {
+	return "shebang"
+}
+
+// scanShebang 在文件以 #! 开头时读取首行，并按 options.Shebang 计入 metrics；否则不读取任何内容。
+// Python、Ruby、JavaScript、TypeScript 与通用分析器在逐行扫描前调用，使各语言对 shebang 的计数一致，
+// 不再取决于该语言的注释符号（例如 JavaScript 中 # 不是注释）。
+func (s *lineScratch) scanShebang(metrics *model.LineMetrics, options Options) error {
+	mode, err := ParseShebang(options.Shebang)
+	if err != nil {
+		return err
+	}
+	if prefix, _ := s.reader.Peek(2); !bytes.Equal(prefix, []byte("#!")) {
+		return nil
+	}
+
+	line, _, _, err := s.scanLine(&shebangLine{})
+	if err != nil {
+		return err
+	}
+	switch mode {
+	case ShebangDirective:
+		applyPreprocessorLine(metrics, options, line, false)
+	case ShebangCode:
+		applyLineClassification(metrics, options, line, true, false)
+	default:
+		applyLineClassification(metrics, options, line, false, true)
+	}
+	return nil
+}
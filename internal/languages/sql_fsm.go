@@ -1,16 +1,55 @@
 package languages
 
 import (
-	"bufio"
 	"errors"
+	"fmt"
 	"io"
+	"regexp"
+	"strings"
 	"unicode"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// sqlFunctionMatcher 识别 CREATE [OR REPLACE] FUNCTION/PROCEDURE 语句。
+var sqlFunctionMatcher = functionMatcher{
+	pattern: regexp.MustCompile(`(?i)^create\s+(or\s+replace\s+)?(function|procedure)\b`),
+}
+
+// SQL 方言，控制 SQL 分析器对方言特有语法的识别。
+const (
+	// SQLDialectAuto 按语法特征逐处判断：识别 $tag$ 字符串、q'[...]' 字符串与同一行闭合的 [标识符]，
+	// # 只在行首或前后都是空白时视为注释，避免与 T-SQL 临时表 #tmp 冲突。
+	SQLDialectAuto = "auto"
+	// SQLDialectANSI 只识别标准 SQL 的 -- 与 /* */ 注释和引号字符串。
+	SQLDialectANSI = "ansi"
+	// SQLDialectMySQL 额外把 # 视为行注释。
+	SQLDialectMySQL = "mysql"
+	// SQLDialectPostgres 额外识别 $$...$$ 与 $tag$...$tag$ 字符串。
+	SQLDialectPostgres = "postgres"
+	// SQLDialectTSQL 额外识别 [标识符]，其中的 -- 与引号不改变状态。
+	SQLDialectTSQL = "tsql"
+	// SQLDialectOracle 额外识别 Oracle 的 q'[...]'、q'{...}' 等替代引号字符串。
+	SQLDialectOracle = "oracle"
+)
+
+// ParseSQLDialect 规范化 SQL 方言名称（不区分大小写，空串视为 auto），未知方言返回错误。
+func ParseSQLDialect(value string) (string, error) {
+	dialect := strings.ToLower(strings.TrimSpace(value))
+	switch dialect {
+	case "":
+		return SQLDialectAuto, nil
+	case SQLDialectAuto, SQLDialectANSI, SQLDialectMySQL, SQLDialectPostgres, SQLDialectTSQL, SQLDialectOracle:
+		return dialect, nil
+	}
+	return "", fmt.Errorf("unsupported sql dialect %q, allowed values: auto, ansi, mysql, postgres, tsql, oracle", value)
+}
+
 // SQLAnalyzer 是 SQL 专用 FSM 分析器。
-type SQLAnalyzer struct{}
+type SQLAnalyzer struct {
+	// Options 控制附加统计能力，零值仅统计基础行数。
+	Options Options
+}
 
 // Name 返回语言名称。
 func (a *SQLAnalyzer) Name() string {
@@ -22,18 +61,46 @@ func (a *SQLAnalyzer) Extensions() []string {
 	return []string{".sql"}
 }
 
+// Capabilities 返回该 FSM 支持的注释与字符串语法。
+func (a *SQLAnalyzer) Capabilities() Capabilities {
+	return Capabilities{
+		LineComments:     []string{"--", "#"},
+		BlockComments:    []BlockCommentPair{{Start: "/*", End: "*/"}},
+		StringDelimiters: []string{"'", "\"", "$$", "$tag$", "[", "q'"},
+		NestedComments:   true,
+		Notes: []string{
+			"方言由 Options.SQLDialect 选择：# 行注释只在 mysql 方言生效（auto 下仅行首或前后为空白时生效），" +
+				"$tag$ 字符串只在 postgres 与 auto 生效，[标识符] 只在 tsql 与 auto 生效，q'[...]' 字符串只在 oracle 与 auto 生效",
+		},
+	}
+}
+
 // Analyze 使用 SQL 独立 FSM 进行分析。
 func (a *SQLAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
-	engine := &sqlFSMEngine{}
+	dialect, err := ParseSQLDialect(a.Options.SQLDialect)
+	if err != nil {
+		return model.LineMetrics{}, err
+	}
+	engine := &sqlFSMEngine{options: a.Options, dialect: dialect}
 	return engine.analyze(reader)
 }
 
 // sqlFSMEngine 维护 SQL 解析状态。
-// 此实现支持 /* */ 嵌套块注释。
+// 此实现支持 /* */ 嵌套块注释，方言特有语法按 dialect 开启。
 type sqlFSMEngine struct {
+	options Options
+	dialect string
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
 	blockCommentDepth int
 	inSingleQuotedStr bool
 	inDoubleQuotedStr bool
+	// dollarTag 非空时处于 PostgreSQL 美元引号字符串中，值为完整的开始定界符（如 "$$"、"$body$"）。
+	dollarTag string
+	// quoteCloser 非零时处于 Oracle q'...' 字符串中，值为结束定界符（与 ' 连用，例如 q'[...]' 中的 ]）。
+	quoteCloser rune
 }
 
 // analyze 逐行读取并累计统计值。
@@ -42,45 +109,74 @@ func (e *sqlFSMEngine) analyze(reader io.Reader) (model.LineMetrics, error) {
 
 	// SQL 逐行流式读取，避免加载整文件。
 	// 嵌套注释深度与字符串状态跨行保留，确保复杂 SQL 脚本统计准确。
-	bufferedReader := bufio.NewReader(reader)
-
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
 	for {
-		line, err := bufferedReader.ReadString('\n')
+		startsInCode := e.inCodeState()
+		// processLine 返回本行的 code/comment 标志，再统一累加。
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
 		// 没有剩余内容时结束读取循环。
-		if errors.Is(err, io.EOF) && len(line) == 0 {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		// 非 EOF 错误直接返回。
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return metrics, err
 		}
 
-		// processLine 返回本行的 code/comment 标志，再统一累加。
-		currentLine := normalizeLine(line)
-		hasCode, hasComment := e.processLine(currentLine)
-		applyLineClassification(&metrics, currentLine, hasCode, hasComment)
-
-		// 最后一行无 \n 的情况已处理，退出循环。
-		if errors.Is(err, io.EOF) {
-			break
+		if e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic {
+			applyStringLiteralLine(&metrics, e.options, line, hasComment)
+		} else {
+			applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+		}
+		if e.options.CountFunctions && startsInCode && hasCode && sqlFunctionMatcher.matches(line.text) {
+			metrics.Functions++
 		}
 	}
 
 	return metrics, nil
 }
 
+// inCodeState 判断当前是否处于普通代码态（不在注释或字符串等跨行状态中）。
+func (e *sqlFSMEngine) inCodeState() bool {
+	return e.blockCommentDepth == 0 && !e.inSingleQuotedStr && !e.inDoubleQuotedStr && e.dollarTag == "" && e.quoteCloser == 0
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *sqlFSMEngine) state() string {
+	switch {
+	case e.blockCommentDepth > 1:
+		return fmt.Sprintf("block-comment(%d)", e.blockCommentDepth)
+	case e.blockCommentDepth == 1:
+		return "block-comment"
+	case e.inSingleQuotedStr:
+		return "string"
+	case e.inDoubleQuotedStr:
+		return "quoted-identifier"
+	case e.dollarTag != "":
+		return "dollar-string"
+	case e.quoteCloser != 0:
+		return "q-string"
+	}
+	return "code"
+}
+
 // processLine 分析单行 SQL 文本。
 func (e *sqlFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
 	hasCode := false
 	hasComment := false
-	runes := []rune(line)
+	runes := e.scratch.decode(line)
 
 	// SQL 的块注释支持嵌套，因此采用 depth 而非布尔状态。
 	if e.blockCommentDepth > 0 {
 		hasComment = true
 	}
-	if e.inSingleQuotedStr || e.inDoubleQuotedStr {
+	if e.inSingleQuotedStr || e.inDoubleQuotedStr || e.dollarTag != "" || e.quoteCloser != 0 {
 		hasCode = true
+		e.lineHasLiteral = true
 	}
 
 	for idx := 0; idx < len(runes); {
@@ -112,6 +208,7 @@ func (e *sqlFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inSingleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// SQL 单引号字符串使用 '' 作为转义，这里显式跳过。
 			if current == '\'' {
 				// SQL 单引号转义方式：''。
@@ -127,6 +224,7 @@ func (e *sqlFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inDoubleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// SQL 双引号标识符/字符串中，"" 表示转义双引号。
 			if current == '"' {
 				// SQL 双引号转义方式：""。
@@ -140,7 +238,33 @@ func (e *sqlFSMEngine) processLine(line string) (bool, bool) {
 			continue
 		}
 
-		if unicode.IsSpace(current) {
+		if e.dollarTag != "" {
+			hasCode = true
+			e.lineHasLiteral = true
+			// 美元引号字符串没有转义，只有完全相同的 $tag$ 才能结束。
+			if current == '$' && sqlHasPrefixAt(runes, idx, e.dollarTag) {
+				idx += len([]rune(e.dollarTag))
+				e.dollarTag = ""
+				continue
+			}
+			idx++
+			continue
+		}
+
+		if e.quoteCloser != 0 {
+			hasCode = true
+			e.lineHasLiteral = true
+			// q'...' 字符串没有转义，只有结束定界符紧跟 ' 才能结束，其中的 '、-- 都是普通文本。
+			if current == e.quoteCloser && hasNext && next == '\'' {
+				e.quoteCloser = 0
+				idx += 2
+				continue
+			}
+			idx++
+			continue
+		}
+
+		if isBlankRune(current) {
 			// 空白字符不直接决定分类。
 			idx++
 			continue
@@ -148,9 +272,48 @@ func (e *sqlFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '-' && hasNext && next == '-' {
 			hasComment = true
+			e.lineCommented = true
+			return hasCode, hasComment
+		}
+
+		if current == '#' && e.isHashComment(runes, idx) {
+			hasComment = true
+			e.lineCommented = true
 			return hasCode, hasComment
 		}
 
+		if current == '$' && (e.dialect == SQLDialectPostgres || e.dialect == SQLDialectAuto) {
+			if tag := sqlDollarTag(runes, idx); tag != "" {
+				hasCode = true
+				e.lineHasLiteral = true
+				e.dollarTag = tag
+				idx += len([]rune(tag))
+				continue
+			}
+		}
+
+		// Oracle 替代引号字符串：q'[...]'、Q'{...}'、nq'<...>'，以及 q'!...!' 等以相同字符闭合的形式，可以跨行。
+		if (current == 'q' || current == 'Q') && (e.dialect == SQLDialectOracle || e.dialect == SQLDialectAuto) {
+			if closer := sqlQuoteCloser(runes, idx); closer != 0 {
+				hasCode = true
+				e.lineHasLiteral = true
+				e.quoteCloser = closer
+				idx += 3
+				continue
+			}
+		}
+
+		// T-SQL 方括号标识符：[Order Details]、[a--b]，其中 ]] 表示转义的 ]。
+		// 标识符不能跨行，本行找不到结束符时 [ 按普通代码处理（例如 PostgreSQL 的数组下标）。
+		if current == '[' && (e.dialect == SQLDialectTSQL || e.dialect == SQLDialectAuto) {
+			if end := sqlBracketIdentifierEnd(runes, idx); end > idx {
+				hasCode = true
+				e.lineHasLogic = true
+				idx = end
+				continue
+			}
+		}
+
 		if current == '/' && hasNext && next == '*' {
 			hasComment = true
 			// 首次进入块注释，深度初始化为 1。
@@ -161,6 +324,7 @@ func (e *sqlFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '\'' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inSingleQuotedStr = true
 			idx++
 			continue
@@ -168,14 +332,119 @@ func (e *sqlFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
 			e.inDoubleQuotedStr = true
 			idx++
 			continue
 		}
 
 		hasCode = true
+		if !isStringLiteralPunctuation(current) {
+			e.lineHasLogic = true
+		}
 		idx++
 	}
 
 	return hasCode, hasComment
 }
+
+// isHashComment 判断 idx 处的 # 是否开始行注释。
+// mysql 方言下 # 总是注释；auto 下只有位于行首或前后都是空白时才是注释，
+// 以免把 T-SQL 临时表 #tmp 与 PostgreSQL 的 # 运算符当成注释。
+func (e *sqlFSMEngine) isHashComment(runes []rune, idx int) bool {
+	switch e.dialect {
+	case SQLDialectMySQL:
+		return true
+	case SQLDialectAuto:
+		atLineStart := true
+		for _, previous := range runes[:idx] {
+			if !unicode.IsSpace(previous) {
+				atLineStart = false
+				break
+			}
+		}
+		if atLineStart {
+			return idx+1 >= len(runes) || !sqlIsIdentifierRune(runes[idx+1])
+		}
+		return unicode.IsSpace(runes[idx-1]) && (idx+1 >= len(runes) || unicode.IsSpace(runes[idx+1]))
+	}
+	return false
+}
+
+// sqlDollarTag 识别 idx 处的美元引号开始定界符 $$ 或 $tag$，返回完整定界符，不是时返回空串。
+// tag 以字母或下划线开头，因此 $1 之类的位置参数不会被误判；前一个字符是标识符字符时也不识别。
+func sqlDollarTag(runes []rune, idx int) string {
+	if idx > 0 && sqlIsIdentifierRune(runes[idx-1]) {
+		return ""
+	}
+	cursor := idx + 1
+	for cursor < len(runes) && runes[cursor] != '$' {
+		if !sqlIsIdentifierRune(runes[cursor]) || (cursor == idx+1 && unicode.IsDigit(runes[cursor])) {
+			return ""
+		}
+		cursor++
+	}
+	if cursor >= len(runes) {
+		return ""
+	}
+	return string(runes[idx : cursor+1])
+}
+
+// sqlQuoteCloser 识别 idx 处 Oracle q'x 替代引号的开始，返回结束定界符，不是时返回 0。
+// 成对括号 [ { ( < 以对应的右括号结束，其他非空白字符以自身结束；
+// q 前只允许出现国家字符集前缀 n/N，其他标识符字符（例如列名 freq'）都不识别。
+func sqlQuoteCloser(runes []rune, idx int) rune {
+	if idx+2 >= len(runes) || runes[idx+1] != '\'' {
+		return 0
+	}
+	if idx > 0 && sqlIsIdentifierRune(runes[idx-1]) {
+		if runes[idx-1] != 'n' && runes[idx-1] != 'N' || (idx > 1 && sqlIsIdentifierRune(runes[idx-2])) {
+			return 0
+		}
+	}
+	switch opener := runes[idx+2]; opener {
+	case '[':
+		return ']'
+	case '{':
+		return '}'
+	case '(':
+		return ')'
+	case '<':
+		return '>'
+	case ' ', '\t', '\'':
+		return 0
+	default:
+		return opener
+	}
+}
+
+// sqlBracketIdentifierEnd 返回 idx 处方括号标识符结束后的索引，本行没有结束符时返回 idx。
+func sqlBracketIdentifierEnd(runes []rune, idx int) int {
+	for cursor := idx + 1; cursor < len(runes); cursor++ {
+		if runes[cursor] != ']' {
+			continue
+		}
+		if cursor+1 < len(runes) && runes[cursor+1] == ']' {
+			cursor++
+			continue
+		}
+		return cursor + 1
+	}
+	return idx
+}
+
+// sqlHasPrefixAt 判断 runes 从 idx 开始是否以 prefix 开头。
+func sqlHasPrefixAt(runes []rune, idx int, prefix string) bool {
+	for _, expected := range prefix {
+		if idx >= len(runes) || runes[idx] != expected {
+			return false
+		}
+		idx++
+	}
+	return true
+}
+
+// sqlIsIdentifierRune 判断字符能否出现在 SQL 标识符中。
+func sqlIsIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
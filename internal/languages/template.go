@@ -0,0 +1,43 @@
+package languages
+
+// templateFrame 是一层尚未闭合的 ${...} 插值。
+type templateFrame struct {
+	// braces 为插值表达式内尚未闭合的 { 数量。
+	braces int
+	// jsxDepth 为进入插值时 JSX 解析栈的深度；表达式中的 JSX 元素拥有自己的花括号，不计入本层。
+	jsxDepth int
+}
+
+// templateTracker 供 JavaScript/TypeScript 状态机跟踪模板字符串的 ${...} 插值嵌套。
+// 插值表达式回到普通代码态（其中的 // 是注释、反引号开始新的模板字符串），
+// 与 ${ 配对的 } 使引擎回到外层模板字符串，而不是在表达式内的反引号处提前结束外层字符串。
+type templateTracker struct {
+	frames []templateFrame
+}
+
+// open 在模板字符串中遇到 ${ 时进入一层插值。
+func (t *templateTracker) open(jsxDepth int) {
+	t.frames = append(t.frames, templateFrame{jsxDepth: jsxDepth})
+}
+
+// brace 在普通代码态处理插值表达式内的花括号；handled 为 false 时由引擎按普通代码处理该字符。
+// closed 为 true 表示遇到了与 ${ 配对的 }，引擎应回到模板字符串状态。
+func (t *templateTracker) brace(current rune, jsxDepth int) (closed bool, handled bool) {
+	if len(t.frames) == 0 || (current != '{' && current != '}') {
+		return false, false
+	}
+	top := &t.frames[len(t.frames)-1]
+	if top.jsxDepth != jsxDepth {
+		return false, false
+	}
+	switch {
+	case current == '{':
+		top.braces++
+	case top.braces > 0:
+		top.braces--
+	default:
+		t.frames = t.frames[:len(t.frames)-1]
+		return true, true
+	}
+	return false, true
+}
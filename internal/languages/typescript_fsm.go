@@ -1,17 +1,26 @@
 package languages
 
 import (
-	"bufio"
 	"errors"
 	"io"
-	"unicode"
+	"regexp"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// typeScriptFunctionMatcher 识别 function 声明（含 export/declare/async 修饰）。
+var typeScriptFunctionMatcher = functionMatcher{
+	pattern: regexp.MustCompile(`^(export\s+(default\s+)?)?(declare\s+)?(async\s+)?function\b`),
+}
+
 // TypeScriptAnalyzer 是 TypeScript 专用 FSM 分析器。
 // 尽管语法与 JavaScript 相近，也保持独立文件与独立引擎实现。
-type TypeScriptAnalyzer struct{}
+type TypeScriptAnalyzer struct {
+	// Options 控制附加统计能力，零值仅统计基础行数。
+	Options Options
+	// JSX 为 true 时识别 JSX 语法，只用于 .tsx 文件：.ts 文件中的 <T>expr 是类型断言而不是元素。
+	JSX bool
+}
 
 // Name 返回语言名称。
 func (a *TypeScriptAnalyzer) Name() string {
@@ -23,18 +32,55 @@ func (a *TypeScriptAnalyzer) Extensions() []string {
 	return []string{".ts", ".tsx"}
 }
 
+// Capabilities 返回该 FSM 支持的注释与字符串语法。
+func (a *TypeScriptAnalyzer) Capabilities() Capabilities {
+	return Capabilities{
+		LineComments:     []string{"//"},
+		BlockComments:    []BlockCommentPair{{Start: "/*", End: "*/"}},
+		StringDelimiters: []string{"'", "\"", "`"},
+		Shebang:          true,
+		Notes: []string{
+			"模板字符串可以跨行，${...} 插值按代码解析，其中可以嵌套模板字符串",
+			".tsx 文件识别 JSX：元素文本中的 '、// 不是字符串或注释，只包含注释的 {/* ... */} 计为注释",
+			"首行 shebang（#!）按 Options.Shebang 计为注释、preprocessor 或代码",
+		},
+	}
+}
+
+// IsTestFile 按前端社区约定识别测试文件：*.test.ts、*.spec.ts 或位于 __tests__ 目录。
+func (a *TypeScriptAnalyzer) IsTestFile(path string) bool {
+	return hasTestInfix(pathBase(path)) || hasPathSegment(path, "__tests__")
+}
+
 // Analyze 逐行调用 TypeScript 独立状态机。
 func (a *TypeScriptAnalyzer) Analyze(reader io.Reader) (model.LineMetrics, error) {
-	engine := &typeScriptFSMEngine{}
+	engine := &typeScriptFSMEngine{options: a.Options, jsx: jsxTracker{enabled: a.JSX}}
 	return engine.analyze(reader)
 }
 
+// ForExtension 为 .tsx 返回识别 JSX 的分析器，其他后缀返回自身。
+func (a *TypeScriptAnalyzer) ForExtension(ext string) Analyzer {
+	if ext != ".tsx" || a.JSX {
+		return a
+	}
+	return &TypeScriptAnalyzer{Options: a.Options, JSX: true}
+}
+
 // typeScriptFSMEngine 维护 TypeScript 状态机状态。
 type typeScriptFSMEngine struct {
+	options Options
+	// scratch 为分析期间从池中借用的读取与解码缓冲区，analyze 结束时归还。
+	scratch *lineScratch
+	lineFlags
+
 	inBlockComment    bool
 	inSingleQuotedStr bool
 	inDoubleQuotedStr bool
 	inTemplateLiteral bool
+	// templates 跟踪模板字符串中尚未闭合的 ${...} 插值，跨行保留。
+	templates templateTracker
+	// jsx 跟踪 JSX 元素结构，跨行保留。
+	jsx jsxTracker
 }
 
 // analyze 执行流式读取与行级统计。
@@ -44,38 +90,65 @@ func (e *typeScriptFSMEngine) analyze(reader io.Reader) (model.LineMetrics, erro
 	// 逐行流式读取可以兼顾性能和准确性：
 	// - 性能：不需要把文件整体读入内存；
 	// - 准确性：行级计数天然贴合 total/code/comment/blank 的定义。
-	bufferedReader := bufio.NewReader(reader)
-
+	e.scratch = acquireLineScratch(reader, e.options)
+	defer releaseLineScratch(e.scratch)
+	e.scratch.traceStates(&metrics, e.options)
+	if err := e.scratch.scanShebang(&metrics, e.options); err != nil {
+		return metrics, err
+	}
 	for {
-		line, err := bufferedReader.ReadString('\n')
+		startsInCode := e.inCodeState()
+		// 单行解析由 processLine 负责，内部会处理状态迁移。
+		line, hasCode, hasComment, err := e.scratch.scanLine(e)
 		// EOF 且无剩余文本时结束。
-		if errors.Is(err, io.EOF) && len(line) == 0 {
+		if errors.Is(err, io.EOF) {
 			break
 		}
 		// 只要是非 EOF 的异常都应该中断。
-		if err != nil && !errors.Is(err, io.EOF) {
+		if err != nil {
 			return metrics, err
 		}
 
-		// 单行解析由 processLine 负责，内部会处理状态迁移。
-		currentLine := normalizeLine(line)
-		hasCode, hasComment := e.processLine(currentLine)
-		applyLineClassification(&metrics, currentLine, hasCode, hasComment)
-
-		// 处理完最后一行（无换行符）后退出。
-		if errors.Is(err, io.EOF) {
-			break
+		if e.options.StringLiteralLines && hasCode && e.lineHasLiteral && !e.lineHasLogic {
+			applyStringLiteralLine(&metrics, e.options, line, hasComment)
+		} else {
+			applyLineClassification(&metrics, e.options, line, hasCode, hasComment)
+		}
+		if e.options.CountFunctions && startsInCode && hasCode && typeScriptFunctionMatcher.matches(line.text) {
+			metrics.Functions++
 		}
 	}
 
 	return metrics, nil
 }
 
+// inCodeState 判断当前是否处于普通代码态（不在注释或字符串等跨行状态中）。
+func (e *typeScriptFSMEngine) inCodeState() bool {
+	return !e.inBlockComment && !e.inSingleQuotedStr && !e.inDoubleQuotedStr && !e.inTemplateLiteral && !e.jsx.markup()
+}
+
+// state 返回当前的跨行状态名称，供 Options.TraceStates 记录。
+func (e *typeScriptFSMEngine) state() string {
+	switch {
+	case e.inBlockComment:
+		return "block-comment"
+	case e.inSingleQuotedStr || e.inDoubleQuotedStr:
+		return "string"
+	case e.inTemplateLiteral:
+		return "template"
+	case e.jsx.markup():
+		return "jsx"
+	}
+	return "code"
+}
+
 // processLine 解析一行 TypeScript 内容。
 func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
+	e.lineHasLiteral = false
+	e.lineHasLogic = false
 	hasCode := false
 	hasComment := false
-	runes := []rune(line)
+	runes := e.scratch.decode(line)
 
 	// 把上一行遗留状态带入本行，避免跨行字符串/注释统计丢失。
 	if e.inBlockComment {
@@ -83,6 +156,7 @@ func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
 	}
 	if e.inSingleQuotedStr || e.inDoubleQuotedStr || e.inTemplateLiteral {
 		hasCode = true
+		e.lineHasLiteral = true
 	}
 
 	for idx := 0; idx < len(runes); {
@@ -107,6 +181,7 @@ func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inSingleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 在字符串态里，转义字符优先级高于结束引号。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -121,6 +196,7 @@ func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inDoubleQuotedStr {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 双引号字符串用同样的转义策略。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -135,6 +211,7 @@ func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if e.inTemplateLiteral {
 			hasCode = true
+			e.lineHasLiteral = true
 			// 模板字符串支持跨行，直到反引号闭合才退出该状态。
 			if current == '\\' && hasNext {
 				idx += 2
@@ -143,11 +220,30 @@ func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
 			if current == '`' {
 				e.inTemplateLiteral = false
 			}
+			// ${ 进入插值表达式，表达式内按普通代码解析，直到配对的 } 回到模板字符串。
+			if current == '$' && hasNext && next == '{' {
+				e.inTemplateLiteral = false
+				e.lineHasLogic = true
+				e.templates.open(len(e.jsx.stack))
+				idx += 2
+				continue
+			}
 			idx++
 			continue
 		}
 
-		if unicode.IsSpace(current) {
+		// JSX 子内容中的 '、"、// 都是普通文本，只有 {、< 会改变结构。
+		if e.jsx.inChildren() {
+			next, code := e.jsx.scanChildren(runes, idx)
+			if code {
+				hasCode = true
+				e.lineHasLogic = true
+			}
+			idx = next
+			continue
+		}
+
+		if isBlankRune(current) {
 			// 空白字符不直接决定行分类。
 			idx++
 			continue
@@ -155,6 +251,7 @@ func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '/' && hasNext && next == '/' {
 			hasComment = true
+			e.lineCommented = true
 			return hasCode, hasComment
 		}
 
@@ -165,8 +262,34 @@ func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
 			continue
 		}
 
+		// 模板字符串插值表达式中的花括号；与 ${ 配对的 } 回到模板字符串。
+		if closed, handled := e.templates.brace(current, len(e.jsx.stack)); handled {
+			hasCode = true
+			if closed {
+				e.inTemplateLiteral = true
+				e.lineHasLiteral = true
+			} else {
+				e.lineHasLogic = true
+				e.jsx.noteCode(current)
+			}
+			idx++
+			continue
+		}
+
+		// JSX 标签的 > 与 />、表达式容器的花括号以及开始元素的 <。
+		if next, code, handled := e.jsx.scanCode(runes, idx); handled {
+			if code {
+				hasCode = true
+				e.lineHasLogic = true
+			}
+			idx = next
+			continue
+		}
+
 		if current == '\'' {
 			hasCode = true
+			e.lineHasLiteral = true
+			e.jsx.noteCode(current)
 			e.inSingleQuotedStr = true
 			idx++
 			continue
@@ -174,6 +297,8 @@ func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '"' {
 			hasCode = true
+			e.lineHasLiteral = true
+			e.jsx.noteCode(current)
 			e.inDoubleQuotedStr = true
 			idx++
 			continue
@@ -181,12 +306,18 @@ func (e *typeScriptFSMEngine) processLine(line string) (bool, bool) {
 
 		if current == '`' {
 			hasCode = true
+			e.lineHasLiteral = true
+			e.jsx.noteCode(current)
 			e.inTemplateLiteral = true
 			idx++
 			continue
 		}
 
 		hasCode = true
+		if !isStringLiteralPunctuation(current) {
+			e.lineHasLogic = true
+		}
+		e.jsx.noteCode(current)
 		idx++
 	}
 
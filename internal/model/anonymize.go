@@ -0,0 +1,105 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"sort"
+	"strings"
+)
+
+// anonymizedHashLength 是匿名化路径中保留的十六进制哈希长度（48 位，足以避免常见规模仓库内的冲突）。
+const anonymizedHashLength = 12
+
+// AnonymizePath 把路径替换为稳定的哈希名：SHA-256 前 12 位十六进制加原后缀，例如 src/billing/invoice.go -> cff260b51def.go。
+// 同一路径总是得到同一结果，便于对比多次导出；目录结构与文件名不会出现在结果中。空路径保持为空。
+func AnonymizePath(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:anonymizedHashLength] + path.Ext(strings.ReplaceAll(value, "\\", "/"))
+}
+
+// Anonymized 返回路径匿名化后的副本，当前结果不会被修改，用于对外分享（基准对比、供应商审计）而不泄露仓库结构。
+//
+// 口径说明：
+// - 扫描路径、文件明细、大文件榜单、重复代码区域、错误、跳过的特殊文件与包目录均按 AnonymizePath 替换
+// （根目录 "." 除外），语言与后缀保留
+// - 错误信息中出现的原路径同样被替换，但底层错误可能包含的其他路径信息无法识别
+// - 文件明细、错误与跳过的特殊文件按匿名化后的路径重新排序，避免原有顺序暴露目录结构
+func (r ScanResult) Anonymized() ScanResult {
+	anonymized := r
+	anonymized.ScannedPath = AnonymizePath(r.ScannedPath)
+	anonymized.Files = anonymizeFiles(r.Files)
+	sort.Slice(anonymized.Files, func(i int, j int) bool {
+		return anonymized.Files[i].Path < anonymized.Files[j].Path
+	})
+
+	if r.LargestFiles != nil {
+		anonymized.LargestFiles = &FileRanking{
+			ByCode:  anonymizeFiles(r.LargestFiles.ByCode),
+			ByTotal: anonymizeFiles(r.LargestFiles.ByTotal),
+		}
+	}
+	if r.Duplication != nil {
+		duplication := *r.Duplication
+		duplication.TopRegions = append([]DuplicateRegion(nil), r.Duplication.TopRegions...)
+		for index := range duplication.TopRegions {
+			duplication.TopRegions[index].Path = AnonymizePath(duplication.TopRegions[index].Path)
+		}
+		anonymized.Duplication = &duplication
+	}
+	if r.Errors != nil {
+		anonymized.Errors = make([]ScanError, len(r.Errors))
+		for index, item := range r.Errors {
+			if item.Path != "" {
+				item.Message = strings.ReplaceAll(item.Message, item.Path, AnonymizePath(item.Path))
+			}
+			if r.ScannedPath != "" {
+				item.Message = strings.ReplaceAll(item.Message, r.ScannedPath, anonymized.ScannedPath)
+			}
+			item.Path = AnonymizePath(item.Path)
+			anonymized.Errors[index] = item
+		}
+		sort.SliceStable(anonymized.Errors, func(i int, j int) bool {
+			return anonymized.Errors[i].Path < anonymized.Errors[j].Path
+		})
+	}
+	if r.Skipped != nil {
+		anonymized.Skipped = make([]SkippedFile, len(r.Skipped))
+		for index, item := range r.Skipped {
+			item.Path = AnonymizePath(item.Path)
+			anonymized.Skipped[index] = item
+		}
+		sort.Slice(anonymized.Skipped, func(i int, j int) bool {
+			return anonymized.Skipped[i].Path < anonymized.Skipped[j].Path
+		})
+	}
+	if r.Packages != nil {
+		anonymized.Packages = make([]PackageMetrics, len(r.Packages))
+		for index, item := range r.Packages {
+			if item.Path != "." {
+				item.Path = AnonymizePath(item.Path)
+			}
+			anonymized.Packages[index] = item
+		}
+		sort.Slice(anonymized.Packages, func(i int, j int) bool {
+			return anonymized.Packages[i].Path < anonymized.Packages[j].Path
+		})
+	}
+	return anonymized
+}
+
+// anonymizeFiles 返回路径匿名化后的文件明细副本，顺序不变。
+func anonymizeFiles(files []FileMetrics) []FileMetrics {
+	if files == nil {
+		return nil
+	}
+	anonymized := make([]FileMetrics, len(files))
+	for index, item := range files {
+		item.Path = AnonymizePath(item.Path)
+		anonymized[index] = item
+	}
+	return anonymized
+}
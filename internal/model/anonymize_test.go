@@ -0,0 +1,54 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestScanResultAnonymized 验证路径被替换为保留后缀的稳定哈希、错误信息中的原路径被替换，且原结果不被修改。
+func TestScanResultAnonymized(t *testing.T) {
+	result := ScanResult{
+		ScannedPath: "/src/secret-project",
+		Files: []FileMetrics{
+			{Path: "internal/billing/invoice.go", Language: "Go", Metrics: LineMetrics{Total: 3, Code: 3}},
+			{Path: "Makefile", Language: "Make", Metrics: LineMetrics{Total: 1, Code: 1}},
+		},
+		Errors:  []ScanError{{Path: "internal/billing/keys.pem", Message: "open /src/secret-project/internal/billing/keys.pem: permission denied"}},
+		Skipped: []SkippedFile{{Path: "internal/billing/agent.sock", Reason: SkipReasonSocket}},
+	}
+	result.Summarize(SummaryOptions{})
+	ranking := RankFiles(result.Files, 1)
+	result.LargestFiles = &ranking
+
+	anonymized := result.Anonymized()
+
+	if anonymized.ScannedPath != AnonymizePath("/src/secret-project") || len(anonymized.ScannedPath) != anonymizedHashLength {
+		t.Fatalf("unexpected scanned path: %q", anonymized.ScannedPath)
+	}
+	paths := make(map[string]string)
+	for _, item := range anonymized.Files {
+		paths[item.Language] = item.Path
+	}
+	if paths["Go"] != AnonymizePath("internal/billing/invoice.go") || !strings.HasSuffix(paths["Go"], ".go") || strings.Contains(paths["Go"], "/") {
+		t.Fatalf("unexpected anonymized go path: %q", paths["Go"])
+	}
+	if paths["Make"] != AnonymizePath("Makefile") || strings.Contains(paths["Make"], ".") {
+		t.Fatalf("unexpected anonymized path without extension: %q", paths["Make"])
+	}
+	if anonymized.LargestFiles == nil || anonymized.LargestFiles.ByCode[0].Path != paths["Go"] {
+		t.Fatalf("expected anonymized ranking: %+v", anonymized.LargestFiles)
+	}
+	message := anonymized.Errors[0].Message
+	if strings.Contains(message, "billing") || strings.Contains(message, "secret") || !strings.HasSuffix(message, ": permission denied") {
+		t.Fatalf("expected paths removed from error message: %q", message)
+	}
+	if anonymized.Skipped[0].Path != AnonymizePath("internal/billing/agent.sock") || anonymized.Skipped[0].Reason != SkipReasonSocket {
+		t.Fatalf("unexpected anonymized skipped file: %+v", anonymized.Skipped)
+	}
+	if result.Skipped[0].Path != "internal/billing/agent.sock" || result.Files[0].Path != "Makefile" || result.LargestFiles.ByCode[0].Path != "internal/billing/invoice.go" || result.Errors[0].Path != "internal/billing/keys.pem" {
+		t.Fatalf("original result modified: %+v", result)
+	}
+	if AnonymizePath("Makefile") != AnonymizePath("Makefile") || AnonymizePath("") != "" {
+		t.Fatal("expected stable anonymization")
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"path"
+	"sort"
+)
+
+// RepositoryResult 是批量扫描中单个仓库的结果，扫描失败时 Error 非空、Result 为零值。
+type RepositoryResult struct {
+	Label string `json:"label"`
+	// Source 为仓库来源（本地路径或 git 地址）。
+	Source string     `json:"source"`
+	Error  string     `json:"error,omitempty"`
+	Result ScanResult `json:"result"`
+}
+
+// BatchResult 是多仓库批量扫描的结果：各仓库的独立结果与全部仓库的汇总。
+type BatchResult struct {
+	Repositories []RepositoryResult `json:"repositories"`
+	// Aggregate 为所有成功仓库合并后的结果，文件与错误路径以 "<label>/" 为前缀，避免不同仓库的同名文件被去重。
+	Aggregate ScanResult `json:"aggregate"`
+}
+
+// NewBatchResult 按标签排序仓库结果并计算汇总，各仓库的结果不会被修改。
+func NewBatchResult(repositories []RepositoryResult) BatchResult {
+	batch := BatchResult{Repositories: append([]RepositoryResult(nil), repositories...)}
+	sort.Slice(batch.Repositories, func(i int, j int) bool {
+		return batch.Repositories[i].Label < batch.Repositories[j].Label
+	})
+
+	batch.Aggregate.Files = make([]FileMetrics, 0)
+	batch.Aggregate.Errors = make([]ScanError, 0)
+	for _, repository := range batch.Repositories {
+		if repository.Error != "" {
+			continue
+		}
+		prefixed := ScanResult{
+			ScannedPath: repository.Label,
+			Files:       make([]FileMetrics, len(repository.Result.Files)),
+			Errors:      make([]ScanError, len(repository.Result.Errors)),
+			Languages:   repository.Result.Languages,
+			Owners:      repository.Result.Owners,
+		}
+		for index, item := range repository.Result.Files {
+			item.Path = repository.Label + "/" + item.Path
+			prefixed.Files[index] = item
+		}
+		for index, item := range repository.Result.Errors {
+			item.Path = repository.Label + "/" + item.Path
+			prefixed.Errors[index] = item
+		}
+		for _, item := range repository.Result.Skipped {
+			item.Path = repository.Label + "/" + item.Path
+			prefixed.Skipped = append(prefixed.Skipped, item)
+		}
+		for _, item := range repository.Result.Packages {
+			item.Path = path.Join(repository.Label, item.Path)
+			prefixed.Packages = append(prefixed.Packages, item)
+		}
+		batch.Aggregate.Merge(prefixed)
+	}
+	return batch
+}
@@ -0,0 +1,30 @@
+package model
+
+import "testing"
+
+// TestNewBatchResult 验证汇总以标签为路径前缀（同名文件不会被去重）、跳过失败仓库，且不修改各仓库结果。
+func TestNewBatchResult(t *testing.T) {
+	api := ScanResult{ScannedPath: "/src/api", Files: []FileMetrics{{Path: "main.go", Language: "Go", Metrics: LineMetrics{Total: 3, Code: 2, Blank: 1}}}}
+	api.Summarize(SummaryOptions{})
+	web := ScanResult{ScannedPath: "/src/web", Files: []FileMetrics{
+		{Path: "main.go", Language: "Go", Metrics: LineMetrics{Total: 4, Code: 4}},
+		{Path: "app.py", Language: "Python", Metrics: LineMetrics{Total: 2, Code: 1, Comment: 1}},
+	}}
+	web.Summarize(SummaryOptions{})
+
+	batch := NewBatchResult([]RepositoryResult{
+		{Label: "web", Source: "/src/web", Result: web},
+		{Label: "broken", Source: "https://host/broken.git", Error: "clone failed"},
+		{Label: "api", Source: "/src/api", Result: api},
+	})
+
+	if batch.Repositories[0].Label != "api" || batch.Repositories[1].Label != "broken" || batch.Repositories[2].Label != "web" {
+		t.Fatalf("expected repositories sorted by label: %+v", batch.Repositories)
+	}
+	if batch.Aggregate.Total.Files != 3 || batch.Aggregate.Total.Code != 7 || len(batch.Aggregate.Languages) != 2 {
+		t.Fatalf("unexpected aggregate: %+v", batch.Aggregate.Total)
+	}
+	if batch.Aggregate.Files[0].Path != "api/main.go" || web.Files[0].Path != "app.py" {
+		t.Fatalf("unexpected prefixed paths: %s, original %s", batch.Aggregate.Files[0].Path, web.Files[0].Path)
+	}
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// bytesPerMB 是吞吐量中 MB 的字节数。
+const bytesPerMB = 1_000_000
+
+// BenchRun 是基准测试中一次完整扫描的测量值，分配统计取自扫描前后 runtime.MemStats 的差值。
+type BenchRun struct {
+	Duration time.Duration `json:"duration_ns"`
+	Files    int64         `json:"files"`
+	Bytes    int64         `json:"bytes"`
+	// Allocs 为扫描期间的堆分配次数，AllocBytes 为累计分配的字节数。
+	Allocs     uint64 `json:"allocs"`
+	AllocBytes uint64 `json:"alloc_bytes"`
+	// GCCycles 为扫描期间完成的 GC 次数。
+	GCCycles uint32 `json:"gc_cycles"`
+}
+
+// FilesPerSecond 返回每秒分析的文件数。
+func (r BenchRun) FilesPerSecond() float64 {
+	return perSecond(float64(r.Files), r.Duration)
+}
+
+// MBPerSecond 返回每秒分析的 MB 数（1 MB = 10^6 字节）。
+func (r BenchRun) MBPerSecond() float64 {
+	return perSecond(float64(r.Bytes)/bytesPerMB, r.Duration)
+}
+
+// BenchLanguage 是按语言统计的分析吞吐。
+// Duration 为所有 worker 分析该语言文件的累计耗时（含读取文件），吞吐按单个 worker 计算，
+// 因此与 worker 数量无关，可以直接比较不同语言分析器的快慢。
+type BenchLanguage struct {
+	Language       string        `json:"language"`
+	Files          int64         `json:"files"`
+	Bytes          int64         `json:"bytes"`
+	Duration       time.Duration `json:"analyze_ns"`
+	FilesPerSecond float64       `json:"files_per_second"`
+	MBPerSecond    float64       `json:"mb_per_second"`
+}
+
+// BenchResult 是 gocloc bench 的结果：预热之外每次扫描的测量值，以及按中位数耗时计算的整体吞吐。
+type BenchResult struct {
+	Paths   []string `json:"paths"`
+	Workers int      `json:"workers"`
+	Warmup  int      `json:"warmup"`
+	// Runs 按执行顺序排列，不含预热。
+	Runs []BenchRun `json:"runs"`
+	// MinDuration、MedianDuration、MaxDuration 为各次扫描耗时的最小值、中位数与最大值。
+	MinDuration    time.Duration `json:"min_duration_ns"`
+	MedianDuration time.Duration `json:"median_duration_ns"`
+	MaxDuration    time.Duration `json:"max_duration_ns"`
+	// FilesPerSecond 与 MBPerSecond 按中位数耗时的那次扫描计算。
+	FilesPerSecond float64 `json:"files_per_second"`
+	MBPerSecond    float64 `json:"mb_per_second"`
+	// AllocsPerFile 与 AllocBytesPerFile 为全部扫描平均到每个文件的分配次数与字节数。
+	AllocsPerFile     float64 `json:"allocs_per_file"`
+	AllocBytesPerFile float64 `json:"alloc_bytes_per_file"`
+	// Languages 为全部扫描累计的按语言吞吐，按累计耗时从高到低排序。
+	Languages []BenchLanguage `json:"languages"`
+}
+
+// NewBenchResult 根据各次扫描的测量值与按语言累计的耗时计算基准测试结果；languages 中的吞吐字段会被重新计算。
+func NewBenchResult(paths []string, workers int, warmup int, runs []BenchRun, languages []BenchLanguage) BenchResult {
+	result := BenchResult{
+		Paths:     append([]string(nil), paths...),
+		Workers:   workers,
+		Warmup:    warmup,
+		Runs:      append([]BenchRun(nil), runs...),
+		Languages: make([]BenchLanguage, 0, len(languages)),
+	}
+	if len(runs) > 0 {
+		sorted := append([]BenchRun(nil), runs...)
+		sort.SliceStable(sorted, func(i int, j int) bool {
+			return sorted[i].Duration < sorted[j].Duration
+		})
+		median := sorted[(len(sorted)-1)/2]
+		result.MinDuration = sorted[0].Duration
+		result.MedianDuration = median.Duration
+		result.MaxDuration = sorted[len(sorted)-1].Duration
+		result.FilesPerSecond = median.FilesPerSecond()
+		result.MBPerSecond = median.MBPerSecond()
+
+		var files int64
+		var allocs, allocBytes uint64
+		for _, run := range runs {
+			files += run.Files
+			allocs += run.Allocs
+			allocBytes += run.AllocBytes
+		}
+		if files > 0 {
+			result.AllocsPerFile = float64(allocs) / float64(files)
+			result.AllocBytesPerFile = float64(allocBytes) / float64(files)
+		}
+	}
+
+	for _, item := range languages {
+		item.FilesPerSecond = perSecond(float64(item.Files), item.Duration)
+		item.MBPerSecond = perSecond(float64(item.Bytes)/bytesPerMB, item.Duration)
+		result.Languages = append(result.Languages, item)
+	}
+	sort.Slice(result.Languages, func(i int, j int) bool {
+		if result.Languages[i].Duration != result.Languages[j].Duration {
+			return result.Languages[i].Duration > result.Languages[j].Duration
+		}
+		return result.Languages[i].Language < result.Languages[j].Language
+	})
+	return result
+}
+
+// perSecond 把 duration 内的数量换算为每秒的数量，duration 不为正时返回 0。
+func perSecond(value float64, duration time.Duration) float64 {
+	if duration <= 0 {
+		return 0
+	}
+	return value / duration.Seconds()
+}
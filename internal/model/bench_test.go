@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNewBenchResult 验证整体吞吐取中位数耗时的扫描、分配统计按文件平均，语言吞吐按累计耗时计算并排序。
+func TestNewBenchResult(t *testing.T) {
+	runs := []BenchRun{
+		{Duration: 3 * time.Second, Files: 300, Bytes: 3_000_000, Allocs: 900, AllocBytes: 9000},
+		{Duration: time.Second, Files: 300, Bytes: 3_000_000, Allocs: 600, AllocBytes: 6000},
+		{Duration: 2 * time.Second, Files: 300, Bytes: 3_000_000, Allocs: 300, AllocBytes: 3000},
+	}
+	languages := []BenchLanguage{
+		{Language: "Python", Files: 100, Bytes: 500_000, Duration: time.Second},
+		{Language: "Go", Files: 200, Bytes: 2_000_000, Duration: 4 * time.Second},
+	}
+
+	result := NewBenchResult([]string{"."}, 4, 1, runs, languages)
+	if result.MinDuration != time.Second || result.MedianDuration != 2*time.Second || result.MaxDuration != 3*time.Second {
+		t.Fatalf("unexpected durations: %+v", result)
+	}
+	if result.FilesPerSecond != 150 || result.MBPerSecond != 1.5 {
+		t.Fatalf("unexpected throughput: %v files/s, %v MB/s", result.FilesPerSecond, result.MBPerSecond)
+	}
+	if result.AllocsPerFile != 2 || result.AllocBytesPerFile != 20 {
+		t.Fatalf("unexpected allocation stats: %v allocs/file, %v B/file", result.AllocsPerFile, result.AllocBytesPerFile)
+	}
+	if result.Runs[0].Duration != 3*time.Second {
+		t.Fatalf("expected runs in execution order: %+v", result.Runs)
+	}
+	if len(result.Languages) != 2 || result.Languages[0].Language != "Go" || result.Languages[0].FilesPerSecond != 50 || result.Languages[1].MBPerSecond != 0.5 {
+		t.Fatalf("unexpected language throughput: %+v", result.Languages)
+	}
+
+	if empty := NewBenchResult(nil, 1, 0, nil, nil); empty.FilesPerSecond != 0 || empty.AllocsPerFile != 0 {
+		t.Fatalf("unexpected empty result: %+v", empty)
+	}
+}
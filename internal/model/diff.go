@@ -0,0 +1,272 @@
+package model
+
+import (
+	"sort"
+)
+
+// Clone 返回深拷贝，修改副本不会影响原对象（包括空白统计、逐行分类与去重哈希集合）。
+func (m LineMetrics) Clone() LineMetrics {
+	clone := m
+	if m.Whitespace != nil {
+		whitespace := *m.Whitespace
+		clone.Whitespace = &whitespace
+	}
+	if m.Embedded != nil {
+		clone.Embedded = make([]EmbeddedMetrics, len(m.Embedded))
+		for index, embedded := range m.Embedded {
+			clone.Embedded[index] = EmbeddedMetrics{Language: embedded.Language, Metrics: embedded.Metrics.Clone()}
+		}
+	}
+	if m.LineClasses != nil {
+		clone.LineClasses = append([]string(nil), m.LineClasses...)
+	}
+	if m.LineStates != nil {
+		clone.LineStates = append([]LineState(nil), m.LineStates...)
+	}
+	if m.CodeLines != nil {
+		clone.CodeLines = append([]CodeLine(nil), m.CodeLines...)
+	}
+	if m.uniqueLines != nil {
+		clone.uniqueLines = make(map[uint64]struct{}, len(m.uniqueLines))
+		for hash := range m.uniqueLines {
+			clone.uniqueLines[hash] = struct{}{}
+		}
+	}
+	return clone
+}
+
+// Subtract 从当前对象逐字段减去另一个统计结果，得到 m - other 的差值，结果中的字段可以为负数。
+//
+// 口径说明：
+// - 计数字段（Total、Code、Bytes 等）直接相减
+// - MaxLineLength、AvgLineLength 为两者数值之差，而不是由差值重新推导
+// - Whitespace 的计数与最大缩进宽度相减，缩进风格保留当前对象的取值
+// - LineClasses、LineStates、CodeLines 与去重哈希集合无法表达差值，会被清空
+func (m *LineMetrics) Subtract(other LineMetrics) {
+	m.Total -= other.Total
+	m.Code -= other.Code
+	m.Comment -= other.Comment
+	m.Blank -= other.Blank
+	m.ULOC -= other.ULOC
+	m.Preprocessor -= other.Preprocessor
+	m.StringLiteral -= other.StringLiteral
+	m.Functions -= other.Functions
+	m.Bytes -= other.Bytes
+	m.Characters -= other.Characters
+	m.MaxLineLength -= other.MaxLineLength
+	m.AvgLineLength -= other.AvgLineLength
+	if m.Whitespace != nil || other.Whitespace != nil {
+		whitespace := WhitespaceMetrics{IndentStyle: IndentStyleNone}
+		if m.Whitespace != nil {
+			whitespace = *m.Whitespace
+		}
+		if other.Whitespace != nil {
+			whitespace.TabIndentedLines -= other.Whitespace.TabIndentedLines
+			whitespace.SpaceIndentedLines -= other.Whitespace.SpaceIndentedLines
+			whitespace.MixedIndentedLines -= other.Whitespace.MixedIndentedLines
+			whitespace.MaxIndentWidth -= other.Whitespace.MaxIndentWidth
+			whitespace.TrailingWhitespaceLines -= other.Whitespace.TrailingWhitespaceLines
+		}
+		m.Whitespace = &whitespace
+	}
+	m.LineClasses = nil
+	m.LineStates = nil
+	m.CodeLines = nil
+	m.uniqueLines = nil
+}
+
+// Subtract 逐项相减比例指标。
+func (r *Ratios) Subtract(other Ratios) {
+	r.CommentDensity -= other.CommentDensity
+	r.BlankRatio -= other.BlankRatio
+	r.CodeShare -= other.CodeShare
+}
+
+// Clone 返回深拷贝。
+func (m TotalMetrics) Clone() TotalMetrics {
+	clone := m
+	clone.LineMetrics = m.LineMetrics.Clone()
+	return clone
+}
+
+// Subtract 从当前总计中减去另一个总计，文件数、行数与比例均为差值。
+func (m *TotalMetrics) Subtract(other TotalMetrics) {
+	m.Files -= other.Files
+	m.LineMetrics.Subtract(other.LineMetrics)
+	m.Ratios.Subtract(other.Ratios)
+}
+
+// Clone 返回深拷贝。
+func (m LanguageMetrics) Clone() LanguageMetrics {
+	clone := m
+	clone.Metrics = m.Metrics.Clone()
+	if m.Extensions != nil {
+		clone.Extensions = append([]string(nil), m.Extensions...)
+	}
+	if m.Variants != nil {
+		clone.Variants = make([]VariantMetrics, len(m.Variants))
+		for index, variant := range m.Variants {
+			clone.Variants[index] = VariantMetrics{Name: variant.Name, Files: variant.Files, Metrics: variant.Metrics.Clone()}
+		}
+	}
+	if m.Distribution != nil {
+		distribution := *m.Distribution
+		distribution.Buckets = append([]HistogramBucket(nil), m.Distribution.Buckets...)
+		clone.Distribution = &distribution
+	}
+	return clone
+}
+
+// Subtract 从当前语言汇总中减去另一个汇总。
+// 子类别按名称对齐相减（只出现在一方的子类别视为另一方为 0）；分布无法表达差值，会被清空。
+func (m *LanguageMetrics) Subtract(other LanguageMetrics) {
+	m.Files -= other.Files
+	m.Metrics.Subtract(other.Metrics)
+	m.Ratios.Subtract(other.Ratios)
+	m.Distribution = nil
+
+	for _, variant := range other.Variants {
+		index := sort.Search(len(m.Variants), func(i int) bool {
+			return m.Variants[i].Name >= variant.Name
+		})
+		if index == len(m.Variants) || m.Variants[index].Name != variant.Name {
+			m.Variants = append(m.Variants, VariantMetrics{})
+			copy(m.Variants[index+1:], m.Variants[index:])
+			m.Variants[index] = VariantMetrics{Name: variant.Name}
+		}
+		m.Variants[index].Files -= variant.Files
+		m.Variants[index].Metrics.Subtract(variant.Metrics)
+	}
+}
+
+// DiffStatus 表示文件或语言在两次扫描之间的变化类型。
+type DiffStatus string
+
+const (
+	DiffStatusAdded    DiffStatus = "added"
+	DiffStatusRemoved  DiffStatus = "removed"
+	DiffStatusModified DiffStatus = "modified"
+)
+
+// FileDiff 表示单个文件的变化，Delta 为 head - base（新增文件即其自身统计，删除文件为负值）。
+type FileDiff struct {
+	Path     string      `json:"path"`
+	Language string      `json:"language"`
+	Status   DiffStatus  `json:"status"`
+	Delta    LineMetrics `json:"delta"`
+}
+
+// LanguageDiff 表示单个语言汇总的变化，Delta 为 head - base。
+// AddedFiles 与 RemovedFiles 为该语言新增与删除的文件数（Delta.Files 为两者之差加上改变语言的文件）。
+type LanguageDiff struct {
+	Language     string          `json:"language"`
+	Status       DiffStatus      `json:"status"`
+	AddedFiles   int64           `json:"added_files"`
+	RemovedFiles int64           `json:"removed_files"`
+	Delta        LanguageMetrics `json:"delta"`
+}
+
+// DiffResult 表示两次扫描结果之间的差值，是 diff/历史趋势等功能的数据层。
+// Files 与 Languages 只包含发生变化的条目，分别按路径与语言名称排序；Total 为总计差值。
+type DiffResult struct {
+	Base      string         `json:"base"`
+	Head      string         `json:"head"`
+	Total     TotalMetrics   `json:"total"`
+	Languages []LanguageDiff `json:"languages"`
+	Files     []FileDiff     `json:"files"`
+}
+
+// NewDiffResult 计算 head 相对 base 的差值，两个结果都不会被修改。
+// 文件按路径对齐；同一路径的行数、字节数与函数数都未变化时视为未修改。
+func NewDiffResult(base ScanResult, head ScanResult) DiffResult {
+	diff := DiffResult{
+		Base:      base.ScannedPath,
+		Head:      head.ScannedPath,
+		Total:     head.Total.Clone(),
+		Languages: make([]LanguageDiff, 0),
+		Files:     make([]FileDiff, 0),
+	}
+	diff.Total.Subtract(base.Total)
+
+	baseFiles := make(map[string]FileMetrics, len(base.Files))
+	for _, item := range base.Files {
+		baseFiles[item.Path] = item
+	}
+	for _, item := range head.Files {
+		delta := item.Metrics.Clone()
+		previous, ok := baseFiles[item.Path]
+		status := DiffStatusAdded
+		if ok {
+			delete(baseFiles, item.Path)
+			delta.Subtract(previous.Metrics)
+			if previous.Language == item.Language && !lineMetricsChanged(delta) {
+				continue
+			}
+			status = DiffStatusModified
+		}
+		delta.LineClasses = nil
+		delta.LineStates = nil
+		delta.CodeLines = nil
+		diff.Files = append(diff.Files, FileDiff{Path: item.Path, Language: item.Language, Status: status, Delta: delta})
+	}
+	for _, item := range baseFiles {
+		var delta LineMetrics
+		delta.Subtract(item.Metrics)
+		diff.Files = append(diff.Files, FileDiff{Path: item.Path, Language: item.Language, Status: DiffStatusRemoved, Delta: delta})
+	}
+	sort.Slice(diff.Files, func(i int, j int) bool {
+		return diff.Files[i].Path < diff.Files[j].Path
+	})
+
+	baseLanguages := make(map[string]LanguageMetrics, len(base.Languages))
+	for _, item := range base.Languages {
+		baseLanguages[item.Language] = item
+	}
+	for _, item := range head.Languages {
+		delta := item.Clone()
+		delta.Distribution = nil
+		previous, ok := baseLanguages[item.Language]
+		status := DiffStatusAdded
+		if ok {
+			delete(baseLanguages, item.Language)
+			delta.Subtract(previous)
+			if delta.Files == 0 && !lineMetricsChanged(delta.Metrics) {
+				continue
+			}
+			status = DiffStatusModified
+		}
+		diff.Languages = append(diff.Languages, LanguageDiff{Language: item.Language, Status: status, Delta: delta})
+	}
+	for _, item := range baseLanguages {
+		delta := LanguageMetrics{Language: item.Language, Extensions: append([]string(nil), item.Extensions...)}
+		delta.Subtract(item)
+		diff.Languages = append(diff.Languages, LanguageDiff{Language: item.Language, Status: DiffStatusRemoved, Delta: delta})
+	}
+	sort.Slice(diff.Languages, func(i int, j int) bool {
+		return diff.Languages[i].Language < diff.Languages[j].Language
+	})
+	for _, item := range diff.Files {
+		if item.Status == DiffStatusModified {
+			continue
+		}
+		index := sort.Search(len(diff.Languages), func(i int) bool {
+			return diff.Languages[i].Language >= item.Language
+		})
+		if index == len(diff.Languages) || diff.Languages[index].Language != item.Language {
+			continue
+		}
+		if item.Status == DiffStatusAdded {
+			diff.Languages[index].AddedFiles++
+		} else {
+			diff.Languages[index].RemovedFiles++
+		}
+	}
+
+	return diff
+}
+
+// lineMetricsChanged 判断差值中的行数、字节数或函数数是否非零。
+func lineMetricsChanged(delta LineMetrics) bool {
+	return delta.Total != 0 || delta.Code != 0 || delta.Comment != 0 || delta.Blank != 0 ||
+		delta.Bytes != 0 || delta.Functions != 0 || delta.Preprocessor != 0 || delta.StringLiteral != 0
+}
@@ -0,0 +1,71 @@
+package model
+
+import "testing"
+
+// TestLineMetricsCloneAndSubtract 验证深拷贝互不影响，相减结果可以为负数。
+func TestLineMetricsCloneAndSubtract(t *testing.T) {
+	original := LineMetrics{Total: 10, Code: 6, Comment: 2, Blank: 2, Whitespace: &WhitespaceMetrics{TabIndentedLines: 3}}
+	original.AddUniqueLine(1)
+
+	clone := original.Clone()
+	clone.Whitespace.TabIndentedLines = 0
+	clone.AddUniqueLine(2)
+	if original.Whitespace.TabIndentedLines != 3 || original.ULOC != 1 {
+		t.Fatalf("clone must not share state with original: %+v", original)
+	}
+
+	clone.Subtract(LineMetrics{Total: 12, Code: 9, Comment: 1, Blank: 2, Whitespace: &WhitespaceMetrics{TabIndentedLines: 1}})
+	if clone.Total != -2 || clone.Code != -3 || clone.Comment != 1 || clone.Blank != 0 {
+		t.Fatalf("unexpected delta: %+v", clone)
+	}
+	if clone.Whitespace.TabIndentedLines != -1 {
+		t.Fatalf("unexpected whitespace delta: %+v", clone.Whitespace)
+	}
+}
+
+// TestNewDiffResult 验证文件与语言的新增、删除、修改状态，以及未变化条目被省略。
+func TestNewDiffResult(t *testing.T) {
+	base := ScanResult{ScannedPath: "v1", Files: []FileMetrics{
+		{Path: "main.go", Language: "Go", Metrics: LineMetrics{Total: 10, Code: 8, Blank: 2}},
+		{Path: "util.go", Language: "Go", Metrics: LineMetrics{Total: 5, Code: 5}},
+		{Path: "build.py", Language: "Python", Metrics: LineMetrics{Total: 3, Code: 3}},
+	}}
+	head := ScanResult{ScannedPath: "v2", Files: []FileMetrics{
+		{Path: "main.go", Language: "Go", Metrics: LineMetrics{Total: 14, Code: 11, Blank: 3}},
+		{Path: "util.go", Language: "Go", Metrics: LineMetrics{Total: 5, Code: 5}},
+		{Path: "lib.rs", Language: "Rust", Metrics: LineMetrics{Total: 4, Code: 4}},
+	}}
+	base.Summarize(SummaryOptions{})
+	head.Summarize(SummaryOptions{})
+
+	diff := NewDiffResult(base, head)
+	if diff.Base != "v1" || diff.Head != "v2" || diff.Total.Files != 0 || diff.Total.Code != 4 {
+		t.Fatalf("unexpected total delta: %+v", diff.Total)
+	}
+
+	expectedFiles := map[string]DiffStatus{"build.py": DiffStatusRemoved, "lib.rs": DiffStatusAdded, "main.go": DiffStatusModified}
+	if len(diff.Files) != len(expectedFiles) {
+		t.Fatalf("unexpected file diffs: %+v", diff.Files)
+	}
+	for _, item := range diff.Files {
+		if expectedFiles[item.Path] != item.Status {
+			t.Fatalf("unexpected status for %s: %s", item.Path, item.Status)
+		}
+	}
+	if diff.Files[0].Path != "build.py" || diff.Files[0].Delta.Code != -3 || diff.Files[2].Delta.Code != 3 {
+		t.Fatalf("unexpected file deltas: %+v", diff.Files)
+	}
+
+	if len(diff.Languages) != 3 || diff.Languages[0].Language != "Go" || diff.Languages[0].Delta.Metrics.Code != 3 {
+		t.Fatalf("unexpected language diffs: %+v", diff.Languages)
+	}
+	if diff.Languages[1].Status != DiffStatusRemoved || diff.Languages[1].Delta.Files != -1 || diff.Languages[1].RemovedFiles != 1 {
+		t.Fatalf("unexpected removed language: %+v", diff.Languages[1])
+	}
+	if diff.Languages[0].AddedFiles != 0 || diff.Languages[2].AddedFiles != 1 || diff.Languages[2].RemovedFiles != 0 {
+		t.Fatalf("unexpected added files: %+v", diff.Languages)
+	}
+	if base.Total.Code != 16 || head.Total.Code != 20 {
+		t.Fatalf("inputs must not change: base=%d head=%d", base.Total.Code, head.Total.Code)
+	}
+}
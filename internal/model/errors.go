@@ -0,0 +1,121 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+)
+
+// ErrorCategory 是扫描错误的分类。
+type ErrorCategory string
+
+const (
+	// ErrorCategoryOpen 表示打开文件（或遍历目录）失败，常见原因是权限不足或文件已被删除。
+	ErrorCategoryOpen ErrorCategory = "open"
+	// ErrorCategoryRead 表示读取文件内容失败。
+	ErrorCategoryRead ErrorCategory = "read"
+	// ErrorCategoryDecode 表示内容无法被分析器解析（例如插件输出不合法）。
+	ErrorCategoryDecode ErrorCategory = "decode"
+	// ErrorCategoryTimeout 表示分析超时（扫描设置了单文件分析时限，见 scanner.Options.FileTimeout）。
+	ErrorCategoryTimeout ErrorCategory = "timeout"
+	// ErrorCategoryOversize 表示文件超过大小限制（见 scanner.Options.MaxFileSize）。
+	ErrorCategoryOversize ErrorCategory = "oversize"
+)
+
+// 各分类对应的哨兵错误，可通过 errors.Is(scanError, ErrOpen) 判断分类。
+// 分析器等内部组件也可以用 fmt.Errorf("...: %w", ErrDecode) 包装错误以指定分类。
+var (
+	ErrOpen     = errors.New("open failed")
+	ErrRead     = errors.New("read failed")
+	ErrDecode   = errors.New("decode failed")
+	ErrTimeout  = errors.New("timeout")
+	ErrOversize = errors.New("file too large")
+)
+
+// sentinel 返回分类对应的哨兵错误。
+func (c ErrorCategory) sentinel() error {
+	switch c {
+	case ErrorCategoryOpen:
+		return ErrOpen
+	case ErrorCategoryRead:
+		return ErrRead
+	case ErrorCategoryDecode:
+		return ErrDecode
+	case ErrorCategoryTimeout:
+		return ErrTimeout
+	case ErrorCategoryOversize:
+		return ErrOversize
+	default:
+		return nil
+	}
+}
+
+// ScanError 记录单文件扫描失败信息。
+// 设计为“错误不阻断全量扫描”，便于大仓库分析时容错。
+//
+// ScanError 实现 error 接口：errors.Is 既可以匹配分类哨兵（如 ErrOpen），
+// 也可以匹配底层错误（如 fs.ErrPermission）；从 JSON 读回时底层错误按 Errno 还原。
+type ScanError struct {
+	Path string `json:"path"`
+	// Message 为错误描述，JSON 字段沿用 error 以保持输出格式兼容。
+	Message  string        `json:"error"`
+	Category ErrorCategory `json:"category,omitempty"`
+	// Errno 为底层系统调用错误码（如 EACCES），非系统调用错误时为 0。
+	Errno int `json:"errno,omitempty"`
+
+	err error
+}
+
+// NewScanError 根据底层错误创建 ScanError。
+// err 已包装某个分类哨兵时使用该分类，超时类错误归为 timeout，其余使用 fallback。
+func NewScanError(path string, fallback ErrorCategory, err error) ScanError {
+	scanError := ScanError{
+		Path:     path,
+		Message:  err.Error(),
+		Category: categorize(err, fallback),
+		err:      err,
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		scanError.Errno = int(errno)
+	}
+	return scanError
+}
+
+// categorize 按错误链推断分类。
+func categorize(err error, fallback ErrorCategory) ErrorCategory {
+	for _, category := range []ErrorCategory{ErrorCategoryOversize, ErrorCategoryTimeout, ErrorCategoryDecode, ErrorCategoryRead, ErrorCategoryOpen} {
+		if errors.Is(err, category.sentinel()) {
+			return category
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return ErrorCategoryTimeout
+	}
+	return fallback
+}
+
+// Error 实现 error 接口。
+func (e ScanError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Unwrap 返回分类哨兵与底层错误，供 errors.Is/errors.As 使用。
+func (e ScanError) Unwrap() []error {
+	unwrapped := make([]error, 0, 2)
+	if sentinel := e.Category.sentinel(); sentinel != nil {
+		unwrapped = append(unwrapped, sentinel)
+	}
+	switch {
+	case e.err != nil:
+		unwrapped = append(unwrapped, e.err)
+	case e.Errno != 0:
+		unwrapped = append(unwrapped, syscall.Errno(e.Errno))
+	}
+	return unwrapped
+}
@@ -2,17 +2,141 @@
 // 这些结构会被扫描器、输出层和命令层共同使用。
 package model
 
+import (
+	"sort"
+	"time"
+)
+
+// 逐行分类名称，用于 LineMetrics.LineClasses。
+const (
+	LineClassCode    = "code"
+	LineClassComment = "comment"
+	LineClassBlank   = "blank"
+	LineClassMixed   = "mixed"
+	// LineClassPreprocessor 表示 C/C++ 预处理指令行，以及按选项归入该分类的 Go 编译指令行与脚本 shebang 行。
+	LineClassPreprocessor = "preprocessor"
+	// LineClassString 表示只包含字符串字面量内容的行（需开启字符串行统计）。
+	LineClassString = "string"
+)
+
 // LineMetrics 表示一组行级统计值。
 //
 // 注意：
 // - Total 表示总行数（每行计 1）
 // - Code/Comment 可以在同一行同时 +1（例如: x := 1 // note）
 // - Blank 仅用于既不是代码也不是注释的空白行
+// - 行长度按 rune 计数且不含换行符；AvgLineLength 由 Characters/Total 推导
+// - Bytes 表示文件在磁盘上的字节大小，由扫描器通过 stat 填充
+// - ULOC 表示去重后的代码行数（按去除首尾空白后的内容去重），语言级/项目级为跨文件去重结果
+// - Preprocessor 为 C/C++ 预处理指令行数（开启对应选项时也包括 Go 的 //go:build 等编译指令行与脚本首行 shebang），这些行不计入 Code
+// - StringLiteral 仅在开启字符串行统计时填充，表示只包含字符串字面量内容的行，这些行不计入 Code
+// - Functions 仅在开启函数计数时填充，表示识别到的函数/方法定义数量
+// - Whitespace 仅在开启空白统计时填充，记录缩进风格、最大缩进宽度与行尾空白
+// - Embedded 为文件中按其他语言统计的嵌入代码（例如开启对应选项时 Go 文件的 cgo 前导代码），这些行不计入本对象的 Total 等字段；语言级汇总与全局总计会把它们计入各自的语言，LineMetrics.Add 不会合并
+// - LineClasses 仅在开启逐行标注时由分析器填充（按行号顺序，包括 Embedded 中的行），聚合时不会合并
+// - LineStates 仅在开启 FSM 状态跟踪时由分析器填充（与 LineClasses 一一对应），供 explain 命令使用，只存在于内存中，聚合时不会合并
+// - CodeLines 仅在开启重复代码检测时填充，只存在于内存中，聚合时不会合并
 type LineMetrics struct {
-	Total   int64 `json:"total"`
-	Code    int64 `json:"code"`
-	Comment int64 `json:"comment"`
-	Blank   int64 `json:"blank"`
+	Total         int64              `json:"total"`
+	Code          int64              `json:"code"`
+	Comment       int64              `json:"comment"`
+	Blank         int64              `json:"blank"`
+	ULOC          int64              `json:"uloc"`
+	Preprocessor  int64              `json:"preprocessor,omitempty"`
+	StringLiteral int64              `json:"string_literal,omitempty"`
+	Functions     int64              `json:"functions,omitempty"`
+	Bytes         int64              `json:"bytes"`
+	Characters    int64              `json:"characters"`
+	MaxLineLength int64              `json:"max_line_length"`
+	AvgLineLength float64            `json:"avg_line_length"`
+	Whitespace    *WhitespaceMetrics `json:"whitespace,omitempty"`
+	Embedded      []EmbeddedMetrics  `json:"embedded,omitempty"`
+	LineClasses   []string           `json:"line_classes,omitempty"`
+	LineStates    []LineState        `json:"-"`
+	CodeLines     []CodeLine         `json:"-"`
+
+	// uniqueLines 保存代码行内容哈希，仅存在于内存中，不参与序列化。
+	uniqueLines map[uint64]struct{}
+}
+
+// 缩进风格取值，用于 WhitespaceMetrics.IndentStyle。
+const (
+	IndentStyleNone   = "none"
+	IndentStyleTabs   = "tabs"
+	IndentStyleSpaces = "spaces"
+	IndentStyleMixed  = "mixed"
+)
+
+// WhitespaceMetrics 表示缩进与空白风格统计。
+//
+// 口径说明：
+// - 缩进行数按行首空白字符分类，空白行不计入
+// - MixedIndentedLines 表示同一行缩进中同时出现 tab 与空格
+// - MaxIndentWidth 为最大缩进宽度（列数，tab 按 4 列计）
+// - IndentStyle 由各类缩进行数推导：只有 tab 为 tabs，只有空格为 spaces，否则为 mixed
+type WhitespaceMetrics struct {
+	IndentStyle             string `json:"indent_style"`
+	TabIndentedLines        int64  `json:"tab_indented_lines"`
+	SpaceIndentedLines      int64  `json:"space_indented_lines"`
+	MixedIndentedLines      int64  `json:"mixed_indented_lines"`
+	MaxIndentWidth          int64  `json:"max_indent_width"`
+	TrailingWhitespaceLines int64  `json:"trailing_whitespace_lines"`
+}
+
+// Add 将另一个空白统计叠加到当前对象，并重新推导缩进风格。
+func (w *WhitespaceMetrics) Add(other WhitespaceMetrics) {
+	w.TabIndentedLines += other.TabIndentedLines
+	w.SpaceIndentedLines += other.SpaceIndentedLines
+	w.MixedIndentedLines += other.MixedIndentedLines
+	w.TrailingWhitespaceLines += other.TrailingWhitespaceLines
+	if other.MaxIndentWidth > w.MaxIndentWidth {
+		w.MaxIndentWidth = other.MaxIndentWidth
+	}
+	w.UpdateIndentStyle()
+}
+
+// UpdateIndentStyle 根据各类缩进行数推导缩进风格。
+func (w *WhitespaceMetrics) UpdateIndentStyle() {
+	switch {
+	case w.MixedIndentedLines > 0 || (w.TabIndentedLines > 0 && w.SpaceIndentedLines > 0):
+		w.IndentStyle = IndentStyleMixed
+	case w.TabIndentedLines > 0:
+		w.IndentStyle = IndentStyleTabs
+	case w.SpaceIndentedLines > 0:
+		w.IndentStyle = IndentStyleSpaces
+	default:
+		w.IndentStyle = IndentStyleNone
+	}
+}
+
+// EmbeddedMetrics 表示文件中按另一种语言统计的嵌入代码。
+type EmbeddedMetrics struct {
+	Language string      `json:"language"`
+	Metrics  LineMetrics `json:"metrics"`
+}
+
+// AddEmbedded 把一段嵌入代码的统计计入 Embedded，同一语言合并为一项。
+func (m *LineMetrics) AddEmbedded(language string, metrics LineMetrics) {
+	for index := range m.Embedded {
+		if m.Embedded[index].Language == language {
+			m.Embedded[index].Metrics.Add(metrics)
+			return
+		}
+	}
+	m.Embedded = append(m.Embedded, EmbeddedMetrics{Language: language, Metrics: metrics})
+}
+
+// LineState 记录一行开始与结束时分析器 FSM 所处的状态（如 code、block-comment、string），
+// 两者不同说明该行中的注释、字符串等结构延续到了下一行。
+type LineState struct {
+	Start string
+	End   string
+}
+
+// CodeLine 记录一行代码的行号（从 1 开始）与归一化内容哈希，供重复代码检测使用。
+type CodeLine struct {
+	Number int64
+	Hash   uint64
 }
 
 // Add 将另一个统计结果叠加到当前对象。
@@ -21,28 +145,159 @@ func (m *LineMetrics) Add(other LineMetrics) {
 	m.Code += other.Code
 	m.Comment += other.Comment
 	m.Blank += other.Blank
+	m.Bytes += other.Bytes
+	m.Preprocessor += other.Preprocessor
+	m.StringLiteral += other.StringLiteral
+	m.Functions += other.Functions
+	m.Characters += other.Characters
+	if other.MaxLineLength > m.MaxLineLength {
+		m.MaxLineLength = other.MaxLineLength
+	}
+	m.updateAvgLineLength()
+	m.addUniqueLines(other)
+	if other.Whitespace != nil {
+		if m.Whitespace == nil {
+			m.Whitespace = &WhitespaceMetrics{}
+		}
+		m.Whitespace.Add(*other.Whitespace)
+	}
+}
+
+// AddUniqueLine 记录一行代码内容的哈希，首次出现时 ULOC +1。
+func (m *LineMetrics) AddUniqueLine(hash uint64) {
+	if m.uniqueLines == nil {
+		m.uniqueLines = make(map[uint64]struct{})
+	}
+	if _, ok := m.uniqueLines[hash]; ok {
+		return
+	}
+	m.uniqueLines[hash] = struct{}{}
+	m.ULOC++
+}
+
+// UniqueLineHashes 返回代码行内容哈希集合（顺序不定），供需要持久化跨文件去重信息的场景使用；
+// 从 JSON 读回的结果没有哈希集合，返回 nil。
+func (m LineMetrics) UniqueLineHashes() []uint64 {
+	if m.uniqueLines == nil {
+		return nil
+	}
+	hashes := make([]uint64, 0, len(m.uniqueLines))
+	for hash := range m.uniqueLines {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// addUniqueLines 把另一个统计结果的代码行哈希并入当前集合。
+// 对于没有哈希集合的结果（例如从 JSON 反序列化而来），只能退化为直接累加 ULOC。
+func (m *LineMetrics) addUniqueLines(other LineMetrics) {
+	if other.uniqueLines == nil {
+		m.ULOC += other.ULOC
+		return
+	}
+	for hash := range other.uniqueLines {
+		m.AddUniqueLine(hash)
+	}
+}
+
+// AddLineLength 记录一行的长度，并同步刷新最大/平均行长度。
+// 调用方需要先累加 Total，保证平均值的分母已经包含当前行。
+func (m *LineMetrics) AddLineLength(length int64) {
+	m.Characters += length
+	if length > m.MaxLineLength {
+		m.MaxLineLength = length
+	}
+	m.updateAvgLineLength()
+}
+
+// updateAvgLineLength 根据字符总数与总行数重新计算平均行长度。
+func (m *LineMetrics) updateAvgLineLength() {
+	if m.Total == 0 {
+		m.AvgLineLength = 0
+		return
+	}
+	m.AvgLineLength = float64(m.Characters) / float64(m.Total)
 }
 
 // FileMetrics 表示单文件扫描结果。
+// Test 表示文件按语言约定被识别为测试代码。
+// Variant 为语言内的子类别（例如 C/C++ 的 Header/Implementation），没有子类别时为空。
+// Generated 表示文件被 .gitattributes 标记为 linguist-generated，汇总时计入 ScanResult.Generated 而不是语言统计与总计。
+// Git 仅在开启 git blame 补充信息且文件已被 git 跟踪时填充。
+// Shebang（解释器名）与 Executable 仅在开启脚本统计时填充。
 type FileMetrics struct {
-	Path     string      `json:"path"`
-	Language string      `json:"language"`
-	Metrics  LineMetrics `json:"metrics"`
+	Path       string      `json:"path"`
+	Language   string      `json:"language"`
+	Variant    string      `json:"variant,omitempty"`
+	Test       bool        `json:"test"`
+	Generated  bool        `json:"generated,omitempty"`
+	Metrics    LineMetrics `json:"metrics"`
+	Git        *GitMetrics `json:"git,omitempty"`
+	Shebang    string      `json:"shebang,omitempty"`
+	Executable bool        `json:"executable,omitempty"`
+	// Owners 为 CODEOWNERS 中该文件的所有者，未开启所有者统计或没有所有者时为空。
+	Owners []string `json:"owners,omitempty"`
+}
+
+// DiscoveredFile 表示发现阶段判定会被分析的文件（list-files 的输出），不包含任何统计信息。
+type DiscoveredFile struct {
+	Path     string `json:"path"`
+	Language string `json:"language"`
+}
+
+// GitMetrics 表示基于 git blame 的文件归属与新鲜度信息。
+type GitMetrics struct {
+	Authors      int       `json:"authors"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Ratios 表示由行数推导出的比例指标，统一在汇总阶段计算，避免各消费方口径不一致。
+//
+// 口径说明：
+// - CommentDensity = comment / code（无代码行时为 0）
+// - BlankRatio = blank / total（无任何行时为 0）
+// - CodeShare = 当前汇总的 code / 项目 code（项目总计固定为 1，无代码时为 0）
+type Ratios struct {
+	CommentDensity float64 `json:"comment_density"`
+	BlankRatio     float64 `json:"blank_ratio"`
+	CodeShare      float64 `json:"code_share"`
+}
+
+// NewRatios 根据汇总行数与项目代码总行数计算比例指标。
+func NewRatios(metrics LineMetrics, projectCode int64) Ratios {
+	return Ratios{
+		CommentDensity: safeRatio(metrics.Comment, metrics.Code),
+		BlankRatio:     safeRatio(metrics.Blank, metrics.Total),
+		CodeShare:      safeRatio(metrics.Code, projectCode),
+	}
+}
+
+// safeRatio 计算 numerator/denominator，分母为 0 时返回 0。
+func safeRatio(numerator int64, denominator int64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
 }
 
 // LanguageMetrics 表示某个语言的聚合结果。
+// Variants 为语言内子类别的拆分汇总（按名称排序），仅对声明了子类别的语言填充。
+// Distribution 为单文件总行数的分布，仅在开启分布统计时填充。
 type LanguageMetrics struct {
-	Language   string      `json:"language"`
-	Extensions []string    `json:"extensions"`
-	Files      int64       `json:"files"`
-	Metrics    LineMetrics `json:"metrics"`
+	Language     string            `json:"language"`
+	Extensions   []string          `json:"extensions"`
+	Files        int64             `json:"files"`
+	Metrics      LineMetrics       `json:"metrics"`
+	Ratios       Ratios            `json:"ratios"`
+	Variants     []VariantMetrics  `json:"variants,omitempty"`
+	Distribution *SizeDistribution `json:"distribution,omitempty"`
 }
 
-// ScanError 记录单文件扫描失败信息。
-// 设计为“错误不阻断全量扫描”，便于大仓库分析时容错。
-type ScanError struct {
-	Path  string `json:"path"`
-	Error string `json:"error"`
+// VariantMetrics 表示语言内某个子类别的聚合结果。
+type VariantMetrics struct {
+	Name    string      `json:"name"`
+	Files   int64       `json:"files"`
+	Metrics LineMetrics `json:"metrics"`
 }
 
 // TotalMetrics 表示项目级总计信息。
@@ -51,6 +306,7 @@ type ScanError struct {
 type TotalMetrics struct {
 	Files int64 `json:"files"`
 	LineMetrics
+	Ratios Ratios `json:"ratios"`
 }
 
 // AddFileMetrics 累加一个文件的统计值到项目总计中。
@@ -59,12 +315,216 @@ func (m *TotalMetrics) AddFileMetrics(other LineMetrics) {
 	m.LineMetrics.Add(other)
 }
 
+// UnownedOwner 是 OwnerMetrics 中没有所有者的文件的汇总名称。
+const UnownedOwner = "(unowned)"
+
+// OwnerMetrics 表示一个 CODEOWNERS 所有者（团队、用户或邮箱）名下文件的汇总。
+// 有多个所有者的文件计入每个所有者，因此各所有者之和可能大于总计；没有所有者的文件计入 UnownedOwner。
+// ULOC 为各文件 ULOC 之和（不做跨文件去重），嵌入代码计入行数但不增加文件数。
+type OwnerMetrics struct {
+	Owner   string      `json:"owner"`
+	Files   int64       `json:"files"`
+	Metrics LineMetrics `json:"metrics"`
+}
+
+// TestSplit 表示生产代码与测试代码的拆分统计。
+// TestToCodeRatio = 测试代码行 / 生产代码行（无生产代码时为 0）。
+type TestSplit struct {
+	Production      TotalMetrics `json:"production"`
+	Test            TotalMetrics `json:"test"`
+	TestToCodeRatio float64      `json:"test_to_code_ratio"`
+}
+
+// FileRanking 表示按行数排序的大文件榜单，便于快速定位重构候选。
+type FileRanking struct {
+	ByCode  []FileMetrics `json:"by_code"`
+	ByTotal []FileMetrics `json:"by_total"`
+}
+
+// SizeHistogramBounds 是文件大小直方图各分桶的上界（不含），最后一个分桶没有上界。
+var SizeHistogramBounds = []int64{50, 100, 250, 500, 1000, 2500}
+
+// HistogramBucket 表示文件大小直方图中的一个分桶，区间为 [Min, Max)。
+// 最后一个分桶没有上界，此时 Max 为 0 且在 JSON 中省略。
+type HistogramBucket struct {
+	Min   int64 `json:"min"`
+	Max   int64 `json:"max,omitempty"`
+	Files int64 `json:"files"`
+}
+
+// SizeDistribution 表示某个语言单文件总行数的分布。
+// 分位数采用最近秩法（nearest-rank），Buckets 与 SizeHistogramBounds 一一对应并多出一个无上界分桶。
+type SizeDistribution struct {
+	P50     int64             `json:"p50"`
+	P90     int64             `json:"p90"`
+	Max     int64             `json:"max"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+// NewSizeDistribution 根据每个文件的总行数构建分布统计，传入切片不会被修改。
+func NewSizeDistribution(lines []int64) SizeDistribution {
+	distribution := SizeDistribution{Buckets: make([]HistogramBucket, 0, len(SizeHistogramBounds)+1)}
+	lower := int64(0)
+	for _, upper := range SizeHistogramBounds {
+		distribution.Buckets = append(distribution.Buckets, HistogramBucket{Min: lower, Max: upper})
+		lower = upper
+	}
+	distribution.Buckets = append(distribution.Buckets, HistogramBucket{Min: lower})
+
+	if len(lines) == 0 {
+		return distribution
+	}
+
+	sorted := append([]int64(nil), lines...)
+	sort.Slice(sorted, func(i int, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	distribution.P50 = nearestRank(sorted, 50)
+	distribution.P90 = nearestRank(sorted, 90)
+	distribution.Max = sorted[len(sorted)-1]
+
+	for _, value := range sorted {
+		index := sort.Search(len(SizeHistogramBounds), func(i int) bool {
+			return value < SizeHistogramBounds[i]
+		})
+		distribution.Buckets[index].Files++
+	}
+	return distribution
+}
+
+// nearestRank 返回已升序排列数据的第 percentile 百分位数（最近秩法）。
+func nearestRank(sorted []int64, percentile int) int64 {
+	rank := (percentile*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// RankFiles 分别按代码行数与总行数返回排名前 n 的文件。
+// 行数相同时按路径排序，保证输出稳定。
+func RankFiles(files []FileMetrics, n int) FileRanking {
+	return FileRanking{
+		ByCode:  topFiles(files, n, func(item FileMetrics) int64 { return item.Metrics.Code }),
+		ByTotal: topFiles(files, n, func(item FileMetrics) int64 { return item.Metrics.Total }),
+	}
+}
+
+// topFiles 按 key 降序取前 n 个文件，不修改原切片顺序。
+func topFiles(files []FileMetrics, n int, key func(FileMetrics) int64) []FileMetrics {
+	sorted := append([]FileMetrics(nil), files...)
+	sort.SliceStable(sorted, func(i int, j int) bool {
+		left, right := key(sorted[i]), key(sorted[j])
+		if left != right {
+			return left > right
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// DuplicateRegion 表示某个文件中一段与其他位置重复的代码区域。
+// StartLine/EndLine 为物理行号（闭区间），Lines 为区域内的代码行数，
+// Occurrences 为区域首个滑动窗口在整个扫描范围内出现的次数。
+type DuplicateRegion struct {
+	Path        string `json:"path"`
+	StartLine   int64  `json:"start_line"`
+	EndLine     int64  `json:"end_line"`
+	Lines       int64  `json:"lines"`
+	Occurrences int64  `json:"occurrences"`
+}
+
+// DuplicationReport 是重复代码检测的汇总结果。
+type DuplicationReport struct {
+	WindowSize      int               `json:"window_size"`
+	Blocks          int64             `json:"blocks"`
+	DuplicatedLines int64             `json:"duplicated_lines"`
+	TopRegions      []DuplicateRegion `json:"top_regions"`
+}
+
+// InterpreterCount 表示某个 shebang 解释器对应的文件数。
+type InterpreterCount struct {
+	Interpreter string `json:"interpreter"`
+	Files       int64  `json:"files"`
+}
+
+// ScriptReport 表示可执行脚本盘点结果。
+//
+// 口径说明：
+// - ShebangFiles 为首行以 #! 开头的文件数
+// - ExecutableFiles 为带任一执行权限位的文件数
+// - ExecutableScripts 为同时带 shebang 与执行权限的文件数
+// - Interpreters 按文件数降序、同数量按名称升序排列
+type ScriptReport struct {
+	ShebangFiles      int64              `json:"shebang_files"`
+	ExecutableFiles   int64              `json:"executable_files"`
+	ExecutableScripts int64              `json:"executable_scripts"`
+	Interpreters      []InterpreterCount `json:"interpreters"`
+}
+
+// SchemaVersion 是当前 ScanResult 序列化格式的版本号。
+// 字段只做向后兼容的新增时版本不变；删除或改变字段语义时递增。
+const SchemaVersion = 1
+
 // ScanResult 是 scan 命令的完整输出模型。
 // 包含文件级明细、语言级汇总、全局总计和错误列表。
+// LargestFiles 仅在请求大文件榜单时填充，Duplication 仅在开启重复检测时填充，
+// Scripts 仅在开启脚本统计时填充。
+// SchemaVersion 在序列化时写入；旧版本 gocloc 导出的结果没有该字段（读回后为 0）。
+// SummaryOnly 表示扫描时没有保留文件级明细（Files 为空），只有汇总与错误列表。
+// Generated 为标记为 linguist-generated 的文件的汇总，这些文件不计入 Languages、Total 与 TestSplit，没有这类文件时为 nil。
 type ScanResult struct {
-	ScannedPath string            `json:"scanned_path"`
-	Files       []FileMetrics     `json:"files"`
-	Languages   []LanguageMetrics `json:"languages"`
-	Total       TotalMetrics      `json:"total"`
-	Errors      []ScanError       `json:"errors"`
+	SchemaVersion int                `json:"schema_version,omitempty"`
+	ScannedPath   string             `json:"scanned_path"`
+	Files         []FileMetrics      `json:"files"`
+	SummaryOnly   bool               `json:"summary_only,omitempty"`
+	Languages     []LanguageMetrics  `json:"languages"`
+	Total         TotalMetrics       `json:"total"`
+	TestSplit     TestSplit          `json:"test_split"`
+	Generated     *TotalMetrics      `json:"generated,omitempty"`
+	LargestFiles  *FileRanking       `json:"largest_files,omitempty"`
+	Duplication   *DuplicationReport `json:"duplication,omitempty"`
+	Scripts       *ScriptReport      `json:"scripts,omitempty"`
+	Errors        []ScanError        `json:"errors"`
+	// Skipped 为遍历时跳过、没有被打开的特殊文件（命名管道、套接字、设备等），没有时省略。
+	Skipped []SkippedFile `json:"skipped,omitempty"`
+	// Packages 为按构建清单划分的包汇总（见 PackageMetrics），未开启包统计时为 nil。
+	Packages []PackageMetrics `json:"packages,omitempty"`
+	// Owners 为按 CODEOWNERS 所有者的汇总（见 OwnerMetrics），未开启所有者统计时为 nil。
+	Owners []OwnerMetrics `json:"owners,omitempty"`
+	// Labels 为扫描时附加的标签（如 team=payments、env=ci），原样随结果导出，供下游按团队、环境等维度切分，没有时省略。
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// AddLabels 把 labels 合并到结果的标签中，同名键以 labels 为准。
+func (r *ScanResult) AddLabels(labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+	if r.Labels == nil {
+		r.Labels = make(map[string]string, len(labels))
+	}
+	for key, value := range labels {
+		r.Labels[key] = value
+	}
+}
+
+// LabelPairs 返回按键排序的 "key=value" 标签列表，没有标签时返回 nil。
+func LabelPairs(labels map[string]string) []string {
+	if len(labels) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return pairs
 }
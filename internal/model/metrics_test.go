@@ -0,0 +1,44 @@
+package model
+
+import "testing"
+
+// TestRankFiles 验证排名按行数降序、行数相同时按路径升序，按 n 截断且不修改原切片顺序。
+func TestRankFiles(t *testing.T) {
+	files := []FileMetrics{
+		{Path: "c.go", Metrics: LineMetrics{Total: 20, Code: 10}},
+		{Path: "b.go", Metrics: LineMetrics{Total: 30, Code: 10}},
+		{Path: "d.go", Metrics: LineMetrics{Total: 20, Code: 15}},
+		{Path: "a.go", Metrics: LineMetrics{Total: 20, Code: 10}},
+	}
+	paths := func(items []FileMetrics) string {
+		names := ""
+		for _, item := range items {
+			names += item.Path + " "
+		}
+		return names
+	}
+
+	ranking := RankFiles(files, 10)
+	if got := paths(ranking.ByCode); got != "d.go a.go b.go c.go " {
+		t.Fatalf("by code = %q", got)
+	}
+	if got := paths(ranking.ByTotal); got != "b.go a.go c.go d.go " {
+		t.Fatalf("by total = %q", got)
+	}
+	if got := paths(files); got != "c.go b.go d.go a.go " {
+		t.Fatalf("input reordered: %q", got)
+	}
+
+	ranking = RankFiles(files, 2)
+	if got := paths(ranking.ByCode); got != "d.go a.go " {
+		t.Fatalf("truncated by code = %q", got)
+	}
+	if got := paths(ranking.ByTotal); got != "b.go a.go " {
+		t.Fatalf("truncated by total = %q", got)
+	}
+
+	ranking = RankFiles(files, 0)
+	if len(ranking.ByCode) != 0 || len(ranking.ByTotal) != 0 {
+		t.Fatalf("expected empty ranking for n=0: %+v", ranking)
+	}
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"path"
+	"sort"
+)
+
+// PackageMetrics 是一个包的汇总。包以构建清单（go.mod、package.json 等）所在目录为边界，
+// 文件归入路径上最近的包；不属于任何包的文件归入根目录（Path 为 "."，Manifests 为空）。
+type PackageMetrics struct {
+	// Path 为包目录相对扫描根目录、以 / 分隔的路径，根目录为 "."。
+	Path string `json:"path"`
+	// Manifests 为包目录下发现的构建清单文件名，按名称排序。
+	Manifests []string          `json:"manifests,omitempty"`
+	Files     int64             `json:"files"`
+	Metrics   LineMetrics       `json:"metrics"`
+	Languages []PackageLanguage `json:"languages"`
+}
+
+// PackageLanguage 是包内某个语言的文件数与代码行数，嵌入代码计入代码行但不增加文件数。
+type PackageLanguage struct {
+	Language string `json:"language"`
+	Files    int64  `json:"files"`
+	Code     int64  `json:"code"`
+}
+
+// PackageAggregator 按目录累加文件统计，结束时再按包边界归并，因此包边界可以晚于其中的文件被发现，
+// 内存占用只与目录数量相关。ULOC 为各文件 ULOC 之和（不做跨文件去重）；linguist-generated 的文件与总计一样不计入。
+// 单个 PackageAggregator 不能并发使用。
+type PackageAggregator struct {
+	boundaries  map[string][]string
+	directories map[string]*PackageMetrics
+}
+
+// NewPackageAggregator 创建空的包累加器。
+func NewPackageAggregator() *PackageAggregator {
+	return &PackageAggregator{
+		boundaries:  make(map[string][]string),
+		directories: make(map[string]*PackageMetrics),
+	}
+}
+
+// AddBoundary 把目录（相对扫描根目录、以 / 分隔，根目录为 "."）登记为包边界，manifests 为其中的构建清单文件名。
+func (a *PackageAggregator) AddBoundary(dir string, manifests ...string) {
+	existing := a.boundaries[dir]
+next:
+	for _, manifest := range manifests {
+		for _, name := range existing {
+			if name == manifest {
+				continue next
+			}
+		}
+		existing = append(existing, manifest)
+	}
+	sort.Strings(existing)
+	a.boundaries[dir] = existing
+}
+
+// Add 累加一个文件的统计。
+func (a *PackageAggregator) Add(item FileMetrics) {
+	if item.Generated {
+		return
+	}
+	metrics := a.directory(path.Dir(item.Path))
+	metrics.Files++
+	metrics.Metrics.Add(countsOnly(item.Metrics))
+	metrics.addLanguage(PackageLanguage{Language: item.Language, Files: 1, Code: item.Metrics.Code})
+	for _, embedded := range item.Metrics.Embedded {
+		metrics.Metrics.Add(countsOnly(embedded.Metrics))
+		metrics.addLanguage(PackageLanguage{Language: embedded.Language, Code: embedded.Metrics.Code})
+	}
+}
+
+// directory 返回目录的累加结果，不存在时创建。
+func (a *PackageAggregator) directory(dir string) *PackageMetrics {
+	metrics, ok := a.directories[dir]
+	if !ok {
+		metrics = &PackageMetrics{Path: dir}
+		a.directories[dir] = metrics
+	}
+	return metrics
+}
+
+// Packages 按包边界归并各目录的统计，返回按路径排序的包列表；没有文件的包边界同样列出。
+func (a *PackageAggregator) Packages() []PackageMetrics {
+	packages := make(map[string]*PackageMetrics, len(a.boundaries)+1)
+	for dir, manifests := range a.boundaries {
+		packages[dir] = &PackageMetrics{Path: dir, Manifests: manifests}
+	}
+	for dir, metrics := range a.directories {
+		owner := "."
+		for candidate := dir; candidate != "." && candidate != "/" && candidate != ""; candidate = path.Dir(candidate) {
+			if _, ok := a.boundaries[candidate]; ok {
+				owner = candidate
+				break
+			}
+		}
+		target, ok := packages[owner]
+		if !ok {
+			target = &PackageMetrics{Path: owner}
+			packages[owner] = target
+		}
+		target.Files += metrics.Files
+		target.Metrics.Add(metrics.Metrics)
+		for _, language := range metrics.Languages {
+			target.addLanguage(language)
+		}
+	}
+
+	result := make([]PackageMetrics, 0, len(packages))
+	for _, item := range packages {
+		if item.Languages == nil {
+			item.Languages = make([]PackageLanguage, 0)
+		}
+		sort.Slice(item.Languages, func(i int, j int) bool {
+			left, right := item.Languages[i], item.Languages[j]
+			if left.Code != right.Code {
+				return left.Code > right.Code
+			}
+			return left.Language < right.Language
+		})
+		result = append(result, *item)
+	}
+	sort.Slice(result, func(i int, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// addLanguage 把语言统计累加到包中。
+func (m *PackageMetrics) addLanguage(language PackageLanguage) {
+	for index := range m.Languages {
+		if m.Languages[index].Language == language.Language {
+			m.Languages[index].Files += language.Files
+			m.Languages[index].Code += language.Code
+			return
+		}
+	}
+	m.Languages = append(m.Languages, language)
+}
+
+// countsOnly 返回只保留计数的副本：代码行哈希、逐行分类与嵌入代码明细不进入包与所有者汇总，避免重复占用内存。
+func countsOnly(metrics LineMetrics) LineMetrics {
+	metrics.uniqueLines = nil
+	metrics.CodeLines = nil
+	metrics.LineClasses = nil
+	metrics.LineStates = nil
+	metrics.Embedded = nil
+	metrics.Whitespace = nil
+	return metrics
+}
+
+// mergePackages 合并两个结果的包汇总，供 ScanResult.Merge 使用；两边都没有包汇总时返回 nil。
+// 有文件明细时以两边的包目录为边界、按合并后的文件重新归并，只有汇总时同一路径的包直接相加。
+func mergePackages(left []PackageMetrics, right []PackageMetrics, files []FileMetrics, summaryOnly bool) []PackageMetrics {
+	if left == nil && right == nil {
+		return nil
+	}
+	aggregator := NewPackageAggregator()
+	for _, item := range append(append([]PackageMetrics(nil), left...), right...) {
+		aggregator.AddBoundary(item.Path, item.Manifests...)
+		if summaryOnly {
+			metrics := aggregator.directory(item.Path)
+			metrics.Files += item.Files
+			metrics.Metrics.Add(countsOnly(item.Metrics))
+			for _, language := range item.Languages {
+				metrics.addLanguage(language)
+			}
+		}
+	}
+	if !summaryOnly {
+		for _, item := range files {
+			aggregator.Add(item)
+		}
+	}
+	return aggregator.Packages()
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestPackageAggregator 验证文件归入最近的包、包边界晚于文件登记时同样生效、不属于任何包的文件归入根目录，
+// 以及合并时按两边的包边界重新归并。
+func TestPackageAggregator(t *testing.T) {
+	files := []FileMetrics{
+		{Path: "README.go", Language: "Go", Metrics: LineMetrics{Total: 2, Code: 1, Blank: 1}},
+		{Path: "services/api/main.go", Language: "Go", Metrics: LineMetrics{Total: 10, Code: 8, Comment: 2}},
+		{Path: "services/api/internal/db.go", Language: "Go", Metrics: LineMetrics{Total: 5, Code: 5}},
+		{Path: "web/src/app.ts", Language: "TypeScript", Metrics: LineMetrics{Total: 4, Code: 4, Embedded: []EmbeddedMetrics{{Language: "CSS", Metrics: LineMetrics{Total: 2, Code: 2}}}}},
+		{Path: "web/dist/bundle.js", Language: "JavaScript", Generated: true, Metrics: LineMetrics{Total: 100, Code: 100}},
+	}
+	aggregator := NewPackageAggregator()
+	for _, item := range files {
+		aggregator.Add(item)
+	}
+	aggregator.AddBoundary("services/api", "go.mod")
+	aggregator.AddBoundary("web", "package.json")
+	aggregator.AddBoundary("tools", "setup.py")
+
+	packages := aggregator.Packages()
+	summary := make([][3]any, 0, len(packages))
+	for _, item := range packages {
+		summary = append(summary, [3]any{item.Path, item.Files, item.Metrics.Code})
+	}
+	expected := [][3]any{{".", int64(1), int64(1)}, {"services/api", int64(2), int64(13)}, {"tools", int64(0), int64(0)}, {"web", int64(1), int64(6)}}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("expected packages %v, got %v", expected, summary)
+	}
+	web := packages[3]
+	if !reflect.DeepEqual(web.Manifests, []string{"package.json"}) || !reflect.DeepEqual(web.Languages, []PackageLanguage{{Language: "TypeScript", Files: 1, Code: 4}, {Language: "CSS", Code: 2}}) {
+		t.Fatalf("unexpected web package: %+v", web)
+	}
+
+	left := ScanResult{Files: files[:3], Packages: packages[:2]}
+	left.Merge(ScanResult{Files: files[3:4], Packages: []PackageMetrics{{Path: "web", Manifests: []string{"package.json"}, Files: 1}}})
+	if len(left.Packages) != 3 || left.Packages[2].Path != "web" || left.Packages[2].Metrics.Code != 6 || left.Packages[1].Files != 2 {
+		t.Fatalf("unexpected merged packages: %+v", left.Packages)
+	}
+}
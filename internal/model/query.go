@@ -0,0 +1,100 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/glob"
+)
+
+// Filter 返回只包含满足条件文件的子结果，语言汇总、总计、测试拆分等按保留的文件重新计算。
+// keepError 为 nil 时丢弃全部错误记录。
+//
+// 派生报告的处理与 Merge 一致：原结果带有分布、脚本统计或大文件榜单时一并重新计算（榜单长度不变）；
+// 重复检测依赖扫描期的代码行哈希，无法在子结果上重算，因此被清空。
+// 子结果的 ULOC 为保留文件的 ULOC 之和，不做跨文件去重。
+func (r ScanResult) Filter(keep func(FileMetrics) bool, keepError func(ScanError) bool) ScanResult {
+	extensions := make(map[string][]string, len(r.Languages))
+	distribution := false
+	for _, item := range r.Languages {
+		extensions[item.Language] = item.Extensions
+		if item.Distribution != nil {
+			distribution = true
+		}
+	}
+
+	sub := ScanResult{
+		SchemaVersion: r.SchemaVersion,
+		ScannedPath:   r.ScannedPath,
+		Files:         make([]FileMetrics, 0),
+		Errors:        make([]ScanError, 0),
+	}
+	sub.AddLabels(r.Labels)
+	for _, item := range r.Files {
+		if keep(item) {
+			sub.Files = append(sub.Files, item)
+		}
+	}
+	if keepError != nil {
+		for _, item := range r.Errors {
+			if keepError(item) {
+				sub.Errors = append(sub.Errors, item)
+			}
+		}
+	}
+
+	sub.Summarize(SummaryOptions{
+		Extensions:       func(language string) []string { return extensions[language] },
+		SizeDistribution: distribution,
+		Owners:           r.Owners != nil,
+	})
+	if r.Scripts != nil {
+		report := NewScriptReport(sub.Files)
+		sub.Scripts = &report
+	}
+	if top := rankingSize(r.LargestFiles); top > 0 {
+		ranking := RankFiles(sub.Files, top)
+		sub.LargestFiles = &ranking
+	}
+	return sub
+}
+
+// FilterByLanguage 返回只包含指定语言（不区分大小写）文件的子结果，错误记录无法归属语言，因此被丢弃。
+func (r ScanResult) FilterByLanguage(languages ...string) ScanResult {
+	return r.Filter(func(item FileMetrics) bool {
+		for _, language := range languages {
+			if strings.EqualFold(item.Language, strings.TrimSpace(language)) {
+				return true
+			}
+		}
+		return false
+	}, nil)
+}
+
+// FilterByGlob 返回路径匹配 pattern 的子结果（文件与错误均按路径过滤），通配规则见 glob.Match，
+// 例如 "src/**"、"**/*_test.go"。
+func (r ScanResult) FilterByGlob(pattern string) (ScanResult, error) {
+	if err := glob.Validate(pattern); err != nil {
+		return ScanResult{}, err
+	}
+
+	matches := func(path string) bool {
+		ok, _ := glob.Match(pattern, path)
+		return ok
+	}
+	return r.Filter(
+		func(item FileMetrics) bool { return matches(item.Path) },
+		func(item ScanError) bool { return matches(item.Path) },
+	), nil
+}
+
+// TopFiles 返回只包含代码行数最多的前 n 个文件的子结果（代码行相同时按路径排序），错误记录被丢弃。
+func (r ScanResult) TopFiles(n int) ScanResult {
+	if n < 0 {
+		n = 0
+	}
+	keep := make(map[string]bool, n)
+	for _, item := range topFiles(r.Files, n, func(item FileMetrics) int64 { return item.Metrics.Code }) {
+		keep[item.Path] = true
+	}
+	return r.Filter(func(item FileMetrics) bool { return keep[item.Path] }, nil)
+}
@@ -0,0 +1,63 @@
+package model
+
+import "testing"
+
+// queryFixture 构造一个包含两种语言与一条错误记录的扫描结果。
+func queryFixture() ScanResult {
+	result := ScanResult{
+		ScannedPath: "repo",
+		Files: []FileMetrics{
+			{Path: "src/main.go", Language: "Go", Metrics: LineMetrics{Total: 10, Code: 8, Blank: 2}},
+			{Path: "src/util/util.go", Language: "Go", Metrics: LineMetrics{Total: 30, Code: 20, Comment: 10}},
+			{Path: "scripts/build.py", Language: "Python", Metrics: LineMetrics{Total: 5, Code: 5}},
+		},
+		Errors: []ScanError{{Path: "src/broken.go", Message: "read failed"}},
+	}
+	result.Summarize(SummaryOptions{Extensions: func(language string) []string { return []string{"." + language} }})
+	ranking := RankFiles(result.Files, 2)
+	result.LargestFiles = &ranking
+	return result
+}
+
+// TestFilterByLanguage 验证按语言过滤后汇总重新计算且保留后缀信息。
+func TestFilterByLanguage(t *testing.T) {
+	sub := queryFixture().FilterByLanguage("go")
+
+	if sub.Total.Files != 2 || sub.Total.Code != 28 || len(sub.Languages) != 1 {
+		t.Fatalf("unexpected sub result: %+v", sub.Total)
+	}
+	if sub.Languages[0].Extensions[0] != ".Go" || sub.Languages[0].Ratios.CodeShare != 1 {
+		t.Fatalf("unexpected language summary: %+v", sub.Languages[0])
+	}
+	if len(sub.Errors) != 0 || sub.LargestFiles == nil || len(sub.LargestFiles.ByCode) != 2 {
+		t.Fatalf("unexpected derived reports: errors=%v ranking=%v", sub.Errors, sub.LargestFiles)
+	}
+}
+
+// TestFilterByGlob 验证 glob 过滤同时作用于文件与错误记录。
+func TestFilterByGlob(t *testing.T) {
+	sub, err := queryFixture().FilterByGlob("src/**")
+	if err != nil {
+		t.Fatalf("filter failed: %v", err)
+	}
+	if sub.Total.Files != 2 || len(sub.Errors) != 1 || sub.Errors[0].Path != "src/broken.go" {
+		t.Fatalf("unexpected sub result: files=%d errors=%v", sub.Total.Files, sub.Errors)
+	}
+
+	if _, err := queryFixture().FilterByGlob("src/[a"); err == nil {
+		t.Fatalf("expected bad pattern error")
+	}
+}
+
+// TestTopFiles 验证 TopFiles 只保留代码行最多的文件，原结果不受影响。
+func TestTopFiles(t *testing.T) {
+	result := queryFixture()
+	sub := result.TopFiles(1)
+
+	if len(sub.Files) != 1 || sub.Files[0].Path != "src/util/util.go" || sub.Total.Code != 20 {
+		t.Fatalf("unexpected top files: %+v", sub.Files)
+	}
+	if result.Total.Files != 3 {
+		t.Fatalf("original result must not change: %+v", result.Total)
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+// SkipReason 是遍历时跳过非普通文件的原因。
+type SkipReason string
+
+const (
+	// SkipReasonNamedPipe 表示命名管道（FIFO），打开后读取会一直阻塞到有写入方为止。
+	SkipReasonNamedPipe SkipReason = "named_pipe"
+	// SkipReasonSocket 表示 Unix 域套接字。
+	SkipReasonSocket SkipReason = "socket"
+	// SkipReasonDevice 表示块设备或字符设备，读取可能阻塞或永不结束（如 /dev/zero）。
+	SkipReasonDevice SkipReason = "device"
+	// SkipReasonIrregular 表示其他无法识别类型的非普通文件。
+	SkipReasonIrregular SkipReason = "irregular"
+)
+
+// SkippedFile 是遍历时跳过、没有被打开的非普通文件，路径规则与 FileMetrics.Path 相同。
+type SkippedFile struct {
+	Path   string     `json:"path"`
+	Reason SkipReason `json:"reason"`
+}
@@ -0,0 +1,444 @@
+package model
+
+import (
+	"sort"
+)
+
+// SummaryOptions 控制 ScanResult.Summarize 的汇总行为。
+type SummaryOptions struct {
+	// Extensions 返回语言对应的后缀列表，为 nil 时语言汇总的 Extensions 为空。
+	Extensions func(language string) []string
+	// SizeDistribution 为每个语言计算单文件行数分布。
+	SizeDistribution bool
+	// Owners 按 FileMetrics.Owners 计算所有者汇总（ScanResult.Owners），为 false 时 Owners 为 nil。
+	Owners bool
+}
+
+// Summarize 根据 Files 重新计算语言级汇总、全局总计与测试拆分，并把文件与错误按路径排序。
+// Duplication、Scripts、LargestFiles 等派生报告不在此处计算。
+func (r *ScanResult) Summarize(options SummaryOptions) {
+	r.SortByPath()
+	aggregator := NewSummaryAggregator(options)
+	for _, item := range r.Files {
+		aggregator.Add(item)
+	}
+	aggregator.Apply(r)
+}
+
+// SortByPath 把文件、错误与跳过的特殊文件按路径排序。
+func (r *ScanResult) SortByPath() {
+	sort.Slice(r.Files, func(i int, j int) bool {
+		return r.Files[i].Path < r.Files[j].Path
+	})
+
+	sort.Slice(r.Errors, func(i int, j int) bool {
+		return r.Errors[i].Path < r.Errors[j].Path
+	})
+	sort.Slice(r.Skipped, func(i int, j int) bool {
+		return r.Skipped[i].Path < r.Skipped[j].Path
+	})
+}
+
+// SummaryAggregator 逐个累加文件统计，得到与 Summarize 相同的语言级汇总、全局总计与测试拆分。
+// 扫描器用它边分析边累加，不必在分析结束后再遍历全部 FileMetrics。
+// 单个 SummaryAggregator 不能并发使用；并发场景为每个 goroutine 创建一个，结束后用 Merge 合并。
+type SummaryAggregator struct {
+	options    SummaryOptions
+	byLanguage map[string]*LanguageMetrics
+	// fileLines 为开启分布统计时各语言的单文件总行数，每个文件只占一个 int64。
+	fileLines map[string][]int64
+	total     TotalMetrics
+	testSplit TestSplit
+	generated TotalMetrics
+	owners    map[string]*OwnerMetrics
+}
+
+// NewSummaryAggregator 创建空的汇总累加器。
+func NewSummaryAggregator(options SummaryOptions) *SummaryAggregator {
+	return &SummaryAggregator{
+		options:    options,
+		byLanguage: make(map[string]*LanguageMetrics),
+		fileLines:  make(map[string][]int64),
+		owners:     make(map[string]*OwnerMetrics),
+	}
+}
+
+// Add 累加一个文件的统计。
+func (a *SummaryAggregator) Add(item FileMetrics) {
+	if item.Generated {
+		a.generated.AddFileMetrics(item.Metrics)
+		return
+	}
+	a.total.AddFileMetrics(item.Metrics)
+	if item.Test {
+		a.testSplit.Test.AddFileMetrics(item.Metrics)
+	} else {
+		a.testSplit.Production.AddFileMetrics(item.Metrics)
+	}
+
+	summary := a.language(item.Language)
+	summary.Files++
+	summary.Metrics.Add(item.Metrics)
+	if a.options.SizeDistribution {
+		a.fileLines[item.Language] = append(a.fileLines[item.Language], item.Metrics.Total)
+	}
+
+	if item.Variant != "" {
+		addVariantMetrics(summary, VariantMetrics{Name: item.Variant, Files: 1, Metrics: item.Metrics})
+	}
+
+	// 嵌入代码计入所属语言与全局总计，但不增加文件数。
+	for _, embedded := range item.Metrics.Embedded {
+		a.total.LineMetrics.Add(embedded.Metrics)
+		if item.Test {
+			a.testSplit.Test.LineMetrics.Add(embedded.Metrics)
+		} else {
+			a.testSplit.Production.LineMetrics.Add(embedded.Metrics)
+		}
+		a.language(embedded.Language).Metrics.Add(embedded.Metrics)
+	}
+
+	if a.options.Owners {
+		owners := item.Owners
+		if len(owners) == 0 {
+			owners = []string{UnownedOwner}
+		}
+		for _, name := range owners {
+			owner := a.owner(name)
+			owner.Files++
+			owner.Metrics.Add(countsOnly(item.Metrics))
+			for _, embedded := range item.Metrics.Embedded {
+				owner.Metrics.Add(countsOnly(embedded.Metrics))
+			}
+		}
+	}
+}
+
+// Merge 把另一个累加器（选项相同）的部分汇总合并进来，结果与把两边的文件依次 Add 到同一个累加器相同；
+// other 此后不应再使用。
+func (a *SummaryAggregator) Merge(other *SummaryAggregator) {
+	for name, item := range other.byLanguage {
+		summary := a.language(name)
+		summary.Files += item.Files
+		summary.Metrics.Add(item.Metrics)
+		for _, variant := range item.Variants {
+			addVariantMetrics(summary, variant)
+		}
+	}
+	for name, lines := range other.fileLines {
+		a.fileLines[name] = append(a.fileLines[name], lines...)
+	}
+	addTotalMetrics(&a.total, other.total)
+	addTotalMetrics(&a.testSplit.Production, other.testSplit.Production)
+	addTotalMetrics(&a.testSplit.Test, other.testSplit.Test)
+	addTotalMetrics(&a.generated, other.generated)
+	for name, item := range other.owners {
+		owner := a.owner(name)
+		owner.Files += item.Files
+		owner.Metrics.Add(item.Metrics)
+	}
+}
+
+// addSummaries 累加另一份结果的语言级汇总、全局总计与测试拆分，用于合并只有汇总的结果。
+func (a *SummaryAggregator) addSummaries(result ScanResult) {
+	for _, item := range result.Languages {
+		summary := a.language(item.Language)
+		summary.Extensions = mergeExtensions(summary.Extensions, item.Extensions)
+		summary.Files += item.Files
+		summary.Metrics.Add(item.Metrics)
+		for _, variant := range item.Variants {
+			addVariantMetrics(summary, variant)
+		}
+	}
+	addTotalMetrics(&a.total, result.Total)
+	addTotalMetrics(&a.testSplit.Production, result.TestSplit.Production)
+	addTotalMetrics(&a.testSplit.Test, result.TestSplit.Test)
+	if result.Generated != nil {
+		addTotalMetrics(&a.generated, *result.Generated)
+	}
+	for _, item := range result.Owners {
+		owner := a.owner(item.Owner)
+		owner.Files += item.Files
+		owner.Metrics.Add(countsOnly(item.Metrics))
+	}
+}
+
+// owner 返回所有者的汇总记录，不存在时创建。
+func (a *SummaryAggregator) owner(name string) *OwnerMetrics {
+	owner, ok := a.owners[name]
+	if !ok {
+		owner = &OwnerMetrics{Owner: name}
+		a.owners[name] = owner
+	}
+	return owner
+}
+
+// language 返回语言的汇总记录，不存在时创建。
+func (a *SummaryAggregator) language(language string) *LanguageMetrics {
+	summary, ok := a.byLanguage[language]
+	if !ok {
+		summary = &LanguageMetrics{Language: language}
+		if a.options.Extensions != nil {
+			summary.Extensions = a.options.Extensions(language)
+		}
+		a.byLanguage[language] = summary
+	}
+	return summary
+}
+
+// Apply 计算比例与分布，把累加结果写入 r 的 Languages、Total、TestSplit、Generated 与 Owners，文件与错误明细不变。
+func (a *SummaryAggregator) Apply(r *ScanResult) {
+	r.Total = a.total
+	r.TestSplit = a.testSplit
+	r.Total.Ratios = NewRatios(r.Total.LineMetrics, r.Total.Code)
+	r.TestSplit.Production.Ratios = NewRatios(r.TestSplit.Production.LineMetrics, r.Total.Code)
+	r.TestSplit.Test.Ratios = NewRatios(r.TestSplit.Test.LineMetrics, r.Total.Code)
+	if r.TestSplit.Production.Code > 0 {
+		r.TestSplit.TestToCodeRatio = float64(r.TestSplit.Test.Code) / float64(r.TestSplit.Production.Code)
+	}
+	r.Generated = nil
+	if a.generated.Files > 0 {
+		generated := a.generated
+		generated.Ratios = NewRatios(generated.LineMetrics, r.Total.Code)
+		r.Generated = &generated
+	}
+
+	r.Languages = make([]LanguageMetrics, 0, len(a.byLanguage))
+	for _, item := range a.byLanguage {
+		item.Ratios = NewRatios(item.Metrics, r.Total.Code)
+		if a.options.SizeDistribution {
+			distribution := NewSizeDistribution(a.fileLines[item.Language])
+			item.Distribution = &distribution
+		}
+		r.Languages = append(r.Languages, *item)
+	}
+
+	sort.Slice(r.Languages, func(i int, j int) bool {
+		return r.Languages[i].Language < r.Languages[j].Language
+	})
+
+	// 所有者按代码行数从多到少排列，便于直接得到“按所有者的代码量”排行。
+	r.Owners = nil
+	if a.options.Owners {
+		r.Owners = make([]OwnerMetrics, 0, len(a.owners))
+		for _, item := range a.owners {
+			r.Owners = append(r.Owners, *item)
+		}
+		sort.Slice(r.Owners, func(i int, j int) bool {
+			if r.Owners[i].Metrics.Code != r.Owners[j].Metrics.Code {
+				return r.Owners[i].Metrics.Code > r.Owners[j].Metrics.Code
+			}
+			return r.Owners[i].Owner < r.Owners[j].Owner
+		})
+	}
+}
+
+// addTotalMetrics 把另一份总计累加到 total 中，比例由 Apply 重新计算。
+func addTotalMetrics(total *TotalMetrics, other TotalMetrics) {
+	total.Files += other.Files
+	total.LineMetrics.Add(other.LineMetrics)
+}
+
+// addVariantMetrics 把子类别统计累加到所属语言的子类别汇总中，并保持子类别按名称排序。
+func addVariantMetrics(summary *LanguageMetrics, other VariantMetrics) {
+	for index := range summary.Variants {
+		if summary.Variants[index].Name == other.Name {
+			summary.Variants[index].Files += other.Files
+			summary.Variants[index].Metrics.Add(other.Metrics)
+			return
+		}
+	}
+
+	variant := VariantMetrics{Name: other.Name, Files: other.Files}
+	variant.Metrics.Add(other.Metrics)
+	summary.Variants = append(summary.Variants, variant)
+	sort.Slice(summary.Variants, func(i int, j int) bool {
+		return summary.Variants[i].Name < summary.Variants[j].Name
+	})
+}
+
+// NewScriptReport 汇总所有文件的 shebang 与可执行权限信息。
+func NewScriptReport(files []FileMetrics) ScriptReport {
+	report := ScriptReport{Interpreters: make([]InterpreterCount, 0)}
+	byInterpreter := make(map[string]int64)
+
+	for _, item := range files {
+		if item.Shebang != "" {
+			report.ShebangFiles++
+			byInterpreter[item.Shebang]++
+		}
+		if item.Executable {
+			report.ExecutableFiles++
+			if item.Shebang != "" {
+				report.ExecutableScripts++
+			}
+		}
+	}
+
+	for interpreter, count := range byInterpreter {
+		report.Interpreters = append(report.Interpreters, InterpreterCount{
+			Interpreter: interpreter,
+			Files:       count,
+		})
+	}
+	sort.Slice(report.Interpreters, func(i int, j int) bool {
+		left := report.Interpreters[i]
+		right := report.Interpreters[j]
+		if left.Files != right.Files {
+			return left.Files > right.Files
+		}
+		return left.Interpreter < right.Interpreter
+	})
+	return report
+}
+
+// Merge 把另一个扫描结果合并到当前结果，用于分片扫描与多根目录汇总。
+//
+// 合并规则：
+// - 文件按路径去重，同一路径以 other 中的记录为准；错误同样按路径去重，且已成功统计的路径不再保留错误
+// - 跳过的特殊文件按路径去重，同一路径以 other 中的记录为准
+// - 任一方带有所有者汇总时按合并后文件的 Owners 重新计算；只有汇总时同一所有者直接相加
+// - 任一方带有包汇总时，以两边的包目录为边界按合并后的文件重新归并；只有汇总时同一路径的包直接相加
+// - 语言汇总、总计、测试拆分按合并后的文件重新计算；任一方带有分布或脚本统计时一并重新计算
+// - 大文件榜单按两者中较大的榜单长度重新计算
+// - 重复检测依赖扫描期的代码行哈希，无法在合并时重算，因此被清空
+// - 从 JSON 读回的结果没有跨文件去重信息，此时语言级与总计的 ULOC 退化为文件 ULOC 之和
+// - ScannedPath 不同时以 ", " 连接
+// - 标签只保留两边取值相同的部分，避免合并后的结果带有只属于一方的团队、环境等标签；当前结果为空（ScannedPath 为空）时取 other 的标签
+// - 任一方只有汇总（SummaryOnly）时，汇总直接相加，结果同样只有汇总；同一文件出现在两边时会被重复计入，
+// 分布、脚本统计与大文件榜单无法由汇总得到，因此被清空
+func (r *ScanResult) Merge(other ScanResult) {
+	extensions := make(map[string][]string)
+	distribution := false
+	for _, languages := range [][]LanguageMetrics{r.Languages, other.Languages} {
+		for _, item := range languages {
+			extensions[item.Language] = mergeExtensions(extensions[item.Language], item.Extensions)
+			if item.Distribution != nil {
+				distribution = true
+			}
+		}
+	}
+
+	files := make(map[string]FileMetrics, len(r.Files)+len(other.Files))
+	for _, item := range r.Files {
+		files[item.Path] = item
+	}
+	for _, item := range other.Files {
+		files[item.Path] = item
+	}
+	r.Files = make([]FileMetrics, 0, len(files))
+	for _, item := range files {
+		r.Files = append(r.Files, item)
+	}
+
+	scanErrors := make(map[string]ScanError, len(r.Errors)+len(other.Errors))
+	for _, item := range append(append([]ScanError(nil), r.Errors...), other.Errors...) {
+		if _, ok := files[item.Path]; ok {
+			continue
+		}
+		scanErrors[item.Path] = item
+	}
+	r.Errors = make([]ScanError, 0, len(scanErrors))
+	for _, item := range scanErrors {
+		r.Errors = append(r.Errors, item)
+	}
+
+	skipped := make(map[string]SkippedFile, len(r.Skipped)+len(other.Skipped))
+	for _, item := range append(append([]SkippedFile(nil), r.Skipped...), other.Skipped...) {
+		skipped[item.Path] = item
+	}
+	r.Skipped = nil
+	for _, item := range skipped {
+		r.Skipped = append(r.Skipped, item)
+	}
+	sort.Slice(r.Skipped, func(i int, j int) bool {
+		return r.Skipped[i].Path < r.Skipped[j].Path
+	})
+
+	if r.ScannedPath == "" {
+		r.Labels = nil
+		r.AddLabels(other.Labels)
+	} else {
+		for key, value := range r.Labels {
+			if actual, ok := other.Labels[key]; !ok || actual != value {
+				delete(r.Labels, key)
+			}
+		}
+		if len(r.Labels) == 0 {
+			r.Labels = nil
+		}
+	}
+	switch {
+	case r.ScannedPath == "":
+		r.ScannedPath = other.ScannedPath
+	case other.ScannedPath != "" && other.ScannedPath != r.ScannedPath:
+		r.ScannedPath += ", " + other.ScannedPath
+	}
+
+	packages := mergePackages(r.Packages, other.Packages, r.Files, r.SummaryOnly || other.SummaryOnly)
+	r.Packages = packages
+	owners := r.Owners != nil || other.Owners != nil
+
+	if r.SummaryOnly || other.SummaryOnly {
+		aggregator := NewSummaryAggregator(SummaryOptions{Owners: owners})
+		aggregator.addSummaries(*r)
+		aggregator.addSummaries(other)
+		aggregator.Apply(r)
+		r.Files = make([]FileMetrics, 0)
+		r.SummaryOnly = true
+		sort.Slice(r.Errors, func(i int, j int) bool {
+			return r.Errors[i].Path < r.Errors[j].Path
+		})
+		r.Scripts = nil
+		r.LargestFiles = nil
+		r.Duplication = nil
+		return
+	}
+
+	r.Summarize(SummaryOptions{
+		Extensions:       func(language string) []string { return extensions[language] },
+		SizeDistribution: distribution,
+		Owners:           owners,
+	})
+
+	if r.Scripts != nil || other.Scripts != nil {
+		report := NewScriptReport(r.Files)
+		r.Scripts = &report
+	}
+	top := rankingSize(r.LargestFiles)
+	if size := rankingSize(other.LargestFiles); size > top {
+		top = size
+	}
+	if top > 0 {
+		ranking := RankFiles(r.Files, top)
+		r.LargestFiles = &ranking
+	}
+	r.Duplication = nil
+}
+
+// mergeExtensions 合并两组后缀并去重排序。
+func mergeExtensions(left []string, right []string) []string {
+	seen := make(map[string]bool, len(left)+len(right))
+	merged := make([]string, 0, len(left)+len(right))
+	for _, ext := range append(append([]string(nil), left...), right...) {
+		if seen[ext] {
+			continue
+		}
+		seen[ext] = true
+		merged = append(merged, ext)
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// rankingSize 返回大文件榜单的长度，没有榜单时为 0。
+func rankingSize(ranking *FileRanking) int {
+	if ranking == nil {
+		return 0
+	}
+	size := len(ranking.ByCode)
+	if len(ranking.ByTotal) > size {
+		size = len(ranking.ByTotal)
+	}
+	return size
+}
@@ -0,0 +1,124 @@
+package model
+
+import "testing"
+
+// TestSummarizeEmbedded 验证嵌入代码计入所属语言与全局总计，但不增加该语言的文件数。
+func TestSummarizeEmbedded(t *testing.T) {
+	host := LineMetrics{Total: 10, Code: 8, Blank: 2}
+	host.AddEmbedded("C/C++", LineMetrics{Total: 3, Code: 2, Preprocessor: 1})
+	host.AddEmbedded("C/C++", LineMetrics{Total: 1, Code: 1})
+	result := ScanResult{Files: []FileMetrics{
+		{Path: "wrap.go", Language: "Go", Metrics: host},
+		{Path: "foo.c", Language: "C/C++", Metrics: LineMetrics{Total: 5, Code: 5}},
+	}}
+
+	result.Summarize(SummaryOptions{})
+	if len(host.Embedded) != 1 || host.Embedded[0].Metrics.Total != 4 {
+		t.Fatalf("expected embedded metrics merged by language: %+v", host.Embedded)
+	}
+	if result.Total.Files != 2 || result.Total.Total != 19 || result.Total.Code != 16 || result.TestSplit.Production.Total != 19 {
+		t.Fatalf("unexpected total: %+v", result.Total)
+	}
+	if len(result.Languages) != 2 || result.Languages[0].Language != "C/C++" || result.Languages[0].Files != 1 || result.Languages[0].Metrics.Total != 9 || result.Languages[0].Metrics.Preprocessor != 1 {
+		t.Fatalf("unexpected C/C++ summary: %+v", result.Languages)
+	}
+	if result.Languages[1].Metrics.Total != 10 {
+		t.Fatalf("unexpected Go summary: %+v", result.Languages[1])
+	}
+}
+
+// TestSummarizeVariants 验证子类别按名称汇总到所属语言下，且在累加器合并与只有汇总的结果合并时按名称累加。
+func TestSummarizeVariants(t *testing.T) {
+	files := []FileMetrics{
+		{Path: "lib.h", Language: "C/C++", Variant: "Header", Metrics: LineMetrics{Total: 5, Code: 4, Comment: 1}},
+		{Path: "lib.c", Language: "C/C++", Variant: "Implementation", Metrics: LineMetrics{Total: 12, Code: 8, Comment: 4}},
+		{Path: "util.h", Language: "C/C++", Variant: "Header", Metrics: LineMetrics{Total: 3, Code: 2, Blank: 1}},
+		{Path: "main.go", Language: "Go", Metrics: LineMetrics{Total: 9, Code: 8, Blank: 1}},
+	}
+	check := func(name string, languages []LanguageMetrics, scale int64) {
+		t.Helper()
+		if len(languages) != 2 || len(languages[1].Variants) != 0 {
+			t.Fatalf("%s: unexpected languages: %+v", name, languages)
+		}
+		variants := languages[0].Variants
+		if len(variants) != 2 || variants[0].Name != "Header" || variants[1].Name != "Implementation" {
+			t.Fatalf("%s: unexpected variants: %+v", name, variants)
+		}
+		if variants[0].Files != 2*scale || variants[0].Metrics.Total != 8*scale || variants[0].Metrics.Code != 6*scale ||
+			variants[1].Files != scale || variants[1].Metrics.Comment != 4*scale {
+			t.Fatalf("%s: unexpected variant metrics: %+v", name, variants)
+		}
+	}
+
+	result := ScanResult{Files: files}
+	result.Summarize(SummaryOptions{})
+	check("summarize", result.Languages, 1)
+
+	left, right := NewSummaryAggregator(SummaryOptions{}), NewSummaryAggregator(SummaryOptions{})
+	for index, item := range files {
+		if index%2 == 0 {
+			left.Add(item)
+		} else {
+			right.Add(item)
+		}
+	}
+	left.Merge(right)
+	var merged ScanResult
+	left.Apply(&merged)
+	check("aggregator merge", merged.Languages, 1)
+
+	partial := ScanResult{Files: files}
+	partial.Summarize(SummaryOptions{})
+	partial.Files, partial.SummaryOnly = nil, true
+	summaryOnly := partial
+	summaryOnly.Languages = append([]LanguageMetrics(nil), partial.Languages...)
+	summaryOnly.Languages[0].Variants = append([]VariantMetrics(nil), partial.Languages[0].Variants...)
+	summaryOnly.Merge(partial)
+	check("summary-only merge", summaryOnly.Languages, 2)
+}
+
+// TestSummarizeGenerated 验证生成文件计入 Generated 而不计入语言汇总与总计，只有汇总的结果合并时 Generated 相加。
+func TestSummarizeGenerated(t *testing.T) {
+	result := ScanResult{Files: []FileMetrics{
+		{Path: "api.pb.go", Language: "Go", Generated: true, Metrics: LineMetrics{Total: 100, Code: 90, Blank: 10}},
+		{Path: "main.go", Language: "Go", Metrics: LineMetrics{Total: 10, Code: 10}},
+	}}
+
+	result.Summarize(SummaryOptions{})
+	if result.Total.Files != 1 || result.Total.Code != 10 || len(result.Languages) != 1 || result.Languages[0].Files != 1 {
+		t.Fatalf("expected generated file excluded from totals: %+v, %+v", result.Total, result.Languages)
+	}
+	if result.Generated == nil || result.Generated.Files != 1 || result.Generated.Code != 90 || result.Generated.Ratios.CodeShare != 9 {
+		t.Fatalf("unexpected generated summary: %+v", result.Generated)
+	}
+
+	other := ScanResult{SummaryOnly: true, Generated: &TotalMetrics{Files: 2, LineMetrics: LineMetrics{Total: 5, Code: 5}}}
+	result.Merge(other)
+	if result.Generated == nil || result.Generated.Files != 3 || result.Generated.Code != 95 {
+		t.Fatalf("unexpected merged generated summary: %+v", result.Generated)
+	}
+}
+
+// TestMergeLabels 验证合并时只保留两边取值相同的标签，空结果取 other 的标签，过滤得到的子结果保留标签副本。
+func TestMergeLabels(t *testing.T) {
+	var merged ScanResult
+	merged.Merge(ScanResult{ScannedPath: "api", Labels: map[string]string{"org": "acme", "team": "payments", "env": "ci"}})
+	if len(merged.Labels) != 3 {
+		t.Fatalf("expected labels of the first result, got %v", merged.Labels)
+	}
+	merged.Merge(ScanResult{ScannedPath: "web", Labels: map[string]string{"org": "acme", "team": "frontend"}})
+	if len(merged.Labels) != 1 || merged.Labels["org"] != "acme" {
+		t.Fatalf("expected only common labels, got %v", merged.Labels)
+	}
+	merged.Merge(ScanResult{ScannedPath: "cli"})
+	if merged.Labels != nil {
+		t.Fatalf("expected no labels, got %v", merged.Labels)
+	}
+
+	result := ScanResult{Labels: map[string]string{"team": "payments"}}
+	sub := result.Filter(func(FileMetrics) bool { return true }, nil)
+	sub.AddLabels(map[string]string{"team": "core", "env": "ci"})
+	if result.Labels["team"] != "payments" || sub.Labels["team"] != "core" || sub.Labels["env"] != "ci" {
+		t.Fatalf("unexpected labels: %v, %v", result.Labels, sub.Labels)
+	}
+}
@@ -0,0 +1,194 @@
+package oci
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 层中的 whiteout 标记（OCI image spec 的 layer changesets）：.wh.<name> 删除下层的 name，
+// 目录中的 .wh..wh..opq 表示清空该目录在下层的全部内容。
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// ApplyLayer 把一个未压缩的层 tar 应用到 dir，结果与容器运行时合并各层后的文件系统一致（只包含普通文件与目录）：
+//   - whiteout 删除下层中的文件或目录，不透明目录清空下层的内容
+//   - 符号链接、设备与命名管道不会被创建，避免扫描时跟随链接读到 dir 之外的文件
+//   - 硬链接按其目标的内容复制
+//   - 权限位只保留可执行位，文件与目录总是可读，以便扫描与清理
+//
+// 条目路径会被规范化，试图写到 dir 之外的条目返回错误。
+func ApplyLayer(reader io.Reader, dir string) error {
+	archive := tar.NewReader(reader)
+	// written 为本层写入的路径及其全部上级目录；不透明 whiteout 只清空下层的内容，保留本层已写入的条目。
+	written := make(map[string]bool)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read layer: %w", err)
+		}
+		name, err := layerPath(header.Name)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+		directory, base := path.Split(name)
+		directory = strings.TrimSuffix(directory, "/")
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch {
+		case base == opaqueWhiteout:
+			if err := clearOpaque(filepath.Join(dir, filepath.FromSlash(directory)), directory, written); err != nil {
+				return err
+			}
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			hidden, err := whiteoutTarget(directory, base)
+			if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(filepath.Join(dir, filepath.FromSlash(hidden))); err != nil {
+				return fmt.Errorf("apply whiteout %s: %w", name, err)
+			}
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+				if err := os.Remove(target); err != nil {
+					return fmt.Errorf("replace %s: %w", name, err)
+				}
+			}
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return fmt.Errorf("create directory %s: %w", name, err)
+			}
+		case tar.TypeReg:
+			if err := writeLayerFile(target, archive, header.FileInfo().Mode()); err != nil {
+				return fmt.Errorf("extract %s: %w", name, err)
+			}
+		case tar.TypeLink:
+			linked, err := layerPath(header.Linkname)
+			if err != nil {
+				return err
+			}
+			source, err := os.Open(filepath.Join(dir, filepath.FromSlash(linked)))
+			if err != nil {
+				// 目标不是普通文件（例如被跳过的符号链接）时跳过硬链接。
+				continue
+			}
+			info, statErr := source.Stat()
+			if statErr != nil || !info.Mode().IsRegular() {
+				_ = source.Close()
+				continue
+			}
+			err = writeLayerFile(target, source, info.Mode())
+			_ = source.Close()
+			if err != nil {
+				return fmt.Errorf("extract %s: %w", name, err)
+			}
+		default:
+			// 符号链接与特殊文件不创建，但仍然覆盖下层的同名条目。
+			if err := os.RemoveAll(target); err != nil {
+				return fmt.Errorf("replace %s: %w", name, err)
+			}
+			continue
+		}
+		for current := name; current != "." && current != ""; current = path.Dir(current) {
+			written[current] = true
+		}
+	}
+}
+
+// layerPath 把 tar 条目名规范化为以 / 分隔的相对路径，根目录返回空字符串，指向根目录之外时返回错误。
+func layerPath(name string) (string, error) {
+	cleaned := path.Clean(strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/"))
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("layer entry %q escapes the image root", name)
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// whiteoutTarget 返回 whiteout 条目（directory 下的 base）要删除的路径。被删除的名称必须是 directory 中的单个条目：
+// 空名称、.、.. 或包含分隔符的名称（例如 .wh... 会指向上级目录）返回错误，规范化后的路径同样不能是根目录或根目录之外。
+func whiteoutTarget(directory string, base string) (string, error) {
+	hidden := strings.TrimPrefix(base, whiteoutPrefix)
+	if hidden == "" || hidden == "." || hidden == ".." || strings.ContainsAny(hidden, "/\\") {
+		return "", fmt.Errorf("layer whiteout %q has an invalid target", path.Join(directory, base))
+	}
+	target, err := layerPath(path.Join(directory, hidden))
+	if err != nil {
+		return "", err
+	}
+	if target == "" {
+		return "", fmt.Errorf("layer whiteout %q has an invalid target", path.Join(directory, base))
+	}
+	return target, nil
+}
+
+// writeLayerFile 把内容写入 target，替换已有的文件或目录并创建缺少的上级目录。
+func writeLayerFile(target string, content io.Reader, mode os.FileMode) error {
+	if info, err := os.Lstat(target); err == nil && info.IsDir() {
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	perm := os.FileMode(0o644)
+	if mode&0o111 != 0 {
+		perm = 0o755
+	}
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// clearOpaque 删除 directory 下本层没有写入的全部条目，relative 为其相对镜像根目录的路径。
+func clearOpaque(directory string, relative string, written map[string]bool) error {
+	entries, err := os.ReadDir(directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("apply opaque whiteout %s: %w", relative, err)
+	}
+	for _, entry := range entries {
+		child := path.Join(relative, entry.Name())
+		target := filepath.Join(directory, entry.Name())
+		if !written[child] {
+			if err := os.RemoveAll(target); err != nil {
+				return fmt.Errorf("apply opaque whiteout %s: %w", relative, err)
+			}
+			continue
+		}
+		if entry.IsDir() {
+			if err := clearOpaque(target, child, written); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
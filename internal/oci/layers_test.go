@@ -0,0 +1,140 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// layerEntry 描述测试层中的一个条目，Link 非空时为符号链接（Hard 为 true 时为硬链接）。
+type layerEntry struct {
+	Name    string
+	Content string
+	Dir     bool
+	Link    string
+	Hard    bool
+}
+
+// buildLayer 按顺序把条目写成未压缩的层 tar。
+func buildLayer(t *testing.T, entries ...layerEntry) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.Name, Mode: 0o644, Typeflag: tar.TypeReg, Size: int64(len(entry.Content))}
+		switch {
+		case entry.Dir:
+			header.Typeflag, header.Mode, header.Size = tar.TypeDir, 0o755, 0
+		case entry.Hard:
+			header.Typeflag, header.Linkname, header.Size = tar.TypeLink, entry.Link, 0
+		case entry.Link != "":
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, entry.Link, 0
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Size > 0 {
+			if _, err := writer.Write([]byte(entry.Content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// listFiles 返回 dir 下全部普通文件的 路径=内容，按路径排序。
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relative, _ := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(relative)+"="+string(content))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// TestApplyLayer 验证多层合并：上层覆盖下层，whiteout 删除文件，不透明目录只保留本层内容，
+// 符号链接不被创建，硬链接复制内容。
+func TestApplyLayer(t *testing.T) {
+	dir := t.TempDir()
+	lower := buildLayer(t,
+		layerEntry{Name: "app/", Dir: true},
+		layerEntry{Name: "app/main.go", Content: "v1"},
+		layerEntry{Name: "app/old.py", Content: "old"},
+		layerEntry{Name: "/etc/config/a.conf", Content: "a"},
+		layerEntry{Name: "./srv/web/index.js", Content: "js"},
+	)
+	upper := buildLayer(t,
+		layerEntry{Name: "app/main.go", Content: "v2"},
+		layerEntry{Name: "app/.wh.old.py"},
+		layerEntry{Name: "srv/web/new.ts", Content: "ts"},
+		layerEntry{Name: "srv/web/.wh..wh..opq"},
+		layerEntry{Name: "etc/passwd-link", Link: "/etc/passwd"},
+		layerEntry{Name: "app/copy.go", Link: "app/main.go", Hard: true},
+	)
+	for _, layer := range [][]byte{lower, upper} {
+		if err := ApplyLayer(bytes.NewReader(layer), dir); err != nil {
+			t.Fatalf("apply failed: %v", err)
+		}
+	}
+
+	expected := []string{"app/copy.go=v2", "app/main.go=v2", "etc/config/a.conf=a", "srv/web/new.ts=ts"}
+	if files := listFiles(t, dir); strings.Join(files, " ") != strings.Join(expected, " ") {
+		t.Fatalf("unexpected files: %v", files)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "etc", "passwd-link")); !os.IsNotExist(err) {
+		t.Fatalf("expected symlink to be skipped, got %v", err)
+	}
+
+	escape := buildLayer(t, layerEntry{Name: "../outside.go", Content: "x"})
+	if err := ApplyLayer(bytes.NewReader(escape), dir); err == nil {
+		t.Fatal("expected error for entry outside the image root")
+	}
+}
+
+// TestApplyLayerWhiteoutEscape 验证指向上级目录的 whiteout（.wh... 会去掉前缀得到 ..）被拒绝，
+// 不会删除解压目录之外或整个解压目录的内容。
+func TestApplyLayerWhiteoutEscape(t *testing.T) {
+	for _, name := range []string{".wh...", "sub/.wh...", "sub/.wh..", ".wh."} {
+		parent := t.TempDir()
+		dir := filepath.Join(parent, "root")
+		sentinel := filepath.Join(parent, "sentinel.txt")
+		if err := os.WriteFile(sentinel, []byte("keep"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		layer := buildLayer(t, layerEntry{Name: "sub/main.go", Content: "x"}, layerEntry{Name: "keep.go", Content: "y"})
+		if err := ApplyLayer(bytes.NewReader(layer), dir); err != nil {
+			t.Fatalf("apply failed: %v", err)
+		}
+
+		whiteout := buildLayer(t, layerEntry{Name: name})
+		if err := ApplyLayer(bytes.NewReader(whiteout), dir); err == nil {
+			t.Fatalf("expected error for whiteout %q", name)
+		}
+		if _, err := os.Stat(sentinel); err != nil {
+			t.Fatalf("whiteout %q removed a file outside the extraction dir: %v", name, err)
+		}
+		if files := listFiles(t, dir); strings.Join(files, " ") != "keep.go=y sub/main.go=x" {
+			t.Fatalf("whiteout %q changed the extraction dir: %v", name, files)
+		}
+	}
+}
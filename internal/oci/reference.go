@@ -0,0 +1,112 @@
+// Package oci 从镜像仓库（OCI Distribution / Docker Registry HTTP API V2）拉取镜像，
+// 按顺序应用各层得到合并后的文件系统，供 scan --image 统计镜像中打包的源码。
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// 与 Docker 一致的默认仓库：省略仓库地址的镜像来自 Docker Hub，单段名称位于 library/ 下。
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+var (
+	// repositoryPattern 为仓库路径的合法字符（小写字母、数字与分隔符 . _ - /）。
+	repositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagPattern        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	digestPattern     = regexp.MustCompile(`^[a-z0-9]+(?:[+._-][a-z0-9]+)*:[A-Za-z0-9=_-]+$`)
+)
+
+// Reference 是解析后的镜像引用，例如 ghcr.io/org/app:1.0 或 alpine@sha256:...。
+type Reference struct {
+	// Registry 为仓库 API 的主机（可带端口），Docker Hub 为 registry-1.docker.io。
+	Registry string
+	// Repository 为仓库路径，Docker Hub 的官方镜像补全为 library/<name>。
+	Repository string
+	// Tag 与 Digest 至少有一个；两者都有时按 Digest 拉取。
+	Tag    string
+	Digest string
+}
+
+// ParseReference 按 Docker 的规则解析镜像引用：第一段包含 . 或 :、或者为 localhost 时视为仓库地址，
+// 否则镜像来自 Docker Hub；没有标签与摘要时使用 latest。
+func ParseReference(value string) (Reference, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Reference{}, errors.New("empty image reference")
+	}
+	var reference Reference
+	name := value
+	if before, digest, ok := strings.Cut(name, "@"); ok {
+		if !digestPattern.MatchString(digest) {
+			return Reference{}, fmt.Errorf("invalid digest in image reference %q", value)
+		}
+		name, reference.Digest = before, digest
+	}
+	// 标签是最后一个 / 之后的 : 部分，仓库地址中的端口不算标签。
+	if index := strings.LastIndex(name, ":"); index > strings.LastIndex(name, "/") {
+		name, reference.Tag = name[:index], name[index+1:]
+		if !tagPattern.MatchString(reference.Tag) {
+			return Reference{}, fmt.Errorf("invalid tag in image reference %q", value)
+		}
+	}
+
+	domain, repository, ok := strings.Cut(name, "/")
+	if !ok || (!strings.ContainsAny(domain, ".:") && domain != "localhost") {
+		domain, repository = dockerHubDomain, name
+	}
+	if domain == dockerHubDomain || domain == "index.docker.io" {
+		domain = dockerHubRegistry
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	}
+	if !repositoryPattern.MatchString(repository) {
+		return Reference{}, fmt.Errorf("invalid repository in image reference %q", value)
+	}
+	reference.Registry, reference.Repository = domain, repository
+	if reference.Tag == "" && reference.Digest == "" {
+		reference.Tag = defaultTag
+	}
+	return reference, nil
+}
+
+// String 返回完整的引用（仓库地址/仓库路径:标签@摘要）。
+func (r Reference) String() string {
+	value := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		value += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		value += "@" + r.Digest
+	}
+	return value
+}
+
+// manifestReference 返回拉取清单时使用的标签或摘要，摘要优先。
+func (r Reference) manifestReference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// baseURL 返回仓库 API 的基础地址：本机回环地址上的仓库（本地调试常用的 registry:2）使用 http，其余使用 https。
+func (r Reference) baseURL() string {
+	host := r.Registry
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.Trim(host, "[]")
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return "http://" + r.Registry
+	}
+	return "https://" + r.Registry
+}
@@ -0,0 +1,377 @@
+package oci
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+)
+
+// 清单与层的媒体类型，同时支持 OCI 与 Docker schema 2。
+const (
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// maxManifestBytes 是清单与令牌响应的大小上限，防止异常响应占满内存。
+const maxManifestBytes = 4 << 20
+
+// DefaultPlatform 返回默认拉取的平台：linux 与当前进程的 CPU 架构，与 docker pull 一致。
+func DefaultPlatform() string {
+	return "linux/" + runtime.GOARCH
+}
+
+// Client 从镜像仓库拉取镜像，零值使用 http.DefaultClient 匿名拉取默认平台。
+type Client struct {
+	// HTTP 为发送请求的客户端，为 nil 时使用 http.DefaultClient。
+	HTTP *http.Client
+	// Username 与 Password 用于需要认证的仓库：Bearer 认证时向令牌服务换取令牌，Basic 认证时直接发送。
+	Username string
+	Password string
+	// Platform 为多平台镜像中选择的平台（os/arch 或 os/arch/variant），为空时使用 DefaultPlatform。
+	Platform string
+}
+
+// Image 描述拉取并展开的镜像。
+type Image struct {
+	Reference Reference
+	// Digest 为实际展开的镜像清单摘要（多平台镜像为所选平台的清单）。
+	Digest string
+	// Platform 为所选平台，单平台镜像为空。
+	Platform string
+	Layers   int
+	// Bytes 为下载的层（压缩后）总大小。
+	Bytes int64
+}
+
+// descriptor 是清单中引用的内容描述。
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	Platform  *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant"`
+	} `json:"platform,omitempty"`
+}
+
+// manifest 同时容纳镜像清单（Layers）与多平台索引（Manifests）。
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Manifests []descriptor `json:"manifests"`
+	Layers    []descriptor `json:"layers"`
+}
+
+// Pull 拉取镜像并把各层按顺序应用到 dir（见 ApplyLayer），dir 应为空目录。
+// 多平台镜像按 Platform 选择清单；层按 sha256 摘要校验，zstd 等不支持的压缩格式返回错误。
+func (c *Client) Pull(ctx context.Context, reference Reference, dir string) (Image, error) {
+	session := &registrySession{client: c, reference: reference}
+	image := Image{Reference: reference}
+
+	current, digest, err := session.manifest(ctx, reference.manifestReference())
+	if err != nil {
+		return image, err
+	}
+	if len(current.Manifests) > 0 {
+		platform := c.Platform
+		if platform == "" {
+			platform = DefaultPlatform()
+		}
+		selected, err := selectPlatform(current.Manifests, platform)
+		if err != nil {
+			return image, fmt.Errorf("%s: %w", reference, err)
+		}
+		if current, digest, err = session.manifest(ctx, selected.Digest); err != nil {
+			return image, err
+		}
+		image.Platform = platform
+	}
+	if len(current.Layers) == 0 {
+		return image, fmt.Errorf("%s: image manifest has no layers", reference)
+	}
+	image.Digest = digest
+
+	for _, layer := range current.Layers {
+		if err := session.applyBlob(ctx, layer, dir); err != nil {
+			return image, err
+		}
+		image.Layers++
+		image.Bytes += layer.Size
+	}
+	return image, nil
+}
+
+// selectPlatform 从多平台索引中选择 os/arch（可带 /variant）匹配的清单；未指定 variant 时接受任意 variant。
+func selectPlatform(manifests []descriptor, platform string) (descriptor, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return descriptor{}, fmt.Errorf("invalid platform %q, expected OS/ARCH[/VARIANT]", platform)
+	}
+	available := make([]string, 0, len(manifests))
+	for _, item := range manifests {
+		if item.Platform == nil {
+			continue
+		}
+		name := item.Platform.OS + "/" + item.Platform.Architecture
+		if item.Platform.Variant != "" {
+			name += "/" + item.Platform.Variant
+		}
+		if item.Platform.OS == parts[0] && item.Platform.Architecture == parts[1] &&
+			(len(parts) == 2 || item.Platform.Variant == parts[2]) {
+			return item, nil
+		}
+		// attestation 清单的平台为 unknown/unknown，不属于可选平台。
+		if item.Platform.OS != "unknown" {
+			available = append(available, name)
+		}
+	}
+	return descriptor{}, fmt.Errorf("no image for platform %s, available: %s", platform, strings.Join(available, ", "))
+}
+
+// registrySession 在一次拉取中复用认证结果。
+type registrySession struct {
+	client    *Client
+	reference Reference
+	// authorization 为认证成功后附加到后续请求的 Authorization 头。
+	authorization string
+}
+
+// manifest 拉取并解析清单，返回清单与按内容计算的 sha256 摘要；按摘要拉取时校验内容与摘要一致。
+func (s *registrySession) manifest(ctx context.Context, reference string) (manifest, string, error) {
+	endpoint := fmt.Sprintf("%s/v2/%s/manifests/%s", s.reference.baseURL(), s.reference.Repository, reference)
+	response, err := s.get(ctx, endpoint, strings.Join([]string{mediaTypeOCIIndex, mediaTypeOCIManifest, mediaTypeDockerList, mediaTypeDockerManifest}, ", "))
+	if err != nil {
+		return manifest{}, "", err
+	}
+	defer response.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxManifestBytes))
+	if err != nil {
+		return manifest{}, "", fmt.Errorf("read manifest %s: %w", reference, err)
+	}
+
+	var parsed manifest
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		return manifest{}, "", fmt.Errorf("parse manifest %s: %w", reference, err)
+	}
+	mediaType := parsed.MediaType
+	if mediaType == "" {
+		mediaType, _, _ = strings.Cut(response.Header.Get("Content-Type"), ";")
+	}
+	switch strings.TrimSpace(mediaType) {
+	case mediaTypeOCIIndex, mediaTypeDockerList, mediaTypeOCIManifest, mediaTypeDockerManifest, "":
+	default:
+		return manifest{}, "", fmt.Errorf("unsupported manifest media type %q for %s", mediaType, s.reference)
+	}
+
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if strings.HasPrefix(reference, "sha256:") && reference != digest {
+		return manifest{}, "", fmt.Errorf("manifest digest mismatch for %s: got %s", reference, digest)
+	}
+	return parsed, digest, nil
+}
+
+// applyBlob 下载一层并应用到 dir，下载内容按描述中的 sha256 摘要校验。
+func (s *registrySession) applyBlob(ctx context.Context, layer descriptor, dir string) error {
+	compression := ""
+	switch {
+	case strings.HasSuffix(layer.MediaType, "gzip"):
+		compression = "gzip"
+	case strings.HasSuffix(layer.MediaType, "zstd"):
+		return fmt.Errorf("layer %s: zstd compressed layers are not supported", layer.Digest)
+	case strings.HasSuffix(layer.MediaType, ".tar") || layer.MediaType == "":
+	default:
+		return fmt.Errorf("layer %s: unsupported media type %q", layer.Digest, layer.MediaType)
+	}
+	algorithm, expected, ok := strings.Cut(layer.Digest, ":")
+	if !ok || algorithm != "sha256" {
+		return fmt.Errorf("layer %s: unsupported digest algorithm", layer.Digest)
+	}
+
+	endpoint := fmt.Sprintf("%s/v2/%s/blobs/%s", s.reference.baseURL(), s.reference.Repository, layer.Digest)
+	response, err := s.get(ctx, endpoint, "")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	verified := &digestReader{reader: response.Body, hash: sha256.New()}
+	var content io.Reader = verified
+	if compression == "gzip" {
+		decompressed, err := gzip.NewReader(verified)
+		if err != nil {
+			return fmt.Errorf("layer %s: %w", layer.Digest, err)
+		}
+		defer decompressed.Close()
+		content = decompressed
+	}
+	if err := ApplyLayer(content, dir); err != nil {
+		return fmt.Errorf("layer %s: %w", layer.Digest, err)
+	}
+	// tar 结束标记之后可能还有填充，读完剩余内容才能校验摘要。
+	if _, err := io.Copy(io.Discard, verified); err != nil {
+		return fmt.Errorf("layer %s: %w", layer.Digest, err)
+	}
+	if actual := hex.EncodeToString(verified.hash.Sum(nil)); actual != expected {
+		return fmt.Errorf("layer %s: digest mismatch, got sha256:%s", layer.Digest, actual)
+	}
+	return nil
+}
+
+// get 发送 GET 请求，收到 401 时按 WWW-Authenticate 完成认证后重试一次；返回的响应状态总是 200。
+func (s *registrySession) get(ctx context.Context, endpoint string, accept string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("build registry request: %w", err)
+		}
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		if s.authorization != "" {
+			request.Header.Set("Authorization", s.authorization)
+		}
+		response, err := s.httpClient().Do(request)
+		if err != nil {
+			return nil, fmt.Errorf("registry request: %w", err)
+		}
+		if response.StatusCode == http.StatusOK {
+			return response, nil
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxManifestBytes))
+		_ = response.Body.Close()
+		if response.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			if err := s.authenticate(ctx, response.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, fmt.Errorf("registry GET %s: %s", endpoint, response.Status)
+	}
+}
+
+// authenticate 按质询完成认证：Basic 直接使用凭证，Bearer 向 realm 申请仓库的 pull 令牌（没有凭证时匿名申请）。
+func (s *registrySession) authenticate(ctx context.Context, challenge string) error {
+	scheme, parameters := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if s.client.Username == "" {
+			return fmt.Errorf("registry %s requires credentials", s.reference.Registry)
+		}
+		request := &http.Request{Header: make(http.Header)}
+		request.SetBasicAuth(s.client.Username, s.client.Password)
+		s.authorization = request.Header.Get("Authorization")
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("registry %s: unsupported authentication challenge %q", s.reference.Registry, challenge)
+	}
+
+	realm, err := url.Parse(parameters["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("registry %s: invalid token realm %q", s.reference.Registry, parameters["realm"])
+	}
+	query := realm.Query()
+	if service := parameters["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := parameters["scope"]
+	if scope == "" {
+		scope = "repository:" + s.reference.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return fmt.Errorf("build token request: %w", err)
+	}
+	if s.client.Username != "" {
+		request.SetBasicAuth(s.client.Username, s.client.Password)
+	}
+	response, err := s.httpClient().Do(request)
+	if err != nil {
+		return fmt.Errorf("request registry token: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("request registry token for %s: %s", s.reference.Repository, response.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxManifestBytes)).Decode(&token); err != nil {
+		return fmt.Errorf("parse registry token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return errors.New("registry token response contains no token")
+	}
+	s.authorization = "Bearer " + token.Token
+	return nil
+}
+
+// httpClient 返回发送请求使用的客户端。
+func (s *registrySession) httpClient() *http.Client {
+	if s.client.HTTP != nil {
+		return s.client.HTTP
+	}
+	return http.DefaultClient
+}
+
+// parseChallenge 解析 WWW-Authenticate 头，例如 Bearer realm="https://auth.docker.io/token",service="registry.docker.io"，
+// 返回小写的认证方案与参数。
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	parameters := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				parameters[key] = value[1:]
+				break
+			}
+			parameters[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			parameters[key], rest, _ = strings.Cut(value, ",")
+		}
+		rest = strings.TrimLeft(strings.TrimSpace(rest), ",")
+		rest = strings.TrimSpace(rest)
+	}
+	return strings.ToLower(scheme), parameters
+}
+
+// digestReader 在读取的同时计算内容摘要。
+type digestReader struct {
+	reader io.Reader
+	hash   hash.Hash
+}
+
+// Read 实现 io.Reader。
+func (r *digestReader) Read(buffer []byte) (int, error) {
+	n, err := r.reader.Read(buffer)
+	r.hash.Write(buffer[:n])
+	return n, err
+}
@@ -0,0 +1,141 @@
+package oci
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestParseReference 验证 Docker Hub 的默认值、带端口的仓库地址、标签与摘要。
+func TestParseReference(t *testing.T) {
+	cases := map[string]Reference{
+		"alpine":                            {Registry: "registry-1.docker.io", Repository: "library/alpine", Tag: "latest"},
+		"org/app:1.0":                       {Registry: "registry-1.docker.io", Repository: "org/app", Tag: "1.0"},
+		"ghcr.io/org/app:v2":                {Registry: "ghcr.io", Repository: "org/app", Tag: "v2"},
+		"localhost:5000/app":                {Registry: "localhost:5000", Repository: "app", Tag: "latest"},
+		"registry:5000/team/app@sha256:abc": {Registry: "registry:5000", Repository: "team/app", Digest: "sha256:abc"},
+	}
+	for value, expected := range cases {
+		reference, err := ParseReference(value)
+		if err != nil || reference != expected {
+			t.Fatalf("%s: unexpected reference %+v (%v)", value, reference, err)
+		}
+	}
+	for _, value := range []string{"", "App", "ghcr.io/org/app:bad tag", "app@md5"} {
+		if _, err := ParseReference(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+	if reference, _ := ParseReference("localhost:5000/app"); reference.baseURL() != "http://localhost:5000" {
+		t.Fatalf("unexpected base url %s", reference.baseURL())
+	}
+	if reference, _ := ParseReference("ghcr.io/org/app"); reference.baseURL() != "https://ghcr.io" {
+		t.Fatalf("unexpected base url %s", reference.baseURL())
+	}
+}
+
+// TestParseChallenge 验证 WWW-Authenticate 头的解析。
+func TestParseChallenge(t *testing.T) {
+	scheme, parameters := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:a/b:pull"`)
+	if scheme != "bearer" || parameters["realm"] != "https://auth.example.com/token" ||
+		parameters["service"] != "registry.example.com" || parameters["scope"] != "repository:a/b:pull" {
+		t.Fatalf("unexpected challenge: %s %v", scheme, parameters)
+	}
+}
+
+// TestPull 验证通过 Bearer 认证拉取多平台镜像：选择平台清单、解压并校验各层。
+func TestPull(t *testing.T) {
+	blobs := map[string][]byte{}
+	addBlob := func(content []byte) string {
+		sum := sha256.Sum256(content)
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		blobs[digest] = content
+		return digest
+	}
+	gzipped := func(content []byte) []byte {
+		var buffer bytes.Buffer
+		writer := gzip.NewWriter(&buffer)
+		_, _ = writer.Write(content)
+		_ = writer.Close()
+		return buffer.Bytes()
+	}
+
+	base := gzipped(buildLayer(t, layerEntry{Name: "src/main.go", Content: "package main\n"}, layerEntry{Name: "src/old.go", Content: "old\n"}))
+	top := buildLayer(t, layerEntry{Name: "src/.wh.old.go"}, layerEntry{Name: "src/app.py", Content: "print()\n"})
+	layers := []map[string]any{
+		{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": addBlob(base), "size": len(base)},
+		{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": addBlob(top), "size": len(top)},
+	}
+	imageManifest, _ := json.Marshal(map[string]any{"schemaVersion": 2, "mediaType": mediaTypeOCIManifest, "layers": layers})
+	imageDigest := addBlob(imageManifest)
+	index, _ := json.Marshal(map[string]any{"schemaVersion": 2, "mediaType": mediaTypeOCIIndex, "manifests": []map[string]any{
+		{"mediaType": mediaTypeOCIManifest, "digest": "sha256:" + strings.Repeat("0", 64), "platform": map[string]string{"os": "linux", "architecture": "arm64"}},
+		{"mediaType": mediaTypeOCIManifest, "digest": imageDigest, "platform": map[string]string{"os": "linux", "architecture": "amd64"}},
+	}})
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/token" {
+			if request.URL.Query().Get("scope") != "repository:team/app:pull" {
+				http.Error(writer, "bad scope", http.StatusBadRequest)
+				return
+			}
+			_, _ = writer.Write([]byte(`{"token":"secret"}`))
+			return
+		}
+		if request.Header.Get("Authorization") != "Bearer secret" {
+			writer.Header().Set("WWW-Authenticate", `Bearer realm="http://`+request.Host+`/token",service="test"`)
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch request.URL.Path {
+		case "/v2/team/app/manifests/1.0":
+			_, _ = writer.Write(index)
+		case "/v2/team/app/manifests/" + imageDigest:
+			_, _ = writer.Write(imageManifest)
+		default:
+			digest := strings.TrimPrefix(request.URL.Path, "/v2/team/app/blobs/")
+			content, ok := blobs[digest]
+			if !ok {
+				http.NotFound(writer, request)
+				return
+			}
+			_, _ = writer.Write(content)
+		}
+	}))
+	defer server.Close()
+
+	reference, err := ParseReference(strings.TrimPrefix(server.URL, "http://") + "/team/app:1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	client := &Client{Platform: "linux/amd64"}
+	image, err := client.Pull(context.Background(), reference, dir)
+	if err != nil {
+		t.Fatalf("pull failed: %v", err)
+	}
+	if image.Digest != imageDigest || image.Layers != 2 || image.Platform != "linux/amd64" {
+		t.Fatalf("unexpected image: %+v", image)
+	}
+	if files := listFiles(t, dir); strings.Join(files, " ") != "src/app.py=print()\n src/main.go=package main\n" {
+		t.Fatalf("unexpected files: %q", files)
+	}
+
+	if _, err := (&Client{Platform: "windows/amd64"}).Pull(context.Background(), reference, t.TempDir()); err == nil ||
+		!strings.Contains(err.Error(), "linux/arm64, linux/amd64") {
+		t.Fatalf("expected platform error listing available platforms, got %v", err)
+	}
+
+	// 篡改层内容后摘要校验失败。
+	blobs[layers[1]["digest"].(string)] = buildLayer(t, layerEntry{Name: "evil.go", Content: "x"})
+	if _, err := client.Pull(context.Background(), reference, t.TempDir()); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected digest mismatch, got %v", err)
+	}
+}
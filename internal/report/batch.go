@@ -0,0 +1,95 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// PrintBatchTable 使用表格展示多仓库批量扫描结果：每个仓库一行，随后是全部仓库按语言的汇总与总计。
+func PrintBatchTable(writer io.Writer, batch model.BatchResult) error {
+	tw := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, "REPOSITORY\tSOURCE\tFILES\tTOTAL\tCODE\tCOMMENT\tBLANK\tERRORS"); err != nil {
+		return err
+	}
+	for _, item := range batch.Repositories {
+		if item.Error != "" {
+			if _, err := fmt.Fprintf(tw, "%s\t%s\tfailed: %s\n", item.Label, item.Source, item.Error); err != nil {
+				return err
+			}
+			continue
+		}
+		total := item.Result.Total
+		if _, err := fmt.Fprintf(
+			tw,
+			"%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			item.Label,
+			item.Source,
+			total.Files,
+			total.Total,
+			total.Code,
+			total.Comment,
+			total.Blank,
+			len(item.Result.Errors),
+		); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(tw, "\nLANGUAGE\tFILES\tTOTAL\tCODE\tCOMMENT\tBLANK\tCODE SHARE"); err != nil {
+		return err
+	}
+	for _, item := range batch.Aggregate.Languages {
+		if _, err := fmt.Fprintf(
+			tw,
+			"%s\t%d\t%d\t%d\t%d\t%d\t%.1f%%\n",
+			item.Language,
+			item.Files,
+			item.Metrics.Total,
+			item.Metrics.Code,
+			item.Metrics.Comment,
+			item.Metrics.Blank,
+			item.Ratios.CodeShare*100,
+		); err != nil {
+			return err
+		}
+	}
+
+	aggregate := batch.Aggregate.Total
+	if _, err := fmt.Fprintf(
+		tw,
+		"\nTOTAL\t%d\t%d\t%d\t%d\t%d\t%.1f%%\n",
+		aggregate.Files,
+		aggregate.Total,
+		aggregate.Code,
+		aggregate.Comment,
+		aggregate.Blank,
+		aggregate.Ratios.CodeShare*100,
+	); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// PrintBatchJSON 把批量扫描结果按易读 JSON 输出到任意 writer，各仓库结果与汇总都带有当前 schema_version。
+func PrintBatchJSON(writer io.Writer, batch model.BatchResult) error {
+	batch.Repositories = append([]model.RepositoryResult(nil), batch.Repositories...)
+	for index := range batch.Repositories {
+		batch.Repositories[index].Result.SchemaVersion = model.SchemaVersion
+	}
+	batch.Aggregate.SchemaVersion = model.SchemaVersion
+
+	content, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal json: %w", err)
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
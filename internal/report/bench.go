@@ -0,0 +1,101 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// PrintBenchTable 使用表格展示基准测试结果：每次扫描一行，随后是整体吞吐、分配统计与按语言的分析吞吐。
+func PrintBenchTable(writer io.Writer, bench model.BenchResult) error {
+	tw := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, "RUN\tDURATION\tFILES\tMB\tFILES/S\tMB/S\tALLOCS\tALLOC MB\tGC"); err != nil {
+		return err
+	}
+	for index, run := range bench.Runs {
+		if _, err := fmt.Fprintf(
+			tw,
+			"%d\t%s\t%d\t%.1f\t%.0f\t%.1f\t%d\t%.1f\t%d\n",
+			index+1,
+			run.Duration.Round(time.Millisecond),
+			run.Files,
+			float64(run.Bytes)/1e6,
+			run.FilesPerSecond(),
+			run.MBPerSecond(),
+			run.Allocs,
+			float64(run.AllocBytes)/1e6,
+			run.GCCycles,
+		); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(
+		writer,
+		"\npaths: %s, workers: %d, runs: %d (+%d warmup)\n"+
+			"duration: median %s, min %s, max %s\n"+
+			"throughput: %.0f files/s, %.1f MB/s\n"+
+			"allocations: %.1f allocs/file, %.0f B/file\n",
+		strings.Join(bench.Paths, " "),
+		bench.Workers,
+		len(bench.Runs),
+		bench.Warmup,
+		bench.MedianDuration.Round(time.Millisecond),
+		bench.MinDuration.Round(time.Millisecond),
+		bench.MaxDuration.Round(time.Millisecond),
+		bench.FilesPerSecond,
+		bench.MBPerSecond,
+		bench.AllocsPerFile,
+		bench.AllocBytesPerFile,
+	); err != nil {
+		return err
+	}
+
+	if len(bench.Languages) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(writer, "\nper-worker analyzer throughput:"); err != nil {
+		return err
+	}
+	tw = tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "LANGUAGE\tFILES\tMB\tANALYZE TIME\tFILES/S\tMB/S"); err != nil {
+		return err
+	}
+	for _, item := range bench.Languages {
+		if _, err := fmt.Fprintf(
+			tw,
+			"%s\t%d\t%.1f\t%s\t%.0f\t%.1f\n",
+			item.Language,
+			item.Files,
+			float64(item.Bytes)/1e6,
+			item.Duration.Round(time.Millisecond),
+			item.FilesPerSecond,
+			item.MBPerSecond,
+		); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// PrintBenchJSON 把基准测试结果按易读 JSON 输出到任意 writer，耗时字段单位为纳秒。
+func PrintBenchJSON(writer io.Writer, bench model.BenchResult) error {
+	content, err := json.MarshalIndent(bench, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal json: %w", err)
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// ReporterDefinition 描述一个外部输出格式插件。
+type ReporterDefinition struct {
+	// Name 为格式名称，与内置格式同名时替换内置格式。
+	Name string
+	// Command 为插件命令及参数，Command[0] 为可执行文件。
+	Command []string
+}
+
+// ParseReporterSpec 解析命令行输出格式插件描述，格式为 NAME:COMMAND [ARGS...]，
+// 例如 "sonar:gocloc-sonar --project api"。命令部分按空白切分，不支持引号。
+func ParseReporterSpec(spec string) (ReporterDefinition, error) {
+	name, command, ok := strings.Cut(spec, ":")
+	definition := ReporterDefinition{Name: strings.TrimSpace(name), Command: strings.Fields(command)}
+	if !ok || definition.Name == "" || len(definition.Command) == 0 {
+		return ReporterDefinition{}, fmt.Errorf("invalid reporter %q, expected NAME:COMMAND", spec)
+	}
+	return definition, nil
+}
+
+// CommandReporter 通过子进程协议调用外部输出格式插件。
+//
+// 协议说明：
+// 1) 每次输出启动一次插件进程，标准输入写入与 json 格式相同的扫描结果（带 schema_version）；
+// 2) 插件把渲染结果写到标准输出并以 0 退出，gocloc 原样转发；
+// 3) 非 0 退出时不转发任何输出，错误信息附带插件的标准错误输出。
+type CommandReporter struct {
+	Definition ReporterDefinition
+}
+
+// Name 返回格式名称。
+func (r CommandReporter) Name() string {
+	return r.Definition.Name
+}
+
+// Render 把扫描结果交给插件进程，成功后把其标准输出写入 writer。
+func (r CommandReporter) Render(writer io.Writer, result model.ScanResult) error {
+	var request bytes.Buffer
+	if err := PrintJSON(&request, result); err != nil {
+		return err
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd := exec.Command(r.Definition.Command[0], r.Definition.Command[1:]...)
+	cmd.Stdin = &request
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("reporter %s: %w: %s", r.Definition.Name, err, message)
+		}
+		return fmt.Errorf("reporter %s: %w", r.Definition.Name, err)
+	}
+	if _, err := writer.Write(stdout.Bytes()); err != nil {
+		return fmt.Errorf("write reporter %s output: %w", r.Definition.Name, err)
+	}
+	return nil
+}
+
+// Traits 返回格式特性：插件输出的内容未知，因此视为完整的文档。
+func (r CommandReporter) Traits() Traits {
+	return Traits{Description: "外部命令 " + r.Definition.Command[0], Document: true}
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// PrintDiffTable 使用表格展示两次扫描的差值：变化的文件、按语言的增量与总计增量，数值带符号。
+func PrintDiffTable(writer io.Writer, diff model.DiffResult) error {
+	tw := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+
+	if _, err := fmt.Fprintf(tw, "BASE\t%s\nHEAD\t%s\n\n", diff.Base, diff.Head); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(tw, "FILE\tLANGUAGE\tSTATUS\tTOTAL\tCODE\tCOMMENT\tBLANK"); err != nil {
+		return err
+	}
+	for _, item := range diff.Files {
+		if _, err := fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%+d\t%+d\t%+d\t%+d\n",
+			item.Path,
+			item.Language,
+			item.Status,
+			item.Delta.Total,
+			item.Delta.Code,
+			item.Delta.Comment,
+			item.Delta.Blank,
+		); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintln(tw, "\nLANGUAGE\tSTATUS\tFILES\tNEW FILES\tREMOVED FILES\tTOTAL\tCODE\tCOMMENT\tBLANK"); err != nil {
+		return err
+	}
+	for _, item := range diff.Languages {
+		if _, err := fmt.Fprintf(
+			tw,
+			"%s\t%s\t%+d\t%d\t%d\t%+d\t%+d\t%+d\t%+d\n",
+			item.Language,
+			item.Status,
+			item.Delta.Files,
+			item.AddedFiles,
+			item.RemovedFiles,
+			item.Delta.Metrics.Total,
+			item.Delta.Metrics.Code,
+			item.Delta.Metrics.Comment,
+			item.Delta.Metrics.Blank,
+		); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(
+		tw,
+		"\nTOTAL\t\t%+d\t%+d\t%+d\t%+d\t%+d\n",
+		diff.Total.Files,
+		diff.Total.Total,
+		diff.Total.Code,
+		diff.Total.Comment,
+		diff.Total.Blank,
+	); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// PrintDiffJSON 把差值按易读 JSON 输出到任意 writer。
+func PrintDiffJSON(writer io.Writer, diff model.DiffResult) error {
+	content, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal json: %w", err)
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
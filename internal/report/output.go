@@ -0,0 +1,60 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// outputPlaceholder 匹配导出路径中的 {name} 占位符。
+var outputPlaceholder = regexp.MustCompile(`\{([a-z-]+)\}`)
+
+// OutputTemplateValues 提供导出路径占位符的取值。
+type OutputTemplateValues struct {
+	// Time 为 {date}（2006-01-02）与 {time}（150405）的取值来源。
+	Time time.Time
+	// ScannedPath 为扫描路径，{path-hash} 取其 SHA-256 的前 12 位十六进制。
+	ScannedPath string
+	// Ref 返回 {ref} 的取值（如当前 git 分支），只在模板包含 {ref} 时调用；为 nil 时 {ref} 视为不可用。
+	Ref func() (string, error)
+}
+
+// ExpandOutputPath 展开导出路径中的占位符，使定时扫描无需包装脚本即可按日期、分支归档结果，例如
+// "result-{date}-{ref}.json" -> "result-2024-05-01-main.json"。
+//
+// 支持 {date}、{time}、{ref}、{path-hash}；{ref} 中的 / 等路径分隔符会替换为 -。未知占位符返回错误。
+func ExpandOutputPath(template string, values OutputTemplateValues) (string, error) {
+	var expandErr error
+	expanded := outputPlaceholder.ReplaceAllStringFunc(template, func(match string) string {
+		if expandErr != nil {
+			return match
+		}
+		switch name := match[1 : len(match)-1]; name {
+		case "date":
+			return values.Time.Format("2006-01-02")
+		case "time":
+			return values.Time.Format("150405")
+		case "path-hash":
+			sum := sha256.Sum256([]byte(values.ScannedPath))
+			return hex.EncodeToString(sum[:])[:12]
+		case "ref":
+			if values.Ref == nil {
+				expandErr = fmt.Errorf("output placeholder {ref} is not available")
+				return match
+			}
+			ref, err := values.Ref()
+			if err != nil {
+				expandErr = fmt.Errorf("expand output placeholder {ref}: %w", err)
+				return match
+			}
+			return strings.NewReplacer("/", "-", `\`, "-").Replace(ref)
+		default:
+			expandErr = fmt.Errorf("unknown output placeholder %s, allowed: {date}, {time}, {ref}, {path-hash}", match)
+			return match
+		}
+	})
+	return expanded, expandErr
+}
@@ -0,0 +1,439 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// DefaultPDFTop 是结果没有携带大文件榜单时，PDF 报告列出的代码行最多的文件数。
+const DefaultPDFTop = 20
+
+// PDFOptions 是 PDF 报告的可选内容。
+type PDFOptions struct {
+	// Title 为报告标题，为空时为 "gocloc report"。
+	Title string
+	// Generated 为报告生成时间，非零时写在标题下方与文档信息中；为零时输出与时间无关，便于比对归档。
+	Generated time.Time
+	// Top 为结果没有携带大文件榜单（LargestFiles）时列出的文件数，<=0 时为 DefaultPDFTop。
+	Top int
+	// History 为按时间先后排列的历史结果，非空时附加代码行趋势图，当前结果作为最后一个点。
+	History []TrendPoint
+}
+
+// TrendPoint 是趋势图中的一个历史结果。
+type TrendPoint struct {
+	Label string
+	Total model.TotalMetrics
+}
+
+// PDF 页面布局（单位为 point），页面为 A4 纵向。
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+	pdfFooter     = 30.0
+	pdfRowHeight  = 14.0
+	pdfTableSize  = 9.0
+)
+
+// PrintPDF 把扫描结果输出为分页的 PDF 报告：概要、语言表、代码行最多的文件，以及提供历史结果时的趋势图。
+// 报告只使用 PDF 标准字体（Helvetica），非 ASCII 字符显示为 ?；表格单元格过长时保留末尾并以 ... 开头。
+func PrintPDF(writer io.Writer, result model.ScanResult, options PDFOptions) error {
+	title := options.Title
+	if title == "" {
+		title = "gocloc report"
+	}
+	document := &pdfDocument{title: title, generated: options.Generated}
+
+	document.newPage()
+	document.y -= 10
+	document.text(pdfMargin, document.y, 20, true, title)
+	document.y -= 22
+	document.text(pdfMargin, document.y, 10, false, "Scanned path: "+result.ScannedPath)
+	if !options.Generated.IsZero() {
+		document.y -= 14
+		document.text(pdfMargin, document.y, 10, false, "Generated: "+options.Generated.Format(time.RFC3339))
+	}
+	document.y -= 6
+
+	document.heading("Summary")
+	summary := [][]string{
+		{"Files", strconv.FormatInt(result.Total.Files, 10)},
+		{"Total lines", strconv.FormatInt(result.Total.Total, 10)},
+		{"Code", strconv.FormatInt(result.Total.Code, 10)},
+		{"Comment", strconv.FormatInt(result.Total.Comment, 10)},
+		{"Blank", strconv.FormatInt(result.Total.Blank, 10)},
+		{"ULOC", strconv.FormatInt(result.Total.ULOC, 10)},
+		{"Comment/code", fmt.Sprintf("%.2f", result.Total.Ratios.CommentDensity)},
+		{"Languages", strconv.Itoa(len(result.Languages))},
+		{"Test code", fmt.Sprintf("%d (test/code %.2f)", result.TestSplit.Test.Code, result.TestSplit.TestToCodeRatio)},
+		{"Errors", strconv.Itoa(len(result.Errors))},
+	}
+	if result.Generated != nil {
+		summary = append(summary, []string{"Generated code (excluded)", strconv.FormatInt(result.Generated.Code, 10)})
+	}
+	document.table([]pdfColumn{{title: "METRIC", width: 200}, {title: "VALUE", width: 150, right: true}}, summary)
+
+	document.heading("Languages")
+	languages := make([][]string, 0, len(result.Languages))
+	for _, item := range result.Languages {
+		languages = append(languages, []string{
+			item.Language,
+			strconv.FormatInt(item.Files, 10),
+			strconv.FormatInt(item.Metrics.Total, 10),
+			strconv.FormatInt(item.Metrics.Code, 10),
+			strconv.FormatInt(item.Metrics.Comment, 10),
+			strconv.FormatInt(item.Metrics.Blank, 10),
+			fmt.Sprintf("%.1f%%", item.Ratios.CodeShare*100),
+		})
+	}
+	document.table([]pdfColumn{
+		{title: "LANGUAGE", width: 145},
+		{title: "FILES", width: 50, right: true},
+		{title: "TOTAL", width: 60, right: true},
+		{title: "CODE", width: 60, right: true},
+		{title: "COMMENT", width: 60, right: true},
+		{title: "BLANK", width: 60, right: true},
+		{title: "SHARE", width: 60, right: true},
+	}, languages)
+
+	top := options.Top
+	if top <= 0 {
+		top = DefaultPDFTop
+	}
+	var largest []model.FileMetrics
+	if result.LargestFiles != nil {
+		largest = result.LargestFiles.ByCode
+	} else if len(result.Files) > 0 {
+		largest = model.RankFiles(result.Files, top).ByCode
+	}
+	if len(largest) > 0 {
+		document.heading("Top files by code")
+		files := make([][]string, 0, len(largest))
+		for _, item := range largest {
+			files = append(files, []string{
+				item.Path,
+				item.Language,
+				strconv.FormatInt(item.Metrics.Total, 10),
+				strconv.FormatInt(item.Metrics.Code, 10),
+				strconv.FormatInt(item.Metrics.Comment, 10),
+				strconv.FormatInt(item.Metrics.Blank, 10),
+			})
+		}
+		document.table([]pdfColumn{
+			{title: "FILE", width: 205},
+			{title: "LANGUAGE", width: 70},
+			{title: "TOTAL", width: 50, right: true},
+			{title: "CODE", width: 50, right: true},
+			{title: "COMMENT", width: 60, right: true},
+			{title: "BLANK", width: 60, right: true},
+		}, files)
+	}
+
+	if len(options.History) > 0 {
+		points := append(append([]TrendPoint(nil), options.History...), TrendPoint{Label: "current", Total: result.Total})
+		document.heading("Code trend")
+		document.trendChart(points)
+		trend := make([][]string, 0, len(points))
+		for index, point := range points {
+			change := "-"
+			if index > 0 {
+				change = fmt.Sprintf("%+d", point.Total.Code-points[index-1].Total.Code)
+			}
+			trend = append(trend, []string{
+				point.Label,
+				strconv.FormatInt(point.Total.Files, 10),
+				strconv.FormatInt(point.Total.Code, 10),
+				strconv.FormatInt(point.Total.Comment, 10),
+				change,
+			})
+		}
+		document.table([]pdfColumn{
+			{title: "SNAPSHOT", width: 215},
+			{title: "FILES", width: 70, right: true},
+			{title: "CODE", width: 70, right: true},
+			{title: "COMMENT", width: 70, right: true},
+			{title: "CHANGE", width: 70, right: true},
+		}, trend)
+	}
+
+	return document.write(writer)
+}
+
+// pdfColumn 是表格的一列，right 表示右对齐（数值列）。
+type pdfColumn struct {
+	title string
+	width float64
+	right bool
+}
+
+// pdfDocument 按页累积内容流，y 为当前页下一行的基线位置（自页面底部起算）。
+type pdfDocument struct {
+	title     string
+	generated time.Time
+	pages     []*bytes.Buffer
+	page      *bytes.Buffer
+	y         float64
+}
+
+// newPage 开始新的一页。
+func (d *pdfDocument) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// ensure 在当前页剩余高度不足 height 时换页。
+func (d *pdfDocument) ensure(height float64) {
+	if d.y-height < pdfMargin+pdfFooter {
+		d.newPage()
+	}
+}
+
+// heading 输出段落标题，并保证标题之后至少还能放下表头与一行。
+func (d *pdfDocument) heading(value string) {
+	d.ensure(28 + 3*pdfRowHeight)
+	d.y -= 28
+	d.text(pdfMargin, d.y, 13, true, value)
+	d.y -= 6
+}
+
+// text 在 (x, y) 处输出一段文本。
+func (d *pdfDocument) text(x float64, y float64, size float64, bold bool, value string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(value))
+}
+
+// rect 以灰度 gray（0 为黑色，1 为白色）填充矩形。
+func (d *pdfDocument) rect(x float64, y float64, width float64, height float64, gray float64) {
+	fmt.Fprintf(d.page, "%.2f g %.2f %.2f %.2f %.2f re f 0 g\n", gray, x, y, width, height)
+}
+
+// line 以灰度 gray 画一条细线。
+func (d *pdfDocument) line(x1 float64, y1 float64, x2 float64, y2 float64, gray float64) {
+	fmt.Fprintf(d.page, "%.2f G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n", gray, x1, y1, x2, y2)
+}
+
+// table 输出带表头的表格，跨页时在新页重复表头。
+func (d *pdfDocument) table(columns []pdfColumn, rows [][]string) {
+	header := make([]string, len(columns))
+	for index, column := range columns {
+		header[index] = column.title
+	}
+	d.row(columns, header, true)
+	for _, row := range rows {
+		if d.y-pdfRowHeight < pdfMargin+pdfFooter {
+			d.newPage()
+			d.row(columns, header, true)
+		}
+		d.row(columns, row, false)
+	}
+}
+
+// row 输出表格的一行，表头带灰色底色。
+func (d *pdfDocument) row(columns []pdfColumn, cells []string, header bool) {
+	d.y -= pdfRowHeight
+	if header {
+		width := 0.0
+		for _, column := range columns {
+			width += column.width
+		}
+		d.rect(pdfMargin, d.y-3.5, width, pdfRowHeight, 0.88)
+	}
+	x := pdfMargin
+	for index, column := range columns {
+		value := fitText(cells[index], column.width-8, pdfTableSize)
+		if column.right {
+			d.text(x+column.width-4-textWidth(value, pdfTableSize), d.y, pdfTableSize, false, value)
+		} else {
+			d.text(x+4, d.y, pdfTableSize, false, value)
+		}
+		x += column.width
+	}
+}
+
+// trendChart 画出各数据点代码行数的柱状图，标签过密时只标注部分柱子。
+func (d *pdfDocument) trendChart(points []TrendPoint) {
+	const chartHeight = 180.0
+	d.ensure(chartHeight + 40)
+	left, right := pdfMargin+50, pdfPageWidth-pdfMargin
+	bottom := d.y - chartHeight - 10
+
+	maximum := int64(0)
+	for _, point := range points {
+		maximum = max(maximum, point.Total.Code)
+	}
+	scale := niceCeiling(maximum)
+	for step := 0; step <= 4; step++ {
+		y := bottom + chartHeight*float64(step)/4
+		d.line(left, y, right, y, 0.8)
+		label := strconv.FormatInt(scale*int64(step)/4, 10)
+		d.text(left-6-textWidth(label, 7), y-2.5, 7, false, label)
+	}
+
+	slot := (right - left) / float64(len(points))
+	labelEvery := 1
+	for _, point := range points {
+		labelEvery = max(labelEvery, int(math.Ceil((textWidth(point.Label, 7)+4)/slot)))
+	}
+	for index, point := range points {
+		x := left + slot*float64(index) + slot*0.2
+		height := 0.0
+		if scale > 0 {
+			height = chartHeight * float64(point.Total.Code) / float64(scale)
+		}
+		gray := 0.55
+		if index == len(points)-1 {
+			gray = 0.25
+		}
+		d.rect(x, bottom, slot*0.6, height, gray)
+		if value := strconv.FormatInt(point.Total.Code, 10); textWidth(value, 7) <= slot {
+			d.text(x+slot*0.3-textWidth(value, 7)/2, bottom+height+3, 7, false, value)
+		}
+		if index%labelEvery == 0 || index == len(points)-1 {
+			label := fitText(point.Label, slot*float64(labelEvery)-4, 7)
+			d.text(x+slot*0.3-textWidth(label, 7)/2, bottom-11, 7, false, label)
+		}
+	}
+	d.y = bottom - 16
+}
+
+// niceCeiling 返回不小于 value 的 1、2、5 乘以 10 的幂，作为图表纵轴上限。
+func niceCeiling(value int64) int64 {
+	if value <= 0 {
+		return 0
+	}
+	for magnitude := int64(1); ; magnitude *= 10 {
+		for _, factor := range []int64{1, 2, 5} {
+			if factor*magnitude >= value {
+				return factor * magnitude
+			}
+		}
+	}
+}
+
+// write 为每页加上页脚，按 PDF 1.4 结构写出全部对象与交叉引用表。
+func (d *pdfDocument) write(writer io.Writer) error {
+	for index, page := range d.pages {
+		d.page = page
+		d.line(pdfMargin, pdfMargin+12, pdfPageWidth-pdfMargin, pdfMargin+12, 0.7)
+		d.text(pdfMargin, pdfMargin, 8, false, d.title)
+		label := fmt.Sprintf("Page %d of %d", index+1, len(d.pages))
+		d.text(pdfPageWidth-pdfMargin-textWidth(label, 8), pdfMargin, 8, false, label)
+	}
+
+	info := "/Producer (gocloc) /Title (" + pdfEscape(d.title) + ")"
+	if !d.generated.IsZero() {
+		info += " /CreationDate (D:" + d.generated.UTC().Format("20060102150405") + "Z)"
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< " + info + " >>",
+	}
+	kids := make([]string, 0, len(d.pages))
+	for _, page := range d.pages {
+		pageObject := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObject))
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, pageObject+1,
+		))
+		var compressed bytes.Buffer
+		compressor := zlib.NewWriter(&compressed)
+		if _, err := compressor.Write(page.Bytes()); err != nil {
+			return fmt.Errorf("compress pdf page: %w", err)
+		}
+		if err := compressor.Close(); err != nil {
+			return fmt.Errorf("compress pdf page: %w", err)
+		}
+		objects = append(objects, fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))
+
+	var output bytes.Buffer
+	output.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for index, object := range objects {
+		offsets[index] = output.Len()
+		fmt.Fprintf(&output, "%d 0 obj\n%s\nendobj\n", index+1, object)
+	}
+	xref := output.Len()
+	fmt.Fprintf(&output, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&output, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&output, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	if _, err := writer.Write(output.Bytes()); err != nil {
+		return fmt.Errorf("write pdf: %w", err)
+	}
+	return nil
+}
+
+// pdfEscape 转义 PDF 字符串中的括号与反斜杠，非 ASCII 与控制字符替换为 ?。
+func pdfEscape(value string) string {
+	var builder strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r < ' ' || r > '~':
+			builder.WriteByte('?')
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// helveticaWidths 是 Helvetica 中 ASCII 32..126 各字符的宽度（千分之一字号），来自标准 AFM。
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth 返回文本以 Helvetica、字号 size 输出时的宽度，非 ASCII 字符按 ? 计算。
+func textWidth(value string, size float64) float64 {
+	width := 0
+	for _, r := range value {
+		if r < ' ' || r > '~' {
+			r = '?'
+		}
+		width += helveticaWidths[r-' ']
+	}
+	return float64(width) * size / 1000
+}
+
+// fitText 在文本超出宽度时保留末尾部分并以 ... 开头（路径的末尾通常最有辨识度）。
+func fitText(value string, width float64, size float64) string {
+	if textWidth(value, size) <= width {
+		return value
+	}
+	runes := []rune(value)
+	for start := 1; start < len(runes); start++ {
+		candidate := "..." + string(runes[start:])
+		if textWidth(candidate, size) <= width {
+			return candidate
+		}
+	}
+	return "..."
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// prometheusEscaper 按 Prometheus/OpenMetrics 文本格式的规则转义标签值。
+var prometheusEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusLabel 是一个样本标签。
+type prometheusLabel struct {
+	name  string
+	value string
+}
+
+// PrintPrometheus 输出 Prometheus/OpenMetrics 文本格式的指标，可交给 node_exporter 的 textfile collector
+// 或 Pushgateway，以 "# EOF" 结尾：
+// - gocloc_files、gocloc_lines{type="total|code|comment|blank"} 为总计
+// - gocloc_language_files{language}、gocloc_language_lines{language,type} 为各语言的计数，与总计分开，避免 sum 时重复计算
+// - 结果的标签作为每个样本的标签；标签名中不合法的字符替换为 _，与内置标签 language、type 同名、为空或以 Prometheus 保留的 __ 开头时加 label_ 前缀
+func PrintPrometheus(writer io.Writer, result model.ScanResult) error {
+	labels := prometheusLabels(result.Labels)
+	family := func(name string, help string) error {
+		_, err := fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		return err
+	}
+	sample := func(name string, value int64, builtin ...prometheusLabel) error {
+		pairs := make([]string, 0, len(builtin)+len(labels))
+		for _, label := range append(builtin, labels...) {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label.name, prometheusEscaper.Replace(label.value)))
+		}
+		if len(pairs) == 0 {
+			_, err := fmt.Fprintf(writer, "%s %d\n", name, value)
+			return err
+		}
+		_, err := fmt.Fprintf(writer, "%s{%s} %d\n", name, strings.Join(pairs, ","), value)
+		return err
+	}
+	lineTypes := func(metrics model.LineMetrics) []struct {
+		name  string
+		value int64
+	} {
+		return []struct {
+			name  string
+			value int64
+		}{{"total", metrics.Total}, {"code", metrics.Code}, {"comment", metrics.Comment}, {"blank", metrics.Blank}}
+	}
+
+	if err := family("gocloc_files", "Number of analyzed files."); err != nil {
+		return err
+	}
+	if err := sample("gocloc_files", result.Total.Files); err != nil {
+		return err
+	}
+	if err := family("gocloc_lines", "Number of lines by type."); err != nil {
+		return err
+	}
+	for _, item := range lineTypes(result.Total.LineMetrics) {
+		if err := sample("gocloc_lines", item.value, prometheusLabel{"type", item.name}); err != nil {
+			return err
+		}
+	}
+	if err := family("gocloc_language_files", "Number of analyzed files per language."); err != nil {
+		return err
+	}
+	for _, item := range result.Languages {
+		if err := sample("gocloc_language_files", item.Files, prometheusLabel{"language", item.Language}); err != nil {
+			return err
+		}
+	}
+	if err := family("gocloc_language_lines", "Number of lines per language by type."); err != nil {
+		return err
+	}
+	for _, item := range result.Languages {
+		for _, line := range lineTypes(item.Metrics) {
+			if err := sample("gocloc_language_lines", line.value, prometheusLabel{"language", item.Language}, prometheusLabel{"type", line.name}); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(writer, "# EOF\n")
+	return err
+}
+
+// prometheusLabels 把结果标签转换为按名称排序的样本标签；转换后同名的标签（如 team-a 与 team_a）只保留键排序靠前的一个。
+func prometheusLabels(labels map[string]string) []prometheusLabel {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	converted := make([]prometheusLabel, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		name := prometheusLabelName(key)
+		if name == "" || name == "language" || name == "type" || strings.HasPrefix(name, "__") {
+			name = "label_" + name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		converted = append(converted, prometheusLabel{name: name, value: labels[key]})
+	}
+	sort.Slice(converted, func(i int, j int) bool {
+		return converted[i].name < converted[j].name
+	})
+	return converted
+}
+
+// prometheusLabelName 把标签键转换为合法的标签名（[a-zA-Z_][a-zA-Z0-9_]*）。
+func prometheusLabelName(key string) string {
+	var builder strings.Builder
+	for index, char := range key {
+		switch {
+		case char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z'):
+			builder.WriteRune(char)
+		case char >= '0' && char <= '9':
+			if index == 0 {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(char)
+		default:
+			builder.WriteByte('_')
+		}
+	}
+	return builder.String()
+}
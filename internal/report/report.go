@@ -1,80 +1,287 @@
-// Package report 提供 gocloc 的输出能力。
-// 当前实现支持 table 控制台格式和 JSON 格式（含文件导出）。
+// Package report 提供 gocloc 的输出能力，每种输出格式实现 Reporter 并注册到 Registry。
+// 当前实现支持 table 控制台格式、JSON 格式（含文件导出）、用于归档的 PDF 报告、目录层级的可视化格式（d3、folded、矩形树图）、TeamCity 服务消息与 Prometheus/OpenMetrics 文本格式指标。
 package report
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"text/tabwriter"
 
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
 )
 
+// logger 接收 report 包的诊断日志，默认丢弃。
+var logger atomic.Pointer[slog.Logger]
+
+func init() {
+	logger.Store(slog.New(slog.DiscardHandler))
+}
+
+// SetLogger 设置 report 包的日志输出（进程级），传 nil 恢复为丢弃。
+// report 的函数都是无状态的，因此日志输出按进程统一配置，而不是逐次调用传入。
+func SetLogger(next *slog.Logger) {
+	if next == nil {
+		next = slog.New(slog.DiscardHandler)
+	}
+	logger.Store(next)
+}
+
 // PrintTable 使用表格展示扫描结果。
 func PrintTable(writer io.Writer, result model.ScanResult) error {
 	tw := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
 
-	if _, err := fmt.Fprintf(tw, "SCANNED PATH\t%s\n\n", result.ScannedPath); err != nil {
+	if _, err := fmt.Fprintf(tw, "SCANNED PATH\t%s\n", result.ScannedPath); err != nil {
+		return err
+	}
+	if pairs := model.LabelPairs(result.Labels); len(pairs) > 0 {
+		if _, err := fmt.Fprintf(tw, "LABELS\t%s\n", strings.Join(pairs, ", ")); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(tw); err != nil {
 		return err
 	}
 
-	if _, err := fmt.Fprintln(tw, "FILE\tLANGUAGE\tTOTAL\tCODE\tCOMMENT\tBLANK"); err != nil {
+	if _, err := fmt.Fprintln(tw, "FILE\tLANGUAGE\tTOTAL\tCODE\tCOMMENT\tBLANK\tBYTES\tMAX LINE\tAVG LINE"); err != nil {
 		return err
 	}
 	for _, item := range result.Files {
 		if _, err := fmt.Fprintf(
 			tw,
-			"%s\t%s\t%d\t%d\t%d\t%d\n",
+			"%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.1f\n",
 			item.Path,
 			item.Language,
 			item.Metrics.Total,
 			item.Metrics.Code,
 			item.Metrics.Comment,
 			item.Metrics.Blank,
+			item.Metrics.Bytes,
+			item.Metrics.MaxLineLength,
+			item.Metrics.AvgLineLength,
 		); err != nil {
 			return err
 		}
 	}
 
-	if _, err := fmt.Fprintln(tw, "\nLANGUAGE\tFILES\tTOTAL\tCODE\tCOMMENT\tBLANK"); err != nil {
+	if _, err := fmt.Fprintln(tw, "\nLANGUAGE\tFILES\tTOTAL\tCODE\tCOMMENT\tBLANK\tULOC\tBYTES\tCOMMENT/CODE\tCODE SHARE"); err != nil {
 		return err
 	}
 	for _, item := range result.Languages {
 		if _, err := fmt.Fprintf(
 			tw,
-			"%s\t%d\t%d\t%d\t%d\t%d\n",
+			"%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f\t%.1f%%\n",
 			item.Language,
 			item.Files,
 			item.Metrics.Total,
 			item.Metrics.Code,
 			item.Metrics.Comment,
 			item.Metrics.Blank,
+			item.Metrics.ULOC,
+			item.Metrics.Bytes,
+			item.Ratios.CommentDensity,
+			item.Ratios.CodeShare*100,
 		); err != nil {
 			return err
 		}
+		// 子类别行与语言行列数一致，否则 tabwriter 会把其后各行的列错开。
+		for _, variant := range item.Variants {
+			ratios := model.NewRatios(variant.Metrics, result.Total.Code)
+			if _, err := fmt.Fprintf(
+				tw,
+				"  %s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f\t%.1f%%\n",
+				variant.Name,
+				variant.Files,
+				variant.Metrics.Total,
+				variant.Metrics.Code,
+				variant.Metrics.Comment,
+				variant.Metrics.Blank,
+				variant.Metrics.ULOC,
+				variant.Metrics.Bytes,
+				ratios.CommentDensity,
+				ratios.CodeShare*100,
+			); err != nil {
+				return err
+			}
+		}
 	}
 
 	if _, err := fmt.Fprintf(
 		tw,
-		"\nTOTAL\t%d\t%d\t%d\t%d\t%d\n",
+		"\nTOTAL\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f\t%.1f%%\n",
 		result.Total.Files,
 		result.Total.Total,
 		result.Total.Code,
 		result.Total.Comment,
 		result.Total.Blank,
+		result.Total.ULOC,
+		result.Total.Bytes,
+		result.Total.Ratios.CommentDensity,
+		result.Total.Ratios.CodeShare*100,
+	); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(
+		tw,
+		"\nPRODUCTION\t%d\t%d\t%d\t%d\t%d\nTEST\t%d\t%d\t%d\t%d\t%d\nTEST/CODE\t%.2f\n",
+		result.TestSplit.Production.Files,
+		result.TestSplit.Production.Total,
+		result.TestSplit.Production.Code,
+		result.TestSplit.Production.Comment,
+		result.TestSplit.Production.Blank,
+		result.TestSplit.Test.Files,
+		result.TestSplit.Test.Total,
+		result.TestSplit.Test.Code,
+		result.TestSplit.Test.Comment,
+		result.TestSplit.Test.Blank,
+		result.TestSplit.TestToCodeRatio,
 	); err != nil {
 		return err
 	}
+	if result.Generated != nil {
+		if _, err := fmt.Fprintf(
+			tw,
+			"GENERATED\t%d\t%d\t%d\t%d\t%d\n",
+			result.Generated.Files,
+			result.Generated.Total,
+			result.Generated.Code,
+			result.Generated.Comment,
+			result.Generated.Blank,
+		); err != nil {
+			return err
+		}
+	}
+
+	if len(result.Packages) > 0 {
+		if err := printPackages(tw, result.Packages); err != nil {
+			return err
+		}
+	}
+
+	if len(result.Owners) > 0 {
+		if err := printOwners(tw, result.Owners); err != nil {
+			return err
+		}
+	}
+
+	if result.Total.Preprocessor > 0 {
+		if _, err := fmt.Fprintln(tw, "\nLANGUAGE\tPREPROCESSOR"); err != nil {
+			return err
+		}
+		for _, item := range result.Languages {
+			if item.Metrics.Preprocessor == 0 {
+				continue
+			}
+			if _, err := fmt.Fprintf(tw, "%s\t%d\n", item.Language, item.Metrics.Preprocessor); err != nil {
+				return err
+			}
+		}
+	}
+
+	if result.Total.StringLiteral > 0 {
+		if _, err := fmt.Fprintln(tw, "\nLANGUAGE\tSTRING LITERAL"); err != nil {
+			return err
+		}
+		for _, item := range result.Languages {
+			if _, err := fmt.Fprintf(tw, "%s\t%d\n", item.Language, item.Metrics.StringLiteral); err != nil {
+				return err
+			}
+		}
+	}
+
+	if result.Total.Whitespace != nil {
+		if _, err := fmt.Fprintln(tw, "\nLANGUAGE\tINDENT\tTAB LINES\tSPACE LINES\tMIXED LINES\tMAX INDENT\tTRAILING WS"); err != nil {
+			return err
+		}
+		for _, item := range result.Languages {
+			whitespace := item.Metrics.Whitespace
+			if whitespace == nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(
+				tw,
+				"%s\t%s\t%d\t%d\t%d\t%d\t%d\n",
+				item.Language,
+				whitespace.IndentStyle,
+				whitespace.TabIndentedLines,
+				whitespace.SpaceIndentedLines,
+				whitespace.MixedIndentedLines,
+				whitespace.MaxIndentWidth,
+				whitespace.TrailingWhitespaceLines,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := printDistributions(tw, result.Languages); err != nil {
+		return err
+	}
+
+	if result.Total.Functions > 0 {
+		if _, err := fmt.Fprintln(tw, "\nLANGUAGE\tFUNCTIONS\tCODE/FUNCTION"); err != nil {
+			return err
+		}
+		for _, item := range result.Languages {
+			if _, err := fmt.Fprintf(
+				tw,
+				"%s\t%d\t%.1f\n",
+				item.Language,
+				item.Metrics.Functions,
+				codePerFunction(item.Metrics),
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	if result.LargestFiles != nil {
+		if err := printRanking(tw, "LARGEST BY CODE", result.LargestFiles.ByCode); err != nil {
+			return err
+		}
+		if err := printRanking(tw, "LARGEST BY TOTAL", result.LargestFiles.ByTotal); err != nil {
+			return err
+		}
+	}
+
+	if result.Duplication != nil {
+		if err := printDuplication(tw, *result.Duplication); err != nil {
+			return err
+		}
+	}
+
+	if result.Scripts != nil {
+		if err := printScripts(tw, *result.Scripts); err != nil {
+			return err
+		}
+	}
 
 	if len(result.Errors) > 0 {
-		if _, err := fmt.Fprintln(tw, "\nERROR FILE\tMESSAGE"); err != nil {
+		if _, err := fmt.Fprintln(tw, "\nERROR FILE\tCATEGORY\tMESSAGE"); err != nil {
 			return err
 		}
 		for _, item := range result.Errors {
-			if _, err := fmt.Fprintf(tw, "%s\t%s\n", item.Path, item.Error); err != nil {
+			if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", item.Path, item.Category, item.Message); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(result.Skipped) > 0 {
+		if _, err := fmt.Fprintln(tw, "\nSKIPPED FILE\tREASON"); err != nil {
+			return err
+		}
+		for _, item := range result.Skipped {
+			if _, err := fmt.Fprintf(tw, "%s\t%s\n", item.Path, item.Reason); err != nil {
 				return err
 			}
 		}
@@ -83,8 +290,195 @@ func PrintTable(writer io.Writer, result model.ScanResult) error {
 	return tw.Flush()
 }
 
-// PrintJSON 把扫描结果按易读 JSON 输出到任意 writer。
+// printPackages 输出各包的文件数与行数，LANGUAGES 列按代码行数从多到少列出包内语言。
+func printPackages(tw io.Writer, packages []model.PackageMetrics) error {
+	if _, err := fmt.Fprintln(tw, "\nPACKAGE\tMANIFEST\tFILES\tTOTAL\tCODE\tCOMMENT\tBLANK\tLANGUAGES"); err != nil {
+		return err
+	}
+	for _, item := range packages {
+		manifests := strings.Join(item.Manifests, ",")
+		if manifests == "" {
+			manifests = "-"
+		}
+		languages := make([]string, 0, len(item.Languages))
+		for _, language := range item.Languages {
+			languages = append(languages, language.Language)
+		}
+		if _, err := fmt.Fprintf(
+			tw,
+			"%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			item.Path,
+			manifests,
+			item.Files,
+			item.Metrics.Total,
+			item.Metrics.Code,
+			item.Metrics.Comment,
+			item.Metrics.Blank,
+			strings.Join(languages, ","),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printOwners 输出按 CODEOWNERS 所有者汇总的文件数与行数，多个所有者共有的文件计入每个所有者。
+func printOwners(tw io.Writer, owners []model.OwnerMetrics) error {
+	if _, err := fmt.Fprintln(tw, "\nOWNER\tFILES\tTOTAL\tCODE\tCOMMENT\tBLANK"); err != nil {
+		return err
+	}
+	for _, item := range owners {
+		if _, err := fmt.Fprintf(
+			tw,
+			"%s\t%d\t%d\t%d\t%d\t%d\n",
+			item.Owner,
+			item.Files,
+			item.Metrics.Total,
+			item.Metrics.Code,
+			item.Metrics.Comment,
+			item.Metrics.Blank,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printDistributions 输出各语言的单文件行数分布，没有任何语言携带分布数据时不输出。
+func printDistributions(tw io.Writer, items []model.LanguageMetrics) error {
+	header := false
+	for _, item := range items {
+		distribution := item.Distribution
+		if distribution == nil {
+			continue
+		}
+
+		if !header {
+			columns := []string{"\nLANGUAGE", "P50", "P90", "MAX"}
+			for _, bucket := range distribution.Buckets {
+				columns = append(columns, bucketLabel(bucket))
+			}
+			if _, err := fmt.Fprintln(tw, strings.Join(columns, "\t")); err != nil {
+				return err
+			}
+			header = true
+		}
+
+		values := []string{
+			item.Language,
+			strconv.FormatInt(distribution.P50, 10),
+			strconv.FormatInt(distribution.P90, 10),
+			strconv.FormatInt(distribution.Max, 10),
+		}
+		for _, bucket := range distribution.Buckets {
+			values = append(values, strconv.FormatInt(bucket.Files, 10))
+		}
+		if _, err := fmt.Fprintln(tw, strings.Join(values, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bucketLabel 生成直方图分桶的列名，例如 "<50"、"2500+"。
+func bucketLabel(bucket model.HistogramBucket) string {
+	if bucket.Max == 0 {
+		return strconv.FormatInt(bucket.Min, 10) + "+"
+	}
+	return "<" + strconv.FormatInt(bucket.Max, 10)
+}
+
+// printRanking 输出一个大文件榜单小节。
+func printRanking(tw io.Writer, title string, files []model.FileMetrics) error {
+	if _, err := fmt.Fprintf(tw, "\n%s\tLANGUAGE\tTOTAL\tCODE\n", title); err != nil {
+		return err
+	}
+	for _, item := range files {
+		if _, err := fmt.Fprintf(
+			tw,
+			"%s\t%s\t%d\t%d\n",
+			item.Path,
+			item.Language,
+			item.Metrics.Total,
+			item.Metrics.Code,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printDuplication 输出重复代码检测小节：先给出汇总，再列出最大的重复区域。
+func printDuplication(tw io.Writer, duplication model.DuplicationReport) error {
+	if _, err := fmt.Fprintf(
+		tw,
+		"\nDUPLICATION\tBLOCKS %d\tLINES %d\tWINDOW %d\n",
+		duplication.Blocks,
+		duplication.DuplicatedLines,
+		duplication.WindowSize,
+	); err != nil {
+		return err
+	}
+	if len(duplication.TopRegions) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(tw, "DUPLICATE REGION\tLINES\tOCCURRENCES"); err != nil {
+		return err
+	}
+	for _, item := range duplication.TopRegions {
+		if _, err := fmt.Fprintf(
+			tw,
+			"%s:%d-%d\t%d\t%d\n",
+			item.Path,
+			item.StartLine,
+			item.EndLine,
+			item.Lines,
+			item.Occurrences,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printScripts 输出脚本盘点小节：先给出 shebang/可执行文件汇总，再按解释器列出文件数。
+func printScripts(tw io.Writer, scripts model.ScriptReport) error {
+	if _, err := fmt.Fprintf(
+		tw,
+		"\nSCRIPTS\tSHEBANG %d\tEXECUTABLE %d\tBOTH %d\n",
+		scripts.ShebangFiles,
+		scripts.ExecutableFiles,
+		scripts.ExecutableScripts,
+	); err != nil {
+		return err
+	}
+	if len(scripts.Interpreters) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(tw, "INTERPRETER\tFILES"); err != nil {
+		return err
+	}
+	for _, item := range scripts.Interpreters {
+		if _, err := fmt.Fprintf(tw, "%s\t%d\n", item.Interpreter, item.Files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// codePerFunction 计算平均每个函数对应的代码行数，没有函数时返回 0。
+func codePerFunction(metrics model.LineMetrics) float64 {
+	if metrics.Functions == 0 {
+		return 0
+	}
+	return float64(metrics.Code) / float64(metrics.Functions)
+}
+
+// PrintJSON 把扫描结果按易读 JSON 输出到任意 writer，输出中带有当前 schema_version。
 func PrintJSON(writer io.Writer, result model.ScanResult) error {
+	result.SchemaVersion = model.SchemaVersion
 	content, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal json: %w", err)
@@ -96,12 +490,20 @@ func PrintJSON(writer io.Writer, result model.ScanResult) error {
 	return nil
 }
 
-// WriteJSONFile 将 JSON 结果导出到指定路径。
+// Save 将结果以带 schema_version 的 JSON 导出到指定路径，路径以 .gz 结尾时使用 gzip 压缩。
 // 如果目录不存在会自动创建。
-func WriteJSONFile(path string, result model.ScanResult) error {
-	content, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal json: %w", err)
+func Save(path string, result model.ScanResult) error {
+	var content bytes.Buffer
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		compressor := gzip.NewWriter(&content)
+		if err := PrintJSON(compressor, result); err != nil {
+			return err
+		}
+		if err := compressor.Close(); err != nil {
+			return fmt.Errorf("compress json: %w", err)
+		}
+	} else if err := PrintJSON(&content, result); err != nil {
+		return err
 	}
 
 	directory := filepath.Dir(path)
@@ -111,8 +513,53 @@ func WriteJSONFile(path string, result model.ScanResult) error {
 		}
 	}
 
-	if writeErr := os.WriteFile(path, content, 0o644); writeErr != nil {
+	if writeErr := os.WriteFile(path, content.Bytes(), 0o644); writeErr != nil {
 		return fmt.Errorf("write output file: %w", writeErr)
 	}
+	logger.Load().Debug("result saved", "path", path, "bytes", content.Len(), "schema_version", model.SchemaVersion)
 	return nil
 }
+
+// Load 读取 Save（或旧版本 JSON 导出）生成的结果文件。
+//
+// 兼容规则：
+// - 按文件头自动识别 gzip，与文件后缀无关
+// - 忽略未知字段，便于读取较新的次要格式扩展
+// - 没有 schema_version 的旧结果视为版本 1；版本高于当前实现时返回错误
+func Load(path string) (model.ScanResult, error) {
+	var result model.ScanResult
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return result, fmt.Errorf("read result file: %w", err)
+	}
+
+	if len(content) >= 2 && content[0] == 0x1f && content[1] == 0x8b {
+		decompressor, gzErr := gzip.NewReader(bytes.NewReader(content))
+		if gzErr != nil {
+			return result, fmt.Errorf("decompress result file %s: %w", path, gzErr)
+		}
+		content, err = io.ReadAll(decompressor)
+		if err != nil {
+			return result, fmt.Errorf("decompress result file %s: %w", path, err)
+		}
+	}
+
+	if err := json.Unmarshal(content, &result); err != nil {
+		return result, fmt.Errorf("parse result file %s: %w", path, err)
+	}
+	if result.SchemaVersion > model.SchemaVersion {
+		return result, fmt.Errorf(
+			"result file %s uses schema version %d, newer than supported version %d",
+			path,
+			result.SchemaVersion,
+			model.SchemaVersion,
+		)
+	}
+	if result.SchemaVersion == 0 {
+		logger.Load().Info("loaded result without schema version, treating as version 1", "path", path)
+		result.SchemaVersion = 1
+	}
+	logger.Load().Debug("result loaded", "path", path, "files", len(result.Files), "schema_version", result.SchemaVersion)
+	return result, nil
+}
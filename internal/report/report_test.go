@@ -0,0 +1,419 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// TestSaveLoadRoundTrip 验证 Save/Load 在普通 JSON 与 gzip 下都能还原结果并写入 schema 版本。
+func TestSaveLoadRoundTrip(t *testing.T) {
+	result := model.ScanResult{
+		ScannedPath: "/repo",
+		Files:       []model.FileMetrics{{Path: "main.go", Language: "Go", Metrics: model.LineMetrics{Total: 3, Code: 2}}},
+	}
+
+	for _, name := range []string{"result.json", "result.json.gz"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := Save(path, result); err != nil {
+			t.Fatalf("save %s failed: %v", name, err)
+		}
+
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("load %s failed: %v", name, err)
+		}
+		if loaded.SchemaVersion != model.SchemaVersion || loaded.ScannedPath != "/repo" || loaded.Files[0].Metrics.Code != 2 {
+			t.Fatalf("unexpected loaded result from %s: %+v", name, loaded)
+		}
+	}
+}
+
+// TestLoadCompatibility 验证 Load 兼容旧结果与未知字段，并拒绝更新的 schema 版本。
+func TestLoadCompatibility(t *testing.T) {
+	tempDir := t.TempDir()
+
+	legacyPath := filepath.Join(tempDir, "legacy.json")
+	legacy := `{"scanned_path": "/old", "files": [], "unknown_field": {"nested": true}, "errors": []}`
+	if err := os.WriteFile(legacyPath, []byte(legacy), 0o644); err != nil {
+		t.Fatalf("write legacy file failed: %v", err)
+	}
+	loaded, err := Load(legacyPath)
+	if err != nil {
+		t.Fatalf("load legacy failed: %v", err)
+	}
+	if loaded.SchemaVersion != 1 || loaded.ScannedPath != "/old" {
+		t.Fatalf("unexpected legacy result: %+v", loaded)
+	}
+
+	futurePath := filepath.Join(tempDir, "future.json")
+	if err := os.WriteFile(futurePath, []byte(`{"schema_version": 99}`), 0o644); err != nil {
+		t.Fatalf("write future file failed: %v", err)
+	}
+	if _, err := Load(futurePath); err == nil || !strings.Contains(err.Error(), "schema version 99") {
+		t.Fatalf("expected newer schema error, got %v", err)
+	}
+}
+
+// TestExpandOutputPath 验证导出路径占位符的展开、{ref} 中路径分隔符的替换，以及未知或不可用占位符报错。
+func TestExpandOutputPath(t *testing.T) {
+	values := OutputTemplateValues{
+		Time:        time.Date(2024, 5, 1, 8, 30, 15, 0, time.UTC),
+		ScannedPath: "/repo",
+		Ref:         func() (string, error) { return "feature/login", nil },
+	}
+	expanded, err := ExpandOutputPath("out/result-{date}-{time}-{ref}.json", values)
+	if err != nil || expanded != "out/result-2024-05-01-083015-feature-login.json" {
+		t.Fatalf("unexpected expansion %q: %v", expanded, err)
+	}
+
+	first, _ := ExpandOutputPath("{path-hash}", values)
+	values.ScannedPath = "/other"
+	second, _ := ExpandOutputPath("{path-hash}", values)
+	if len(first) != 12 || first == second {
+		t.Fatalf("expected stable 12-character path hashes per path, got %q and %q", first, second)
+	}
+
+	if plain, err := ExpandOutputPath("result.json", OutputTemplateValues{}); err != nil || plain != "result.json" {
+		t.Fatalf("expected plain path unchanged, got %q: %v", plain, err)
+	}
+	for _, template := range []string{"{unknown}.json", "{ref}.json"} {
+		if _, err := ExpandOutputPath(template, OutputTemplateValues{}); err == nil {
+			t.Fatalf("expected error for %s", template)
+		}
+	}
+}
+
+// TestPrintPDF 验证 PDF 报告的结构：交叉引用表中的偏移指向对应对象，文件较多时分页，历史结果生成趋势段落。
+func TestPrintPDF(t *testing.T) {
+	result := model.ScanResult{ScannedPath: "/repo (main)"}
+	for index := 0; index < 120; index++ {
+		result.Files = append(result.Files, model.FileMetrics{
+			Path:     fmt.Sprintf("src/very/long/directory/name/for/pagination/module_%03d.go", index),
+			Language: "Go",
+			Metrics:  model.LineMetrics{Total: int64(index + 10), Code: int64(index + 5)},
+		})
+	}
+	result.Summarize(model.SummaryOptions{})
+
+	var output bytes.Buffer
+	history := []TrendPoint{{Label: "2024-01", Total: model.TotalMetrics{LineMetrics: model.LineMetrics{Code: 5000}}}}
+	if err := PrintPDF(&output, result, PDFOptions{Top: 100, History: history}); err != nil {
+		t.Fatalf("print pdf failed: %v", err)
+	}
+	content := output.Bytes()
+	if !bytes.HasPrefix(content, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(content, []byte("%%EOF\n")) {
+		t.Fatalf("unexpected pdf framing: %q", content[:20])
+	}
+
+	var xref int
+	if _, err := fmt.Sscanf(string(content[bytes.LastIndex(content, []byte("startxref\n")):]), "startxref\n%d", &xref); err != nil {
+		t.Fatalf("parse startxref failed: %v", err)
+	}
+	lines := strings.Split(string(content[xref:]), "\n")
+	var count int
+	if lines[0] != "xref" || func() error { _, err := fmt.Sscanf(lines[1], "0 %d", &count); return err }() != nil {
+		t.Fatalf("unexpected xref header: %q", lines[:2])
+	}
+	for object := 1; object < count; object++ {
+		var offset int
+		if _, err := fmt.Sscanf(lines[2+object], "%010d 00000 n ", &offset); err != nil {
+			t.Fatalf("parse xref entry %d failed: %v", object, err)
+		}
+		if !bytes.HasPrefix(content[offset:], []byte(fmt.Sprintf("%d 0 obj\n", object))) {
+			t.Fatalf("xref entry %d points to %q", object, content[offset:offset+10])
+		}
+	}
+
+	// 概要、语言表与 100 个文件的榜单跨越三页，表头在每页重复。
+	if pages := bytes.Count(content, []byte("/Type /Page /Parent")); pages != 3 {
+		t.Fatalf("expected 3 pages, got %d", pages)
+	}
+	if !bytes.Contains(content, []byte("/Count 3")) {
+		t.Fatal("expected page tree count to match")
+	}
+
+	if pdfEscape("a(b)\\c 中") != `a\(b\)\\c ?` {
+		t.Fatalf("unexpected escape: %q", pdfEscape("a(b)\\c 中"))
+	}
+	if fitted := fitText("internal/scanner/scanner.go", 60, 9); !strings.HasPrefix(fitted, "...") || !strings.HasSuffix(fitted, "scanner.go") || textWidth(fitted, 9) > 60 {
+		t.Fatalf("unexpected fitted text %q", fitted)
+	}
+}
+
+// TestTreemapFormats 验证 d3 层级 JSON、folded 格式与矩形树图布局，以及只汇总结果被拒绝。
+func TestTreemapFormats(t *testing.T) {
+	result := model.ScanResult{ScannedPath: "repo", Files: []model.FileMetrics{
+		{Path: "src/app/main.go", Language: "Go", Metrics: model.LineMetrics{Code: 60}},
+		{Path: "src/app/util.go", Language: "Go", Metrics: model.LineMetrics{Code: 20}},
+		{Path: "src/lib;v2.py", Language: "Python", Metrics: model.LineMetrics{Code: 15}},
+		{Path: "README.md", Language: "Markdown", Metrics: model.LineMetrics{Code: 5}},
+		{Path: "empty.go", Language: "Go", Metrics: model.LineMetrics{Blank: 3}},
+	}}
+
+	var folded bytes.Buffer
+	if err := PrintFolded(&folded, result); err != nil {
+		t.Fatalf("print folded failed: %v", err)
+	}
+	if folded.String() != "README.md 5\nsrc;app;main.go 60\nsrc;app;util.go 20\nsrc;lib_v2.py 15\n" {
+		t.Fatalf("unexpected folded output:\n%s", folded.String())
+	}
+
+	var tree bytes.Buffer
+	if err := PrintTreeJSON(&tree, result); err != nil {
+		t.Fatalf("print tree failed: %v", err)
+	}
+	var root struct {
+		Name     string `json:"name"`
+		Value    *int64 `json:"value"`
+		Children []struct {
+			Name     string            `json:"name"`
+			Value    *int64            `json:"value"`
+			Children []json.RawMessage `json:"children"`
+		} `json:"children"`
+	}
+	if err := json.Unmarshal(tree.Bytes(), &root); err != nil {
+		t.Fatalf("parse tree failed: %v", err)
+	}
+	if root.Name != "repo" || root.Value != nil || len(root.Children) != 2 {
+		t.Fatalf("unexpected root: %s", tree.String())
+	}
+	if root.Children[0].Name != "README.md" || *root.Children[0].Value != 5 || root.Children[1].Value != nil || len(root.Children[1].Children) != 2 {
+		t.Fatalf("unexpected children: %s", tree.String())
+	}
+
+	placed := squarify([]float64{6, 6, 4, 3, 2, 2, 1}, treemapRect{width: 6, height: 4})
+	area := 0.0
+	for _, rect := range placed {
+		area += rect.width * rect.height
+		if rect.x < 0 || rect.y < 0 || rect.x+rect.width > 6.0001 || rect.y+rect.height > 4.0001 {
+			t.Fatalf("rect out of bounds: %+v", rect)
+		}
+	}
+	if len(placed) != 7 || math.Abs(area-24) > 1e-6 || math.Abs(placed[0].width*placed[0].height-6) > 1e-6 {
+		t.Fatalf("unexpected layout: %+v", placed)
+	}
+
+	var page bytes.Buffer
+	if err := PrintTreemapHTML(&page, result); err != nil {
+		t.Fatalf("print treemap failed: %v", err)
+	}
+	if !strings.Contains(page.String(), "src/app/main.go — 60 code lines") || !strings.Contains(page.String(), "Go (80)") {
+		t.Fatalf("unexpected treemap page:\n%s", page.String())
+	}
+
+	result.SummaryOnly = true
+	if err := PrintFolded(io.Discard, result); err == nil {
+		t.Fatal("expected summary-only result to be rejected")
+	}
+}
+
+// TestPrintTeamCity 验证总计与各语言的统计消息、标签对应的构建标签，以及属性值中特殊字符的转义。
+// TestPrintTableVariants 验证 C/C++ 的 Header/Implementation 子类别行输出比例列，
+// 语言汇总各行（含子类别行之后的语言行）与表头按列对齐。
+func TestPrintTableVariants(t *testing.T) {
+	result := model.ScanResult{ScannedPath: ".", Files: []model.FileMetrics{
+		{Path: "lib.h", Language: "C/C++", Variant: "Header", Metrics: model.LineMetrics{Total: 5, Code: 4, Comment: 1}},
+		{Path: "lib.c", Language: "C/C++", Variant: "Implementation", Metrics: model.LineMetrics{Total: 12, Code: 8, Comment: 4}},
+		{Path: "main.go", Language: "Go", Metrics: model.LineMetrics{Total: 9, Code: 8, Blank: 1}},
+	}}
+	result.Summarize(model.SummaryOptions{})
+
+	var output bytes.Buffer
+	if err := PrintTable(&output, result); err != nil {
+		t.Fatalf("print table failed: %v", err)
+	}
+	lines := strings.Split(output.String(), "\n")
+	start := -1
+	for index, line := range lines {
+		if strings.HasPrefix(line, "LANGUAGE") {
+			start = index
+			break
+		}
+	}
+	if start < 0 || start+5 > len(lines) {
+		t.Fatalf("missing language summary:\n%s", output.String())
+	}
+	header := lines[start]
+	rows := lines[start+1 : start+5]
+	for index, prefix := range []string{"C/C++", "  Header", "  Implementation", "Go"} {
+		if !strings.HasPrefix(rows[index], prefix) {
+			t.Fatalf("row %d = %q, want prefix %q", index, rows[index], prefix)
+		}
+	}
+	if !strings.HasSuffix(strings.Join(strings.Fields(rows[1]), " "), "0.25 20.0%") ||
+		!strings.HasSuffix(strings.Join(strings.Fields(rows[2]), " "), "0.50 40.0%") {
+		t.Fatalf("unexpected variant ratios:\n%s", strings.Join(rows, "\n"))
+	}
+	for _, column := range []string{"FILES", "ULOC", "COMMENT/CODE", "CODE SHARE"} {
+		offset := strings.Index(header, column)
+		for _, row := range rows {
+			if offset >= len(row) || row[offset-1] != ' ' || row[offset] == ' ' {
+				t.Fatalf("column %s misaligned at offset %d:\n%s\n%s", column, offset, header, strings.Join(rows, "\n"))
+			}
+		}
+	}
+}
+
+func TestPrintTeamCity(t *testing.T) {
+	result := model.ScanResult{Files: []model.FileMetrics{
+		{Path: "main.go", Language: "Go", Metrics: model.LineMetrics{Total: 12, Code: 10, Comment: 1, Blank: 1}},
+		{Path: "odd.x", Language: "Odd'[1]", Metrics: model.LineMetrics{Total: 2, Code: 2}},
+	}}
+	result.Summarize(model.SummaryOptions{})
+
+	var output bytes.Buffer
+	if err := PrintTeamCity(&output, result); err != nil {
+		t.Fatalf("print teamcity failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 13 || lines[2] != "##teamcity[buildStatisticValue key='gocloc.code' value='12']" {
+		t.Fatalf("unexpected messages:\n%s", output.String())
+	}
+	for _, expected := range []string{
+		"##teamcity[buildStatisticValue key='gocloc.code.Go' value='10']",
+		"##teamcity[buildStatisticValue key='gocloc.code.Odd|'|[1|]' value='2']",
+	} {
+		if !strings.Contains(output.String(), expected+"\n") {
+			t.Fatalf("missing %q in:\n%s", expected, output.String())
+		}
+	}
+
+	result.AddLabels(map[string]string{"team": "pay'ments", "env": "ci"})
+	output.Reset()
+	if err := PrintTeamCity(&output, result); err != nil {
+		t.Fatalf("print teamcity failed: %v", err)
+	}
+	if !strings.HasPrefix(output.String(), "##teamcity[addBuildTag 'env=ci']\n##teamcity[addBuildTag 'team=pay|'ments']\n") {
+		t.Fatalf("unexpected label tags:\n%s", output.String())
+	}
+}
+
+// TestPrintPrometheus 验证总计与各语言的指标族、结果标签作为样本标签（名称转换与转义），以及 # EOF 结尾。
+func TestPrintPrometheus(t *testing.T) {
+	result := model.ScanResult{Files: []model.FileMetrics{
+		{Path: "main.go", Language: "Go", Metrics: model.LineMetrics{Total: 12, Code: 10, Comment: 1, Blank: 1}},
+		{Path: "lib.c", Language: "C/C++", Metrics: model.LineMetrics{Total: 2, Code: 2}},
+	}}
+	result.Summarize(model.SummaryOptions{})
+	result.AddLabels(map[string]string{"team": `pay"ments`, "build-id": "42", "language": "mixed"})
+
+	var output bytes.Buffer
+	if err := PrintPrometheus(&output, result); err != nil {
+		t.Fatalf("print prometheus failed: %v", err)
+	}
+	labels := `build_id="42",label_language="mixed",team="pay\"ments"`
+	for _, expected := range []string{
+		"# TYPE gocloc_files gauge\ngocloc_files{" + labels + "} 2\n",
+		`gocloc_lines{type="code",` + labels + "} 12\n",
+		`gocloc_language_files{language="C/C++",` + labels + "} 1\n",
+		`gocloc_language_lines{language="Go",type="comment",` + labels + "} 1\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("missing %q in:\n%s", expected, output.String())
+		}
+	}
+	if !strings.HasSuffix(output.String(), "\n# EOF\n") {
+		t.Fatalf("expected # EOF terminator:\n%s", output.String())
+	}
+
+	result.Labels = nil
+	output.Reset()
+	if err := PrintPrometheus(&output, result); err != nil {
+		t.Fatalf("print prometheus failed: %v", err)
+	}
+	if !strings.Contains(output.String(), "\ngocloc_files 2\n") {
+		t.Fatalf("unexpected samples without labels:\n%s", output.String())
+	}
+
+	// 空键与转换后以 __ 开头的键（Prometheus 保留前缀）加 label_ 前缀，不输出空标签名或保留标签名。
+	result.Labels = map[string]string{"": "empty", "__name__": "override", "--dry": "yes", "_ok": "1"}
+	output.Reset()
+	if err := PrintPrometheus(&output, result); err != nil {
+		t.Fatalf("print prometheus failed: %v", err)
+	}
+	expected := `gocloc_files{_ok="1",label_="empty",label___dry="yes",label___name__="override"} 2` + "\n"
+	if !strings.Contains(output.String(), expected) {
+		t.Fatalf("missing %q in:\n%s", expected, output.String())
+	}
+}
+
+// stubReporter 是测试用的输出格式。
+type stubReporter struct{ name string }
+
+// Name 返回格式名称。
+func (r stubReporter) Name() string { return r.name }
+
+// Render 输出格式名称与文件数。
+func (r stubReporter) Render(writer io.Writer, result model.ScanResult) error {
+	_, err := fmt.Fprintf(writer, "%s:%d", r.name, result.Total.Files)
+	return err
+}
+
+// TestRegistry 验证内置格式的特性、不区分大小写的查找，以及同名注册原位替换、新名称追加到末尾。
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	reporter, ok := registry.Lookup(" TreeMap ")
+	if !ok || reporter.Name() != "treemap" || !TraitsOf(reporter).RequiresFiles || !TraitsOf(reporter).Document {
+		t.Fatalf("unexpected treemap reporter: %v %+v", ok, TraitsOf(reporter))
+	}
+	if reporter, _ := registry.Lookup("json"); TraitsOf(reporter).Document {
+		t.Fatal("json output should not be a document")
+	}
+
+	registry.Register(stubReporter{name: "JSON"})
+	registry.Register(stubReporter{name: "custom"})
+	names := strings.Join(registry.Names(), ",")
+	if names != "table,JSON,pdf,d3,folded,treemap,teamcity,prometheus,custom" {
+		t.Fatalf("unexpected names: %s", names)
+	}
+	if TraitsOf(stubReporter{}) != (Traits{}) {
+		t.Fatal("expected zero traits for reporter without TraitsDescriber")
+	}
+	var output bytes.Buffer
+	reporter, _ = registry.Lookup("json")
+	if err := reporter.Render(&output, model.ScanResult{Total: model.TotalMetrics{Files: 3}}); err != nil || output.String() != "JSON:3" {
+		t.Fatalf("unexpected output %q (%v)", output.String(), err)
+	}
+}
+
+// TestCommandReporter 验证插件描述的解析，以及插件从标准输入收到带 schema_version 的 JSON 结果。
+func TestCommandReporter(t *testing.T) {
+	for _, spec := range []string{"", "name", "name:", ":cat"} {
+		if _, err := ParseReporterSpec(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+	definition, err := ParseReporterSpec("echo: cat -")
+	if err != nil || definition.Name != "echo" || strings.Join(definition.Command, " ") != "cat -" {
+		t.Fatalf("unexpected definition %+v (%v)", definition, err)
+	}
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is not available")
+	}
+
+	var output bytes.Buffer
+	if err := (CommandReporter{Definition: definition}).Render(&output, model.ScanResult{ScannedPath: "repo"}); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	var echoed model.ScanResult
+	if err := json.Unmarshal(output.Bytes(), &echoed); err != nil || echoed.ScannedPath != "repo" || echoed.SchemaVersion != model.SchemaVersion {
+		t.Fatalf("unexpected plugin input %s (%v)", output.String(), err)
+	}
+
+	failing := CommandReporter{Definition: ReporterDefinition{Name: "broken", Command: []string{"cat", "/nonexistent/input"}}}
+	output.Reset()
+	if err := failing.Render(&output, model.ScanResult{}); err == nil || output.Len() != 0 {
+		t.Fatalf("expected error without output, got %q (%v)", output.String(), err)
+	}
+}
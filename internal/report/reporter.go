@@ -0,0 +1,136 @@
+package report
+
+import (
+	"io"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// Reporter 定义一种扫描结果的输出格式。
+// 实现后注册到 Registry 即可通过 scan --format <name> 选择，命令层不需要为新格式增加分支。
+type Reporter interface {
+	// Name 返回格式名称（即 --format 的取值），比较时不区分大小写。
+	Name() string
+	// Render 把扫描结果按该格式写入 writer。
+	Render(writer io.Writer, result model.ScanResult) error
+}
+
+// Traits 描述输出格式的特性，供命令层校验参数与安排提示信息。
+type Traits struct {
+	// Description 为 --format 帮助中的简短说明。
+	Description string
+	// RequiresFiles 为 true 时输出需要文件级明细，不能用于 --summary-only 的结果。
+	RequiresFiles bool
+	// Document 为 true 时输出是完整的文档（PDF、HTML 等），JSON 导出提示等附加信息写到标准错误，避免混入输出内容。
+	Document bool
+}
+
+// TraitsDescriber 是 Reporter 可选实现的接口，用于声明格式特性；未实现时特性为零值。
+type TraitsDescriber interface {
+	Traits() Traits
+}
+
+// TraitsOf 返回 reporter 声明的特性。
+func TraitsOf(reporter Reporter) Traits {
+	if describer, ok := reporter.(TraitsDescriber); ok {
+		return describer.Traits()
+	}
+	return Traits{}
+}
+
+// funcReporter 把渲染函数包装为 Reporter，用于内置格式。
+type funcReporter struct {
+	name   string
+	traits Traits
+	render func(writer io.Writer, result model.ScanResult) error
+}
+
+// Name 返回格式名称。
+func (r funcReporter) Name() string {
+	return r.name
+}
+
+// Render 调用渲染函数。
+func (r funcReporter) Render(writer io.Writer, result model.ScanResult) error {
+	return r.render(writer, result)
+}
+
+// Traits 返回格式特性。
+func (r funcReporter) Traits() Traits {
+	return r.traits
+}
+
+// PDFReporter 以 PDF 报告格式输出（见 PrintPDF），Options 为报告选项。
+type PDFReporter struct {
+	Options PDFOptions
+}
+
+// Name 返回格式名称 pdf。
+func (r PDFReporter) Name() string {
+	return "pdf"
+}
+
+// Render 输出 PDF 报告。
+func (r PDFReporter) Render(writer io.Writer, result model.ScanResult) error {
+	return PrintPDF(writer, result, r.Options)
+}
+
+// Traits 返回格式特性。
+func (r PDFReporter) Traits() Traits {
+	return Traits{Description: "分页 PDF 报告", Document: true}
+}
+
+// Registry 管理输出格式的注册与按名称查找，保持注册顺序。
+type Registry struct {
+	reporters []Reporter
+}
+
+// NewRegistry 创建并注册所有内置输出格式。
+func NewRegistry() *Registry {
+	return &Registry{reporters: []Reporter{
+		funcReporter{name: "table", traits: Traits{Description: "控制台表格"}, render: PrintTable},
+		funcReporter{name: "json", traits: Traits{Description: "带 schema_version 的 JSON"}, render: PrintJSON},
+		PDFReporter{},
+		funcReporter{name: "d3", traits: Traits{Description: "d3-hierarchy 目录层级 JSON", RequiresFiles: true, Document: true}, render: PrintTreeJSON},
+		funcReporter{name: "folded", traits: Traits{Description: "火焰图 folded 格式", RequiresFiles: true, Document: true}, render: PrintFolded},
+		funcReporter{name: "treemap", traits: Traits{Description: "矩形树图 HTML 页面", RequiresFiles: true, Document: true}, render: PrintTreemapHTML},
+		funcReporter{name: "teamcity", traits: Traits{Description: "TeamCity 构建统计服务消息"}, render: PrintTeamCity},
+		funcReporter{name: "prometheus", traits: Traits{Description: "Prometheus/OpenMetrics 文本格式指标，标签作为指标标签", Document: true}, render: PrintPrometheus},
+	}}
+}
+
+// Register 注册输出格式，与已注册格式同名（不区分大小写）时原位替换，否则追加到末尾。
+func (r *Registry) Register(reporter Reporter) {
+	for index, existing := range r.reporters {
+		if strings.EqualFold(existing.Name(), reporter.Name()) {
+			r.reporters[index] = reporter
+			return
+		}
+	}
+	r.reporters = append(r.reporters, reporter)
+}
+
+// Lookup 按名称查找输出格式，名称比较不区分大小写。
+func (r *Registry) Lookup(name string) (Reporter, bool) {
+	for _, reporter := range r.reporters {
+		if strings.EqualFold(reporter.Name(), strings.TrimSpace(name)) {
+			return reporter, true
+		}
+	}
+	return nil, false
+}
+
+// Reporters 返回已注册的输出格式，按注册顺序排列。
+func (r *Registry) Reporters() []Reporter {
+	return append([]Reporter(nil), r.reporters...)
+}
+
+// Names 返回已注册的格式名称，按注册顺序排列。
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.reporters))
+	for _, reporter := range r.reporters {
+		names = append(names, reporter.Name())
+	}
+	return names
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// teamCityEscaper 按 TeamCity 服务消息的规则转义属性值。
+var teamCityEscaper = strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]")
+
+// PrintTeamCity 输出 TeamCity 服务消息（##teamcity[buildStatisticValue ...]），TeamCity 据此直接绘制统计图：
+// - 总计的键为 gocloc.files、gocloc.total、gocloc.code、gocloc.comment、gocloc.blank
+// - 每个语言的键为 gocloc.<指标>.<语言>（指标为 files、code、comment、blank），例如 gocloc.code.Go
+// - 结果带标签时，每个标签先输出为一个构建标签（##teamcity[addBuildTag 'key=value']）
+func PrintTeamCity(writer io.Writer, result model.ScanResult) error {
+	emit := func(key string, value int64) error {
+		_, err := fmt.Fprintf(writer, "##teamcity[buildStatisticValue key='%s' value='%d']\n", teamCityEscaper.Replace(key), value)
+		return err
+	}
+
+	for _, pair := range model.LabelPairs(result.Labels) {
+		if _, err := fmt.Fprintf(writer, "##teamcity[addBuildTag '%s']\n", teamCityEscaper.Replace(pair)); err != nil {
+			return err
+		}
+	}
+	if err := emit("gocloc.files", result.Total.Files); err != nil {
+		return err
+	}
+	if err := emit("gocloc.total", result.Total.Total); err != nil {
+		return err
+	}
+	if err := emit("gocloc.code", result.Total.Code); err != nil {
+		return err
+	}
+	if err := emit("gocloc.comment", result.Total.Comment); err != nil {
+		return err
+	}
+	if err := emit("gocloc.blank", result.Total.Blank); err != nil {
+		return err
+	}
+	for _, item := range result.Languages {
+		if err := emit("gocloc.files."+item.Language, item.Files); err != nil {
+			return err
+		}
+		if err := emit("gocloc.code."+item.Language, item.Metrics.Code); err != nil {
+			return err
+		}
+		if err := emit("gocloc.comment."+item.Language, item.Metrics.Comment); err != nil {
+			return err
+		}
+		if err := emit("gocloc.blank."+item.Language, item.Metrics.Blank); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// errTreemapSummaryOnly 表示结果没有文件级明细，无法构建目录层级。
+var errTreemapSummaryOnly = errors.New("treemap output requires per-file results, the result was scanned with --summary-only")
+
+// sizeNode 是目录层级中的一个节点：目录的 Code 为其下全部文件之和，文件的 Children 为空。
+type sizeNode struct {
+	Name     string      `json:"name"`
+	Path     string      `json:"path,omitempty"`
+	Language string      `json:"language,omitempty"`
+	Code     int64       `json:"-"`
+	Value    *int64      `json:"value,omitempty"`
+	Children []*sizeNode `json:"children,omitempty"`
+}
+
+// buildSizeTree 按路径把文件组织成 目录 → 文件 的层级，只包含代码行大于 0 的文件，子节点按名称排序。
+func buildSizeTree(result model.ScanResult) (*sizeNode, error) {
+	if result.SummaryOnly {
+		return nil, errTreemapSummaryOnly
+	}
+	root := &sizeNode{Name: result.ScannedPath}
+	if root.Name == "" {
+		root.Name = "."
+	}
+	directories := map[string]*sizeNode{"": root}
+	for _, item := range result.Files {
+		if item.Metrics.Code <= 0 {
+			continue
+		}
+		parent := root
+		segments := strings.Split(item.Path, "/")
+		for index, segment := range segments[:len(segments)-1] {
+			key := strings.Join(segments[:index+1], "/")
+			directory, ok := directories[key]
+			if !ok {
+				directory = &sizeNode{Name: segment, Path: key}
+				directories[key] = directory
+				parent.Children = append(parent.Children, directory)
+			}
+			parent = directory
+		}
+		code := item.Metrics.Code
+		parent.Children = append(parent.Children, &sizeNode{
+			Name:     segments[len(segments)-1],
+			Path:     item.Path,
+			Language: item.Language,
+			Code:     code,
+			Value:    &code,
+		})
+	}
+	root.sum()
+	return root, nil
+}
+
+// sum 自下而上累加目录的代码行，并按名称排序子节点。
+func (n *sizeNode) sum() int64 {
+	if n.Value != nil {
+		return n.Code
+	}
+	n.Code = 0
+	for _, child := range n.Children {
+		n.Code += child.sum()
+	}
+	sort.Slice(n.Children, func(i int, j int) bool {
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	return n.Code
+}
+
+// PrintTreeJSON 按 d3-hierarchy 的格式输出 目录 → 文件 的代码行层级：节点为 {"name", "children"}，
+// 文件节点带 "value"（代码行）、"path" 与 "language"，目录不带 value，可直接用 d3.hierarchy(data).sum(d => d.value) 渲染。
+func PrintTreeJSON(writer io.Writer, result model.ScanResult) error {
+	root, err := buildSizeTree(result)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal json: %w", err)
+	}
+	if _, err := writer.Write(content); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
+
+// PrintFolded 按 Brendan Gregg 的 folded 格式输出，每个文件一行："目录;子目录;文件 代码行"，
+// 可直接交给 flamegraph.pl 或 speedscope 等渲染；路径中的 ; 替换为 _。
+func PrintFolded(writer io.Writer, result model.ScanResult) error {
+	if result.SummaryOnly {
+		return errTreemapSummaryOnly
+	}
+	files := append([]model.FileMetrics(nil), result.Files...)
+	sort.Slice(files, func(i int, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	for _, item := range files {
+		if item.Metrics.Code <= 0 {
+			continue
+		}
+		stack := strings.ReplaceAll(strings.ReplaceAll(item.Path, ";", "_"), "/", ";")
+		if _, err := fmt.Fprintf(writer, "%s %d\n", stack, item.Metrics.Code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 矩形树图页面的尺寸（像素）与目录标题栏高度。
+const (
+	treemapWidth  = 1200.0
+	treemapHeight = 800.0
+	treemapHeader = 14.0
+)
+
+// treemapRect 是布局后的一个矩形。
+type treemapRect struct {
+	x, y, width, height float64
+}
+
+// PrintTreemapHTML 输出自包含的 HTML 矩形树图页面（不依赖脚本与外部资源）：面积与代码行成正比，颜色区分语言，
+// 目录以带标题的边框包围，悬停显示路径与代码行。布局为 squarified 算法，过小而无法显示的文件被省略。
+func PrintTreemapHTML(writer io.Writer, result model.ScanResult) error {
+	root, err := buildSizeTree(result)
+	if err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	languages := make(map[string]int64)
+	renderTreemap(&body, root, treemapRect{width: treemapWidth, height: treemapHeight}, languages, true)
+
+	legend := make([]string, 0, len(languages))
+	for language := range languages {
+		legend = append(legend, language)
+	}
+	sort.Slice(legend, func(i int, j int) bool {
+		if languages[legend[i]] != languages[legend[j]] {
+			return languages[legend[i]] > languages[legend[j]]
+		}
+		return legend[i] < legend[j]
+	})
+
+	title := html.EscapeString(root.Name)
+	var page strings.Builder
+	fmt.Fprintf(&page, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>gocloc treemap - %s</title>\n", title)
+	page.WriteString("<style>body{font-family:sans-serif;margin:16px}svg text{font-size:11px;pointer-events:none}" +
+		".legend span{display:inline-block;margin-right:12px}.legend i{display:inline-block;width:10px;height:10px;margin-right:4px}</style>\n</head>\n<body>\n")
+	fmt.Fprintf(&page, "<h1>%s</h1>\n<p>%d code lines in %d files</p>\n<div class=\"legend\">", title, root.Code, result.Total.Files)
+	for _, language := range legend {
+		fmt.Fprintf(&page, "<span><i style=\"background:%s\"></i>%s (%d)</span>", languageColor(language), html.EscapeString(language), languages[language])
+	}
+	fmt.Fprintf(&page, "</div>\n<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.0f %.0f\">\n", treemapWidth, treemapHeight, treemapWidth, treemapHeight)
+	page.WriteString(body.String())
+	page.WriteString("</svg>\n</body>\n</html>\n")
+
+	if _, err := io.WriteString(writer, page.String()); err != nil {
+		return fmt.Errorf("write html: %w", err)
+	}
+	return nil
+}
+
+// renderTreemap 把节点画进矩形：文件为填充矩形，目录为边框加标题栏（根目录不画），子节点按 squarified 布局。
+func renderTreemap(builder *strings.Builder, node *sizeNode, rect treemapRect, languages map[string]int64, root bool) {
+	if rect.width < 1 || rect.height < 1 {
+		return
+	}
+	tooltip := html.EscapeString(fmt.Sprintf("%s — %d code lines", node.Path, node.Code))
+	if node.Value != nil {
+		languages[node.Language] += node.Code
+		fmt.Fprintf(builder, "<g><title>%s</title><rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\" stroke=\"#fff\"/>",
+			tooltip, rect.x, rect.y, rect.width, rect.height, languageColor(node.Language))
+		if rect.width > 40 && rect.height > 14 {
+			fmt.Fprintf(builder, "<text x=\"%.1f\" y=\"%.1f\">%s</text>", rect.x+3, rect.y+12, html.EscapeString(truncateLabel(node.Name, rect.width)))
+		}
+		builder.WriteString("</g>\n")
+		return
+	}
+
+	inner := rect
+	if !root {
+		fmt.Fprintf(builder, "<g><title>%s</title><rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"#eee\" stroke=\"#999\"/>",
+			tooltip, rect.x, rect.y, rect.width, rect.height)
+		if rect.width > 30 && rect.height > treemapHeader*2 {
+			fmt.Fprintf(builder, "<text x=\"%.1f\" y=\"%.1f\">%s/</text>", rect.x+3, rect.y+11, html.EscapeString(truncateLabel(node.Name, rect.width)))
+			inner = treemapRect{x: rect.x + 1, y: rect.y + treemapHeader, width: rect.width - 2, height: rect.height - treemapHeader - 1}
+		}
+		builder.WriteString("</g>\n")
+	}
+
+	children := append([]*sizeNode(nil), node.Children...)
+	sort.SliceStable(children, func(i int, j int) bool {
+		return children[i].Code > children[j].Code
+	})
+	values := make([]float64, len(children))
+	for index, child := range children {
+		values[index] = float64(child.Code)
+	}
+	for index, placed := range squarify(values, inner) {
+		renderTreemap(builder, children[index], placed, languages, false)
+	}
+}
+
+// squarify 按 squarified 算法（Bruls 等）把降序排列的值布局到矩形中，使各矩形尽量接近正方形，返回与 values 一一对应的矩形。
+func squarify(values []float64, rect treemapRect) []treemapRect {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	placed := make([]treemapRect, 0, len(values))
+	if total <= 0 || rect.width <= 0 || rect.height <= 0 {
+		for range values {
+			placed = append(placed, treemapRect{x: rect.x, y: rect.y})
+		}
+		return placed
+	}
+	scale := rect.width * rect.height / total
+	areas := make([]float64, len(values))
+	for index, value := range values {
+		areas[index] = value * scale
+	}
+
+	start := 0
+	for start < len(areas) {
+		side := math.Min(rect.width, rect.height)
+		end := start + 1
+		for end < len(areas) && worstRatio(areas[start:end+1], side) <= worstRatio(areas[start:end], side) {
+			end++
+		}
+
+		row := areas[start:end]
+		sum := 0.0
+		for _, area := range row {
+			sum += area
+		}
+		if rect.width >= rect.height {
+			// 矩形较宽时，这一行作为左侧的一列，各项自上而下排列。
+			width := sum / rect.height
+			y := rect.y
+			for _, area := range row {
+				height := area / width
+				placed = append(placed, treemapRect{x: rect.x, y: y, width: width, height: height})
+				y += height
+			}
+			rect.x += width
+			rect.width -= width
+		} else {
+			height := sum / rect.width
+			x := rect.x
+			for _, area := range row {
+				width := area / height
+				placed = append(placed, treemapRect{x: x, y: rect.y, width: width, height: height})
+				x += width
+			}
+			rect.y += height
+			rect.height -= height
+		}
+		start = end
+	}
+	return placed
+}
+
+// worstRatio 返回一行面积沿长度为 side 的边排列时最差的长宽比（>= 1）。
+func worstRatio(row []float64, side float64) float64 {
+	sum, smallest, largest := 0.0, math.Inf(1), 0.0
+	for _, area := range row {
+		sum += area
+		smallest = math.Min(smallest, area)
+		largest = math.Max(largest, area)
+	}
+	if sum <= 0 || smallest <= 0 {
+		return math.Inf(1)
+	}
+	squared := side * side
+	return math.Max(squared*largest/(sum*sum), sum*sum/(squared*smallest))
+}
+
+// languageColor 按语言名的哈希选取色相，同一语言在不同报告中颜色一致。
+func languageColor(language string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(language))
+	return fmt.Sprintf("hsl(%d,55%%,65%%)", hash.Sum32()%360)
+}
+
+// truncateLabel 按约 7 像素一个字符截断标签以适应矩形宽度。
+func truncateLabel(label string, width float64) string {
+	limit := int((width - 6) / 7)
+	runes := []rune(label)
+	if len(runes) <= limit {
+		return label
+	}
+	if limit <= 1 {
+		return ""
+	}
+	return string(runes[:limit-1]) + "…"
+}
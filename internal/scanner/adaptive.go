@@ -0,0 +1,114 @@
+package scanner
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	// adaptiveInterval 是自适应并发每次评估 CPU 利用率的间隔。
+	adaptiveInterval = 200 * time.Millisecond
+	// adaptiveLowUtilization 以下视为 worker 主要在等待 I/O，增加并发。
+	adaptiveLowUtilization = 0.6
+	// adaptiveHighUtilization 以上视为 CPU 已饱和，多余的并发只会增加调度开销。
+	adaptiveHighUtilization = 0.9
+)
+
+// workerGate 限制同时执行分析的 worker 数量，上限可以在扫描过程中调整。
+// worker 池按最大并发启动，gate 决定其中有多少个可以同时工作。
+type workerGate struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+// newWorkerGate 创建初始上限为 limit 的 gate。
+func newWorkerGate(limit int) *workerGate {
+	gate := &workerGate{limit: limit}
+	gate.cond = sync.NewCond(&gate.mu)
+	return gate
+}
+
+// acquire 阻塞直到正在工作的 worker 数量低于上限。
+func (g *workerGate) acquire() {
+	g.mu.Lock()
+	for g.active >= g.limit {
+		g.cond.Wait()
+	}
+	g.active++
+	g.mu.Unlock()
+}
+
+// release 归还 acquire 占用的名额。
+func (g *workerGate) release() {
+	g.mu.Lock()
+	g.active--
+	g.mu.Unlock()
+	g.cond.Signal()
+}
+
+// setLimit 调整上限（至少为 1），调高时唤醒等待中的 worker。
+func (g *workerGate) setLimit(limit int) {
+	g.mu.Lock()
+	g.limit = max(limit, 1)
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
+
+// currentLimit 返回当前上限。
+func (g *workerGate) currentLimit() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.limit
+}
+
+// adaptWorkers 周期性比较进程 CPU 时间与墙钟时间，据此调整 gate 的上限，直到 ctx 结束。
+//
+// 利用率 = CPU 时间增量 / (墙钟时间 × min(当前上限, CPU 核心数))：
+// - 利用率低说明 worker 大多在等待 I/O（网络挂载、冷缓存），按倍数增加并发直到 maximum
+// - 利用率高说明 CPU 已饱和（SSD 上的热缓存仓库），逐步回落到 CPU 核心数
+// 当前平台无法取得进程 CPU 时间时直接使用 maximum。
+func adaptWorkers(ctx context.Context, gate *workerGate, maximum int, logger *slog.Logger) {
+	lastCPU, ok := processCPUTime()
+	if !ok {
+		gate.setLimit(maximum)
+		return
+	}
+	lastWall := time.Now()
+	ticker := time.NewTicker(adaptiveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			cpu, _ := processCPUTime()
+			limit := gate.currentLimit()
+			busy := min(limit, runtime.NumCPU())
+			utilization := float64(cpu-lastCPU) / (float64(now.Sub(lastWall)) * float64(busy))
+			lastCPU, lastWall = cpu, now
+
+			if next := nextWorkerLimit(limit, maximum, utilization); next != limit {
+				gate.setLimit(next)
+				logger.DebugContext(ctx, "workers adjusted", "from", limit, "to", next, "cpu_utilization", utilization)
+			}
+		}
+	}
+}
+
+// nextWorkerLimit 根据利用率计算下一个并发上限，结果在 [1, maximum] 内，且不会因 CPU 饱和降到核心数以下。
+func nextWorkerLimit(limit int, maximum int, utilization float64) int {
+	switch {
+	case utilization < adaptiveLowUtilization && limit < maximum:
+		return min(limit*2, maximum)
+	case utilization > adaptiveHighUtilization && limit > runtime.NumCPU():
+		return max(limit-max(limit/4, 1), runtime.NumCPU(), 1)
+	default:
+		return limit
+	}
+}
@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"sync"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// CacheKey 标识一个本地文件的某个版本：路径、大小与修改时间都不变时认为内容未变化。
+type CacheKey struct {
+	// LocalPath 为文件在本地文件系统中的路径（Entry.LocalPath）。
+	LocalPath string
+	Size      int64
+	// ModTime 为修改时间的 Unix 纳秒值。
+	ModTime int64
+}
+
+// Cache 保存单文件分析结果，命中时跳过文件读取与分析，供 daemon 等长期运行的场景跨扫描复用。
+// 实现方需要保证并发安全；同一个 Cache 只应用于分析选项相同的扫描，否则会读到按其他选项得到的结果。
+type Cache interface {
+	Get(key CacheKey) (model.FileMetrics, bool)
+	Put(key CacheKey, file model.FileMetrics)
+}
+
+// MemoryCache 是进程内的 Cache 实现，每个本地路径只保留最新版本的结果，条目在进程退出前不会过期；
+// 本地文件被删除后条目仍然保留，可以用 Prune 清理。
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	hits    int64
+	misses  int64
+}
+
+// cacheEntry 是 MemoryCache 中某个路径的最新版本结果。
+type cacheEntry struct {
+	key  CacheKey
+	file model.FileMetrics
+}
+
+// MemoryCacheStats 是 MemoryCache 的命中统计。
+type MemoryCacheStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+// NewMemoryCache 创建空的进程内缓存。
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]cacheEntry)}
+}
+
+// Get 在路径、大小与修改时间都一致时返回缓存结果的深拷贝，调用方可以自由修改。
+func (c *MemoryCache) Get(key CacheKey) (model.FileMetrics, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key.LocalPath]
+	if !ok || entry.key != key {
+		c.misses++
+		return model.FileMetrics{}, false
+	}
+	c.hits++
+	file := entry.file
+	file.Metrics = file.Metrics.Clone()
+	return file, true
+}
+
+// Put 保存结果的深拷贝，覆盖同一路径的旧版本。
+func (c *MemoryCache) Put(key CacheKey, file model.FileMetrics) {
+	file.Metrics = file.Metrics.Clone()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key.LocalPath] = cacheEntry{key: key, file: file}
+}
+
+// Prune 删除 keep 返回 false 的条目（例如本地文件已被删除），返回删除的条目数。
+func (c *MemoryCache) Prune(keep func(key CacheKey) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for localPath, entry := range c.entries {
+		if !keep(entry.key) {
+			delete(c.entries, localPath)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Stats 返回当前条目数与累计命中/未命中次数。
+func (c *MemoryCache) Stats() MemoryCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return MemoryCacheStats{Entries: len(c.entries), Hits: c.hits, Misses: c.misses}
+}
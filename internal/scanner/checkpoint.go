@@ -0,0 +1,259 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// DefaultCheckpointInterval 是 Checkpoint.Interval 的默认值。
+const DefaultCheckpointInterval = 10 * time.Second
+
+// checkpointVersion 是检查点文件格式的版本号。
+const checkpointVersion = 2
+
+// Checkpoint 把扫描中已完成的文件结果定期写入磁盘，中断（进程被杀、机器重启）的长时间扫描可以从中恢复，
+// 只分析尚未完成的文件，而不是从头开始。
+//
+// 文件为 JSON Lines：首行记录格式版本与注册中心指纹，其后每行是一个扫描根目录下已完成的文件结果。
+// 剩余队列不单独保存：恢复时重新遍历扫描根目录，已完成的文件在发现阶段跳过，其余文件即为剩余队列，
+// 因此中断期间新增或删除的文件也能被正确处理。分析失败的文件不写入检查点，恢复时会重试。
+//
+// 使用约定：
+// - 同一个 Checkpoint 可以用于多个根目录的扫描（例如 ScanPaths），记录按根目录区分
+// - 恢复时分析选项、语言开关或后缀映射与检查点不一致会返回错误，避免混合不同口径的结果
+// - ULOC 去重与重复检测依赖的代码行哈希随文件记录一起写入，恢复的文件与重新分析的文件结果一致
+// - 中断期间修改过的已完成文件不会重新分析，沿用检查点中的结果
+// - 全部扫描完成后由调用方调用 Remove 删除检查点；扫描失败时调用 Close 保留检查点以便恢复
+type Checkpoint struct {
+	// Interval 为两次写盘之间的最短间隔，<=0 时使用 DefaultCheckpointInterval。
+	// 间隔内完成的文件先在内存中缓冲，中断时最多丢失一个间隔的结果（恢复后重新分析）。
+	Interval time.Duration
+
+	path string
+	mu   sync.Mutex
+	file *os.File
+	// writer 为 nil 表示尚未写入首行（新建的检查点在第一次扫描开始时写入）。
+	writer      *bufio.Writer
+	fingerprint string
+	lastFlush   time.Time
+	// restored 为恢复时读回的结果，按根目录与路径索引。
+	restored map[string]map[string]model.FileMetrics
+}
+
+// checkpointHeader 是检查点文件的首行。
+type checkpointHeader struct {
+	Version     int    `json:"version"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// checkpointRecord 是检查点文件中的一条已完成文件记录。
+// FileMetrics 的 JSON 不含代码行哈希集合与代码行，因此与 contentRecord 一样单独保存。
+type checkpointRecord struct {
+	Root        string            `json:"root"`
+	File        model.FileMetrics `json:"file"`
+	UniqueLines []uint64          `json:"unique_lines,omitempty"`
+	CodeLines   []model.CodeLine  `json:"code_lines,omitempty"`
+}
+
+// OpenCheckpoint 打开检查点文件。resume 为 true 时读回已有记录（文件不存在时视为空检查点），
+// 末尾因中断而写了一半的记录会被截掉；resume 为 false 时清空已有内容重新开始。
+func OpenCheckpoint(path string, resume bool) (*Checkpoint, error) {
+	flags := os.O_RDWR | os.O_CREATE
+	if !resume {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open checkpoint: %w", err)
+	}
+
+	checkpoint := &Checkpoint{path: path, file: file, restored: make(map[string]map[string]model.FileMetrics)}
+	if err := checkpoint.load(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// load 读回已有记录，并把文件截断到最后一条完整记录之后，后续记录从该位置追加。
+func (c *Checkpoint) load() error {
+	reader := bufio.NewReader(c.file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read checkpoint: %w", err)
+		}
+
+		if offset == 0 {
+			var header checkpointHeader
+			if json.Unmarshal(line, &header) != nil || header.Version != checkpointVersion || header.Fingerprint == "" {
+				return fmt.Errorf("read checkpoint: %s is not a gocloc checkpoint of version %d", c.path, checkpointVersion)
+			}
+			c.fingerprint = header.Fingerprint
+		} else {
+			var record checkpointRecord
+			if json.Unmarshal(bytes.TrimSpace(line), &record) != nil {
+				break
+			}
+			files, ok := c.restored[record.Root]
+			if !ok {
+				files = make(map[string]model.FileMetrics)
+				c.restored[record.Root] = files
+			}
+			file := record.File
+			file.Metrics.ULOC = 0
+			for _, hash := range record.UniqueLines {
+				file.Metrics.AddUniqueLine(hash)
+			}
+			file.Metrics.CodeLines = record.CodeLines
+			files[file.Path] = file
+		}
+		offset += int64(len(line))
+	}
+
+	if err := c.file.Truncate(offset); err != nil {
+		return fmt.Errorf("truncate checkpoint: %w", err)
+	}
+	if _, err := c.file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek checkpoint: %w", err)
+	}
+	if offset > 0 {
+		c.writer = bufio.NewWriter(c.file)
+	}
+	return nil
+}
+
+// begin 在一次扫描开始时校验注册中心指纹（新建的检查点写入首行），返回该根目录下已完成的文件。
+func (c *Checkpoint) begin(root string, registryFingerprint string) (map[string]model.FileMetrics, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(registryFingerprint))
+	fingerprint := hex.EncodeToString(sum[:])
+	if c.writer != nil {
+		if c.fingerprint != fingerprint {
+			return nil, fmt.Errorf("checkpoint %s was created with different analysis options, remove it or scan without resuming", c.path)
+		}
+		c.lastFlush = time.Now()
+		return c.restored[root], nil
+	}
+
+	c.fingerprint = fingerprint
+	c.writer = bufio.NewWriter(c.file)
+	if err := json.NewEncoder(c.writer).Encode(checkpointHeader{Version: checkpointVersion, Fingerprint: fingerprint}); err != nil {
+		return nil, fmt.Errorf("write checkpoint: %w", err)
+	}
+	c.lastFlush = time.Now()
+	return c.restored[root], c.flushLocked()
+}
+
+// record 追加一个已完成的文件，距上次写盘超过 Interval 时写盘。
+func (c *Checkpoint) record(root string, file model.FileMetrics) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := json.NewEncoder(c.writer).Encode(checkpointRecord{
+		Root:        root,
+		File:        file,
+		UniqueLines: file.Metrics.UniqueLineHashes(),
+		CodeLines:   file.Metrics.CodeLines,
+	}); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+	if time.Since(c.lastFlush) < interval {
+		return nil
+	}
+	c.lastFlush = time.Now()
+	return c.flushLocked()
+}
+
+// flush 把缓冲的记录写盘。
+func (c *Checkpoint) flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flushLocked()
+}
+
+// flushLocked 把缓冲的记录写入文件并同步到磁盘，调用方需持有 mu。
+func (c *Checkpoint) flushLocked() error {
+	if c.writer == nil {
+		return nil
+	}
+	if err := c.writer.Flush(); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return fmt.Errorf("sync checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Restored 返回恢复时读回的已完成文件数（所有根目录合计）。
+func (c *Checkpoint) Restored() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := 0
+	for _, files := range c.restored {
+		count += len(files)
+	}
+	return count
+}
+
+// Close 写盘并关闭检查点，保留文件以便之后恢复。
+func (c *Checkpoint) Close() error {
+	flushErr := c.flush()
+	if err := c.file.Close(); err != nil && flushErr == nil {
+		flushErr = fmt.Errorf("close checkpoint: %w", err)
+	}
+	return flushErr
+}
+
+// Remove 关闭并删除检查点，在全部扫描成功完成后调用。
+func (c *Checkpoint) Remove() error {
+	if err := c.file.Close(); err != nil {
+		return fmt.Errorf("close checkpoint: %w", err)
+	}
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// checkpointWalker 把检查点中已完成的文件交给 restore 而不是 visit，其余文件原样交给 visit。
+// 只有本次遍历仍然存在的已完成文件会被恢复，中断期间删除的文件不会出现在结果中。
+type checkpointWalker struct {
+	Walker
+	completed map[string]model.FileMetrics
+	restore   func(ctx context.Context, entry Entry, file model.FileMetrics)
+}
+
+// Walk 实现 Walker。
+func (w checkpointWalker) Walk(ctx context.Context, visit func(entry Entry) error) error {
+	return w.Walker.Walk(ctx, func(entry Entry) error {
+		if file, ok := w.completed[entry.Path]; ok {
+			w.restore(ctx, entry, file)
+			return nil
+		}
+		return visit(entry)
+	})
+}
@@ -0,0 +1,186 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// ContentKey 标识一份文件内容在某个分析器配置下的分析结果。
+type ContentKey struct {
+	// Digest 为文件内容的 SHA-256 十六进制值。
+	Digest string
+	// Analyzer 为分析器指纹（见 languages.Fingerprint）与影响结果的扫描选项。
+	Analyzer string
+}
+
+// Name 返回键在存储后端中的名称：内容哈希加分析器指纹哈希的前 16 位，可直接用作文件名或对象名。
+func (k ContentKey) Name() string {
+	sum := sha256.Sum256([]byte(k.Analyzer))
+	return k.Digest + "-" + hex.EncodeToString(sum[:])[:16]
+}
+
+// ContentStore 是按名称读写字节的缓存后端，实现方需要保证并发安全。
+type ContentStore interface {
+	// Load 返回名称对应的内容，不存在时返回 false 而不是错误。
+	Load(ctx context.Context, name string) ([]byte, bool, error)
+	// Store 保存内容，覆盖同名条目。
+	Store(ctx context.Context, name string, data []byte) error
+}
+
+// ContentCache 以文件内容哈希为键缓存分析结果，结果持久化在 ContentStore（目录、HTTP 或 S3）中，
+// 与构建缓存类似：多次 CI 扫描大部分未变化的 monorepo 时，只有新的内容需要分析，且不受检出路径与修改时间影响。
+//
+// 与 Cache 的区别：
+// - Cache 以路径、大小与修改时间为键，只在同一进程内有效；ContentCache 跨进程、跨机器共享
+// - ContentCache 命中前仍需读取文件计算哈希，但跳过逐行分析
+// - 键包含分析器指纹，分析选项、自定义语言定义或分析逻辑版本变化时自然失效；外部插件的结果不缓存
+type ContentCache struct {
+	store  ContentStore
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+// ContentCacheStats 是 ContentCache 的命中统计，Errors 为读写后端失败的次数（失败时按未命中处理）。
+type ContentCacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Errors int64 `json:"errors"`
+}
+
+// contentRecord 是缓存条目的序列化格式，只包含由文件内容决定的结果。
+// LineMetrics 的 JSON 不含代码行哈希集合，因此单独保存，保证命中时 ULOC 的跨文件去重与重新分析一致。
+type contentRecord struct {
+	Metrics     model.LineMetrics `json:"metrics"`
+	UniqueLines []uint64          `json:"unique_lines,omitempty"`
+	CodeLines   []model.CodeLine  `json:"code_lines,omitempty"`
+	Shebang     string            `json:"shebang,omitempty"`
+}
+
+// NewContentCache 创建使用 store 持久化的内容缓存。
+func NewContentCache(store ContentStore) *ContentCache {
+	return &ContentCache{store: store}
+}
+
+// Stats 返回累计命中、未命中与后端失败次数。
+func (c *ContentCache) Stats() ContentCacheStats {
+	return ContentCacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Errors: c.errors.Load()}
+}
+
+// lookup 读取缓存结果，后端失败或条目无法解析时返回错误并按未命中计数。
+func (c *ContentCache) lookup(ctx context.Context, key ContentKey) (model.LineMetrics, string, bool, error) {
+	data, ok, err := c.store.Load(ctx, key.Name())
+	if err == nil && ok {
+		var record contentRecord
+		if err = json.Unmarshal(data, &record); err == nil {
+			c.hits.Add(1)
+			metrics := record.Metrics
+			metrics.ULOC = 0
+			for _, hash := range record.UniqueLines {
+				metrics.AddUniqueLine(hash)
+			}
+			metrics.CodeLines = record.CodeLines
+			return metrics, record.Shebang, true, nil
+		}
+		err = fmt.Errorf("decode content cache entry %s: %w", key.Name(), err)
+	}
+	if err != nil {
+		c.errors.Add(1)
+	}
+	c.misses.Add(1)
+	return model.LineMetrics{}, "", false, err
+}
+
+// save 保存分析结果。
+func (c *ContentCache) save(ctx context.Context, key ContentKey, metrics model.LineMetrics, shebang string) error {
+	data, err := json.Marshal(contentRecord{
+		Metrics:     metrics,
+		UniqueLines: metrics.UniqueLineHashes(),
+		CodeLines:   metrics.CodeLines,
+		Shebang:     shebang,
+	})
+	if err != nil {
+		return fmt.Errorf("encode content cache entry: %w", err)
+	}
+	if err := c.store.Store(ctx, key.Name(), data); err != nil {
+		c.errors.Add(1)
+		return err
+	}
+	return nil
+}
+
+// contentDigest 读取 reader 的全部内容并返回 SHA-256 十六进制值。
+func contentDigest(reader io.Reader) (string, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// DirStore 把缓存条目保存为目录下的文件（按名称前两位分子目录），适合挂载为 CI 缓存目录。
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore 创建保存到 dir 的存储，目录在首次写入时创建。
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+// Load 读取条目文件，文件不存在时返回 false。
+func (s *DirStore) Load(_ context.Context, name string) ([]byte, bool, error) {
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("read content cache: %w", err)
+	}
+	return data, true, nil
+}
+
+// Store 先写入临时文件再重命名，多个并发的扫描进程共享同一目录时不会读到写了一半的条目。
+func (s *DirStore) Store(_ context.Context, name string, data []byte) error {
+	target := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("create content cache directory: %w", err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(target), name+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write content cache: %w", err)
+	}
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+		return fmt.Errorf("write content cache: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(temp.Name())
+		return fmt.Errorf("write content cache: %w", err)
+	}
+	if err := os.Rename(temp.Name(), target); err != nil {
+		_ = os.Remove(temp.Name())
+		return fmt.Errorf("write content cache: %w", err)
+	}
+	return nil
+}
+
+// path 返回条目文件路径。
+func (s *DirStore) path(name string) string {
+	if len(name) < 2 {
+		return filepath.Join(s.dir, name)
+	}
+	return filepath.Join(s.dir, name[:2], name)
+}
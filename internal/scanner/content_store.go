@@ -0,0 +1,222 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// contentStoreTimeout 是远程缓存单次请求的超时时间，缓存不可用时扫描只是退化为重新分析，不应长时间阻塞。
+const contentStoreTimeout = 10 * time.Second
+
+// HTTPStore 通过 HTTP GET/PUT 读写缓存条目（bazel-remote、nginx WebDAV 等通用构建缓存服务），
+// 条目地址为基础地址后接名称；GET 返回 404 视为未命中。
+type HTTPStore struct {
+	base   string
+	client *http.Client
+	// Header 为附加到每个请求的请求头（例如 Authorization）。
+	Header http.Header
+	// sign 在请求发出前对其签名，S3 使用；payload 为请求体。
+	sign func(request *http.Request, payload []byte) error
+}
+
+// NewHTTPStore 创建读写 base 下条目的存储；base 中的用户名与密码作为 Basic 认证发送。
+func NewHTTPStore(base string) (*HTTPStore, error) {
+	parsed, err := url.Parse(base)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid content cache url %q", base)
+	}
+	store := &HTTPStore{client: &http.Client{Timeout: contentStoreTimeout}, Header: make(http.Header)}
+	if parsed.User != nil {
+		password, _ := parsed.User.Password()
+		request := &http.Request{Header: make(http.Header)}
+		request.SetBasicAuth(parsed.User.Username(), password)
+		store.Header.Set("Authorization", request.Header.Get("Authorization"))
+		parsed.User = nil
+	}
+	store.base = strings.TrimSuffix(parsed.String(), "/")
+	return store, nil
+}
+
+// Load 通过 GET 读取条目。
+func (s *HTTPStore) Load(ctx context.Context, name string) ([]byte, bool, error) {
+	response, err := s.do(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("content cache GET %s: %s", name, response.Status)
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("read content cache response: %w", err)
+	}
+	return data, true, nil
+}
+
+// Store 通过 PUT 写入条目，任意 2xx 状态视为成功。
+func (s *HTTPStore) Store(ctx context.Context, name string, data []byte) error {
+	response, err := s.do(ctx, http.MethodPut, name, data)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("content cache PUT %s: %s", name, response.Status)
+	}
+	return nil
+}
+
+// do 发送请求，data 为 nil 时不带请求体。
+func (s *HTTPStore) do(ctx context.Context, method string, name string, data []byte) (*http.Response, error) {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, s.base+"/"+name, body)
+	if err != nil {
+		return nil, fmt.Errorf("build content cache request: %w", err)
+	}
+	for key, values := range s.Header {
+		request.Header[key] = values
+	}
+	if s.sign != nil {
+		if err := s.sign(request, data); err != nil {
+			return nil, err
+		}
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("content cache %s %s: %w", method, name, err)
+	}
+	return response, nil
+}
+
+// NewS3Store 创建读写 S3 桶 bucket 中 prefix 下对象的存储，请求使用 AWS Signature V4 签名。
+//
+// 凭证与区域取自标准环境变量：AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY、AWS_SESSION_TOKEN（可选）、
+// AWS_REGION（或 AWS_DEFAULT_REGION，默认 us-east-1）；设置 AWS_ENDPOINT_URL 时改用该地址并采用路径风格，
+// 用于 MinIO 等 S3 兼容服务。读取不存在的对象时 S3 只有在具备 s3:ListBucket 权限时才返回 404，否则返回 403 并被视为错误。
+func NewS3Store(bucket string, prefix string) (*HTTPStore, error) {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if bucket == "" {
+		return nil, errors.New("s3 content cache requires a bucket")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("s3 content cache requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	base := "https://" + bucket + ".s3." + region + ".amazonaws.com"
+	if endpoint := strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/"); endpoint != "" {
+		base = endpoint + "/" + bucket
+	}
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		base += "/" + prefix
+	}
+	store, err := NewHTTPStore(base)
+	if err != nil {
+		return nil, err
+	}
+	signer := s3Signer{accessKey: accessKey, secretKey: secretKey, sessionToken: os.Getenv("AWS_SESSION_TOKEN"), region: region}
+	store.sign = func(request *http.Request, payload []byte) error {
+		signer.sign(request, payload, time.Now().UTC())
+		return nil
+	}
+	return store, nil
+}
+
+// s3Signer 为 S3 请求计算 AWS Signature V4。
+type s3Signer struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+}
+
+// sign 按 SigV4 规范为请求添加 x-amz-date、x-amz-content-sha256 与 Authorization 请求头。
+// 签名的请求头为 host、x-amz-content-sha256、x-amz-date（以及存在会话令牌时的 x-amz-security-token）。
+func (s s3Signer) sign(request *http.Request, payload []byte, now time.Time) {
+	payloadSum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(payloadSum[:])
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	headers := []string{"host:" + request.URL.Host, "x-amz-content-sha256:" + payloadHash, "x-amz-date:" + amzDate}
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	if s.sessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", s.sessionToken)
+		headers = append(headers, "x-amz-security-token:"+s.sessionToken)
+		signedHeaders += ";x-amz-security-token"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		strings.Join(headers, "\n") + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalSum := sha256.Sum256([]byte(canonicalRequest))
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalSum[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{day, s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+// hmacSHA256 计算 HMAC-SHA256。
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// OpenContentStore 按位置创建缓存后端：http:// 或 https:// 地址使用 HTTPStore，
+// s3://bucket/prefix 使用 S3，其他值视为本地目录。
+func OpenContentStore(location string) (ContentStore, error) {
+	location = strings.TrimSpace(location)
+	switch {
+	case location == "":
+		return nil, errors.New("content cache location is empty")
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		return NewHTTPStore(location)
+	case strings.HasPrefix(location, "s3://"):
+		bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+		return NewS3Store(bucket, prefix)
+	default:
+		return NewDirStore(location), nil
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package scanner
+
+import "time"
+
+// processCPUTime 在无法取得进程 CPU 时间的平台上返回 false，自适应并发直接使用最大 worker 数。
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
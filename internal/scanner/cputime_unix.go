@@ -0,0 +1,17 @@
+//go:build unix
+
+package scanner
+
+import (
+	"syscall"
+	"time"
+)
+
+// processCPUTime 返回当前进程累计的用户态与内核态 CPU 时间。
+func processCPUTime() (time.Duration, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
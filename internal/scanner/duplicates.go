@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// DefaultDuplicateWindow 是判定重复代码块的默认最小连续代码行数。
+const DefaultDuplicateWindow = 6
+
+// DefaultDuplicateTop 是报告中默认保留的重复区域数量。
+const DefaultDuplicateTop = 10
+
+// windowOccurrence 表示某个滑动窗口在文件中的位置。
+type windowOccurrence struct {
+	fileIndex int
+	start     int
+}
+
+// detectDuplicates 对所有文件的代码行做滑动窗口哈希，找出跨文件（或文件内）重复的代码区域。
+//
+// 算法说明：
+// 1) 每个文件的代码行（已去除首尾空白并哈希）按 window 行组成滑动窗口；
+// 2) 出现两次及以上的窗口视为重复窗口；
+// 3) 同一文件中相邻/重叠的重复窗口合并为一个区域，区域数即重复块数。
+//
+// 检测完成后会释放文件上的 CodeLines，避免大仓库扫描时长期占用内存。
+func detectDuplicates(files []model.FileMetrics, window int, top int) model.DuplicationReport {
+	if window <= 0 {
+		window = DefaultDuplicateWindow
+	}
+	if top <= 0 {
+		top = DefaultDuplicateTop
+	}
+
+	occurrences := make(map[uint64][]windowOccurrence)
+	windowHashes := make([][]uint64, len(files))
+	for fileIndex, item := range files {
+		lines := item.Metrics.CodeLines
+		if len(lines) < window {
+			continue
+		}
+		hashes := make([]uint64, len(lines)-window+1)
+		for start := range hashes {
+			hash := hashWindow(lines[start : start+window])
+			hashes[start] = hash
+			occurrences[hash] = append(occurrences[hash], windowOccurrence{fileIndex: fileIndex, start: start})
+		}
+		windowHashes[fileIndex] = hashes
+	}
+
+	report := model.DuplicationReport{
+		WindowSize: window,
+		TopRegions: make([]model.DuplicateRegion, 0),
+	}
+	regions := make([]model.DuplicateRegion, 0)
+
+	for fileIndex, hashes := range windowHashes {
+		lines := files[fileIndex].Metrics.CodeLines
+		for start := 0; start < len(hashes); {
+			if len(occurrences[hashes[start]]) < 2 {
+				start++
+				continue
+			}
+
+			// 向后扩展，直到遇到不重复的窗口；重叠窗口共同覆盖的代码行构成一个区域。
+			end := start
+			for end+1 < len(hashes) && len(occurrences[hashes[end+1]]) >= 2 {
+				end++
+			}
+
+			lastLine := end + window - 1
+			regions = append(regions, model.DuplicateRegion{
+				Path:        files[fileIndex].Path,
+				StartLine:   lines[start].Number,
+				EndLine:     lines[lastLine].Number,
+				Lines:       int64(lastLine - start + 1),
+				Occurrences: int64(len(occurrences[hashes[start]])),
+			})
+			start = end + 1
+		}
+		files[fileIndex].Metrics.CodeLines = nil
+	}
+
+	for _, region := range regions {
+		report.Blocks++
+		report.DuplicatedLines += region.Lines
+	}
+
+	sort.Slice(regions, func(i int, j int) bool {
+		if regions[i].Lines != regions[j].Lines {
+			return regions[i].Lines > regions[j].Lines
+		}
+		if regions[i].Path != regions[j].Path {
+			return regions[i].Path < regions[j].Path
+		}
+		return regions[i].StartLine < regions[j].StartLine
+	})
+	if len(regions) > top {
+		regions = regions[:top]
+	}
+	report.TopRegions = append(report.TopRegions, regions...)
+	return report
+}
+
+// hashWindow 把一组代码行哈希组合成窗口哈希。
+func hashWindow(lines []model.CodeLine) uint64 {
+	hasher := fnv.New64a()
+	var buffer [8]byte
+	for _, line := range lines {
+		binary.LittleEndian.PutUint64(buffer[:], line.Hash)
+		_, _ = hasher.Write(buffer[:])
+	}
+	return hasher.Sum64()
+}
@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"expvar"
+	"time"
+)
+
+// Metrics 是扫描计数指标的接收方，供嵌入方把 gocloc 的计数接入自有监控系统。
+// 方法会在多个 worker 中并发调用，实现方需要保证并发安全且不阻塞。
+type Metrics interface {
+	// IncFiles 在一个文件分析成功后调用，language 为文件语言。
+	IncFiles(language string)
+	// AddBytes 累加已分析文件的字节数。
+	AddBytes(n int64)
+	// ObserveFileDuration 记录单个文件（无论成功失败）的分析耗时。
+	ObserveFileDuration(duration time.Duration)
+}
+
+// LanguageObserver 是 Metrics 的可选扩展：Metrics 同时实现该接口时，每个分析成功的文件还会连同语言与字节数上报耗时，
+// 用于按语言统计分析吞吐（gocloc bench）。耗时包含读取文件，命中缓存的文件同样上报。
+type LanguageObserver interface {
+	ObserveLanguage(language string, bytes int64, duration time.Duration)
+}
+
+// noopMetrics 是未设置 Metrics 时使用的空实现。
+type noopMetrics struct{}
+
+func (noopMetrics) IncFiles(string)                   {}
+func (noopMetrics) AddBytes(int64)                    {}
+func (noopMetrics) ObserveFileDuration(time.Duration) {}
+
+// ExpvarMetrics 是基于标准库 expvar 的默认 Metrics 实现，指标通过 /debug/vars 暴露：
+// - <prefix>_files：按语言统计的已分析文件数（expvar.Map）
+// - <prefix>_bytes：已分析文件的字节总数（expvar.Int）
+// - <prefix>_file_duration：文件分析耗时，包含 count 与 total_ns（expvar.Map），平均耗时为 total_ns/count
+type ExpvarMetrics struct {
+	files    *expvar.Map
+	bytes    *expvar.Int
+	duration *expvar.Map
+}
+
+// NewExpvarMetrics 创建并发布以 prefix 为前缀的 expvar 指标。
+// 同名变量已发布时复用已有变量（expvar 不允许重复发布），因此多次调用会累加到同一组指标上。
+func NewExpvarMetrics(prefix string) *ExpvarMetrics {
+	return &ExpvarMetrics{
+		files:    publishedMap(prefix + "_files"),
+		bytes:    publishedInt(prefix + "_bytes"),
+		duration: publishedMap(prefix + "_file_duration"),
+	}
+}
+
+// IncFiles 实现 Metrics。
+func (m *ExpvarMetrics) IncFiles(language string) {
+	m.files.Add(language, 1)
+}
+
+// AddBytes 实现 Metrics。
+func (m *ExpvarMetrics) AddBytes(n int64) {
+	m.bytes.Add(n)
+}
+
+// ObserveFileDuration 实现 Metrics。
+func (m *ExpvarMetrics) ObserveFileDuration(duration time.Duration) {
+	m.duration.Add("count", 1)
+	m.duration.Add("total_ns", int64(duration))
+}
+
+// publishedMap 返回已发布的同名 expvar.Map，不存在时新建并发布。
+func publishedMap(name string) *expvar.Map {
+	if existing, ok := expvar.Get(name).(*expvar.Map); ok {
+		return existing
+	}
+	return expvar.NewMap(name)
+}
+
+// publishedInt 返回已发布的同名 expvar.Int，不存在时新建并发布。
+func publishedInt(name string) *expvar.Int {
+	if existing, ok := expvar.Get(name).(*expvar.Int); ok {
+		return existing
+	}
+	return expvar.NewInt(name)
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package scanner
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile 在不支持 mmap 的平台上总是返回错误，调用方回退到流式读取。
+func mapFile(_ *os.File, _ int64) ([]byte, func() error, error) {
+	return nil, nil, errors.New("mmap is not supported on this platform")
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package scanner
+
+import (
+	"errors"
+	"math"
+	"os"
+	"syscall"
+)
+
+// mapFile 把整个文件以只读方式映射到内存，返回的 unmap 用于解除映射。
+// 映射期间文件被截断时访问映射区域可能触发 SIGBUS，因此只用于扫描期间不会被修改的源码文件。
+func mapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	if size <= 0 {
+		return nil, nil, errors.New("cannot map empty file")
+	}
+	if size > math.MaxInt {
+		return nil, nil, errors.New("file too large to map")
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -3,246 +3,902 @@
 package scanner
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
 	"sync"
+	"time"
 
-	"gocloc/internal/languages"
-	"gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/glob"
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/vcs"
 )
 
 // Service 是扫描服务对象。
 type Service struct {
 	registry *languages.Registry
 	workers  int
+	options  Options
+	logger   *slog.Logger
+	metrics  Metrics
+	// fingerprints 缓存各分析器的内容缓存指纹，避免每个文件重复格式化分析器配置。
+	fingerprints sync.Map
+	// throttle 为读取限制，未设置 MaxReadBytesPerSec 与 IOConcurrency 时为 nil。
+	throttle *ioThrottle
 }
 
+// Options 描述扫描服务的可选行为。
+type Options struct {
+	// Workers 为并发 worker 数量，<=0 时使用 CPU 核心数。
+	Workers int
+	// DetectDuplicates 开启跨文件重复代码检测。
+	// 需要配合 languages.Options.TrackCodeLines 构建的注册中心，否则没有可比较的代码行。
+	DetectDuplicates bool
+	// DuplicateWindow 为判定重复的最小连续代码行数，<=0 时使用 DefaultDuplicateWindow。
+	DuplicateWindow int
+	// DuplicateTop 为报告中保留的最大重复区域数量，<=0 时使用 DefaultDuplicateTop。
+	DuplicateTop int
+	// GitBlame 开启 git blame 补充信息（作者数、最后修改时间），未被跟踪的文件会被跳过。
+	GitBlame bool
+	// ScriptStats 开启 shebang 与可执行权限位统计（FileMetrics.Shebang/Executable 与 ScanResult.Scripts）。
+	ScriptStats bool
+	// SizeDistribution 开启按语言的单文件行数分布统计（分位数与直方图）。
+	SizeDistribution bool
+	// Hooks 为扫描生命周期回调，零值表示不设置任何回调。
+	Hooks Hooks
+	// Logger 接收扫描诊断日志，为 nil 时丢弃：
+	// Info 为扫描开始/结束与各阶段（discover、analyze、summarize、duplicates）耗时；
+	// Debug 为发现阶段的决策（跳过原因、匹配的分析器）与单文件结果；Warn 为单文件失败。
+	Logger *slog.Logger
+	// Excludes 为排除的路径通配（相对扫描根目录、以 / 分隔，支持 **，规则见 glob.Match），
+	// 模式匹配文件本身或其任一上级目录时跳过该文件；格式错误的模式会被忽略，调用方应先用 glob.Validate 校验。
+	Excludes []string
+	// Metrics 接收文件数、字节数与单文件耗时等计数指标，为 nil 时不记录；ExpvarMetrics 为基于 expvar 的默认实现。
+	Metrics Metrics
+	// Cache 为单文件结果缓存，为 nil 时不缓存。只对本地文件生效，开启 GitBlame 时不使用
+	// （提交历史变化不会改变文件大小与修改时间）。命中时文件只被打开一次用于取得文件信息，不会被读取。
+	Cache Cache
+	// MmapThreshold 大于 0 时，不小于该字节数的本地文件通过内存映射读取，
+	// 几百 MB 的生成代码、SQL 导出等文件不再经过 read 系统调用逐块拷贝；映射失败或平台不支持时回退到流式读取。
+	MmapThreshold int64
+	// AdaptiveWorkers 开启自适应并发：Workers 作为上限，从 CPU 核心数开始，
+	// 按观察到的 CPU 利用率（worker 等待 I/O 的程度）在扫描过程中增减同时分析的文件数。
+	AdaptiveWorkers bool
+	// ContentCache 为按内容哈希的分析结果缓存，可持久化到目录或远程后端，为 nil 时不使用。
+	// 与 Cache 同时设置时先查 Cache；开启 GitBlame 时仍然生效（blame 信息不进入缓存）。
+	ContentCache *ContentCache
+	// SummaryOnly 开启只汇总模式：分析结果边到达边累加到语言汇总、总计与测试拆分中，不保留 FileMetrics，
+	// 百万级文件的仓库内存占用只与语言数量相关。结果的 Files 为空且 SummaryOnly 为 true；
+	// 重复检测与脚本统计依赖文件明细，此模式下不生效。
+	SummaryOnly bool
+	// UnsortedFiles 跳过分析结束后按路径对 Files 与 Errors 的排序，二者保持分析完成的顺序（每次扫描可能不同）；
+	// 百万级文件的扫描中排序是汇总阶段的主要耗时，只关心汇总或自行排序的调用方可以开启。
+	UnsortedFiles bool
+	// Gitattributes 开启 .gitattributes 中 linguist-* 属性的支持（规则见 Linguist），与 GitHub 的语言统计保持一致。
+	// 只对文件系统扫描生效；自定义 Walker 可以自行填充 Entry.Linguist，无论该选项是否开启都会被采用。
+	Gitattributes bool
+	// Gitignore 跳过扫描目录（含子目录）中 .gitignore 忽略的文件与目录（规则见 vcs.Ignore），只对文件系统扫描生效。
+	Gitignore bool
+	// Checkpoint 非 nil 时把已完成的文件结果定期写入检查点，并跳过检查点中已完成的文件、直接采用其结果，
+	// 用于恢复中断的长时间扫描（见 Checkpoint）。只对 ScanPath/ScanWalker 生效，流式扫描与 ListWalker 不使用。
+	Checkpoint *Checkpoint
+	// MaxReadBytesPerSec 大于 0 时按令牌桶限制所有 worker 合计的文件读取速率（字节/秒），
+	// 用于在共享存储或生产 NFS 上执行定时扫描时避免挤占其他负载；开启后不使用内存映射读取。
+	// 按内容缓存的哈希读取同样计入，按文件信息缓存命中的文件不会被读取，不受影响。
+	MaxReadBytesPerSec int64
+	// IOConcurrency 大于 0 时限制同时进行中的文件读取调用数量，与 Workers 相互独立：
+	// 分析仍按 Workers 并行，只有读取会排队，开启后同样不使用内存映射读取。
+	IOConcurrency int
+	// Packages 开启按包汇总（ScanResult.Packages）：遍历时把 packageManifests 中的构建清单所在目录识别为包边界，
+	// 文件归入路径上最近的包。只对 ScanPath/ScanWalker 生效。
+	Packages bool
+	// CodeOwners 非 nil 时按其中的规则为每个文件填写 FileMetrics.Owners，并按所有者汇总（ScanResult.Owners）；
+	// 规则中的路径相对扫描根目录匹配，因此扫描根目录应为仓库根目录。
+	CodeOwners *vcs.CodeOwners
+	// FollowLinks 进入指向目录的符号链接与 Windows 目录联接，带循环保护（见 FileSystemWalker.FollowLinks），只对文件系统扫描生效。
+	FollowLinks bool
+	// MaxFileSize 大于 0 时，超过该字节数的文件不被读取，记为 oversize 类错误（model.ErrOversize），
+	// 避免误入扫描目录的数据导出、归档等文件拖慢整个扫描。
+	MaxFileSize int64
+	// FileTimeout 大于 0 时限制单个文件的分析时间：超时后分析器的下一次读取失败，文件记为 timeout 类错误（model.ErrTimeout）。
+	// 超时在读取时检查，已读完内容后的计算与按路径分析的外部插件不会被中断。
+	FileTimeout time.Duration
+}
+
+// Hooks 描述扫描生命周期回调，供嵌入方实现进度展示、日志或提前过滤，未设置的回调会被跳过。
+//
+// 并发约定：
+// - OnFileDiscovered 只在遍历 goroutine 中串行调用
+// - OnFileAnalyzed 与 OnError 会在多个 worker 中并发调用，实现方需要自行保证并发安全
+type Hooks struct {
+	// OnFileDiscovered 在发现一个可识别语言的文件、尚未分析时调用，返回 false 表示跳过该文件。
+	// path 为相对扫描根目录、以 / 分隔的路径。
+	OnFileDiscovered func(path string, language string) bool
+	// OnFileAnalyzed 在单个文件分析成功后调用。
+	OnFileAnalyzed func(file model.FileMetrics)
+	// OnError 在单个文件打开、读取或分析失败时调用。
+	OnError func(scanError model.ScanError)
+}
+
+// packageManifests 是划定包边界的构建清单文件名。
+var packageManifests = map[string]bool{
+	"go.mod":       true,
+	"package.json": true,
+	"Cargo.toml":   true,
+	"pom.xml":      true,
+	"setup.py":     true,
+}
+
+// dispatchBatchSize 是每次通过任务通道发送的最大任务数。
+// 百万级小文件的仓库中逐个发送任务会让通道操作与 goroutine 调度成为明显开销，按批发送可以把它们摊薄到 1/64。
+const dispatchBatchSize = 64
+
 // scanTask 表示一个待分析文件任务。
 type scanTask struct {
-	absolutePath string
-	displayPath  string
-	analyzer     languages.Analyzer
+	entry    Entry
+	analyzer languages.Analyzer
 }
 
 // workerResult 表示 worker 的执行产物。
 type workerResult struct {
 	fileMetrics *model.FileMetrics
 	scanError   *model.ScanError
+	duration    time.Duration
 }
 
 // NewService 创建扫描服务。
 func NewService(registry *languages.Registry, workers int) *Service {
+	return NewServiceWithOptions(registry, Options{Workers: workers})
+}
+
+// NewServiceWithOptions 使用完整选项创建扫描服务。
+func NewServiceWithOptions(registry *languages.Registry, options Options) *Service {
+	workers := options.Workers
 	if workers <= 0 {
 		workers = runtime.NumCPU()
 	}
+	logger := options.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	var metrics Metrics = noopMetrics{}
+	if options.Metrics != nil {
+		metrics = options.Metrics
+	}
 	return &Service{
 		registry: registry,
 		workers:  workers,
+		options:  options,
+		logger:   logger,
+		metrics:  metrics,
+		throttle: newIOThrottle(options.MaxReadBytesPerSec, options.IOConcurrency),
 	}
 }
 
 // ScanPath 扫描目录或单文件。
 // 扫描过程默认并发执行，单文件解析过程采用流式读取。
 func (s *Service) ScanPath(targetPath string) (model.ScanResult, error) {
+	return s.ScanPathContext(context.Background(), targetPath)
+}
+
+// ScanPathContext 是可取消的 ScanPath，ctx 取消时停止遍历与分析并返回取消原因。
+func (s *Service) ScanPathContext(ctx context.Context, targetPath string) (model.ScanResult, error) {
+	walker, err := s.pathWalker(targetPath)
+	if err != nil {
+		return model.ScanResult{}, err
+	}
+	return s.ScanWalker(ctx, walker)
+}
+
+// ScanWalker 扫描任意 Walker 提供的文件，遍历、并发分析与汇总逻辑与 ScanPath 相同。
+// ScanResult.ScannedPath 取 walker.Root()。
+func (s *Service) ScanWalker(ctx context.Context, walker Walker) (model.ScanResult, error) {
 	var result model.ScanResult
+	result.ScannedPath = walker.Root()
+
+	startedAt := time.Now()
+	s.logger.InfoContext(ctx, "scan started", "root", walker.Root(), "workers", s.workers)
+	// 每个 worker 把自己分析的文件累加到独立的部分汇总中，汇总随分析并行完成，结束后只需合并各部分。
+	partials := make([]*model.SummaryAggregator, s.workers)
+	for index := range partials {
+		partials[index] = model.NewSummaryAggregator(s.summaryOptions())
+	}
+
+	// 检查点中已完成的文件在遍历时按发现规则过滤后直接恢复，不再分析；恢复列表只在遍历 goroutine 中追加，
+	// 遍历错误通道收到结果后才读取。
+	// 特殊文件与构建清单同样在遍历 goroutine 中记录：未被 Excludes 排除的特殊文件写入结果，
+	// 构建清单无论是否被排除都划定包边界；遍历错误通道收到结果后才读取。
+	var skipped []model.SkippedFile
+	var packages *model.PackageAggregator
+	if s.options.Packages {
+		packages = model.NewPackageAggregator()
+	}
+	walker = observedWalker{Walker: walker, observe: func(entry Entry) {
+		if entry.Skipped != "" {
+			if _, excluded := s.excludedBy(entry.Path); !excluded {
+				skipped = append(skipped, model.SkippedFile{Path: entry.Path, Reason: entry.Skipped})
+			}
+			return
+		}
+		if name := path.Base(entry.Path); packages != nil && packageManifests[name] {
+			packages.AddBoundary(path.Dir(entry.Path), name)
+		}
+	}}
+
+	checkpoint := s.options.Checkpoint
+	var restored []model.FileMetrics
+	if checkpoint != nil {
+		completed, err := checkpoint.begin(walker.Root(), s.registry.Fingerprint())
+		if err != nil {
+			return result, err
+		}
+		walker = checkpointWalker{Walker: walker, completed: completed, restore: func(ctx context.Context, entry Entry, file model.FileMetrics) {
+			if _, ok := s.discover(ctx, entry); ok {
+				restored = append(restored, file)
+			}
+		}}
+	}
+	results, walkErrChan := s.startPipeline(ctx, walker, partials)
+
+	result.Files = make([]model.FileMetrics, 0)
+	result.Errors = make([]model.ScanError, 0)
+	for item := range results {
+		if item.fileMetrics != nil && !s.options.SummaryOnly {
+			result.Files = append(result.Files, *item.fileMetrics)
+		}
+		if item.fileMetrics != nil && packages != nil {
+			packages.Add(*item.fileMetrics)
+		}
+		if item.fileMetrics != nil && checkpoint != nil {
+			if err := checkpoint.record(result.ScannedPath, *item.fileMetrics); err != nil {
+				s.logger.WarnContext(ctx, "checkpoint write failed", "path", item.fileMetrics.Path, "error", err)
+			}
+		}
+		if item.scanError != nil {
+			result.Errors = append(result.Errors, *item.scanError)
+		}
+	}
+	s.logger.InfoContext(ctx, "phase finished", "phase", "analyze", "duration", time.Since(startedAt))
+	if checkpoint != nil {
+		if err := checkpoint.flush(); err != nil {
+			s.logger.WarnContext(ctx, "checkpoint write failed", "error", err)
+		}
+	}
 
+	if walkErr := <-walkErrChan; walkErr != nil {
+		s.logger.ErrorContext(ctx, "scan aborted", "root", walker.Root(), "error", walkErr)
+		return result, walkErr
+	}
+	if len(skipped) > 0 {
+		s.logger.InfoContext(ctx, "special files skipped", "root", walker.Root(), "files", len(skipped))
+		result.Skipped = skipped
+	}
+	if len(restored) > 0 {
+		s.logger.InfoContext(ctx, "files restored from checkpoint", "root", walker.Root(), "files", len(restored))
+		for _, file := range restored {
+			partials[0].Add(file)
+			if packages != nil {
+				packages.Add(file)
+			}
+			if !s.options.SummaryOnly {
+				result.Files = append(result.Files, file)
+			}
+		}
+	}
+
+	phaseStartedAt := time.Now()
+	if !s.options.UnsortedFiles {
+		result.SortByPath()
+	}
+	mergePartials(partials).Apply(&result)
+	if packages != nil {
+		result.Packages = packages.Packages()
+	}
+	result.SummaryOnly = s.options.SummaryOnly
+	s.logger.InfoContext(ctx, "phase finished", "phase", "summarize", "duration", time.Since(phaseStartedAt))
+	if s.options.DetectDuplicates && !result.SummaryOnly {
+		phaseStartedAt = time.Now()
+		report := detectDuplicates(result.Files, s.options.DuplicateWindow, s.options.DuplicateTop)
+		result.Duplication = &report
+		s.logger.InfoContext(ctx, "phase finished", "phase", "duplicates", "duration", time.Since(phaseStartedAt))
+	}
+	if s.options.ScriptStats && !result.SummaryOnly {
+		report := model.NewScriptReport(result.Files)
+		result.Scripts = &report
+	}
+	s.logger.InfoContext(
+		ctx,
+		"scan finished",
+		"root", walker.Root(),
+		"files", result.Total.Files,
+		"errors", len(result.Errors),
+		"duration", time.Since(startedAt),
+	)
+	return result, nil
+}
+
+// ScanStream 以流式方式扫描目录或单文件，每个文件分析完成后立即投递到通道，不在内存中汇总 ScanResult。
+//
+// 使用约定：
+// - 路径非法时同步返回错误，两个通道均为 nil
+// - 调用方需要同时消费两个通道（例如在同一个 select 循环中）直到两者都关闭
+// - 取消 ctx 会停止遍历与分析并尽快关闭通道，已取消时不再投递结果
+// - 遍历目录本身失败时以 Path 为扫描根目录的 ScanError 投递
+// - 流式结果不包含重复检测、分布统计等需要全量数据的汇总信息
+func (s *Service) ScanStream(ctx context.Context, targetPath string) (<-chan model.FileMetrics, <-chan model.ScanError, error) {
+	walker, err := s.pathWalker(targetPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, scanErrors := s.StreamWalker(ctx, walker)
+	return files, scanErrors, nil
+}
+
+// StreamWalker 是 ScanStream 的 Walker 版本，使用约定与 ScanStream 相同。
+func (s *Service) StreamWalker(ctx context.Context, walker Walker) (<-chan model.FileMetrics, <-chan model.ScanError) {
+	files := make(chan model.FileMetrics)
+	scanErrors := make(chan model.ScanError)
+	results, walkErrChan := s.startPipeline(ctx, walker, nil)
+
+	go func() {
+		defer close(files)
+		defer close(scanErrors)
+
+		for item := range results {
+			if item.fileMetrics != nil {
+				select {
+				case files <- *item.fileMetrics:
+				case <-ctx.Done():
+				}
+			}
+			if item.scanError != nil {
+				select {
+				case scanErrors <- *item.scanError:
+				case <-ctx.Done():
+				}
+			}
+		}
+
+		walkErr := <-walkErrChan
+		if walkErr == nil || ctx.Err() != nil {
+			return
+		}
+		select {
+		case scanErrors <- model.NewScanError(filepath.ToSlash(walker.Root()), model.ErrorCategoryOpen, walkErr):
+		case <-ctx.Done():
+		}
+	}()
+
+	return files, scanErrors
+}
+
+// ListPath 列出扫描目录或单文件时会被分析的文件及其语言，但不读取文件内容，用于在长时间扫描前验证过滤规则。
+func (s *Service) ListPath(ctx context.Context, targetPath string) ([]model.DiscoveredFile, error) {
+	walker, err := s.pathWalker(targetPath)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListWalker(ctx, walker)
+}
+
+// ListWalker 列出 Walker 提供的文件中会被分析的文件，判断规则与扫描完全一致（后缀、Excludes、OnFileDiscovered），
+// 结果按遍历顺序返回；遍历失败时返回已列出的部分与错误。
+func (s *Service) ListWalker(ctx context.Context, walker Walker) ([]model.DiscoveredFile, error) {
+	files := make([]model.DiscoveredFile, 0)
+	startedAt := time.Now()
+	err := walker.Walk(ctx, func(entry Entry) error {
+		if analyzer, ok := s.discover(ctx, entry); ok {
+			files = append(files, model.DiscoveredFile{Path: entry.Path, Language: analyzer.Name()})
+		}
+		return nil
+	})
+	s.logger.InfoContext(ctx, "phase finished", "phase", "discover", "duration", time.Since(startedAt))
+	return files, err
+}
+
+// pathWalker 校验扫描路径并创建对应的文件系统 Walker。
+// 用户直接给定单文件且后缀无法识别时返回错误，而不是静默得到空结果。
+func (s *Service) pathWalker(targetPath string) (Walker, error) {
 	trimmedPath := strings.TrimSpace(targetPath)
 	if trimmedPath == "" {
-		return result, errors.New("scan path is empty")
+		return nil, errors.New("scan path is empty")
 	}
 
 	absoluteTarget, err := filepath.Abs(trimmedPath)
 	if err != nil {
-		return result, fmt.Errorf("resolve absolute path: %w", err)
+		return nil, fmt.Errorf("resolve absolute path: %w", err)
 	}
 
 	info, err := os.Stat(absoluteTarget)
 	if err != nil {
-		return result, fmt.Errorf("stat path: %w", err)
+		return nil, fmt.Errorf("stat path: %w", err)
 	}
 
-	result.ScannedPath = absoluteTarget
+	if !info.IsDir() {
+		if _, ok := s.registry.AnalyzerForFile(absoluteTarget); !ok {
+			return nil, fmt.Errorf("unsupported file extension: %s", filepath.Ext(absoluteTarget))
+		}
+	}
+	walker := NewFileSystemWalker(absoluteTarget)
+	walker.Gitattributes = s.options.Gitattributes
+	walker.Gitignore = s.options.Gitignore
+	walker.FollowLinks = s.options.FollowLinks
+	if len(s.options.Excludes) > 0 {
+		walker.SkipDir = s.prunedDir
+	}
+	return walker, nil
+}
 
-	tasks := make(chan scanTask, s.workers*4)
+// prunedDir 判断遍历时是否跳过整个目录：目录匹配任一排除模式时，其下所有文件都会被 excludedBy 排除，
+// 因此不进入该目录，node_modules 等包含大量文件的目录不会被逐个 stat。
+func (s *Service) prunedDir(dir string) bool {
+	for _, pattern := range s.options.Excludes {
+		if ok, _ := glob.Match(pattern, dir); ok {
+			s.logger.Debug("directory skipped", "path", dir, "reason", "excluded", "pattern", pattern)
+			return true
+		}
+	}
+	return false
+}
+
+// startPipeline 启动遍历 goroutine 与 worker 池，返回结果通道与遍历错误通道。
+// partials 非 nil 时长度为 worker 数量，第 i 个 worker 把成功投递的文件累加到 partials[i]。
+// 结果通道在所有 worker 退出后关闭；遍历错误通道恰好收到一个值（成功时为 nil）。
+// ctx 取消后遍历立即停止，worker 丢弃剩余任务。
+func (s *Service) startPipeline(ctx context.Context, walker Walker, partials []*model.SummaryAggregator) (<-chan workerResult, <-chan error) {
+	tasks := make(chan []scanTask, s.workers*2)
 	results := make(chan workerResult, s.workers*4)
 	walkErrChan := make(chan error, 1)
 
+	// 开启自适应并发时 worker 按上限启动，由 gate 控制同时工作的数量，所有 worker 退出后停止调整。
+	var gate *workerGate
+	stopAdapt := func() {}
+	if s.options.AdaptiveWorkers && s.workers > 1 {
+		gate = newWorkerGate(min(s.workers, runtime.NumCPU()))
+		var adaptCtx context.Context
+		adaptCtx, stopAdapt = context.WithCancel(ctx)
+		go adaptWorkers(adaptCtx, gate, s.workers, s.logger)
+	}
+
 	var workerGroup sync.WaitGroup
 	for i := 0; i < s.workers; i++ {
+		var partial *model.SummaryAggregator
+		if partials != nil {
+			partial = partials[i]
+		}
 		workerGroup.Add(1)
 		go func() {
 			defer workerGroup.Done()
-			s.runWorker(tasks, results)
+			s.runWorker(ctx, gate, tasks, results, partial)
 		}()
 	}
 
 	go func() {
 		defer close(tasks)
-		if info.IsDir() {
-			walkErrChan <- s.enqueueDirectoryTasks(absoluteTarget, tasks)
-			return
+		startedAt := time.Now()
+		batch := make([]scanTask, 0, dispatchBatchSize)
+		err := walker.Walk(ctx, func(entry Entry) error {
+			return s.enqueueEntry(ctx, entry, &batch, tasks)
+		})
+		// 遍历失败前已发现的文件仍然分析，与逐个发送时的行为一致；ctx 取消时不再发送。
+		if flushErr := flushTasks(ctx, &batch, tasks); err == nil {
+			err = flushErr
 		}
-		walkErrChan <- s.enqueueSingleFileTask(absoluteTarget, tasks)
+		s.logger.InfoContext(ctx, "phase finished", "phase", "discover", "duration", time.Since(startedAt))
+		walkErrChan <- err
 	}()
 
 	go func() {
 		workerGroup.Wait()
+		stopAdapt()
 		close(results)
 	}()
 
-	result.Files = make([]model.FileMetrics, 0)
-	result.Errors = make([]model.ScanError, 0)
+	return results, walkErrChan
+}
 
-	for item := range results {
-		if item.fileMetrics != nil {
-			result.Files = append(result.Files, *item.fileMetrics)
-		}
-		if item.scanError != nil {
-			result.Errors = append(result.Errors, *item.scanError)
-		}
+// enqueueEntry 为可识别语言的文件创建任务并加入当前批次，批次已满时推入队列；ctx 取消时放弃并返回取消原因。
+func (s *Service) enqueueEntry(ctx context.Context, entry Entry, batch *[]scanTask, tasks chan<- []scanTask) error {
+	analyzer, ok := s.discover(ctx, entry)
+	if !ok {
+		return nil
 	}
 
-	if walkErr := <-walkErrChan; walkErr != nil {
-		return result, walkErr
+	*batch = append(*batch, scanTask{entry: entry, analyzer: analyzer})
+	if len(*batch) < dispatchBatchSize {
+		return nil
 	}
+	return flushTasks(ctx, batch, tasks)
+}
 
-	s.buildSummaries(&result)
-	return result, nil
+// flushTasks 把当前批次推入队列并换用新的批次（已发送的切片归 worker 所有），批次为空时什么也不做。
+func flushTasks(ctx context.Context, batch *[]scanTask, tasks chan<- []scanTask) error {
+	if len(*batch) == 0 {
+		return nil
+	}
+	select {
+	case tasks <- *batch:
+		*batch = make([]scanTask, 0, dispatchBatchSize)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// enqueueDirectoryTasks 遍历目录并把可识别语言文件推入任务队列。
-func (s *Service) enqueueDirectoryTasks(root string, tasks chan<- scanTask) error {
-	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
+// discover 判断文件是否需要分析并返回匹配的分析器，扫描与 ListWalker 共用同一套判断：
+// 特殊文件、标记为 linguist-vendored/linguist-documentation、后缀无法识别、被 Excludes 排除或 OnFileDiscovered 返回 false 时跳过该文件；
+// linguist-language 指定的语言优先于按后缀识别的结果。
+func (s *Service) discover(ctx context.Context, entry Entry) (languages.Analyzer, bool) {
+	if entry.Skipped != "" {
+		s.logger.DebugContext(ctx, "file skipped", "path", entry.Path, "reason", string(entry.Skipped))
+		return nil, false
+	}
+	if entry.Linguist.Vendored || entry.Linguist.Documentation {
+		s.logger.DebugContext(ctx, "file skipped", "path", entry.Path, "reason", "linguist attributes")
+		return nil, false
+	}
+	analyzer, ok := s.linguistAnalyzer(ctx, entry)
+	if !ok {
+		analyzer, ok = s.registry.AnalyzerForFile(entry.Path)
+	}
+	if !ok {
+		s.logger.DebugContext(ctx, "file skipped", "path", entry.Path, "reason", "unsupported extension")
+		return nil, false
+	}
+	if pattern, ok := s.excludedBy(entry.Path); ok {
+		s.logger.DebugContext(ctx, "file skipped", "path", entry.Path, "reason", "excluded", "pattern", pattern)
+		return nil, false
+	}
 
-		if entry.IsDir() {
-			return nil
-		}
+	if discovered := s.options.Hooks.OnFileDiscovered; discovered != nil && !discovered(entry.Path, analyzer.Name()) {
+		s.logger.DebugContext(ctx, "file skipped", "path", entry.Path, "reason", "discovery hook")
+		return nil, false
+	}
+	s.logger.DebugContext(ctx, "file matched", "path", entry.Path, "analyzer", analyzer.Name())
+	return analyzer, true
+}
+
+// linguistAliases 把 GitHub linguist 的语言名映射到内置语言名（比较前转为小写）。
+var linguistAliases = map[string]string{
+	"c":   "C/C++",
+	"c++": "C/C++",
+}
 
-		analyzer, ok := s.registry.AnalyzerForFile(path)
-		if !ok {
-			return nil
+// linguistAnalyzer 返回 linguist-language 指定语言的分析器。
+// 语言名不区分大小写，- 视为空格（gitattributes 的值不能包含空格）；语言未注册时记录日志并回退到按后缀识别。
+func (s *Service) linguistAnalyzer(ctx context.Context, entry Entry) (languages.Analyzer, bool) {
+	name := entry.Linguist.Language
+	if name == "" {
+		return nil, false
+	}
+	if alias, ok := linguistAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	if analyzer, ok := s.registry.AnalyzerForLanguage(name); ok {
+		return analyzer, true
+	}
+	if analyzer, ok := s.registry.AnalyzerForLanguage(strings.ReplaceAll(name, "-", " ")); ok {
+		return analyzer, true
+	}
+	s.logger.DebugContext(ctx, "linguist language not registered", "path", entry.Path, "language", entry.Linguist.Language)
+	return nil, false
+}
+
+// excludedBy 返回排除该路径的模式：模式匹配路径本身或其任一上级目录即视为排除。
+func (s *Service) excludedBy(filePath string) (string, bool) {
+	for _, pattern := range s.options.Excludes {
+		for candidate := filePath; candidate != "." && candidate != "/" && candidate != ""; candidate = path.Dir(candidate) {
+			if ok, _ := glob.Match(pattern, candidate); ok {
+				return pattern, true
+			}
 		}
+	}
+	return "", false
+}
 
-		relativePath, relErr := filepath.Rel(root, path)
-		if relErr != nil {
-			relativePath = path
+// runWorker 持续消费任务并投递分析结果；ctx 取消后只排空任务队列，不再分析。
+// 任务按批次到达，worker 依次处理批次内的文件；gate 非 nil 时每个文件的分析都需要先取得名额，用于自适应并发。
+// partial 非 nil 时，成功投递的文件同时累加到该 worker 独占的部分汇总中。
+func (s *Service) runWorker(ctx context.Context, gate *workerGate, tasks <-chan []scanTask, results chan<- workerResult, partial *model.SummaryAggregator) {
+	for batch := range tasks {
+		for _, task := range batch {
+			if ctx.Err() != nil {
+				break
+			}
+			if gate != nil {
+				gate.acquire()
+			}
+			startedAt := time.Now()
+			result := s.analyzeTask(ctx, task)
+			result.duration = time.Since(startedAt)
+			if gate != nil {
+				gate.release()
+			}
+			s.notifyResult(result)
+			select {
+			case results <- result:
+				if partial != nil && result.fileMetrics != nil {
+					partial.Add(*result.fileMetrics)
+				}
+			case <-ctx.Done():
+			}
 		}
+	}
+}
 
-		tasks <- scanTask{
-			absolutePath: path,
-			displayPath:  filepath.ToSlash(relativePath),
-			analyzer:     analyzer,
+// mergePartials 两两并行合并各 worker 的部分汇总，返回合并结果（即 partials[0]）。
+func mergePartials(partials []*model.SummaryAggregator) *model.SummaryAggregator {
+	for step := 1; step < len(partials); step *= 2 {
+		var group sync.WaitGroup
+		for index := 0; index+step < len(partials); index += 2 * step {
+			group.Add(1)
+			go func(left *model.SummaryAggregator, right *model.SummaryAggregator) {
+				defer group.Done()
+				left.Merge(right)
+			}(partials[index], partials[index+step])
 		}
-		return nil
-	})
+		group.Wait()
+	}
+	return partials[0]
 }
 
-// enqueueSingleFileTask 在用户给定单文件路径时创建任务。
-func (s *Service) enqueueSingleFileTask(filePath string, tasks chan<- scanTask) error {
-	analyzer, ok := s.registry.AnalyzerForFile(filePath)
-	if !ok {
-		return fmt.Errorf("unsupported file extension: %s", filepath.Ext(filePath))
+// notifyResult 记录单文件日志与计数指标，并按结果类型触发 OnFileAnalyzed 或 OnError 回调。
+func (s *Service) notifyResult(result workerResult) {
+	s.metrics.ObserveFileDuration(result.duration)
+	if result.fileMetrics != nil {
+		s.metrics.IncFiles(result.fileMetrics.Language)
+		s.metrics.AddBytes(result.fileMetrics.Metrics.Bytes)
+		if observer, ok := s.metrics.(LanguageObserver); ok {
+			observer.ObserveLanguage(result.fileMetrics.Language, result.fileMetrics.Metrics.Bytes, result.duration)
+		}
+		s.logger.Debug(
+			"file analyzed",
+			"path", result.fileMetrics.Path,
+			"language", result.fileMetrics.Language,
+			"total", result.fileMetrics.Metrics.Total,
+			"code", result.fileMetrics.Metrics.Code,
+		)
+	}
+	if result.scanError != nil {
+		s.logger.Warn("file failed", "path", result.scanError.Path, "category", result.scanError.Category, "error", result.scanError.Message)
 	}
 
-	tasks <- scanTask{
-		absolutePath: filePath,
-		displayPath:  filepath.Base(filePath),
-		analyzer:     analyzer,
+	hooks := s.options.Hooks
+	if result.fileMetrics != nil && hooks.OnFileAnalyzed != nil {
+		hooks.OnFileAnalyzed(*result.fileMetrics)
+	}
+	if result.scanError != nil && hooks.OnError != nil {
+		hooks.OnError(*result.scanError)
 	}
-	return nil
 }
 
-// runWorker 执行真实的文件读取和语言 FSM 分析。
-func (s *Service) runWorker(tasks <-chan scanTask, results chan<- workerResult) {
-	for task := range tasks {
-		file, openErr := os.Open(task.absolutePath)
-		if openErr != nil {
-			results <- workerResult{
-				scanError: &model.ScanError{
-					Path:  task.displayPath,
-					Error: openErr.Error(),
-				},
+// analyzeTask 执行真实的文件读取和语言 FSM 分析。
+func (s *Service) analyzeTask(ctx context.Context, task scanTask) workerResult {
+	file, info, openErr := task.entry.Open()
+	if openErr != nil {
+		return workerResult{
+			scanError: newScanError(task.entry.Path, model.ErrorCategoryOpen, openErr),
+		}
+	}
+	if s.options.MaxFileSize > 0 && info.Size() > s.options.MaxFileSize {
+		_ = file.Close()
+		oversizeErr := fmt.Errorf("%d bytes exceeds the %d byte limit: %w", info.Size(), s.options.MaxFileSize, model.ErrOversize)
+		return workerResult{
+			scanError: newScanError(task.entry.Path, model.ErrorCategoryOversize, oversizeErr),
+		}
+	}
+
+	var cacheKey CacheKey
+	useCache := s.options.Cache != nil && task.entry.LocalPath != "" && !s.options.GitBlame
+	if useCache {
+		cacheKey = CacheKey{LocalPath: task.entry.LocalPath, Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+		if cached, ok := s.options.Cache.Get(cacheKey); ok {
+			if closeErr := file.Close(); closeErr != nil {
+				return workerResult{
+					scanError: newScanError(task.entry.Path, model.ErrorCategoryRead, closeErr),
+				}
 			}
-			continue
+			cached.Path = task.entry.Path
+			cached.Generated = task.entry.Linguist.Generated
+			cached.Owners = s.owners(task.entry.Path)
+			return workerResult{fileMetrics: &cached}
 		}
+	}
 
-		metrics, analyzeErr := task.analyzer.Analyze(file)
+	// 按内容缓存时先读一遍文件计算哈希，命中则跳过分析，未命中时重新打开文件分析。
+	// 外部插件按路径分析且结果取决于插件本身，不使用按内容缓存。
+	_, pathAnalyzer := task.analyzer.(languages.PathAnalyzer)
+	useContentCache := s.options.ContentCache != nil && !pathAnalyzer
+	var contentKey ContentKey
+	if useContentCache {
+		digest, digestErr := contentDigest(s.throttle.reader(ctx, file))
 		closeErr := file.Close()
-
-		if analyzeErr != nil {
-			results <- workerResult{
-				scanError: &model.ScanError{
-					Path:  task.displayPath,
-					Error: analyzeErr.Error(),
-				},
+		if digestErr == nil {
+			digestErr = closeErr
+		}
+		if digestErr != nil {
+			return workerResult{
+				scanError: newScanError(task.entry.Path, model.ErrorCategoryRead, digestErr),
 			}
-			continue
 		}
+		contentKey = ContentKey{Digest: digest, Analyzer: s.contentFingerprint(task.analyzer)}
+		metrics, shebang, ok, cacheErr := s.options.ContentCache.lookup(ctx, contentKey)
+		if cacheErr != nil {
+			s.logger.Warn("content cache lookup failed", "path", task.entry.Path, "error", cacheErr)
+		}
+		if ok {
+			metrics.Bytes = info.Size()
+			return s.completeFile(task, info, metrics, shebang, cacheKey, useCache)
+		}
+		if file, _, openErr = task.entry.Open(); openErr != nil {
+			return workerResult{
+				scanError: newScanError(task.entry.Path, model.ErrorCategoryOpen, openErr),
+			}
+		}
+	}
 
-		if closeErr != nil {
-			results <- workerResult{
-				scanError: &model.ScanError{
-					Path:  task.displayPath,
-					Error: closeErr.Error(),
-				},
+	reader := s.throttle.reader(ctx, file)
+	if mapped, unmap, ok := s.mapLargeFile(task.entry, file, info); ok {
+		defer func() {
+			if err := unmap(); err != nil {
+				s.logger.Warn("munmap failed", "path", task.entry.Path, "error", err)
 			}
-			continue
+		}()
+		reader = bytes.NewReader(mapped)
+	}
+	if s.options.FileTimeout > 0 {
+		reader = &deadlineReader{reader: reader, timeout: s.options.FileTimeout, deadline: time.Now().Add(s.options.FileTimeout)}
+	}
+	shebang := ""
+	if s.options.ScriptStats {
+		// 先用带缓冲的 reader 窥探首行，再把同一个 reader 交给分析器，文件只读一遍。
+		buffered := acquireShebangReader(reader)
+		defer releaseShebangReader(buffered)
+		shebang = peekShebang(buffered)
+		reader = buffered
+	}
+
+	var metrics model.LineMetrics
+	var analyzeErr error
+	if pathAnalyzer, ok := task.analyzer.(languages.PathAnalyzer); ok {
+		metrics, analyzeErr = pathAnalyzer.AnalyzePath(task.entry.Path, reader)
+	} else {
+		metrics, analyzeErr = task.analyzer.Analyze(reader)
+	}
+	metrics.Bytes = info.Size()
+	closeErr := file.Close()
+
+	if analyzeErr != nil {
+		return workerResult{
+			scanError: newScanError(task.entry.Path, model.ErrorCategoryRead, analyzeErr),
 		}
+	}
 
-		results <- workerResult{
-			fileMetrics: &model.FileMetrics{
-				Path:     task.displayPath,
-				Language: task.analyzer.Name(),
-				Metrics:  metrics,
-			},
+	if closeErr != nil {
+		return workerResult{
+			scanError: newScanError(task.entry.Path, model.ErrorCategoryRead, closeErr),
 		}
 	}
+
+	if useContentCache {
+		if cacheErr := s.options.ContentCache.save(ctx, contentKey, metrics, shebang); cacheErr != nil {
+			s.logger.Warn("content cache store failed", "path", task.entry.Path, "error", cacheErr)
+		}
+	}
+	return s.completeFile(task, info, metrics, shebang, cacheKey, useCache)
 }
 
-// buildSummaries 计算语言级汇总和总计信息。
-func (s *Service) buildSummaries(result *model.ScanResult) {
-	sort.Slice(result.Files, func(i int, j int) bool {
-		return result.Files[i].Path < result.Files[j].Path
-	})
+// completeFile 为分析结果补充路径相关的信息（测试文件、子类别、可执行位、git blame），并写入单文件结果缓存。
+func (s *Service) completeFile(task scanTask, info fs.FileInfo, metrics model.LineMetrics, shebang string, cacheKey CacheKey, useCache bool) workerResult {
+	fileMetrics := &model.FileMetrics{
+		Path:      task.entry.Path,
+		Language:  task.analyzer.Name(),
+		Generated: task.entry.Linguist.Generated,
+		Metrics:   metrics,
+		Owners:    s.owners(task.entry.Path),
+	}
+	if classifier, ok := task.analyzer.(languages.TestFileClassifier); ok {
+		fileMetrics.Test = classifier.IsTestFile(task.entry.Path)
+	}
+	if classifier, ok := task.analyzer.(languages.VariantClassifier); ok {
+		fileMetrics.Variant = classifier.Variant(task.entry.Path)
+	}
+	if s.options.ScriptStats {
+		fileMetrics.Shebang = shebang
+		fileMetrics.Executable = info.Mode().Perm()&0o111 != 0
+	}
+	if s.options.GitBlame && task.entry.LocalPath != "" {
+		// blame 失败通常意味着文件未被跟踪或不在仓库中，这类文件只是缺少补充信息，不记为扫描错误。
+		if blame, blameErr := vcs.Blame(task.entry.LocalPath); blameErr == nil {
+			fileMetrics.Git = &model.GitMetrics{
+				Authors:      blame.Authors,
+				LastModified: blame.LastModified,
+			}
+		} else {
+			s.logger.Debug("git blame skipped", "path", task.entry.Path, "error", blameErr)
+		}
+	}
+	if useCache {
+		s.options.Cache.Put(cacheKey, *fileMetrics)
+	}
 
-	sort.Slice(result.Errors, func(i int, j int) bool {
-		return result.Errors[i].Path < result.Errors[j].Path
-	})
+	return workerResult{fileMetrics: fileMetrics}
+}
 
-	byLanguage := make(map[string]*model.LanguageMetrics)
-	result.Total = model.TotalMetrics{}
+// contentFingerprint 返回按内容缓存的分析器指纹，开启脚本统计时结果额外包含 shebang，因此单独区分。
+func (s *Service) contentFingerprint(analyzer languages.Analyzer) string {
+	if cached, ok := s.fingerprints.Load(analyzer); ok {
+		return cached.(string)
+	}
+	fingerprint := languages.Fingerprint(analyzer)
+	if s.options.ScriptStats {
+		fingerprint += "|scripts"
+	}
+	s.fingerprints.Store(analyzer, fingerprint)
+	return fingerprint
+}
 
-	for _, item := range result.Files {
-		result.Total.AddFileMetrics(item.Metrics)
+// deadlineReader 在超过单文件分析时限后让后续读取返回包装了 model.ErrTimeout 的错误。
+type deadlineReader struct {
+	reader   io.Reader
+	timeout  time.Duration
+	deadline time.Time
+}
 
-		summary, ok := byLanguage[item.Language]
-		if !ok {
-			summary = &model.LanguageMetrics{
-				Language:   item.Language,
-				Extensions: s.registry.ExtensionsForLanguage(item.Language),
-			}
-			byLanguage[item.Language] = summary
-		}
+func (r *deadlineReader) Read(buffer []byte) (int, error) {
+	if !time.Now().Before(r.deadline) {
+		return 0, fmt.Errorf("analysis exceeded %s: %w", r.timeout, model.ErrTimeout)
+	}
+	return r.reader.Read(buffer)
+}
 
-		summary.Files++
-		summary.Metrics.Add(item.Metrics)
+// mapLargeFile 在开启 MmapThreshold 且文件足够大时把本地文件映射到内存，返回映射内容与解除映射的函数。
+// 非本地文件、映射失败或平台不支持时返回 false，由调用方继续流式读取。
+func (s *Service) mapLargeFile(entry Entry, file io.ReadCloser, info fs.FileInfo) ([]byte, func() error, bool) {
+	if s.options.MmapThreshold <= 0 || info.Size() < s.options.MmapThreshold || entry.LocalPath == "" || s.throttle != nil {
+		return nil, nil, false
+	}
+	osFile, ok := file.(*os.File)
+	if !ok {
+		return nil, nil, false
 	}
+	data, unmap, err := mapFile(osFile, info.Size())
+	if err != nil {
+		s.logger.Debug("mmap skipped", "path", entry.Path, "error", err)
+		return nil, nil, false
+	}
+	return data, unmap, true
+}
 
-	result.Languages = make([]model.LanguageMetrics, 0, len(byLanguage))
-	for _, item := range byLanguage {
-		result.Languages = append(result.Languages, *item)
+// newScanError 创建 worker 结果中的扫描错误。
+func newScanError(path string, fallback model.ErrorCategory, err error) *model.ScanError {
+	scanError := model.NewScanError(path, fallback, err)
+	return &scanError
+}
+
+// owners 返回文件在 CODEOWNERS 中的所有者，未设置 CodeOwners 时返回 nil。
+func (s *Service) owners(filePath string) []string {
+	if s.options.CodeOwners == nil {
+		return nil
 	}
+	return s.options.CodeOwners.Owners(filePath)
+}
 
-	sort.Slice(result.Languages, func(i int, j int) bool {
-		return result.Languages[i].Language < result.Languages[j].Language
-	})
+// summaryOptions 返回汇总语言级统计时使用的选项。
+func (s *Service) summaryOptions() model.SummaryOptions {
+	return model.SummaryOptions{
+		Extensions:       s.registry.ExtensionsForLanguage,
+		SizeDistribution: s.options.SizeDistribution,
+		Owners:           s.options.CodeOwners != nil,
+	}
 }
@@ -3,11 +3,12 @@ package scanner
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
 
-	"gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
 )
 
 // prepareBenchmarkFile 创建一个用于单文件扫描基准测试的 Go 文件。
@@ -86,3 +87,31 @@ func BenchmarkScanDirectory(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkScanSmallFiles 衡量大量小文件场景下每个文件的分配次数与字节数，用于验证缓冲区复用的效果。
+func BenchmarkScanSmallFiles(b *testing.B) {
+	dirPath := prepareBenchmarkDirectory(b)
+	service := NewService(languages.NewRegistry(), 4)
+
+	var before runtime.MemStats
+	var after runtime.MemStats
+	b.ReportAllocs()
+	b.ResetTimer()
+	runtime.ReadMemStats(&before)
+
+	files := 0
+	for i := 0; i < b.N; i++ {
+		result, err := service.ScanPath(dirPath)
+		if err != nil {
+			b.Fatalf("scan failed: %v", err)
+		}
+		files += len(result.Files)
+	}
+
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	if files > 0 {
+		b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(files), "allocs/file")
+		b.ReportMetric(float64(after.TotalAlloc-before.TotalAlloc)/float64(files), "B/file")
+	}
+}
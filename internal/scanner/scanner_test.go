@@ -1,12 +1,34 @@
 package scanner
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"testing/fstest"
+	"testing/iotest"
+	"time"
 
-	"gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/vcs"
 )
 
 // writeFixtureFile 是测试辅助函数，用于在临时目录快速落地测试文件。
@@ -102,3 +124,1463 @@ func TestScanUnsupportedSingleFile(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+// TestScanByteSizes 验证文件级与总计的字节大小统计。
+func TestScanByteSizes(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeFixtureFile(t, filepath.Join(tempDir, "a.go"), "package a\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "b.py"), "x = 1\r\ny = 2\r\n")
+
+	service := NewService(languages.NewRegistry(), 2)
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	if result.Files[0].Metrics.Bytes != 10 || result.Files[1].Metrics.Bytes != 14 {
+		t.Fatalf("unexpected file bytes: %+v", result.Files)
+	}
+	if result.Total.Bytes != 24 {
+		t.Fatalf("expected total bytes 24, got %d", result.Total.Bytes)
+	}
+}
+
+// TestScanUniqueLines 验证 ULOC 在文件内、语言内以及项目级的去重结果。
+func TestScanUniqueLines(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeFixtureFile(t, filepath.Join(tempDir, "a.go"), strings.Join([]string{
+		"package p",
+		"var x = 1",
+		"    var x = 1",
+	}, "\n"))
+	writeFixtureFile(t, filepath.Join(tempDir, "b.go"), strings.Join([]string{
+		"package p",
+		"var y = 2",
+	}, "\n"))
+	writeFixtureFile(t, filepath.Join(tempDir, "c.py"), "var x = 1\n")
+
+	service := NewService(languages.NewRegistry(), 2)
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	if result.Files[0].Metrics.ULOC != 2 {
+		t.Fatalf("expected file uloc 2, got %d", result.Files[0].Metrics.ULOC)
+	}
+	if result.Languages[0].Language != "Go" || result.Languages[0].Metrics.ULOC != 3 {
+		t.Fatalf("unexpected go summary: %+v", result.Languages[0])
+	}
+	if result.Total.ULOC != 3 || result.Total.Code != 6 {
+		t.Fatalf("unexpected total uloc: %+v", result.Total)
+	}
+}
+
+// TestScanRatios 验证语言级与总计的派生比例指标。
+func TestScanRatios(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeFixtureFile(t, filepath.Join(tempDir, "a.go"), strings.Join([]string{
+		"package p",
+		"// comment",
+		"",
+		"var x = 1",
+	}, "\n"))
+	writeFixtureFile(t, filepath.Join(tempDir, "b.py"), "x = 1\ny = 2\n")
+
+	service := NewService(languages.NewRegistry(), 2)
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	goRatios := result.Languages[0].Ratios
+	if goRatios.CommentDensity != 0.5 || goRatios.BlankRatio != 0.25 || goRatios.CodeShare != 0.5 {
+		t.Fatalf("unexpected go ratios: %+v", goRatios)
+	}
+	if result.Total.Ratios.CodeShare != 1 || result.Total.Ratios.CommentDensity != 0.25 {
+		t.Fatalf("unexpected total ratios: %+v", result.Total.Ratios)
+	}
+}
+
+// TestScanDuplicates 验证跨文件重复代码块检测。
+func TestScanDuplicates(t *testing.T) {
+	tempDir := t.TempDir()
+
+	shared := []string{
+		"a := 1",
+		"b := 2",
+		"",
+		"  c := a + b",
+		"d := c * 2",
+	}
+	writeFixtureFile(t, filepath.Join(tempDir, "a.go"), strings.Join(append([]string{"package p", "// a"}, shared...), "\n"))
+	writeFixtureFile(t, filepath.Join(tempDir, "b.go"), strings.Join(append(append([]string{"package q"}, shared...), "e := 5"), "\n"))
+
+	registry := languages.NewRegistryWithOptions(languages.Options{TrackCodeLines: true})
+	service := NewServiceWithOptions(registry, Options{Workers: 2, DetectDuplicates: true, DuplicateWindow: 4})
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	duplication := result.Duplication
+	if duplication == nil {
+		t.Fatalf("expected duplication report")
+	}
+	if duplication.Blocks != 2 || duplication.DuplicatedLines != 8 {
+		t.Fatalf("unexpected duplication summary: %+v", duplication)
+	}
+	first := duplication.TopRegions[0]
+	if first.Path != "a.go" || first.StartLine != 3 || first.EndLine != 7 || first.Occurrences != 2 {
+		t.Fatalf("unexpected top region: %+v", first)
+	}
+}
+
+// TestScanTestSplit 验证按语言约定拆分生产代码与测试代码。
+func TestScanTestSplit(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeFixtureFile(t, filepath.Join(tempDir, "main.go"), "package main\nfunc main() {}\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "main_test.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "web", "app.spec.ts"), "it('ok', () => {})\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "py", "test_util.py"), "assert True\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "src", "test", "java", "AppIT.java"), "class AppIT {}\n")
+
+	service := NewService(languages.NewRegistry(), 2)
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	split := result.TestSplit
+	if split.Production.Files != 1 || split.Test.Files != 4 {
+		t.Fatalf("unexpected test split: %+v", split)
+	}
+	if split.TestToCodeRatio != 2 {
+		t.Fatalf("unexpected test to code ratio: %v", split.TestToCodeRatio)
+	}
+}
+
+// TestScanScripts 验证 shebang 解释器识别与可执行权限位统计。
+func TestScanScripts(t *testing.T) {
+	tempDir := t.TempDir()
+
+	runPath := filepath.Join(tempDir, "run.py")
+	writeFixtureFile(t, runPath, "#!/usr/bin/env python3\nprint('hi')\n")
+	if err := os.Chmod(runPath, 0o755); err != nil {
+		t.Fatalf("chmod fixture failed: %v", err)
+	}
+	writeFixtureFile(t, filepath.Join(tempDir, "tool.rb"), "#!/usr/bin/ruby -w\nputs 1\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "lib.py"), "x = 1\n")
+
+	service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, ScriptStats: true})
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	scripts := result.Scripts
+	if scripts == nil {
+		t.Fatalf("expected script report")
+	}
+	if scripts.ShebangFiles != 2 || scripts.ExecutableFiles != 1 || scripts.ExecutableScripts != 1 {
+		t.Fatalf("unexpected script counts: %+v", scripts)
+	}
+	if len(scripts.Interpreters) != 2 || scripts.Interpreters[0].Interpreter != "python3" || scripts.Interpreters[1].Interpreter != "ruby" {
+		t.Fatalf("unexpected interpreters: %+v", scripts.Interpreters)
+	}
+	// shebang 窥探不能影响后续行数统计。
+	for _, item := range result.Files {
+		if item.Metrics.Total != 2 && item.Path != "lib.py" {
+			t.Fatalf("unexpected total lines for %s: %d", item.Path, item.Metrics.Total)
+		}
+	}
+}
+
+// TestScanMmap 验证超过阈值的文件经内存映射读取后统计结果与流式读取一致，空文件与小文件不受影响。
+func TestScanMmap(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, "large.go"), strings.Repeat("x := 1 // c\n\n/* block */\n", 200)+"var last = 1")
+	writeFixtureFile(t, filepath.Join(tempDir, "small.py"), "x = 1\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "empty.go"), "")
+
+	streamed, err := NewService(languages.NewRegistry(), 2).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	mapped, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, MmapThreshold: 1024}).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory with mmap failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(streamed.Files, mapped.Files) || len(mapped.Errors) != 0 {
+		t.Fatalf("mmap changed results:\n%+v\n%+v", streamed.Files, mapped.Files)
+	}
+	if mapped.Total.Files != 3 || mapped.Total.Code != 202 {
+		t.Fatalf("unexpected totals: %+v", mapped.Total)
+	}
+}
+
+// TestScanAdaptiveWorkers 验证自适应并发不改变扫描结果。
+func TestScanAdaptiveWorkers(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		writeFixtureFile(t, filepath.Join(tempDir, fmt.Sprintf("pkg%d", i%5), fmt.Sprintf("f%d.go", i)), "package p\n\n// c\nvar x = 1\n")
+	}
+
+	fixed, err := NewService(languages.NewRegistry(), 4).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	adaptive, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 16, AdaptiveWorkers: true}).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("adaptive scan failed: %v", err)
+	}
+	if !reflect.DeepEqual(fixed.Files, adaptive.Files) || adaptive.Total.Files != 50 {
+		t.Fatalf("adaptive workers changed results: %+v", adaptive.Total)
+	}
+}
+
+// TestScanSummaryOnly 验证只汇总模式不保留文件明细，且语言汇总、总计与测试拆分与完整扫描一致。
+func TestScanSummaryOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 30; i++ {
+		writeFixtureFile(t, filepath.Join(tempDir, fmt.Sprintf("pkg%d", i%3), fmt.Sprintf("f%d.go", i)), strings.Repeat("var x = 1\n", i+1))
+		writeFixtureFile(t, filepath.Join(tempDir, fmt.Sprintf("pkg%d", i%3), fmt.Sprintf("f%d_test.go", i)), "package p\n// c\n")
+	}
+	writeFixtureFile(t, filepath.Join(tempDir, "main.py"), "print(1)\n\n")
+
+	options := Options{Workers: 4, SizeDistribution: true}
+	full, err := NewServiceWithOptions(languages.NewRegistry(), options).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	options.SummaryOnly = true
+	summary, err := NewServiceWithOptions(languages.NewRegistry(), options).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("summary-only scan failed: %v", err)
+	}
+
+	if !summary.SummaryOnly || len(summary.Files) != 0 || summary.Total.Files != 61 {
+		t.Fatalf("expected summary without files: %d files, total %+v", len(summary.Files), summary.Total)
+	}
+	if !reflect.DeepEqual(full.Languages, summary.Languages) || !reflect.DeepEqual(full.Total, summary.Total) || !reflect.DeepEqual(full.TestSplit, summary.TestSplit) {
+		t.Fatalf("summary-only changed summaries: %+v vs %+v", summary.Total, full.Total)
+	}
+}
+
+// TestScanParallelSummaries 验证各 worker 并行累加的部分汇总合并后与按文件重新 Summarize 的结果一致（包括跨文件 ULOC 与分布），
+// UnsortedFiles 只跳过排序而不改变文件集合。
+func TestScanParallelSummaries(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 40; i++ {
+		writeFixtureFile(t, filepath.Join(tempDir, fmt.Sprintf("pkg%d", i%5), fmt.Sprintf("f%d.go", i)), strings.Repeat(fmt.Sprintf("var x%d = 1\n", i%7), i%4+1))
+		writeFixtureFile(t, filepath.Join(tempDir, fmt.Sprintf("pkg%d", i%5), fmt.Sprintf("h%d.h", i)), "int x;\n/* c */\n")
+	}
+	writeFixtureFile(t, filepath.Join(tempDir, "util_test.py"), "x = 1\n")
+
+	options := Options{Workers: 6, SizeDistribution: true}
+	result, err := NewServiceWithOptions(languages.NewRegistry(), options).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	expected := result
+	expected.Files = append([]model.FileMetrics(nil), result.Files...)
+	expected.Summarize(model.SummaryOptions{Extensions: languages.NewRegistry().ExtensionsForLanguage, SizeDistribution: true})
+	if !reflect.DeepEqual(expected.Languages, result.Languages) || !reflect.DeepEqual(expected.Total, result.Total) || !reflect.DeepEqual(expected.TestSplit, result.TestSplit) {
+		t.Fatalf("parallel summaries differ: %+v vs %+v", result.Total, expected.Total)
+	}
+	if result.Total.ULOC != 9 {
+		t.Fatalf("expected cross-file uloc 9, got %d", result.Total.ULOC)
+	}
+
+	options.UnsortedFiles = true
+	unsorted, err := NewServiceWithOptions(languages.NewRegistry(), options).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if !reflect.DeepEqual(unsorted.Total, result.Total) || len(unsorted.Files) != len(result.Files) {
+		t.Fatalf("unsorted scan changed results: %+v", unsorted.Total)
+	}
+	unsorted.SortByPath()
+	if !reflect.DeepEqual(unsorted.Files, result.Files) {
+		t.Fatalf("unsorted scan changed files")
+	}
+}
+
+// TestNextWorkerLimit 验证 I/O 等待时增加并发、CPU 饱和时回落到核心数，且不超过上限。
+func TestNextWorkerLimit(t *testing.T) {
+	cpus := runtime.NumCPU()
+	if next := nextWorkerLimit(cpus, cpus*8, 0.2); next != min(cpus*2, cpus*8) {
+		t.Fatalf("expected limit doubled on low utilization, got %d", next)
+	}
+	if next := nextWorkerLimit(cpus*8, cpus*8, 0.1); next != cpus*8 {
+		t.Fatalf("expected limit capped at maximum, got %d", next)
+	}
+	if next := nextWorkerLimit(cpus*8, cpus*8, 0.95); next >= cpus*8 || next < cpus {
+		t.Fatalf("expected limit decreased towards cpu count, got %d", next)
+	}
+	if next := nextWorkerLimit(cpus, cpus*8, 0.95); next != cpus {
+		t.Fatalf("expected limit kept at cpu count, got %d", next)
+	}
+	if next := nextWorkerLimit(cpus*2, cpus*8, 0.75); next != cpus*2 {
+		t.Fatalf("expected limit unchanged, got %d", next)
+	}
+}
+
+// TestWorkerGate 验证 gate 限制同时工作的数量，调高上限后等待者被唤醒。
+func TestWorkerGate(t *testing.T) {
+	gate := newWorkerGate(1)
+	gate.acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		gate.acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("expected second acquire to block at limit 1")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	gate.setLimit(2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("expected waiter to proceed after raising the limit")
+	}
+	gate.release()
+	gate.release()
+	if gate.currentLimit() != 2 {
+		t.Fatalf("unexpected limit: %d", gate.currentLimit())
+	}
+}
+
+// TestScanSizeDistribution 验证按语言的单文件行数分位数与直方图分桶。
+func TestScanSizeDistribution(t *testing.T) {
+	tempDir := t.TempDir()
+
+	for index, lines := range []int{1, 2, 3, 60, 300} {
+		content := strings.Repeat("x = 1\n", lines)
+		writeFixtureFile(t, filepath.Join(tempDir, "f"+string(rune('a'+index))+".py"), content)
+	}
+
+	service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, SizeDistribution: true})
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	if len(result.Languages) != 1 || result.Languages[0].Distribution == nil {
+		t.Fatalf("expected python distribution: %+v", result.Languages)
+	}
+	distribution := result.Languages[0].Distribution
+	if distribution.P50 != 3 || distribution.P90 != 300 || distribution.Max != 300 {
+		t.Fatalf("unexpected percentiles: %+v", distribution)
+	}
+	if distribution.Buckets[0].Files != 3 || distribution.Buckets[1].Files != 1 || distribution.Buckets[3].Files != 1 {
+		t.Fatalf("unexpected buckets: %+v", distribution.Buckets)
+	}
+}
+
+// TestScanStream 验证流式扫描逐个投递文件结果与错误，并在结束后关闭通道。
+func TestScanStream(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, "a.go"), "package a\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "b.py"), "x = 1\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "c.rs"), "fn main() {}\n")
+
+	service := NewService(languages.NewRegistry(), 2)
+	files, scanErrors, err := service.ScanStream(context.Background(), tempDir)
+	if err != nil {
+		t.Fatalf("scan stream failed: %v", err)
+	}
+
+	paths := make([]string, 0)
+	for files != nil || scanErrors != nil {
+		select {
+		case item, ok := <-files:
+			if !ok {
+				files = nil
+				continue
+			}
+			paths = append(paths, item.Path)
+		case item, ok := <-scanErrors:
+			if !ok {
+				scanErrors = nil
+				continue
+			}
+			t.Fatalf("unexpected scan error: %+v", item)
+		}
+	}
+
+	sort.Strings(paths)
+	if strings.Join(paths, ",") != "a.go,b.py,c.rs" {
+		t.Fatalf("unexpected streamed files: %v", paths)
+	}
+}
+
+// TestScanStreamCanceled 验证取消 ctx 后通道会被关闭。
+func TestScanStreamCanceled(t *testing.T) {
+	tempDir := t.TempDir()
+	for index := 0; index < 20; index++ {
+		writeFixtureFile(t, filepath.Join(tempDir, fmt.Sprintf("f%d.go", index)), "package f\n")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	service := NewService(languages.NewRegistry(), 2)
+	files, scanErrors, err := service.ScanStream(ctx, tempDir)
+	if err != nil {
+		t.Fatalf("scan stream failed: %v", err)
+	}
+	for range files {
+	}
+	for range scanErrors {
+	}
+}
+
+// TestScanHooks 验证生命周期回调的触发与 OnFileDiscovered 的过滤能力。
+func TestScanHooks(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, "keep.go"), "package keep\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "skip.go"), "package skip\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "util.py"), "x = 1\n")
+
+	var mu sync.Mutex
+	discovered := make([]string, 0)
+	analyzed := make([]string, 0)
+	hooks := Hooks{
+		OnFileDiscovered: func(path string, language string) bool {
+			discovered = append(discovered, language+":"+path)
+			return path != "skip.go"
+		},
+		OnFileAnalyzed: func(file model.FileMetrics) {
+			mu.Lock()
+			defer mu.Unlock()
+			analyzed = append(analyzed, file.Path)
+		},
+	}
+
+	service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Hooks: hooks})
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	if strings.Join(discovered, ",") != "Go:keep.go,Go:skip.go,Python:util.py" {
+		t.Fatalf("unexpected discovered files: %v", discovered)
+	}
+	sort.Strings(analyzed)
+	if strings.Join(analyzed, ",") != "keep.go,util.py" || result.Total.Files != 2 {
+		t.Fatalf("unexpected analyzed files: %v (total %d)", analyzed, result.Total.Files)
+	}
+}
+
+// mapFSWalker 是测试用的内存 Walker，模拟非文件系统来源。
+type mapFSWalker struct {
+	files fstest.MapFS
+}
+
+func (w mapFSWalker) Root() string {
+	return "memory"
+}
+
+func (w mapFSWalker) Walk(ctx context.Context, visit func(entry Entry) error) error {
+	return fs.WalkDir(w.files, ".", func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil || entry.IsDir() {
+			return walkErr
+		}
+		return visit(Entry{
+			Path: path,
+			Open: func() (io.ReadCloser, fs.FileInfo, error) {
+				file, err := w.files.Open(path)
+				if err != nil {
+					return nil, nil, err
+				}
+				info, err := file.Stat()
+				return file, info, err
+			},
+		})
+	})
+}
+
+// TestScanWalker 验证自定义 Walker 可以复用同一套分析与汇总逻辑。
+func TestScanWalker(t *testing.T) {
+	walker := mapFSWalker{files: fstest.MapFS{
+		"main.go":        {Data: []byte("package main\n\nfunc main() {}\n")},
+		"lib/util.py":    {Data: []byte("# util\nx = 1\n")},
+		"docs/README.md": {Data: []byte("# ignored\n")},
+	}}
+
+	service := NewService(languages.NewRegistry(), 2)
+	result, err := service.ScanWalker(context.Background(), walker)
+	if err != nil {
+		t.Fatalf("scan walker failed: %v", err)
+	}
+
+	if result.ScannedPath != "memory" || result.Total.Files != 2 {
+		t.Fatalf("unexpected result: path=%s files=%d", result.ScannedPath, result.Total.Files)
+	}
+	if result.Files[0].Path != "lib/util.py" || result.Files[0].Metrics.Bytes != 13 {
+		t.Fatalf("unexpected file metrics: %+v", result.Files[0])
+	}
+	if result.Total.Code != 3 || result.Total.Comment != 1 {
+		t.Fatalf("unexpected total: %+v", result.Total)
+	}
+}
+
+// TestScanPlugin 验证外部插件收到文件路径与内容，并以其输出作为统计结果。
+func TestScanPlugin(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, "infra", "main.tf"), "resource \"x\" \"y\" {}\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "bad.tf"), "oops\n")
+
+	script := `input=$(cat); case "$input" in *'"path":"bad.tf"'*) echo broken >&2; exit 3;; esac; ` +
+		`echo '{"total":4,"code":3,"comment":1,"blank":0}'`
+	plugin, err := languages.ParsePluginSpec("Terraform:tf:sh -c")
+	if err != nil {
+		t.Fatalf("parse plugin failed: %v", err)
+	}
+	plugin.Command = append(plugin.Command, script)
+
+	registry := languages.NewRegistry()
+	registry.Register(&languages.PluginAnalyzer{Definition: plugin})
+	result, err := NewService(registry, 2).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	if len(result.Files) != 1 || result.Files[0].Path != "infra/main.tf" || result.Files[0].Metrics.Code != 3 {
+		t.Fatalf("unexpected plugin files: %+v", result.Files)
+	}
+	if result.Files[0].Metrics.Bytes != 20 {
+		t.Fatalf("unexpected bytes: %d", result.Files[0].Metrics.Bytes)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "broken") {
+		t.Fatalf("unexpected plugin errors: %+v", result.Errors)
+	}
+	if result.Errors[0].Category != model.ErrorCategoryDecode || !errors.Is(result.Errors[0], model.ErrDecode) {
+		t.Fatalf("unexpected plugin error category: %+v", result.Errors[0])
+	}
+}
+
+// failingWalker 是测试用的 Walker，按文件名返回打开失败或读取失败的条目。
+type failingWalker struct{}
+
+func (failingWalker) Root() string {
+	return "failing"
+}
+
+func (failingWalker) Walk(_ context.Context, visit func(entry Entry) error) error {
+	if err := visit(Entry{
+		Path: "secret.go",
+		Open: func() (io.ReadCloser, fs.FileInfo, error) {
+			return nil, nil, &fs.PathError{Op: "open", Path: "secret.go", Err: syscall.EACCES}
+		},
+	}); err != nil {
+		return err
+	}
+	return visit(Entry{
+		Path: "broken.go",
+		Open: func() (io.ReadCloser, fs.FileInfo, error) {
+			info, err := fs.Stat(fstest.MapFS{"broken.go": {}}, "broken.go")
+			return io.NopCloser(iotest.ErrReader(io.ErrUnexpectedEOF)), info, err
+		},
+	})
+}
+
+// countingWalker 是测试用的内存 Walker，依次提供 files 个 Go 文件并记录每个文件被打开的次数；
+// 提供完 cancelAfter 个文件（大于 0 时）后调用 cancel，全部提供后返回 err。
+type countingWalker struct {
+	files       int
+	err         error
+	cancelAfter int
+	cancel      context.CancelFunc
+
+	mu     sync.Mutex
+	opened map[string]int
+}
+
+func (w *countingWalker) Root() string {
+	return "counting"
+}
+
+func (w *countingWalker) Walk(ctx context.Context, visit func(entry Entry) error) error {
+	for index := 0; index < w.files; index++ {
+		if index == w.cancelAfter && w.cancel != nil {
+			w.cancel()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := fmt.Sprintf("f%04d.go", index)
+		err := visit(Entry{
+			Path: path,
+			Open: func() (io.ReadCloser, fs.FileInfo, error) {
+				w.mu.Lock()
+				w.opened[path]++
+				w.mu.Unlock()
+				files := fstest.MapFS{path: {Data: []byte("package f\n")}}
+				file, err := files.Open(path)
+				if err != nil {
+					return nil, nil, err
+				}
+				info, err := file.Stat()
+				return file, info, err
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return w.err
+}
+
+// TestScanBatchedDispatchWalkError 验证按批发送任务时，遍历失败前已发现的文件（包括未满一批的尾部）都恰好分析一次，
+// 遍历错误仍会返回。
+func TestScanBatchedDispatchWalkError(t *testing.T) {
+	walkErr := errors.New("walk failed")
+	for _, count := range []int{1, dispatchBatchSize - 1, dispatchBatchSize, dispatchBatchSize + 1, 3*dispatchBatchSize + 5} {
+		walker := &countingWalker{files: count, err: walkErr, opened: map[string]int{}}
+		result, err := NewService(languages.NewRegistry(), 4).ScanWalker(context.Background(), walker)
+		if !errors.Is(err, walkErr) {
+			t.Fatalf("%d files: expected walk error, got %v", count, err)
+		}
+		if len(result.Files) != count || len(walker.opened) != count {
+			t.Fatalf("%d files: got %d results, %d opened", count, len(result.Files), len(walker.opened))
+		}
+		seen := make(map[string]bool, count)
+		for _, file := range result.Files {
+			if seen[file.Path] || walker.opened[file.Path] != 1 {
+				t.Fatalf("%d files: %s delivered twice or opened %d times", count, file.Path, walker.opened[file.Path])
+			}
+			seen[file.Path] = true
+		}
+
+		walker = &countingWalker{files: count, err: walkErr, opened: map[string]int{}}
+		files, scanErrors := NewService(languages.NewRegistry(), 4).StreamWalker(context.Background(), walker)
+		streamed := make(map[string]int)
+		var streamErrors []model.ScanError
+		for files != nil || scanErrors != nil {
+			select {
+			case file, ok := <-files:
+				if !ok {
+					files = nil
+					continue
+				}
+				streamed[file.Path]++
+			case scanError, ok := <-scanErrors:
+				if !ok {
+					scanErrors = nil
+					continue
+				}
+				streamErrors = append(streamErrors, scanError)
+			}
+		}
+		if len(streamed) != count || len(streamErrors) != 1 || !strings.Contains(streamErrors[0].Message, "walk failed") {
+			t.Fatalf("%d files: streamed %d files, errors %+v", count, len(streamed), streamErrors)
+		}
+		for path, times := range streamed {
+			if times != 1 {
+				t.Fatalf("%d files: %s streamed %d times", count, path, times)
+			}
+		}
+	}
+}
+
+// TestScanBatchedDispatchCanceled 验证遍历中途取消时扫描返回取消原因，取消后发现的文件不会被分析，
+// 已分析的文件不会重复打开或重复投递。
+func TestScanBatchedDispatchCanceled(t *testing.T) {
+	for _, cancelAfter := range []int{dispatchBatchSize / 2, dispatchBatchSize, 2*dispatchBatchSize + 7} {
+		ctx, cancel := context.WithCancel(context.Background())
+		walker := &countingWalker{files: 10 * dispatchBatchSize, cancelAfter: cancelAfter, cancel: cancel, opened: map[string]int{}}
+		result, err := NewService(languages.NewRegistry(), 4).ScanWalker(ctx, walker)
+		cancel()
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("cancel after %d: expected context.Canceled, got %v", cancelAfter, err)
+		}
+		if len(walker.opened) > cancelAfter || len(result.Files) > len(walker.opened) {
+			t.Fatalf("cancel after %d: %d opened, %d results", cancelAfter, len(walker.opened), len(result.Files))
+		}
+		for path, times := range walker.opened {
+			if times != 1 {
+				t.Fatalf("cancel after %d: %s opened %d times", cancelAfter, path, times)
+			}
+		}
+		seen := make(map[string]bool, len(result.Files))
+		for _, file := range result.Files {
+			if seen[file.Path] || walker.opened[file.Path] != 1 {
+				t.Fatalf("cancel after %d: %s delivered twice or never opened", cancelAfter, file.Path)
+			}
+			seen[file.Path] = true
+		}
+	}
+}
+
+// TestScanErrorCategories 验证扫描错误携带分类与错误码，并可用 errors.Is 区分权限问题与读取问题。
+func TestScanErrorCategories(t *testing.T) {
+	result, err := NewService(languages.NewRegistry(), 1).ScanWalker(context.Background(), failingWalker{})
+	if err != nil {
+		t.Fatalf("scan walker failed: %v", err)
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("unexpected errors: %+v", result.Errors)
+	}
+
+	broken, secret := result.Errors[0], result.Errors[1]
+	if secret.Category != model.ErrorCategoryOpen || secret.Errno != int(syscall.EACCES) {
+		t.Fatalf("unexpected open error: %+v", secret)
+	}
+	if !errors.Is(secret, model.ErrOpen) || !errors.Is(secret, fs.ErrPermission) || errors.Is(secret, model.ErrRead) {
+		t.Fatalf("open error does not match expected sentinels: %v", secret)
+	}
+	if broken.Category != model.ErrorCategoryRead || broken.Errno != 0 || !errors.Is(broken, io.ErrUnexpectedEOF) {
+		t.Fatalf("unexpected read error: %+v", broken)
+	}
+
+	// 从 JSON 读回后底层错误丢失，但分类与错误码仍可用于 errors.Is。
+	data, err := json.Marshal(secret)
+	if err != nil {
+		t.Fatalf("marshal scan error failed: %v", err)
+	}
+	var decoded model.ScanError
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal scan error failed: %v", err)
+	}
+	if !errors.Is(decoded, model.ErrOpen) || !errors.Is(decoded, fs.ErrPermission) {
+		t.Fatalf("decoded error lost its category: %s", data)
+	}
+}
+
+// TestScanFileLimits 验证超过大小上限的文件记为 oversize、超过分析时限的文件记为 timeout，二者都不计入统计。
+func TestScanFileLimits(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, "small.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "large.go"), "package main\n\n"+strings.Repeat("var x = 1\n", 100))
+
+	result, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, MaxFileSize: 64}).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Path != "small.go" || len(result.Errors) != 1 {
+		t.Fatalf("unexpected files %+v and errors %+v", result.Files, result.Errors)
+	}
+	if oversize := result.Errors[0]; oversize.Path != "large.go" || oversize.Category != model.ErrorCategoryOversize || !errors.Is(oversize, model.ErrOversize) {
+		t.Fatalf("unexpected oversize error: %+v", oversize)
+	}
+
+	// 时限极短时第一次读取就已超时。
+	result, err = NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, FileTimeout: time.Nanosecond}).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if len(result.Files) != 0 || len(result.Errors) != 2 {
+		t.Fatalf("unexpected files %+v and errors %+v", result.Files, result.Errors)
+	}
+	for _, timeout := range result.Errors {
+		if timeout.Category != model.ErrorCategoryTimeout || !errors.Is(timeout, model.ErrTimeout) {
+			t.Fatalf("unexpected timeout error: %+v", timeout)
+		}
+	}
+}
+
+// TestScanExpvarMetrics 验证默认 expvar 指标按语言累计文件数、字节数与耗时次数，同名前缀复用同一组变量。
+func TestScanExpvarMetrics(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, "main.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "tool.py"), "x = 1\n")
+
+	metrics := NewExpvarMetrics("gocloc_test")
+	service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Metrics: metrics})
+	for round := 0; round < 2; round++ {
+		if _, err := service.ScanPath(tempDir); err != nil {
+			t.Fatalf("scan directory failed: %v", err)
+		}
+	}
+
+	if NewExpvarMetrics("gocloc_test").files != metrics.files {
+		t.Fatalf("expected published variables to be reused")
+	}
+	if value := expvar.Get("gocloc_test_files").(*expvar.Map).Get("Go").String(); value != "2" {
+		t.Fatalf("unexpected Go file count: %s", value)
+	}
+	if value := expvar.Get("gocloc_test_bytes").String(); value != "38" {
+		t.Fatalf("unexpected bytes: %s", value)
+	}
+	if value := expvar.Get("gocloc_test_file_duration").(*expvar.Map).Get("count").String(); value != "4" {
+		t.Fatalf("unexpected duration count: %s", value)
+	}
+}
+
+// languageRecorder 记录 LanguageObserver 上报的按语言字节数。
+type languageRecorder struct {
+	noopMetrics
+	mu    sync.Mutex
+	bytes map[string]int64
+}
+
+func (r *languageRecorder) ObserveLanguage(language string, bytes int64, _ time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bytes[language] += bytes
+}
+
+// TestScanLanguageObserver 验证 Metrics 实现 LanguageObserver 时按语言上报成功分析的文件。
+func TestScanLanguageObserver(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, "main.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "tool.py"), "x = 1\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "lib.py"), "y = 2\n")
+
+	recorder := &languageRecorder{bytes: make(map[string]int64)}
+	if _, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Metrics: recorder}).ScanPath(tempDir); err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if !reflect.DeepEqual(recorder.bytes, map[string]int64{"Go": 13, "Python": 12}) {
+		t.Fatalf("unexpected language bytes: %v", recorder.bytes)
+	}
+}
+
+// TestScanExcludes 验证排除模式可以匹配文件本身或其上级目录。
+func TestScanExcludes(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, "main.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "api", "types_gen.go"), "package api\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "api", "handler.go"), "package api\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "vendor", "lib", "lib.go"), "package lib\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "web", "node_modules", "pkg", "index.js"), "x\n")
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Logger: logger, Excludes: []string{"vendor", "**/*_gen.go", "**/node_modules"}})
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	if len(result.Files) != 2 || result.Files[0].Path != "api/handler.go" || result.Files[1].Path != "main.go" {
+		t.Fatalf("unexpected files: %+v", result.Files)
+	}
+	// 匹配排除模式的目录在遍历时整体跳过，其中的文件不会再逐个判断。
+	for _, expected := range []string{
+		`msg="directory skipped" path=vendor reason=excluded pattern=vendor`,
+		`msg="directory skipped" path=web/node_modules reason=excluded pattern=**/node_modules`,
+		`msg="file skipped" path=api/types_gen.go reason=excluded`,
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected log %q in:\n%s", expected, output.String())
+		}
+	}
+	if strings.Contains(output.String(), "lib.go") || strings.Contains(output.String(), "index.js") {
+		t.Fatalf("expected pruned directories not to be walked:\n%s", output.String())
+	}
+}
+
+// TestScanGitattributes 验证 linguist 属性：vendored/documentation 的文件被跳过，generated 的文件计入单独的汇总，
+// linguist-language 覆盖按后缀识别的语言；未开启选项时属性不生效。
+func TestScanGitattributes(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, ".gitattributes"), "third_party/** linguist-vendored\n*.pb.go linguist-generated\n*.h linguist-language=Go\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "docs", ".gitattributes"), "*.py linguist-documentation\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "main.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "api", "types.pb.go"), "package api\n\nvar x = 1\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "third_party", "lib.go"), "package lib\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "docs", "conf.py"), "x = 1\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "defs.h"), "package defs\n")
+
+	result, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Gitattributes: true}).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if len(result.Files) != 3 || result.Files[0].Path != "api/types.pb.go" || !result.Files[0].Generated || result.Files[1].Language != "Go" {
+		t.Fatalf("unexpected files: %+v", result.Files)
+	}
+	if result.Total.Files != 2 || result.Generated == nil || result.Generated.Files != 1 || result.Generated.Code != 2 {
+		t.Fatalf("unexpected totals: %+v, generated: %+v", result.Total, result.Generated)
+	}
+	if len(result.Languages) != 1 || result.Languages[0].Files != 2 {
+		t.Fatalf("unexpected languages: %+v", result.Languages)
+	}
+
+	plain, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2}).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if plain.Total.Files != 5 || plain.Generated != nil {
+		t.Fatalf("expected attributes to be ignored by default: %+v", plain.Total)
+	}
+}
+
+// TestScanGitignore 验证 .gitignore 规则：子目录中的规则相对该目录生效，否定规则重新包含文件，
+// 被忽略的目录整体跳过；未开启选项时规则不生效。
+func TestScanGitignore(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, ".gitignore"), "# build output\nout/\n*.gen.go\n!keep.gen.go\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "pkg", ".gitignore"), "/local.go\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "main.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "out", "bin.go"), "package out\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "api", "types.gen.go"), "package api\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "api", "keep.gen.go"), "package api\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "pkg", "local.go"), "package pkg\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "pkg", "sub", "local.go"), "package sub\n")
+
+	result, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Gitignore: true}).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	var paths []string
+	for _, file := range result.Files {
+		paths = append(paths, file.Path)
+	}
+	if want := []string{"api/keep.gen.go", "main.go", "pkg/sub/local.go"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("scanned files = %v, want %v", paths, want)
+	}
+
+	plain, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2}).ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if plain.Total.Files != 6 {
+		t.Fatalf("expected .gitignore to be ignored by default: %+v", plain.Total)
+	}
+}
+
+// TestScanFollowLinks 验证目录链接的处理：默认跳过，开启 FollowLinks 后进入链接目标，
+// 指回上级目录或指向已遍历目录的链接被跳过，指向文件的链接与作为扫描路径的链接总是生效。
+func TestScanFollowLinks(t *testing.T) {
+	tempDir := t.TempDir()
+	root := filepath.Join(tempDir, "root")
+	outside := filepath.Join(tempDir, "outside")
+	writeFixtureFile(t, filepath.Join(root, "src", "a.go"), "package a\n")
+	writeFixtureFile(t, filepath.Join(outside, "b.go"), "package b\n")
+	links := map[string]string{
+		filepath.Join(root, "src", "loop"): root,
+		filepath.Join(root, "dup"):         filepath.Join(root, "src"),
+		filepath.Join(root, "ext"):         outside,
+		filepath.Join(outside, "back"):     outside,
+		filepath.Join(root, "file.go"):     filepath.Join(outside, "b.go"),
+		filepath.Join(tempDir, "alias"):    root,
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+
+	cases := []struct {
+		path        string
+		followLinks bool
+		expected    []string
+	}{
+		{path: root, expected: []string{"file.go", "src/a.go"}},
+		{path: root, followLinks: true, expected: []string{"ext/b.go", "file.go", "src/a.go"}},
+		{path: filepath.Join(tempDir, "alias"), expected: []string{"file.go", "src/a.go"}},
+	}
+	for _, testCase := range cases {
+		service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, FollowLinks: testCase.followLinks})
+		result, err := service.ScanPath(testCase.path)
+		if err != nil {
+			t.Fatalf("scan %s failed: %v", testCase.path, err)
+		}
+		paths := make([]string, 0, len(result.Files))
+		for _, file := range result.Files {
+			paths = append(paths, file.Path)
+		}
+		if !reflect.DeepEqual(paths, testCase.expected) || len(result.Errors) != 0 {
+			t.Fatalf("scan %s (follow links %v): expected %v, got %v with errors %+v", testCase.path, testCase.followLinks, testCase.expected, paths, result.Errors)
+		}
+	}
+}
+
+// TestScanSkipsSpecialFiles 验证命名管道不会被打开（否则 worker 会永久阻塞），而是记录到 Skipped；被排除的不记录。
+func TestScanSkipsSpecialFiles(t *testing.T) {
+	if _, err := exec.LookPath("mkfifo"); err != nil {
+		t.Skip("mkfifo is not available")
+	}
+	root := t.TempDir()
+	writeFixtureFile(t, filepath.Join(root, "main.go"), "package main\n")
+	if err := os.MkdirAll(filepath.Join(root, "tmp"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	for _, name := range []string{"pipe.go", "tmp/excluded.go"} {
+		if output, err := exec.Command("mkfifo", filepath.Join(root, name)).CombinedOutput(); err != nil {
+			t.Skipf("mkfifo failed: %v: %s", err, output)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Excludes: []string{"tmp/excluded.go"}})
+	result, err := service.ScanPathContext(ctx, root)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(result.Files) != 1 || len(result.Errors) != 0 {
+		t.Fatalf("expected only main.go to be scanned, got files %+v errors %+v", result.Files, result.Errors)
+	}
+	expected := []model.SkippedFile{{Path: "pipe.go", Reason: model.SkipReasonNamedPipe}}
+	if !reflect.DeepEqual(result.Skipped, expected) {
+		t.Fatalf("expected skipped %+v, got %+v", expected, result.Skipped)
+	}
+
+	files, err := service.ListPath(ctx, root)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected special files to be excluded from listing, got %+v (%v)", files, err)
+	}
+}
+
+// TestScanPackages 验证包汇总：构建清单所在目录为包边界，被排除的清单同样生效，开启只汇总模式时结果一致。
+func TestScanPackages(t *testing.T) {
+	root := t.TempDir()
+	writeFixtureFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n")
+	writeFixtureFile(t, filepath.Join(root, "main.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(root, "services", "billing", "go.mod"), "module example.com/billing\n")
+	writeFixtureFile(t, filepath.Join(root, "services", "billing", "internal", "invoice.go"), "package internal\n\nvar total = 1\n")
+	writeFixtureFile(t, filepath.Join(root, "web", "package.json"), "{\"name\": \"web\"}\n")
+	writeFixtureFile(t, filepath.Join(root, "web", "src", "app.js"), "const app = 1;\n")
+
+	for _, summaryOnly := range []bool{false, true} {
+		service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Packages: true, SummaryOnly: summaryOnly, Excludes: []string{"**/*.json"}})
+		result, err := service.ScanPath(root)
+		if err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		packages := make(map[string]int64)
+		for _, item := range result.Packages {
+			packages[item.Path] = item.Metrics.Code
+		}
+		expected := map[string]int64{".": 1, "services/billing": 2, "web": 1}
+		if !reflect.DeepEqual(packages, expected) {
+			t.Fatalf("summary only %v: expected packages %v, got %+v", summaryOnly, expected, result.Packages)
+		}
+	}
+
+	result, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2}).ScanPath(root)
+	if err != nil || result.Packages != nil {
+		t.Fatalf("expected no packages by default, got %+v (%v)", result.Packages, err)
+	}
+}
+
+// TestScanCodeOwners 验证按 CODEOWNERS 汇总：多个所有者的文件计入每个所有者，没有所有者的文件计入 (unowned)，
+// 开启只汇总模式时结果一致。
+func TestScanCodeOwners(t *testing.T) {
+	root := t.TempDir()
+	writeFixtureFile(t, filepath.Join(root, "main.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(root, "api", "handler.go"), "package api\n\nvar handler = 1\n")
+	writeFixtureFile(t, filepath.Join(root, "docs", "gen.go"), "package docs\n")
+	codeOwners, err := vcs.ParseCodeOwners(strings.NewReader("* @org/core\n/api/ @org/api @org/platform\n/docs/\n"))
+	if err != nil {
+		t.Fatalf("parse codeowners failed: %v", err)
+	}
+
+	for _, summaryOnly := range []bool{false, true} {
+		service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, CodeOwners: codeOwners, SummaryOnly: summaryOnly})
+		result, err := service.ScanPath(root)
+		if err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		owners := make(map[string]int64)
+		for _, item := range result.Owners {
+			owners[item.Owner] = item.Metrics.Code
+		}
+		expected := map[string]int64{"@org/core": 1, "@org/api": 2, "@org/platform": 2, model.UnownedOwner: 1}
+		if !reflect.DeepEqual(owners, expected) {
+			t.Fatalf("summary only %v: expected owners %v, got %+v", summaryOnly, expected, result.Owners)
+		}
+		if result.Total.Code != 4 {
+			t.Fatalf("expected owners not to affect totals, got %+v", result.Total)
+		}
+		if !summaryOnly {
+			for _, item := range result.Files {
+				if item.Path == "api/handler.go" && !reflect.DeepEqual(item.Owners, []string{"@org/api", "@org/platform"}) {
+					t.Fatalf("unexpected file owners: %+v", item.Owners)
+				}
+			}
+		}
+	}
+
+	result, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2}).ScanPath(root)
+	if err != nil || result.Owners != nil {
+		t.Fatalf("expected no owners by default, got %+v (%v)", result.Owners, err)
+	}
+}
+
+// TestScanCheckpoint 验证中断的扫描可以从检查点恢复：已完成的文件直接采用检查点中的结果，
+// 写了一半的末尾记录被丢弃，分析选项不一致时拒绝恢复，成功后删除检查点。
+func TestScanCheckpoint(t *testing.T) {
+	tempDir := t.TempDir()
+	root := filepath.Join(tempDir, "src")
+	writeFixtureFile(t, filepath.Join(root, "a.go"), "package a\n")
+	writeFixtureFile(t, filepath.Join(root, "b.go"), "package b\n\nvar b = 1\n")
+	path := filepath.Join(tempDir, "scan.ckpt")
+
+	// 第一次扫描只完成 a.go，模拟在 b.go 之前中断。
+	checkpoint, err := OpenCheckpoint(path, false)
+	if err != nil {
+		t.Fatalf("open checkpoint failed: %v", err)
+	}
+	onlyA := Hooks{OnFileDiscovered: func(path string, _ string) bool { return path == "a.go" }}
+	if _, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Checkpoint: checkpoint, Hooks: onlyA}).ScanPath(root); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if err := checkpoint.Close(); err != nil {
+		t.Fatalf("close checkpoint failed: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open checkpoint file failed: %v", err)
+	}
+	if _, err := file.WriteString(`{"root":"`); err != nil {
+		t.Fatalf("write partial record failed: %v", err)
+	}
+	_ = file.Close()
+	// 已完成的文件不会重新分析，修改后仍沿用检查点中的结果。
+	writeFixtureFile(t, filepath.Join(root, "a.go"), "package a\n\nvar a = 1\n")
+
+	if checkpoint, err = OpenCheckpoint(path, true); err != nil {
+		t.Fatalf("resume checkpoint failed: %v", err)
+	}
+	if checkpoint.Restored() != 1 {
+		t.Fatalf("expected one restored file, got %d", checkpoint.Restored())
+	}
+	_, err = NewServiceWithOptions(languages.NewRegistryWithOptions(languages.Options{CountFunctions: true}), Options{Checkpoint: checkpoint}).ScanPath(root)
+	if err == nil || !strings.Contains(err.Error(), "different analysis options") {
+		t.Fatalf("expected options mismatch error, got %v", err)
+	}
+
+	result, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 2, Checkpoint: checkpoint}).ScanPath(root)
+	if err != nil {
+		t.Fatalf("resumed scan failed: %v", err)
+	}
+	if len(result.Files) != 2 || result.Files[0].Metrics.Total != 1 || result.Files[1].Metrics.Total != 3 || result.Total.Total != 4 {
+		t.Fatalf("unexpected resumed result: %+v", result.Files)
+	}
+	if err := checkpoint.Remove(); err != nil {
+		t.Fatalf("remove checkpoint failed: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected checkpoint to be removed, got %v", err)
+	}
+}
+
+// TestScanCheckpointDuplicates 验证从检查点恢复的文件保留代码行哈希：ULOC 跨文件去重与重复检测的结果
+// 与一次完成的扫描一致。
+func TestScanCheckpointDuplicates(t *testing.T) {
+	tempDir := t.TempDir()
+	root := filepath.Join(tempDir, "src")
+	content := "package dup\n\nfunc f() int {\n\tx := 1\n\ty := 2\n\treturn x + y\n}\n"
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		writeFixtureFile(t, filepath.Join(root, name), content)
+	}
+	path := filepath.Join(tempDir, "scan.ckpt")
+	registry := languages.NewRegistryWithOptions(languages.Options{TrackCodeLines: true})
+	options := Options{Workers: 2, DetectDuplicates: true, DuplicateWindow: 4}
+
+	fresh, err := NewServiceWithOptions(registry, options).ScanPath(root)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	// 第一次扫描只完成 a.go 与 b.go，恢复后 c.go 重新分析。
+	checkpoint, err := OpenCheckpoint(path, false)
+	if err != nil {
+		t.Fatalf("open checkpoint failed: %v", err)
+	}
+	partial := options
+	partial.Checkpoint = checkpoint
+	partial.Hooks = Hooks{OnFileDiscovered: func(path string, _ string) bool { return path != "c.go" }}
+	if _, err := NewServiceWithOptions(registry, partial).ScanPath(root); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if err := checkpoint.Close(); err != nil {
+		t.Fatalf("close checkpoint failed: %v", err)
+	}
+
+	if checkpoint, err = OpenCheckpoint(path, true); err != nil {
+		t.Fatalf("resume checkpoint failed: %v", err)
+	}
+	defer checkpoint.Remove()
+	resumedOptions := options
+	resumedOptions.Checkpoint = checkpoint
+	resumed, err := NewServiceWithOptions(registry, resumedOptions).ScanPath(root)
+	if err != nil {
+		t.Fatalf("resumed scan failed: %v", err)
+	}
+	if checkpoint.Restored() != 2 {
+		t.Fatalf("expected two restored files, got %d", checkpoint.Restored())
+	}
+	if resumed.Total.ULOC != fresh.Total.ULOC || resumed.Total.ULOC != 6 {
+		t.Fatalf("resumed uloc = %d, fresh uloc = %d", resumed.Total.ULOC, fresh.Total.ULOC)
+	}
+	if fresh.Duplication == nil || resumed.Duplication == nil || fresh.Duplication.DuplicatedLines == 0 ||
+		resumed.Duplication.DuplicatedLines != fresh.Duplication.DuplicatedLines || resumed.Duplication.Blocks != fresh.Duplication.Blocks {
+		t.Fatalf("resumed duplication = %+v, fresh duplication = %+v", resumed.Duplication, fresh.Duplication)
+	}
+}
+
+// TestScanCache 验证缓存命中时复用结果，文件变化后重新分析。
+func TestScanCache(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "main.go")
+	writeFixtureFile(t, filePath, "package main\n")
+
+	cache := NewMemoryCache()
+	service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 1, Cache: cache})
+	for range 2 {
+		result, err := service.ScanPath(tempDir)
+		if err != nil {
+			t.Fatalf("scan directory failed: %v", err)
+		}
+		if result.Total.Code != 1 || result.Files[0].Path != "main.go" {
+			t.Fatalf("unexpected result: %+v", result.Total)
+		}
+	}
+	if stats := cache.Stats(); stats.Entries != 1 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("unexpected cache stats: %+v", stats)
+	}
+
+	writeFixtureFile(t, filePath, "package main\n\nfunc main() {}\n")
+	result, err := service.ScanPath(tempDir)
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if result.Total.Code != 2 || cache.Stats().Misses != 2 {
+		t.Fatalf("expected changed file to be analyzed again: %+v", cache.Stats())
+	}
+}
+
+// TestScanContentCache 验证按内容缓存跨扫描器、跨检出路径复用结果（包括 ULOC 去重与 shebang），
+// 分析选项变化时不会读到其他配置的结果。
+func TestScanContentCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	checkouts := []string{t.TempDir(), t.TempDir()}
+	for _, root := range checkouts {
+		writeFixtureFile(t, filepath.Join(root, "main.go"), "package main\n\nvar x = 1\n")
+		writeFixtureFile(t, filepath.Join(root, "pkg", "copy.go"), "package pkg\nvar x = 1\n")
+		writeFixtureFile(t, filepath.Join(root, "run.py"), "#!/usr/bin/env python3\nprint(1)\n")
+	}
+
+	cache := NewContentCache(NewDirStore(cacheDir))
+	options := Options{Workers: 2, ScriptStats: true, ContentCache: cache}
+	first, err := NewServiceWithOptions(languages.NewRegistry(), options).ScanPath(checkouts[0])
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	second, err := NewServiceWithOptions(languages.NewRegistry(), options).ScanPath(checkouts[1])
+	if err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if stats := cache.Stats(); stats.Hits != 3 || stats.Misses != 3 || stats.Errors != 0 {
+		t.Fatalf("unexpected content cache stats: %+v", stats)
+	}
+	if !reflect.DeepEqual(first.Files, second.Files) || !reflect.DeepEqual(first.Total, second.Total) || second.Total.ULOC != 4 {
+		t.Fatalf("cached results differ: %+v vs %+v", second.Total, first.Total)
+	}
+	for _, item := range second.Files {
+		if item.Path == "run.py" && item.Shebang != "python3" {
+			t.Fatalf("expected cached shebang: %+v", item)
+		}
+	}
+
+	counting := NewServiceWithOptions(languages.NewRegistryWithOptions(languages.Options{CountFunctions: true}), Options{Workers: 1, ContentCache: cache})
+	if _, err := counting.ScanPath(checkouts[1]); err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+	if stats := cache.Stats(); stats.Hits != 3 || stats.Misses != 6 {
+		t.Fatalf("expected different analyzer options to miss: %+v", stats)
+	}
+}
+
+// TestHTTPContentStore 验证 HTTP 后端的 GET/PUT、404 未命中与 Basic 认证，以及 S3 请求带有 SigV4 签名。
+func TestHTTPContentStore(t *testing.T) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	authorizations := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		authorizations = append(authorizations, request.Header.Get("Authorization"))
+		switch request.Method {
+		case http.MethodPut:
+			objects[request.URL.Path], _ = io.ReadAll(request.Body)
+			writer.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			data, ok := objects[request.URL.Path]
+			if !ok {
+				http.NotFound(writer, request)
+				return
+			}
+			_, _ = writer.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	store, err := OpenContentStore(strings.Replace(server.URL, "http://", "http://ci:secret@", 1) + "/cache/")
+	if err != nil {
+		t.Fatalf("open http store failed: %v", err)
+	}
+	ctx := context.Background()
+	if _, ok, err := store.Load(ctx, "missing"); ok || err != nil {
+		t.Fatalf("expected miss, got %v %v", ok, err)
+	}
+	if err := store.Store(ctx, "entry", []byte("data")); err != nil {
+		t.Fatalf("store failed: %v", err)
+	}
+	if data, ok, err := store.Load(ctx, "entry"); !ok || err != nil || string(data) != "data" {
+		t.Fatalf("unexpected load: %q %v %v", data, ok, err)
+	}
+	if _, ok := objects["/cache/entry"]; !ok || !strings.HasPrefix(authorizations[0], "Basic ") {
+		t.Fatalf("unexpected requests: %v %v", objects, authorizations)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+	s3Store, err := OpenContentStore("s3://bucket/gocloc")
+	if err != nil {
+		t.Fatalf("open s3 store failed: %v", err)
+	}
+	if err := s3Store.Store(ctx, "entry", []byte("data")); err != nil {
+		t.Fatalf("s3 store failed: %v", err)
+	}
+	last := authorizations[len(authorizations)-1]
+	if _, ok := objects["/bucket/gocloc/entry"]; !ok || !strings.HasPrefix(last, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(last, "/eu-west-1/s3/aws4_request") {
+		t.Fatalf("unexpected s3 request: %v %q", objects, last)
+	}
+}
+
+// TestListWalker 验证文件清单与扫描使用同一套过滤规则（后缀、Excludes 与 OnFileDiscovered）。
+func TestListWalker(t *testing.T) {
+	walker := mapFSWalker{files: fstest.MapFS{
+		"main.go":            {Data: []byte("package main\n")},
+		"lib/util.py":        {Data: []byte("x = 1\n")},
+		"vendor/dep/dep.go":  {Data: []byte("package dep\n")},
+		"docs/README.md":     {Data: []byte("# ignored\n")},
+		"scripts/release.py": {Data: []byte("print(1)\n")},
+	}}
+
+	service := NewServiceWithOptions(languages.NewRegistry(), Options{
+		Excludes: []string{"vendor"},
+		Hooks: Hooks{OnFileDiscovered: func(path string, _ string) bool {
+			return !strings.HasPrefix(path, "scripts/")
+		}},
+	})
+	files, err := service.ListWalker(context.Background(), walker)
+	if err != nil {
+		t.Fatalf("list walker failed: %v", err)
+	}
+
+	expected := []model.DiscoveredFile{{Path: "lib/util.py", Language: "Python"}, {Path: "main.go", Language: "Go"}}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+}
+
+// TestScanLogger 验证注入的 slog 日志能收到扫描开始/结束、阶段耗时与发现决策。
+func TestScanLogger(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFixtureFile(t, filepath.Join(tempDir, "main.go"), "package main\n")
+	writeFixtureFile(t, filepath.Join(tempDir, "README.md"), "# readme\n")
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	service := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 1, Logger: logger})
+	if _, err := service.ScanPath(tempDir); err != nil {
+		t.Fatalf("scan directory failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		`msg="scan started"`,
+		`msg="file skipped" path=README.md reason="unsupported extension"`,
+		`msg="file matched" path=main.go analyzer=Go`,
+		`msg="file analyzed" path=main.go language=Go`,
+		`msg="phase finished" phase=analyze`,
+		`msg="scan finished"`,
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("expected log %q in:\n%s", expected, output.String())
+		}
+	}
+}
+
+// TestScanIOThrottle 验证读取限速：结果与不限速时一致，读取量超过令牌桶容量的部分按速率等待，
+// 并发上限只约束进行中的读取调用，ctx 取消时等待中的读取立即返回。
+func TestScanIOThrottle(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		writeFixtureFile(t, filepath.Join(root, name), "package p\n\n"+strings.Repeat("var x = 1 // padding\n", 200))
+	}
+
+	expected, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 3}).ScanPath(root)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	// 三个文件约 12.6 KB，令牌桶容量为 8 KB，超出部分至少需要等待约 0.5 秒。
+	startedAt := time.Now()
+	throttled, err := NewServiceWithOptions(languages.NewRegistry(), Options{Workers: 3, MaxReadBytesPerSec: 8 << 10, IOConcurrency: 1}).ScanPath(root)
+	if err != nil {
+		t.Fatalf("throttled scan failed: %v", err)
+	}
+	if elapsed := time.Since(startedAt); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected reads to be rate limited, scan took %s", elapsed)
+	}
+	if !reflect.DeepEqual(throttled.Total, expected.Total) || len(throttled.Files) != 3 {
+		t.Fatalf("unexpected throttled result: %+v, expected %+v", throttled.Total, expected.Total)
+	}
+
+	throttle := newIOThrottle(0, 2)
+	var active, peak int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reader := throttle.reader(context.Background(), readerFunc(func(buffer []byte) (int, error) {
+				mu.Lock()
+				active++
+				peak = max(peak, active)
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				active--
+				mu.Unlock()
+				return 0, io.EOF
+			}))
+			_, _ = reader.Read(make([]byte, 1))
+		}()
+	}
+	wg.Wait()
+	if peak != 2 {
+		t.Fatalf("expected at most 2 concurrent reads, got %d", peak)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reader := newIOThrottle(1, 0).reader(ctx, strings.NewReader("package p\n"))
+	if _, err := io.ReadAll(reader); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled read, got %v", err)
+	}
+	if newIOThrottle(0, 0) != nil {
+		t.Fatal("expected no throttle without limits")
+	}
+}
+
+// readerFunc 把函数适配为 io.Reader。
+type readerFunc func(buffer []byte) (int, error)
+
+// Read 实现 io.Reader。
+func (f readerFunc) Read(buffer []byte) (int, error) {
+	return f(buffer)
+}
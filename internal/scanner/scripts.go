@@ -0,0 +1,39 @@
+package scanner
+
+import (
+	"bufio"
+	"io"
+	"sync"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+)
+
+// shebangPeekSize 是识别 shebang 时最多窥探的首行字节数。
+const shebangPeekSize = 256
+
+// shebangReaderPool 缓存开启脚本统计时窥探首行用的 bufio.Reader，避免每个文件新建缓冲区。
+var shebangReaderPool = sync.Pool{
+	New: func() any {
+		return bufio.NewReader(nil)
+	},
+}
+
+// acquireShebangReader 从池中借用 reader 并让其从 source 读取。
+func acquireShebangReader(source io.Reader) *bufio.Reader {
+	reader := shebangReaderPool.Get().(*bufio.Reader)
+	reader.Reset(source)
+	return reader
+}
+
+// releaseShebangReader 解除对输入的引用后归还 reader。
+func releaseShebangReader(reader *bufio.Reader) {
+	reader.Reset(nil)
+	shebangReaderPool.Put(reader)
+}
+
+// peekShebang 在不消费数据的前提下读取首行，若以 #! 开头则返回解释器名，否则返回空字符串。
+// 解释器名的提取规则见 languages.ShebangInterpreter。
+func peekShebang(reader *bufio.Reader) string {
+	head, _ := reader.Peek(shebangPeekSize)
+	return languages.ShebangInterpreter(head)
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// ioThrottle 限制 worker 读取文件的速率与并发：
+// - 速率为令牌桶，每秒补充 bytesPerSecond 个令牌，桶容量为一秒的量；每次读取先读再扣除实际读到的字节数，
+// 令牌不足时读取方等待到令牌补足为止，因此长期速率不超过上限，单次读取的大小不受限制
+// - 并发为同时进行中的读取调用数量，只在 Read 调用期间占用名额，分析不占用，
+// 因此 CPU 密集的分析仍可按 worker 数量并行，而共享存储上的未完成请求数不超过上限
+type ioThrottle struct {
+	bytesPerSecond float64
+	// slots 为读取名额，为 nil 时不限制并发。
+	slots chan struct{}
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newIOThrottle 创建读取限制，bytesPerSecond 与 concurrency 均 <=0 时返回 nil（不限制）。
+func newIOThrottle(bytesPerSecond int64, concurrency int) *ioThrottle {
+	if bytesPerSecond <= 0 && concurrency <= 0 {
+		return nil
+	}
+	throttle := &ioThrottle{bytesPerSecond: float64(max(bytesPerSecond, 0))}
+	throttle.tokens = throttle.bytesPerSecond
+	throttle.last = time.Now()
+	if concurrency > 0 {
+		throttle.slots = make(chan struct{}, concurrency)
+	}
+	return throttle
+}
+
+// reader 返回受限制的 reader；throttle 为 nil 时原样返回。
+func (t *ioThrottle) reader(ctx context.Context, reader io.Reader) io.Reader {
+	if t == nil {
+		return reader
+	}
+	return &throttledReader{ctx: ctx, reader: reader, throttle: t}
+}
+
+// acquire 取得一个读取名额，ctx 取消时返回取消原因。
+func (t *ioThrottle) acquire(ctx context.Context) error {
+	if t.slots == nil {
+		return nil
+	}
+	select {
+	case t.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release 归还读取名额。
+func (t *ioThrottle) release() {
+	if t.slots != nil {
+		<-t.slots
+	}
+}
+
+// consume 扣除 n 个令牌，返回需要等待的时间（令牌为负数时等到补足为止）。
+func (t *ioThrottle) consume(n int) time.Duration {
+	if t.bytesPerSecond <= 0 || n <= 0 {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.tokens = min(t.tokens+now.Sub(t.last).Seconds()*t.bytesPerSecond, t.bytesPerSecond)
+	t.last = now
+	t.tokens -= float64(n)
+	if t.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-t.tokens / t.bytesPerSecond * float64(time.Second))
+}
+
+// throttledReader 在每次 Read 时占用读取名额，并按读到的字节数等待令牌。
+type throttledReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	throttle *ioThrottle
+}
+
+// Read 实现 io.Reader；等待期间 ctx 取消时返回取消原因。
+func (r *throttledReader) Read(buffer []byte) (int, error) {
+	if err := r.throttle.acquire(r.ctx); err != nil {
+		return 0, err
+	}
+	n, err := r.reader.Read(buffer)
+	r.throttle.release()
+
+	if wait := r.throttle.consume(n); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.ctx.Done():
+			return n, r.ctx.Err()
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,262 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/vcs"
+)
+
+// Walker 抽象文件发现来源，默认实现为文件系统遍历。
+// 其他来源（git 树、归档、文件内容数据库等）实现该接口后即可复用同一套 worker 池与汇总逻辑。
+type Walker interface {
+	// Root 返回扫描来源的描述（目录绝对路径、归档路径等），写入 ScanResult.ScannedPath。
+	Root() string
+	// Walk 依次把每个文件交给 visit；visit 返回错误时应停止遍历并原样返回该错误。
+	// ctx 取消时应尽快停止遍历。
+	Walk(ctx context.Context, visit func(entry Entry) error) error
+}
+
+// Entry 表示 Walker 发现的一个文件。
+type Entry struct {
+	// Path 为相对扫描根目录、以 / 分隔的路径，用于语言识别、测试文件判定与结果展示。
+	Path string
+	// LocalPath 为文件在本地文件系统中的路径，非本地来源为空（此时跳过 git blame 等依赖本地文件的补充信息）。
+	LocalPath string
+	// Open 打开文件内容，同时返回文件信息（用于字节大小与权限位）。
+	Open func() (io.ReadCloser, fs.FileInfo, error)
+	// Linguist 为 .gitattributes 中 linguist-* 属性对该文件的覆盖，零值表示没有覆盖。
+	Linguist Linguist
+	// Skipped 非空时表示该条目是遍历时跳过的特殊文件（命名管道、套接字、设备等），Open 为 nil，
+	// 扫描只把它记录到 ScanResult.Skipped，不会打开或分析。
+	Skipped model.SkipReason
+}
+
+// Linguist 表示与 GitHub 语言统计一致的 linguist-* 属性。
+// Vendored 与 Documentation 的文件不参与扫描；Generated 的文件照常分析，但计入单独的 generated 汇总，不计入语言统计与总计；
+// Language 非空时按该语言分析，替代按后缀识别的结果。
+type Linguist struct {
+	Vendored      bool
+	Generated     bool
+	Documentation bool
+	Language      string
+}
+
+// linguistFromAttributes 从文件的 git 属性中提取 linguist-* 属性。
+func linguistFromAttributes(values map[string]string) Linguist {
+	linguist := Linguist{
+		Vendored:      values["linguist-vendored"] == "true",
+		Generated:     values["linguist-generated"] == "true",
+		Documentation: values["linguist-documentation"] == "true",
+	}
+	if language := values["linguist-language"]; language != "true" && language != "false" {
+		linguist.Language = language
+	}
+	return linguist
+}
+
+// FileSystemWalker 是基于 filepath.WalkDir 的默认 Walker，根路径可以是目录或单个文件。
+type FileSystemWalker struct {
+	root string
+	// SkipDir 非 nil 时对根目录下的每个子目录调用（参数为相对根目录、以 / 分隔的路径），
+	// 返回 true 的目录不会被进入，其中的文件与子目录都不会被读取或 stat。
+	SkipDir func(path string) bool
+	// Gitattributes 为 true 时读取遍历到的每个目录下的 .gitattributes，把 linguist-* 属性写入 Entry.Linguist。
+	Gitattributes bool
+	// Gitignore 为 true 时读取遍历到的每个目录下的 .gitignore，被忽略的文件不交给 visit，被忽略的目录不会被进入（规则见 vcs.Ignore）。
+	Gitignore bool
+	// FollowLinks 为 true 时进入指向目录的符号链接与 Windows 目录联接（junction）等重解析点，
+	// 结果中的路径保持链接所在位置；目标位于根目录或已进入的链接目标之内的链接会被跳过，避免循环与重复统计。
+	// 为 false 时这类链接被跳过；指向文件的链接总是按普通文件处理。
+	FollowLinks bool
+}
+
+// walkState 是一次 Walk 中跨链接目录共享的状态。
+type walkState struct {
+	ctx        context.Context
+	visit      func(entry Entry) error
+	attributes vcs.Attributes
+	ignore     vcs.Ignore
+	// followed 为已遍历目录树的真实路径（根目录与已进入的链接目标），用于检测循环与重复。
+	followed []string
+}
+
+// NewFileSystemWalker 创建文件系统 Walker，root 应为绝对路径。
+func NewFileSystemWalker(root string) *FileSystemWalker {
+	return &FileSystemWalker{root: root}
+}
+
+// Root 返回扫描根路径。
+func (w *FileSystemWalker) Root() string {
+	return w.root
+}
+
+// Walk 遍历根路径下的所有普通文件；根路径为单文件时只产生一个 Entry，其 Path 为文件名。
+// 根路径本身是符号链接或目录联接时遍历其目标；命名管道、设备等特殊文件以 Entry.Skipped 标记的条目交给 visit，不会被打开。
+func (w *FileSystemWalker) Walk(ctx context.Context, visit func(entry Entry) error) error {
+	state := &walkState{ctx: ctx, visit: visit}
+	walkRoot := w.root
+	if real, err := realPath(w.root); err == nil {
+		walkRoot = real
+		state.followed = append(state.followed, real)
+	}
+	return w.walk(state, walkRoot, w.root, "")
+}
+
+// walk 遍历 walkRoot（真实路径），localRoot 为它在结果中的本地路径，base 为它相对扫描根目录的路径（根目录为空）。
+func (w *FileSystemWalker) walk(state *walkState, walkRoot string, localRoot string, base string) error {
+	return filepath.WalkDir(extendedLengthPath(walkRoot), func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if err := state.ctx.Err(); err != nil {
+			return err
+		}
+
+		relativePath, relErr := filepath.Rel(extendedLengthPath(walkRoot), path)
+		if relErr != nil {
+			relativePath = path
+		}
+		localPath := filepath.Join(localRoot, relativePath)
+		if base != "" {
+			relativePath = filepath.Join(base, relativePath)
+		}
+		if entry.IsDir() {
+			dir := filepath.ToSlash(relativePath)
+			if relativePath != "." && w.SkipDir != nil && w.SkipDir(dir) {
+				return fs.SkipDir
+			}
+			if w.Gitignore {
+				if relativePath != "." && state.ignore.Ignored(dir, true) {
+					return fs.SkipDir
+				}
+				if err := loadDirectoryRules(path, ".gitignore", func(reader io.Reader) error { return state.ignore.Parse(reader, dir) }); err != nil {
+					return err
+				}
+			}
+			if w.Gitattributes {
+				return loadDirectoryRules(path, ".gitattributes", func(reader io.Reader) error { return state.attributes.Parse(reader, dir) })
+			}
+			return nil
+		}
+		if relativePath == "." {
+			relativePath = filepath.Base(localPath)
+		} else if w.Gitignore && state.ignore.Ignored(filepath.ToSlash(relativePath), false) {
+			return nil
+		}
+
+		switch mode := entry.Type(); {
+		case mode&(fs.ModeSymlink|fs.ModeIrregular) != 0:
+			// 符号链接与重解析点（Windows 上目录联接等非符号链接的重解析点报告为 ModeIrregular）按目标类型处理，
+			// 无法解析的链接照常交给 visit，打开失败会记录为扫描错误。
+			target, err := realPath(localPath)
+			if err != nil {
+				break
+			}
+			info, err := os.Stat(target)
+			if err != nil {
+				break
+			}
+			if info.IsDir() {
+				return w.followDir(state, target, localPath, relativePath)
+			}
+			if !info.Mode().IsRegular() {
+				return state.visit(skippedEntry(relativePath, localPath, info.Mode()))
+			}
+		case mode != 0:
+			return state.visit(skippedEntry(relativePath, localPath, mode))
+		}
+
+		visited := Entry{
+			Path:      filepath.ToSlash(relativePath),
+			LocalPath: localPath,
+			Open:      func() (io.ReadCloser, fs.FileInfo, error) { return openLocalFile(localPath) },
+		}
+		if w.Gitattributes {
+			visited.Linguist = linguistFromAttributes(state.attributes.Lookup(visited.Path))
+		}
+		return state.visit(visited)
+	})
+}
+
+// skippedEntry 为不能打开的特殊文件创建跳过条目：命名管道在没有写入方时会让 worker 永久阻塞，设备可能永远读不完。
+func skippedEntry(relativePath string, localPath string, mode fs.FileMode) Entry {
+	reason := model.SkipReasonIrregular
+	switch {
+	case mode&fs.ModeNamedPipe != 0:
+		reason = model.SkipReasonNamedPipe
+	case mode&fs.ModeSocket != 0:
+		reason = model.SkipReasonSocket
+	case mode&fs.ModeDevice != 0:
+		reason = model.SkipReasonDevice
+	}
+	return Entry{Path: filepath.ToSlash(relativePath), LocalPath: localPath, Skipped: reason}
+}
+
+// followDir 处理指向目录的链接：未开启 FollowLinks，或目标位于已遍历的目录树之内（循环或重复）时跳过，否则进入目标。
+func (w *FileSystemWalker) followDir(state *walkState, target string, localPath string, relativePath string) error {
+	if !w.FollowLinks {
+		return nil
+	}
+	for _, followed := range state.followed {
+		if target == followed || strings.HasPrefix(target, strings.TrimSuffix(followed, string(filepath.Separator))+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	state.followed = append(state.followed, target)
+	return w.walk(state, target, localPath, relativePath)
+}
+
+// loadDirectoryRules 用 parse 解析目录下名为 name 的规则文件（.gitattributes、.gitignore），不存在时忽略。
+// filepath.WalkDir 先访问目录本身再访问其内容，因此上级目录的规则总是先于子目录加入。
+func loadDirectoryRules(directory string, name string, parse func(reader io.Reader) error) error {
+	file, err := os.Open(extendedLengthPath(filepath.Join(directory, name)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	if err := parse(file); err != nil {
+		return fmt.Errorf("%s: %w", file.Name(), err)
+	}
+	return nil
+}
+
+// openLocalFile 打开本地文件并对句柄 stat 获取文件信息，避免再次按路径查找。
+func openLocalFile(path string) (io.ReadCloser, fs.FileInfo, error) {
+	file, err := os.Open(extendedLengthPath(path))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// observedWalker 在交给 visit 之前把每个条目（包括随后被 discover 跳过的）交给 observe，
+// 用于记录特殊文件与构建清单等不经过分析的信息；observe 只在遍历 goroutine 中串行调用。
+type observedWalker struct {
+	Walker
+	observe func(entry Entry)
+}
+
+// Walk 实现 Walker。
+func (w observedWalker) Walk(ctx context.Context, visit func(entry Entry) error) error {
+	return w.Walker.Walk(ctx, func(entry Entry) error {
+		w.observe(entry)
+		return visit(entry)
+	})
+}
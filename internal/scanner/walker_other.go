@@ -0,0 +1,15 @@
+//go:build !windows
+
+package scanner
+
+import "path/filepath"
+
+// extendedLengthPath 只在 Windows 上为超过 MAX_PATH 的路径加上 \\?\ 前缀，其他平台原样返回。
+func extendedLengthPath(path string) string {
+	return path
+}
+
+// realPath 返回解析所有符号链接之后的真实路径。
+func realPath(path string) (string, error) {
+	return filepath.EvalSymlinks(path)
+}
@@ -0,0 +1,64 @@
+//go:build windows
+
+package scanner
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// maxPath 是不加 \\?\ 前缀时目录路径的长度上限（MAX_PATH 减去 8.3 文件名所需的 12 个字符）。
+const maxPath = 248
+
+// procGetFinalPathNameByHandle 用于解析目录联接等非符号链接的重解析点，syscall 包没有对应的封装。
+var procGetFinalPathNameByHandle = syscall.NewLazyDLL("kernel32.dll").NewProc("GetFinalPathNameByHandleW")
+
+// extendedLengthPath 为超过 MAX_PATH 的绝对路径加上 \\?\ 前缀（UNC 路径为 \\?\UNC\），
+// 深层的 node_modules、Maven 目录在未开启长路径支持的系统上也能打开；短路径、相对路径与已带前缀的路径原样返回。
+func extendedLengthPath(path string) string {
+	if len(path) < maxPath || strings.HasPrefix(path, `\\?\`) || !filepath.IsAbs(path) {
+		return path
+	}
+	path = filepath.Clean(path)
+	if strings.HasPrefix(path, `\\`) {
+		return `\\?\UNC\` + path[2:]
+	}
+	return `\\?\` + path
+}
+
+// realPath 返回路径的最终真实路径。filepath.EvalSymlinks 只解析符号链接，目录联接与挂载点需要打开句柄后
+// 通过 GetFinalPathNameByHandle 取得，否则无法判断联接是否指回上级目录。
+func realPath(path string) (string, error) {
+	name, err := syscall.UTF16PtrFromString(extendedLengthPath(path))
+	if err != nil {
+		return "", err
+	}
+	// FILE_FLAG_BACKUP_SEMANTICS 允许打开目录句柄，不请求任何访问权限，只用于查询路径。
+	handle, err := syscall.CreateFile(name, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE, nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return "", &fs.PathError{Op: "open", Path: path, Err: err}
+	}
+	defer syscall.CloseHandle(handle)
+
+	buffer := make([]uint16, syscall.MAX_PATH)
+	for {
+		length, _, callErr := procGetFinalPathNameByHandle.Call(uintptr(handle), uintptr(unsafe.Pointer(&buffer[0])), uintptr(len(buffer)), 0)
+		if length == 0 {
+			return "", &fs.PathError{Op: "realpath", Path: path, Err: callErr}
+		}
+		// 缓冲区不足时返回值为所需长度（含结尾的 0）。
+		if int(length) < len(buffer) {
+			break
+		}
+		buffer = make([]uint16, length)
+	}
+
+	final := syscall.UTF16ToString(buffer)
+	if rest, ok := strings.CutPrefix(final, `\\?\UNC\`); ok {
+		return `\\` + rest, nil
+	}
+	return strings.TrimPrefix(final, `\\?\`), nil
+}
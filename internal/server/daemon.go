@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+)
+
+// DaemonScanRequest 是 daemon 的 POST /scan 请求体。
+type DaemonScanRequest struct {
+	// Paths 为待扫描的绝对路径，必须位于 Config.Root 之内；多个路径的合并规则同 gocloc.Scanner.ScanPaths。
+	Paths   []string    `json:"paths"`
+	Options ScanOptions `json:"options"`
+}
+
+// DaemonStats 是 daemon 的 GET /stats 响应体，汇总所有缓存的命中情况。
+type DaemonStats struct {
+	// Caches 为缓存份数，每组影响单文件结果的扫描选项各占一份。
+	Caches  int   `json:"caches"`
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+// Daemon 是常驻进程的扫描服务：在内存中保留单文件结果缓存，重复扫描时只重新分析大小或修改时间变化的文件。
+// 它通过 unix socket 提供服务，只接受本机客户端，因此请求路径为绝对路径（仍须位于 Config.Root 之内）。
+type Daemon struct {
+	config Config
+
+	mu     sync.Mutex
+	caches map[string]*gocloc.MemoryCache
+}
+
+// NewDaemon 创建 daemon，缓存随进程存活，不做持久化。
+func NewDaemon(config Config) *Daemon {
+	return &Daemon{config: config, caches: make(map[string]*gocloc.MemoryCache)}
+}
+
+// Handler 返回 daemon 的 HTTP 接口：
+// - POST /scan：扫描请求体 DaemonScanRequest 中的路径，返回与 scan --format json 相同的结果
+// - GET /stats：返回 DaemonStats
+func (d *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scan", d.handleScan)
+	mux.HandleFunc("GET /stats", d.handleStats)
+	return mux
+}
+
+// handleScan 处理扫描请求，同一组分析选项的请求共享一份缓存。
+func (d *Daemon) handleScan(writer http.ResponseWriter, request *http.Request) {
+	var body DaemonScanRequest
+	if err := decodeJSONBody(writer, request, &body); err != nil {
+		writeHTTPError(writer, http.StatusBadRequest, err)
+		return
+	}
+	if len(body.Paths) == 0 {
+		writeHTTPError(writer, http.StatusBadRequest, errors.New("paths is required"))
+		return
+	}
+	for _, path := range body.Paths {
+		if err := d.checkPath(path); err != nil {
+			writeHTTPError(writer, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	options := d.config.scannerOptions(body.Options)
+	options.Cache = d.cache(body.Options)
+	scanner := gocloc.NewScanner(options)
+	if err := scanner.Err(); err != nil {
+		writeHTTPError(writer, http.StatusBadRequest, err)
+		return
+	}
+	result, err := scanner.ScanPaths(body.Paths...)
+	if err != nil {
+		writeHTTPError(writer, http.StatusBadRequest, err)
+		return
+	}
+	writeScanResult(writer, result)
+}
+
+// handleStats 返回所有缓存的累计命中情况。
+func (d *Daemon) handleStats(writer http.ResponseWriter, _ *http.Request) {
+	d.mu.Lock()
+	stats := DaemonStats{Caches: len(d.caches)}
+	for _, cache := range d.caches {
+		item := cache.Stats()
+		stats.Entries += item.Entries
+		stats.Hits += item.Hits
+		stats.Misses += item.Misses
+	}
+	d.mu.Unlock()
+	writeJSON(writer, http.StatusOK, stats)
+}
+
+// checkPath 要求路径为绝对路径且位于 Config.Root 之内。
+func (d *Daemon) checkPath(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("path %q must be absolute", path)
+	}
+	root, err := filepath.Abs(d.config.Root)
+	if err != nil {
+		return fmt.Errorf("resolve server root: %w", err)
+	}
+	relative, err := filepath.Rel(root, filepath.Clean(path))
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return errOutsideRoot
+	}
+	return nil
+}
+
+// cache 返回该组选项对应的缓存，不存在时创建。
+// 只有影响单文件结果的选项参与区分，worker 数、榜单、排除规则、标签等只影响调度或汇总的选项共享同一份缓存。
+func (d *Daemon) cache(options ScanOptions) *gocloc.MemoryCache {
+	options.Workers = 0
+	options.Top = 0
+	options.DuplicateLines = 0
+	options.SizeDistribution = false
+	options.GitBlame = false
+	options.Excludes = nil
+	options.Labels = nil
+	key, _ := json.Marshal(options)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cache, ok := d.caches[string(key)]
+	if !ok {
+		cache = gocloc.NewMemoryCache()
+		d.caches[string(key)] = cache
+	}
+	return cache
+}
+
+// ScanWithDaemon 是 daemon 的客户端：通过 unix socket 发送扫描请求并返回结果，请求中的路径应为绝对路径。
+func ScanWithDaemon(ctx context.Context, socketPath string, request DaemonScanRequest) (model.ScanResult, error) {
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	defer client.CloseIdleConnections()
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return model.ScanResult{}, err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://gocloc/scan", bytes.NewReader(payload))
+	if err != nil {
+		return model.ScanResult{}, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		return model.ScanResult{}, fmt.Errorf("connect daemon: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		var body httpError
+		if err := json.NewDecoder(response.Body).Decode(&body); err != nil || body.Error == "" {
+			return model.ScanResult{}, fmt.Errorf("daemon returned status %d", response.StatusCode)
+		}
+		return model.ScanResult{}, fmt.Errorf("daemon: %s", body.Error)
+	}
+	var result model.ScanResult
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return model.ScanResult{}, fmt.Errorf("decode daemon response: %w", err)
+	}
+	return result, nil
+}
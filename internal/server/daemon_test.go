@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDaemon 验证 daemon 通过 unix socket 响应扫描请求，重复扫描命中缓存，并拒绝相对路径与越界路径。
+func TestDaemon(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write fixture file failed: %v", err)
+	}
+	// unix socket 路径有长度限制，不使用可能很长的 t.TempDir。
+	socketDir, err := os.MkdirTemp("", "gocloc-daemon-")
+	if err != nil {
+		t.Fatalf("create socket directory failed: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(socketDir) })
+	socket := filepath.Join(socketDir, "daemon.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	daemon := NewDaemon(Config{Root: root, Workers: 1})
+	httpServer := &http.Server{Handler: daemon.Handler()}
+	go func() {
+		_ = httpServer.Serve(listener)
+	}()
+	t.Cleanup(func() { _ = httpServer.Close() })
+
+	request := DaemonScanRequest{Paths: []string{root}}
+	for range 2 {
+		result, err := ScanWithDaemon(context.Background(), socket, request)
+		if err != nil {
+			t.Fatalf("scan with daemon failed: %v", err)
+		}
+		if result.Total.Files != 1 || result.Total.Code != 1 {
+			t.Fatalf("unexpected scan result: %+v", result.Total)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	daemon.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	var stats DaemonStats
+	if err := json.Unmarshal(recorder.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decode stats failed: %v", err)
+	}
+	if stats.Caches != 1 || stats.Entries != 1 || stats.Hits != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	for _, path := range []string{"main.go", filepath.Dir(root)} {
+		_, err := ScanWithDaemon(context.Background(), socket, DaemonScanRequest{Paths: []string{path}})
+		if err == nil || !strings.Contains(err.Error(), "daemon:") {
+			t.Fatalf("expected daemon error for %s, got %v", path, err)
+		}
+	}
+}
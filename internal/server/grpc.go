@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/api/goclocv1"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServiceName 是 gRPC 服务的完整名称，服务定义见 pkg/api/goclocv1/gocloc.proto。
+const GRPCServiceName = goclocv1.ServiceName
+
+// grpcService 实现 gocloc.v1.Gocloc 服务，消息为 goclocv1 中生成的 protobuf 类型。
+type grpcService struct {
+	config Config
+}
+
+// NewGRPCServer 创建注册了 gocloc 服务与 gRPC 反射服务的 gRPC 服务器，
+// 消息使用标准 protobuf 编码，grpcurl 等工具可以通过反射直接调用。
+func NewGRPCServer(config Config, options ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(options...)
+	goclocv1.RegisterGoclocServer(server, &grpcService{config: config})
+	reflection.Register(server)
+	return server
+}
+
+// Scan 扫描根目录下的路径并返回完整结果。
+func (s *grpcService) Scan(ctx context.Context, request *goclocv1.ScanRequest) (*goclocv1.ScanResult, error) {
+	path, err := s.config.resolvePath(request.GetPath())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	options := scanOptionsFromProto(request.GetOptions())
+	result, err := gocloc.NewScanner(s.config.scannerOptions(options)).ScanContext(ctx, path)
+	if err != nil {
+		return nil, scanStatus(err)
+	}
+	message, err := scanResultToProto(result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return message, nil
+}
+
+// ScanStream 扫描根目录下的路径并逐个推送文件结果与错误。
+func (s *grpcService) ScanStream(request *goclocv1.ScanRequest, stream goclocv1.ScanStreamServer) error {
+	path, err := s.config.resolvePath(request.GetPath())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	scanner := gocloc.NewScanner(s.config.scannerOptions(scanOptionsFromProto(request.GetOptions())))
+	files, scanErrors, err := scanner.ScanStream(ctx, path)
+	if err != nil {
+		return scanStatus(err)
+	}
+
+	for files != nil || scanErrors != nil {
+		message := &goclocv1.ScanStreamMessage{}
+		select {
+		case item, ok := <-files:
+			if !ok {
+				files = nil
+				continue
+			}
+			message.Item = &goclocv1.ScanStreamMessage_File{File: fileMetricsToProto(item)}
+		case item, ok := <-scanErrors:
+			if !ok {
+				scanErrors = nil
+				continue
+			}
+			message.Item = &goclocv1.ScanStreamMessage_Error{Error: scanErrorToProto(item)}
+		}
+		if err := stream.Send(message); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// AnalyzeContent 统计单个内容缓冲区。
+func (s *grpcService) AnalyzeContent(_ context.Context, request *goclocv1.AnalyzeContentRequest) (*goclocv1.LineMetrics, error) {
+	scanner := gocloc.NewScanner(s.config.scannerOptions(scanOptionsFromProto(request.GetOptions())))
+	metrics, err := scanner.AnalyzeReader(request.GetLanguage(), strings.NewReader(request.GetContent()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	metrics.Bytes = int64(len(request.GetContent()))
+	return lineMetricsToProto(metrics), nil
+}
+
+// scanStatus 把扫描错误转换为 gRPC 状态码。
+func scanStatus(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+}
+
+// scanOptionsFromProto 把 protobuf 请求选项转换为与 HTTP 接口共用的 ScanOptions。
+func scanOptionsFromProto(options *goclocv1.ScanOptions) ScanOptions {
+	if options == nil {
+		return ScanOptions{}
+	}
+	return ScanOptions{
+		Workers:           int(options.GetWorkers()),
+		CountFunctions:    options.GetCountFunctions(),
+		Annotate:          options.GetAnnotate(),
+		StringLines:       options.GetStringLines(),
+		Whitespace:        options.GetWhitespace(),
+		Duplicates:        options.GetDuplicates(),
+		DuplicateLines:    int(options.GetDuplicateLines()),
+		Scripts:           options.GetScripts(),
+		SizeDistribution:  options.GetDistribution(),
+		GitBlame:          options.GetGitBlame(),
+		Top:               int(options.GetTop()),
+		SummaryOnly:       options.GetSummaryOnly(),
+		Unsorted:          options.GetUnsorted(),
+		PythonDocstrings:  options.GetPythonDocstrings(),
+		SQLDialect:        options.GetSqlDialect(),
+		GoDirectives:      options.GetGoDirectives(),
+		Shebang:           options.GetShebang(),
+		LogicalDirectives: options.GetLogicalDirectives(),
+		CgoPreamble:       options.GetCgoPreamble(),
+		TrailingEmptyLine: options.GetTrailingEmptyLine(),
+		Gitattributes:     options.GetGitattributes(),
+		Packages:          options.GetPackages(),
+		DisabledLanguages: options.GetDisabledLanguages(),
+		ExtensionMap:      options.GetExtensionMap_(),
+		Languages:         options.GetLanguages(),
+		Excludes:          options.GetExcludes(),
+		Labels:            options.GetLabels(),
+	}
+}
+
+// scanResultToProto 转换扫描结果的核心字段，完整结果以 JSON 附在 result_json 中。
+func scanResultToProto(result model.ScanResult) (*goclocv1.ScanResult, error) {
+	result.SchemaVersion = model.SchemaVersion
+	content, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("marshal json: %w", err)
+	}
+
+	message := &goclocv1.ScanResult{
+		SchemaVersion: int32(result.SchemaVersion),
+		ScannedPath:   result.ScannedPath,
+		SummaryOnly:   result.SummaryOnly,
+		Total:         &goclocv1.TotalMetrics{Files: result.Total.Files, Metrics: lineMetricsToProto(result.Total.LineMetrics)},
+		Labels:        result.Labels,
+		ResultJson:    content,
+	}
+	for _, file := range result.Files {
+		message.Files = append(message.Files, fileMetricsToProto(file))
+	}
+	for _, language := range result.Languages {
+		message.Languages = append(message.Languages, &goclocv1.LanguageMetrics{
+			Language:   language.Language,
+			Extensions: language.Extensions,
+			Files:      language.Files,
+			Metrics:    lineMetricsToProto(language.Metrics),
+		})
+	}
+	for _, scanError := range result.Errors {
+		message.Errors = append(message.Errors, scanErrorToProto(scanError))
+	}
+	return message, nil
+}
+
+// fileMetricsToProto 转换单个文件的统计。
+func fileMetricsToProto(file model.FileMetrics) *goclocv1.FileMetrics {
+	return &goclocv1.FileMetrics{
+		Path:      file.Path,
+		Language:  file.Language,
+		Variant:   file.Variant,
+		Test:      file.Test,
+		Generated: file.Generated,
+		Metrics:   lineMetricsToProto(file.Metrics),
+		Owners:    file.Owners,
+	}
+}
+
+// scanErrorToProto 转换单个文件的扫描错误。
+func scanErrorToProto(scanError model.ScanError) *goclocv1.ScanError {
+	return &goclocv1.ScanError{
+		Path:     scanError.Path,
+		Message:  scanError.Message,
+		Category: string(scanError.Category),
+		Errno:    int32(scanError.Errno),
+	}
+}
+
+// lineMetricsToProto 转换一组行数统计。
+func lineMetricsToProto(metrics model.LineMetrics) *goclocv1.LineMetrics {
+	return &goclocv1.LineMetrics{
+		Total:         metrics.Total,
+		Code:          metrics.Code,
+		Comment:       metrics.Comment,
+		Blank:         metrics.Blank,
+		Uloc:          metrics.ULOC,
+		Preprocessor:  metrics.Preprocessor,
+		StringLiteral: metrics.StringLiteral,
+		Functions:     metrics.Functions,
+		Bytes:         metrics.Bytes,
+		Characters:    metrics.Characters,
+		MaxLineLength: metrics.MaxLineLength,
+		AvgLineLength: metrics.AvgLineLength,
+		LineClasses:   metrics.LineClasses,
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/api/goclocv1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+)
+
+// startGRPCServer 是测试辅助函数，在随机端口启动服务并返回已连接的客户端。
+func startGRPCServer(t *testing.T, root string) *grpc.ClientConn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	server := NewGRPCServer(Config{Root: root, Workers: 2})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		listener.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// TestGRPCService 验证 Scan、ScanStream、AnalyzeContent 三个方法与根目录越界保护。
+func TestGRPCService(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "repo"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	for name, content := range map[string]string{"main.go": "package main\n", "util.py": "x = 1\n"} {
+		if err := os.WriteFile(filepath.Join(root, "repo", name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write fixture file failed: %v", err)
+		}
+	}
+	client := goclocv1.NewGoclocClient(startGRPCServer(t, root))
+	ctx := context.Background()
+
+	result, err := client.Scan(ctx, &goclocv1.ScanRequest{Path: "repo", Options: &goclocv1.ScanOptions{Labels: map[string]string{"team": "api"}}})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if result.GetTotal().GetFiles() != 2 || result.GetTotal().GetMetrics().GetCode() != 2 || result.GetLabels()["team"] != "api" {
+		t.Fatalf("unexpected scan result: %v", result)
+	}
+	var full model.ScanResult
+	if err := json.Unmarshal(result.GetResultJson(), &full); err != nil {
+		t.Fatalf("decode result_json failed: %v", err)
+	}
+	if full.SchemaVersion != model.SchemaVersion || full.Total.Code != 2 || len(full.Files) != 2 {
+		t.Fatalf("unexpected result_json: %+v", full)
+	}
+
+	stream, err := client.ScanStream(ctx, &goclocv1.ScanRequest{Path: "repo"})
+	if err != nil {
+		t.Fatalf("open stream failed: %v", err)
+	}
+	streamed := 0
+	for {
+		message, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("receive failed: %v", err)
+		}
+		if message.GetFile() != nil {
+			streamed++
+		}
+	}
+	if streamed != 2 {
+		t.Fatalf("expected 2 streamed files, got %d", streamed)
+	}
+
+	metrics, err := client.AnalyzeContent(ctx, &goclocv1.AnalyzeContentRequest{Language: "go", Content: "// c\npackage main\n"})
+	if err != nil {
+		t.Fatalf("analyze content failed: %v", err)
+	}
+	if metrics.GetCode() != 1 || metrics.GetComment() != 1 {
+		t.Fatalf("unexpected content metrics: %v", metrics)
+	}
+
+	_, err = client.Scan(ctx, &goclocv1.ScanRequest{Path: "../"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected invalid argument for escaping path, got %v", err)
+	}
+}
+
+// TestGRPCReflection 验证服务端开启了反射，grpcurl 等工具无需 .proto 即可发现服务。
+func TestGRPCReflection(t *testing.T) {
+	conn := startGRPCServer(t, t.TempDir())
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("open reflection stream failed: %v", err)
+	}
+	request := &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}
+	if err := stream.Send(request); err != nil {
+		t.Fatalf("send reflection request failed: %v", err)
+	}
+	response, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("receive reflection response failed: %v", err)
+	}
+	found := false
+	for _, service := range response.GetListServicesResponse().GetService() {
+		found = found || service.GetName() == GRPCServiceName
+	}
+	if !found {
+		t.Fatalf("%s is not listed by reflection: %v", GRPCServiceName, response)
+	}
+
+	request = &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: GRPCServiceName},
+	}
+	if err := stream.Send(request); err != nil {
+		t.Fatalf("send reflection request failed: %v", err)
+	}
+	if response, err = stream.Recv(); err != nil || len(response.GetFileDescriptorResponse().GetFileDescriptorProto()) == 0 {
+		t.Fatalf("expected a file descriptor for %s, got %v (%v)", GRPCServiceName, response, err)
+	}
+	_ = stream.CloseSend()
+}
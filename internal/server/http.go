@@ -0,0 +1,168 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+	"github.com/zhizhixiongxuwei/gocloc/internal/vcs"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+)
+
+// maxHTTPRequestBytes 是 HTTP 请求体的大小上限。
+const maxHTTPRequestBytes = 8 << 20
+
+// HTTPScanRequest 是 POST /scan 的请求体，Path 与 GitURL 二选一。
+type HTTPScanRequest struct {
+	// Path 为相对 Config.Root 的路径。
+	Path string `json:"path,omitempty"`
+	// GitURL 为远程仓库地址，服务端会浅克隆到临时目录后扫描，结果的 scanned_path 为该地址。
+	GitURL  string      `json:"git_url,omitempty"`
+	Options ScanOptions `json:"options"`
+}
+
+// httpError 是错误响应体。
+type httpError struct {
+	Error string `json:"error"`
+}
+
+// NewHTTPHandler 创建 REST 接口：
+// - POST /scan：扫描根目录下的路径或远程仓库，返回与 scan --format json 相同的结果
+// - POST /analyze：统计单个内容缓冲区，请求体同 AnalyzeContentRequest
+// - GET /languages：返回内置语言及后缀
+// - GET /debug/vars：expvar 指标，仅在设置了 Config.Metrics 时提供
+func NewHTTPHandler(config Config) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scan", config.handleScan)
+	mux.HandleFunc("POST /analyze", config.handleAnalyze)
+	mux.HandleFunc("GET /languages", handleLanguages)
+	if config.Metrics != nil {
+		mux.Handle("GET /debug/vars", expvar.Handler())
+	}
+	return mux
+}
+
+// handleScan 处理扫描请求。
+func (c Config) handleScan(writer http.ResponseWriter, request *http.Request) {
+	var body HTTPScanRequest
+	if err := decodeJSONBody(writer, request, &body); err != nil {
+		writeHTTPError(writer, http.StatusBadRequest, err)
+		return
+	}
+
+	hasPath := strings.TrimSpace(body.Path) != ""
+	hasGitURL := strings.TrimSpace(body.GitURL) != ""
+	if hasPath == hasGitURL {
+		writeHTTPError(writer, http.StatusBadRequest, errors.New("exactly one of path and git_url is required"))
+		return
+	}
+
+	scanner := gocloc.NewScanner(c.scannerOptions(body.Options))
+	if err := scanner.Err(); err != nil {
+		writeHTTPError(writer, http.StatusBadRequest, err)
+		return
+	}
+	if hasPath {
+		path, err := c.resolvePath(body.Path)
+		if err != nil {
+			writeHTTPError(writer, http.StatusBadRequest, err)
+			return
+		}
+		result, err := scanner.ScanContext(request.Context(), path)
+		if err != nil {
+			writeHTTPError(writer, http.StatusBadRequest, err)
+			return
+		}
+		writeScanResult(writer, result)
+		return
+	}
+
+	if err := vcs.ValidateRemote(body.GitURL); err != nil {
+		writeHTTPError(writer, http.StatusBadRequest, err)
+		return
+	}
+	directory, err := os.MkdirTemp("", "gocloc-clone-")
+	if err != nil {
+		writeHTTPError(writer, http.StatusInternalServerError, err)
+		return
+	}
+	defer os.RemoveAll(directory)
+
+	if err := vcs.Clone(request.Context(), body.GitURL, directory); err != nil {
+		writeHTTPError(writer, http.StatusBadGateway, err)
+		return
+	}
+	result, err := scanner.ScanContext(request.Context(), directory)
+	if err != nil {
+		writeHTTPError(writer, http.StatusInternalServerError, err)
+		return
+	}
+	result.ScannedPath = body.GitURL
+	writeScanResult(writer, result)
+}
+
+// handleAnalyze 处理单个内容缓冲区的分析请求。
+func (c Config) handleAnalyze(writer http.ResponseWriter, request *http.Request) {
+	var body AnalyzeContentRequest
+	if err := decodeJSONBody(writer, request, &body); err != nil {
+		writeHTTPError(writer, http.StatusBadRequest, err)
+		return
+	}
+
+	scanner := gocloc.NewScanner(c.scannerOptions(body.Options))
+	metrics, err := scanner.AnalyzeReader(body.Language, strings.NewReader(body.Content))
+	if err != nil {
+		writeHTTPError(writer, http.StatusBadRequest, err)
+		return
+	}
+	metrics.Bytes = int64(len(body.Content))
+	writeJSON(writer, http.StatusOK, metrics)
+}
+
+// handleLanguages 返回内置语言及后缀。
+func handleLanguages(writer http.ResponseWriter, _ *http.Request) {
+	type language struct {
+		Name         string               `json:"name"`
+		Extensions   []string             `json:"extensions"`
+		Capabilities *gocloc.Capabilities `json:"capabilities,omitempty"`
+	}
+
+	items := make([]language, 0)
+	for _, item := range gocloc.Languages() {
+		items = append(items, language{Name: item.Name, Extensions: item.Extensions, Capabilities: item.Capabilities})
+	}
+	writeJSON(writer, http.StatusOK, items)
+}
+
+// decodeJSONBody 解析有大小上限的 JSON 请求体。
+func decodeJSONBody(writer http.ResponseWriter, request *http.Request, target any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxHTTPRequestBytes))
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("decode request body: %w", err)
+	}
+	return nil
+}
+
+// writeScanResult 以 report.PrintJSON 的格式返回扫描结果，与 CLI 的 JSON 输出保持一致。
+func writeScanResult(writer http.ResponseWriter, result gocloc.ScanResult) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	_ = report.PrintJSON(writer, result)
+}
+
+// writeJSON 输出 JSON 响应。
+func writeJSON(writer http.ResponseWriter, statusCode int, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	_ = json.NewEncoder(writer).Encode(value)
+}
+
+// writeHTTPError 输出 {"error": "..."} 错误响应。
+func writeHTTPError(writer http.ResponseWriter, statusCode int, err error) {
+	writeJSON(writer, statusCode, httpError{Error: err.Error()})
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+)
+
+// TestHTTPHandler 验证 REST 接口的扫描、语言列表与参数校验。
+func TestHTTPHandler(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write fixture file failed: %v", err)
+	}
+	handler := NewHTTPHandler(Config{Root: root, Workers: 1})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/scan", strings.NewReader(`{"path": "."}`)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected scan status %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var result model.ScanResult
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode scan result failed: %v", err)
+	}
+	if result.Total.Files != 1 || result.SchemaVersion != model.SchemaVersion {
+		t.Fatalf("unexpected scan result: %+v", result)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/languages", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"name":"Go"`) {
+		t.Fatalf("unexpected languages response %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	for _, body := range []string{`{}`, `{"path": "../etc"}`, `{"git_url": "file:///etc"}`, `{"path": ".", "git_url": "https://x/y.git"}`} {
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/scan", strings.NewReader(body)))
+		if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), `"error"`) {
+			t.Fatalf("expected bad request for %s, got %d: %s", body, recorder.Code, recorder.Body.String())
+		}
+	}
+}
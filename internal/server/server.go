@@ -0,0 +1,161 @@
+// Package server 提供 gocloc 的服务化能力（HTTP REST 与 gRPC），供构建集群与内部平台以服务方式调用，
+// 避免每个请求都启动一个进程。
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+)
+
+// Config 描述服务端的公共配置。
+type Config struct {
+	// Root 为允许扫描的根目录，请求中的路径按它解析且不能越出该目录。
+	Root string
+	// Workers 为每次扫描的默认并发 worker 数量，同时是请求可以指定的上限，<=0 时使用 CPU 核心数。
+	Workers int
+	// Logger 接收每次扫描的诊断日志，为 nil 时丢弃。
+	Logger *slog.Logger
+	// Metrics 接收所有请求累计的扫描计数指标，为 nil 时不记录。
+	// 设置后 HTTP 服务额外提供 GET /debug/vars（expvar 格式），便于配合 NewExpvarMetrics 使用。
+	Metrics gocloc.Metrics
+}
+
+// ScanOptions 是请求中可携带的扫描选项。
+// 插件、自定义语言等会在服务端执行外部程序或读取服务端文件的能力不对远程请求开放。
+type ScanOptions struct {
+	// Workers 为并发 worker 数量，超过服务端 Config.Workers 时按上限执行，避免远程请求创建任意数量的 goroutine。
+	Workers          int  `json:"workers,omitempty"`
+	CountFunctions   bool `json:"count_functions,omitempty"`
+	Annotate         bool `json:"annotate,omitempty"`
+	StringLines      bool `json:"string_lines,omitempty"`
+	Whitespace       bool `json:"whitespace,omitempty"`
+	Duplicates       bool `json:"duplicates,omitempty"`
+	DuplicateLines   int  `json:"duplicate_lines,omitempty"`
+	Scripts          bool `json:"scripts,omitempty"`
+	SizeDistribution bool `json:"distribution,omitempty"`
+	GitBlame         bool `json:"git_blame,omitempty"`
+	Top              int  `json:"top,omitempty"`
+	SummaryOnly      bool `json:"summary_only,omitempty"`
+	Unsorted         bool `json:"unsorted,omitempty"`
+	PythonDocstrings bool `json:"python_docstrings,omitempty"`
+	// SQLDialect 为 SQL 方言：auto（默认）、ansi、mysql、postgres、tsql 或 oracle。
+	SQLDialect string `json:"sql_dialect,omitempty"`
+	// GoDirectives 为 Go 编译指令行的计入方式：comment（默认）、directive 或 code。
+	GoDirectives string `json:"go_directives,omitempty"`
+	// Shebang 为脚本首行 shebang 的计入方式：comment（默认）、directive 或 code。
+	Shebang string `json:"shebang,omitempty"`
+	// LogicalDirectives 把 C/C++ 多行预处理指令计为一条 preprocessor。
+	LogicalDirectives bool `json:"logical_directives,omitempty"`
+	// CgoPreamble 把 Go 文件的 cgo 前导代码计入 C/C++。
+	CgoPreamble bool `json:"cgo_preamble,omitempty"`
+	// TrailingEmptyLine 把文件末尾换行符之后的空内容计为一个空白行。
+	TrailingEmptyLine bool `json:"trailing_empty_line,omitempty"`
+	// Gitattributes 按扫描目录中 .gitattributes 的 linguist-* 属性排除、归类或改变文件语言。
+	Gitattributes bool `json:"gitattributes,omitempty"`
+	// Gitignore 跳过扫描目录中 .gitignore 忽略的文件与目录。
+	Gitignore bool `json:"gitignore,omitempty"`
+	// Packages 按 go.mod、package.json 等构建清单所在目录输出包汇总。
+	Packages bool `json:"packages,omitempty"`
+	// DisabledLanguages 为本次请求禁用的语言，只影响该请求。
+	DisabledLanguages []string `json:"disabled_languages,omitempty"`
+	// ExtensionMap 把后缀映射到指定语言（如 {".inc": "C/C++"}），只影响该请求。
+	ExtensionMap map[string]string `json:"extension_map,omitempty"`
+	// Languages 非空时只统计列出的语言，只影响该请求。
+	Languages []string `json:"languages,omitempty"`
+	// Excludes 为排除的路径通配（相对扫描路径，支持 **），只影响该请求。
+	Excludes []string `json:"excludes,omitempty"`
+	// Labels 为附加到结果的标签（如 {"team": "payments"}），原样写入结果的 labels。
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ScanRequest 是扫描请求。
+type ScanRequest struct {
+	// Path 为相对 Config.Root 的路径，为空时扫描整个根目录。
+	Path    string      `json:"path"`
+	Options ScanOptions `json:"options"`
+}
+
+// AnalyzeContentRequest 是单个内容缓冲区的分析请求。
+type AnalyzeContentRequest struct {
+	// Language 为语言名称，不区分大小写。
+	Language string      `json:"language"`
+	Content  string      `json:"content"`
+	Options  ScanOptions `json:"options"`
+}
+
+// scannerOptions 把请求选项转换为库选项，请求未指定 worker 数时使用服务端默认值，超过上限时按上限执行。
+func (c Config) scannerOptions(options ScanOptions) gocloc.Options {
+	workers := c.Workers
+	if options.Workers > 0 {
+		workers = min(options.Workers, c.maxWorkers())
+	}
+	return gocloc.Options{
+		Workers:            workers,
+		CountFunctions:     options.CountFunctions,
+		Annotate:           options.Annotate,
+		StringLiteralLines: options.StringLines,
+		WhitespaceStats:    options.Whitespace,
+		DetectDuplicates:   options.Duplicates,
+		DuplicateWindow:    options.DuplicateLines,
+		ScriptStats:        options.Scripts,
+		SizeDistribution:   options.SizeDistribution,
+		GitBlame:           options.GitBlame,
+		Top:                options.Top,
+		SummaryOnly:        options.SummaryOnly,
+		UnsortedFiles:      options.Unsorted,
+		PythonDocstrings:   options.PythonDocstrings,
+		SQLDialect:         options.SQLDialect,
+		GoDirectives:       options.GoDirectives,
+		Shebang:            options.Shebang,
+		LogicalDirectives:  options.LogicalDirectives,
+		CgoPreamble:        options.CgoPreamble,
+		TrailingEmptyLine:  options.TrailingEmptyLine,
+		Gitattributes:      options.Gitattributes,
+		Gitignore:          options.Gitignore,
+		Packages:           options.Packages,
+		Logger:             c.Logger,
+		Metrics:            c.Metrics,
+		DisabledLanguages:  options.DisabledLanguages,
+		ExtensionOverrides: options.ExtensionMap,
+		Languages:          options.Languages,
+		Excludes:           options.Excludes,
+		Labels:             options.Labels,
+	}
+}
+
+// maxWorkers 返回单次请求允许的 worker 数量上限：服务端配置的 Workers，未配置时为 CPU 核心数。
+func (c Config) maxWorkers() int {
+	if c.Workers > 0 {
+		return c.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// errOutsideRoot 表示请求路径越出了允许扫描的根目录。
+var errOutsideRoot = errors.New("path is outside the server root")
+
+// resolvePath 把请求路径解析为根目录下的绝对路径，拒绝绝对路径与 .. 越界。
+func (c Config) resolvePath(path string) (string, error) {
+	root, err := filepath.Abs(c.Root)
+	if err != nil {
+		return "", fmt.Errorf("resolve server root: %w", err)
+	}
+
+	trimmed := strings.TrimSpace(path)
+	if filepath.IsAbs(trimmed) {
+		return "", errOutsideRoot
+	}
+
+	resolved := filepath.Join(root, trimmed)
+	relative, err := filepath.Rel(root, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", errOutsideRoot
+	}
+	return resolved, nil
+}
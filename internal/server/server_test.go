@@ -0,0 +1,30 @@
+package server
+
+import (
+	"runtime"
+	"testing"
+)
+
+// TestScannerOptionsWorkers 验证请求的 worker 数量不超过服务端上限，未指定时使用服务端默认值。
+func TestScannerOptionsWorkers(t *testing.T) {
+	cases := []struct {
+		name      string
+		config    Config
+		requested int
+		expected  int
+	}{
+		{name: "default", config: Config{Workers: 4}, requested: 0, expected: 4},
+		{name: "lower", config: Config{Workers: 4}, requested: 2, expected: 2},
+		{name: "clamped", config: Config{Workers: 4}, requested: 1_000_000, expected: 4},
+		{name: "clamped to cpus", config: Config{}, requested: 1_000_000, expected: runtime.NumCPU()},
+		{name: "unset", config: Config{}, requested: 0, expected: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := tc.config.scannerOptions(ScanOptions{Workers: tc.requested})
+			if options.Workers != tc.expected {
+				t.Fatalf("expected %d workers, got %d", tc.expected, options.Workers)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package vcs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/glob"
+)
+
+// Attributes 是按目录累积的 .gitattributes 规则，不调用 git 命令，直接解析文件内容。
+//
+// 匹配规则与 git 一致：
+// - 不含 / 的模式匹配任意深度的文件名，含 / 的模式相对 .gitattributes 所在目录匹配（开头的 / 只表示锚定）
+// - 同一属性以最后一条匹配的规则为准，子目录中的规则晚于上级目录加入，因此优先于上级目录
+// - 匹配目录的模式不会作用于目录下的文件，需要写成 dir/** 的形式
+// - 宏属性（[attr]）与引号包裹的模式不支持，会被忽略
+type Attributes struct {
+	rules []attributeRule
+}
+
+// attributeRule 是一行 .gitattributes 规则。
+type attributeRule struct {
+	// dir 为规则所在目录（相对扫描根目录，根目录为空）。
+	dir     string
+	pattern string
+	// basename 表示模式只与文件名匹配。
+	basename bool
+	// values 为属性值：设置为 "true"，-attr 为 "false"，attr=value 为 value，!attr 为空串（恢复未指定）。
+	values map[string]string
+}
+
+// Parse 解析 dir（相对扫描根目录、以 / 分隔，根目录为空或 "."）下 .gitattributes 的内容并追加规则。
+// 调用方应按先上级目录、后子目录的顺序解析。
+func (a *Attributes) Parse(reader io.Reader, dir string) error {
+	if dir == "." {
+		dir = ""
+	}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") || strings.HasPrefix(fields[0], "\"") {
+			continue
+		}
+
+		pattern := fields[0]
+		rule := attributeRule{dir: dir, values: make(map[string]string, len(fields)-1)}
+		if trimmed, anchored := strings.CutPrefix(pattern, "/"); anchored || strings.Contains(pattern, "/") {
+			pattern = trimmed
+		} else {
+			rule.basename = true
+		}
+		if glob.Validate(pattern) != nil {
+			continue
+		}
+		rule.pattern = pattern
+
+		for _, field := range fields[1:] {
+			switch {
+			case strings.HasPrefix(field, "-"):
+				rule.values[field[1:]] = "false"
+			case strings.HasPrefix(field, "!"):
+				rule.values[field[1:]] = ""
+			default:
+				name, value, ok := strings.Cut(field, "=")
+				if !ok {
+					value = "true"
+				}
+				rule.values[name] = value
+			}
+		}
+		a.rules = append(a.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read gitattributes: %w", err)
+	}
+	return nil
+}
+
+// Lookup 返回相对扫描根目录、以 / 分隔的文件路径上指定的属性值，未指定的属性不出现在结果中。
+func (a *Attributes) Lookup(filePath string) map[string]string {
+	values := make(map[string]string)
+	for _, rule := range a.rules {
+		if !rule.matches(filePath) {
+			continue
+		}
+		for name, value := range rule.values {
+			if value == "" {
+				delete(values, name)
+				continue
+			}
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// matches 判断规则是否作用于文件路径。
+func (r attributeRule) matches(filePath string) bool {
+	relative := filePath
+	if r.dir != "" {
+		trimmed, ok := strings.CutPrefix(filePath, r.dir+"/")
+		if !ok {
+			return false
+		}
+		relative = trimmed
+	}
+	if r.basename {
+		relative = path.Base(relative)
+	}
+	ok, _ := glob.Match(r.pattern, relative)
+	return ok
+}
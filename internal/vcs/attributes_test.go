@@ -0,0 +1,43 @@
+package vcs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestAttributesLookup 验证文件名与锚定模式的匹配、子目录规则的作用范围，以及后出现的规则覆盖、取消或恢复属性。
+func TestAttributesLookup(t *testing.T) {
+	var attributes Attributes
+	root := "# comment\n" +
+		"[attr]binary -diff -merge\n" +
+		"*.pb.go linguist-generated\n" +
+		"/vendor/** linguist-vendored\n" +
+		"vendor/keep/** -linguist-vendored\n" +
+		"*.inc linguist-language=C++ text\n" +
+		"docs/*.md linguist-documentation\n"
+	if err := attributes.Parse(strings.NewReader(root), ""); err != nil {
+		t.Fatalf("parse root attributes failed: %v", err)
+	}
+	if err := attributes.Parse(strings.NewReader("*.inc !linguist-language\nlocal/* linguist-generated\n"), "lib"); err != nil {
+		t.Fatalf("parse nested attributes failed: %v", err)
+	}
+
+	cases := map[string]map[string]string{
+		"api/types.pb.go":      {"linguist-generated": "true"},
+		"vendor/x/lib.go":      {"linguist-vendored": "true"},
+		"vendor/keep/lib.go":   {"linguist-vendored": "false"},
+		"src/vendor/lib.go":    {},
+		"macros.inc":           {"linguist-language": "C++", "text": "true"},
+		"lib/macros.inc":       {"text": "true"},
+		"lib/local/gen.go":     {"linguist-generated": "true"},
+		"other/lib/local/x.go": {},
+		"docs/guide.md":        {"linguist-documentation": "true"},
+		"docs/api/guide.md":    {},
+	}
+	for path, expected := range cases {
+		if values := attributes.Lookup(path); !reflect.DeepEqual(values, expected) {
+			t.Fatalf("unexpected attributes for %s: %v, expected %v", path, values, expected)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package vcs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/glob"
+)
+
+// CodeOwnersLocations 是 GitHub 查找 CODEOWNERS 的位置（相对仓库根目录），按优先级排列。
+var CodeOwnersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners 是解析后的 CODEOWNERS 规则，不调用 git 命令，直接解析文件内容。
+//
+// 匹配规则与 GitHub 一致：
+// - 以最后一条匹配的规则为准；只有模式没有所有者的规则表示该路径没有所有者
+// - 以 / 开头或中间含 / 的模式相对仓库根目录匹配，其余模式匹配任意深度的文件名或目录名
+// - 以 / 结尾的模式只匹配目录；匹配目录的模式作用于目录下的全部文件
+// - 以 /* 结尾的模式只匹配该目录下直接包含的文件，不作用于更深的子目录（GitHub 与 gitignore 不同之处）
+// - ! 取反与 [ ] 字符类在 GitHub 中不受支持，这里同样视为无效规则而忽略
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+// codeOwnersRule 是一行 CODEOWNERS 规则。
+type codeOwnersRule struct {
+	pattern string
+	// anchored 表示模式相对仓库根目录匹配，否则与任意一级路径名匹配。
+	anchored bool
+	// directory 表示模式只匹配目录（以 / 结尾）。
+	directory bool
+	// shallow 表示模式只匹配文件本身，不匹配上级目录（以 /* 结尾）。
+	shallow bool
+	owners  []string
+}
+
+// ParseCodeOwners 解析 CODEOWNERS 内容，格式错误的行被忽略。
+func ParseCodeOwners(reader io.Reader) (*CodeOwners, error) {
+	owners := &CodeOwners{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, " #"); index >= 0 {
+			line = line[:index]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "!") || strings.ContainsAny(fields[0], "[]") {
+			continue
+		}
+
+		pattern := fields[0]
+		rule := codeOwnersRule{owners: fields[1:]}
+		if trimmed, ok := strings.CutSuffix(pattern, "/"); ok {
+			pattern = trimmed
+			rule.directory = true
+		}
+		if trimmed, ok := strings.CutPrefix(pattern, "/"); ok {
+			pattern = trimmed
+			rule.anchored = true
+		} else if strings.Contains(pattern, "/") {
+			rule.anchored = true
+		}
+		rule.shallow = strings.HasSuffix(pattern, "/*")
+		if pattern == "" || glob.Validate(pattern) != nil {
+			continue
+		}
+		rule.pattern = pattern
+		owners.rules = append(owners.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read codeowners: %w", err)
+	}
+	return owners, nil
+}
+
+// LoadCodeOwners 读取并解析 CODEOWNERS 文件。
+func LoadCodeOwners(filePath string) (*CodeOwners, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open codeowners: %w", err)
+	}
+	defer file.Close()
+	return ParseCodeOwners(file)
+}
+
+// FindCodeOwners 按 CodeOwnersLocations 的顺序在仓库根目录下查找 CODEOWNERS，返回找到的路径。
+func FindCodeOwners(root string) (string, bool) {
+	for _, location := range CodeOwnersLocations {
+		candidate := filepath.Join(root, filepath.FromSlash(location))
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// Owners 返回相对仓库根目录、以 / 分隔的文件路径的所有者，没有所有者时返回 nil。
+func (c *CodeOwners) Owners(filePath string) []string {
+	for index := len(c.rules) - 1; index >= 0; index-- {
+		if c.rules[index].matches(filePath) {
+			if len(c.rules[index].owners) == 0 {
+				return nil
+			}
+			return c.rules[index].owners
+		}
+	}
+	return nil
+}
+
+// matches 判断规则是否作用于文件路径：依次检查文件本身与各级上级目录。
+func (r codeOwnersRule) matches(filePath string) bool {
+	for candidate := filePath; candidate != "." && candidate != "/" && candidate != ""; candidate = path.Dir(candidate) {
+		if r.directory && candidate == filePath {
+			continue
+		}
+		if r.shallow && candidate != filePath {
+			return false
+		}
+		name := candidate
+		if !r.anchored {
+			name = path.Base(candidate)
+		}
+		if ok, _ := glob.Match(r.pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
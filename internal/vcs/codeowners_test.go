@@ -0,0 +1,63 @@
+package vcs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCodeOwners 验证最后匹配的规则生效、锚定与任意深度的模式、目录模式、/* 只匹配直接包含的文件，以及无所有者的规则。
+func TestCodeOwners(t *testing.T) {
+	content := "# default owners\n" +
+		"*       @org/platform\n" +
+		"*.js    @org/frontend # inline comment\n" +
+		"/docs/* docs@example.com\n" +
+		"apps/   @org/apps\n" +
+		"/services/billing/ @org/billing @alice\n" +
+		"/services/billing/generated\n" +
+		"!ignored @nobody\n"
+	owners, err := ParseCodeOwners(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parse codeowners failed: %v", err)
+	}
+
+	cases := map[string][]string{
+		"main.go":                             {"@org/platform"},
+		"web/src/app.js":                      {"@org/frontend"},
+		"docs/index.md":                       {"docs@example.com"},
+		"docs/guide/setup.md":                 {"@org/platform"},
+		"tools/apps/cli.go":                   {"@org/apps"},
+		"apps":                                {"@org/platform"},
+		"services/billing/invoice.go":         {"@org/billing", "@alice"},
+		"services/billing/generated/types.go": nil,
+	}
+	for filePath, expected := range cases {
+		if actual := owners.Owners(filePath); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("owners of %s: expected %v, got %v", filePath, expected, actual)
+		}
+	}
+
+	root := t.TempDir()
+	if _, ok := FindCodeOwners(root); ok {
+		t.Fatal("expected no codeowners in empty directory")
+	}
+	for _, location := range []string{"docs/CODEOWNERS", ".github/CODEOWNERS"} {
+		path := filepath.Join(root, filepath.FromSlash(location))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("* @org/"+filepath.Base(filepath.Dir(path))+"\n"), 0o644); err != nil {
+			t.Fatalf("write codeowners failed: %v", err)
+		}
+	}
+	found, ok := FindCodeOwners(root)
+	if !ok || found != filepath.Join(root, ".github", "CODEOWNERS") {
+		t.Fatalf("expected .github/CODEOWNERS to take precedence, got %q", found)
+	}
+	loaded, err := LoadCodeOwners(found)
+	if err != nil || !reflect.DeepEqual(loaded.Owners("a.go"), []string{"@org/.github"}) {
+		t.Fatalf("unexpected loaded owners: %v (%v)", loaded, err)
+	}
+}
@@ -0,0 +1,252 @@
+// Package vcs 封装 gocloc 对版本控制系统（当前为 git 命令行）的调用。
+// 扫描与统计逻辑不直接依赖 git，只在需要补充版本信息时通过该包获取。
+package vcs
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notCommittedMail 是 git blame 对未提交改动使用的占位作者邮箱。
+const notCommittedMail = "<not.committed.yet>"
+
+// BlameInfo 表示单文件的 blame 汇总信息。
+type BlameInfo struct {
+	// Authors 为贡献过当前内容的不同作者数量（按邮箱去重，未提交改动不计入）。
+	Authors int
+	// LastModified 为当前内容中最新一行对应提交的提交时间。
+	LastModified time.Time
+}
+
+// Available 检查当前环境是否可以执行 git 命令。
+func Available() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git executable not found: %w", err)
+	}
+	return nil
+}
+
+// Blame 对指定文件执行 git blame，并统计作者数与最后修改时间。
+// 文件不在 git 仓库中或尚未被跟踪时返回错误，调用方可据此跳过补充信息。
+func Blame(path string) (BlameInfo, error) {
+	command := exec.Command("git", "-C", filepath.Dir(path), "blame", "--line-porcelain", "--", filepath.Base(path))
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+
+	output, err := command.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return BlameInfo{}, fmt.Errorf("git blame: %w", err)
+		}
+		return BlameInfo{}, fmt.Errorf("git blame: %s", message)
+	}
+	return parseLinePorcelain(output)
+}
+
+// CurrentRef 返回 directory 所在仓库当前检出的分支名，分离 HEAD 时返回短提交哈希。
+func CurrentRef(directory string) (string, error) {
+	output, err := gitOutput(directory, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if ref := strings.TrimSpace(string(output)); ref != "HEAD" {
+		return ref, nil
+	}
+	output, err = gitOutput(directory, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// gitOutput 在 directory 中执行 git 子命令并返回标准输出，失败时以标准错误作为错误信息。
+func gitOutput(directory string, args ...string) ([]byte, error) {
+	command := exec.Command("git", append([]string{"-C", directory}, args...)...)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+
+	output, err := command.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return nil, fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], message)
+	}
+	return output, nil
+}
+
+// ErrUnsupportedRemote 表示远程仓库地址的协议不受支持（例如 file:// 或本地路径）。
+var ErrUnsupportedRemote = errors.New("unsupported git remote, expected https, http, ssh, git or user@host:path")
+
+// ValidateRemote 校验远程仓库地址，只允许网络协议，拒绝 file:// 与本地路径，
+// 避免服务端被用来读取本机上的任意仓库。
+func ValidateRemote(url string) error {
+	trimmed := strings.TrimSpace(url)
+	if strings.HasPrefix(trimmed, "-") {
+		return ErrUnsupportedRemote
+	}
+	for _, scheme := range []string{"https://", "http://", "ssh://", "git://"} {
+		if strings.HasPrefix(strings.ToLower(trimmed), scheme) {
+			return nil
+		}
+	}
+	// scp 风格：user@host:path
+	if at := strings.Index(trimmed, "@"); at > 0 {
+		if colon := strings.Index(trimmed[at:], ":"); colon > 1 && !strings.Contains(trimmed[:at], "/") {
+			return nil
+		}
+	}
+	return ErrUnsupportedRemote
+}
+
+// Clone 把远程仓库的默认分支浅克隆到 directory，ctx 取消时终止 git 进程。
+func Clone(ctx context.Context, url string, directory string) error {
+	if err := ValidateRemote(url); err != nil {
+		return err
+	}
+
+	command := exec.CommandContext(ctx, "git", "clone", "--depth", "1", "--quiet", "--", url, directory)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return fmt.Errorf("git clone: %w", err)
+		}
+		return fmt.Errorf("git clone: %s", message)
+	}
+	return nil
+}
+
+// ExportTree 把仓库 repository 中 ref（分支、标签或提交）对应的文件树导出到 directory，ctx 取消时终止 git 进程。
+// 导出内容与 git archive 一致：只包含已提交的文件，不含 .git 与工作区改动；符号链接会被跳过。
+func ExportTree(ctx context.Context, repository string, ref string, directory string) error {
+	trimmed := strings.TrimSpace(ref)
+	if trimmed == "" || strings.HasPrefix(trimmed, "-") {
+		return fmt.Errorf("invalid git ref %q", ref)
+	}
+
+	command := exec.CommandContext(ctx, "git", "-C", repository, "archive", "--format=tar", trimmed)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("git archive: %w", err)
+	}
+	if err := command.Start(); err != nil {
+		return fmt.Errorf("git archive: %w", err)
+	}
+
+	extractErr := extractTar(stdout, directory)
+	// 解压失败时仍需排空输出，否则 git 可能阻塞在写管道上而无法退出。
+	_, _ = io.Copy(io.Discard, stdout)
+	if err := command.Wait(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return fmt.Errorf("git archive: %w", err)
+		}
+		return fmt.Errorf("git archive: %s", message)
+	}
+	return extractErr
+}
+
+// extractTar 把 tar 流解压到 directory，拒绝绝对路径与越出目录的条目，只还原目录与普通文件。
+func extractTar(reader io.Reader, directory string) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+
+		name := filepath.FromSlash(header.Name)
+		if filepath.IsAbs(name) || !filepath.IsLocal(name) {
+			return fmt.Errorf("archive entry %q escapes the target directory", header.Name)
+		}
+		target := filepath.Join(directory, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(target, archive, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// writeArchiveFile 把 tar 中的一个普通文件写到 target，保留权限位（供脚本统计识别可执行文件）。
+func writeArchiveFile(target string, content io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// parseLinePorcelain 解析 git blame --line-porcelain 输出。
+// 该格式为每一行都重复输出完整的提交头信息，因此逐行累计即可，无需维护提交缓存。
+func parseLinePorcelain(output []byte) (BlameInfo, error) {
+	var info BlameInfo
+	authors := make(map[string]struct{})
+	currentMail := ""
+
+	lineScanner := bufio.NewScanner(bytes.NewReader(output))
+	lineScanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineScanner.Scan() {
+		line := lineScanner.Text()
+		switch {
+		case strings.HasPrefix(line, "author-mail "):
+			currentMail = strings.TrimPrefix(line, "author-mail ")
+			if currentMail != notCommittedMail {
+				authors[currentMail] = struct{}{}
+			}
+		case strings.HasPrefix(line, "committer-time "):
+			if currentMail == notCommittedMail {
+				continue
+			}
+			seconds, err := strconv.ParseInt(strings.TrimPrefix(line, "committer-time "), 10, 64)
+			if err != nil {
+				return info, fmt.Errorf("parse committer-time: %w", err)
+			}
+			if committed := time.Unix(seconds, 0).UTC(); committed.After(info.LastModified) {
+				info.LastModified = committed
+			}
+		}
+	}
+	if err := lineScanner.Err(); err != nil {
+		return info, err
+	}
+
+	info.Authors = len(authors)
+	if info.Authors == 0 {
+		return info, errors.New("git blame: no committed lines")
+	}
+	return info, nil
+}
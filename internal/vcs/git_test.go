@@ -0,0 +1,110 @@
+package vcs
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseLinePorcelain 验证作者去重、未提交行忽略以及最后修改时间的选取。
+func TestParseLinePorcelain(t *testing.T) {
+	output := strings.Join([]string{
+		"a1 1 1 1",
+		"author Alice",
+		"author-mail <alice@example.com>",
+		"committer-time 1700000000",
+		"\tline one",
+		"b2 2 2 1",
+		"author Bob",
+		"author-mail <bob@example.com>",
+		"committer-time 1710000000",
+		"\tline two",
+		"a1 3 3 1",
+		"author Alice",
+		"author-mail <alice@example.com>",
+		"committer-time 1700000000",
+		"\tline three",
+		"0000 4 4 1",
+		"author Not Committed Yet",
+		"author-mail <not.committed.yet>",
+		"committer-time 1800000000",
+		"\tline four",
+	}, "\n")
+
+	info, err := parseLinePorcelain([]byte(output))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if info.Authors != 2 {
+		t.Fatalf("expected 2 authors, got %d", info.Authors)
+	}
+	if !info.LastModified.Equal(time.Unix(1710000000, 0)) {
+		t.Fatalf("unexpected last modified: %v", info.LastModified)
+	}
+}
+
+// TestValidateRemote 验证只接受网络协议的远程仓库地址。
+func TestValidateRemote(t *testing.T) {
+	for _, url := range []string{"https://github.com/a/b.git", "ssh://git@host/a.git", "git@github.com:a/b.git"} {
+		if err := ValidateRemote(url); err != nil {
+			t.Fatalf("expected %s to be accepted: %v", url, err)
+		}
+	}
+	for _, url := range []string{"file:///etc", "/srv/repo", "../repo", "--upload-pack=evil", "a/b@c:d"} {
+		if err := ValidateRemote(url); err == nil {
+			t.Fatalf("expected %s to be rejected", url)
+		}
+	}
+}
+
+// TestExtractTar 验证解压只还原目录与普通文件（保留权限位），并拒绝越出目标目录的条目。
+func TestExtractTar(t *testing.T) {
+	build := func(entries ...*tar.Header) *bytes.Buffer {
+		var buffer bytes.Buffer
+		writer := tar.NewWriter(&buffer)
+		for _, header := range entries {
+			if err := writer.WriteHeader(header); err != nil {
+				t.Fatalf("write header failed: %v", err)
+			}
+			if header.Typeflag == tar.TypeReg {
+				if _, err := writer.Write([]byte(strings.Repeat("x", int(header.Size)))); err != nil {
+					t.Fatalf("write content failed: %v", err)
+				}
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("close archive failed: %v", err)
+		}
+		return &buffer
+	}
+
+	directory := t.TempDir()
+	archive := build(
+		&tar.Header{Name: "cmd/", Typeflag: tar.TypeDir, Mode: 0o755},
+		&tar.Header{Name: "cmd/run.sh", Typeflag: tar.TypeReg, Mode: 0o755, Size: 3},
+		&tar.Header{Name: "lib/util.go", Typeflag: tar.TypeReg, Mode: 0o644, Size: 2},
+		&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	)
+	if err := extractTar(archive, directory); err != nil {
+		t.Fatalf("extract failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(directory, "cmd", "run.sh"))
+	if err != nil || info.Size() != 3 || info.Mode().Perm()&0o111 == 0 {
+		t.Fatalf("unexpected extracted script: %v %v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(directory, "lib", "util.go")); err != nil {
+		t.Fatalf("expected extracted file: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(directory, "link")); !os.IsNotExist(err) {
+		t.Fatalf("expected symlink to be skipped, got %v", err)
+	}
+
+	escaping := build(&tar.Header{Name: "../escape.go", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+	if err := extractTar(escaping, t.TempDir()); err == nil {
+		t.Fatalf("expected escaping entry to be rejected")
+	}
+}
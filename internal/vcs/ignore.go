@@ -0,0 +1,108 @@
+package vcs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/glob"
+)
+
+// Ignore 是按目录累积的 .gitignore 规则，与 Attributes 一样不调用 git 命令，直接解析文件内容。
+//
+// 匹配规则与 git 一致：
+// - 不含 / 的模式匹配任意深度的文件名或目录名，含 / 的模式相对 .gitignore 所在目录匹配（开头的 / 只表示锚定）
+// - 以 / 结尾的模式只匹配目录；以 ! 开头的模式重新包含之前被忽略的路径，最后一条匹配的规则为准
+// - 目录被忽略时其中的全部内容都被忽略，! 不能重新包含被忽略目录中的文件
+// - 开头的 \# 与 \! 按字面匹配，行尾的空白被忽略；扫描根目录以上的 .gitignore 与全局排除文件不读取
+type Ignore struct {
+	rules []ignoreRule
+}
+
+// ignoreRule 是一行 .gitignore 规则。
+type ignoreRule struct {
+	// dir 为规则所在目录（相对扫描根目录，根目录为空）。
+	dir     string
+	pattern string
+	// basename 表示模式只与文件名或目录名匹配。
+	basename bool
+	// dirOnly 表示模式只匹配目录。
+	dirOnly bool
+	// negate 表示匹配的路径重新被包含。
+	negate bool
+}
+
+// Parse 解析 dir（相对扫描根目录、以 / 分隔，根目录为空或 "."）下 .gitignore 的内容并追加规则。
+// 调用方应按先上级目录、后子目录的顺序解析。
+func (i *Ignore) Parse(reader io.Reader, dir string) error {
+	if dir == "." {
+		dir = ""
+	}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{dir: dir}
+		if negated, ok := strings.CutPrefix(line, "!"); ok {
+			rule.negate = true
+			line = negated
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if trimmed, ok := strings.CutSuffix(line, "/"); ok {
+			rule.dirOnly = true
+			line = trimmed
+		}
+		if trimmed, anchored := strings.CutPrefix(line, "/"); anchored || strings.Contains(line, "/") {
+			line = trimmed
+		} else {
+			rule.basename = true
+		}
+		if line == "" || glob.Validate(line) != nil {
+			continue
+		}
+		rule.pattern = line
+		i.rules = append(i.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read gitignore: %w", err)
+	}
+	return nil
+}
+
+// Ignored 判断相对扫描根目录、以 / 分隔的路径是否被忽略，isDir 表示该路径是目录。
+// 调用方应跳过被忽略的目录而不是继续判断其中的文件。
+func (i *Ignore) Ignored(filePath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range i.rules {
+		if rule.matches(filePath, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matches 判断规则是否作用于路径。
+func (r ignoreRule) matches(filePath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	relative := filePath
+	if r.dir != "" {
+		trimmed, ok := strings.CutPrefix(filePath, r.dir+"/")
+		if !ok {
+			return false
+		}
+		relative = trimmed
+	}
+	if r.basename {
+		relative = path.Base(relative)
+	}
+	ok, _ := glob.Match(r.pattern, relative)
+	return ok
+}
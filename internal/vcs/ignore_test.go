@@ -0,0 +1,52 @@
+package vcs
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestIgnored 验证文件名与锚定模式、只匹配目录的模式、! 重新包含，以及子目录规则的作用范围。
+func TestIgnored(t *testing.T) {
+	var ignore Ignore
+	root := "# comment\n" +
+		"*.log\n" +
+		"!keep.log\n" +
+		"/build\n" +
+		"tmp/\n" +
+		"docs/*.html\n" +
+		"\\#notes\n" +
+		"**/generated/**  \n"
+	if err := ignore.Parse(strings.NewReader(root), ""); err != nil {
+		t.Fatalf("parse root gitignore failed: %v", err)
+	}
+	if err := ignore.Parse(strings.NewReader("*.go\n!main.go\n"), "lib"); err != nil {
+		t.Fatalf("parse nested gitignore failed: %v", err)
+	}
+
+	cases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "app.log", ignored: true},
+		{path: "src/debug.log", ignored: true},
+		{path: "src/keep.log", ignored: false},
+		{path: "build", isDir: true, ignored: true},
+		{path: "src/build", isDir: true, ignored: false},
+		{path: "tmp", isDir: true, ignored: true},
+		{path: "src/tmp", isDir: true, ignored: true},
+		{path: "tmp", isDir: false, ignored: false},
+		{path: "docs/index.html", ignored: true},
+		{path: "docs/api/index.html", ignored: false},
+		{path: "#notes", ignored: true},
+		{path: "src/generated/types.go", ignored: true},
+		{path: "lib/util.go", ignored: true},
+		{path: "lib/main.go", ignored: false},
+		{path: "cmd/util.go", ignored: false},
+	}
+	for _, tc := range cases {
+		if got := ignore.Ignored(tc.path, tc.isDir); got != tc.ignored {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tc.path, tc.isDir, got, tc.ignored)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package vcs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ChangedFile 是提交范围内改动的一个普通文件，BaseObject 或 HeadObject 为空表示该侧不存在该文件（新增或删除）。
+// 符号链接与子模块不属于普通文件：类型改变为普通文件时只保留普通文件的一侧。
+type ChangedFile struct {
+	// Path 为相对仓库根目录、以 / 分隔的路径。
+	Path       string
+	BaseObject string
+	BaseMode   os.FileMode
+	HeadObject string
+	HeadMode   os.FileMode
+}
+
+// SplitRange 把 base...head 或 base..head 形式的范围拆成两侧，省略的一侧为 HEAD。
+// 三点形式以两者的合并基点为 base（与 git diff 一致，只包含 head 分支上的改动），mergeBase 为 true。
+func SplitRange(spec string) (base string, head string, mergeBase bool, err error) {
+	spec = strings.TrimSpace(spec)
+	separator := "..."
+	index := strings.Index(spec, separator)
+	if index < 0 {
+		separator = ".."
+		index = strings.Index(spec, separator)
+	}
+	if index < 0 {
+		return "", "", false, fmt.Errorf("invalid git range %q, expected base...head or base..head", spec)
+	}
+	base, head = spec[:index], spec[index+len(separator):]
+	if base == "" {
+		base = "HEAD"
+	}
+	if head == "" {
+		head = "HEAD"
+	}
+	for _, ref := range []string{base, head} {
+		if strings.HasPrefix(ref, "-") || strings.Contains(ref, "..") {
+			return "", "", false, fmt.Errorf("invalid git range %q", spec)
+		}
+	}
+	return base, head, separator == "...", nil
+}
+
+// ChangedFiles 列出 repository 中提交范围 spec（见 SplitRange）内改动的普通文件，按 git diff 的顺序排列；
+// 重命名视为删除加新增，ctx 取消时终止 git 进程。
+func ChangedFiles(ctx context.Context, repository string, spec string) ([]ChangedFile, error) {
+	base, head, mergeBase, err := SplitRange(spec)
+	if err != nil {
+		return nil, err
+	}
+	separator := ".."
+	if mergeBase {
+		separator = "..."
+	}
+	command := exec.CommandContext(ctx, "git", "-C", repository, "diff", "--raw", "-z", "--no-renames", "--no-abbrev", "--no-ext-diff", base+separator+head, "--")
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return nil, fmt.Errorf("git diff: %w", err)
+		}
+		return nil, fmt.Errorf("git diff: %s", message)
+	}
+	return parseRawDiff(output)
+}
+
+// parseRawDiff 解析 git diff --raw -z --no-renames 的输出，每条记录为 ":<旧模式> <新模式> <旧对象> <新对象> <状态>\0<路径>\0"。
+func parseRawDiff(output []byte) ([]ChangedFile, error) {
+	files := make([]ChangedFile, 0)
+	fields := strings.Split(string(output), "\x00")
+	for index := 0; index+1 < len(fields); index += 2 {
+		header := fields[index]
+		parts := strings.Fields(strings.TrimPrefix(header, ":"))
+		if !strings.HasPrefix(header, ":") || len(parts) != 5 {
+			return nil, fmt.Errorf("unexpected git diff record %q", header)
+		}
+		baseMode, err := strconv.ParseUint(parts[0], 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected git diff mode %q", parts[0])
+		}
+		headMode, err := strconv.ParseUint(parts[1], 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected git diff mode %q", parts[1])
+		}
+
+		file := ChangedFile{Path: fields[index+1]}
+		if perm, ok := regularFileMode(baseMode); ok {
+			file.BaseObject, file.BaseMode = parts[2], perm
+		}
+		if perm, ok := regularFileMode(headMode); ok {
+			file.HeadObject, file.HeadMode = parts[3], perm
+		}
+		if file.BaseObject != "" || file.HeadObject != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// regularFileMode 把 git 的文件模式转换为权限位，只接受普通文件（100644 与 100755）。
+func regularFileMode(mode uint64) (os.FileMode, bool) {
+	switch mode {
+	case 0o100644:
+		return 0o644, true
+	case 0o100755:
+		return 0o755, true
+	default:
+		return 0, false
+	}
+}
+
+// ExportChangedFiles 把改动文件在一侧（head 为 false 时为 base 侧）的内容写到 directory 下对应的相对路径，
+// 该侧不存在的文件跳过。通过一次 git cat-file --batch 读取，不受命令行长度限制，ctx 取消时终止 git 进程。
+func ExportChangedFiles(ctx context.Context, repository string, files []ChangedFile, head bool, directory string) error {
+	exported := make([]ChangedFile, 0, len(files))
+	var input bytes.Buffer
+	for _, file := range files {
+		object := file.BaseObject
+		if head {
+			object = file.HeadObject
+		}
+		if object == "" {
+			continue
+		}
+		name := filepath.FromSlash(file.Path)
+		if filepath.IsAbs(name) || !filepath.IsLocal(name) {
+			return fmt.Errorf("git path %q escapes the target directory", file.Path)
+		}
+		exported = append(exported, file)
+		input.WriteString(object)
+		input.WriteByte('\n')
+	}
+	if len(exported) == 0 {
+		return nil
+	}
+
+	command := exec.CommandContext(ctx, "git", "-C", repository, "cat-file", "--batch")
+	command.Stdin = &input
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("git cat-file: %w", err)
+	}
+	if err := command.Start(); err != nil {
+		return fmt.Errorf("git cat-file: %w", err)
+	}
+
+	readErr := readObjects(bufio.NewReader(stdout), exported, head, directory)
+	// 读取失败时仍需排空输出，否则 git 可能阻塞在写管道上而无法退出。
+	_, _ = io.Copy(io.Discard, stdout)
+	if err := command.Wait(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return fmt.Errorf("git cat-file: %w", err)
+		}
+		return fmt.Errorf("git cat-file: %s", message)
+	}
+	return readErr
+}
+
+// readObjects 按请求顺序读取 git cat-file --batch 的输出（"<对象> <类型> <大小>\n<内容>\n"），逐个写入文件。
+func readObjects(reader *bufio.Reader, files []ChangedFile, head bool, directory string) error {
+	for _, file := range files {
+		filePath, perm := file.Path, file.BaseMode
+		if head {
+			perm = file.HeadMode
+		}
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("read git object for %s: %w", filePath, err)
+		}
+		parts := strings.Fields(header)
+		if len(parts) != 3 || parts[1] != "blob" {
+			return fmt.Errorf("unexpected git object for %s: %q", filePath, strings.TrimSpace(header))
+		}
+		size, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("unexpected git object size for %s: %q", filePath, parts[2])
+		}
+		if err := writeArchiveFile(filepath.Join(directory, filepath.FromSlash(filePath)), io.LimitReader(reader, size), perm); err != nil {
+			return err
+		}
+		if _, err := reader.Discard(1); err != nil {
+			return fmt.Errorf("read git object for %s: %w", filePath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package vcs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSplitRange 验证两点与三点范围的拆分、省略一侧时的默认值以及非法范围。
+func TestSplitRange(t *testing.T) {
+	base, head, mergeBase, err := SplitRange("origin/main...HEAD")
+	if err != nil || base != "origin/main" || head != "HEAD" || !mergeBase {
+		t.Fatalf("unexpected range: %s %s %v (%v)", base, head, mergeBase, err)
+	}
+	base, head, mergeBase, err = SplitRange("v1.0..")
+	if err != nil || base != "v1.0" || head != "HEAD" || mergeBase {
+		t.Fatalf("unexpected range: %s %s %v (%v)", base, head, mergeBase, err)
+	}
+	for _, spec := range []string{"HEAD", "--output=x..HEAD", "a..b..c"} {
+		if _, _, _, err := SplitRange(spec); err == nil {
+			t.Fatalf("expected error for range %q", spec)
+		}
+	}
+}
+
+// TestParseRawDiff 验证新增、删除、修改与可执行位的解析，符号链接与子模块被忽略。
+func TestParseRawDiff(t *testing.T) {
+	zero := strings.Repeat("0", 40)
+	a, b := strings.Repeat("a", 40), strings.Repeat("b", 40)
+	output := strings.Join([]string{
+		":000000 100755 " + zero + " " + a + " A", "run.sh",
+		":100644 000000 " + a + " " + zero + " D", "old.go",
+		":100644 100644 " + a + " " + b + " M", "dir with space/main.go",
+		":120000 120000 " + a + " " + b + " M", "link",
+		":160000 160000 " + a + " " + b + " M", "vendor/module",
+		":120000 100644 " + a + " " + b + " T", "became-file.txt",
+		"",
+	}, "\x00")
+
+	files, err := parseRawDiff([]byte(output))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	expected := []ChangedFile{
+		{Path: "run.sh", HeadObject: a, HeadMode: 0o755},
+		{Path: "old.go", BaseObject: a, BaseMode: 0o644},
+		{Path: "dir with space/main.go", BaseObject: a, BaseMode: 0o644, HeadObject: b, HeadMode: 0o644},
+		{Path: "became-file.txt", HeadObject: b, HeadMode: 0o644},
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+
+	if _, err := parseRawDiff([]byte("garbage\x00path\x00")); err == nil {
+		t.Fatal("expected error for malformed record")
+	}
+}
+
+// TestReadObjects 验证按请求顺序解析 git cat-file --batch 输出并写出文件与权限位。
+func TestReadObjects(t *testing.T) {
+	directory := t.TempDir()
+	output := "aaa blob 8\npackage\n\n" + "bbb blob 5\necho\n\n"
+	files := []ChangedFile{
+		{Path: "src/main.go", HeadObject: "aaa", HeadMode: 0o644},
+		{Path: "run.sh", HeadObject: "bbb", HeadMode: 0o755},
+	}
+	if err := readObjects(bufio.NewReader(strings.NewReader(output)), files, true, directory); err != nil {
+		t.Fatalf("read objects failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(directory, "src", "main.go"))
+	if err != nil || string(content) != "package\n" {
+		t.Fatalf("unexpected content %q (%v)", content, err)
+	}
+	if info, err := os.Stat(filepath.Join(directory, "run.sh")); err != nil || info.Mode().Perm()&0o111 == 0 {
+		t.Fatalf("expected executable script, got %v (%v)", info, err)
+	}
+
+	missing := "ccc missing\n"
+	if err := readObjects(bufio.NewReader(strings.NewReader(missing)), files[:1], true, directory); err == nil {
+		t.Fatal("expected error for missing object")
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"fmt"
 	"os"
 
-	"gocloc/cmd"
+	"github.com/zhizhixiongxuwei/gocloc/cmd"
 )
 
 // version 默认值为 dev。
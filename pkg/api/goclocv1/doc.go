@@ -0,0 +1,12 @@
+// Package goclocv1 是 gocloc serve --grpc 服务（gocloc.v1.Gocloc）的 protobuf 消息与 gRPC 客户端、服务端桩代码。
+//
+// gocloc.proto 是服务的权威定义，其他语言可以直接据此生成客户端；gocloc.pb.go 由 protoc-gen-go 生成，
+// service.go 中的服务描述与桩代码与 protoc-gen-go-grpc 的输出等价。修改 gocloc.proto 后在仓库根目录执行：
+//
+//	protoc --go_out=. --go_opt=paths=source_relative pkg/api/goclocv1/gocloc.proto
+//
+// Go 客户端示例：
+//
+//	conn, err := grpc.NewClient("localhost:9090", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	result, err := goclocv1.NewGoclocClient(conn).Scan(ctx, &goclocv1.ScanRequest{Path: "repo-a"})
+package goclocv1
@@ -0,0 +1,1308 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.5
+// 	protoc        (unknown)
+// source: pkg/api/goclocv1/gocloc.proto
+
+package goclocv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ScanOptions 是请求中可携带的扫描选项，与 HTTP 接口的 options 同名同义。
+type ScanOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 并发 worker 数量，0 时使用服务端默认值，超过服务端上限时按上限执行。
+	Workers          int32 `protobuf:"varint,1,opt,name=workers,proto3" json:"workers,omitempty"`
+	CountFunctions   bool  `protobuf:"varint,2,opt,name=count_functions,json=countFunctions,proto3" json:"count_functions,omitempty"`
+	Annotate         bool  `protobuf:"varint,3,opt,name=annotate,proto3" json:"annotate,omitempty"`
+	StringLines      bool  `protobuf:"varint,4,opt,name=string_lines,json=stringLines,proto3" json:"string_lines,omitempty"`
+	Whitespace       bool  `protobuf:"varint,5,opt,name=whitespace,proto3" json:"whitespace,omitempty"`
+	Duplicates       bool  `protobuf:"varint,6,opt,name=duplicates,proto3" json:"duplicates,omitempty"`
+	DuplicateLines   int32 `protobuf:"varint,7,opt,name=duplicate_lines,json=duplicateLines,proto3" json:"duplicate_lines,omitempty"`
+	Scripts          bool  `protobuf:"varint,8,opt,name=scripts,proto3" json:"scripts,omitempty"`
+	Distribution     bool  `protobuf:"varint,9,opt,name=distribution,proto3" json:"distribution,omitempty"`
+	GitBlame         bool  `protobuf:"varint,10,opt,name=git_blame,json=gitBlame,proto3" json:"git_blame,omitempty"`
+	Top              int32 `protobuf:"varint,11,opt,name=top,proto3" json:"top,omitempty"`
+	SummaryOnly      bool  `protobuf:"varint,12,opt,name=summary_only,json=summaryOnly,proto3" json:"summary_only,omitempty"`
+	Unsorted         bool  `protobuf:"varint,13,opt,name=unsorted,proto3" json:"unsorted,omitempty"`
+	PythonDocstrings bool  `protobuf:"varint,14,opt,name=python_docstrings,json=pythonDocstrings,proto3" json:"python_docstrings,omitempty"`
+	// SQL 方言：auto（默认）、ansi、mysql、postgres、tsql 或 oracle。
+	SqlDialect string `protobuf:"bytes,15,opt,name=sql_dialect,json=sqlDialect,proto3" json:"sql_dialect,omitempty"`
+	// Go 编译指令行的计入方式：comment（默认）、directive 或 code。
+	GoDirectives string `protobuf:"bytes,16,opt,name=go_directives,json=goDirectives,proto3" json:"go_directives,omitempty"`
+	// 脚本首行 shebang 的计入方式：comment（默认）、directive 或 code。
+	Shebang           string   `protobuf:"bytes,17,opt,name=shebang,proto3" json:"shebang,omitempty"`
+	LogicalDirectives bool     `protobuf:"varint,18,opt,name=logical_directives,json=logicalDirectives,proto3" json:"logical_directives,omitempty"`
+	CgoPreamble       bool     `protobuf:"varint,19,opt,name=cgo_preamble,json=cgoPreamble,proto3" json:"cgo_preamble,omitempty"`
+	TrailingEmptyLine bool     `protobuf:"varint,20,opt,name=trailing_empty_line,json=trailingEmptyLine,proto3" json:"trailing_empty_line,omitempty"`
+	Gitattributes     bool     `protobuf:"varint,21,opt,name=gitattributes,proto3" json:"gitattributes,omitempty"`
+	Packages          bool     `protobuf:"varint,22,opt,name=packages,proto3" json:"packages,omitempty"`
+	DisabledLanguages []string `protobuf:"bytes,23,rep,name=disabled_languages,json=disabledLanguages,proto3" json:"disabled_languages,omitempty"`
+	// 把后缀映射到指定语言，如 {".inc": "C/C++"}。
+	ExtensionMap_ map[string]string `protobuf:"bytes,24,rep,name=extension_map,json=extensionMap,proto3" json:"extension_map,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Languages     []string          `protobuf:"bytes,25,rep,name=languages,proto3" json:"languages,omitempty"`
+	Excludes      []string          `protobuf:"bytes,26,rep,name=excludes,proto3" json:"excludes,omitempty"`
+	// 附加到结果的标签，原样写入 ScanResult.labels。
+	Labels        map[string]string `protobuf:"bytes,27,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanOptions) Reset() {
+	*x = ScanOptions{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanOptions) ProtoMessage() {}
+
+func (x *ScanOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanOptions.ProtoReflect.Descriptor instead.
+func (*ScanOptions) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ScanOptions) GetWorkers() int32 {
+	if x != nil {
+		return x.Workers
+	}
+	return 0
+}
+
+func (x *ScanOptions) GetCountFunctions() bool {
+	if x != nil {
+		return x.CountFunctions
+	}
+	return false
+}
+
+func (x *ScanOptions) GetAnnotate() bool {
+	if x != nil {
+		return x.Annotate
+	}
+	return false
+}
+
+func (x *ScanOptions) GetStringLines() bool {
+	if x != nil {
+		return x.StringLines
+	}
+	return false
+}
+
+func (x *ScanOptions) GetWhitespace() bool {
+	if x != nil {
+		return x.Whitespace
+	}
+	return false
+}
+
+func (x *ScanOptions) GetDuplicates() bool {
+	if x != nil {
+		return x.Duplicates
+	}
+	return false
+}
+
+func (x *ScanOptions) GetDuplicateLines() int32 {
+	if x != nil {
+		return x.DuplicateLines
+	}
+	return 0
+}
+
+func (x *ScanOptions) GetScripts() bool {
+	if x != nil {
+		return x.Scripts
+	}
+	return false
+}
+
+func (x *ScanOptions) GetDistribution() bool {
+	if x != nil {
+		return x.Distribution
+	}
+	return false
+}
+
+func (x *ScanOptions) GetGitBlame() bool {
+	if x != nil {
+		return x.GitBlame
+	}
+	return false
+}
+
+func (x *ScanOptions) GetTop() int32 {
+	if x != nil {
+		return x.Top
+	}
+	return 0
+}
+
+func (x *ScanOptions) GetSummaryOnly() bool {
+	if x != nil {
+		return x.SummaryOnly
+	}
+	return false
+}
+
+func (x *ScanOptions) GetUnsorted() bool {
+	if x != nil {
+		return x.Unsorted
+	}
+	return false
+}
+
+func (x *ScanOptions) GetPythonDocstrings() bool {
+	if x != nil {
+		return x.PythonDocstrings
+	}
+	return false
+}
+
+func (x *ScanOptions) GetSqlDialect() string {
+	if x != nil {
+		return x.SqlDialect
+	}
+	return ""
+}
+
+func (x *ScanOptions) GetGoDirectives() string {
+	if x != nil {
+		return x.GoDirectives
+	}
+	return ""
+}
+
+func (x *ScanOptions) GetShebang() string {
+	if x != nil {
+		return x.Shebang
+	}
+	return ""
+}
+
+func (x *ScanOptions) GetLogicalDirectives() bool {
+	if x != nil {
+		return x.LogicalDirectives
+	}
+	return false
+}
+
+func (x *ScanOptions) GetCgoPreamble() bool {
+	if x != nil {
+		return x.CgoPreamble
+	}
+	return false
+}
+
+func (x *ScanOptions) GetTrailingEmptyLine() bool {
+	if x != nil {
+		return x.TrailingEmptyLine
+	}
+	return false
+}
+
+func (x *ScanOptions) GetGitattributes() bool {
+	if x != nil {
+		return x.Gitattributes
+	}
+	return false
+}
+
+func (x *ScanOptions) GetPackages() bool {
+	if x != nil {
+		return x.Packages
+	}
+	return false
+}
+
+func (x *ScanOptions) GetDisabledLanguages() []string {
+	if x != nil {
+		return x.DisabledLanguages
+	}
+	return nil
+}
+
+func (x *ScanOptions) GetExtensionMap_() map[string]string {
+	if x != nil {
+		return x.ExtensionMap_
+	}
+	return nil
+}
+
+func (x *ScanOptions) GetLanguages() []string {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+func (x *ScanOptions) GetExcludes() []string {
+	if x != nil {
+		return x.Excludes
+	}
+	return nil
+}
+
+func (x *ScanOptions) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// ScanRequest 是扫描请求。
+type ScanRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 相对服务端根目录的路径，为空时扫描整个根目录。
+	Path          string       `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Options       *ScanOptions `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ScanRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetOptions() *ScanOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+// AnalyzeContentRequest 是单个内容缓冲区的分析请求。
+type AnalyzeContentRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 语言名称，不区分大小写。
+	Language      string       `protobuf:"bytes,1,opt,name=language,proto3" json:"language,omitempty"`
+	Content       string       `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Options       *ScanOptions `protobuf:"bytes,3,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzeContentRequest) Reset() {
+	*x = AnalyzeContentRequest{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeContentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeContentRequest) ProtoMessage() {}
+
+func (x *AnalyzeContentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeContentRequest.ProtoReflect.Descriptor instead.
+func (*AnalyzeContentRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AnalyzeContentRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *AnalyzeContentRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *AnalyzeContentRequest) GetOptions() *ScanOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+// LineMetrics 是一组行数统计，字段与 --format json 的 metrics 一致。
+type LineMetrics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Code          int64                  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Comment       int64                  `protobuf:"varint,3,opt,name=comment,proto3" json:"comment,omitempty"`
+	Blank         int64                  `protobuf:"varint,4,opt,name=blank,proto3" json:"blank,omitempty"`
+	Uloc          int64                  `protobuf:"varint,5,opt,name=uloc,proto3" json:"uloc,omitempty"`
+	Preprocessor  int64                  `protobuf:"varint,6,opt,name=preprocessor,proto3" json:"preprocessor,omitempty"`
+	StringLiteral int64                  `protobuf:"varint,7,opt,name=string_literal,json=stringLiteral,proto3" json:"string_literal,omitempty"`
+	Functions     int64                  `protobuf:"varint,8,opt,name=functions,proto3" json:"functions,omitempty"`
+	Bytes         int64                  `protobuf:"varint,9,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Characters    int64                  `protobuf:"varint,10,opt,name=characters,proto3" json:"characters,omitempty"`
+	MaxLineLength int64                  `protobuf:"varint,11,opt,name=max_line_length,json=maxLineLength,proto3" json:"max_line_length,omitempty"`
+	AvgLineLength float64                `protobuf:"fixed64,12,opt,name=avg_line_length,json=avgLineLength,proto3" json:"avg_line_length,omitempty"`
+	// 逐行分类，仅在 options.annotate 时填充。
+	LineClasses   []string `protobuf:"bytes,13,rep,name=line_classes,json=lineClasses,proto3" json:"line_classes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LineMetrics) Reset() {
+	*x = LineMetrics{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LineMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LineMetrics) ProtoMessage() {}
+
+func (x *LineMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LineMetrics.ProtoReflect.Descriptor instead.
+func (*LineMetrics) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LineMetrics) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetCode() int64 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetComment() int64 {
+	if x != nil {
+		return x.Comment
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetBlank() int64 {
+	if x != nil {
+		return x.Blank
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetUloc() int64 {
+	if x != nil {
+		return x.Uloc
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetPreprocessor() int64 {
+	if x != nil {
+		return x.Preprocessor
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetStringLiteral() int64 {
+	if x != nil {
+		return x.StringLiteral
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetFunctions() int64 {
+	if x != nil {
+		return x.Functions
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetCharacters() int64 {
+	if x != nil {
+		return x.Characters
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetMaxLineLength() int64 {
+	if x != nil {
+		return x.MaxLineLength
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetAvgLineLength() float64 {
+	if x != nil {
+		return x.AvgLineLength
+	}
+	return 0
+}
+
+func (x *LineMetrics) GetLineClasses() []string {
+	if x != nil {
+		return x.LineClasses
+	}
+	return nil
+}
+
+// FileMetrics 是单个文件的统计。
+type FileMetrics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Language      string                 `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	Variant       string                 `protobuf:"bytes,3,opt,name=variant,proto3" json:"variant,omitempty"`
+	Test          bool                   `protobuf:"varint,4,opt,name=test,proto3" json:"test,omitempty"`
+	Generated     bool                   `protobuf:"varint,5,opt,name=generated,proto3" json:"generated,omitempty"`
+	Metrics       *LineMetrics           `protobuf:"bytes,6,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	Owners        []string               `protobuf:"bytes,7,rep,name=owners,proto3" json:"owners,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileMetrics) Reset() {
+	*x = FileMetrics{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileMetrics) ProtoMessage() {}
+
+func (x *FileMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileMetrics.ProtoReflect.Descriptor instead.
+func (*FileMetrics) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FileMetrics) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileMetrics) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *FileMetrics) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *FileMetrics) GetTest() bool {
+	if x != nil {
+		return x.Test
+	}
+	return false
+}
+
+func (x *FileMetrics) GetGenerated() bool {
+	if x != nil {
+		return x.Generated
+	}
+	return false
+}
+
+func (x *FileMetrics) GetMetrics() *LineMetrics {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *FileMetrics) GetOwners() []string {
+	if x != nil {
+		return x.Owners
+	}
+	return nil
+}
+
+// ScanError 是单个文件的扫描错误。
+type ScanError struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Path    string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// 错误分类：open、read、decode、timeout 或 oversize。
+	Category      string `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	Errno         int32  `protobuf:"varint,4,opt,name=errno,proto3" json:"errno,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanError) Reset() {
+	*x = ScanError{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanError) ProtoMessage() {}
+
+func (x *ScanError) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanError.ProtoReflect.Descriptor instead.
+func (*ScanError) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ScanError) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ScanError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ScanError) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ScanError) GetErrno() int32 {
+	if x != nil {
+		return x.Errno
+	}
+	return 0
+}
+
+// LanguageMetrics 是单个语言的汇总。
+type LanguageMetrics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Language      string                 `protobuf:"bytes,1,opt,name=language,proto3" json:"language,omitempty"`
+	Extensions    []string               `protobuf:"bytes,2,rep,name=extensions,proto3" json:"extensions,omitempty"`
+	Files         int64                  `protobuf:"varint,3,opt,name=files,proto3" json:"files,omitempty"`
+	Metrics       *LineMetrics           `protobuf:"bytes,4,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LanguageMetrics) Reset() {
+	*x = LanguageMetrics{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LanguageMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LanguageMetrics) ProtoMessage() {}
+
+func (x *LanguageMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LanguageMetrics.ProtoReflect.Descriptor instead.
+func (*LanguageMetrics) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LanguageMetrics) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *LanguageMetrics) GetExtensions() []string {
+	if x != nil {
+		return x.Extensions
+	}
+	return nil
+}
+
+func (x *LanguageMetrics) GetFiles() int64 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *LanguageMetrics) GetMetrics() *LineMetrics {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+// TotalMetrics 是项目总计。
+type TotalMetrics struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Files         int64                  `protobuf:"varint,1,opt,name=files,proto3" json:"files,omitempty"`
+	Metrics       *LineMetrics           `protobuf:"bytes,2,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TotalMetrics) Reset() {
+	*x = TotalMetrics{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TotalMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TotalMetrics) ProtoMessage() {}
+
+func (x *TotalMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TotalMetrics.ProtoReflect.Descriptor instead.
+func (*TotalMetrics) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TotalMetrics) GetFiles() int64 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *TotalMetrics) GetMetrics() *LineMetrics {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+// ScanResult 是扫描结果的核心字段；重复检测、脚本盘点等扩展段落只在 result_json 中提供。
+type ScanResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SchemaVersion int32                  `protobuf:"varint,1,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	ScannedPath   string                 `protobuf:"bytes,2,opt,name=scanned_path,json=scannedPath,proto3" json:"scanned_path,omitempty"`
+	Files         []*FileMetrics         `protobuf:"bytes,3,rep,name=files,proto3" json:"files,omitempty"`
+	SummaryOnly   bool                   `protobuf:"varint,4,opt,name=summary_only,json=summaryOnly,proto3" json:"summary_only,omitempty"`
+	Languages     []*LanguageMetrics     `protobuf:"bytes,5,rep,name=languages,proto3" json:"languages,omitempty"`
+	Total         *TotalMetrics          `protobuf:"bytes,6,opt,name=total,proto3" json:"total,omitempty"`
+	Errors        []*ScanError           `protobuf:"bytes,7,rep,name=errors,proto3" json:"errors,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// 与 scan --format json 相同的完整结果（带 schema_version），包含上面未建模的全部段落。
+	ResultJson    []byte `protobuf:"bytes,9,opt,name=result_json,json=resultJson,proto3" json:"result_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResult) Reset() {
+	*x = ScanResult{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResult) ProtoMessage() {}
+
+func (x *ScanResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResult.ProtoReflect.Descriptor instead.
+func (*ScanResult) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ScanResult) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *ScanResult) GetScannedPath() string {
+	if x != nil {
+		return x.ScannedPath
+	}
+	return ""
+}
+
+func (x *ScanResult) GetFiles() []*FileMetrics {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *ScanResult) GetSummaryOnly() bool {
+	if x != nil {
+		return x.SummaryOnly
+	}
+	return false
+}
+
+func (x *ScanResult) GetLanguages() []*LanguageMetrics {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+func (x *ScanResult) GetTotal() *TotalMetrics {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *ScanResult) GetErrors() []*ScanError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *ScanResult) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *ScanResult) GetResultJson() []byte {
+	if x != nil {
+		return x.ResultJson
+	}
+	return nil
+}
+
+// ScanStreamMessage 是 ScanStream 的流式消息，file 与 error 二选一。
+type ScanStreamMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Item:
+	//
+	//	*ScanStreamMessage_File
+	//	*ScanStreamMessage_Error
+	Item          isScanStreamMessage_Item `protobuf_oneof:"item"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanStreamMessage) Reset() {
+	*x = ScanStreamMessage{}
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanStreamMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanStreamMessage) ProtoMessage() {}
+
+func (x *ScanStreamMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_goclocv1_gocloc_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanStreamMessage.ProtoReflect.Descriptor instead.
+func (*ScanStreamMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ScanStreamMessage) GetItem() isScanStreamMessage_Item {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *ScanStreamMessage) GetFile() *FileMetrics {
+	if x != nil {
+		if x, ok := x.Item.(*ScanStreamMessage_File); ok {
+			return x.File
+		}
+	}
+	return nil
+}
+
+func (x *ScanStreamMessage) GetError() *ScanError {
+	if x != nil {
+		if x, ok := x.Item.(*ScanStreamMessage_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isScanStreamMessage_Item interface {
+	isScanStreamMessage_Item()
+}
+
+type ScanStreamMessage_File struct {
+	File *FileMetrics `protobuf:"bytes,1,opt,name=file,proto3,oneof"`
+}
+
+type ScanStreamMessage_Error struct {
+	Error *ScanError `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*ScanStreamMessage_File) isScanStreamMessage_Item() {}
+
+func (*ScanStreamMessage_Error) isScanStreamMessage_Item() {}
+
+var File_pkg_api_goclocv1_gocloc_proto protoreflect.FileDescriptor
+
+var file_pkg_api_goclocv1_gocloc_proto_rawDesc = string([]byte{
+	0x0a, 0x1d, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63,
+	0x76, 0x31, 0x2f, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x09, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x22, 0xe5, 0x08, 0x0a, 0x0b, 0x53,
+	0x63, 0x61, 0x6e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x77, 0x6f,
+	0x72, 0x6b, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x77, 0x6f, 0x72,
+	0x6b, 0x65, 0x72, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x66, 0x75,
+	0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x46, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1a, 0x0a,
+	0x08, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x08, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x72,
+	0x69, 0x6e, 0x67, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0b, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x6e, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a,
+	0x77, 0x68, 0x69, 0x74, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0a, 0x77, 0x68, 0x69, 0x74, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x1e, 0x0a, 0x0a,
+	0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0a, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f,
+	0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x4c, 0x69, 0x6e, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x73,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x73, 0x12,
+	0x22, 0x0a, 0x0c, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x67, 0x69, 0x74, 0x5f, 0x62, 0x6c, 0x61, 0x6d, 0x65,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x67, 0x69, 0x74, 0x42, 0x6c, 0x61, 0x6d, 0x65,
+	0x12, 0x10, 0x0a, 0x03, 0x74, 0x6f, 0x70, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x74,
+	0x6f, 0x70, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x5f, 0x6f, 0x6e,
+	0x6c, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72,
+	0x79, 0x4f, 0x6e, 0x6c, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x6e, 0x73, 0x6f, 0x72, 0x74, 0x65,
+	0x64, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x75, 0x6e, 0x73, 0x6f, 0x72, 0x74, 0x65,
+	0x64, 0x12, 0x2b, 0x0a, 0x11, 0x70, 0x79, 0x74, 0x68, 0x6f, 0x6e, 0x5f, 0x64, 0x6f, 0x63, 0x73,
+	0x74, 0x72, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x70, 0x79,
+	0x74, 0x68, 0x6f, 0x6e, 0x44, 0x6f, 0x63, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x1f,
+	0x0a, 0x0b, 0x73, 0x71, 0x6c, 0x5f, 0x64, 0x69, 0x61, 0x6c, 0x65, 0x63, 0x74, 0x18, 0x0f, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x71, 0x6c, 0x44, 0x69, 0x61, 0x6c, 0x65, 0x63, 0x74, 0x12,
+	0x23, 0x0a, 0x0d, 0x67, 0x6f, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x73,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x67, 0x6f, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74,
+	0x69, 0x76, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x68, 0x65, 0x62, 0x61, 0x6e, 0x67, 0x18,
+	0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x68, 0x65, 0x62, 0x61, 0x6e, 0x67, 0x12, 0x2d,
+	0x0a, 0x12, 0x6c, 0x6f, 0x67, 0x69, 0x63, 0x61, 0x6c, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74,
+	0x69, 0x76, 0x65, 0x73, 0x18, 0x12, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x6c, 0x6f, 0x67, 0x69,
+	0x63, 0x61, 0x6c, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x73, 0x12, 0x21, 0x0a,
+	0x0c, 0x63, 0x67, 0x6f, 0x5f, 0x70, 0x72, 0x65, 0x61, 0x6d, 0x62, 0x6c, 0x65, 0x18, 0x13, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x0b, 0x63, 0x67, 0x6f, 0x50, 0x72, 0x65, 0x61, 0x6d, 0x62, 0x6c, 0x65,
+	0x12, 0x2e, 0x0a, 0x13, 0x74, 0x72, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x65, 0x6d, 0x70,
+	0x74, 0x79, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x14, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x74,
+	0x72, 0x61, 0x69, 0x6c, 0x69, 0x6e, 0x67, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x4c, 0x69, 0x6e, 0x65,
+	0x12, 0x24, 0x0a, 0x0d, 0x67, 0x69, 0x74, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65,
+	0x73, 0x18, 0x15, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x67, 0x69, 0x74, 0x61, 0x74, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67,
+	0x65, 0x73, 0x18, 0x16, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67,
+	0x65, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x5f, 0x6c,
+	0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x73, 0x18, 0x17, 0x20, 0x03, 0x28, 0x09, 0x52, 0x11,
+	0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x4c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65,
+	0x73, 0x12, 0x4d, 0x0a, 0x0d, 0x65, 0x78, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x6d,
+	0x61, 0x70, 0x18, 0x18, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f,
+	0x63, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x2e, 0x45, 0x78, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x0c, 0x65, 0x78, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x61, 0x70,
+	0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x73, 0x18, 0x19, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x09, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x18, 0x1a, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x08, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x12, 0x3a, 0x0a, 0x06, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x73, 0x18, 0x1b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x67, 0x6f, 0x63,
+	0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06,
+	0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x1a, 0x3f, 0x0a, 0x11, 0x45, 0x78, 0x74, 0x65, 0x6e, 0x73,
+	0x69, 0x6f, 0x6e, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x22, 0x53, 0x0a, 0x0b, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x30, 0x0a, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07,
+	0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x7f, 0x0a, 0x15, 0x41, 0x6e, 0x61, 0x6c, 0x79,
+	0x7a, 0x65, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x30, 0x0a, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52,
+	0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x8d, 0x03, 0x0a, 0x0b, 0x4c, 0x69, 0x6e,
+	0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x12,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05,
+	0x62, 0x6c, 0x61, 0x6e, 0x6b, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x62, 0x6c, 0x61,
+	0x6e, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x6c, 0x6f, 0x63, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x04, 0x75, 0x6c, 0x6f, 0x63, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x65, 0x70, 0x72, 0x6f,
+	0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x70, 0x72,
+	0x65, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x74,
+	0x72, 0x69, 0x6e, 0x67, 0x5f, 0x6c, 0x69, 0x74, 0x65, 0x72, 0x61, 0x6c, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0d, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x74, 0x65, 0x72, 0x61,
+	0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
+	0x14, 0x0a, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05,
+	0x62, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74,
+	0x65, 0x72, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x63, 0x68, 0x61, 0x72, 0x61,
+	0x63, 0x74, 0x65, 0x72, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6d, 0x61, 0x78, 0x5f, 0x6c, 0x69, 0x6e,
+	0x65, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d,
+	0x6d, 0x61, 0x78, 0x4c, 0x69, 0x6e, 0x65, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x26, 0x0a,
+	0x0f, 0x61, 0x76, 0x67, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68,
+	0x18, 0x0c, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0d, 0x61, 0x76, 0x67, 0x4c, 0x69, 0x6e, 0x65, 0x4c,
+	0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x69, 0x6e, 0x65, 0x5f, 0x63, 0x6c,
+	0x61, 0x73, 0x73, 0x65, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x6c, 0x69, 0x6e,
+	0x65, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x65, 0x73, 0x22, 0xd3, 0x01, 0x0a, 0x0b, 0x46, 0x69, 0x6c,
+	0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1a, 0x0a, 0x08,
+	0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x61, 0x72, 0x69,
+	0x61, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x61, 0x72, 0x69, 0x61,
+	0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x73, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x04, 0x74, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61,
+	0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x67, 0x65, 0x6e, 0x65, 0x72,
+	0x61, 0x74, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76,
+	0x31, 0x2e, 0x4c, 0x69, 0x6e, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x07, 0x6d,
+	0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x73,
+	0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x73, 0x22, 0x6b,
+	0x0a, 0x09, 0x53, 0x63, 0x61, 0x6e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x70,
+	0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12,
+	0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x74,
+	0x65, 0x67, 0x6f, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x61, 0x74,
+	0x65, 0x67, 0x6f, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6e, 0x6f, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6e, 0x6f, 0x22, 0x95, 0x01, 0x0a, 0x0f,
+	0x4c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12,
+	0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x65,
+	0x78, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0a, 0x65, 0x78, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x66,
+	0x69, 0x6c, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x66, 0x69, 0x6c, 0x65,
+	0x73, 0x12, 0x30, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x4c,
+	0x69, 0x6e, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x73, 0x22, 0x56, 0x0a, 0x0c, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x4d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x30, 0x0a, 0x07, 0x6d, 0x65, 0x74,
+	0x72, 0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x63,
+	0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x6e, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x22, 0xd5, 0x03, 0x0a, 0x0a,
+	0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x0d, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x64, 0x5f, 0x70, 0x61, 0x74,
+	0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x63, 0x61, 0x6e, 0x6e, 0x65, 0x64,
+	0x50, 0x61, 0x74, 0x68, 0x12, 0x2c, 0x0a, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x03, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x2e,
+	0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x05, 0x66, 0x69, 0x6c,
+	0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x5f, 0x6f, 0x6e,
+	0x6c, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72,
+	0x79, 0x4f, 0x6e, 0x6c, 0x79, 0x12, 0x38, 0x0a, 0x09, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67,
+	0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f,
+	0x63, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x4d, 0x65, 0x74,
+	0x72, 0x69, 0x63, 0x73, 0x52, 0x09, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x73, 0x12,
+	0x2d, 0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c,
+	0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x2c,
+	0x0a, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x45,
+	0x72, 0x72, 0x6f, 0x72, 0x52, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x39, 0x0a, 0x06,
+	0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x67,
+	0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
+	0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x5f, 0x6a, 0x73, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x72, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x4a, 0x73, 0x6f, 0x6e, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65,
+	0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x77, 0x0a, 0x11, 0x53, 0x63, 0x61, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2c, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e,
+	0x76, 0x31, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x48, 0x00,
+	0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x2c, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x42, 0x06, 0x0a, 0x04, 0x69, 0x74, 0x65, 0x6d, 0x32, 0xd1, 0x01, 0x0a,
+	0x06, 0x47, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x12, 0x35, 0x0a, 0x04, 0x53, 0x63, 0x61, 0x6e, 0x12,
+	0x16, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x44,
+	0x0a, 0x0a, 0x53, 0x63, 0x61, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x16, 0x2e, 0x67,
+	0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x63, 0x61, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x30, 0x01, 0x12, 0x4a, 0x0a, 0x0e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x7a, 0x65, 0x43,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x20, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x7a, 0x65, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x63, 0x6c, 0x6f,
+	0x63, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x6e, 0x65, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
+	0x42, 0x3e, 0x5a, 0x3c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x7a,
+	0x68, 0x69, 0x7a, 0x68, 0x69, 0x78, 0x69, 0x6f, 0x6e, 0x67, 0x78, 0x75, 0x77, 0x65, 0x69, 0x2f,
+	0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x67,
+	0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x76, 0x31, 0x3b, 0x67, 0x6f, 0x63, 0x6c, 0x6f, 0x63, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+})
+
+var (
+	file_pkg_api_goclocv1_gocloc_proto_rawDescOnce sync.Once
+	file_pkg_api_goclocv1_gocloc_proto_rawDescData []byte
+)
+
+func file_pkg_api_goclocv1_gocloc_proto_rawDescGZIP() []byte {
+	file_pkg_api_goclocv1_gocloc_proto_rawDescOnce.Do(func() {
+		file_pkg_api_goclocv1_gocloc_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_goclocv1_gocloc_proto_rawDesc), len(file_pkg_api_goclocv1_gocloc_proto_rawDesc)))
+	})
+	return file_pkg_api_goclocv1_gocloc_proto_rawDescData
+}
+
+var file_pkg_api_goclocv1_gocloc_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_pkg_api_goclocv1_gocloc_proto_goTypes = []any{
+	(*ScanOptions)(nil),           // 0: gocloc.v1.ScanOptions
+	(*ScanRequest)(nil),           // 1: gocloc.v1.ScanRequest
+	(*AnalyzeContentRequest)(nil), // 2: gocloc.v1.AnalyzeContentRequest
+	(*LineMetrics)(nil),           // 3: gocloc.v1.LineMetrics
+	(*FileMetrics)(nil),           // 4: gocloc.v1.FileMetrics
+	(*ScanError)(nil),             // 5: gocloc.v1.ScanError
+	(*LanguageMetrics)(nil),       // 6: gocloc.v1.LanguageMetrics
+	(*TotalMetrics)(nil),          // 7: gocloc.v1.TotalMetrics
+	(*ScanResult)(nil),            // 8: gocloc.v1.ScanResult
+	(*ScanStreamMessage)(nil),     // 9: gocloc.v1.ScanStreamMessage
+	nil,                           // 10: gocloc.v1.ScanOptions.ExtensionMapEntry
+	nil,                           // 11: gocloc.v1.ScanOptions.LabelsEntry
+	nil,                           // 12: gocloc.v1.ScanResult.LabelsEntry
+}
+var file_pkg_api_goclocv1_gocloc_proto_depIdxs = []int32{
+	10, // 0: gocloc.v1.ScanOptions.extension_map:type_name -> gocloc.v1.ScanOptions.ExtensionMapEntry
+	11, // 1: gocloc.v1.ScanOptions.labels:type_name -> gocloc.v1.ScanOptions.LabelsEntry
+	0,  // 2: gocloc.v1.ScanRequest.options:type_name -> gocloc.v1.ScanOptions
+	0,  // 3: gocloc.v1.AnalyzeContentRequest.options:type_name -> gocloc.v1.ScanOptions
+	3,  // 4: gocloc.v1.FileMetrics.metrics:type_name -> gocloc.v1.LineMetrics
+	3,  // 5: gocloc.v1.LanguageMetrics.metrics:type_name -> gocloc.v1.LineMetrics
+	3,  // 6: gocloc.v1.TotalMetrics.metrics:type_name -> gocloc.v1.LineMetrics
+	4,  // 7: gocloc.v1.ScanResult.files:type_name -> gocloc.v1.FileMetrics
+	6,  // 8: gocloc.v1.ScanResult.languages:type_name -> gocloc.v1.LanguageMetrics
+	7,  // 9: gocloc.v1.ScanResult.total:type_name -> gocloc.v1.TotalMetrics
+	5,  // 10: gocloc.v1.ScanResult.errors:type_name -> gocloc.v1.ScanError
+	12, // 11: gocloc.v1.ScanResult.labels:type_name -> gocloc.v1.ScanResult.LabelsEntry
+	4,  // 12: gocloc.v1.ScanStreamMessage.file:type_name -> gocloc.v1.FileMetrics
+	5,  // 13: gocloc.v1.ScanStreamMessage.error:type_name -> gocloc.v1.ScanError
+	1,  // 14: gocloc.v1.Gocloc.Scan:input_type -> gocloc.v1.ScanRequest
+	1,  // 15: gocloc.v1.Gocloc.ScanStream:input_type -> gocloc.v1.ScanRequest
+	2,  // 16: gocloc.v1.Gocloc.AnalyzeContent:input_type -> gocloc.v1.AnalyzeContentRequest
+	8,  // 17: gocloc.v1.Gocloc.Scan:output_type -> gocloc.v1.ScanResult
+	9,  // 18: gocloc.v1.Gocloc.ScanStream:output_type -> gocloc.v1.ScanStreamMessage
+	3,  // 19: gocloc.v1.Gocloc.AnalyzeContent:output_type -> gocloc.v1.LineMetrics
+	17, // [17:20] is the sub-list for method output_type
+	14, // [14:17] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_goclocv1_gocloc_proto_init() }
+func file_pkg_api_goclocv1_gocloc_proto_init() {
+	if File_pkg_api_goclocv1_gocloc_proto != nil {
+		return
+	}
+	file_pkg_api_goclocv1_gocloc_proto_msgTypes[9].OneofWrappers = []any{
+		(*ScanStreamMessage_File)(nil),
+		(*ScanStreamMessage_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_goclocv1_gocloc_proto_rawDesc), len(file_pkg_api_goclocv1_gocloc_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_goclocv1_gocloc_proto_goTypes,
+		DependencyIndexes: file_pkg_api_goclocv1_gocloc_proto_depIdxs,
+		MessageInfos:      file_pkg_api_goclocv1_gocloc_proto_msgTypes,
+	}.Build()
+	File_pkg_api_goclocv1_gocloc_proto = out.File
+	file_pkg_api_goclocv1_gocloc_proto_goTypes = nil
+	file_pkg_api_goclocv1_gocloc_proto_depIdxs = nil
+}
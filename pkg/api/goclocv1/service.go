@@ -0,0 +1,161 @@
+package goclocv1
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ServiceName 是 gRPC 服务的完整名称。
+const ServiceName = "gocloc.v1.Gocloc"
+
+// 各方法的完整路径。
+const (
+	ScanMethod           = "/" + ServiceName + "/Scan"
+	ScanStreamMethod     = "/" + ServiceName + "/ScanStream"
+	AnalyzeContentMethod = "/" + ServiceName + "/AnalyzeContent"
+)
+
+// GoclocServer 是 gocloc.v1.Gocloc 服务的服务端接口。
+type GoclocServer interface {
+	Scan(ctx context.Context, request *ScanRequest) (*ScanResult, error)
+	ScanStream(request *ScanRequest, stream ScanStreamServer) error
+	AnalyzeContent(ctx context.Context, request *AnalyzeContentRequest) (*LineMetrics, error)
+}
+
+// ScanStreamServer 是 ScanStream 服务端的发送流。
+type ScanStreamServer interface {
+	Send(message *ScanStreamMessage) error
+	grpc.ServerStream
+}
+
+// RegisterGoclocServer 把服务实现注册到 gRPC 服务器。
+func RegisterGoclocServer(registrar grpc.ServiceRegistrar, server GoclocServer) {
+	registrar.RegisterService(&serviceDesc, server)
+}
+
+// serviceDesc 是 gocloc.v1.Gocloc 的服务描述，与 gocloc.proto 中的定义一致。
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*GoclocServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Scan", Handler: scanHandler},
+		{MethodName: "AnalyzeContent", Handler: analyzeContentHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "ScanStream", Handler: scanStreamHandler, ServerStreams: true},
+	},
+	Metadata: "pkg/api/goclocv1/gocloc.proto",
+}
+
+func scanHandler(server any, ctx context.Context, decode func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	request := new(ScanRequest)
+	if err := decode(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return server.(GoclocServer).Scan(ctx, request)
+	}
+	handler := func(ctx context.Context, request any) (any, error) {
+		return server.(GoclocServer).Scan(ctx, request.(*ScanRequest))
+	}
+	return interceptor(ctx, request, &grpc.UnaryServerInfo{Server: server, FullMethod: ScanMethod}, handler)
+}
+
+func analyzeContentHandler(server any, ctx context.Context, decode func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	request := new(AnalyzeContentRequest)
+	if err := decode(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return server.(GoclocServer).AnalyzeContent(ctx, request)
+	}
+	handler := func(ctx context.Context, request any) (any, error) {
+		return server.(GoclocServer).AnalyzeContent(ctx, request.(*AnalyzeContentRequest))
+	}
+	return interceptor(ctx, request, &grpc.UnaryServerInfo{Server: server, FullMethod: AnalyzeContentMethod}, handler)
+}
+
+func scanStreamHandler(server any, stream grpc.ServerStream) error {
+	request := new(ScanRequest)
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+	return server.(GoclocServer).ScanStream(request, &scanStreamServer{stream})
+}
+
+// scanStreamServer 把 grpc.ServerStream 包装为 ScanStreamServer。
+type scanStreamServer struct {
+	grpc.ServerStream
+}
+
+func (s *scanStreamServer) Send(message *ScanStreamMessage) error {
+	return s.ServerStream.SendMsg(message)
+}
+
+// GoclocClient 是 gocloc.v1.Gocloc 服务的客户端。
+type GoclocClient interface {
+	Scan(ctx context.Context, request *ScanRequest, options ...grpc.CallOption) (*ScanResult, error)
+	ScanStream(ctx context.Context, request *ScanRequest, options ...grpc.CallOption) (ScanStreamClient, error)
+	AnalyzeContent(ctx context.Context, request *AnalyzeContentRequest, options ...grpc.CallOption) (*LineMetrics, error)
+}
+
+// ScanStreamClient 是 ScanStream 客户端的接收流，Recv 在服务端推送完毕后返回 io.EOF。
+type ScanStreamClient interface {
+	Recv() (*ScanStreamMessage, error)
+	grpc.ClientStream
+}
+
+// NewGoclocClient 基于已建立的连接创建客户端。
+func NewGoclocClient(conn grpc.ClientConnInterface) GoclocClient {
+	return &goclocClient{conn: conn}
+}
+
+// goclocClient 实现 GoclocClient。
+type goclocClient struct {
+	conn grpc.ClientConnInterface
+}
+
+func (c *goclocClient) Scan(ctx context.Context, request *ScanRequest, options ...grpc.CallOption) (*ScanResult, error) {
+	result := new(ScanResult)
+	if err := c.conn.Invoke(ctx, ScanMethod, request, result, options...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *goclocClient) ScanStream(ctx context.Context, request *ScanRequest, options ...grpc.CallOption) (ScanStreamClient, error) {
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[0], ScanStreamMethod, options...)
+	if err != nil {
+		return nil, err
+	}
+	client := &scanStreamClient{stream}
+	if err := client.ClientStream.SendMsg(request); err != nil {
+		return nil, err
+	}
+	if err := client.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (c *goclocClient) AnalyzeContent(ctx context.Context, request *AnalyzeContentRequest, options ...grpc.CallOption) (*LineMetrics, error) {
+	metrics := new(LineMetrics)
+	if err := c.conn.Invoke(ctx, AnalyzeContentMethod, request, metrics, options...); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// scanStreamClient 把 grpc.ClientStream 包装为 ScanStreamClient。
+type scanStreamClient struct {
+	grpc.ClientStream
+}
+
+func (c *scanStreamClient) Recv() (*ScanStreamMessage, error) {
+	message := new(ScanStreamMessage)
+	if err := c.ClientStream.RecvMsg(message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
@@ -0,0 +1,662 @@
+// Package gocloc 是 gocloc 的公开库 API，供其他 Go 程序直接嵌入扫描能力而无需调用二进制。
+//
+// 稳定性约定：
+// - 本包导出的函数、Options 字段与类型别名遵循语义化版本，次版本只做向后兼容的新增
+// - 结果类型是 internal/model 的别名，其 JSON 字段与 scan --format json 的输出保持一致
+// - internal/ 下的包不属于公开 API，可能随时调整
+package gocloc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/glob"
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/internal/report"
+	"github.com/zhizhixiongxuwei/gocloc/internal/scanner"
+	"github.com/zhizhixiongxuwei/gocloc/internal/vcs"
+)
+
+// 扫描结果相关类型，字段含义见各类型的文档。
+type (
+	// ScanResult 是一次扫描的完整结果。
+	ScanResult = model.ScanResult
+	// FileMetrics 是单文件扫描结果。
+	FileMetrics = model.FileMetrics
+	// DiscoveredFile 是 ListFiles 列出的待分析文件。
+	DiscoveredFile = model.DiscoveredFile
+	// LanguageMetrics 是单语言汇总结果。
+	LanguageMetrics = model.LanguageMetrics
+	// VariantMetrics 是语言内子类别的汇总结果。
+	VariantMetrics = model.VariantMetrics
+	// TotalMetrics 是全局汇总结果。
+	TotalMetrics = model.TotalMetrics
+	// LineMetrics 是行级统计指标。
+	LineMetrics = model.LineMetrics
+	// Ratios 是派生比例指标。
+	Ratios = model.Ratios
+	// TestSplit 是生产代码与测试代码的拆分汇总。
+	TestSplit = model.TestSplit
+	// ScanError 是单文件扫描失败记录，实现 error 接口，可用 errors.Is 匹配 ErrOpen 等分类哨兵或 fs.ErrPermission 等底层错误。
+	ScanError = model.ScanError
+	// ErrorCategory 是扫描错误分类。
+	ErrorCategory = model.ErrorCategory
+	// FileRanking 是大文件榜单。
+	FileRanking = model.FileRanking
+	// DuplicationReport 是重复代码检测结果。
+	DuplicationReport = model.DuplicationReport
+	// ScriptReport 是 shebang 与可执行脚本盘点结果。
+	ScriptReport = model.ScriptReport
+	// SizeDistribution 是单文件行数分布。
+	SizeDistribution = model.SizeDistribution
+	// WhitespaceMetrics 是缩进与空白风格统计。
+	WhitespaceMetrics = model.WhitespaceMetrics
+	// GitMetrics 是 git blame 补充信息。
+	GitMetrics = model.GitMetrics
+	// DiffResult 是两次扫描结果之间的差值，由 Diff 计算。
+	DiffResult = model.DiffResult
+	// FileDiff 是单个文件的变化。
+	FileDiff = model.FileDiff
+	// LanguageDiff 是单个语言汇总的变化。
+	LanguageDiff = model.LanguageDiff
+	// DiffStatus 是文件或语言的变化类型（added/removed/modified）。
+	DiffStatus = model.DiffStatus
+	// Hooks 是扫描生命周期回调，并发约定见其文档。
+	Hooks = scanner.Hooks
+	// Metrics 是扫描计数指标接收方（文件数、字节数、单文件耗时）。
+	Metrics = scanner.Metrics
+	// LanguageObserver 是 Metrics 的可选扩展，按语言上报单文件字节数与分析耗时。
+	LanguageObserver = scanner.LanguageObserver
+	// ExpvarMetrics 是基于 expvar 的默认 Metrics 实现。
+	ExpvarMetrics = scanner.ExpvarMetrics
+	// Cache 是单文件结果缓存，命中时跳过文件读取与分析。
+	Cache = scanner.Cache
+	// CacheKey 以本地路径、大小与修改时间标识文件版本。
+	CacheKey = scanner.CacheKey
+	// MemoryCache 是进程内的 Cache 实现。
+	MemoryCache = scanner.MemoryCache
+	// MemoryCacheStats 是 MemoryCache 的命中统计。
+	MemoryCacheStats = scanner.MemoryCacheStats
+	// ContentCache 是按内容哈希的分析结果缓存，可持久化到目录、HTTP 或 S3，跨进程与机器复用。
+	ContentCache = scanner.ContentCache
+	// ContentCacheStats 是 ContentCache 的命中统计。
+	ContentCacheStats = scanner.ContentCacheStats
+	// ContentStore 是 ContentCache 的存储后端，可自行实现以接入其他存储。
+	ContentStore = scanner.ContentStore
+	// Checkpoint 是长时间扫描的检查点，中断后可以从中恢复。
+	Checkpoint = scanner.Checkpoint
+	// Walker 是文件发现来源，实现后可扫描 git 树、归档等非文件系统来源。
+	Walker = scanner.Walker
+	// Entry 是 Walker 发现的一个文件。
+	Entry = scanner.Entry
+	// LanguageDefinition 是自定义语言的声明式定义。
+	LanguageDefinition = languages.LanguageDefinition
+	// BlockCommentPair 是自定义语言的块注释起止符。
+	BlockCommentPair = languages.BlockCommentPair
+	// PluginDefinition 是外部分析器插件定义，协议见 languages.PluginAnalyzer。
+	PluginDefinition = languages.PluginDefinition
+	// Language 描述一个内置语言及其文件后缀与语法能力。
+	Language = languages.LanguageDescriptor
+	// Capabilities 描述分析器支持的注释与字符串语法。
+	Capabilities = languages.Capabilities
+	// Reporter 是一种扫描结果输出格式，注册到 ReporterRegistry 后可按名称选择。
+	Reporter = report.Reporter
+	// ReporterTraits 描述输出格式的特性，Reporter 可以通过 Traits() 方法声明。
+	ReporterTraits = report.Traits
+	// ReporterRegistry 管理输出格式的注册与按名称查找。
+	ReporterRegistry = report.Registry
+)
+
+// 扫描错误分类，见 ScanError.Category。
+const (
+	ErrorCategoryOpen     = model.ErrorCategoryOpen
+	ErrorCategoryRead     = model.ErrorCategoryRead
+	ErrorCategoryDecode   = model.ErrorCategoryDecode
+	ErrorCategoryTimeout  = model.ErrorCategoryTimeout
+	ErrorCategoryOversize = model.ErrorCategoryOversize
+)
+
+// 扫描错误分类对应的哨兵错误。
+var (
+	ErrOpen     = model.ErrOpen
+	ErrRead     = model.ErrRead
+	ErrDecode   = model.ErrDecode
+	ErrTimeout  = model.ErrTimeout
+	ErrOversize = model.ErrOversize
+)
+
+// Options 描述一次扫描的全部可选行为，零值即 scan 命令的默认行为。
+type Options struct {
+	// Workers 为并发 worker 数量，<=0 时使用 CPU 核心数。
+	Workers int
+	// CountFunctions 统计函数/方法定义数量。
+	CountFunctions bool
+	// Annotate 为每个文件记录逐行分类。
+	Annotate bool
+	// StringLiteralLines 把只包含字符串字面量内容的行计入 StringLiteral。
+	StringLiteralLines bool
+	// PythonDocstrings 把 Python 模块、类与函数体开头的 docstring 计为注释而非代码。
+	PythonDocstrings bool
+	// SQLDialect 为 SQL 方言：auto（默认，按语法特征判断）、ansi、mysql、postgres、tsql 或 oracle。
+	SQLDialect string
+	// GoDirectives 为 Go 编译指令行（//go:build、//go:generate 等）的计入方式：
+	// comment（默认）、directive（计入 Preprocessor）或 code。
+	GoDirectives string
+	// Shebang 为 Python、Ruby、JavaScript、TypeScript 与自定义语言文件首行 shebang（#!）的计入方式：
+	// comment（默认）、directive（计入 Preprocessor）或 code。
+	Shebang string
+	// LogicalDirectives 把 C/C++ 以反斜杠续行的多行预处理指令计为一条 Preprocessor，
+	// 续行仍计入 Total，并在逐行标注中标记为 preprocessor。
+	LogicalDirectives bool
+	// CgoPreamble 把 Go 文件中紧邻 import "C" 之前的注释块（cgo 前导代码）按 C/C++ 分析：
+	// 这些行计入文件的 Metrics.Embedded 与 C/C++ 语言汇总，而不是 Go 注释。
+	CgoPreamble bool
+	// TrailingEmptyLine 把文件末尾换行符之后的空内容计为一个空白行（与编辑器行号一致）；
+	// 默认与 cloc、tokei 一致，末尾换行符只结束最后一行。
+	TrailingEmptyLine bool
+	// WhitespaceStats 统计缩进风格、最大缩进宽度与行尾空白。
+	WhitespaceStats bool
+	// DetectDuplicates 开启跨文件重复代码检测。
+	DetectDuplicates bool
+	// DuplicateWindow 为判定重复的最小连续代码行数，<=0 时使用默认值。
+	DuplicateWindow int
+	// ScriptStats 统计 shebang 与可执行权限位。
+	ScriptStats bool
+	// SizeDistribution 统计按语言的单文件行数分布。
+	SizeDistribution bool
+	// GitBlame 补充 git blame 信息，需要本机安装 git。
+	GitBlame bool
+	// Top 大于 0 时在结果中附带前 N 个大文件榜单。
+	Top int
+	// Hooks 为扫描生命周期回调（发现文件、分析完成、出错），可用于进度展示或提前过滤。
+	Hooks Hooks
+	// LanguageDefinitions 为额外的自定义语言，与内置语言同名时替换内置分析器。
+	LanguageDefinitions []LanguageDefinition
+	// Logger 接收扫描诊断日志，为 nil 时丢弃；report 相关函数的日志通过 SetReportLogger 配置。
+	Logger *slog.Logger
+	// Plugins 为外部分析器插件，在自定义语言之后注册，同名时替换已有分析器。
+	Plugins []PluginDefinition
+	// Metrics 接收计数指标，为 nil 时不记录；可使用 NewExpvarMetrics 发布到 /debug/vars。
+	Metrics Metrics
+	// Excludes 为排除的路径通配（相对扫描根目录、以 / 分隔，支持 **），匹配文件本身或其上级目录时跳过。
+	Excludes []string
+	// Languages 非空时只统计列出的语言（不区分大小写）。
+	Languages []string
+	// DisabledLanguages 为本次扫描禁用的语言（不区分大小写），其文件不会被统计。
+	DisabledLanguages []string
+	// ExtensionOverrides 把后缀映射到指定语言（例如 ".inc" -> "C/C++"），在禁用语言之后应用。
+	ExtensionOverrides map[string]string
+	// Cache 为单文件结果缓存，为 nil 时不缓存；同一个 Cache 只应在分析选项相同的扫描器之间共享。
+	Cache Cache
+	// MmapThreshold 大于 0 时，不小于该字节数的本地文件通过内存映射读取（不支持的平台回退到流式读取），为 0 时不使用。
+	MmapThreshold int64
+	// AdaptiveWorkers 开启自适应并发：Workers 作为上限，扫描过程中按 CPU 利用率（I/O 等待程度）调整同时分析的文件数。
+	AdaptiveWorkers bool
+	// ContentCache 为按内容哈希的分析结果缓存，为 nil 时不使用；键包含分析选项，可在不同配置的扫描器之间共享。
+	ContentCache *ContentCache
+	// SummaryOnly 只保留语言汇总、总计、测试拆分与错误，不保留文件明细（Files 为空），
+	// 扫描数百万个文件时内存占用只与语言数量相关；重复检测、脚本统计与大文件榜单在此模式下不生效。
+	SummaryOnly bool
+	// UnsortedFiles 跳过扫描结束后按路径对 Files 与 Errors 的排序，二者保持分析完成的顺序；
+	// 只对单个根路径的扫描生效（多个根路径的合并结果总是排序）。
+	UnsortedFiles bool
+	// Gitattributes 读取扫描目录中的 .gitattributes，按 linguist-* 属性调整统计（与 GitHub 的语言统计一致）：
+	// linguist-vendored 与 linguist-documentation 的文件不参与扫描，linguist-generated 的文件计入 ScanResult.Generated
+	// 而不是语言统计与总计，linguist-language 指定的语言优先于按后缀识别。
+	Gitattributes bool
+	// Gitignore 跳过扫描目录（含子目录）中 .gitignore 忽略的文件与目录，与 git 的规则一致；
+	// 扫描根目录以上的 .gitignore 与全局排除文件不读取。
+	Gitignore bool
+	// Checkpoint 非 nil 时定期把已完成的文件结果写入检查点，并直接采用检查点中已完成文件的结果，
+	// 中断的扫描用同一个检查点（OpenCheckpoint 的 resume 为 true）重新执行即可继续；流式扫描不使用检查点。
+	Checkpoint *Checkpoint
+	// MaxReadBytesPerSec 大于 0 时限制所有 worker 合计的文件读取速率（字节/秒，令牌桶），
+	// 避免在共享存储上的定时扫描挤占其他负载；开启后不使用内存映射读取。
+	MaxReadBytesPerSec int64
+	// IOConcurrency 大于 0 时限制同时进行中的文件读取数量，与 Workers 独立，分析仍按 Workers 并行。
+	IOConcurrency int
+	// FollowLinks 进入指向目录的符号链接与 Windows 目录联接（junction），结果路径保持链接所在位置；
+	// 指回根目录或已进入目录之内的链接会被跳过，避免循环与重复统计。为 false 时这类链接被跳过。
+	FollowLinks bool
+	// Packages 开启按包汇总（ScanResult.Packages）：go.mod、package.json、Cargo.toml、pom.xml 与 setup.py 所在目录为包边界，
+	// 文件归入路径上最近的包，不属于任何包的文件归入根目录 "."，便于 monorepo 按可部署单元查看规模。
+	Packages bool
+	// CodeOwners 为 CODEOWNERS 文件路径，非空时为每个文件填写 FileMetrics.Owners 并按所有者汇总（ScanResult.Owners），
+	// 规则按 GitHub 的语义相对扫描根目录匹配；FindCodeOwners 可以在仓库根目录下查找该文件。
+	CodeOwners string
+	// Labels 为附加到结果的标签（ScanResult.Labels，如 team=payments），不影响统计，供下游按团队、环境等维度切分。
+	Labels map[string]string
+	// MaxFileSize 大于 0 时，超过该字节数的文件不被读取，记为 ErrorCategoryOversize 类错误。
+	MaxFileSize int64
+	// FileTimeout 大于 0 时限制单个文件的分析时间，超时的文件记为 ErrorCategoryTimeout 类错误；
+	// 超时在分析器读取内容时检查，按路径分析的外部插件不受限制。
+	FileTimeout time.Duration
+}
+
+// Scanner 是可复用的扫描器，并发安全，可对多个路径重复调用 Scan。
+type Scanner struct {
+	registry *languages.Registry
+	service  *scanner.Service
+	options  Options
+	err      error
+}
+
+// NewScanner 按选项创建扫描器。
+// 每个扫描器持有独立的语言注册中心，禁用语言与后缀覆盖只作用于该扫描器，可在并发请求间安全使用；
+// 覆盖设置无效（例如引用了未知语言）时，扫描器的所有方法都返回该错误，也可通过 Err 提前检查。
+func NewScanner(options Options) *Scanner {
+	analyzerOptions := languages.Options{
+		CountFunctions:     options.CountFunctions,
+		Annotate:           options.Annotate,
+		TrackCodeLines:     options.DetectDuplicates,
+		StringLiteralLines: options.StringLiteralLines,
+		WhitespaceStats:    options.WhitespaceStats,
+		PythonDocstrings:   options.PythonDocstrings,
+		LogicalDirectives:  options.LogicalDirectives,
+		CgoPreamble:        options.CgoPreamble,
+		TrailingEmptyLine:  options.TrailingEmptyLine,
+	}
+	dialect, err := languages.ParseSQLDialect(options.SQLDialect)
+	if err != nil {
+		return &Scanner{err: err}
+	}
+	analyzerOptions.SQLDialect = dialect
+	if analyzerOptions.GoDirectives, err = languages.ParseGoDirectives(options.GoDirectives); err != nil {
+		return &Scanner{err: err}
+	}
+	if analyzerOptions.Shebang, err = languages.ParseShebang(options.Shebang); err != nil {
+		return &Scanner{err: err}
+	}
+	registry := languages.NewRegistryWithOptions(analyzerOptions)
+	for _, definition := range options.LanguageDefinitions {
+		registry.Register(&languages.GenericAnalyzer{Definition: definition, Options: analyzerOptions})
+	}
+	for _, definition := range options.Plugins {
+		registry.Register(&languages.PluginAnalyzer{Definition: definition, Options: analyzerOptions})
+	}
+	for _, pattern := range options.Excludes {
+		if err := glob.Validate(pattern); err != nil {
+			return &Scanner{err: fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)}
+		}
+	}
+	registry, err = registry.WithOverrides(languages.RegistryOverrides{
+		EnabledLanguages:  options.Languages,
+		DisabledLanguages: options.DisabledLanguages,
+		Extensions:        options.ExtensionOverrides,
+	})
+	if err != nil {
+		return &Scanner{err: err}
+	}
+	var codeOwners *vcs.CodeOwners
+	if options.CodeOwners != "" {
+		if codeOwners, err = vcs.LoadCodeOwners(options.CodeOwners); err != nil {
+			return &Scanner{err: err}
+		}
+	}
+	service := scanner.NewServiceWithOptions(registry, scanner.Options{
+		Workers:          options.Workers,
+		DetectDuplicates: options.DetectDuplicates,
+		DuplicateWindow:  options.DuplicateWindow,
+		ScriptStats:      options.ScriptStats,
+		SizeDistribution: options.SizeDistribution,
+		GitBlame:         options.GitBlame,
+		Hooks:            options.Hooks,
+		Logger:           options.Logger,
+		Metrics:          options.Metrics,
+		Excludes:         options.Excludes,
+		Cache:            options.Cache,
+		MmapThreshold:    options.MmapThreshold,
+		AdaptiveWorkers:  options.AdaptiveWorkers,
+		SummaryOnly:      options.SummaryOnly,
+		UnsortedFiles:    options.UnsortedFiles,
+		ContentCache:     options.ContentCache,
+		Gitattributes:    options.Gitattributes,
+		Gitignore:        options.Gitignore,
+		Checkpoint:       options.Checkpoint,
+		// 读取限制对 Scanner 的所有扫描共享，同一扫描器上并发的多次扫描合计不超过上限。
+		MaxReadBytesPerSec: options.MaxReadBytesPerSec,
+		IOConcurrency:      options.IOConcurrency,
+		FollowLinks:        options.FollowLinks,
+		Packages:           options.Packages,
+		CodeOwners:         codeOwners,
+		MaxFileSize:        options.MaxFileSize,
+		FileTimeout:        options.FileTimeout,
+	})
+	return &Scanner{registry: registry, service: service, options: options}
+}
+
+// Err 返回创建扫描器时遇到的选项错误。
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Scan 扫描目录或单文件。
+func (s *Scanner) Scan(path string) (ScanResult, error) {
+	return s.ScanContext(context.Background(), path)
+}
+
+// ScanContext 是可取消的 Scan，适合在服务端按请求生命周期扫描。
+func (s *Scanner) ScanContext(ctx context.Context, path string) (ScanResult, error) {
+	if s.err != nil {
+		return ScanResult{}, s.err
+	}
+	result, err := s.service.ScanPathContext(ctx, path)
+	if err != nil {
+		return result, err
+	}
+	s.finish(&result)
+	return result, nil
+}
+
+// ScanWalker 扫描任意 Walker 提供的文件，结果与 Scan 相同。
+func (s *Scanner) ScanWalker(ctx context.Context, walker Walker) (ScanResult, error) {
+	if s.err != nil {
+		return ScanResult{}, s.err
+	}
+	result, err := s.service.ScanWalker(ctx, walker)
+	if err != nil {
+		return result, err
+	}
+	s.finish(&result)
+	return result, nil
+}
+
+// finish 为结果附带 Options.Labels，并在设置了 Top 时附带大文件榜单（只有汇总的结果没有可排序的文件）。
+func (s *Scanner) finish(result *ScanResult) {
+	result.AddLabels(s.options.Labels)
+	if s.options.Top > 0 && !result.SummaryOnly {
+		ranking := model.RankFiles(result.Files, s.options.Top)
+		result.LargestFiles = &ranking
+	}
+}
+
+// ScanStream 以流式方式扫描，每个文件分析完成后立即投递，适合增量消费（进度展示、写入数据库等）。
+// 调用方需要同时消费两个通道直到两者都关闭，或取消 ctx；流式结果不包含 Top 榜单等汇总信息。
+func (s *Scanner) ScanStream(ctx context.Context, path string) (<-chan FileMetrics, <-chan ScanError, error) {
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	return s.service.ScanStream(ctx, path)
+}
+
+// AnalyzeReader 使用扫描器的分析选项（含自定义语言与插件）统计单个内容缓冲区，语言名称不区分大小写。
+func (s *Scanner) AnalyzeReader(language string, reader io.Reader) (LineMetrics, error) {
+	if s.err != nil {
+		return LineMetrics{}, s.err
+	}
+	analyzer, ok := s.registry.AnalyzerForLanguage(language)
+	if !ok {
+		return LineMetrics{}, fmt.Errorf("unsupported language: %s", language)
+	}
+	return analyzer.Analyze(reader)
+}
+
+// ScanReader 把单个内容缓冲区当作名为 name 的文件统计，返回与 Scan 结构相同的结果，
+// 适合编辑器集成或检查生成代码等从标准输入读取内容的场景。language 不区分大小写；
+// 测试文件与子类别按 name 判断，重复检测、脚本统计与 git blame 不适用于内容缓冲区。
+func (s *Scanner) ScanReader(name string, language string, reader io.Reader) (ScanResult, error) {
+	if s.err != nil {
+		return ScanResult{}, s.err
+	}
+	analyzer, ok := s.registry.AnalyzerForLanguage(language)
+	if !ok {
+		return ScanResult{}, fmt.Errorf("unsupported language: %s", language)
+	}
+
+	counter := &countingReader{reader: reader}
+	var metrics LineMetrics
+	var err error
+	if pathAnalyzer, ok := analyzer.(languages.PathAnalyzer); ok {
+		metrics, err = pathAnalyzer.AnalyzePath(name, counter)
+	} else {
+		metrics, err = analyzer.Analyze(counter)
+	}
+	if err != nil {
+		return ScanResult{}, err
+	}
+	metrics.Bytes = counter.bytes
+
+	file := FileMetrics{Path: name, Language: analyzer.Name(), Metrics: metrics}
+	if classifier, ok := analyzer.(languages.TestFileClassifier); ok {
+		file.Test = classifier.IsTestFile(name)
+	}
+	if classifier, ok := analyzer.(languages.VariantClassifier); ok {
+		file.Variant = classifier.Variant(name)
+	}
+
+	result := ScanResult{ScannedPath: name, Files: []FileMetrics{file}, Errors: make([]ScanError, 0)}
+	result.Summarize(model.SummaryOptions{
+		Extensions:       s.registry.ExtensionsForLanguage,
+		SizeDistribution: s.options.SizeDistribution,
+	})
+	if s.options.SummaryOnly {
+		result.Files = make([]FileMetrics, 0)
+		result.SummaryOnly = true
+	}
+	s.finish(&result)
+	return result, nil
+}
+
+// countingReader 统计经过的字节数，用于填充内容缓冲区的 Bytes。
+type countingReader struct {
+	reader io.Reader
+	bytes  int64
+}
+
+func (r *countingReader) Read(buffer []byte) (int, error) {
+	n, err := r.reader.Read(buffer)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// DetectLanguage 按扫描器的语言设置（含自定义语言、插件与覆盖）识别文件语言，规则见 DetectLanguage。
+func (s *Scanner) DetectLanguage(path string, head []byte) (string, bool) {
+	if s.err != nil {
+		return "", false
+	}
+	return s.registry.Detect(path, head)
+}
+
+// DetectLanguage 使用内置语言识别文件语言，依次按后缀、约定文件名（如 Rakefile）、shebang 与 vim/emacs modeline 判断。
+// head 为文件开头的若干字节，为 nil 时只按路径识别；无法识别时返回 false。
+func DetectLanguage(path string, head []byte) (string, bool) {
+	return languages.Detect(path, head)
+}
+
+// AnalyzeReader 按默认选项统计指定语言的内容，适合编辑器、代码评审机器人等已持有文件内容的场景。
+// 不访问文件系统，因此结果中的 Bytes 为 0。
+func AnalyzeReader(language string, reader io.Reader) (LineMetrics, error) {
+	return NewScanner(Options{}).AnalyzeReader(language, reader)
+}
+
+// AnalyzeBytes 是 AnalyzeReader 的字节切片版本，结果中的 Bytes 为内容长度。
+func AnalyzeBytes(language string, content []byte) (LineMetrics, error) {
+	metrics, err := AnalyzeReader(language, bytes.NewReader(content))
+	if err != nil {
+		return metrics, err
+	}
+	metrics.Bytes = int64(len(content))
+	return metrics, nil
+}
+
+// ScanPaths 依次扫描多个根路径并用 ScanResult.Merge 合并结果。
+// 多于一个根路径时，文件与错误路径以根路径（按调用方输入，/ 分隔）为前缀，避免不同根下的同名文件被去重；
+// 合并后的结果不包含重复检测报告。
+func (s *Scanner) ScanPaths(paths ...string) (ScanResult, error) {
+	if len(paths) == 0 {
+		return ScanResult{}, errors.New("scan path is empty")
+	}
+	if len(paths) == 1 {
+		return s.Scan(paths[0])
+	}
+	if s.err != nil {
+		return ScanResult{}, s.err
+	}
+
+	var merged ScanResult
+	for _, path := range paths {
+		result, err := s.service.ScanPath(path)
+		if err != nil {
+			return merged, err
+		}
+		prefixPaths(&result, rootPrefix(path))
+		merged.Merge(result)
+	}
+	s.finish(&merged)
+	return merged, nil
+}
+
+// ListFiles 列出扫描这些根路径时会被分析的文件及其语言，过滤规则（后缀、语言开关、Excludes、Hooks）与扫描一致，
+// 但不读取文件内容，适合在长时间扫描前验证过滤规则。多于一个根路径时路径前缀规则与 ScanPaths 相同。
+func (s *Scanner) ListFiles(ctx context.Context, paths ...string) ([]DiscoveredFile, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("scan path is empty")
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	files := make([]DiscoveredFile, 0)
+	for _, path := range paths {
+		listed, err := s.service.ListPath(ctx, path)
+		if len(paths) > 1 {
+			prefix := rootPrefix(path)
+			for index := range listed {
+				listed[index].Path = prefix + listed[index].Path
+			}
+		}
+		files = append(files, listed...)
+		if err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+// rootPrefix 返回根路径对应的展示前缀：目录取其自身，单文件取其所在目录，当前目录为空。
+func rootPrefix(path string) string {
+	prefix := filepath.Clean(strings.TrimSpace(path))
+	if info, err := os.Stat(prefix); err == nil && !info.IsDir() {
+		prefix = filepath.Dir(prefix)
+	}
+	if prefix == "." {
+		return ""
+	}
+	return filepath.ToSlash(prefix) + "/"
+}
+
+// prefixPaths 为结果中的文件、错误、跳过的特殊文件与包目录路径添加前缀。
+func prefixPaths(result *ScanResult, prefix string) {
+	if prefix == "" {
+		return
+	}
+	for index := range result.Files {
+		result.Files[index].Path = prefix + result.Files[index].Path
+	}
+	for index := range result.Errors {
+		result.Errors[index].Path = prefix + result.Errors[index].Path
+	}
+	for index := range result.Skipped {
+		result.Skipped[index].Path = prefix + result.Skipped[index].Path
+	}
+	for index := range result.Packages {
+		result.Packages[index].Path = path.Join(prefix, result.Packages[index].Path)
+	}
+}
+
+// NewMemoryCache 创建空的进程内单文件结果缓存，适合在长期运行的进程中跨扫描复用。
+func NewMemoryCache() *MemoryCache {
+	return scanner.NewMemoryCache()
+}
+
+// NewContentCache 创建使用 store 持久化的按内容缓存。
+func NewContentCache(store ContentStore) *ContentCache {
+	return scanner.NewContentCache(store)
+}
+
+// OpenContentStore 按位置创建缓存后端：本地目录、http(s):// 地址（GET/PUT），或 s3://bucket/prefix（凭证取自 AWS_* 环境变量）。
+func OpenContentStore(location string) (ContentStore, error) {
+	return scanner.OpenContentStore(location)
+}
+
+// FindCodeOwners 按 GitHub 的顺序（.github/CODEOWNERS、CODEOWNERS、docs/CODEOWNERS）在仓库根目录下查找 CODEOWNERS。
+func FindCodeOwners(root string) (string, bool) {
+	return vcs.FindCodeOwners(root)
+}
+
+// OpenCheckpoint 打开检查点文件：resume 为 true 时读回已完成的文件结果继续扫描，为 false 时清空后重新开始。
+// 全部扫描成功后调用 Checkpoint.Remove 删除检查点，失败时调用 Checkpoint.Close 保留以便恢复。
+func OpenCheckpoint(path string, resume bool) (*Checkpoint, error) {
+	return scanner.OpenCheckpoint(path, resume)
+}
+
+// NewExpvarMetrics 创建以 prefix 为前缀、发布到 expvar 的计数指标，同名前缀重复调用时共享同一组变量。
+func NewExpvarMetrics(prefix string) *ExpvarMetrics {
+	return scanner.NewExpvarMetrics(prefix)
+}
+
+// Diff 计算 head 相对 base 的差值，只包含发生变化的文件与语言。
+func Diff(base ScanResult, head ScanResult) DiffResult {
+	return model.NewDiffResult(base, head)
+}
+
+// Scan 是 NewScanner(options).Scan(path) 的便捷写法。
+func Scan(path string, options Options) (ScanResult, error) {
+	return NewScanner(options).Scan(path)
+}
+
+// LoadLanguageDefinitions 从 YAML（.yaml/.yml）或 JSON 文件加载并校验自定义语言定义。
+func LoadLanguageDefinitions(path string) ([]LanguageDefinition, error) {
+	return languages.LoadLanguageDefinitions(path)
+}
+
+// ParsePluginSpec 解析 NAME:EXT[,EXT...]:COMMAND 格式的插件描述。
+func ParsePluginSpec(spec string) (PluginDefinition, error) {
+	return languages.ParsePluginSpec(spec)
+}
+
+// Languages 返回所有内置语言及其后缀，按语言名称排序。
+func Languages() []Language {
+	return languages.NewRegistry().Languages()
+}
+
+// WriteTable 以 scan 命令的 table 格式输出结果。
+func WriteTable(writer io.Writer, result ScanResult) error {
+	return report.PrintTable(writer, result)
+}
+
+// WriteJSON 以 scan 命令的 JSON 格式输出结果。
+func WriteJSON(writer io.Writer, result ScanResult) error {
+	return report.PrintJSON(writer, result)
+}
+
+// NewReporterRegistry 返回包含全部内置输出格式（table、json、pdf、d3、folded、treemap、teamcity）的注册中心，
+// 可以再注册自定义的 Reporter。
+func NewReporterRegistry() *ReporterRegistry {
+	return report.NewRegistry()
+}
+
+// SetReportLogger 设置 Save/Load 等结果读写函数的日志输出（进程级），传 nil 恢复为丢弃。
+func SetReportLogger(logger *slog.Logger) {
+	report.SetLogger(logger)
+}
+
+// Save 将结果以带 schema_version 的 JSON 写入文件，路径以 .gz 结尾时使用 gzip 压缩。
+func Save(path string, result ScanResult) error {
+	return report.Save(path, result)
+}
+
+// Load 读取 Save 或旧版本 gocloc 导出的结果，自动识别 gzip 并校验 schema 版本。
+func Load(path string) (ScanResult, error) {
+	return report.Load(path)
+}
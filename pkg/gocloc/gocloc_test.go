@@ -0,0 +1,139 @@
+package gocloc
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestScan 验证公开 API 可以完成扫描、附带榜单并输出结果。
+func TestScan(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("write fixture file failed: %v", err)
+	}
+
+	result, err := Scan(tempDir, Options{Workers: 1, CountFunctions: true, Top: 1})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if result.Total.Files != 1 || result.Total.Code != 2 || result.Total.Functions != 1 {
+		t.Fatalf("unexpected total: %+v", result.Total)
+	}
+	if result.LargestFiles == nil || len(result.LargestFiles.ByCode) != 1 {
+		t.Fatalf("expected largest files ranking")
+	}
+
+	var output bytes.Buffer
+	if err := WriteJSON(&output, result); err != nil {
+		t.Fatalf("write json failed: %v", err)
+	}
+	if !strings.Contains(output.String(), `"scanned_path"`) {
+		t.Fatalf("unexpected json output: %s", output.String())
+	}
+}
+
+// TestAnalyzeBytes 验证按语言名称统计内存内容，名称不区分大小写。
+func TestAnalyzeBytes(t *testing.T) {
+	metrics, err := AnalyzeBytes("python", []byte("# comment\nx = 1\n\n"))
+	if err != nil {
+		t.Fatalf("analyze bytes failed: %v", err)
+	}
+	if metrics.Code != 1 || metrics.Comment != 1 || metrics.Blank != 1 || metrics.Bytes != 17 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+
+	if _, err := AnalyzeReader("Cobol", strings.NewReader("")); err == nil {
+		t.Fatalf("expected unsupported language error")
+	}
+}
+
+// TestScanReader 验证内容缓冲区按名称判断测试文件，并生成带汇总的完整结果。
+func TestScanReader(t *testing.T) {
+	result, err := NewScanner(Options{Top: 1}).ScanReader("pkg/util_test.go", "go", strings.NewReader("package pkg\n\n// doc\nfunc TestX() {}\n"))
+	if err != nil {
+		t.Fatalf("scan reader failed: %v", err)
+	}
+	if len(result.Files) != 1 || !result.Files[0].Test || result.Files[0].Metrics.Bytes != 36 {
+		t.Fatalf("unexpected file: %+v", result.Files)
+	}
+	if result.Total.Code != 2 || result.TestSplit.Test.Code != 2 || len(result.Languages) != 1 || result.LargestFiles == nil {
+		t.Fatalf("unexpected summary: %+v", result.Total)
+	}
+	if result.Languages[0].Extensions[0] != ".go" {
+		t.Fatalf("unexpected extensions: %v", result.Languages[0].Extensions)
+	}
+
+	if _, err := NewScanner(Options{}).ScanReader("-", "Cobol", strings.NewReader("")); err == nil {
+		t.Fatalf("expected unsupported language error")
+	}
+}
+
+// TestScanPaths 验证多根目录扫描会为路径加前缀并合并汇总。
+func TestScanPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, root := range []string{"api", "worker"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, root), 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+			t.Fatalf("write fixture file failed: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "api", "util.py"), []byte("x = 1\n"), 0o644); err != nil {
+		t.Fatalf("write fixture file failed: %v", err)
+	}
+
+	scanner := NewScanner(Options{Workers: 1, Top: 2, Labels: map[string]string{"team": "core"}})
+	result, err := scanner.ScanPaths(filepath.Join(tempDir, "api"), filepath.Join(tempDir, "worker"))
+	if err != nil {
+		t.Fatalf("scan paths failed: %v", err)
+	}
+
+	if result.Total.Files != 3 || result.Total.Code != 3 || len(result.Languages) != 2 {
+		t.Fatalf("unexpected merged result: %+v", result.Total)
+	}
+	if !strings.HasSuffix(result.Files[0].Path, "api/main.go") || !strings.HasSuffix(result.Files[2].Path, "worker/main.go") {
+		t.Fatalf("unexpected merged paths: %s, %s", result.Files[0].Path, result.Files[2].Path)
+	}
+	if result.Languages[0].Language != "Go" || result.Languages[0].Files != 2 || len(result.Languages[0].Extensions) != 1 {
+		t.Fatalf("unexpected go summary: %+v", result.Languages[0])
+	}
+	if result.LargestFiles == nil || len(result.LargestFiles.ByCode) != 2 {
+		t.Fatalf("expected ranking over merged files")
+	}
+	if len(result.Labels) != 1 || result.Labels["team"] != "core" {
+		t.Fatalf("unexpected labels: %v", result.Labels)
+	}
+
+	// 同一结果再次合并时路径去重，汇总不变。
+	duplicate := result
+	duplicate.Merge(result)
+	if duplicate.Total.Files != 3 {
+		t.Fatalf("expected deduplicated files, got %d", duplicate.Total.Files)
+	}
+
+	// 只汇总模式下多个根路径的汇总直接相加，结果不含文件明细与榜单。
+	summary, err := NewScanner(Options{Workers: 1, SummaryOnly: true}).ScanPaths(filepath.Join(tempDir, "api"), filepath.Join(tempDir, "worker"))
+	if err != nil {
+		t.Fatalf("summary-only scan paths failed: %v", err)
+	}
+	if !summary.SummaryOnly || len(summary.Files) != 0 || summary.LargestFiles != nil {
+		t.Fatalf("expected summary without files: %+v", summary.Files)
+	}
+	if summary.Total.Files != 3 || summary.Total.Code != 3 || summary.Languages[0].Files != 2 || len(summary.Languages[0].Extensions) != 1 || summary.TestSplit.Production.Files != 3 {
+		t.Fatalf("unexpected merged summary: %+v", summary)
+	}
+
+	// 文件清单与扫描结果使用同一套过滤规则与路径前缀。
+	files, err := NewScanner(Options{Languages: []string{"go"}}).ListFiles(context.Background(), filepath.Join(tempDir, "api"), filepath.Join(tempDir, "worker"))
+	if err != nil {
+		t.Fatalf("list files failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != result.Files[0].Path || files[1].Path != result.Files[2].Path || files[1].Language != "Go" {
+		t.Fatalf("unexpected file list: %+v", files)
+	}
+}
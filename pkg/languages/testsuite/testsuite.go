@@ -0,0 +1,186 @@
+// Package testsuite 提供分析器一致性测试：一组按语法生成的标准用例与 RunConformance 辅助函数，
+// 供第三方分析器与通过 LanguageDefinition 声明的自定义语言验证其行分类符合 gocloc 的约定：
+//
+// - 每个物理行计 1 行，\r\n 与 \n 都是换行符，文件末尾的换行符不产生额外的行，没有换行符的最后一行照常计数，开头的 UTF-8 BOM 被忽略
+// - 只包含空白的行为 blank；块注释中的空白行同样为 comment
+// - 同时包含代码与注释的行同时计入 code 与 comment（mixed）
+// - 块注释与字符串的状态跨行延续，字符串中的注释符号、注释中的引号都不改变状态
+// - 声明支持 shebang 的分析器把首行 #! 计为注释
+//
+// 用法（在第三方分析器的测试中）：
+//
+//	func TestConformance(t *testing.T) {
+//		testsuite.RunConformance(t, &MyAnalyzer{})
+//	}
+//
+// 本包遵循与 pkg/gocloc 相同的稳定性约定，新增用例只会覆盖上述已有约定。
+package testsuite
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/internal/model"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+)
+
+// Analyzer 是待验证的分析器，与 gocloc 内部注册的分析器接口一致。
+// 实现 Capabilities() gocloc.Capabilities 的分析器会额外按其声明的注释与字符串语法生成用例，
+// 否则只验证与语法无关的用例。
+type Analyzer interface {
+	Name() string
+	Analyze(reader io.Reader) (gocloc.LineMetrics, error)
+}
+
+// ForDefinition 返回按自定义语言定义构建的分析器（开启逐行分类），用于验证 .yaml/.json 语言定义。
+func ForDefinition(definition gocloc.LanguageDefinition) Analyzer {
+	return &languages.GenericAnalyzer{Definition: definition, Options: languages.Options{Annotate: true}}
+}
+
+// Fixture 是一个一致性用例：Classes 为 Content 逐行的期望分类（code、comment、blank 或 mixed）。
+type Fixture struct {
+	Name    string
+	Content string
+	Classes []string
+}
+
+// Check 分析 Content 并与期望比较：total/code/comment/blank 必须一致；
+// 分析器填充了 LineClasses 时逐行分类也必须一致。不一致时返回描述差异的错误。
+func (f Fixture) Check(analyzer Analyzer) error {
+	metrics, err := analyzer.Analyze(strings.NewReader(f.Content))
+	if err != nil {
+		return fmt.Errorf("analyze: %w", err)
+	}
+
+	var expected model.LineMetrics
+	for _, class := range f.Classes {
+		expected.Total++
+		switch class {
+		case model.LineClassCode:
+			expected.Code++
+		case model.LineClassComment:
+			expected.Comment++
+		case model.LineClassMixed:
+			expected.Code++
+			expected.Comment++
+		case model.LineClassBlank:
+			expected.Blank++
+		}
+	}
+	if metrics.Total != expected.Total || metrics.Code != expected.Code || metrics.Comment != expected.Comment || metrics.Blank != expected.Blank {
+		return fmt.Errorf("got total=%d code=%d comment=%d blank=%d, want total=%d code=%d comment=%d blank=%d (lines %v)",
+			metrics.Total, metrics.Code, metrics.Comment, metrics.Blank,
+			expected.Total, expected.Code, expected.Comment, expected.Blank, f.Classes)
+	}
+	if metrics.LineClasses != nil && strings.Join(metrics.LineClasses, ",") != strings.Join(f.Classes, ",") {
+		return fmt.Errorf("got line classes %v, want %v", metrics.LineClasses, f.Classes)
+	}
+	return nil
+}
+
+// Fixtures 返回标准用例。capabilities 为 nil 时只包含与语法无关的用例（空白行、换行符、BOM 等）；
+// 否则按其中第一个行注释、第一对块注释与第一个单字符字符串定界符（优先 "）生成注释、嵌套、跨行状态、
+// 字符串中的注释符号与 shebang 用例，未声明的语法对应的用例会被省略。
+func Fixtures(capabilities *gocloc.Capabilities) []Fixture {
+	const (
+		code    = model.LineClassCode
+		comment = model.LineClassComment
+		blank   = model.LineClassBlank
+		mixed   = model.LineClassMixed
+	)
+	fixtures := []Fixture{
+		{Name: "empty", Content: "", Classes: nil},
+		{Name: "code", Content: "value\n  value\n", Classes: []string{code, code}},
+		{Name: "blank lines", Content: "value\n\n   \n\t\nvalue\n", Classes: []string{code, blank, blank, blank, code}},
+		{Name: "no trailing newline", Content: "value\nvalue", Classes: []string{code, code}},
+		{Name: "crlf", Content: "value\r\n\r\nvalue\r\n", Classes: []string{code, blank, code}},
+		{Name: "bom", Content: "\ufeffvalue\n", Classes: []string{code}},
+	}
+	if capabilities == nil {
+		return fixtures
+	}
+
+	quote := fixtureQuote(capabilities.StringDelimiters)
+	if len(capabilities.LineComments) > 0 {
+		line := capabilities.LineComments[0]
+		fixtures = append(fixtures,
+			Fixture{Name: "line comment", Content: line + " note\n    " + line + " note\nvalue " + line + " note\n", Classes: []string{comment, comment, mixed}},
+		)
+		if quote != "" {
+			fixtures = append(fixtures,
+				Fixture{Name: "line comment in string", Content: "value = " + quote + line + " note" + quote + "\n", Classes: []string{code}},
+				Fixture{Name: "quote in line comment", Content: line + " unmatched " + quote + "\nvalue\n", Classes: []string{comment, code}},
+			)
+		}
+	}
+
+	if len(capabilities.BlockComments) > 0 {
+		pair := capabilities.BlockComments[0]
+		fixtures = append(fixtures,
+			Fixture{Name: "block comment", Content: pair.Start + "\nnote\n\n" + pair.End + "\nvalue\n", Classes: []string{comment, comment, comment, comment, code}},
+		)
+		if !capabilities.LineStartBlockComments {
+			fixtures = append(fixtures,
+				Fixture{Name: "inline block comment", Content: pair.Start + " note " + pair.End + "\nvalue " + pair.Start + " note " + pair.End + " value\n", Classes: []string{comment, mixed}},
+				Fixture{Name: "block comment across lines", Content: "value " + pair.Start + " note\nnote " + pair.End + " value\nvalue\n", Classes: []string{mixed, mixed, code}},
+			)
+			if capabilities.NestedComments {
+				fixtures = append(fixtures,
+					Fixture{Name: "nested block comment", Content: pair.Start + " a " + pair.Start + " b " + pair.End + " c\n" + pair.End + "\nvalue\n", Classes: []string{comment, comment, code}},
+				)
+			} else if pair.Start != pair.End {
+				fixtures = append(fixtures,
+					Fixture{Name: "unnested block comment", Content: pair.Start + " a " + pair.Start + " b " + pair.End + " value\n", Classes: []string{mixed}},
+				)
+			}
+		}
+		if quote != "" {
+			fixtures = append(fixtures,
+				Fixture{Name: "block comment in string", Content: "value = " + quote + pair.Start + quote + "\nvalue\n", Classes: []string{code, code}},
+				Fixture{Name: "quote in block comment", Content: pair.Start + "\n" + quote + " unmatched\n" + pair.End + "\nvalue\n", Classes: []string{comment, comment, comment, code}},
+			)
+		}
+	}
+
+	if capabilities.Shebang {
+		fixtures = append(fixtures,
+			Fixture{Name: "shebang", Content: "#!/usr/bin/env tool\nvalue\n", Classes: []string{comment, code}},
+		)
+	}
+	return fixtures
+}
+
+// fixtureQuote 选取用例中使用的字符串定界符：优先 "，其次 '，不声明这两者时不生成字符串用例。
+func fixtureQuote(delimiters []string) string {
+	for _, candidate := range []string{"\"", "'"} {
+		for _, delimiter := range delimiters {
+			if delimiter == candidate {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// RunConformance 对 analyzer 运行全部标准用例，每个用例是一个子测试。
+func RunConformance(t *testing.T, analyzer Analyzer) {
+	t.Helper()
+
+	var capabilities *gocloc.Capabilities
+	if describer, ok := analyzer.(languages.CapabilityDescriber); ok {
+		described := describer.Capabilities()
+		capabilities = &described
+	} else {
+		t.Logf("%s does not describe its syntax, only syntax-independent fixtures run", analyzer.Name())
+	}
+	for _, fixture := range Fixtures(capabilities) {
+		t.Run(fixture.Name, func(t *testing.T) {
+			if err := fixture.Check(analyzer); err != nil {
+				t.Errorf("%s: %v\ncontent: %q", analyzer.Name(), err, fixture.Content)
+			}
+		})
+	}
+}
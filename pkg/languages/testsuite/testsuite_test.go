@@ -0,0 +1,109 @@
+package testsuite
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zhizhixiongxuwei/gocloc/internal/languages"
+	"github.com/zhizhixiongxuwei/gocloc/pkg/gocloc"
+)
+
+// TestBuiltinConformance 验证全部内置分析器（包括按后缀区分的变体）与自定义语言定义都通过一致性用例。
+func TestBuiltinConformance(t *testing.T) {
+	registry := languages.NewRegistryWithOptions(languages.Options{Annotate: true})
+	seen := map[Analyzer]bool{}
+	for _, language := range registry.Languages() {
+		for _, ext := range language.Extensions {
+			analyzer, ok := registry.AnalyzerForFile("file" + ext)
+			if !ok || seen[analyzer] {
+				continue
+			}
+			seen[analyzer] = true
+			t.Run(language.Name+ext, func(t *testing.T) {
+				RunConformance(t, analyzer)
+			})
+		}
+	}
+
+	t.Run("definition", func(t *testing.T) {
+		RunConformance(t, ForDefinition(gocloc.LanguageDefinition{
+			Name:             "Lua",
+			Extensions:       []string{".lua"},
+			LineComments:     []string{"--"},
+			BlockComments:    []gocloc.BlockCommentPair{{Start: "--[[", End: "]]"}},
+			StringDelimiters: []string{"\"", "'"},
+		}))
+	})
+}
+
+// lineCounter 把每个非空行都计为代码，用于验证 Check 能发现不符合约定的分析器。
+type lineCounter struct{}
+
+func (lineCounter) Name() string {
+	return "lines"
+}
+
+func (lineCounter) Analyze(reader io.Reader) (gocloc.LineMetrics, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return gocloc.LineMetrics{}, err
+	}
+	var metrics gocloc.LineMetrics
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if line == "" {
+			continue
+		}
+		metrics.Total++
+		if strings.TrimSpace(line) == "" {
+			metrics.Blank++
+		} else {
+			metrics.Code++
+		}
+	}
+	return metrics, nil
+}
+
+// TestFixtures 验证用例按声明的语法生成，且 Check 能报告不符合约定的分析器。
+func TestFixtures(t *testing.T) {
+	if fixtures := Fixtures(nil); len(fixtures) != 6 {
+		t.Fatalf("expected only syntax-independent fixtures, got %d", len(fixtures))
+	}
+	names := func(fixtures []Fixture) string {
+		parts := make([]string, 0, len(fixtures))
+		for _, fixture := range fixtures {
+			parts = append(parts, fixture.Name)
+		}
+		return strings.Join(parts, ",")
+	}
+	ruby := names(Fixtures(&gocloc.Capabilities{
+		LineComments:           []string{"#"},
+		BlockComments:          []gocloc.BlockCommentPair{{Start: "=begin", End: "=end"}},
+		StringDelimiters:       []string{"'"},
+		LineStartBlockComments: true,
+		Shebang:                true,
+	}))
+	for _, name := range []string{"line comment in string", "quote in block comment", "shebang"} {
+		if !strings.Contains(ruby, name) {
+			t.Fatalf("expected fixture %q in %s", name, ruby)
+		}
+	}
+	if strings.Contains(ruby, "inline block comment") || strings.Contains(ruby, "nested") {
+		t.Fatalf("unexpected inline block comment fixtures for line-start comments: %s", ruby)
+	}
+
+	failed := 0
+	for _, fixture := range Fixtures(&gocloc.Capabilities{LineComments: []string{"//"}}) {
+		if fixture.Check(lineCounter{}) != nil {
+			failed++
+		}
+	}
+	if failed == 0 {
+		t.Fatalf("expected comment fixtures to fail for an analyzer without comment support")
+	}
+	for _, fixture := range Fixtures(nil) {
+		if err := fixture.Check(lineCounter{}); err != nil && fixture.Name != "crlf" && fixture.Name != "bom" {
+			t.Fatalf("%s: %v", fixture.Name, err)
+		}
+	}
+}